@@ -0,0 +1,97 @@
+import { apiCall, TimeStamped } from './common';
+import { LazyResult, Weapon } from './stats';
+import { Report } from './report';
+import { parseDateTime } from '../util/text';
+
+export enum AnomalyMetric {
+    Accuracy = 1,
+    Headshots = 2,
+    KillDistance = 3
+}
+
+export enum AnomalyStatus {
+    Any = -1,
+    Open = 0,
+    Dismissed = 1,
+    Reported = 2
+}
+
+export const anomalyMetricString = (metric: AnomalyMetric): string => {
+    switch (metric) {
+        case AnomalyMetric.Accuracy:
+            return 'Accuracy';
+        case AnomalyMetric.Headshots:
+            return 'Headshot Ratio';
+        case AnomalyMetric.KillDistance:
+            return 'Kill Distance';
+    }
+};
+
+export const anomalyStatusString = (status: AnomalyStatus): string => {
+    switch (status) {
+        case AnomalyStatus.Open:
+            return 'Open';
+        case AnomalyStatus.Dismissed:
+            return 'Dismissed';
+        case AnomalyStatus.Reported:
+            return 'Reported';
+        case AnomalyStatus.Any:
+            return 'Any';
+    }
+};
+
+export interface Anomaly extends Weapon, TimeStamped {
+    anomaly_id: number;
+    match_id: string;
+    steam_id: string;
+    metric: AnomalyMetric;
+    value: number;
+    baseline: number;
+    score: number;
+    samples: number;
+    explanation: string;
+    status: AnomalyStatus;
+    report_id: number;
+    persona_name: string;
+    avatar_hash: string;
+    map_name: string;
+}
+
+export interface AnomalyQuery {
+    status?: AnomalyStatus;
+    steam_id?: string;
+    limit?: number;
+    offset?: number;
+}
+
+export const apiGetAnomalies = async (opts: AnomalyQuery) => {
+    const params = new URLSearchParams();
+    Object.entries(opts).forEach(([key, value]) => {
+        if (value !== undefined) {
+            params.set(key, `${value}`);
+        }
+    });
+    const anomalies = await apiCall<LazyResult<Anomaly>>(
+        `/api/anomalies?${params.toString()}`,
+        'GET'
+    );
+    if (anomalies.result) {
+        anomalies.result.data = anomalies.result.data.map((a) => {
+            a.created_on = parseDateTime(a.created_on as unknown as string);
+            a.updated_on = parseDateTime(a.updated_on as unknown as string);
+            return a;
+        });
+    }
+    return anomalies;
+};
+
+export const apiSetAnomalyStatus = async (
+    anomaly_id: number,
+    status: AnomalyStatus
+) =>
+    await apiCall<Anomaly>(`/api/anomalies/${anomaly_id}/status`, 'POST', {
+        status
+    });
+
+export const apiReportAnomaly = async (anomaly_id: number) =>
+    await apiCall<Report>(`/api/anomalies/${anomaly_id}/report`, 'POST');
@@ -5,6 +5,26 @@ export enum Team {
     BLU
 }
 
+export enum GameMode {
+    Unknown,
+    ControlPoint,
+    Payload,
+    PayloadRace,
+    KOTH,
+    CTF,
+    MvM,
+    Passtime,
+    Arena
+}
+
+// Competitive format of a rating, Any covers ratings across all formats
+export enum RatingFormat {
+    Any = -1,
+    Highlander,
+    Sixes,
+    Ultiduo
+}
+
 export enum PlayerClass {
     Spectator,
     Scout,
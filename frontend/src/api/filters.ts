@@ -2,12 +2,21 @@ import { apiCall } from './common';
 
 export const wordFilterSeparator = '---';
 
+export interface FilterNormalize {
+    confusables: boolean;
+    invisible: boolean;
+    leet: boolean;
+    repeats: boolean;
+    spaces: boolean;
+}
+
 export interface Filter {
     filter_id?: number;
     author_id?: bigint;
     pattern: RegExp | string;
     is_regex: boolean;
     is_enabled?: boolean;
+    normalize?: FilterNormalize;
     trigger_count?: number;
     created_on?: Date;
     updated_on?: Date;
@@ -9,3 +9,6 @@ export * from './auth';
 export * from './qp';
 export * from './demo';
 export * from './match';
+export * from './notes';
+export * from './tasks';
+export * from './anomaly';
@@ -1,7 +1,8 @@
-import { PlayerClass, Team } from './const';
+import { GameMode, PlayerClass, Team } from './const';
 import { TeamScores } from './stats';
 import { apiCall, DataCount, QueryFilter } from './common';
 import { parseDateTime } from '../util/text';
+import { readAccessToken } from './auth';
 
 export interface MatchHealer {
     match_medic_id: number;
@@ -59,6 +60,34 @@ export interface MatchPlayerKillstreak {
     duration: number;
 }
 
+export interface MatchPlayerClassKill {
+    player_class: PlayerClass;
+    kills: number;
+    deaths: number;
+    assists: number;
+}
+
+export interface MatchHealTarget {
+    steam_id: string;
+    healing: number;
+}
+
+export interface MatchPlayerObjectives {
+    ball_grabs: number;
+    ball_passes: number;
+    ball_catches: number;
+    ball_interceptions: number;
+    ball_steals: number;
+    ball_blocks: number;
+    ball_scores: number;
+    ball_score_assists: number;
+    robot_kills: number;
+    cart_points: number;
+    koth_cap_time: number;
+    waves_completed: number;
+    waves_failed: number;
+}
+
 export interface MatchPlayer {
     match_player_id: number;
     steam_id: string;
@@ -93,19 +122,53 @@ export interface MatchPlayer {
     classes: MatchPlayerClass[];
     killstreaks: MatchPlayerKillstreak[];
     weapons: MatchPlayerWeapon[];
+    class_kills: MatchPlayerClassKill[];
+    heal_spread: MatchHealTarget[];
+    objectives: MatchPlayerObjectives;
 }
 
 export interface PersonMessages {}
 
+export interface MatchRound {
+    round: number;
+    time_start: Date;
+    length: number;
+    winner: Team;
+    score_red: number;
+    score_blu: number;
+    kills_red: number;
+    kills_blu: number;
+    damage_red: number;
+    damage_blu: number;
+    ubers_red: number;
+    ubers_blu: number;
+    caps_red: number;
+    caps_blu: number;
+    cart_progress_red: number;
+    cart_progress_blu: number;
+}
+
+export interface MatchTimeline {
+    offset: number;
+    kills_red: number;
+    kills_blu: number;
+    damage_red: number;
+    damage_blu: number;
+    ubers_red: number;
+    ubers_blu: number;
+}
+
 export interface MatchResult {
     match_id: string;
     server_id: number;
     title: string;
     map_name: string;
+    game_mode: GameMode;
     team_scores: TeamScores;
     time_start: Date;
     time_end: Date;
     players: MatchPlayer[];
+    rounds: MatchRound[];
     chat: PersonMessages[];
 }
 
@@ -123,10 +186,49 @@ export const apiGetMatch = async (match_id: string) => {
             p.time_end = parseDateTime(p.time_end as unknown as string);
             return p;
         });
+        match.result.rounds = match.result.rounds.map((r) => {
+            r.time_start = parseDateTime(r.time_start as unknown as string);
+            return r;
+        });
     }
     return match;
 };
 
+export const apiGetMatchRounds = async (match_id: string) => {
+    const rounds = await apiCall<MatchRound[]>(
+        `/api/log/${match_id}/rounds`,
+        'GET'
+    );
+    if (rounds.result) {
+        rounds.result = rounds.result.map((r) => {
+            r.time_start = parseDateTime(r.time_start as unknown as string);
+            return r;
+        });
+    }
+    return rounds;
+};
+
+export const apiGetMatchTimeline = async (match_id: string) =>
+    await apiCall<MatchTimeline[]>(`/api/log/${match_id}/timeline`, 'GET');
+
+// The exports are not wrapped in an api response and are authenticated via header, so they must be
+// fetched manually.
+const fetchMatchExport = async (url: string) => {
+    const resp = await fetch(url, {
+        headers: { Authorization: `Bearer ${readAccessToken()}` }
+    });
+    if (!resp.ok) {
+        throw new Error('Failed to download match export');
+    }
+    return await resp.blob();
+};
+
+export const apiDownloadMatchLogsTF = async (match_id: string) =>
+    await fetchMatchExport(`/api/log/${match_id}/logstf`);
+
+export const apiDownloadMatchRawLog = async (match_id: string) =>
+    await fetchMatchExport(`/api/log/${match_id}/raw`);
+
 export interface MatchesQueryOpts extends QueryFilter<MatchSummary> {
     steam_id?: string;
     server_id?: number;
@@ -0,0 +1,73 @@
+import { apiCall } from './common';
+
+export enum NoteSeverity {
+    Info = 0,
+    Warning = 1,
+    Critical = 2
+}
+
+export const noteSeverityString = (severity: NoteSeverity) => {
+    switch (severity) {
+        case NoteSeverity.Warning:
+            return 'Warning';
+        case NoteSeverity.Critical:
+            return 'Critical';
+        default:
+            return 'Info';
+    }
+};
+
+export interface PersonNote {
+    person_note_id: number;
+    steam_id: string;
+    author_id: string;
+    author_name: string;
+    body_md: string;
+    severity: NoteSeverity;
+    deleted: boolean;
+    revisions: number;
+    created_on: Date;
+    updated_on: Date;
+}
+
+export interface PersonNoteRevision {
+    person_note_revision_id: number;
+    person_note_id: number;
+    editor_id: string;
+    editor_name: string;
+    body_md: string;
+    severity: NoteSeverity;
+    created_on: Date;
+}
+
+export const apiGetPersonNotes = async (steam_id: string) =>
+    await apiCall<PersonNote[]>(`/api/notes/${steam_id}`, 'GET');
+
+export const apiCreatePersonNote = async (
+    steam_id: string,
+    body_md: string,
+    severity: NoteSeverity
+) =>
+    await apiCall<PersonNote>(`/api/notes/${steam_id}`, 'POST', {
+        body_md,
+        severity
+    });
+
+export const apiEditPersonNote = async (
+    person_note_id: number,
+    body_md: string,
+    severity: NoteSeverity
+) =>
+    await apiCall<PersonNote>(`/api/note/${person_note_id}`, 'POST', {
+        body_md,
+        severity
+    });
+
+export const apiDeletePersonNote = async (person_note_id: number) =>
+    await apiCall(`/api/note/${person_note_id}`, 'DELETE');
+
+export const apiGetPersonNoteRevisions = async (person_note_id: number) =>
+    await apiCall<PersonNoteRevision[]>(
+        `/api/note/${person_note_id}/revisions`,
+        'GET'
+    );
@@ -6,6 +6,7 @@ import {
     TimeStamped
 } from './common';
 import { parseDateTime } from '../util/text';
+import { readAccessToken } from './auth';
 
 export const defaultAvatarHash = 'fef49e7fa7e1997310d705b2a6158ff8dc1cdfeb';
 
@@ -152,6 +153,10 @@ export interface PersonMessage {
     created_on: Date;
     auto_filter_flagged: boolean;
     avatar_hash: string;
+    // Full-text search relevance, only set when searching
+    rank?: number;
+    // Body with matched terms wrapped in <mark></mark>, only set when searching
+    headline?: string;
 }
 
 export const apiGetPersonConnections = async (steam_id: string) =>
@@ -195,10 +200,13 @@ export interface MessageQuery extends QueryFilter<PersonMessage> {
     server_id?: number;
     sent_after?: Date;
     sent_before?: Date;
+    // Opaque cursor from a previous page, offset is ignored when set
+    cursor?: string;
 }
 
 export interface pagedQueryResults<T> extends DataCount {
     messages: T[];
+    next_cursor?: string;
 }
 
 export const apiGetMessages = async (opts: MessageQuery) => {
@@ -238,3 +246,147 @@ export interface PersonConnectionQuery extends QueryFilter<PersonConnection> {
 export const apiGetConnections = async (opts: PersonConnectionQuery) => {
     return await apiCall<UserNotification[]>(`/api/connections`, 'POST', opts);
 };
+
+export enum DataExportStatus {
+    Pending = 0,
+    Running = 1,
+    Complete = 2,
+    Failed = 3
+}
+
+export interface DataExport {
+    data_export_id: number;
+    steam_id: string;
+    status: DataExportStatus;
+    error: string;
+    size: number;
+    created_on: Date;
+    updated_on: Date;
+}
+
+export const apiGetDataExports = async () =>
+    await apiCall<DataExport[]>(`/api/export`, 'GET');
+
+export const apiCreateDataExport = async () =>
+    await apiCall<DataExport>(`/api/export`, 'POST');
+
+// The download is authenticated via header, so it must be fetched manually rather than linked to directly.
+export const apiDownloadDataExport = async (data_export_id: number) => {
+    const resp = await fetch(`/api/export/${data_export_id}/download`, {
+        headers: { Authorization: `Bearer ${readAccessToken()}` }
+    });
+    if (!resp.ok) {
+        throw new Error('Failed to download export');
+    }
+    return await resp.blob();
+};
+
+export enum ErasureStatus {
+    Pending = 0,
+    Approved = 1,
+    Denied = 2,
+    Complete = 3
+}
+
+export interface PersonErasure {
+    erasure_id: number;
+    steam_id: string;
+    status: ErasureStatus;
+    reason: string;
+    reviewer_id: string;
+    review_note: string;
+    retain_until?: Date;
+    completed_on?: Date;
+    created_on: Date;
+    updated_on: Date;
+}
+
+export const apiGetErasures = async () =>
+    await apiCall<PersonErasure[]>(`/api/erasure`, 'GET');
+
+export const apiCreateErasure = async (reason: string) =>
+    await apiCall<PersonErasure>(`/api/erasure`, 'POST', { reason });
+
+export interface ErasureQuery {
+    steam_id?: string;
+    status?: ErasureStatus[];
+}
+
+export const apiQueryErasures = async (opts: ErasureQuery) =>
+    await apiCall<PersonErasure[]>(`/api/erasures`, 'POST', opts);
+
+export const apiReviewErasure = async (
+    erasure_id: number,
+    approve: boolean,
+    note: string
+) =>
+    await apiCall<PersonErasure>(
+        `/api/erasures/${erasure_id}/review`,
+        'POST',
+        { approve, note }
+    );
+
+export interface PersonName {
+    person_name_id: number;
+    steam_id: string;
+    persona_name: string;
+    first_seen: Date;
+    last_seen: Date;
+}
+
+export interface PersonNameSearchResult extends PersonName {
+    similarity: number;
+}
+
+export const apiGetPersonNames = async (steam_id: string) =>
+    await apiCall<PersonName[]>(`/api/names/${steam_id}`, 'GET');
+
+export const apiSearchPersonNames = async (name: string, limit = 100) =>
+    await apiCall<PersonNameSearchResult[]>(`/api/names/search`, 'POST', {
+        name,
+        limit
+    });
+
+export type TimelineEventType =
+    | 'connect'
+    | 'disconnect'
+    | 'chat'
+    | 'match'
+    | 'report_filed'
+    | 'report_received'
+    | 'ban'
+    | 'mute'
+    | 'unban'
+    | 'warning'
+    | 'name';
+
+export interface TimelineEvent {
+    event_type: TimelineEventType;
+    id: number;
+    server_id: number;
+    body: string;
+    reason: number;
+    ref: string;
+    created_on: Date;
+}
+
+export interface TimelineQueryFilter {
+    types?: TimelineEventType[];
+    server_id?: number;
+    after?: Date;
+    before?: Date;
+    asc?: boolean;
+    limit?: number;
+    cursor?: string;
+}
+
+export interface TimelineResults {
+    events: TimelineEvent[];
+    next_cursor?: string;
+}
+
+export const apiGetPersonTimeline = async (
+    steam_id: string,
+    filter: TimelineQueryFilter
+) =>
+    await apiCall<TimelineResults>(`/api/timeline/${steam_id}`, 'POST', filter);
@@ -57,6 +57,7 @@ export interface Server extends TimeStamped {
     players_max: number;
     is_enabled: boolean;
     colour: string;
+    tags: string[];
 }
 
 export interface Location {
@@ -84,6 +85,7 @@ export interface SaveServerOpts {
     lat: number;
     lon: number;
     is_enabled: boolean;
+    tags: string[];
 }
 
 export const apiCreateServer = async (opts: SaveServerOpts) =>
@@ -118,3 +120,51 @@ export interface SlimServer {
     longitude: number;
     distance: number;
 }
+
+export interface ServerHealth {
+    server_id: number;
+    name_short: string;
+    up: boolean;
+    since: Date;
+    last_seen: Date;
+    player_average: number;
+    map_changed_on: Date;
+    rcon_up: boolean;
+    last_rcon: Date;
+    // Uptime ratio (0-1) keyed by window: 24h, 7d, 30d
+    uptime: Record<string, number>;
+}
+
+export interface ServerDowntime {
+    server_downtime_id: number;
+    server_id: number;
+    reason: string;
+    started_on: Date;
+    ended_on: Date | null;
+}
+
+export interface UptimeDay {
+    day: Date;
+    uptime: number;
+    // Seconds of downtime
+    downtime: number;
+}
+
+export interface ServerUptimeHistory {
+    server_id: number;
+    sla: number;
+    days: UptimeDay[];
+    downtimes: ServerDowntime[];
+}
+
+export const apiGetServersHealth = async () =>
+    await apiCall<ServerHealth[]>(`/api/servers/health`, 'GET');
+
+export const apiGetServerHealthHistory = async (
+    server_id: number,
+    days: number = 30
+) =>
+    await apiCall<ServerUptimeHistory>(
+        `/api/servers/${server_id}/health?days=${days}`,
+        'GET'
+    );
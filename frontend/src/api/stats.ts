@@ -1,4 +1,6 @@
 import { apiCall, DataCount } from './common';
+import { PlayerClass, RatingFormat } from './const';
+import { parseDateTime } from '../util/text';
 
 export interface DatabaseStats {
     bans: number;
@@ -36,6 +38,88 @@ export const apiGetMapUsage = async () => {
     return await apiCall<MapUseDetail[]>(`/api/stats/map`, 'GET');
 };
 
+export interface MapClassStats {
+    player_class: PlayerClass;
+    playtime: number;
+    percent: number;
+    kills: number;
+    assists: number;
+    deaths: number;
+    damage: number;
+}
+
+export interface MapPlayerStats {
+    rank: number;
+    steam_id: string;
+    persona_name: string;
+    avatar_hash: string;
+    matches: number;
+    wins: number;
+    kills: number;
+    assists: number;
+    deaths: number;
+    damage: number;
+    healing: number;
+    playtime: number;
+}
+
+export interface MapTrendPoint {
+    bucket: Date;
+    matches: number;
+    red_wins: number;
+    blu_wins: number;
+    red_win_rate: number;
+    blu_win_rate: number;
+    avg_match_length: number;
+    rounds: number;
+    avg_round_length: number;
+    avg_caps_per_round: number;
+}
+
+export interface MapStats {
+    map_name: string;
+    matches: number;
+    playtime: number;
+    red_wins: number;
+    blu_wins: number;
+    draws: number;
+    red_win_rate: number;
+    blu_win_rate: number;
+    avg_match_length: number;
+    rounds: number;
+    avg_round_length: number;
+    avg_caps_per_round: number;
+    classes: MapClassStats[];
+    top_players: MapPlayerStats[];
+    trend: MapTrendPoint[];
+}
+
+export type MapTrendBucket = 'day' | 'week' | 'month';
+
+export const apiGetMapStats = async (
+    mapName: string,
+    bucket: MapTrendBucket = 'week',
+    limit?: number
+) => {
+    const params = new URLSearchParams({ bucket });
+    if (limit !== undefined) {
+        params.set('limit', `${limit}`);
+    }
+    const stats = await apiCall<MapStats>(
+        `/api/stats/map/${encodeURIComponent(mapName)}?${params.toString()}`,
+        'GET'
+    );
+    if (stats.result) {
+        stats.result.trend = stats.result.trend.map((point) => {
+            return {
+                ...point,
+                bucket: parseDateTime(point.bucket as unknown as string)
+            };
+        });
+    }
+    return stats;
+};
+
 export interface Weapon {
     weapon_id: number;
     key: string;
@@ -60,6 +144,9 @@ export interface WeaponsOverallResult extends Weapon, BaseWeaponStats {
     backstabs_pct: number;
     shots_pct: number;
     hits_pct: number;
+    distance_kills: number;
+    distance_mean: number;
+    distance_std_dev: number;
 }
 
 export const apiGetWeaponsOverall = async () => {
@@ -235,3 +322,150 @@ export const apiGetPlayerWeaponStats = async (weapon_id: number) => {
         'GET'
     );
 };
+
+export interface Rating {
+    rating: number;
+    deviation: number;
+    volatility: number;
+}
+
+export interface PlayerRating extends Rating {
+    steam_id: string;
+    game_type: RatingFormat;
+    player_class: PlayerClass;
+    matches: number;
+    wins: number;
+    losses: number;
+    draws: number;
+    updated_on: Date;
+}
+
+export interface PlayerRatingHistory extends Rating {
+    player_rating_history_id: number;
+    match_id: string;
+    steam_id: string;
+    game_type: RatingFormat;
+    player_class: PlayerClass;
+    change: number;
+    created_on: Date;
+}
+
+export interface RatingLeaderboardEntry extends PlayerRating {
+    rank: number;
+    persona_name: string;
+    avatar_hash: string;
+    score: number;
+}
+
+export interface RatingQuery {
+    game_type?: RatingFormat;
+    player_class?: PlayerClass;
+    limit?: number;
+    offset?: number;
+}
+
+const ratingQueryString = (opts: RatingQuery) => {
+    const params = new URLSearchParams();
+    Object.entries(opts).forEach(([key, value]) => {
+        if (value !== undefined) {
+            params.set(key, `${value}`);
+        }
+    });
+    return params.toString();
+};
+
+export const apiGetPlayerRatings = async (steam_id: string) => {
+    const ratings = await apiCall<PlayerRating[]>(
+        `/api/ratings/player/${steam_id}`,
+        'GET'
+    );
+    if (ratings.result) {
+        ratings.result = ratings.result.map((r) => {
+            r.updated_on = parseDateTime(r.updated_on as unknown as string);
+            return r;
+        });
+    }
+    return ratings;
+};
+
+export const apiGetPlayerRatingHistory = async (
+    steam_id: string,
+    opts: RatingQuery
+) => {
+    const history = await apiCall<PlayerRatingHistory[]>(
+        `/api/ratings/player/${steam_id}/history?${ratingQueryString(opts)}`,
+        'GET'
+    );
+    if (history.result) {
+        history.result = history.result.map((h) => {
+            h.created_on = parseDateTime(h.created_on as unknown as string);
+            return h;
+        });
+    }
+    return history;
+};
+
+export const apiGetRatingLeaderboard = async (opts: RatingQuery) => {
+    return await apiCall<LazyResult<RatingLeaderboardEntry>>(
+        `/api/ratings/leaderboard?${ratingQueryString(opts)}`,
+        'GET'
+    );
+};
+
+export type LeaderboardBoard = 'kills' | 'damage' | 'healing' | 'wins' | 'weapon';
+
+export type LeaderboardPeriod = 'daily' | 'weekly' | 'monthly' | 'season';
+
+export interface LeaderboardEntry {
+    rank: number;
+    previous_rank: number;
+    steam_id: string;
+    persona_name: string;
+    avatar_hash: string;
+    value: number;
+    matches: number;
+}
+
+export interface Leaderboard {
+    board: LeaderboardBoard;
+    period: LeaderboardPeriod;
+    start: Date;
+    end: Date;
+    scope: {
+        server_id: number;
+        region: string;
+    };
+    entries: LeaderboardEntry[];
+}
+
+export interface LeaderboardQuery {
+    board?: LeaderboardBoard;
+    period?: LeaderboardPeriod;
+    server_id?: number;
+    region?: string;
+    weapon_id?: number;
+    previous?: boolean;
+    limit?: number;
+}
+
+export const apiGetLeaderboard = async (opts: LeaderboardQuery) => {
+    const params = new URLSearchParams();
+    Object.entries(opts).forEach(([key, value]) => {
+        if (value !== undefined) {
+            params.set(key, `${value}`);
+        }
+    });
+    const leaderboard = await apiCall<Leaderboard>(
+        `/api/leaderboards?${params.toString()}`,
+        'GET'
+    );
+    if (leaderboard.result) {
+        leaderboard.result.start = parseDateTime(
+            leaderboard.result.start as unknown as string
+        );
+        leaderboard.result.end = parseDateTime(
+            leaderboard.result.end as unknown as string
+        );
+    }
+    return leaderboard;
+};
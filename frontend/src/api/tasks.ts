@@ -0,0 +1,79 @@
+import { apiCall } from './common';
+
+export enum ScheduledTaskType {
+    RCON = 0,
+    Say = 1,
+    CSay = 2
+}
+
+export interface ScheduledTaskOpts {
+    name: string;
+    cron_expression: string;
+    timezone: string;
+    task_type: ScheduledTaskType;
+    // Raw rcon command, or a message template for Say and CSay tasks, eg: {{ .Players }}
+    command: string;
+    // Server names or tags, eg: "us-1,tag:eu"
+    server_selector: string;
+    players_below: number | null;
+    players_at_least: number | null;
+    alert_on_failure: boolean;
+    is_enabled: boolean;
+}
+
+export interface ScheduledTask extends ScheduledTaskOpts {
+    scheduled_task_id: number;
+    last_run: Date | null;
+    next_run: Date | null;
+    created_on: Date;
+    updated_on: Date;
+}
+
+export interface ScheduledTaskServerResult {
+    server_id: number;
+    server_name: string;
+    skipped: boolean;
+    response: string;
+    error: string;
+}
+
+export interface ScheduledTaskRun {
+    scheduled_task_run_id: number;
+    scheduled_task_id: number;
+    success: boolean;
+    error: string;
+    results: ScheduledTaskServerResult[];
+    started_on: Date;
+    finished_on: Date;
+}
+
+export const apiGetScheduledTasks = async () =>
+    await apiCall<ScheduledTask[]>(`/api/tasks`, 'GET');
+
+export const apiCreateScheduledTask = async (opts: ScheduledTaskOpts) =>
+    await apiCall<ScheduledTask>(`/api/tasks`, 'POST', opts);
+
+export const apiEditScheduledTask = async (
+    scheduled_task_id: number,
+    opts: ScheduledTaskOpts
+) =>
+    await apiCall<ScheduledTask>(
+        `/api/tasks/${scheduled_task_id}`,
+        'POST',
+        opts
+    );
+
+export const apiDeleteScheduledTask = async (scheduled_task_id: number) =>
+    await apiCall(`/api/tasks/${scheduled_task_id}`, 'DELETE');
+
+export const apiRunScheduledTask = async (scheduled_task_id: number) =>
+    await apiCall<ScheduledTaskRun>(
+        `/api/tasks/${scheduled_task_id}/run`,
+        'POST'
+    );
+
+export const apiGetScheduledTaskRuns = async (scheduled_task_id: number) =>
+    await apiCall<ScheduledTaskRun[]>(
+        `/api/tasks/${scheduled_task_id}/runs`,
+        'GET'
+    );
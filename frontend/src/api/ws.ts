@@ -21,7 +21,13 @@ export enum MsgType {
     wsMsgTypeQPJoinLobbyRequest = 2004,
     wsMsgTypeQPJoinLobbyResponse = 2005,
     wsMsgTypeQPUserMessageRequest = 2006,
-    wsMsgTypeQPUserMessageResponse = 2007
+    wsMsgTypeQPUserMessageResponse = 2007,
+
+    // RCON Console
+    wsMsgTypeConsoleLog = 3000,
+    wsMsgTypeConsoleCommandRequest = 3001,
+    wsMsgTypeConsoleCommandResponse = 3002,
+    wsMsgTypeConsoleHistory = 3003
 }
 
 // All websocket messages must be wrapped in this payload container
@@ -34,3 +40,30 @@ export interface wsValue<T extends JsonObject> extends JsonObject {
 export const encode = <T>(msg_type: MsgType, payload: T, status?: boolean) => {
     return { msg_type, payload, status: status == undefined ? true : status };
 };
+
+export interface ConsoleLogEvent {
+    server_id: number;
+    event_type: number;
+    event: JsonObject;
+}
+
+export interface ConsoleCommandRequest {
+    command: string;
+}
+
+export interface ConsoleCommand {
+    console_command_id: number;
+    server_id: number;
+    steam_id: string;
+    persona_name: string;
+    command: string;
+    response: string;
+    // False when the user did not have permission to run the command
+    allowed: boolean;
+    error: string;
+    created_on: Date;
+}
+
+// Connect with the access token as the token query parameter
+export const consoleWebsocketURL = (server_id: number) =>
+    `/ws/console/${server_id}`;
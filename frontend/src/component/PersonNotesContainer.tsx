@@ -0,0 +1,252 @@
+import React, { JSX, useCallback, useEffect, useState } from 'react';
+import Stack from '@mui/material/Stack';
+import Typography from '@mui/material/Typography';
+import TextField from '@mui/material/TextField';
+import Button from '@mui/material/Button';
+import ButtonGroup from '@mui/material/ButtonGroup';
+import Chip from '@mui/material/Chip';
+import MenuItem from '@mui/material/MenuItem';
+import Select from '@mui/material/Select';
+import Paper from '@mui/material/Paper';
+import StickyNote2Icon from '@mui/icons-material/StickyNote2';
+import { ContainerWithHeader } from './ContainerWithHeader';
+import {
+    apiCreatePersonNote,
+    apiDeletePersonNote,
+    apiEditPersonNote,
+    apiGetPersonNoteRevisions,
+    apiGetPersonNotes,
+    NoteSeverity,
+    noteSeverityString,
+    PersonNote,
+    PersonNoteRevision
+} from '../api/notes';
+import { renderDateTime } from '../util/text';
+import { logErr } from '../util/errors';
+
+const severityColour = (severity: NoteSeverity) => {
+    switch (severity) {
+        case NoteSeverity.Critical:
+            return 'error';
+        case NoteSeverity.Warning:
+            return 'warning';
+        default:
+            return 'info';
+    }
+};
+
+interface NoteEditorProps {
+    initialBody?: string;
+    initialSeverity?: NoteSeverity;
+    submitLabel: string;
+    onSubmit: (body: string, severity: NoteSeverity) => void;
+    onCancel?: () => void;
+}
+
+const NoteEditor = ({
+    initialBody = '',
+    initialSeverity = NoteSeverity.Info,
+    submitLabel,
+    onSubmit,
+    onCancel
+}: NoteEditorProps): JSX.Element => {
+    const [body, setBody] = useState(initialBody);
+    const [severity, setSeverity] = useState<NoteSeverity>(initialSeverity);
+
+    return (
+        <Stack spacing={1}>
+            <TextField
+                multiline
+                minRows={2}
+                label={'Note'}
+                value={body}
+                onChange={(event) => setBody(event.target.value)}
+            />
+            <Stack direction={'row'} spacing={1}>
+                <Select<NoteSeverity>
+                    size={'small'}
+                    value={severity}
+                    onChange={(event) =>
+                        setSeverity(event.target.value as NoteSeverity)
+                    }
+                >
+                    {[
+                        NoteSeverity.Info,
+                        NoteSeverity.Warning,
+                        NoteSeverity.Critical
+                    ].map((value) => (
+                        <MenuItem key={value} value={value}>
+                            {noteSeverityString(value)}
+                        </MenuItem>
+                    ))}
+                </Select>
+                <ButtonGroup>
+                    <Button
+                        variant={'contained'}
+                        disabled={body.trim() == ''}
+                        onClick={() => {
+                            onSubmit(body, severity);
+                            setBody(initialBody);
+                        }}
+                    >
+                        {submitLabel}
+                    </Button>
+                    {onCancel && (
+                        <Button variant={'outlined'} onClick={onCancel}>
+                            Cancel
+                        </Button>
+                    )}
+                </ButtonGroup>
+            </Stack>
+        </Stack>
+    );
+};
+
+interface NoteItemProps {
+    note: PersonNote;
+    onChange: () => void;
+}
+
+const NoteItem = ({ note, onChange }: NoteItemProps): JSX.Element => {
+    const [editing, setEditing] = useState(false);
+    const [revisions, setRevisions] = useState<PersonNoteRevision[]>([]);
+
+    const onEdit = useCallback(
+        (body: string, severity: NoteSeverity) => {
+            apiEditPersonNote(note.person_note_id, body, severity)
+                .then(() => {
+                    setEditing(false);
+                    setRevisions([]);
+                    onChange();
+                })
+                .catch(logErr);
+        },
+        [note.person_note_id, onChange]
+    );
+
+    const onDelete = useCallback(() => {
+        apiDeletePersonNote(note.person_note_id).then(onChange).catch(logErr);
+    }, [note.person_note_id, onChange]);
+
+    const onToggleHistory = useCallback(() => {
+        if (revisions.length > 0) {
+            setRevisions([]);
+            return;
+        }
+        apiGetPersonNoteRevisions(note.person_note_id)
+            .then((resp) => {
+                setRevisions(resp.result ?? []);
+            })
+            .catch(logErr);
+    }, [note.person_note_id, revisions.length]);
+
+    return (
+        <Paper variant={'outlined'} sx={{ padding: 1 }}>
+            <Stack spacing={1}>
+                <Stack direction={'row'} spacing={1} alignItems={'center'}>
+                    <Chip
+                        size={'small'}
+                        color={severityColour(note.severity)}
+                        label={noteSeverityString(note.severity)}
+                    />
+                    <Typography variant={'body2'}>
+                        {note.author_name} &mdash;{' '}
+                        {renderDateTime(new Date(note.created_on))}
+                    </Typography>
+                </Stack>
+                {editing ? (
+                    <NoteEditor
+                        initialBody={note.body_md}
+                        initialSeverity={note.severity}
+                        submitLabel={'Save'}
+                        onSubmit={onEdit}
+                        onCancel={() => setEditing(false)}
+                    />
+                ) : (
+                    <Typography
+                        variant={'body1'}
+                        sx={{ whiteSpace: 'pre-wrap' }}
+                    >
+                        {note.body_md}
+                    </Typography>
+                )}
+                <ButtonGroup size={'small'} variant={'text'}>
+                    <Button onClick={() => setEditing(!editing)}>Edit</Button>
+                    <Button color={'error'} onClick={onDelete}>
+                        Delete
+                    </Button>
+                    {note.revisions > 1 && (
+                        <Button onClick={onToggleHistory}>
+                            History ({note.revisions})
+                        </Button>
+                    )}
+                </ButtonGroup>
+                {revisions.map((revision) => (
+                    <Typography
+                        key={revision.person_note_revision_id}
+                        variant={'body2'}
+                        color={'text.secondary'}
+                        sx={{ whiteSpace: 'pre-wrap' }}
+                    >
+                        {renderDateTime(new Date(revision.created_on))}{' '}
+                        {revision.editor_name} [
+                        {noteSeverityString(revision.severity)}]:{' '}
+                        {revision.body_md}
+                    </Typography>
+                ))}
+            </Stack>
+        </Paper>
+    );
+};
+
+interface PersonNotesContainerProps {
+    steam_id: string;
+}
+
+/**
+ * Private moderator notes for a player. Only render this for moderators, the api will reject anyone else.
+ */
+export const PersonNotesContainer = ({
+    steam_id
+}: PersonNotesContainerProps): JSX.Element => {
+    const [notes, setNotes] = useState<PersonNote[]>([]);
+
+    const loadNotes = useCallback(() => {
+        apiGetPersonNotes(steam_id)
+            .then((resp) => {
+                setNotes(resp.result ?? []);
+            })
+            .catch(logErr);
+    }, [steam_id]);
+
+    useEffect(() => {
+        loadNotes();
+    }, [loadNotes]);
+
+    const onCreate = useCallback(
+        (body: string, severity: NoteSeverity) => {
+            apiCreatePersonNote(steam_id, body, severity)
+                .then(loadNotes)
+                .catch(logErr);
+        },
+        [loadNotes, steam_id]
+    );
+
+    return (
+        <ContainerWithHeader
+            title={`Moderator Notes (${notes.length})`}
+            iconLeft={<StickyNote2Icon />}
+        >
+            <Stack spacing={1} padding={1}>
+                {notes.map((note) => (
+                    <NoteItem
+                        key={note.person_note_id}
+                        note={note}
+                        onChange={loadNotes}
+                    />
+                ))}
+                <NoteEditor submitLabel={'Add Note'} onSubmit={onCreate} />
+            </Stack>
+        </ContainerWithHeader>
+    );
+};
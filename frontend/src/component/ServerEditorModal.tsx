@@ -39,6 +39,7 @@ export const ServerEditorModal = ({
     const [reservedSlots, setReservedSlots] = useState<number>(0);
     const [playersMax, setPlayersMax] = useState<number>(24);
     const [isEnabled, setIsEnabled] = useState<boolean>(false);
+    const [tags, setTags] = useState<string>('');
 
     useEffect(() => {
         setServerId(server?.server_id ?? 0);
@@ -50,6 +51,7 @@ export const ServerEditorModal = ({
         setRcon(server?.rcon ?? '');
         setRegion(server?.region ?? '');
         setCountryCode(server?.cc ?? '');
+        setTags((server?.tags ?? []).join(', '));
 
         setReservedSlots(server?.reserved_slots ?? 0);
         setPlayersMax(server?.players_max ?? 24);
@@ -67,7 +69,6 @@ export const ServerEditorModal = ({
             !serverName ||
             !serverNameLong ||
             !address ||
-            !rcon ||
             !countryCode ||
             port <= 0 ||
             port > 65535
@@ -86,7 +87,11 @@ export const ServerEditorModal = ({
             server_name_short: serverName,
             region: region,
             reserved_slots: reservedSlots,
-            is_enabled: isEnabled
+            is_enabled: isEnabled,
+            tags: tags
+                .split(',')
+                .map((tag) => tag.trim())
+                .filter((tag) => tag != '')
         };
         if (serverId > 0) {
             apiSaveServer(serverId, opts)
@@ -135,6 +140,7 @@ export const ServerEditorModal = ({
         region,
         reservedSlots,
         isEnabled,
+        tags,
         serverId,
         sendFlash,
         onSuccess,
@@ -228,6 +234,9 @@ export const ServerEditorModal = ({
                         fullWidth
                         id={'rcon'}
                         label={'RCON Password'}
+                        helperText={
+                            'Leave empty for third-party servers which are only listed, their state is queried using A2S'
+                        }
                         value={rcon}
                         onChange={(evt: ChangeEvent<HTMLInputElement>) => {
                             setRcon(evt.target.value);
@@ -244,6 +253,17 @@ export const ServerEditorModal = ({
                         }}
                     />
 
+                    <TextField
+                        fullWidth
+                        id={'tags'}
+                        label={'Tags'}
+                        helperText={'Comma separated, eg: pub, eu, event'}
+                        value={tags}
+                        onChange={(evt: ChangeEvent<HTMLInputElement>) => {
+                            setTags(evt.target.value);
+                        }}
+                    />
+
                     <TextField
                         fullWidth
                         id={'cc'}
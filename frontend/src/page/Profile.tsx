@@ -3,6 +3,7 @@ import Grid from '@mui/material/Unstable_Grid2';
 import {
     apiGetPlayerWeaponsOverall,
     apiGetProfile,
+    PermissionLevel,
     PlayerProfile
 } from '../api';
 import { Nullable } from '../util/types';
@@ -27,6 +28,8 @@ import { PlayerClassStatsContainer } from '../component/PlayerClassStatsContaine
 import { PlayerStatsOverallContainer } from '../component/PlayerStatsOverallContainer';
 import InsightsIcon from '@mui/icons-material/Insights';
 import { WeaponsStatListContainer } from '../component/WeaponsStatListContainer';
+import { PersonNotesContainer } from '../component/PersonNotesContainer';
+import { useCurrentUserCtx } from '../contexts/CurrentUserCtx';
 
 export const Profile = () => {
     const [profile, setProfile] = React.useState<Nullable<PlayerProfile>>(null);
@@ -35,6 +38,7 @@ export const Profile = () => {
 
     const { steam_id } = useParams();
     const { sendFlash } = useUserFlashCtx();
+    const { currentUser } = useCurrentUserCtx();
 
     useEffect(() => {
         if (!steam_id) {
@@ -128,6 +132,13 @@ export const Profile = () => {
                 <Grid xs={6} md={2}>
                     <SteamIDList steam_id={profile.player.steam_id} />
                 </Grid>
+                {currentUser.permission_level >= PermissionLevel.Moderator && (
+                    <Grid xs={12}>
+                        <PersonNotesContainer
+                            steam_id={profile.player.steam_id}
+                        />
+                    </Grid>
+                )}
                 <Grid xs={12}>
                     <PlayerStatsOverallContainer
                         steam_id={profile.player.steam_id}
@@ -181,7 +192,7 @@ export const Profile = () => {
                 </Grid>
             </>
         );
-    }, [error, profile]);
+    }, [currentUser.permission_level, error, profile]);
 
     return (
         <Grid container spacing={2}>
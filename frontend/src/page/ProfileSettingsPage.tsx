@@ -1,4 +1,4 @@
-import React, { JSX } from 'react';
+import React, { JSX, useCallback, useEffect, useState } from 'react';
 import Typography from '@mui/material/Typography';
 import Button from '@mui/material/Button';
 import Link from '@mui/material/Link';
@@ -10,43 +10,199 @@ import ChatBubbleIcon from '@mui/icons-material/ChatBubble';
 import LinkIcon from '@mui/icons-material/Link';
 import AddLinkIcon from '@mui/icons-material/AddLink';
 import Tooltip from '@mui/material/Tooltip';
+import DownloadIcon from '@mui/icons-material/Download';
+import DeleteForeverIcon from '@mui/icons-material/DeleteForever';
+import TextField from '@mui/material/TextField';
+import {
+    apiCreateDataExport,
+    apiCreateErasure,
+    apiDownloadDataExport,
+    apiGetDataExports,
+    DataExport,
+    DataExportStatus
+} from '../api/profile';
+import { humanFileSize, renderDateTime } from '../util/text';
+import { logErr } from '../util/errors';
+
+const exportStatusText = (status: DataExportStatus) => {
+    switch (status) {
+        case DataExportStatus.Pending:
+            return 'Queued';
+        case DataExportStatus.Running:
+            return 'Processing';
+        case DataExportStatus.Complete:
+            return 'Ready';
+        default:
+            return 'Failed';
+    }
+};
+
+const DataExportSettings = (): JSX.Element => {
+    const [exports, setExports] = useState<DataExport[]>([]);
+    const [message, setMessage] = useState('');
+
+    const loadExports = useCallback(() => {
+        apiGetDataExports()
+            .then((resp) => {
+                setExports(resp.result ?? []);
+            })
+            .catch(logErr);
+    }, []);
+
+    useEffect(() => {
+        loadExports();
+    }, [loadExports]);
+
+    const onRequest = useCallback(() => {
+        apiCreateDataExport()
+            .then((resp) => {
+                setMessage(resp.message ?? '');
+                loadExports();
+            })
+            .catch(logErr);
+    }, [loadExports]);
+
+    const onDownload = useCallback((dataExport: DataExport) => {
+        apiDownloadDataExport(dataExport.data_export_id)
+            .then((blob) => {
+                const url = URL.createObjectURL(blob);
+                const link = document.createElement('a');
+                link.href = url;
+                link.download = `gbans-export-${dataExport.steam_id}.zip`;
+                link.click();
+                URL.revokeObjectURL(url);
+            })
+            .catch(logErr);
+    }, []);
+
+    return (
+        <ContainerWithHeader title={'Data Export'} iconLeft={<DownloadIcon />}>
+            <Stack padding={2} paddingTop={0} spacing={2}>
+                <Typography variant={'body1'}>
+                    Request an archive of all the data we hold about you. You
+                    will be notified once it is ready. Archives are available
+                    for 7 days and can be requested once per day.
+                </Typography>
+                <Button variant={'contained'} onClick={onRequest}>
+                    Request Export
+                </Button>
+                {message != '' && (
+                    <Typography variant={'body2'}>{message}</Typography>
+                )}
+                {exports.map((dataExport) => (
+                    <Stack
+                        direction={'row'}
+                        spacing={2}
+                        key={dataExport.data_export_id}
+                        alignItems={'center'}
+                    >
+                        <Typography variant={'body2'}>
+                            {renderDateTime(new Date(dataExport.created_on))}
+                        </Typography>
+                        <Typography variant={'body2'}>
+                            {exportStatusText(dataExport.status)}
+                        </Typography>
+                        {dataExport.status == DataExportStatus.Complete && (
+                            <Button
+                                size={'small'}
+                                startIcon={<DownloadIcon />}
+                                onClick={() => onDownload(dataExport)}
+                            >
+                                {humanFileSize(dataExport.size)}
+                            </Button>
+                        )}
+                    </Stack>
+                ))}
+            </Stack>
+        </ContainerWithHeader>
+    );
+};
+
+const ErasureSettings = (): JSX.Element => {
+    const [reason, setReason] = useState('');
+    const [message, setMessage] = useState('');
+
+    const onRequest = useCallback(() => {
+        apiCreateErasure(reason)
+            .then((resp) => {
+                setMessage(resp.message ?? '');
+            })
+            .catch(logErr);
+    }, [reason]);
 
-export const ProfileSettingsPage = (): JSX.Element => {
-    const { currentUser } = useCurrentUserCtx();
-    const loginUrl = discordLoginURL();
     return (
         <ContainerWithHeader
-            title={'Discord Settings'}
-            iconLeft={<ChatBubbleIcon />}
+            title={'Erase My Data'}
+            iconLeft={<DeleteForeverIcon />}
         >
             <Stack padding={2} paddingTop={0} spacing={2}>
-                <Tooltip title={`id: ${currentUser.steam_id}`}>
-                    <Button
-                        component={Link}
-                        href={loginUrl}
-                        variant={'contained'}
-                        disabled={currentUser.discord_id != ''}
-                        startIcon={
-                            currentUser.discord_id == '' ? (
-                                <AddLinkIcon />
-                            ) : (
-                                <LinkIcon />
-                            )
-                        }
-                    >
-                        {currentUser.discord_id != ''
-                            ? 'Already Linked!'
-                            : 'Link Discord'}
-                    </Button>
-                </Tooltip>
                 <Typography variant={'body1'}>
-                    By linking your discord account, you will unlock certain
-                    functionality available on the discord platform. This
-                    currently includes functionality related to reporting
-                    primarily, but will be extended to include other
-                    functionality in the future.
+                    Request that your personal data be erased. Once approved by
+                    a moderator your name, chat history and connection history
+                    are anonymized. Ban records are kept, and evidence relating
+                    to them is retained for a limited time as required.
                 </Typography>
+                <TextField
+                    label={'Reason (optional)'}
+                    value={reason}
+                    onChange={(event) => setReason(event.target.value)}
+                    multiline
+                />
+                <Button
+                    variant={'contained'}
+                    color={'error'}
+                    onClick={onRequest}
+                >
+                    Request Erasure
+                </Button>
+                {message != '' && (
+                    <Typography variant={'body2'}>{message}</Typography>
+                )}
             </Stack>
         </ContainerWithHeader>
     );
 };
+
+export const ProfileSettingsPage = (): JSX.Element => {
+    const { currentUser } = useCurrentUserCtx();
+    const loginUrl = discordLoginURL();
+    return (
+        <Stack spacing={2}>
+            <ContainerWithHeader
+                title={'Discord Settings'}
+                iconLeft={<ChatBubbleIcon />}
+            >
+                <Stack padding={2} paddingTop={0} spacing={2}>
+                    <Tooltip title={`id: ${currentUser.steam_id}`}>
+                        <Button
+                            component={Link}
+                            href={loginUrl}
+                            variant={'contained'}
+                            disabled={currentUser.discord_id != ''}
+                            startIcon={
+                                currentUser.discord_id == '' ? (
+                                    <AddLinkIcon />
+                                ) : (
+                                    <LinkIcon />
+                                )
+                            }
+                        >
+                            {currentUser.discord_id != ''
+                                ? 'Already Linked!'
+                                : 'Link Discord'}
+                        </Button>
+                    </Tooltip>
+                    <Typography variant={'body1'}>
+                        By linking your discord account, you will unlock certain
+                        functionality available on the discord platform. This
+                        currently includes functionality related to reporting
+                        primarily, but will be extended to include other
+                        functionality in the future.
+                    </Typography>
+                </Stack>
+            </ContainerWithHeader>
+            <DataExportSettings />
+            <ErasureSettings />
+        </Stack>
+    );
+};
@@ -29,6 +29,7 @@ import Avatar from '@mui/material/Avatar';
 import ListItemAvatar from '@mui/material/ListItemAvatar';
 import ListItemText from '@mui/material/ListItemText';
 import { useCurrentUserCtx } from '../contexts/CurrentUserCtx';
+import { PersonNotesContainer } from '../component/PersonNotesContainer';
 import { logErr } from '../util/errors';
 import { useUserFlashCtx } from '../contexts/UserFlashCtx';
 import { Heading } from '../component/Heading';
@@ -328,6 +329,13 @@ export const ReportViewPage = (): JSX.Element => {
                             </Paper>
                         </>
                     )}
+                    {currentUser.permission_level >=
+                        PermissionLevel.Moderator &&
+                        report?.subject.steam_id && (
+                            <PersonNotesContainer
+                                steam_id={report.subject.steam_id}
+                            />
+                        )}
                     {report?.subject.steam_id ? (
                         <BanSteamModal
                             reportId={report.report.report_id}
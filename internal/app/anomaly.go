@@ -0,0 +1,298 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leighmacdonald/gbans/internal/discord"
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// weaponBaseline is the population distribution of a weapons stats across every match.
+type weaponBaseline struct {
+	store.Weapon
+	shots          int64
+	hits           int64
+	kills          int64
+	headshots      int64
+	distanceKills  int64
+	distanceMean   float64
+	distanceStdDev float64
+}
+
+func newWeaponBaselines(overall []store.WeaponsOverallResult) map[int]weaponBaseline {
+	baselines := map[int]weaponBaseline{}
+
+	for _, result := range overall {
+		baselines[result.WeaponID] = weaponBaseline{
+			Weapon:         result.Weapon,
+			shots:          result.Shots,
+			hits:           result.Hits,
+			kills:          result.Kills,
+			headshots:      result.Headshots,
+			distanceKills:  result.DistanceKills,
+			distanceMean:   result.DistanceMean,
+			distanceStdDev: result.DistanceStdDev,
+		}
+	}
+
+	return baselines
+}
+
+// baselineCache holds the weapon baselines so the full match_weapon table is not aggregated for every match.
+type baselineCache struct {
+	*sync.Mutex
+	baselines map[int]weaponBaseline
+	updatedOn time.Time
+}
+
+func newBaselineCache() *baselineCache {
+	return &baselineCache{
+		Mutex:     &sync.Mutex{},
+		baselines: map[int]weaponBaseline{},
+	}
+}
+
+func (c *baselineCache) get(ctx context.Context, database *store.Store, maxAge time.Duration) (map[int]weaponBaseline, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if time.Since(c.updatedOn) < maxAge {
+		return c.baselines, nil
+	}
+
+	overall, errOverall := database.WeaponsOverall(ctx)
+	if errOverall != nil {
+		return nil, errors.Wrap(errOverall, "Failed to load weapon baselines")
+	}
+
+	c.baselines = newWeaponBaselines(overall)
+	c.updatedOn = time.Now()
+
+	return c.baselines, nil
+}
+
+// proportionScore returns how many standard deviations the observed successes are above the expected successes
+// of the population rate, treating each trial as a binomial sample.
+func proportionScore(successes int, trials int, rate float64) float64 {
+	if trials <= 0 || rate <= 0 || rate >= 1 {
+		return 0
+	}
+
+	expected := float64(trials) * rate
+
+	return (float64(successes) - expected) / math.Sqrt(expected*(1-rate))
+}
+
+// meanScore returns how many standard errors the observed mean is above the population mean.
+func meanScore(mean float64, samples int, populationMean float64, populationStdDev float64) float64 {
+	if samples <= 0 || populationStdDev <= 0 {
+		return 0
+	}
+
+	return (mean - populationMean) / (populationStdDev / math.Sqrt(float64(samples)))
+}
+
+// detectAnomalies compares the weapon stats of each player in the match against the population baselines, returning
+// any which are at least conf.MinScore standard deviations above normal. Only unusually high values are flagged,
+// low accuracy or short kill distances are not suspicious.
+func detectAnomalies(conf anomalyConfig, baselines map[int]weaponBaseline, match *store.MatchResult) []store.Anomaly {
+	var anomalies []store.Anomaly
+
+	for _, player := range match.Players {
+		if !player.SteamID.Valid() {
+			continue
+		}
+
+		for _, weapon := range player.Weapons {
+			baseline, found := baselines[weapon.WeaponID]
+			if !found {
+				continue
+			}
+
+			newAnomaly := func(metric store.AnomalyMetric, value float64, baselineValue float64, score float64,
+				samples int, explanation string,
+			) {
+				anomalies = append(anomalies, store.Anomaly{
+					MatchID:     match.MatchID,
+					SteamID:     player.SteamID,
+					Weapon:      weapon.Weapon,
+					Metric:      metric,
+					Value:       value,
+					Baseline:    baselineValue,
+					Score:       score,
+					Samples:     samples,
+					Explanation: explanation + fmt.Sprintf(", %.1f standard deviations above normal", score),
+					Status:      store.AnomalyOpen,
+					MapName:     match.MapName,
+					CreatedOn:   match.TimeEnd,
+					UpdatedOn:   match.TimeEnd,
+				})
+			}
+
+			if weapon.Shots >= conf.MinShots && baseline.shots >= int64(conf.MinBaselineSamples) {
+				rate := float64(baseline.hits) / float64(baseline.shots)
+				if score := proportionScore(weapon.Hits, weapon.Shots, rate); score >= conf.MinScore {
+					accuracy := float64(weapon.Hits) / float64(weapon.Shots) * 100
+					newAnomaly(store.AnomalyAccuracy, accuracy, rate*100, score, weapon.Shots,
+						fmt.Sprintf("%s accuracy of %.1f%% (%d of %d shots hit) against a population average of %.1f%%",
+							weapon.Name, accuracy, weapon.Hits, weapon.Shots, rate*100))
+				}
+			}
+
+			if weapon.Kills >= conf.MinKills && baseline.kills >= int64(conf.MinBaselineSamples) {
+				rate := float64(baseline.headshots) / float64(baseline.kills)
+				if score := proportionScore(weapon.Headshots, weapon.Kills, rate); score >= conf.MinScore {
+					ratio := float64(weapon.Headshots) / float64(weapon.Kills) * 100
+					newAnomaly(store.AnomalyHeadshots, ratio, rate*100, score, weapon.Kills,
+						fmt.Sprintf("%s headshot ratio of %.1f%% (%d of %d kills) against a population average of %.1f%%",
+							weapon.Name, ratio, weapon.Headshots, weapon.Kills, rate*100))
+				}
+			}
+
+			if weapon.DistanceKills >= conf.MinKills && baseline.distanceKills >= int64(conf.MinBaselineSamples) {
+				distance := weapon.KillDistance()
+				if score := meanScore(distance, weapon.DistanceKills, baseline.distanceMean, baseline.distanceStdDev); score >= conf.MinScore {
+					newAnomaly(store.AnomalyKillDistance, distance, baseline.distanceMean, score, weapon.DistanceKills,
+						fmt.Sprintf("%s average kill distance of %.0f units over %d kills against a population average of %.0f units",
+							weapon.Name, distance, weapon.DistanceKills, baseline.distanceMean))
+				}
+			}
+		}
+	}
+
+	return anomalies
+}
+
+// checkMatchAnomalies adds any anomalies found in a newly completed match to the moderation queue, optionally
+// reporting the players responsible.
+func (app *App) checkMatchAnomalies(ctx context.Context, match *store.MatchResult) error {
+	baselines, errBaselines := app.baselines.get(ctx, app.db, app.conf.Anomaly.BaselineRefresh.Duration())
+	if errBaselines != nil {
+		return errBaselines
+	}
+
+	playerAnomalies := map[steamid.SID64][]store.Anomaly{}
+
+	for _, anomalyVal := range detectAnomalies(app.conf.Anomaly, baselines, match) {
+		anomaly := anomalyVal
+		if errSave := app.db.SaveAnomaly(ctx, &anomaly); errSave != nil {
+			if errors.Is(errSave, store.ErrDuplicate) {
+				continue
+			}
+
+			return errors.Wrap(errSave, "Failed to save anomaly")
+		}
+
+		playerAnomalies[anomaly.SteamID] = append(playerAnomalies[anomaly.SteamID], anomaly)
+	}
+
+	if !app.conf.Anomaly.AutoReport {
+		return nil
+	}
+
+	for _, anomalies := range playerAnomalies {
+		if _, errReport := app.reportAnomalies(ctx, anomalies); errReport != nil {
+			return errReport
+		}
+	}
+
+	return nil
+}
+
+// anomalyEvidence formats the anomalies as markdown to be attached to a report.
+func (app *App) anomalyEvidence(anomalies []store.Anomaly) string {
+	sorted := make([]store.Anomaly, len(anomalies))
+	copy(sorted, anomalies)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Score > sorted[j].Score
+	})
+
+	var evidence strings.Builder
+
+	evidence.WriteString("Statistical anomalies were detected in the players weapon stats.\n\n")
+
+	for _, anomaly := range sorted {
+		evidence.WriteString(fmt.Sprintf("- %s. [Match](%s)\n", anomaly.Explanation,
+			app.ExtURLRaw("/log/%s", anomaly.MatchID.String())))
+	}
+
+	return evidence.String()
+}
+
+// reportAnomalies files a cheating report against the player with the anomalies as evidence. When the system
+// already has an open report against the player the evidence is added to it instead of opening a new one. The
+// anomalies are marked as reported and linked to the report.
+func (app *App) reportAnomalies(ctx context.Context, anomalies []store.Anomaly) (store.Report, error) {
+	var report store.Report
+
+	if len(anomalies) == 0 {
+		return report, errors.New("No anomalies to report")
+	}
+
+	var (
+		targetID = anomalies[0].SteamID
+		evidence = app.anomalyEvidence(anomalies)
+	)
+
+	errExisting := app.db.GetReportBySteamID(ctx, app.conf.General.Owner, targetID, &report)
+	if errExisting != nil && !errors.Is(errExisting, store.ErrNoResult) {
+		return report, errors.Wrap(errExisting, "Failed to query existing reports")
+	}
+
+	if report.ReportID > 0 {
+		message := store.NewUserMessage(report.ReportID, app.conf.General.Owner, evidence)
+		if errMessage := app.db.SaveReportMessage(ctx, &message); errMessage != nil {
+			return report, errors.Wrap(errMessage, "Failed to add evidence to report")
+		}
+	} else {
+		report = store.NewReport()
+		report.SourceID = app.conf.General.Owner
+		report.TargetID = targetID
+		report.ReportStatus = store.Opened
+		report.Reason = store.Cheating
+		report.Description = evidence
+
+		if errSave := app.db.SaveReport(ctx, &report); errSave != nil {
+			return report, errors.Wrap(errSave, "Failed to save report")
+		}
+	}
+
+	for _, anomalyVal := range anomalies {
+		anomaly := anomalyVal
+		anomaly.Status = store.AnomalyReported
+		anomaly.ReportID = report.ReportID
+
+		if errSave := app.db.SaveAnomaly(ctx, &anomaly); errSave != nil {
+			return report, errors.Wrap(errSave, "Failed to update anomaly")
+		}
+	}
+
+	msgEmbed := discord.
+		NewEmbed("Anomalies Reported").
+		SetDescription(evidence).
+		SetColor(app.bot.Colour.Warn).
+		SetURL(app.ExtURL(report))
+
+	msgEmbed.AddField("Reason", report.Reason.String())
+	discord.AddFieldsSteamID(msgEmbed, targetID)
+
+	app.bot.SendPayload(discord.Payload{
+		ChannelID: app.conf.Discord.LogChannelID,
+		Embed:     msgEmbed.Truncate().MessageEmbed,
+	})
+
+	app.log.Info("Reported player anomalies", zap.Int64("report_id", report.ReportID),
+		zap.String("steam_id", targetID.String()), zap.Int("anomalies", len(anomalies)))
+
+	return report, nil
+}
@@ -0,0 +1,108 @@
+package app // nolint:testpackage
+
+import (
+	"testing"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/leighmacdonald/gbans/pkg/logparse"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+	"github.com/stretchr/testify/require"
+)
+
+func testAnomalyConfig() anomalyConfig {
+	return anomalyConfig{
+		Enabled:            true,
+		MinScore:           4,
+		MinShots:           100,
+		MinKills:           10,
+		MinBaselineSamples: 1000,
+	}
+}
+
+func testAnomalyBaselines() map[int]weaponBaseline {
+	return newWeaponBaselines([]store.WeaponsOverallResult{
+		{
+			Weapon:         store.Weapon{WeaponID: 1, Key: logparse.SniperRifle, Name: "Sniper Rifle"},
+			Kills:          10000,
+			Headshots:      4000,
+			Shots:          50000,
+			Hits:           15000,
+			DistanceKills:  10000,
+			DistanceMean:   1200,
+			DistanceStdDev: 600,
+		},
+	})
+}
+
+func testAnomalyMatch(weapon store.MatchPlayerWeapon) *store.MatchResult {
+	player := &store.MatchPlayer{Weapons: []store.MatchPlayerWeapon{weapon}}
+	player.SteamID = steamid.New(76561198000000001)
+
+	return &store.MatchResult{
+		MatchID: uuid.Must(uuid.NewV4()),
+		MapName: "cp_process_final",
+		Players: []*store.MatchPlayer{player},
+	}
+}
+
+func TestDetectAnomalies(t *testing.T) {
+	var (
+		conf      = testAnomalyConfig()
+		baselines = testAnomalyBaselines()
+		weapon    = store.Weapon{WeaponID: 1, Key: logparse.SniperRifle, Name: "Sniper Rifle"}
+	)
+
+	// Slightly above average stats should not be flagged
+	normal := store.MatchPlayerWeapon{Weapon: weapon, Shots: 120, Hits: 40, Kills: 20, Headshots: 9,
+		DistanceKills: 20, DistanceSum: 20 * 1300}
+	require.Empty(t, detectAnomalies(conf, baselines, testAnomalyMatch(normal)))
+
+	suspicious := store.MatchPlayerWeapon{Weapon: weapon, Shots: 120, Hits: 100, Kills: 30, Headshots: 29,
+		DistanceKills: 30, DistanceSum: 30 * 2500}
+	anomalies := detectAnomalies(conf, baselines, testAnomalyMatch(suspicious))
+	require.Len(t, anomalies, 3)
+
+	metrics := map[store.AnomalyMetric]store.Anomaly{}
+	for _, anomaly := range anomalies {
+		require.GreaterOrEqual(t, anomaly.Score, conf.MinScore)
+		require.Equal(t, store.AnomalyOpen, anomaly.Status)
+		require.Equal(t, weapon.WeaponID, anomaly.WeaponID)
+		require.NotEmpty(t, anomaly.Explanation)
+
+		metrics[anomaly.Metric] = anomaly
+	}
+
+	require.InDelta(t, 83.33, metrics[store.AnomalyAccuracy].Value, 0.01)
+	require.InDelta(t, 30, metrics[store.AnomalyAccuracy].Baseline, 0.01)
+	require.Equal(t, 120, metrics[store.AnomalyAccuracy].Samples)
+	require.InDelta(t, 96.67, metrics[store.AnomalyHeadshots].Value, 0.01)
+	require.InDelta(t, 2500, metrics[store.AnomalyKillDistance].Value, 0.01)
+	require.Equal(t, 30, metrics[store.AnomalyKillDistance].Samples)
+}
+
+func TestDetectAnomaliesSamples(t *testing.T) {
+	var (
+		conf   = testAnomalyConfig()
+		weapon = store.Weapon{WeaponID: 1, Key: logparse.SniperRifle, Name: "Sniper Rifle"}
+		// Perfect stats over too few samples can be luck
+		lucky = store.MatchPlayerWeapon{Weapon: weapon, Shots: 20, Hits: 20, Kills: 5, Headshots: 5,
+			DistanceKills: 5, DistanceSum: 5 * 4000}
+	)
+
+	require.Empty(t, detectAnomalies(conf, testAnomalyBaselines(), testAnomalyMatch(lucky)))
+
+	// Weapons without enough population data have no reliable baseline
+	conf.MinBaselineSamples = 100000
+	suspicious := store.MatchPlayerWeapon{Weapon: weapon, Shots: 120, Hits: 100, Kills: 30, Headshots: 29,
+		DistanceKills: 30, DistanceSum: 30 * 2500}
+	require.Empty(t, detectAnomalies(conf, testAnomalyBaselines(), testAnomalyMatch(suspicious)))
+}
+
+func TestProportionScore(t *testing.T) {
+	require.InDelta(t, 0, proportionScore(30, 100, 0.3), 0.0001)
+	require.InDelta(t, 10/4.582576, proportionScore(40, 100, 0.3), 0.0001)
+	require.Less(t, proportionScore(20, 100, 0.3), 0.0)
+	require.Equal(t, 0.0, proportionScore(10, 10, 0))
+	require.Equal(t, 0.0, proportionScore(10, 0, 0.5))
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/leighmacdonald/steamid/v3/steamid"
 	"github.com/leighmacdonald/steamweb/v2"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 )
 
@@ -46,6 +47,10 @@ type App struct {
 	mc                   *metricCollector
 	logListener          *logparse.UDPLogListener
 	matchUUIDMap         fp.MutexMap[int, uuid.UUID]
+	dataExportTrigger    chan bool
+	nameHistory          *nameHistoryCache
+	health               *healthMonitor
+	baselines            *baselineCache
 }
 
 func New(conf *Config, database *store.Store, bot *discord.Bot, logger *zap.Logger) App {
@@ -65,6 +70,10 @@ func New(conf *Config, database *store.Store, bot *discord.Bot, logger *zap.Logg
 		wordFilters:          newWordFilters(),
 		mc:                   newMetricCollector(),
 		state:                newServerStateCollector(logger),
+		dataExportTrigger:    make(chan bool, 1),
+		nameHistory:          newNameHistoryCache(),
+		health:               newHealthMonitor(conf.Health),
+		baselines:            newBaselineCache(),
 	}
 
 	if conf.Discord.Enabled {
@@ -274,136 +283,35 @@ func (app *App) warnWorker(ctx context.Context) { //nolint:maintidx
 					}
 				}
 			case newWarn := <-warningChan:
-				if !newWarn.userMessage.SteamID.Valid() {
-					continue
-				}
-
-				newWarn.MatchedFilter.TriggerCount++
-				if errSave := app.db.SaveFilter(ctx, newWarn.MatchedFilter); errSave != nil {
-					log.Error("Failed to update filter trigger count", zap.Error(errSave))
-				}
-
-				var person store.Person
-				if personErr := app.PersonBySID(ctx, newWarn.userMessage.SteamID, &person); personErr != nil {
-					log.Error("Failed to get person for warning", zap.Error(personErr))
-
-					continue
-				}
-
-				if !newWarn.MatchedFilter.IsEnabled {
-					continue
-				}
-
-				title := fmt.Sprintf("Language Warning (#%d/%d)", len(warnings[newWarn.userMessage.SteamID])+1, app.conf.General.WarningLimit)
-				if app.conf.Filter.Dry {
-					title = "[DRYRUN] " + title
-				}
-
-				msgEmbed := discord.
-					NewEmbed(title).
-					SetDescription(newWarn.userWarning.Message).
-					SetColor(app.bot.Colour.Warn).
-					AddField("Filter ID", fmt.Sprintf("%d", newWarn.MatchedFilter.FilterID)).
-					AddField("Matched", newWarn.Matched).
-					AddField("Server", newWarn.userMessage.ServerName).InlineAllFields().
-					AddField("Pattern", newWarn.MatchedFilter.Pattern)
-
-				app.addAuthor(ctx, msgEmbed, newWarn.userMessage.SteamID)
-
-				discord.AddFieldsSteamID(msgEmbed, newWarn.userMessage.SteamID)
-
-				if !newWarn.MatchedFilter.IsEnabled {
-					continue
-				}
-
-				if !app.conf.Filter.Dry {
-					_, found := warnings[newWarn.userMessage.SteamID]
-					if !found {
-						warnings[newWarn.userMessage.SteamID] = []userWarning{}
-					}
-
-					warnings[newWarn.userMessage.SteamID] = append(warnings[newWarn.userMessage.SteamID], newWarn.userWarning)
-
-					if len(warnings[newWarn.userMessage.SteamID]) > app.conf.General.WarningLimit {
-						log.Info("Warn limit exceeded",
-							zap.Int64("sid64", newWarn.userMessage.SteamID.Int64()),
-							zap.Int("count", len(warnings[newWarn.userMessage.SteamID])))
-
-						var (
-							errBan   error
-							banSteam store.BanSteam
-							expIn    = "Permanent"
-							expAt    = expIn
-						)
-
-						if errNewBan := store.NewBanSteam(ctx, store.StringSID(app.conf.General.Owner.String()),
-							store.StringSID(newWarn.userMessage.SteamID.String()),
-							store.Duration(app.conf.General.WarningExceededDuration),
-							newWarn.WarnReason,
-							"",
-							"Automatic warning ban",
-							store.System,
-							0,
-							store.NoComm,
-							&banSteam); errNewBan != nil {
-							log.Error("Failed to create warning ban", zap.Error(errNewBan))
-
-							continue
-						}
-
-						switch app.conf.General.WarningExceededAction {
-						case Gag:
-							banSteam.BanType = store.NoComm
-							errBan = app.BanSteam(ctx, &banSteam)
-						case Ban:
-							banSteam.BanType = store.Banned
-							errBan = app.BanSteam(ctx, &banSteam)
-						case Kick:
-							errBan = app.Kick(ctx, store.System, newWarn.userMessage.SteamID, app.conf.General.Owner, newWarn.WarnReason)
-						}
-
-						if errBan != nil {
-							log.Error("Failed to apply warning action",
-								zap.Error(errBan),
-								zap.String("action", string(app.conf.General.WarningExceededAction)))
-						}
-
-						msgEmbed.AddField("Name", person.PersonaName)
-
-						if banSteam.ValidUntil.Year()-time.Now().Year() < 5 {
-							expIn = FmtDuration(banSteam.ValidUntil)
-							expAt = FmtTimeShort(banSteam.ValidUntil)
-						}
-
-						msgEmbed.AddField("Expires In", expIn)
-						msgEmbed.AddField("Expires At", expAt)
-					} else {
-						msg := fmt.Sprintf("[WARN #%d] Please refrain from using slurs/toxicity (see: rules & MOTD). "+
-							"Further offenses will result in mutes/bans", len(warnings[newWarn.userMessage.SteamID]))
-
-						if errPSay := app.PSay(ctx, newWarn.userMessage.SteamID, msg); errPSay != nil {
-							log.Error("Failed to send user warning psay message", zap.Error(errPSay))
-						}
-					}
-				}
-
-				if app.conf.Filter.PingDiscord {
-					app.bot.SendPayload(discord.Payload{
-						ChannelID: app.conf.Discord.LogChannelID,
-						Embed:     msgEmbed.MessageEmbed,
-					})
-				}
-
+				app.onWarning(ctx, log, warnings, newWarn)
 			case <-ctx.Done():
 				return
 			}
 		}
 	}
 
+	var (
+		spam           = newSpamDetector(app.conf.Spam)
+		spamPruneTimer = time.NewTicker(time.Minute)
+		roundEventChan = make(chan logparse.ServerEvent)
+	)
+
+	// Registered before the handler is started so that a failure does not leave it running without
+	// anything reading incoming chat.
+	if errRegister := app.eb.Consume(roundEventChan, logparse.WRoundStart); errRegister != nil {
+		log.Error("Tried to register duplicate reader channel", zap.Error(errRegister))
+
+		return
+	}
+
 	go warningHandler()
 
 	for {
 		select {
+		case evt := <-roundEventChan:
+			spam.roundStarted(evt.ServerID)
+		case now := <-spamPruneTimer.C:
+			spam.prune(now)
 		case userMessage := <-app.incomingGameChat:
 			matchedWord, matchedFilter := app.wordFilters.findFilteredWordMatch(userMessage.Body)
 			if matchedFilter != nil {
@@ -421,12 +329,213 @@ func (app *App) warnWorker(ctx context.Context) { //nolint:maintidx
 					},
 				}
 			}
+
+			if !app.conf.Spam.Enabled {
+				continue
+			}
+
+			kind, isSpam := spam.check(userMessage)
+			if !isSpam {
+				continue
+			}
+
+			app.mc.spamCounter.With(prometheus.Labels{"server_name": userMessage.ServerName, "kind": string(kind)}).Inc()
+
+			log.Info("Chat spam detected", zap.String("kind", string(kind)),
+				zap.Int64("sid64", userMessage.SteamID.Int64()), zap.String("server", userMessage.ServerName))
+
+			if app.conf.Spam.Dry {
+				continue
+			}
+
+			go app.spamGag(ctx, userMessage, spam.thresholds(userMessage.ServerName).GagDuration.Duration())
+
+			// Only a single infraction is counted for each message
+			if matchedFilter != nil {
+				continue
+			}
+
+			warningChan <- newUserWarning{
+				userMessage: userMessage,
+				userWarning: userWarning{
+					WarnReason: store.Spam,
+					Message:    userMessage.Body,
+					Matched:    string(kind),
+					CreatedOn:  time.Now(),
+				},
+			}
 		case <-ctx.Done():
 			return
 		}
 	}
 }
 
+// warningDry returns true when warnings for the reason should only be reported and not recorded or acted
+// upon. Spam warnings follow the spam detection setting, all others follow the word filter setting.
+func (app *App) warningDry(reason store.Reason) bool {
+	if reason == store.Spam {
+		return app.conf.Spam.Dry
+	}
+
+	return app.conf.Filter.Dry
+}
+
+// onWarning records a new warning against the player, applying the configured action once they exceed
+// the warning limit.
+func (app *App) onWarning(ctx context.Context, log *zap.Logger, warnings map[steamid.SID64][]userWarning, //nolint:maintidx
+	newWarn newUserWarning,
+) {
+	dry := app.warningDry(newWarn.WarnReason)
+
+	if !newWarn.userMessage.SteamID.Valid() {
+		return
+	}
+
+	if newWarn.MatchedFilter != nil {
+		newWarn.MatchedFilter.TriggerCount++
+		if errSave := app.db.SaveFilter(ctx, newWarn.MatchedFilter); errSave != nil {
+			log.Error("Failed to update filter trigger count", zap.Error(errSave))
+		}
+	}
+
+	var person store.Person
+	if personErr := app.PersonBySID(ctx, newWarn.userMessage.SteamID, &person); personErr != nil {
+		log.Error("Failed to get person for warning", zap.Error(personErr))
+
+		return
+	}
+
+	if newWarn.MatchedFilter != nil && !newWarn.MatchedFilter.IsEnabled {
+		return
+	}
+
+	title := fmt.Sprintf("%s Warning (#%d/%d)", newWarn.WarnReason.String(),
+		len(warnings[newWarn.userMessage.SteamID])+1, app.conf.General.WarningLimit)
+	if dry {
+		title = "[DRYRUN] " + title
+	}
+
+	msgEmbed := discord.
+		NewEmbed(title).
+		SetDescription(newWarn.userWarning.Message).
+		SetColor(app.bot.Colour.Warn)
+
+	if newWarn.MatchedFilter != nil {
+		msgEmbed.AddField("Filter ID", fmt.Sprintf("%d", newWarn.MatchedFilter.FilterID))
+	}
+
+	msgEmbed.
+		AddField("Matched", newWarn.Matched).
+		AddField("Server", newWarn.userMessage.ServerName).InlineAllFields()
+
+	if newWarn.MatchedFilter != nil {
+		msgEmbed.AddField("Pattern", newWarn.MatchedFilter.Pattern)
+	}
+
+	app.addAuthor(ctx, msgEmbed, newWarn.userMessage.SteamID)
+
+	discord.AddFieldsSteamID(msgEmbed, newWarn.userMessage.SteamID)
+
+	if newWarn.MatchedFilter != nil && !newWarn.MatchedFilter.IsEnabled {
+		return
+	}
+
+	if !dry {
+		_, found := warnings[newWarn.userMessage.SteamID]
+		if !found {
+			warnings[newWarn.userMessage.SteamID] = []userWarning{}
+		}
+
+		warnings[newWarn.userMessage.SteamID] = append(warnings[newWarn.userMessage.SteamID], newWarn.userWarning)
+
+		personWarning := store.PersonWarning{
+			SteamID:         newWarn.userMessage.SteamID,
+			PersonMessageID: newWarn.userMessage.PersonMessageID,
+			ServerID:        newWarn.userMessage.ServerID,
+			Reason:          newWarn.WarnReason,
+			Matched:         newWarn.Matched,
+			CreatedOn:       newWarn.CreatedOn,
+		}
+
+		if errWarning := app.db.AddPersonWarning(ctx, &personWarning); errWarning != nil {
+			log.Error("Failed to save warning", zap.Error(errWarning))
+		}
+
+		if len(warnings[newWarn.userMessage.SteamID]) > app.conf.General.WarningLimit {
+			log.Info("Warn limit exceeded",
+				zap.Int64("sid64", newWarn.userMessage.SteamID.Int64()),
+				zap.Int("count", len(warnings[newWarn.userMessage.SteamID])))
+
+			var (
+				errBan   error
+				banSteam store.BanSteam
+				expIn    = "Permanent"
+				expAt    = expIn
+			)
+
+			if errNewBan := store.NewBanSteam(ctx, store.StringSID(app.conf.General.Owner.String()),
+				store.StringSID(newWarn.userMessage.SteamID.String()),
+				store.Duration(app.conf.General.WarningExceededDuration),
+				newWarn.WarnReason,
+				"",
+				"Automatic warning ban",
+				store.System,
+				0,
+				store.NoComm,
+				&banSteam); errNewBan != nil {
+				log.Error("Failed to create warning ban", zap.Error(errNewBan))
+
+				return
+			}
+
+			switch app.conf.General.WarningExceededAction {
+			case Gag:
+				banSteam.BanType = store.NoComm
+				errBan = app.BanSteam(ctx, &banSteam)
+			case Ban:
+				banSteam.BanType = store.Banned
+				errBan = app.BanSteam(ctx, &banSteam)
+			case Kick:
+				errBan = app.Kick(ctx, store.System, newWarn.userMessage.SteamID, app.conf.General.Owner, newWarn.WarnReason)
+			}
+
+			if errBan != nil {
+				log.Error("Failed to apply warning action",
+					zap.Error(errBan),
+					zap.String("action", string(app.conf.General.WarningExceededAction)))
+			}
+
+			msgEmbed.AddField("Name", person.PersonaName)
+
+			if banSteam.ValidUntil.Year()-time.Now().Year() < 5 {
+				expIn = FmtDuration(banSteam.ValidUntil)
+				expAt = FmtTimeShort(banSteam.ValidUntil)
+			}
+
+			msgEmbed.AddField("Expires In", expIn)
+			msgEmbed.AddField("Expires At", expAt)
+		} else {
+			msg := fmt.Sprintf("[WARN #%d] Please refrain from using slurs/toxicity (see: rules & MOTD). "+
+				"Further offenses will result in mutes/bans", len(warnings[newWarn.userMessage.SteamID]))
+			if newWarn.WarnReason == store.Spam {
+				msg = fmt.Sprintf("[WARN #%d] Please stop spamming chat (%s). "+
+					"Further offenses will result in mutes/bans", len(warnings[newWarn.userMessage.SteamID]), newWarn.Matched)
+			}
+
+			if errPSay := app.PSay(ctx, newWarn.userMessage.SteamID, msg); errPSay != nil {
+				log.Error("Failed to send user warning psay message", zap.Error(errPSay))
+			}
+		}
+	}
+
+	if app.conf.Filter.PingDiscord {
+		app.bot.SendPayload(discord.Payload{
+			ChannelID: app.conf.Discord.LogChannelID,
+			Embed:     msgEmbed.MessageEmbed,
+		})
+	}
+}
+
 func (app *App) chatRecorder(ctx context.Context) {
 	var (
 		log             = app.log.Named("chatRecorder")
@@ -503,7 +612,7 @@ func (app *App) playerConnectionWriter(ctx context.Context) {
 	log := app.log.Named("playerConnectionWriter")
 
 	serverEventChan := make(chan logparse.ServerEvent)
-	if errRegister := app.eb.Consume(serverEventChan, logparse.Connected); errRegister != nil {
+	if errRegister := app.eb.Consume(serverEventChan, logparse.Connected, logparse.Disconnected); errRegister != nil {
 		log.Warn("logWriter Tried to register duplicate reader channel", zap.Error(errRegister))
 
 		return
@@ -514,6 +623,18 @@ func (app *App) playerConnectionWriter(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case evt := <-serverEventChan:
+			if disconnectEvent, isDisconnect := evt.Event.(logparse.DisconnectedEvt); isDisconnect {
+				lCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+				if errDisconnect := app.db.SetConnectionDisconnected(lCtx, disconnectEvent.SID, evt.ServerID,
+					disconnectEvent.CreatedOn); errDisconnect != nil {
+					log.Error("Failed to update connection history", zap.Error(errDisconnect))
+				}
+
+				cancel()
+
+				continue
+			}
+
 			newServerEvent, ok := evt.Event.(logparse.ConnectedEvt)
 			if !ok {
 				continue
@@ -544,6 +665,7 @@ func (app *App) playerConnectionWriter(ctx context.Context) {
 				IPAddr:      parsedAddr,
 				SteamID:     newServerEvent.SID,
 				PersonaName: strings.ToValidUTF8(newServerEvent.Name, "_"),
+				ServerID:    evt.ServerID,
 				CreatedOn:   newServerEvent.CreatedOn,
 			}
 
@@ -607,6 +729,7 @@ func (app *App) logReader(ctx context.Context, writeUnhandled bool) {
 				newServerEvent := logparse.ServerEvent{
 					ServerName: logFile.ServerName,
 					ServerID:   logFile.ServerID,
+					Raw:        logLine,
 					Results:    parseResult,
 				}
 
@@ -670,10 +793,17 @@ func (app *App) startWorkers(ctx context.Context) {
 	go app.matchSummarizer(ctx)
 	go app.chatRecorder(ctx)
 	go app.playerConnectionWriter(ctx)
+	go app.nameFilterWorker(ctx)
 	go app.steamGroupMembershipUpdater(ctx)
 	go cleanupTasks(ctx, app.db, app.log)
 	go app.showReportMeta(ctx)
 	go app.notificationSender(ctx)
+	go app.dataExportWorker(ctx)
+	go app.erasureWorker(ctx)
+	go app.nameHistoryWorker(ctx)
+	go app.scheduledTaskWorker(ctx)
+	go app.serverHealthWorker(ctx)
+	go app.leaderboardWorker(ctx)
 	go demoCleaner(ctx, app.db, app.log)
 	go app.stateUpdater(ctx)
 }
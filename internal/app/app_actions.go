@@ -183,56 +183,95 @@ func (app *App) Silence(ctx context.Context, _ store.Origin, target steamid.SID6
 	return nil
 }
 
-// Say is used to send a message to the server via sm_say.
-func (app *App) Say(ctx context.Context, author steamid.SID64, serverName string, message string) error {
-	state := app.state.current()
-	servers := state.serverIDsByName(serverName, true)
+// rconResultsSummary counts the successful and failed results.
+func rconResultsSummary(results []rconResult) (int, int) {
+	var success, failed int
+
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		} else {
+			success++
+		}
+	}
 
-	if len(servers) == 0 {
-		return errUnknownServer
+	return success, failed
+}
+
+// Say is used to send a message to the servers matching the selector via sm_say.
+func (app *App) Say(ctx context.Context, author steamid.SID64, selector string, message string) ([]rconResult, error) {
+	results, errBroadcast := app.state.broadcastSelector(selector, fmt.Sprintf(`sm_say %s`, message))
+	if errBroadcast != nil {
+		return nil, errBroadcast
 	}
 
-	app.state.broadcast(servers, fmt.Sprintf(`sm_say %s`, message))
-	app.log.Info("Server Message Sent Successfully", zap.Int64("author", author.Int64()), zap.String("msg", message))
+	success, failed := rconResultsSummary(results)
+
+	app.log.Info("Server Message Sent Successfully", zap.Int64("author", author.Int64()), zap.String("msg", message),
+		zap.Int("success", success), zap.Int("failed", failed))
 
 	msgEmbed := discord.
 		NewEmbed("Message sent successfully").
 		SetDescription(message).
 		SetColor(app.bot.Colour.Success).
-		AddField("servers", fmt.Sprintf("%d", len(servers)))
+		AddField("servers", fmt.Sprintf("%d/%d", success, len(results)))
 
 	app.addAuthor(ctx, msgEmbed, author)
 
 	app.bot.SendPayload(discord.Payload{ChannelID: app.conf.Discord.LogChannelID, Embed: msgEmbed.MessageEmbed})
 
-	return nil
+	return results, nil
 }
 
-// CSay is used to send a centered message to the server via sm_csay.
-func (app *App) CSay(ctx context.Context, author steamid.SID64, serverName string, message string) error {
-	state := app.state.current()
-	servers := state.serverIDsByName(serverName, true)
-
-	if len(servers) == 0 {
-		return errUnknownServer
+// CSay is used to send a centered message to the servers matching the selector via sm_csay.
+func (app *App) CSay(ctx context.Context, author steamid.SID64, selector string, message string) ([]rconResult, error) {
+	results, errBroadcast := app.state.broadcastSelector(selector, fmt.Sprintf(`sm_csay %s`, message))
+	if errBroadcast != nil {
+		return nil, errBroadcast
 	}
 
-	app.state.broadcast(servers, fmt.Sprintf(`sm_csay %s`, message))
+	success, failed := rconResultsSummary(results)
 
 	app.log.Info("Server Center Message Sent Successfully", zap.Int64("author", author.Int64()),
-		zap.String("msg", message), zap.Int("servers", len(servers)))
+		zap.String("msg", message), zap.Int("success", success), zap.Int("failed", failed))
 
 	msgEmbed := discord.
 		NewEmbed("center Message Sent Successfully").
 		SetDescription(message).
 		SetColor(app.bot.Colour.Success).
-		AddField("servers", fmt.Sprintf("%d", len(servers)))
+		AddField("servers", fmt.Sprintf("%d/%d", success, len(results)))
 
 	app.addAuthor(ctx, msgEmbed, author)
 
 	app.bot.SendPayload(discord.Payload{ChannelID: app.conf.Discord.LogChannelID, Embed: msgEmbed.MessageEmbed})
 
-	return nil
+	return results, nil
+}
+
+// RCON executes an arbitrary command on all the servers matching the selector.
+func (app *App) RCON(ctx context.Context, author steamid.SID64, selector string, command string) ([]rconResult, error) {
+	results, errBroadcast := app.state.broadcastSelector(selector, command)
+	if errBroadcast != nil {
+		return nil, errBroadcast
+	}
+
+	success, failed := rconResultsSummary(results)
+
+	app.log.Info("RCON command executed", zap.Int64("author", author.Int64()), zap.String("selector", selector),
+		zap.String("command", command), zap.Int("success", success), zap.Int("failed", failed))
+
+	msgEmbed := discord.
+		NewEmbed("RCON command executed").
+		SetDescription(command).
+		SetColor(app.bot.Colour.Success).
+		AddField("selector", selector).
+		AddField("servers", fmt.Sprintf("%d/%d", success, len(results)))
+
+	app.addAuthor(ctx, msgEmbed, author)
+
+	app.bot.SendPayload(discord.Payload{ChannelID: app.conf.Discord.LogChannelID, Embed: msgEmbed.MessageEmbed})
+
+	return results, nil
 }
 
 // PSay is used to send a private message to a player.
@@ -363,18 +402,14 @@ func (app *App) FilterCheck(message string) []store.Filter {
 		return nil
 	}
 
-	words := strings.Split(strings.ToLower(message), " ")
-
 	app.wordFilters.RLock()
 	defer app.wordFilters.RUnlock()
 
 	var found []store.Filter
 
 	for _, filter := range app.wordFilters.wordFilters {
-		for _, word := range words {
-			if filter.Match(word) {
-				found = append(found, filter)
-			}
+		if _, matched := filter.Match(message); matched {
+			found = append(found, filter)
 		}
 	}
 
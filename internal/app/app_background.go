@@ -40,6 +40,11 @@ type activeMatchContext struct {
 	incomingEvents chan logparse.ServerEvent
 	log            *zap.Logger
 	finalScores    int
+	// checkpoint state is only accessed by the summarizer, the match itself is owned by start
+	checkpoint   store.MatchCheckpoint
+	pendingLines []string
+	roundStarted bool
+	persisted    bool
 }
 
 func (am *activeMatchContext) start(ctx context.Context) {
@@ -70,21 +75,28 @@ func (app *App) matchSummarizer(ctx context.Context) {
 		log.Error("logWriter Tried to register duplicate reader channel", zap.Error(errReg))
 	}
 
-	matches := map[int]*activeMatchContext{}
+	var (
+		matches          = map[int]*activeMatchContext{}
+		resumable        = app.loadMatchCheckpoints(ctx, log)
+		checkpointTicker = time.NewTicker(app.conf.Match.CheckpointInterval.Duration())
+		pruneTicker      = time.NewTicker(time.Hour * 24)
+	)
+
+	app.pruneMatchCheckpoints(ctx, log)
 
 	for {
 		select {
+		case <-checkpointTicker.C:
+			app.checkpointMatches(ctx, log, matches, resumable)
+		case <-pruneTicker.C:
+			app.pruneMatchCheckpoints(ctx, log)
 		case evt := <-eventChan:
 			matchContext, exists := matches[evt.ServerID]
 
 			if !exists {
 				cancelCtx, cancel := context.WithCancel(ctx)
-				matchContext = &activeMatchContext{
-					match:          logparse.NewMatch(evt.ServerID, evt.ServerName),
-					cancel:         cancel,
-					log:            log.Named(evt.ServerName),
-					incomingEvents: make(chan logparse.ServerEvent),
-				}
+				matchContext = app.newMatchContext(ctx, log, evt, resumable)
+				matchContext.cancel = cancel
 
 				go matchContext.start(cancelCtx)
 
@@ -93,6 +105,7 @@ func (app *App) matchSummarizer(ctx context.Context) {
 				matches[evt.ServerID] = matchContext
 			}
 
+			matchContext.track(evt)
 			matchContext.incomingEvents <- evt
 
 			switch evt.EventType {
@@ -117,16 +130,29 @@ func (app *App) matchSummarizer(ctx context.Context) {
 					app.log.Error("Failed to save match",
 						zap.Int("server", matchContext.match.ServerID), zap.Error(errSave))
 
+					app.closeMatchCheckpoint(ctx, log, matchContext, store.MatchCheckpointFailed, errSave.Error())
 					delete(matches, evt.ServerID)
 
 					continue
 				}
 
+				app.closeMatchCheckpoint(ctx, log, matchContext, store.MatchCheckpointCompleted, "")
 				app.onMatchComplete(ctx, matchContext.match.MatchID)
 
 				delete(matches, evt.ServerID)
 			}
 		case <-ctx.Done():
+			checkpointTicker.Stop()
+			pruneTicker.Stop()
+
+			// Flush any remaining lines so the matches can be resumed once we are running again
+			flushCtx, cancel := context.WithTimeout(context.Background(), checkpointSaveTimeout)
+			for _, matchContext := range matches {
+				app.saveMatchCheckpoint(flushCtx, log, matchContext)
+			}
+
+			cancel()
+
 			return
 		}
 	}
@@ -140,6 +166,28 @@ func (app *App) onMatchComplete(ctx context.Context, matchID uuid.UUID) {
 		return
 	}
 
+	if app.conf.Rating.Enabled {
+		if errRating := app.updateMatchRatings(ctx, &result); errRating != nil {
+			if isRatingSkipped(errRating) {
+				app.log.Debug("Match not rated", zap.String("match_id", matchID.String()), zap.Error(errRating))
+			} else {
+				app.log.Error("Failed to update match ratings", zap.Error(errRating))
+			}
+		}
+	}
+
+	if app.conf.Leaderboard.Enabled {
+		if errLeaderboards := app.updateMatchLeaderboards(ctx, &result); errLeaderboards != nil {
+			app.log.Error("Failed to update leaderboards", zap.Error(errLeaderboards))
+		}
+	}
+
+	if app.conf.Anomaly.Enabled {
+		if errAnomalies := app.checkMatchAnomalies(ctx, &result); errAnomalies != nil {
+			app.log.Error("Failed to check match anomalies", zap.Error(errAnomalies))
+		}
+	}
+
 	app.bot.SendPayload(discord.Payload{
 		ChannelID: app.conf.Discord.PublicMatchLogChannelID,
 		Embed:     app.genDiscordMatchEmbed(result).MessageEmbed,
@@ -391,10 +439,25 @@ func (app *App) updateProfiles(ctx context.Context, people store.People) error {
 		return errors.Wrap(errFetch, "Failed to fetch data from steamapi")
 	}
 
+	type profileMatch struct {
+		steamID steamid.SID64
+		field   string
+		value   string
+		matched string
+		filter  *store.Filter
+	}
+
+	var profileMatches []profileMatch
+
 	for _, curPerson := range people {
 		person := curPerson
 		person.UpdatedOnSteam = time.Now()
 
+		var previous steamweb.PlayerSummary
+		if person.PlayerSummary != nil {
+			previous = *person.PlayerSummary
+		}
+
 		for _, newSummary := range summaries {
 			summary := newSummary
 			if person.SteamID != summary.SteamID {
@@ -422,6 +485,44 @@ func (app *App) updateProfiles(ctx context.Context, people store.People) error {
 		if errSavePerson := app.db.SavePerson(ctx, &person); errSavePerson != nil {
 			return errors.Wrap(errSavePerson, "Failed to save person")
 		}
+
+		if person.PlayerSummary == nil || person.Erased {
+			continue
+		}
+
+		app.recordPersonaName(ctx, person.SteamID, person.PersonaName, person.UpdatedOnSteam)
+
+		// Only check values that have changed since the last refresh so the same profile is not acted on repeatedly
+		for _, profileField := range []struct {
+			field    string
+			value    string
+			previous string
+		}{
+			{"Persona Name", person.PersonaName, previous.PersonaName},
+			{"Real Name", person.RealName, previous.RealName},
+		} {
+			if profileField.value == profileField.previous {
+				continue
+			}
+
+			if matched, filter := app.findNameFilterMatch(app.conf.Filter.Profile, profileField.value); filter != nil {
+				profileMatches = append(profileMatches, profileMatch{
+					steamID: person.SteamID,
+					field:   profileField.field,
+					value:   profileField.value,
+					matched: matched,
+					filter:  filter,
+				})
+			}
+		}
+	}
+
+	for _, match := range profileMatches {
+		if errApply := app.applyNameFilterAction(ctx, app.conf.Filter.Profile, match.steamID, match.field,
+			match.value, match.matched, match.filter); errApply != nil {
+			app.log.Error("Failed to apply profile filter action", zap.Error(errApply),
+				zap.Int64("sid64", match.steamID.Int64()))
+		}
 	}
 
 	return nil
@@ -431,8 +532,9 @@ func (app *App) updateProfiles(ctx context.Context, people store.People) error {
 // The 100 oldest profiles are updated on each execution.
 func (app *App) profileUpdater(ctx context.Context) {
 	var (
-		log    = app.log.Named("profileUpdate")
-		run    = make(chan any)
+		log = app.log.Named("profileUpdate")
+		// Buffered since the loop triggers itself, an unbuffered send from the ticker case blocks forever
+		run    = make(chan any, 1)
 		ticker = time.NewTicker(time.Second * 60)
 	)
 
@@ -502,6 +604,7 @@ func (app *App) stateUpdater(ctx context.Context) {
 					server.Region,
 					server.Latitude,
 					server.Longitude,
+					server.Tags,
 				))
 			}
 
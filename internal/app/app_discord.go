@@ -42,6 +42,7 @@ func (app *App) registerDiscordHandlers() error {
 		// discord.CmdCheckIP:  onCheckIp,
 		discord.CmdPlayers:  makeOnPlayers(app),
 		discord.CmdPSay:     makeOnPSay(app),
+		discord.CmdRCON:     makeOnRCON(app),
 		discord.CmdSay:      makeOnSay(app),
 		discord.CmdServers:  makeOnServers(app),
 		discord.CmdSetSteam: makeOnSetSteam(app),
@@ -327,6 +328,22 @@ func makeOnCheck(app *App) discord.CommandHandler { //nolint:maintidx
 			app.addAuthor(ctx, msgEmbed, ban.Ban.SourceID)
 		}
 
+		notes, errNotes := app.db.GetPersonNotes(ctx, sid)
+		if errNotes != nil && !errors.Is(errNotes, store.ErrNoResult) {
+			app.log.Error("Failed to fetch person notes", zap.Error(errNotes))
+		}
+
+		for index, note := range notes {
+			if index == 3 {
+				msgEmbed.AddField("More Notes", fmt.Sprintf("%d more on profile", len(notes)-index))
+
+				break
+			}
+
+			msgEmbed.AddField(fmt.Sprintf("Mod Note (%s)", note.Severity.String()),
+				fmt.Sprintf("%s\n- %s %s", note.BodyMD, note.AuthorName, FmtTimeShort(note.CreatedOn)))
+		}
+
 		if player.IPAddr != nil {
 			msgEmbed.AddField("Last IP", player.IPAddr.String()).MakeFieldInline()
 		}
@@ -369,9 +386,11 @@ func makeOnCheck(app *App) discord.CommandHandler { //nolint:maintidx
 
 func makeOnHistory(app *App) discord.CommandHandler {
 	return func(ctx context.Context, session *discordgo.Session, interaction *discordgo.InteractionCreate) (*discordgo.MessageEmbed, error) {
-		switch interaction.ApplicationCommandData().Name {
+		switch interaction.ApplicationCommandData().Options[0].Name {
 		case string(discord.CmdHistoryIP):
 			return onHistoryIP(ctx, app, session, interaction)
+		case string(discord.CmdHistoryName):
+			return onHistoryNames(ctx, app, session, interaction)
 		default:
 			// return bot.onHistoryChat(ctx, session, interaction, response)
 			return nil, discord.ErrCommandFailed
@@ -417,6 +436,52 @@ func onHistoryIP(ctx context.Context, app *App, _ *discordgo.Session, interactio
 	return msgEmbed.MessageEmbed, nil
 }
 
+func onHistoryNames(ctx context.Context, app *App, _ *discordgo.Session, interaction *discordgo.InteractionCreate) (*discordgo.MessageEmbed, error) {
+	opts := discord.OptionMap(interaction.ApplicationCommandData().Options[0].Options)
+
+	steamID, errResolve := resolveSID(ctx, opts[discord.OptUserIdentifier].StringValue())
+	if errResolve != nil {
+		return nil, consts.ErrInvalidSID
+	}
+
+	person := store.NewPerson(steamID)
+	if errPersonBySID := app.PersonBySID(ctx, steamID, &person); errPersonBySID != nil {
+		return nil, discord.ErrCommandFailed
+	}
+
+	names, errNames := app.db.GetPersonNames(ctx, steamID)
+	if errNames != nil && !errors.Is(errNames, store.ErrNoResult) {
+		return nil, discord.ErrCommandFailed
+	}
+
+	writer := &strings.Builder{}
+	table := defaultTable(writer)
+	table.SetHeader([]string{"Name", "First Seen", "Last Seen"})
+
+	for index, name := range names {
+		if index == 25 {
+			break
+		}
+
+		table.Append([]string{
+			name.PersonaName,
+			name.FirstSeen.Format(time.DateOnly),
+			name.LastSeen.Format(time.DateOnly),
+		})
+	}
+
+	table.Render()
+
+	msgEmbed := discord.
+		NewEmbed(fmt.Sprintf("Name History of: %s", person.PersonaName)).
+		SetDescription(fmt.Sprintf("%d unique names (25 max)```%s```", len(names), writer.String())).
+		SetColor(app.bot.Colour.Success)
+
+	discord.AddFieldsSteamID(msgEmbed, steamID)
+
+	return msgEmbed.Truncate().MessageEmbed, nil
+}
+
 //
 // func (bot *Discord) onHistoryChat(ctx context.Context, _ *discordgo.Session, interaction *discordgo.InteractionCreate, response *botResponse) error {
 //	steamId, errResolveSID := resolveSID(ctx, interaction.Data.Options[0].Options[0].Value.(string))
@@ -635,24 +700,56 @@ func makeOnKick(app *App) discord.CommandHandler {
 	}
 }
 
+// addRCONResultFields adds a field for each server showing the response or error of the command.
+func addRCONResultFields(msgEmbed *embed.Embed, results []rconResult, showResponse bool) {
+	const maxResponseLen = 1000
+
+	for _, result := range results {
+		value := "OK"
+
+		switch {
+		case result.Err != nil:
+			value = "Failed: " + result.Err.Error()
+		case showResponse && strings.TrimSpace(result.Response) != "":
+			value = strings.TrimSpace(result.Response)
+			if len(value) > maxResponseLen {
+				value = value[:maxResponseLen] + "..."
+			}
+
+			value = fmt.Sprintf("```%s```", value)
+		}
+
+		msgEmbed.AddField(result.NameShort, value)
+	}
+}
+
+func rconResultsColour(app *App, results []rconResult) int {
+	if _, failed := rconResultsSummary(results); failed > 0 {
+		return app.bot.Colour.Warn
+	}
+
+	return app.bot.Colour.Success
+}
+
 func makeOnSay(app *App) discord.CommandHandler {
 	return func(ctx context.Context, _ *discordgo.Session, interaction *discordgo.InteractionCreate) (*discordgo.MessageEmbed, error) {
 		opts := discord.OptionMap(interaction.ApplicationCommandData().Options)
 		server := opts[discord.OptServerIdentifier].StringValue()
 		msg := opts[discord.OptMessage].StringValue()
 
-		if errSay := app.Say(ctx, "", server, msg); errSay != nil {
+		results, errSay := app.Say(ctx, "", server, msg)
+		if errSay != nil {
 			return nil, discord.ErrCommandFailed
 		}
 
 		msgEmbed := discord.
-			NewEmbed("Sent center message successfully").
-			SetColor(app.bot.Colour.Success).
-			AddField("Server", server).
-			AddField("Message", msg).
-			Truncate()
+			NewEmbed("Sent console message").
+			SetColor(rconResultsColour(app, results)).
+			AddField("Message", msg)
 
-		return msgEmbed.MessageEmbed, nil
+		addRCONResultFields(msgEmbed, results, false)
+
+		return msgEmbed.Truncate().MessageEmbed, nil
 	}
 }
 
@@ -663,18 +760,51 @@ func makeOnCSay(app *App) discord.CommandHandler {
 		server := opts[discord.OptServerIdentifier].StringValue()
 		msg := opts[discord.OptMessage].StringValue()
 
-		if errCSay := app.CSay(ctx, "", server, msg); errCSay != nil {
+		results, errCSay := app.CSay(ctx, "", server, msg)
+		if errCSay != nil {
 			return nil, discord.ErrCommandFailed
 		}
 
 		msgEmbed := discord.
-			NewEmbed("Sent console message successfully").
-			SetColor(app.bot.Colour.Success).
-			AddField("Server", server).
-			AddField("Message", msg).
-			Truncate()
+			NewEmbed("Sent center message").
+			SetColor(rconResultsColour(app, results)).
+			AddField("Message", msg)
 
-		return msgEmbed.MessageEmbed, nil
+		addRCONResultFields(msgEmbed, results, false)
+
+		return msgEmbed.Truncate().MessageEmbed, nil
+	}
+}
+
+func makeOnRCON(app *App) discord.CommandHandler {
+	return func(ctx context.Context, _ *discordgo.Session, interaction *discordgo.InteractionCreate,
+	) (*discordgo.MessageEmbed, error) {
+		opts := discord.OptionMap(interaction.ApplicationCommandData().Options)
+		server := opts[discord.OptServerIdentifier].StringValue()
+		command := opts[discord.OptCommand].StringValue()
+
+		author, errAuthor := getDiscordAuthor(ctx, app.db, interaction)
+		if errAuthor != nil {
+			return nil, errAuthor
+		}
+
+		if author.PermissionLevel < consts.PAdmin {
+			return nil, consts.ErrPermissionDenied
+		}
+
+		results, errRCON := app.RCON(ctx, author.SteamID, server, command)
+		if errRCON != nil {
+			return nil, discord.ErrCommandFailed
+		}
+
+		msgEmbed := discord.
+			NewEmbed("RCON command executed").
+			SetColor(rconResultsColour(app, results)).
+			AddField("Command", command)
+
+		addRCONResultFields(msgEmbed, results, true)
+
+		return msgEmbed.Truncate().MessageEmbed, nil
 	}
 }
 
@@ -809,7 +939,12 @@ func makeOnPlayers(app *App) discord.CommandHandler {
 
 		var rows []string
 
-		msgEmbed := discord.NewEmbed(fmt.Sprintf("%s Current Players: %d / %d", serverState.Name, len(serverState.Players), serverState.MaxPlayers))
+		title := "Current Players"
+		if serverState.PlayersStale {
+			title = "Last Known Players"
+		}
+
+		msgEmbed := discord.NewEmbed(fmt.Sprintf("%s %s: %d / %d", serverState.Name, title, len(serverState.Players), serverState.MaxPlayers))
 
 		if len(serverState.Players) > 0 {
 			sort.SliceStable(serverState.Players, func(i, j int) bool {
@@ -4,8 +4,11 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/leighmacdonald/gbans/internal/discord"
 	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/leighmacdonald/steamid/v3/steamid"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
@@ -66,9 +69,53 @@ func TestApp(t *testing.T) {
 	app := New(&config, database, nil, zap.NewNop())
 
 	t.Run("match_sum", testMatchSum(&app))
+	t.Run("map_stats_not_found", testMapStatsNotFound(&app))
+	t.Run("spam_warning", testSpamWarning(&app))
 }
 
 func testMatchSum(_ *App) func(t *testing.T) {
 	return func(t *testing.T) {
 	}
 }
+
+func testSpamWarning(app *App) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := context.Background()
+
+		// Not connected, payloads are dropped
+		bot, errBot := discord.New(zap.NewNop(), "test", "", false, "")
+		require.NoError(t, errBot)
+
+		app.bot = bot
+		// The defaults leave the word filter in dry mode, which must not stop spam warnings
+		app.conf.Filter.Dry = true
+		app.conf.Spam.Dry = false
+
+		person := store.NewPerson(steamid.New(76561198084134099))
+		require.NoError(t, app.db.SavePerson(ctx, &person))
+
+		warnings := map[steamid.SID64][]userWarning{}
+		newWarning := func(reason store.Reason) newUserWarning {
+			return newUserWarning{
+				userMessage: store.PersonMessage{SteamID: person.SteamID, ServerName: "test", Body: "spam spam"},
+				userWarning: userWarning{WarnReason: reason, Message: "spam spam", Matched: string(spamRepeat),
+					CreatedOn: time.Now()},
+			}
+		}
+
+		app.onWarning(ctx, app.log, warnings, newWarning(store.Spam))
+		require.Len(t, warnings[person.SteamID], 1)
+
+		events, _, errEvents := app.db.GetPersonTimeline(ctx, store.TimelineQueryFilter{
+			SteamID: person.SteamID,
+			Types:   []store.TimelineEventType{store.TimelineWarning},
+		})
+		require.NoError(t, errEvents)
+		require.Len(t, events, 1)
+		require.Equal(t, store.Spam, events[0].Reason)
+
+		// Other warnings still follow the word filter setting
+		app.onWarning(ctx, app.log, warnings, newWarning(store.Language))
+		require.Len(t, warnings[person.SteamID], 1)
+	}
+}
@@ -0,0 +1,337 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unicode"
+
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/leighmacdonald/gbans/pkg/util"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+type spamKind string
+
+const (
+	spamRate   spamKind = "rate"
+	spamRepeat spamKind = "repeat"
+	spamCaps   spamKind = "caps"
+	spamBind   spamKind = "bind"
+)
+
+// spamNormalizeOpts is used to fold messages before comparing them so trivial variations of the same
+// message are still considered repeats.
+var spamNormalizeOpts = util.NormalizeOpts{Confusables: true, Invisible: true, Repeats: true, Spaces: true} //nolint:gochecknoglobals
+
+type spamMessage struct {
+	body      string
+	caps      bool
+	round     int
+	createdOn time.Time
+}
+
+// spamDetector tracks recent chat history for each player and checks incoming messages against the
+// configured spam thresholds. It is not safe for concurrent use.
+type spamDetector struct {
+	conf    spamConfig
+	history map[steamid.SID64][]spamMessage
+	// Current round counter for each server id
+	rounds map[int]int
+}
+
+func newSpamDetector(conf spamConfig) *spamDetector {
+	return &spamDetector{
+		conf:    conf,
+		history: map[steamid.SID64][]spamMessage{},
+		rounds:  map[int]int{},
+	}
+}
+
+// thresholds returns the thresholds for the server, with any unset override values using the defaults.
+func (d *spamDetector) thresholds(serverName string) spamThresholds {
+	limits := d.conf.Defaults
+
+	override, found := d.conf.Servers[serverName]
+	if !found {
+		return limits
+	}
+
+	if override.RateLimit != 0 {
+		limits.RateLimit = override.RateLimit
+	}
+
+	if override.RateWindow != "" {
+		limits.RateWindow = override.RateWindow
+	}
+
+	if override.RepeatLimit != 0 {
+		limits.RepeatLimit = override.RepeatLimit
+	}
+
+	if override.RepeatWindow != "" {
+		limits.RepeatWindow = override.RepeatWindow
+	}
+
+	if override.RepeatSimilarity != 0 {
+		limits.RepeatSimilarity = override.RepeatSimilarity
+	}
+
+	if override.CapsLimit != 0 {
+		limits.CapsLimit = override.CapsLimit
+	}
+
+	if override.CapsWindow != "" {
+		limits.CapsWindow = override.CapsWindow
+	}
+
+	if override.CapsMinLength != 0 {
+		limits.CapsMinLength = override.CapsMinLength
+	}
+
+	if override.CapsRatio != 0 {
+		limits.CapsRatio = override.CapsRatio
+	}
+
+	if override.BindLimit != 0 {
+		limits.BindLimit = override.BindLimit
+	}
+
+	if override.BindWindow != "" {
+		limits.BindWindow = override.BindWindow
+	}
+
+	if override.GagDuration != "" {
+		limits.GagDuration = override.GagDuration
+	}
+
+	return limits
+}
+
+// roundStarted advances the round counter used for detecting bind spam.
+func (d *spamDetector) roundStarted(serverID int) {
+	d.rounds[serverID]++
+}
+
+// prune removes history entries that are older than the longest check window of any server.
+func (d *spamDetector) prune(now time.Time) {
+	window := d.pruneWindow()
+
+	for steamID, messages := range d.history {
+		if len(messages) == 0 || now.Sub(messages[len(messages)-1].createdOn) > window {
+			delete(d.history, steamID)
+		}
+	}
+}
+
+// pruneWindow returns the longest check window across the defaults and every server override. History is
+// tracked per player rather than per server, so it must be kept for as long as any server could use it.
+func (d *spamDetector) pruneWindow() time.Duration {
+	window := d.maxWindow(d.conf.Defaults)
+
+	for serverName := range d.conf.Servers {
+		if serverWindow := d.maxWindow(d.thresholds(serverName)); serverWindow > window {
+			window = serverWindow
+		}
+	}
+
+	return window
+}
+
+func (d *spamDetector) maxWindow(limits spamThresholds) time.Duration {
+	var window time.Duration
+
+	for _, duration := range []StringDuration{limits.RateWindow, limits.RepeatWindow, limits.CapsWindow, limits.BindWindow} {
+		if duration == "" {
+			continue
+		}
+
+		if value := duration.Duration(); value > window {
+			window = value
+		}
+	}
+
+	return window
+}
+
+// check records the message and returns the first kind of spam that it triggers, if any. The history for
+// a player is reset once triggered, so that further detections require new evidence.
+func (d *spamDetector) check(msg store.PersonMessage) (spamKind, bool) {
+	limits := d.thresholds(msg.ServerName)
+
+	current := spamMessage{
+		body:      util.Normalize(msg.Body, spamNormalizeOpts),
+		caps:      isCapsMessage(msg.Body, limits.CapsMinLength, limits.CapsRatio),
+		round:     d.rounds[msg.ServerID],
+		createdOn: msg.CreatedOn,
+	}
+
+	maxWindow := d.maxWindow(limits)
+
+	var history []spamMessage
+
+	for _, prev := range d.history[msg.SteamID] {
+		if current.createdOn.Sub(prev.createdOn) <= maxWindow {
+			history = append(history, prev)
+		}
+	}
+
+	history = append(history, current)
+	d.history[msg.SteamID] = history
+
+	within := func(window StringDuration, matcher func(prev spamMessage) bool) int {
+		var (
+			count    int
+			duration = window.Duration()
+		)
+
+		for _, prev := range history {
+			if current.createdOn.Sub(prev.createdOn) <= duration && matcher(prev) {
+				count++
+			}
+		}
+
+		return count
+	}
+
+	var kind spamKind
+
+	switch {
+	case limits.RateLimit > 0 && within(limits.RateWindow, func(_ spamMessage) bool {
+		return true
+	}) > limits.RateLimit:
+		kind = spamRate
+	case limits.RepeatLimit > 0 && current.body != "" && within(limits.RepeatWindow, func(prev spamMessage) bool {
+		return similarity(prev.body, current.body) >= limits.RepeatSimilarity
+	}) >= limits.RepeatLimit:
+		kind = spamRepeat
+	case limits.BindLimit > 0 && current.body != "" && d.countRounds(history, current, limits.BindWindow) >= limits.BindLimit:
+		kind = spamBind
+	case limits.CapsLimit > 0 && current.caps && within(limits.CapsWindow, func(prev spamMessage) bool {
+		return prev.caps
+	}) >= limits.CapsLimit:
+		kind = spamCaps
+	default:
+		return "", false
+	}
+
+	delete(d.history, msg.SteamID)
+
+	return kind, true
+}
+
+// countRounds returns the number of distinct rounds that the current message was sent in.
+func (d *spamDetector) countRounds(history []spamMessage, current spamMessage, window StringDuration) int {
+	var (
+		rounds   = map[int]bool{}
+		duration = window.Duration()
+	)
+
+	for _, prev := range history {
+		if current.createdOn.Sub(prev.createdOn) <= duration && prev.body == current.body {
+			rounds[prev.round] = true
+		}
+	}
+
+	return len(rounds)
+}
+
+// isCapsMessage checks if the ratio of upper case letters meets the threshold. Messages with fewer letters than
+// minLength are never considered all-caps.
+func isCapsMessage(body string, minLength int, ratio float64) bool {
+	var letters, upper int
+
+	for _, r := range body {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+
+		letters++
+
+		if unicode.IsUpper(r) {
+			upper++
+		}
+	}
+
+	if letters == 0 || letters < minLength {
+		return false
+	}
+
+	return float64(upper)/float64(letters) >= ratio
+}
+
+// similarity returns a ratio between 0 and 1 of how similar two strings are, based on their levenshtein distance.
+func similarity(a string, b string) float64 {
+	if a == b {
+		return 1
+	}
+
+	runesA, runesB := []rune(a), []rune(b)
+
+	maxLen := len(runesA)
+	if len(runesB) > maxLen {
+		maxLen = len(runesB)
+	}
+
+	if maxLen == 0 {
+		return 1
+	}
+
+	prev := make([]int, len(runesB)+1)
+	curr := make([]int, len(runesB)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(runesA); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(runesB); j++ {
+			cost := 1
+			if runesA[i-1] == runesB[j-1] {
+				cost = 0
+			}
+
+			curr[j] = prev[j] + 1
+			if curr[j-1]+1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+
+			if prev[j-1]+cost < curr[j] {
+				curr[j] = prev[j-1] + cost
+			}
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return 1 - float64(prev[len(runesB)])/float64(maxLen)
+}
+
+// spamGag temporarily silences a player, removing the gag once the duration has elapsed.
+func (app *App) spamGag(ctx context.Context, msg store.PersonMessage, duration time.Duration) {
+	log := app.log.Named("spamGag")
+
+	if errSilence := app.Silence(ctx, store.System, msg.SteamID, app.conf.General.Owner, store.Spam); errSilence != nil {
+		log.Error("Failed to gag spammer", zap.Error(errSilence), zap.Int64("sid64", msg.SteamID.Int64()))
+
+		return
+	}
+
+	app.mc.spamGagCounter.With(prometheus.Labels{"server_name": msg.ServerName}).Inc()
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(duration):
+	}
+
+	if errExec := app.OnFindExec(ctx, findOpts{SteamID: msg.SteamID}, func(info playerServerInfo) string {
+		return fmt.Sprintf(`sm_unsilence "#%s"`, steamid.SID64ToSID(msg.SteamID))
+	}); errExec != nil {
+		log.Debug("Failed to remove spam gag", zap.Error(errExec), zap.Int64("sid64", msg.SteamID.Int64()))
+	}
+}
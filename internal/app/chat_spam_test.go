@@ -0,0 +1,142 @@
+package app // nolint:testpackage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpamDetector(t *testing.T) {
+	conf := spamConfig{
+		Enabled: true,
+		Defaults: spamThresholds{
+			RateLimit:        4,
+			RateWindow:       "5s",
+			RepeatLimit:      3,
+			RepeatWindow:     "30s",
+			RepeatSimilarity: 0.8,
+			CapsLimit:        2,
+			CapsWindow:       "1m",
+			CapsMinLength:    6,
+			CapsRatio:        0.8,
+			BindLimit:        3,
+			BindWindow:       "30m",
+			GagDuration:      "1m",
+		},
+		Servers: map[string]spamThresholds{
+			"relaxed": {RateLimit: 10, RepeatLimit: -1},
+		},
+	}
+
+	var (
+		start = time.Now()
+		sid   = steamid.New(76561198084134025)
+	)
+
+	newMsg := func(server string, body string, offset time.Duration) store.PersonMessage {
+		return store.PersonMessage{SteamID: sid, ServerName: server, ServerID: 1, Body: body, CreatedOn: start.Add(offset)}
+	}
+
+	t.Run("rate", func(t *testing.T) {
+		detector := newSpamDetector(conf)
+		for i, body := range []string{"push cart", "medic pls", "sentry up top", "nice shot"} {
+			_, found := detector.check(newMsg("test", body, time.Duration(i)*time.Second))
+			require.False(t, found)
+		}
+
+		kind, found := detector.check(newMsg("test", "another", time.Second*4))
+		require.True(t, found)
+		require.Equal(t, spamRate, kind)
+
+		// Outside the window
+		_, found = detector.check(newMsg("test", "later", time.Minute))
+		require.False(t, found)
+	})
+
+	t.Run("repeat", func(t *testing.T) {
+		detector := newSpamDetector(conf)
+		_, found := detector.check(newMsg("test", "buy cheap items at example", 0))
+		require.False(t, found)
+		_, found = detector.check(newMsg("test", "buy  cheap itemss at example!", time.Second*10))
+		require.False(t, found)
+
+		kind, found := detector.check(newMsg("test", "BUY cheap items at exampl", time.Second*20))
+		require.True(t, found)
+		require.Equal(t, spamRepeat, kind)
+	})
+
+	t.Run("caps", func(t *testing.T) {
+		detector := newSpamDetector(conf)
+		_, found := detector.check(newMsg("test", "HELLO EVERYONE", 0))
+		require.False(t, found)
+		_, found = detector.check(newMsg("test", "OK", time.Second*10))
+		require.False(t, found)
+
+		kind, found := detector.check(newMsg("test", "WHY IS NOBODY ON POINT", time.Second*20))
+		require.True(t, found)
+		require.Equal(t, spamCaps, kind)
+	})
+
+	t.Run("bind", func(t *testing.T) {
+		detector := newSpamDetector(conf)
+		for round := 0; round < 2; round++ {
+			_, found := detector.check(newMsg("test", "gg ez", time.Duration(round)*time.Minute*5))
+			require.False(t, found)
+			detector.roundStarted(1)
+		}
+
+		kind, found := detector.check(newMsg("test", "gg ez", time.Minute*10))
+		require.True(t, found)
+		require.Equal(t, spamBind, kind)
+	})
+
+	t.Run("server_override", func(t *testing.T) {
+		detector := newSpamDetector(conf)
+		for i := 0; i < 6; i++ {
+			_, found := detector.check(newMsg("relaxed", "same message", time.Duration(i)*time.Millisecond*500))
+			require.False(t, found)
+		}
+
+		limits := detector.thresholds("relaxed")
+		require.Equal(t, 10, limits.RateLimit)
+		require.Equal(t, conf.Defaults.CapsLimit, limits.CapsLimit)
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		pruneConf := conf
+		pruneConf.Servers = map[string]spamThresholds{"slow": {BindWindow: "2h"}}
+
+		detector := newSpamDetector(pruneConf)
+		_, found := detector.check(newMsg("slow", "gg ez", 0))
+		require.False(t, found)
+
+		// Past every default window, but still within the override
+		detector.prune(start.Add(time.Hour))
+		require.Len(t, detector.history[sid], 1)
+
+		detector.prune(start.Add(time.Hour * 3))
+		require.Empty(t, detector.history)
+	})
+}
+
+func TestIsCapsMessage(t *testing.T) {
+	require.True(t, isCapsMessage("STOP FEEDING!!", 6, 0.8))
+	require.False(t, isCapsMessage("GG", 6, 0.8))
+	require.False(t, isCapsMessage("Stop Feeding Please", 6, 0.8))
+}
+
+func TestWarningDry(t *testing.T) {
+	app := App{conf: &Config{Filter: filterConfig{Dry: true}, Spam: spamConfig{Dry: false}}}
+
+	require.False(t, app.warningDry(store.Spam))
+	require.True(t, app.warningDry(store.Language))
+
+	app.conf.Spam.Dry = true
+	app.conf.Filter.Dry = false
+
+	require.True(t, app.warningDry(store.Spam))
+	require.False(t, app.warningDry(store.Language))
+}
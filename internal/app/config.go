@@ -20,9 +20,56 @@ import (
 )
 
 type filterConfig struct {
-	Enabled     bool `mapstructure:"enabled"`
-	Dry         bool `mapstructure:"dry"`
-	PingDiscord bool `mapstructure:"ping_discord"`
+	Enabled     bool               `mapstructure:"enabled"`
+	Dry         bool               `mapstructure:"dry"`
+	PingDiscord bool               `mapstructure:"ping_discord"`
+	Name        filterTargetConfig `mapstructure:"name"`
+	Profile     filterTargetConfig `mapstructure:"profile"`
+}
+
+// filterTargetConfig defines how matches against a non-chat filter target, such as player names or
+// steam profiles, are handled.
+type filterTargetConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Action to take when matched. One of warn, kick, gag, ban.
+	Action Action `mapstructure:"action"`
+	// Message shown to the player, used as the kick message & ban reason text
+	Message string `mapstructure:"message"`
+	// Duration used for gag & ban actions
+	Duration StringDuration `mapstructure:"duration"`
+}
+
+// spamThresholds defines the limits used to detect chat spam. Limits <= 0 disable the associated check.
+type spamThresholds struct {
+	// Max messages allowed within the rate window
+	RateLimit  int            `mapstructure:"rate_limit"`
+	RateWindow StringDuration `mapstructure:"rate_window"`
+	// Number of identical or near-identical messages within the repeat window to trigger
+	RepeatLimit  int            `mapstructure:"repeat_limit"`
+	RepeatWindow StringDuration `mapstructure:"repeat_window"`
+	// Similarity ratio (0-1) used to consider two messages near-identical
+	RepeatSimilarity float64 `mapstructure:"repeat_similarity"`
+	// Number of all-caps messages within the caps window to trigger
+	CapsLimit  int            `mapstructure:"caps_limit"`
+	CapsWindow StringDuration `mapstructure:"caps_window"`
+	// Minimum number of letters for a message to be considered for caps checks
+	CapsMinLength int `mapstructure:"caps_min_length"`
+	// Ratio (0-1) of upper case letters for a message to be considered all-caps
+	CapsRatio float64 `mapstructure:"caps_ratio"`
+	// Number of distinct rounds the same message must be sent in, within the bind window, to trigger
+	BindLimit  int            `mapstructure:"bind_limit"`
+	BindWindow StringDuration `mapstructure:"bind_window"`
+	// How long to gag players that trigger a spam check
+	GagDuration StringDuration `mapstructure:"gag_duration"`
+}
+
+type spamConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Only log & record metrics, do not warn or gag players
+	Dry      bool           `mapstructure:"dry"`
+	Defaults spamThresholds `mapstructure:"defaults"`
+	// Per-server overrides keyed by the server short name. Unset values use the defaults.
+	Servers map[string]spamThresholds `mapstructure:"servers"`
 }
 
 // Config is the root config container
@@ -31,15 +78,23 @@ type filterConfig struct {
 //	export general.steam_key=STEAM_KEY_STEAM_KEY_STEAM_KEY
 //	./gbans serve
 type Config struct {
-	General generalConfig `mapstructure:"general"`
-	HTTP    httpConfig    `mapstructure:"http"`
-	Filter  filterConfig  `mapstructure:"word_filter"`
-	DB      dbConfig      `mapstructure:"database"`
-	Discord discordConfig `mapstructure:"discord"`
-	Log     LogConfig     `mapstructure:"logging"`
-	NetBans netBans       `mapstructure:"network_bans"`
-	Debug   debugConfig   `mapstructure:"debug"`
-	Patreon patreonConfig `mapstructure:"patreon"`
+	General     generalConfig     `mapstructure:"general"`
+	HTTP        httpConfig        `mapstructure:"http"`
+	Filter      filterConfig      `mapstructure:"word_filter"`
+	Spam        spamConfig        `mapstructure:"spam"`
+	DB          dbConfig          `mapstructure:"database"`
+	Discord     discordConfig     `mapstructure:"discord"`
+	Log         LogConfig         `mapstructure:"logging"`
+	NetBans     netBans           `mapstructure:"network_bans"`
+	Debug       debugConfig       `mapstructure:"debug"`
+	Patreon     patreonConfig     `mapstructure:"patreon"`
+	Erasure     erasureConfig     `mapstructure:"erasure"`
+	Health      healthConfig      `mapstructure:"health"`
+	Console     consoleConfig     `mapstructure:"console"`
+	Match       matchConfig       `mapstructure:"match"`
+	Rating      ratingConfig      `mapstructure:"rating"`
+	Anomaly     anomalyConfig     `mapstructure:"anomaly"`
+	Leaderboard leaderboardConfig `mapstructure:"leaderboard"`
 }
 
 type dbConfig struct {
@@ -48,6 +103,93 @@ type dbConfig struct {
 	LogQueries  bool   `mapstructure:"log_queries"`
 }
 
+type erasureConfig struct {
+	// Retention is how long ban evidence & other legally required items belonging to an erased player
+	// are held before they are also erased.
+	Retention StringDuration `mapstructure:"retention"`
+}
+
+type healthConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Discord channel to send alerts to, defaults to the log channel when empty.
+	AlertChannelID string `mapstructure:"alert_channel_id"`
+	// How long a server can go without a successful status update before it is considered down.
+	DownAfter StringDuration `mapstructure:"down_after"`
+	// Minimum time between repeated alerts of the same kind for a server.
+	AlertDebounce StringDuration `mapstructure:"alert_debounce"`
+	// Number of times a server must go down within the crash loop window to be considered crash looping.
+	CrashLoopCount  int            `mapstructure:"crash_loop_count"`
+	CrashLoopWindow StringDuration `mapstructure:"crash_loop_window"`
+	// Average player count at which a server is considered busy. Busy servers dropping to zero players
+	// trigger an alert. 0 disables the check.
+	BusyPlayers int `mapstructure:"busy_players"`
+	// How long a server can stay on the same map before it is considered stale. 0 disables the check.
+	StaleMap StringDuration `mapstructure:"stale_map"`
+}
+
+type consoleConfig struct {
+	// Commands that cannot be executed through the web console by anyone.
+	BlockedCommands []string `mapstructure:"blocked_commands"`
+}
+
+type matchConfig struct {
+	// How often the log lines of in progress matches are written to the database.
+	CheckpointInterval StringDuration `mapstructure:"checkpoint_interval"`
+	// How long an in progress match can go without receiving events before it's expired. Matches interrupted
+	// by a restart can be resumed if the server sends events for the same map within this window.
+	ResumeWindow StringDuration `mapstructure:"resume_window"`
+	// How long closed checkpoints are kept before being deleted.
+	CheckpointRetention StringDuration `mapstructure:"checkpoint_retention"`
+}
+
+type ratingConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Glicko-2 system constant, smaller values limit how quickly a players volatility can change.
+	Tau float64 `mapstructure:"tau"`
+	// Minimum number of rated players in the match, across both teams.
+	MinPlayers int `mapstructure:"min_players"`
+	// Maximum difference between the number of rated players on each team.
+	MaxTeamDifference int `mapstructure:"max_team_difference"`
+	// Minimum length of a match for it to be rated.
+	MinDuration StringDuration `mapstructure:"min_duration"`
+	// Fraction of the match a player must have been present for to be rated.
+	MinParticipation float64 `mapstructure:"min_participation"`
+	// Number of rated matches a player needs before they are shown on leaderboards.
+	LeaderboardMinMatches int `mapstructure:"leaderboard_min_matches"`
+}
+
+type anomalyConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// File a cheating report against players with new anomalies instead of only adding them to the queue.
+	AutoReport bool `mapstructure:"auto_report"`
+	// Number of standard deviations above the population baseline a stat must be to be flagged.
+	MinScore float64 `mapstructure:"min_score"`
+	// Minimum shots fired with a weapon in a match before its accuracy is checked.
+	MinShots int `mapstructure:"min_shots"`
+	// Minimum kills with a weapon in a match before its headshot ratio and kill distance are checked.
+	MinKills int `mapstructure:"min_kills"`
+	// Minimum samples across all matches before a weapons baseline is considered reliable.
+	MinBaselineSamples int `mapstructure:"min_baseline_samples"`
+	// How often the population baselines are recalculated.
+	BaselineRefresh StringDuration `mapstructure:"baseline_refresh"`
+}
+
+type leaderboardConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Date the first season started on, in YYYY-MM-DD format.
+	SeasonStart string `mapstructure:"season_start"`
+	// Number of days each season lasts.
+	SeasonDays int `mapstructure:"season_days"`
+	// How long the daily leaderboards are kept for, the other periods are kept forever.
+	DailyRetention StringDuration `mapstructure:"daily_retention"`
+	// Post the winners of the previous week to discord.
+	AnnounceWeekly bool `mapstructure:"announce_weekly"`
+	// Channel to post the weekly winners to, uses the public log channel when empty.
+	AnnounceChannelID string `mapstructure:"announce_channel_id"`
+	// Number of players announced for each leaderboard.
+	AnnounceCount int `mapstructure:"announce_count"`
+}
+
 type patreonConfig struct {
 	Enabled             bool   `mapstructure:"enabled"`
 	ClientID            string `mapstructure:"client_id"`
@@ -92,6 +234,7 @@ func (rm RunMode) String() string {
 type Action string
 
 const (
+	Warn Action = "warn"
 	Gag  Action = "gag"
 	Kick Action = "kick"
 	Ban  Action = "ban"
@@ -208,9 +351,25 @@ func ReadConfig(conf *Config, noFileOk bool) error {
 		return errors.Wrap(errMaterDuration, "Failed to parse mater_server_status_update_freq")
 	}
 
+	if _, errSeason := time.Parse(seasonStartFormat, conf.Leaderboard.SeasonStart); errSeason != nil {
+		return errors.Wrap(errSeason, "Failed to parse leaderboard.season_start")
+	}
+
+	if conf.Leaderboard.SeasonDays <= 0 {
+		return errors.New("leaderboard.season_days must be greater than 0")
+	}
+
 	return nil
 }
 
+//nolint:gochecknoglobals
+var (
+	// Changing the rcon password or log address would break the connection to gbans.
+	defaultConsoleBlockedCommands = []string{
+		"rcon_password", "quit", "exit", "killserver", "logaddress_del", "logaddress_delall",
+	}
+)
+
 func setDefaultConfigValues() {
 	if home, errHomeDir := homedir.Dir(); errHomeDir != nil {
 		viper.AddConfigPath(home)
@@ -256,6 +415,29 @@ func setDefaultConfigValues() {
 		"filter.enabled":                           false,
 		"filter.dry":                               true,
 		"filter.ping_discord":                      false,
+		"word_filter.name.enabled":                 false,
+		"word_filter.name.action":                  Kick,
+		"word_filter.name.message":                 "Please change your name",
+		"word_filter.name.duration":                "1d",
+		"word_filter.profile.enabled":              false,
+		"word_filter.profile.action":               Warn,
+		"word_filter.profile.message":              "Please change your steam profile name",
+		"word_filter.profile.duration":             "1d",
+		"spam.enabled":                             false,
+		"spam.dry":                                 true,
+		"spam.defaults.rate_limit":                 6,
+		"spam.defaults.rate_window":                "5s",
+		"spam.defaults.repeat_limit":               3,
+		"spam.defaults.repeat_window":              "30s",
+		"spam.defaults.repeat_similarity":          0.85,
+		"spam.defaults.caps_limit":                 3,
+		"spam.defaults.caps_window":                "1m",
+		"spam.defaults.caps_min_length":            8,
+		"spam.defaults.caps_ratio":                 0.8,
+		"spam.defaults.bind_limit":                 4,
+		"spam.defaults.bind_window":                "30m",
+		"spam.defaults.gag_duration":               "5m",
+		"spam.servers":                             map[string]spamThresholds{},
 		"discord.enabled":                          false,
 		"discord.app_id":                           0,
 		"discord.app_secret":                       "",
@@ -269,6 +451,40 @@ func setDefaultConfigValues() {
 		"discord.log_channel_id":                   "",
 		"discord.mod_ping_role_id":                 "",
 		"discord.unregister_on_start":              false,
+		"erasure.retention":                        "1y",
+		"health.enabled":                           true,
+		"health.alert_channel_id":                  "",
+		"health.down_after":                        "90s",
+		"health.alert_debounce":                    "15m",
+		"health.crash_loop_count":                  3,
+		"health.crash_loop_window":                 "30m",
+		"health.busy_players":                      12,
+		"health.stale_map":                         "6h",
+		"console.blocked_commands":                 defaultConsoleBlockedCommands,
+		"match.checkpoint_interval":                "30s",
+		"match.resume_window":                      "10m",
+		"match.checkpoint_retention":               "7d",
+		"rating.enabled":                           true,
+		"rating.tau":                               0.5,
+		"rating.min_players":                       12,
+		"rating.max_team_difference":               2,
+		"rating.min_duration":                      "10m",
+		"rating.min_participation":                 0.5,
+		"rating.leaderboard_min_matches":           10,
+		"leaderboard.enabled":                      true,
+		"leaderboard.season_start":                 "2024-01-01",
+		"leaderboard.season_days":                  91,
+		"leaderboard.daily_retention":              "2160h",
+		"leaderboard.announce_weekly":              true,
+		"leaderboard.announce_channel_id":          "",
+		"leaderboard.announce_count":               3,
+		"anomaly.enabled":                          true,
+		"anomaly.auto_report":                      false,
+		"anomaly.min_score":                        4.0,
+		"anomaly.min_shots":                        100,
+		"anomaly.min_kills":                        10,
+		"anomaly.min_baseline_samples":             1000,
+		"anomaly.baseline_refresh":                 "1h",
 		"network_bans.enabled":                     false,
 		"network_bans.max_age":                     "1d",
 		"network_bans.cache_path":                  ".cache",
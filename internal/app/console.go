@@ -0,0 +1,297 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/leighmacdonald/gbans/internal/consts"
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/leighmacdonald/gbans/pkg/fp"
+	"github.com/leighmacdonald/gbans/pkg/logparse"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	wsMsgTypeConsoleLog             = 3000
+	wsMsgTypeConsoleCommandRequest  = 3001
+	wsMsgTypeConsoleCommandResponse = 3002
+	wsMsgTypeConsoleHistory         = 3003
+
+	consoleHistorySize     = 100
+	consoleSendBufferSize  = 256
+	consoleMaxResponseSize = 16384
+)
+
+var (
+	errConsoleEmptyCommand   = errors.New("Empty command")
+	errConsoleCommandBlocked = errors.New("Command is blocked")
+)
+
+// consoleIndirectCommands execute other commands, which would allow the blocked commands to be run through
+// them. These are always blocked regardless of the configured blocked commands.
+var consoleIndirectCommands = []string{ //nolint:gochecknoglobals
+	"alias", "exec", "sm_rcon", "sm_cvar", "script", "script_execute",
+}
+
+type consoleLogResponse struct {
+	ServerID  int                `json:"server_id"`
+	EventType logparse.EventType `json:"event_type"`
+	Event     any                `json:"event"`
+}
+
+type consoleCommandRequest struct {
+	Command string `json:"command"`
+}
+
+// consoleCommandNames returns the lowercase names of each command in the input. Like the game console,
+// multiple commands can be chained using ; or newlines.
+func consoleCommandNames(input string) []string {
+	var names []string
+
+	for _, part := range strings.FieldsFunc(input, func(r rune) bool { return r == ';' || r == '\n' }) {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+
+		names = append(names, strings.ToLower(strings.Trim(fields[0], `"`)))
+	}
+
+	return names
+}
+
+// consoleCommandAllowed checks that the user is permitted to execute every command within the input. The
+// console is restricted to admins.
+func consoleCommandAllowed(conf consoleConfig, level consts.Privilege, input string) error {
+	names := consoleCommandNames(input)
+	if len(names) == 0 {
+		return errConsoleEmptyCommand
+	}
+
+	if level < consts.PAdmin {
+		return consts.ErrPermissionDenied
+	}
+
+	for _, name := range names {
+		if fp.Contains(conf.BlockedCommands, name) || fp.Contains(consoleIndirectCommands, name) {
+			return errors.Wrap(errConsoleCommandBlocked, name)
+		}
+	}
+
+	return nil
+}
+
+// consoleCommand executes the command on the server if the user has permission. All attempts are recorded
+// in the command history and audit log.
+func (app *App) consoleCommand(ctx context.Context, user userProfile, serverID int, input string) store.ConsoleCommand {
+	command := store.ConsoleCommand{
+		ServerID:    serverID,
+		SteamID:     user.SteamID,
+		PersonaName: user.Name,
+		Command:     strings.TrimSpace(input),
+		CreatedOn:   time.Now(),
+	}
+
+	// Empty commands are not worth recording
+	if len(consoleCommandNames(command.Command)) == 0 {
+		command.Error = errConsoleEmptyCommand.Error()
+
+		return command
+	}
+
+	if errAllowed := consoleCommandAllowed(app.conf.Console, user.PermissionLevel, command.Command); errAllowed != nil {
+		command.Error = errAllowed.Error()
+	} else {
+		command.Allowed = true
+
+		resp, errRcon := app.state.rcon(serverID, command.Command)
+		if errRcon != nil {
+			command.Error = errRcon.Error()
+		}
+
+		if len(resp) > consoleMaxResponseSize {
+			resp = resp[:consoleMaxResponseSize]
+		}
+
+		command.Response = resp
+	}
+
+	if errSave := app.db.SaveConsoleCommand(ctx, &command); errSave != nil {
+		app.log.Error("Failed to save console command", zap.Error(errSave))
+	}
+
+	app.audit(ctx, user.SteamID, "", store.AuditConsoleCommand,
+		fmt.Sprintf("server_id=%d allowed=%t command=%s", serverID, command.Allowed, command.Command))
+
+	return command
+}
+
+// consoleClient is a single websocket connection to a server console.
+type consoleClient struct {
+	socket   *websocket.Conn
+	sendChan chan wsValue
+	dropped  atomic.Int32
+}
+
+// send queues the payload, dropping it if the client is not keeping up with the log stream.
+func (client *consoleClient) send(msgType wsMsgType, status bool, payload any) {
+	select {
+	case client.sendChan <- wsValue{MsgType: msgType, Status: status, Payload: payload}:
+	default:
+		client.dropped.Add(1)
+	}
+}
+
+func (client *consoleClient) writer(ctx context.Context, log *zap.Logger) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case payload := <-client.sendChan:
+			if errSend := client.socket.WriteJSON(payload); errSend != nil {
+				log.Debug("Failed to send console payload", zap.Error(errSend))
+
+				return
+			}
+		}
+	}
+}
+
+// onWSConsole streams the parsed log events of a server and executes rcon commands sent by the client.
+// Only admins can connect, each command is still checked individually against the blocked commands.
+func onWSConsole(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		serverID, errServerID := getIntParam(ctx, "server_id")
+		if errServerID != nil {
+			responseErr(ctx, http.StatusBadRequest, nil)
+
+			return
+		}
+
+		state := app.state.current()
+		if _, found := state.byServerID(serverID); !found {
+			responseErr(ctx, http.StatusNotFound, nil)
+
+			return
+		}
+
+		upgrader := newWebSocketUpgrader()
+
+		conn, errUpgrade := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+		if errUpgrade != nil {
+			log.Error("Failed to upgrade websocket", zap.Error(errUpgrade))
+
+			return
+		}
+
+		var (
+			user                = currentUserProfile(ctx)
+			client              = &consoleClient{socket: conn, sendChan: make(chan wsValue, consoleSendBufferSize)}
+			eventChan           = make(chan logparse.ServerEvent, consoleSendBufferSize)
+			connCtx, cancelConn = context.WithCancel(ctx.Request.Context())
+		)
+
+		defer func() {
+			cancelConn()
+
+			// The broadcaster blocks while sending, so keep draining until unregistered.
+			drained := make(chan bool)
+
+			go func() {
+				for {
+					select {
+					case <-eventChan:
+					case <-drained:
+						return
+					}
+				}
+			}()
+
+			_ = app.eb.Unregister(eventChan)
+			close(drained)
+
+			if errClose := conn.Close(); errClose != nil {
+				log.Debug("Failed to close console connection", zap.Error(errClose))
+			}
+
+			log.Debug("Console client disconnected", zap.Int("server_id", serverID),
+				zap.Int64("sid64", user.SteamID.Int64()), zap.Int32("dropped", client.dropped.Load()))
+		}()
+
+		if errRegister := app.eb.Consume(eventChan); errRegister != nil {
+			log.Error("Failed to register console event consumer", zap.Error(errRegister))
+
+			return
+		}
+
+		go client.writer(connCtx, log)
+
+		go func() {
+			for {
+				select {
+				case <-connCtx.Done():
+					return
+				case event := <-eventChan:
+					if event.ServerID != serverID || event.Results == nil {
+						continue
+					}
+
+					client.send(wsMsgTypeConsoleLog, true, consoleLogResponse{
+						ServerID:  serverID,
+						EventType: event.EventType,
+						Event:     event.Event,
+					})
+				}
+			}
+		}()
+
+		history, errHistory := app.db.GetConsoleCommands(ctx, serverID, consoleHistorySize)
+		if errHistory != nil {
+			log.Error("Failed to load console history", zap.Error(errHistory))
+		} else {
+			client.send(wsMsgTypeConsoleHistory, true, history)
+		}
+
+		log.Debug("Console client connected", zap.Int("server_id", serverID),
+			zap.Int64("sid64", user.SteamID.Int64()))
+
+		type wsRequest struct {
+			MsgType wsMsgType       `json:"msg_type"`
+			Payload json.RawMessage `json:"payload"`
+		}
+
+		for {
+			var request wsRequest
+			if errRead := conn.ReadJSON(&request); errRead != nil {
+				return
+			}
+
+			if request.MsgType != wsMsgTypeConsoleCommandRequest {
+				client.send(request.MsgType+1, false, wsMsgErrorResponse{Error: "Unhandled message type"})
+
+				continue
+			}
+
+			var commandRequest consoleCommandRequest
+			if errUnmarshal := json.Unmarshal(request.Payload, &commandRequest); errUnmarshal != nil {
+				client.send(wsMsgTypeConsoleCommandResponse, false, wsMsgErrorResponse{Error: "Invalid request"})
+
+				continue
+			}
+
+			command := app.consoleCommand(connCtx, user, serverID, commandRequest.Command)
+			client.send(wsMsgTypeConsoleCommandResponse, command.Error == "", command)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package app // nolint:testpackage
+
+import (
+	"testing"
+
+	"github.com/leighmacdonald/gbans/internal/consts"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsoleCommandNames(t *testing.T) {
+	require.Equal(t, []string{"status"}, consoleCommandNames(" status "))
+	require.Equal(t, []string{"sm_say", "rcon_password"}, consoleCommandNames(`sm_say "hi"; "RCON_PASSWORD" x`))
+	require.Equal(t, []string{"status", "users"}, consoleCommandNames("status\n;;users"))
+	require.Empty(t, consoleCommandNames(" ; \n"))
+}
+
+func TestConsoleCommandAllowed(t *testing.T) {
+	conf := consoleConfig{BlockedCommands: []string{"rcon_password"}}
+
+	require.NoError(t, consoleCommandAllowed(conf, consts.PAdmin, "changelevel pl_upward"))
+	require.NoError(t, consoleCommandAllowed(conf, consts.PAdmin, `sm_kick "player"; status`))
+	require.ErrorIs(t, consoleCommandAllowed(conf, consts.PModerator, "status"), consts.ErrPermissionDenied)
+	require.ErrorIs(t, consoleCommandAllowed(conf, consts.PUser, "status"), consts.ErrPermissionDenied)
+	require.ErrorIs(t, consoleCommandAllowed(conf, consts.PAdmin, "status;rcon_password new"),
+		errConsoleCommandBlocked)
+	// Commands which execute other commands cannot be used to reach the blocked commands
+	require.ErrorIs(t, consoleCommandAllowed(conf, consts.PAdmin, `alias x "rcon_password new"; x`),
+		errConsoleCommandBlocked)
+	require.ErrorIs(t, consoleCommandAllowed(conf, consts.PAdmin, "EXEC server.cfg"), errConsoleCommandBlocked)
+	require.ErrorIs(t, consoleCommandAllowed(conf, consts.PAdmin, "sm_rcon rcon_password new"),
+		errConsoleCommandBlocked)
+	require.ErrorIs(t, consoleCommandAllowed(conf, consts.PAdmin, " "), errConsoleEmptyCommand)
+}
@@ -0,0 +1,465 @@
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/leighmacdonald/gbans/internal/consts"
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// dataExportRetention is how long completed archives are kept before being pruned.
+	dataExportRetention = time.Hour * 24 * 7
+	// dataExportCooldown is the minimum time between export requests for a player.
+	dataExportCooldown = time.Hour * 24
+	dataExportPageSize = 1000
+)
+
+var errDataExportCooldown = errors.New("Export already requested recently")
+
+// dataExportArchive contains everything we hold that is tied to a players steam id.
+type dataExportArchive struct {
+	CreatedOn       time.Time                            `json:"created_on"`
+	Person          store.Person                         `json:"person"`
+	Connections     []store.QueryConnectionHistoryResult `json:"connections"`
+	Messages        []store.QueryChatHistoryResult       `json:"messages"`
+	ReportsFiled    []store.Report                       `json:"reports_filed"`
+	ReportsReceived []dataExportReport                   `json:"reports_received"`
+	Bans            []dataExportBan                      `json:"bans"`
+	BanMessages     []store.UserMessage                  `json:"ban_messages"`
+	Matches         []store.MatchSummary                 `json:"matches"`
+	Stats           store.PlayerStats                    `json:"stats"`
+	ClassStats      store.PlayerClassStatsCollection     `json:"class_stats"`
+	WeaponStats     []store.PlayerWeaponStats            `json:"weapon_stats"`
+	KillstreakStats []store.PlayerKillstreakStats        `json:"killstreak_stats"`
+	MedicStats      []store.PlayerMedicStats             `json:"medic_stats"`
+	Notifications   []store.UserNotification             `json:"notifications"`
+	Names           []store.PersonName                   `json:"names"`
+}
+
+// dataExportBan is a ban against the player without the admin only details, the note and who issued it.
+type dataExportBan struct {
+	BanID           int64             `json:"ban_id"`
+	ReportID        int64             `json:"report_id"`
+	BanType         store.BanType     `json:"ban_type"`
+	Reason          store.Reason      `json:"reason"`
+	ReasonText      string            `json:"reason_text"`
+	UnbanReasonText string            `json:"unban_reason_text"`
+	AppealState     store.AppealState `json:"appeal_state"`
+	Deleted         bool              `json:"deleted"`
+	ValidUntil      time.Time         `json:"valid_until"`
+	CreatedOn       time.Time         `json:"created_on"`
+	UpdatedOn       time.Time         `json:"updated_on"`
+}
+
+func newDataExportBan(ban store.BanSteam) dataExportBan {
+	return dataExportBan{
+		BanID:           ban.BanID,
+		ReportID:        ban.ReportID,
+		BanType:         ban.BanType,
+		Reason:          ban.Reason,
+		ReasonText:      ban.ReasonText,
+		UnbanReasonText: ban.UnbanReasonText,
+		AppealState:     ban.AppealState,
+		Deleted:         ban.Deleted,
+		ValidUntil:      ban.ValidUntil,
+		CreatedOn:       ban.CreatedOn,
+		UpdatedOn:       ban.UpdatedOn,
+	}
+}
+
+// dataExportReport is a report filed against the player. The reporter, their description and any free
+// text reason are never included.
+type dataExportReport struct {
+	ReportID     int64              `json:"report_id"`
+	ReportStatus store.ReportStatus `json:"report_status"`
+	Reason       store.Reason       `json:"reason"`
+	CreatedOn    time.Time          `json:"created_on"`
+	UpdatedOn    time.Time          `json:"updated_on"`
+}
+
+func newDataExportReport(report store.Report) dataExportReport {
+	return dataExportReport{
+		ReportID:     report.ReportID,
+		ReportStatus: report.ReportStatus,
+		Reason:       report.Reason,
+		CreatedOn:    report.CreatedOn,
+		UpdatedOn:    report.UpdatedOn,
+	}
+}
+
+func collectDataExport(ctx context.Context, database *store.Store, steamID steamid.SID64) (dataExportArchive, error) {
+	archive := dataExportArchive{CreatedOn: time.Now()}
+
+	if errPerson := database.GetPersonBySteamID(ctx, steamID, &archive.Person); errPerson != nil {
+		return archive, errors.Wrap(errPerson, "Failed to load person")
+	}
+
+	for offset := uint64(0); ; offset += dataExportPageSize {
+		connections, _, errConns := database.QueryConnectionHistory(ctx, store.ConnectionHistoryQueryFilter{
+			QueryFilter: store.QueryFilter{Offset: offset, Limit: dataExportPageSize, OrderBy: "person_connection_id"},
+			SteamID:     steamID,
+		})
+		if errConns != nil && !errors.Is(errConns, store.ErrNoResult) {
+			return archive, errors.Wrap(errConns, "Failed to load connections")
+		}
+
+		archive.Connections = append(archive.Connections, connections...)
+
+		if len(connections) < dataExportPageSize {
+			break
+		}
+	}
+
+	var cursor string
+
+	for {
+		messages, _, errMessages := database.QueryChatHistory(ctx, store.ChatHistoryQueryFilter{
+			QueryFilter:   store.QueryFilter{Limit: dataExportPageSize, OrderBy: "person_message_id"},
+			SteamID:       steamID.String(),
+			Unrestricted:  true,
+			DontCalcTotal: true,
+			Cursor:        cursor,
+		})
+		if errMessages != nil && !errors.Is(errMessages, store.ErrNoResult) {
+			return archive, errors.Wrap(errMessages, "Failed to load messages")
+		}
+
+		archive.Messages = append(archive.Messages, messages...)
+
+		if len(messages) < dataExportPageSize {
+			break
+		}
+
+		cursor = store.NewChatHistoryCursor(messages[len(messages)-1]).Encode()
+	}
+
+	reportsFiled, errFiled := database.GetReports(ctx, store.AuthorQueryFilter{AuthorID: steamID})
+	if errFiled != nil && !errors.Is(errFiled, store.ErrNoResult) {
+		return archive, errors.Wrap(errFiled, "Failed to load filed reports")
+	}
+
+	archive.ReportsFiled = reportsFiled
+
+	reportsReceived, errReceived := database.GetReports(ctx, store.AuthorQueryFilter{TargetID: steamID})
+	if errReceived != nil && !errors.Is(errReceived, store.ErrNoResult) {
+		return archive, errors.Wrap(errReceived, "Failed to load received reports")
+	}
+
+	for _, report := range reportsReceived {
+		archive.ReportsReceived = append(archive.ReportsReceived, newDataExportReport(report))
+	}
+
+	bans, errBans := database.GetBansSteam(ctx, store.BansQueryFilter{
+		QueryFilter: store.QueryFilter{Deleted: true},
+		SteamID:     steamID,
+	})
+	if errBans != nil && !errors.Is(errBans, store.ErrNoResult) {
+		return archive, errors.Wrap(errBans, "Failed to load bans")
+	}
+
+	for _, ban := range bans {
+		archive.Bans = append(archive.Bans, newDataExportBan(ban.Ban))
+
+		messages, errBanMessages := database.GetBanMessages(ctx, ban.Ban.BanID)
+		if errBanMessages != nil && !errors.Is(errBanMessages, store.ErrNoResult) {
+			return archive, errors.Wrap(errBanMessages, "Failed to load ban messages")
+		}
+
+		archive.BanMessages = append(archive.BanMessages, messages...)
+	}
+
+	matches, _, errMatches := database.Matches(ctx, store.MatchesQueryOpts{SteamID: steamID})
+	if errMatches != nil && !errors.Is(errMatches, store.ErrNoResult) {
+		return archive, errors.Wrap(errMatches, "Failed to load matches")
+	}
+
+	archive.Matches = matches
+
+	if errStats := database.PlayerStats(ctx, steamID, &archive.Stats); errStats != nil && !errors.Is(errStats, store.ErrNoResult) {
+		return archive, errors.Wrap(errStats, "Failed to load player stats")
+	}
+
+	var errStats error
+
+	if archive.ClassStats, errStats = database.StatsPlayerClass(ctx, steamID); errStats != nil && !errors.Is(errStats, store.ErrNoResult) {
+		return archive, errors.Wrap(errStats, "Failed to load class stats")
+	}
+
+	if archive.WeaponStats, errStats = database.StatsPlayerWeapons(ctx, steamID); errStats != nil && !errors.Is(errStats, store.ErrNoResult) {
+		return archive, errors.Wrap(errStats, "Failed to load weapon stats")
+	}
+
+	if archive.KillstreakStats, errStats = database.StatsPlayerKillstreaks(ctx, steamID); errStats != nil && !errors.Is(errStats, store.ErrNoResult) {
+		return archive, errors.Wrap(errStats, "Failed to load killstreak stats")
+	}
+
+	if archive.MedicStats, errStats = database.StatsPlayerMedic(ctx, steamID); errStats != nil && !errors.Is(errStats, store.ErrNoResult) {
+		return archive, errors.Wrap(errStats, "Failed to load medic stats")
+	}
+
+	notifications, errNotifications := database.GetPersonNotifications(ctx, steamID)
+	if errNotifications != nil && !errors.Is(errNotifications, store.ErrNoResult) {
+		return archive, errors.Wrap(errNotifications, "Failed to load notifications")
+	}
+
+	archive.Notifications = notifications
+
+	names, errNames := database.GetPersonNames(ctx, steamID)
+	if errNames != nil && !errors.Is(errNames, store.ErrNoResult) {
+		return archive, errors.Wrap(errNames, "Failed to load name history")
+	}
+
+	archive.Names = names
+
+	return archive, nil
+}
+
+// writeDataExportZip writes the archive as a zip containing a single data.json with everything, along
+// with a csv file for each section.
+func writeDataExportZip(output io.Writer, archive dataExportArchive) error {
+	zipWriter := zip.NewWriter(output)
+
+	jsonWriter, errCreate := zipWriter.Create("data.json")
+	if errCreate != nil {
+		return errors.Wrap(errCreate, "Failed to create json file")
+	}
+
+	encoder := json.NewEncoder(jsonWriter)
+	encoder.SetIndent("", "  ")
+
+	if errEncode := encoder.Encode(archive); errEncode != nil {
+		return errors.Wrap(errEncode, "Failed to encode json")
+	}
+
+	for _, section := range []struct {
+		name string
+		rows any
+	}{
+		{"person", []store.Person{archive.Person}},
+		{"connections", archive.Connections},
+		{"messages", archive.Messages},
+		{"reports_filed", archive.ReportsFiled},
+		{"reports_received", archive.ReportsReceived},
+		{"bans", archive.Bans},
+		{"ban_messages", archive.BanMessages},
+		{"matches", archive.Matches},
+		{"stats", []store.PlayerStats{archive.Stats}},
+		{"class_stats", archive.ClassStats},
+		{"weapon_stats", archive.WeaponStats},
+		{"killstreak_stats", archive.KillstreakStats},
+		{"medic_stats", archive.MedicStats},
+		{"notifications", archive.Notifications},
+		{"names", archive.Names},
+	} {
+		csvWriter, errCreateCSV := zipWriter.Create(section.name + ".csv")
+		if errCreateCSV != nil {
+			return errors.Wrapf(errCreateCSV, "Failed to create %s.csv", section.name)
+		}
+
+		if errWrite := writeCSVRows(csvWriter, section.rows); errWrite != nil {
+			return errors.Wrapf(errWrite, "Failed to write %s.csv", section.name)
+		}
+	}
+
+	return errors.Wrap(zipWriter.Close(), "Failed to finalise zip")
+}
+
+// writeCSVRows writes a slice of structs as csv. The columns are derived from the json field names so the
+// csv and json files are consistent. Nested values are written as json.
+func writeCSVRows(output io.Writer, rows any) error {
+	body, errMarshal := json.Marshal(rows)
+	if errMarshal != nil {
+		return errors.Wrap(errMarshal, "Failed to marshal rows")
+	}
+
+	var records []map[string]any
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	if errDecode := decoder.Decode(&records); errDecode != nil {
+		return errors.Wrap(errDecode, "Failed to decode rows")
+	}
+
+	columnSet := map[string]bool{}
+
+	for _, record := range records {
+		for key := range record {
+			columnSet[key] = true
+		}
+	}
+
+	columns := make([]string, 0, len(columnSet))
+	for column := range columnSet {
+		columns = append(columns, column)
+	}
+
+	sort.Strings(columns)
+
+	writer := csv.NewWriter(output)
+
+	if errHeader := writer.Write(columns); errHeader != nil {
+		return errors.Wrap(errHeader, "Failed to write header")
+	}
+
+	for _, record := range records {
+		row := make([]string, len(columns))
+
+		for index, column := range columns {
+			switch value := record[column].(type) {
+			case nil:
+			case string:
+				row[index] = value
+			case json.Number:
+				row[index] = value.String()
+			case bool:
+				row[index] = strconv.FormatBool(value)
+			default:
+				nested, errNested := json.Marshal(value)
+				if errNested != nil {
+					return errors.Wrap(errNested, "Failed to marshal nested value")
+				}
+
+				row[index] = string(nested)
+			}
+		}
+
+		if errRow := writer.Write(row); errRow != nil {
+			return errors.Wrap(errRow, "Failed to write row")
+		}
+	}
+
+	writer.Flush()
+
+	return errors.Wrap(writer.Error(), "Failed to flush csv")
+}
+
+// RequestDataExport queues a new data export for the player. Only one export may be requested within
+// the cooldown period.
+func (app *App) RequestDataExport(ctx context.Context, steamID steamid.SID64) (store.DataExport, error) {
+	existing, errExisting := app.db.GetDataExports(ctx, steamID)
+	if errExisting != nil && !errors.Is(errExisting, store.ErrNoResult) {
+		return store.DataExport{}, errors.Wrap(errExisting, "Failed to load existing exports")
+	}
+
+	for _, export := range existing {
+		if export.Status != store.DataExportFailed && time.Since(export.CreatedOn) < dataExportCooldown {
+			return export, errDataExportCooldown
+		}
+	}
+
+	export := store.NewDataExport(steamID)
+	if errSave := app.db.SaveDataExport(ctx, &export); errSave != nil {
+		return export, errors.Wrap(errSave, "Failed to save export request")
+	}
+
+	select {
+	case app.dataExportTrigger <- true:
+	default:
+		// A run is already queued and will pick this export up
+	}
+
+	return export, nil
+}
+
+func (app *App) runDataExport(ctx context.Context, export store.DataExport) error {
+	export.Status = store.DataExportRunning
+	if errSave := app.db.SaveDataExport(ctx, &export); errSave != nil {
+		return errors.Wrap(errSave, "Failed to update export status")
+	}
+
+	var (
+		buffer       bytes.Buffer
+		notification = NotificationPayload{
+			Sids:     steamid.Collection{export.SteamID},
+			Severity: consts.SeverityInfo,
+			Message:  "Your data export is ready to download",
+			Link:     app.ExtURLRaw("/settings"),
+		}
+	)
+
+	archive, errCollect := collectDataExport(ctx, app.db, export.SteamID)
+	if errCollect == nil {
+		errCollect = writeDataExportZip(&buffer, archive)
+	}
+
+	if errCollect != nil {
+		export.Status = store.DataExportFailed
+		export.Error = "Failed to create export"
+		notification.Severity = consts.SeverityError
+		notification.Message = "Your data export failed, please try again later"
+	} else {
+		export.Status = store.DataExportComplete
+		export.Contents = buffer.Bytes()
+	}
+
+	if errSave := app.db.SaveDataExport(ctx, &export); errSave != nil {
+		return errors.Wrap(errSave, "Failed to save export")
+	}
+
+	if errNotify := app.SendNotification(ctx, notification); errNotify != nil {
+		app.log.Error("Failed to send export notification", zap.Error(errNotify))
+	}
+
+	return errCollect
+}
+
+// dataExportWorker builds any outstanding data exports. Exports that were interrupted by a restart are
+// picked up again on the next run.
+func (app *App) dataExportWorker(ctx context.Context) {
+	var (
+		log    = app.log.Named("dataExport")
+		ticker = time.NewTicker(time.Minute * 5)
+	)
+
+	process := func() {
+		exports, errExports := app.db.GetIncompleteDataExports(ctx)
+		if errExports != nil && !errors.Is(errExports, store.ErrNoResult) {
+			log.Error("Failed to load pending data exports", zap.Error(errExports))
+
+			return
+		}
+
+		for _, export := range exports {
+			if errRun := app.runDataExport(ctx, export); errRun != nil {
+				log.Error("Failed to create data export", zap.Error(errRun),
+					zap.Int64("data_export_id", export.DataExportID),
+					zap.Int64("sid64", export.SteamID.Int64()))
+			}
+		}
+
+		if errPrune := app.db.PruneDataExports(ctx, time.Now().Add(-dataExportRetention)); errPrune != nil {
+			log.Error("Failed to prune data exports", zap.Error(errPrune))
+		}
+	}
+
+	process()
+
+	for {
+		select {
+		case <-ticker.C:
+			process()
+		case <-app.dataExportTrigger:
+			process()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func dataExportFilename(export store.DataExport) string {
+	return fmt.Sprintf("gbans-export-%d-%s.zip", export.SteamID.Int64(), export.CreatedOn.Format("20060102"))
+}
@@ -0,0 +1,120 @@
+package app // nolint:testpackage
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"testing"
+
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteCSVRows(t *testing.T) {
+	type row struct {
+		Name   string         `json:"name"`
+		Count  int64          `json:"count"`
+		Active bool           `json:"active"`
+		Extra  map[string]int `json:"extra"`
+	}
+
+	var buf bytes.Buffer
+
+	require.NoError(t, writeCSVRows(&buf, []row{
+		{Name: "a", Count: 76561198084134025, Active: true, Extra: map[string]int{"x": 1}},
+		{Name: "b, c", Count: 2},
+	}))
+
+	records, errRead := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, errRead)
+	require.Equal(t, [][]string{
+		{"active", "count", "extra", "name"},
+		{"true", "76561198084134025", `{"x":1}`, "a"},
+		{"false", "2", "", "b, c"},
+	}, records)
+}
+
+func TestWriteDataExportZip(t *testing.T) {
+	sid := steamid.New(76561198084134025)
+	archive := dataExportArchive{
+		Person:   store.NewPerson(sid),
+		Messages: []store.QueryChatHistoryResult{{PersonMessage: store.PersonMessage{SteamID: sid, Body: "hello"}}},
+	}
+
+	var buf bytes.Buffer
+
+	require.NoError(t, writeDataExportZip(&buf, archive))
+
+	reader, errReader := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, errReader)
+
+	files := map[string]*zip.File{}
+	for _, file := range reader.File {
+		files[file.Name] = file
+	}
+
+	require.Contains(t, files, "data.json")
+	require.Contains(t, files, "person.csv")
+	require.Contains(t, files, "messages.csv")
+
+	messages, errOpen := files["messages.csv"].Open()
+	require.NoError(t, errOpen)
+
+	body, errBody := io.ReadAll(messages)
+	require.NoError(t, errBody)
+	require.Contains(t, string(body), "hello")
+}
+
+func TestDataExportRedaction(t *testing.T) {
+	var (
+		target   = steamid.New(76561198084134025)
+		admin    = steamid.New(76561198000000001)
+		reporter = steamid.New(76561198000000002)
+	)
+
+	ban := store.BanSteam{BanID: 5}
+	ban.TargetID = target
+	ban.SourceID = admin
+	ban.Reason = store.Cheating
+	ban.ReasonText = "aimbot"
+	ban.Note = "alt of a known cheater"
+
+	report := store.Report{
+		ReportID:    7,
+		SourceID:    reporter,
+		TargetID:    target,
+		Description: "saw them spinbotting",
+		Reason:      store.Custom,
+		ReasonText:  "spinbot",
+	}
+
+	archive := dataExportArchive{
+		Person:          store.NewPerson(target),
+		Bans:            []dataExportBan{newDataExportBan(ban)},
+		ReportsReceived: []dataExportReport{newDataExportReport(report)},
+	}
+
+	var buf bytes.Buffer
+
+	require.NoError(t, writeDataExportZip(&buf, archive))
+
+	reader, errReader := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, errReader)
+
+	for _, file := range reader.File {
+		contents, errOpen := file.Open()
+		require.NoError(t, errOpen)
+
+		body, errBody := io.ReadAll(contents)
+		require.NoError(t, errBody)
+
+		for _, hidden := range []string{ban.Note, admin.String(), reporter.String(), report.Description, report.ReasonText} {
+			require.NotContains(t, string(body), hidden, file.Name)
+		}
+	}
+
+	require.Equal(t, "aimbot", archive.Bans[0].ReasonText)
+	require.Equal(t, report.ReportID, archive.ReportsReceived[0].ReportID)
+}
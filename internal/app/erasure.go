@@ -0,0 +1,172 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/leighmacdonald/gbans/internal/consts"
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+var (
+	errErasureExists     = errors.New("Erasure already requested")
+	errErasureNotPending = errors.New("Erasure is not pending")
+	errAlreadyErased     = errors.New("Player already erased")
+)
+
+// RequestErasure queues a request for the players data to be erased. Nothing is modified until a moderator
+// approves the request.
+func (app *App) RequestErasure(ctx context.Context, steamID steamid.SID64, reason string) (store.PersonErasure, error) {
+	var person store.Person
+	if errPerson := app.db.GetPersonBySteamID(ctx, steamID, &person); errPerson != nil {
+		return store.PersonErasure{}, errors.Wrap(errPerson, "Failed to load person")
+	}
+
+	if person.Erased {
+		return store.PersonErasure{}, errAlreadyErased
+	}
+
+	existing, errExisting := app.db.GetErasures(ctx, store.ErasureQueryFilter{
+		SteamID: steamID,
+		Status:  []store.ErasureStatus{store.ErasurePending, store.ErasureApproved},
+	})
+	if errExisting != nil && !errors.Is(errExisting, store.ErrNoResult) {
+		return store.PersonErasure{}, errors.Wrap(errExisting, "Failed to check existing erasures")
+	}
+
+	if len(existing) > 0 {
+		return existing[0], errErasureExists
+	}
+
+	erasure := store.NewPersonErasure(steamID, reason)
+	if errSave := app.db.SaveErasure(ctx, &erasure); errSave != nil {
+		return erasure, errors.Wrap(errSave, "Failed to save erasure")
+	}
+
+	app.audit(ctx, steamID, steamID, store.AuditErasureRequested, fmt.Sprintf("erasure_id=%d", erasure.ErasureID))
+
+	if errNotify := app.SendNotification(ctx, NotificationPayload{
+		MinPerms: consts.PModerator,
+		Severity: consts.SeverityInfo,
+		Message:  fmt.Sprintf("New data erasure request: %s", steamID.String()),
+		Link:     app.ExtURLRaw("/admin/erasures"),
+	}); errNotify != nil {
+		app.log.Error("Failed to send erasure notification", zap.Error(errNotify))
+	}
+
+	return erasure, nil
+}
+
+// ReviewErasure approves or denies a pending erasure. Approving immediately anonymizes the player, leaving
+// the retained items to be purged by erasureWorker once the retention period ends.
+func (app *App) ReviewErasure(ctx context.Context, erasureID int64, reviewer steamid.SID64, approve bool,
+	note string,
+) (store.PersonErasure, error) {
+	var erasure store.PersonErasure
+	if errGet := app.db.GetErasure(ctx, erasureID, &erasure); errGet != nil {
+		return erasure, errors.Wrap(errGet, "Failed to load erasure")
+	}
+
+	if erasure.Status != store.ErasurePending {
+		return erasure, errErasureNotPending
+	}
+
+	erasure.ReviewerID = reviewer
+	erasure.ReviewNote = note
+
+	if !approve {
+		erasure.Status = store.ErasureDenied
+		if errSave := app.db.SaveErasure(ctx, &erasure); errSave != nil {
+			return erasure, errors.Wrap(errSave, "Failed to save erasure")
+		}
+
+		app.audit(ctx, reviewer, erasure.SteamID, store.AuditErasureDenied, fmt.Sprintf("erasure_id=%d", erasureID))
+
+		return erasure, nil
+	}
+
+	if errAnon := app.db.AnonymizePerson(ctx, erasure.SteamID); errAnon != nil {
+		return erasure, errors.Wrap(errAnon, "Failed to anonymize person")
+	}
+
+	retainUntil := time.Now().Add(app.conf.Erasure.Retention.Duration())
+	erasure.Status = store.ErasureApproved
+	erasure.RetainUntil = &retainUntil
+
+	if errSave := app.db.SaveErasure(ctx, &erasure); errSave != nil {
+		return erasure, errors.Wrap(errSave, "Failed to save erasure")
+	}
+
+	app.audit(ctx, reviewer, erasure.SteamID, store.AuditErasureApproved,
+		fmt.Sprintf("erasure_id=%d retain_until=%s", erasureID, retainUntil.Format(time.RFC3339)))
+
+	if app.conf.Erasure.Retention.Duration() == 0 {
+		return erasure, app.completeErasure(ctx, &erasure)
+	}
+
+	return erasure, nil
+}
+
+// completeErasure purges everything that was retained for the erased player.
+func (app *App) completeErasure(ctx context.Context, erasure *store.PersonErasure) error {
+	if errPurge := app.db.PurgeRetainedPersonData(ctx, erasure.SteamID); errPurge != nil {
+		return errors.Wrap(errPurge, "Failed to purge retained data")
+	}
+
+	now := time.Now()
+	erasure.Status = store.ErasureComplete
+	erasure.CompletedOn = &now
+
+	if errSave := app.db.SaveErasure(ctx, erasure); errSave != nil {
+		return errors.Wrap(errSave, "Failed to save erasure")
+	}
+
+	app.audit(ctx, "", erasure.SteamID, store.AuditErasureCompleted, fmt.Sprintf("erasure_id=%d", erasure.ErasureID))
+
+	return nil
+}
+
+// audit records a privileged action. Failures are logged rather than failing the action itself.
+func (app *App) audit(ctx context.Context, source steamid.SID64, target steamid.SID64, action store.AuditAction,
+	details string,
+) {
+	entry := store.NewAuditEntry(source, target, action, details)
+	if errSave := app.db.SaveAuditEntry(ctx, &entry); errSave != nil {
+		app.log.Error("Failed to save audit entry", zap.String("action", string(action)), zap.Error(errSave))
+	}
+}
+
+// erasureWorker completes approved erasures once their retention period has ended.
+func (app *App) erasureWorker(ctx context.Context) {
+	var (
+		log    = app.log.Named("erasureWorker")
+		ticker = time.NewTicker(time.Hour)
+	)
+
+	for {
+		select {
+		case <-ticker.C:
+			now := time.Now()
+
+			erasures, errErasures := app.db.GetErasures(ctx, store.ErasureQueryFilter{RetainedBefore: &now})
+			if errErasures != nil && !errors.Is(errErasures, store.ErrNoResult) {
+				log.Error("Failed to load expired erasures", zap.Error(errErasures))
+
+				continue
+			}
+
+			for i := range erasures {
+				if errComplete := app.completeErasure(ctx, &erasures[i]); errComplete != nil {
+					log.Error("Failed to complete erasure", zap.Int64("erasure_id", erasures[i].ErasureID),
+						zap.Error(errComplete))
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
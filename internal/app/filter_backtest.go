@@ -0,0 +1,225 @@
+package app
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+	"github.com/pkg/errors"
+)
+
+const (
+	backtestBatchSize      = 5000
+	backtestMaxDays        = 365
+	backtestDefaultSamples = 25
+)
+
+var errBacktestInvalidFilter = errors.New("Invalid filter")
+
+// FilterBacktestOpts defines the candidate filter and how far back to test it.
+type FilterBacktestOpts struct {
+	Filter store.Filter `json:"filter"`
+	// Days of chat history to run the filter over
+	Days int `json:"days"`
+	// Samples is the max number of example matches to return for each sample set
+	Samples int `json:"samples"`
+}
+
+// FilterBacktestProgress is periodically emitted while a backtest is running.
+type FilterBacktestProgress struct {
+	Processed int64 `json:"processed"`
+	Total     int64 `json:"total"`
+	Matches   int64 `json:"matches"`
+}
+
+type FilterBacktestMatch struct {
+	store.PersonMessage
+	Matched string `json:"matched"`
+	// ExistingFilterID is the id of the currently loaded filter which also matches the message, if any
+	ExistingFilterID int64 `json:"existing_filter_id"`
+}
+
+// FilterBacktestResult summarises how a candidate filter would have behaved over the tested period.
+type FilterBacktestResult struct {
+	Processed       int64                 `json:"processed"`
+	Matches         int64                 `json:"matches"`
+	DistinctPlayers int                   `json:"distinct_players"`
+	NewMatches      int64                 `json:"new_matches"`
+	NewPlayers      int                   `json:"new_players"`
+	Samples         []FilterBacktestMatch `json:"samples"`
+	NewSamples      []FilterBacktestMatch `json:"new_samples"`
+	Since           time.Time             `json:"since"`
+	Duration        time.Duration         `json:"duration"`
+}
+
+// filterBacktest accumulates the results of running a candidate filter over batches of messages.
+type filterBacktest struct {
+	candidate  *wordFilters
+	existing   *wordFilters
+	samples    int
+	players    map[steamid.SID64]bool
+	newPlayers map[steamid.SID64]bool
+	result     FilterBacktestResult
+}
+
+// newFilterBacktest validates & prepares the candidate filter. The candidate itself is excluded from the
+// existing filters when it is an edit of a stored filter.
+func newFilterBacktest(opts FilterBacktestOpts, existing []store.Filter) (*filterBacktest, error) {
+	if opts.Filter.Pattern == "" {
+		return nil, errBacktestInvalidFilter
+	}
+
+	if opts.Filter.IsRegex {
+		if _, errCompile := regexp.Compile(opts.Filter.Pattern); errCompile != nil {
+			return nil, errors.Wrap(errBacktestInvalidFilter, errCompile.Error())
+		}
+	}
+
+	if opts.Samples <= 0 {
+		opts.Samples = backtestDefaultSamples
+	}
+
+	candidate := opts.Filter
+	// The candidate is tested regardless of its current state.
+	candidate.IsEnabled = true
+	candidate.Init()
+
+	candidateFilters := newWordFilters()
+	candidateFilters.importFilteredWords([]store.Filter{candidate})
+
+	var current []store.Filter
+
+	for _, filter := range existing {
+		if filter.FilterID == candidate.FilterID && candidate.FilterID > 0 {
+			continue
+		}
+
+		current = append(current, filter)
+	}
+
+	existingFilters := newWordFilters()
+	existingFilters.importFilteredWords(current)
+
+	return &filterBacktest{
+		candidate:  candidateFilters,
+		existing:   existingFilters,
+		samples:    opts.Samples,
+		players:    map[steamid.SID64]bool{},
+		newPlayers: map[steamid.SID64]bool{},
+	}, nil
+}
+
+// add runs the candidate filter over the messages. Each match is also checked against the existing filters
+// so that matches which would only be caught by the candidate can be reported separately.
+func (b *filterBacktest) add(messages []store.PersonMessage) {
+	for _, message := range messages {
+		matched, filter := b.candidate.findFilteredWordMatch(message.Body)
+		if filter == nil {
+			continue
+		}
+
+		match := FilterBacktestMatch{PersonMessage: message, Matched: matched}
+
+		b.result.Matches++
+		b.players[message.SteamID] = true
+
+		if _, existingFilter := b.existing.findFilteredWordMatch(message.Body); existingFilter != nil {
+			match.ExistingFilterID = existingFilter.FilterID
+		} else {
+			b.result.NewMatches++
+			b.newPlayers[message.SteamID] = true
+
+			if len(b.result.NewSamples) < b.samples {
+				b.result.NewSamples = append(b.result.NewSamples, match)
+			}
+		}
+
+		if len(b.result.Samples) < b.samples {
+			b.result.Samples = append(b.result.Samples, match)
+		}
+	}
+
+	b.result.Processed += int64(len(messages))
+}
+
+// finish returns the final results.
+func (b *filterBacktest) finish() FilterBacktestResult {
+	b.result.DistinctPlayers = len(b.players)
+	b.result.NewPlayers = len(b.newPlayers)
+
+	return b.result
+}
+
+// backtestFilter runs the candidate filter against the chat history within the requested time
+// window. The onProgress func, if supplied, is called after every processed batch.
+func backtestFilter(ctx context.Context, database *store.Store, opts FilterBacktestOpts, existing []store.Filter,
+	onProgress func(progress FilterBacktestProgress),
+) (FilterBacktestResult, error) {
+	backtest, errBacktest := newFilterBacktest(opts, existing)
+	if errBacktest != nil {
+		return FilterBacktestResult{}, errBacktest
+	}
+
+	if opts.Days <= 0 || opts.Days > backtestMaxDays {
+		opts.Days = 7
+	}
+
+	var (
+		startTime = time.Now()
+		since     = startTime.AddDate(0, 0, -opts.Days)
+		lastID    int64
+	)
+
+	total, errCount := database.CountChatHistorySince(ctx, since)
+	if errCount != nil {
+		return backtest.result, errors.Wrap(errCount, "Failed to count messages")
+	}
+
+	backtest.result.Since = since
+
+	for {
+		messages, errMessages := database.ChatHistoryBatch(ctx, since, lastID, backtestBatchSize)
+		if errMessages != nil {
+			return backtest.result, errors.Wrap(errMessages, "Failed to fetch messages")
+		}
+
+		if len(messages) == 0 {
+			break
+		}
+
+		backtest.add(messages)
+		lastID = messages[len(messages)-1].PersonMessageID
+
+		if onProgress != nil {
+			onProgress(FilterBacktestProgress{
+				Processed: backtest.result.Processed,
+				Total:     total,
+				Matches:   backtest.result.Matches,
+			})
+		}
+
+		if ctx.Err() != nil {
+			return backtest.result, errors.Wrap(ctx.Err(), "Backtest cancelled")
+		}
+	}
+
+	result := backtest.finish()
+	result.Duration = time.Since(startTime)
+
+	return result, nil
+}
+
+// BacktestFilter runs the candidate filter against recent chat history, comparing the results
+// against the filters currently stored in the database.
+func BacktestFilter(ctx context.Context, database *store.Store, opts FilterBacktestOpts,
+	onProgress func(progress FilterBacktestProgress),
+) (FilterBacktestResult, error) {
+	existing, errFilters := database.GetFilters(ctx)
+	if errFilters != nil && !errors.Is(errFilters, store.ErrNoResult) {
+		return FilterBacktestResult{}, errors.Wrap(errFilters, "Failed to load existing filters")
+	}
+
+	return backtestFilter(ctx, database, opts, existing, onProgress)
+}
@@ -0,0 +1,112 @@
+package app // nolint:testpackage
+
+import (
+	"testing"
+
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/leighmacdonald/gbans/pkg/util"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+	"github.com/stretchr/testify/require"
+)
+
+func testBacktestMessages() []store.PersonMessage {
+	var (
+		playerA = steamid.New(76561198000000001)
+		playerB = steamid.New(76561198000000002)
+		playerC = steamid.New(76561198000000003)
+	)
+
+	return []store.PersonMessage{
+		{PersonMessageID: 1, SteamID: playerA, Body: "you are a cheater"},
+		{PersonMessageID: 2, SteamID: playerA, Body: "ch3at3r"},
+		{PersonMessageID: 3, SteamID: playerB, Body: "nice shot"},
+		{PersonMessageID: 4, SteamID: playerB, Body: "what a noob cheater"},
+		{PersonMessageID: 5, SteamID: playerC, Body: "cheat at poker"},
+		{PersonMessageID: 6, SteamID: playerC, Body: "gg"},
+	}
+}
+
+func TestFilterBacktest(t *testing.T) {
+	candidate := store.Filter{
+		FilterID:  10,
+		Pattern:   "cheater",
+		Normalize: util.NormalizeOpts{Leet: true},
+	}
+
+	existing := store.Filter{FilterID: 1, Pattern: "noob", IsEnabled: true}
+	existing.Init()
+
+	// An edited version of the candidate being stored must not count as an existing match
+	stored := store.Filter{FilterID: 10, Pattern: "cheater", IsEnabled: true}
+	stored.Init()
+
+	backtest, errBacktest := newFilterBacktest(FilterBacktestOpts{Filter: candidate, Samples: 2},
+		[]store.Filter{existing, stored})
+	require.NoError(t, errBacktest)
+
+	messages := testBacktestMessages()
+	backtest.add(messages[:3])
+	backtest.add(messages[3:])
+
+	result := backtest.finish()
+
+	require.Equal(t, int64(6), result.Processed)
+	require.Equal(t, int64(3), result.Matches)
+	require.Equal(t, 2, result.DistinctPlayers)
+	require.Equal(t, int64(2), result.NewMatches)
+	require.Equal(t, 1, result.NewPlayers)
+
+	require.Len(t, result.Samples, 2)
+	require.Equal(t, "cheater", result.Samples[1].Matched)
+	require.Equal(t, int64(2), result.Samples[1].PersonMessageID)
+
+	require.Len(t, result.NewSamples, 2)
+
+	for _, sample := range result.NewSamples {
+		require.Zero(t, sample.ExistingFilterID)
+	}
+}
+
+func TestFilterBacktestExisting(t *testing.T) {
+	existing := store.Filter{FilterID: 1, Pattern: "cheater", IsEnabled: true}
+	existing.Init()
+
+	backtest, errBacktest := newFilterBacktest(FilterBacktestOpts{Filter: store.Filter{Pattern: "cheater"}},
+		[]store.Filter{existing})
+	require.NoError(t, errBacktest)
+
+	backtest.add(testBacktestMessages())
+
+	result := backtest.finish()
+
+	require.Equal(t, int64(2), result.Matches)
+	require.Zero(t, result.NewMatches)
+	require.Zero(t, result.NewPlayers)
+	require.Empty(t, result.NewSamples)
+
+	for _, sample := range result.Samples {
+		require.Equal(t, existing.FilterID, sample.ExistingFilterID)
+	}
+}
+
+func TestFilterBacktestNoMatches(t *testing.T) {
+	backtest, errBacktest := newFilterBacktest(FilterBacktestOpts{Filter: store.Filter{Pattern: "hacker"}}, nil)
+	require.NoError(t, errBacktest)
+
+	backtest.add(testBacktestMessages())
+
+	result := backtest.finish()
+
+	require.Equal(t, int64(6), result.Processed)
+	require.Zero(t, result.Matches)
+	require.Zero(t, result.DistinctPlayers)
+	require.Empty(t, result.Samples)
+}
+
+func TestFilterBacktestInvalid(t *testing.T) {
+	_, errEmpty := newFilterBacktest(FilterBacktestOpts{}, nil)
+	require.ErrorIs(t, errEmpty, errBacktestInvalidFilter)
+
+	_, errRegex := newFilterBacktest(FilterBacktestOpts{Filter: store.Filter{Pattern: "[a-", IsRegex: true}}, nil)
+	require.ErrorIs(t, errRegex, errBacktestInvalidFilter)
+}
@@ -1,10 +1,10 @@
 package app
 
 import (
-	"strings"
 	"sync"
 
 	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/leighmacdonald/gbans/pkg/util"
 )
 
 type wordFilters struct {
@@ -27,21 +27,34 @@ func (f *wordFilters) importFilteredWords(filters []store.Filter) {
 
 // findFilteredWordMatch checks to see if the body of text contains a known filtered word
 // It will only return the first matched filter found.
+//
+// Each filter can enable its own set of normalization steps, so the body is normalized once for
+// each distinct set of options in use and the result reused across filters sharing them.
 func (f *wordFilters) findFilteredWordMatch(body string) (string, *store.Filter) {
 	if body == "" {
 		return "", nil
 	}
 
-	words := strings.Split(strings.ToLower(body), " ")
-
 	f.RLock()
 	defer f.RUnlock()
 
+	tokenCache := map[util.NormalizeOpts][]string{}
+
 	for _, filter := range f.wordFilters {
-		for _, word := range words {
-			if filter.IsEnabled && filter.Match(word) {
-				return word, &filter
-			}
+		if !filter.IsEnabled {
+			continue
+		}
+
+		words, found := tokenCache[filter.Normalize]
+		if !found {
+			words = store.FilterTokens(body, filter.Normalize)
+			tokenCache[filter.Normalize] = words
+		}
+
+		if word, matched := filter.MatchTokens(words); matched {
+			matchedFilter := filter
+
+			return word, &matchedFilter
 		}
 	}
 
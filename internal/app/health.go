@@ -0,0 +1,545 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/leighmacdonald/gbans/internal/discord"
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+const (
+	healthCheckInterval = time.Second * 30
+	healthUptimeFreq    = time.Minute * 5
+	// healthPlayerAvgWeight is the weight given to each new sample of the moving player average. At the
+	// check interval this roughly averages the last half hour.
+	healthPlayerAvgWeight = 0.03
+)
+
+type healthAlertKind string
+
+const (
+	healthAlertDown      healthAlertKind = "down"
+	healthAlertUp        healthAlertKind = "up"
+	healthAlertCrashLoop healthAlertKind = "crash_loop"
+	healthAlertEmpty     healthAlertKind = "empty"
+	healthAlertStaleMap  healthAlertKind = "stale_map"
+	healthAlertRCONDown  healthAlertKind = "rcon_down"
+	healthAlertRCONUp    healthAlertKind = "rcon_up"
+)
+
+type healthAlert struct {
+	Kind      healthAlertKind
+	ServerID  int
+	NameShort string
+	Message   string
+}
+
+// healthTransition is a server changing between the up and down states.
+type healthTransition struct {
+	ServerID  int
+	NameShort string
+	Up        bool
+	At        time.Time
+	Reason    string
+}
+
+// serverHealth is the current health state of a single server.
+type serverHealth struct {
+	ServerID  int    `json:"server_id"`
+	NameShort string `json:"name_short"`
+	Up        bool   `json:"up"`
+	// Since is when the server last changed between up and down.
+	Since         time.Time `json:"since"`
+	LastSeen      time.Time `json:"last_seen"`
+	PlayerAverage float64   `json:"player_average"`
+	MapChangedOn  time.Time `json:"map_changed_on"`
+	// RCONUp is false when the server is answering a2s queries or plugin updates but not rcon.
+	RCONUp   bool      `json:"rcon_up"`
+	LastRCON time.Time `json:"last_rcon"`
+
+	downs        []time.Time
+	lastAlert    map[healthAlertKind]time.Time
+	downAlerted  bool
+	emptyAlerted bool
+	staleAlerted bool
+	rconAlerted  bool
+}
+
+// healthMonitor tracks the availability of servers using the status updates of the serverStateCollector.
+type healthMonitor struct {
+	mu              *sync.RWMutex
+	servers         map[int]*serverHealth
+	downAfter       time.Duration
+	debounce        time.Duration
+	crashLoopCount  int
+	crashLoopWindow time.Duration
+	busyPlayers     int
+	staleMap        time.Duration
+}
+
+func newHealthMonitor(conf healthConfig) *healthMonitor {
+	return &healthMonitor{
+		mu:              &sync.RWMutex{},
+		servers:         map[int]*serverHealth{},
+		downAfter:       conf.DownAfter.Duration(),
+		debounce:        conf.AlertDebounce.Duration(),
+		crashLoopCount:  conf.CrashLoopCount,
+		crashLoopWindow: conf.CrashLoopWindow.Duration(),
+		busyPlayers:     conf.BusyPlayers,
+		staleMap:        conf.StaleMap.Duration(),
+	}
+}
+
+// restore marks servers with an open downtime period as down, so that servers which were down
+// when gbans was stopped are not considered up on startup.
+func (m *healthMonitor) restore(downtimes []store.ServerDowntime) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, downtime := range downtimes {
+		if downtime.EndedOn != nil {
+			continue
+		}
+
+		m.servers[downtime.ServerID] = &serverHealth{
+			ServerID:    downtime.ServerID,
+			Up:          false,
+			Since:       downtime.StartedOn,
+			RCONUp:      true,
+			lastAlert:   map[healthAlertKind]time.Time{},
+			downAlerted: true,
+		}
+	}
+}
+
+// allowAlert checks that an alert of the same kind was not sent for the server within the debounce period.
+func (m *healthMonitor) allowAlert(health *serverHealth, kind healthAlertKind, now time.Time) bool {
+	if last, found := health.lastAlert[kind]; found && now.Sub(last) < m.debounce {
+		return false
+	}
+
+	health.lastAlert[kind] = now
+
+	return true
+}
+
+// update compares the current server state against the previous state, returning any up/down
+// transitions and the alerts that should be sent.
+func (m *healthMonitor) update(now time.Time, servers serverDetailsCollection) ([]healthTransition, []healthAlert) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var (
+		transitions []healthTransition
+		alerts      []healthAlert
+		seen        = map[int]bool{}
+	)
+
+	for _, server := range servers {
+		seen[server.ServerID] = true
+
+		health, found := m.servers[server.ServerID]
+		if !found {
+			// New servers are given the down threshold to report their first status.
+			health = &serverHealth{
+				ServerID:  server.ServerID,
+				Up:        true,
+				Since:     now,
+				LastSeen:  now,
+				RCONUp:    true,
+				LastRCON:  now,
+				lastAlert: map[healthAlertKind]time.Time{},
+			}
+			m.servers[server.ServerID] = health
+		}
+
+		health.NameShort = server.NameShort
+
+		if server.LastUpdate.After(health.LastSeen) {
+			health.LastSeen = server.LastUpdate
+		}
+
+		if server.LastRCON.After(health.LastRCON) {
+			health.LastRCON = server.LastRCON
+		}
+
+		newAlert := func(kind healthAlertKind, format string, args ...any) {
+			alerts = append(alerts, healthAlert{
+				Kind:      kind,
+				ServerID:  server.ServerID,
+				NameShort: server.NameShort,
+				Message:   fmt.Sprintf(format, args...),
+			})
+		}
+
+		up := !health.LastSeen.IsZero() && now.Sub(health.LastSeen) <= m.downAfter
+
+		switch {
+		case health.Up && !up:
+			reason := fmt.Sprintf("No status update for %s", now.Sub(health.LastSeen).Round(time.Second))
+			health.Up = false
+			health.Since = now
+			transitions = append(transitions, healthTransition{
+				ServerID: server.ServerID, NameShort: server.NameShort, Up: false, At: now, Reason: reason,
+			})
+
+			var downs []time.Time
+
+			for _, down := range health.downs {
+				if now.Sub(down) <= m.crashLoopWindow {
+					downs = append(downs, down)
+				}
+			}
+
+			health.downs = append(downs, now)
+
+			health.downAlerted = m.allowAlert(health, healthAlertDown, now)
+			if health.downAlerted {
+				newAlert(healthAlertDown, "Server is not responding. %s", reason)
+			}
+
+			if m.crashLoopCount > 0 && len(health.downs) >= m.crashLoopCount &&
+				m.allowAlert(health, healthAlertCrashLoop, now) {
+				newAlert(healthAlertCrashLoop, "Server went down %d times in the last %s",
+					len(health.downs), m.crashLoopWindow)
+			}
+		case !health.Up && up:
+			downtime := now.Sub(health.Since).Round(time.Second)
+			health.Up = true
+			health.Since = now
+			transitions = append(transitions, healthTransition{
+				ServerID: server.ServerID, NameShort: server.NameShort, Up: true, At: now,
+			})
+
+			// Recovery alerts are only sent when the matching down alert was sent.
+			if health.downAlerted {
+				health.downAlerted = false
+				newAlert(healthAlertUp, "Server is responding again after %s", downtime)
+			}
+		}
+
+		if !up {
+			continue
+		}
+
+		// Servers without a rcon password are only ever queried over a2s.
+		if server.RconPassword != "" {
+			rconUp := !health.LastRCON.IsZero() && now.Sub(health.LastRCON) <= m.downAfter
+
+			switch {
+			case health.RCONUp && !rconUp:
+				health.RCONUp = false
+				health.rconAlerted = m.allowAlert(health, healthAlertRCONDown, now)
+				if health.rconAlerted {
+					newAlert(healthAlertRCONDown, "Server is up but rcon is not responding. No rcon status for %s",
+						now.Sub(health.LastRCON).Round(time.Second))
+				}
+			case !health.RCONUp && rconUp:
+				health.RCONUp = true
+				if health.rconAlerted {
+					health.rconAlerted = false
+					newAlert(healthAlertRCONUp, "Server rcon is responding again")
+				}
+			}
+		}
+
+		if m.busyPlayers > 0 {
+			if server.PlayerCount > 0 {
+				health.emptyAlerted = false
+			} else if health.PlayerAverage >= float64(m.busyPlayers) && !health.emptyAlerted {
+				health.emptyAlerted = true
+				if m.allowAlert(health, healthAlertEmpty, now) {
+					newAlert(healthAlertEmpty, "Player count dropped to 0 from an average of %.0f",
+						health.PlayerAverage)
+				}
+			}
+
+			health.PlayerAverage += (float64(server.PlayerCount) - health.PlayerAverage) * healthPlayerAvgWeight
+		}
+
+		if !server.MapChangedOn.Equal(health.MapChangedOn) {
+			health.MapChangedOn = server.MapChangedOn
+			health.staleAlerted = false
+		}
+
+		if m.staleMap > 0 && !health.MapChangedOn.IsZero() && !health.staleAlerted &&
+			now.Sub(health.MapChangedOn) > m.staleMap {
+			health.staleAlerted = true
+			if m.allowAlert(health, healthAlertStaleMap, now) {
+				newAlert(healthAlertStaleMap, "Server has been on %s for %s", server.Map,
+					now.Sub(health.MapChangedOn).Round(time.Minute))
+			}
+		}
+	}
+
+	for serverID := range m.servers {
+		if !seen[serverID] {
+			delete(m.servers, serverID)
+		}
+	}
+
+	return transitions, alerts
+}
+
+// status returns a copy of the current health of all servers, ordered by server id.
+func (m *healthMonitor) status() []serverHealth {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	results := make([]serverHealth, 0, len(m.servers))
+	for _, health := range m.servers {
+		results = append(results, serverHealth{
+			ServerID:      health.ServerID,
+			NameShort:     health.NameShort,
+			Up:            health.Up,
+			Since:         health.Since,
+			LastSeen:      health.LastSeen,
+			PlayerAverage: health.PlayerAverage,
+			MapChangedOn:  health.MapChangedOn,
+			RCONUp:        health.RCONUp,
+			LastRCON:      health.LastRCON,
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ServerID < results[j].ServerID
+	})
+
+	return results
+}
+
+// downtimeOverlap returns how much of the downtime period falls within the window. Open periods are
+// considered ongoing until the end of the window.
+func downtimeOverlap(downtime store.ServerDowntime, from time.Time, to time.Time) time.Duration {
+	start := downtime.StartedOn
+	if start.Before(from) {
+		start = from
+	}
+
+	end := to
+	if downtime.EndedOn != nil && downtime.EndedOn.Before(to) {
+		end = *downtime.EndedOn
+	}
+
+	if !end.After(start) {
+		return 0
+	}
+
+	return end.Sub(start)
+}
+
+// uptimeRatio returns the ratio, 0-1, of the window where the server was not down.
+func uptimeRatio(downtimes []store.ServerDowntime, from time.Time, to time.Time) float64 {
+	window := to.Sub(from)
+	if window <= 0 {
+		return 1
+	}
+
+	var down time.Duration
+	for _, downtime := range downtimes {
+		down += downtimeOverlap(downtime, from, to)
+	}
+
+	ratio := 1 - down.Seconds()/window.Seconds()
+	if ratio < 0 {
+		return 0
+	}
+
+	return ratio
+}
+
+type uptimeDay struct {
+	Day time.Time `json:"day"`
+	// Uptime ratio, 0-1
+	Uptime float64 `json:"uptime"`
+	// Total seconds of downtime
+	Downtime int64 `json:"downtime"`
+}
+
+// dailyUptime splits the window into UTC days, calculating the uptime of each. The final day ends at the
+// end of the window.
+func dailyUptime(downtimes []store.ServerDowntime, from time.Time, to time.Time) []uptimeDay {
+	var days []uptimeDay
+
+	for day := from.UTC().Truncate(time.Hour * 24); day.Before(to); day = day.AddDate(0, 0, 1) {
+		start := day
+		if start.Before(from) {
+			start = from
+		}
+
+		end := day.AddDate(0, 0, 1)
+		if end.After(to) {
+			end = to
+		}
+
+		var down time.Duration
+		for _, downtime := range downtimes {
+			down += downtimeOverlap(downtime, start, end)
+		}
+
+		days = append(days, uptimeDay{
+			Day:      day,
+			Uptime:   uptimeRatio(downtimes, start, end),
+			Downtime: int64(down.Seconds()),
+		})
+	}
+
+	return days
+}
+
+// uptimeWindows are the windows that uptime percentages are calculated for.
+//
+//nolint:gochecknoglobals
+var uptimeWindows = []struct {
+	Name     string
+	Duration time.Duration
+}{
+	{"24h", time.Hour * 24},
+	{"7d", time.Hour * 24 * 7},
+	{"30d", time.Hour * 24 * 30},
+}
+
+type serverHealthStatus struct {
+	serverHealth
+	// Uptime ratios keyed by window, eg: 24h, 7d, 30d
+	Uptime map[string]float64 `json:"uptime"`
+}
+
+// serverHealthStatuses combines the current health state with the uptime of each window.
+func (app *App) serverHealthStatuses(ctx context.Context) ([]serverHealthStatus, error) {
+	now := time.Now()
+
+	downtimes, errDowntimes := app.db.GetServerDowntimes(ctx, 0, now.Add(-uptimeWindows[len(uptimeWindows)-1].Duration))
+	if errDowntimes != nil {
+		return nil, errDowntimes
+	}
+
+	byServer := map[int][]store.ServerDowntime{}
+	for _, downtime := range downtimes {
+		byServer[downtime.ServerID] = append(byServer[downtime.ServerID], downtime)
+	}
+
+	statuses := []serverHealthStatus{}
+
+	for _, health := range app.health.status() {
+		status := serverHealthStatus{serverHealth: health, Uptime: map[string]float64{}}
+		for _, window := range uptimeWindows {
+			status.Uptime[window.Name] = uptimeRatio(byServer[health.ServerID], now.Add(-window.Duration), now)
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+func (app *App) updateUptimeMetrics(ctx context.Context) error {
+	statuses, errStatuses := app.serverHealthStatuses(ctx)
+	if errStatuses != nil {
+		return errStatuses
+	}
+
+	for _, status := range statuses {
+		for window, ratio := range status.Uptime {
+			app.mc.serverUptime.With(prometheus.Labels{"server_name": status.NameShort, "window": window}).Set(ratio)
+		}
+	}
+
+	return nil
+}
+
+func (app *App) onHealthTransition(ctx context.Context, transition healthTransition) error {
+	labels := prometheus.Labels{"server_name": transition.NameShort}
+
+	if transition.Up {
+		app.mc.serverUp.With(labels).Set(1)
+
+		return app.db.CloseServerDowntime(ctx, transition.ServerID, transition.At)
+	}
+
+	app.mc.serverUp.With(labels).Set(0)
+	app.mc.serverDownCounter.With(labels).Inc()
+
+	return app.db.OpenServerDowntime(ctx, &store.ServerDowntime{
+		ServerID:  transition.ServerID,
+		Reason:    transition.Reason,
+		StartedOn: transition.At,
+	})
+}
+
+func (app *App) sendHealthAlert(alert healthAlert) {
+	app.mc.healthAlertCounter.With(prometheus.Labels{"server_name": alert.NameShort, "kind": string(alert.Kind)}).Inc()
+
+	colour := app.bot.Colour.Warn
+
+	switch alert.Kind { //nolint:exhaustive
+	case healthAlertDown, healthAlertCrashLoop:
+		colour = app.bot.Colour.Error
+	case healthAlertUp, healthAlertRCONUp:
+		colour = app.bot.Colour.Success
+	}
+
+	channelID := app.conf.Health.AlertChannelID
+	if channelID == "" {
+		channelID = app.conf.Discord.LogChannelID
+	}
+
+	msgEmbed := discord.
+		NewEmbed(fmt.Sprintf("Server health: %s", alert.NameShort)).
+		SetColor(colour).
+		SetDescription(alert.Message).
+		AddField("Kind", string(alert.Kind)).
+		InlineAllFields()
+
+	app.bot.SendPayload(discord.Payload{ChannelID: channelID, Embed: msgEmbed.Truncate().MessageEmbed})
+}
+
+// serverHealthWorker periodically checks the health of the servers, recording downtime and sending alerts.
+func (app *App) serverHealthWorker(ctx context.Context) {
+	if !app.conf.Health.Enabled {
+		return
+	}
+
+	var (
+		log          = app.log.Named("serverHealth")
+		ticker       = time.NewTicker(healthCheckInterval)
+		uptimeTicker = time.NewTicker(healthUptimeFreq)
+	)
+
+	downtimes, errDowntimes := app.db.GetServerDowntimes(ctx, 0, time.Now())
+	if errDowntimes != nil {
+		log.Error("Failed to load open downtime periods", zap.Error(errDowntimes))
+	} else {
+		app.health.restore(downtimes)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			transitions, alerts := app.health.update(now, app.state.current())
+
+			for _, transition := range transitions {
+				if errTransition := app.onHealthTransition(ctx, transition); errTransition != nil {
+					log.Error("Failed to record server downtime", zap.Int("server_id", transition.ServerID),
+						zap.Error(errTransition))
+				}
+			}
+
+			for _, alert := range alerts {
+				app.sendHealthAlert(alert)
+			}
+		case <-uptimeTicker.C:
+			if errUptime := app.updateUptimeMetrics(ctx); errUptime != nil {
+				log.Error("Failed to update uptime metrics", zap.Error(errUptime))
+			}
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package app // nolint:testpackage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/stretchr/testify/require"
+)
+
+func alertKinds(alerts []healthAlert) []healthAlertKind {
+	var kinds []healthAlertKind
+	for _, alert := range alerts {
+		kinds = append(kinds, alert.Kind)
+	}
+
+	return kinds
+}
+
+func TestHealthMonitorTransitions(t *testing.T) {
+	monitor := newHealthMonitor(healthConfig{
+		DownAfter:       "90s",
+		AlertDebounce:   "15m",
+		CrashLoopCount:  2,
+		CrashLoopWindow: "30m",
+		StaleMap:        "0",
+	})
+
+	var (
+		start  = time.Date(2023, time.March, 15, 10, 0, 0, 0, time.UTC)
+		server = serverDetails{ServerID: 1, NameShort: "us-1", LastUpdate: start}
+	)
+
+	transitions, alerts := monitor.update(start, serverDetailsCollection{server})
+	require.Empty(t, transitions)
+	require.Empty(t, alerts)
+
+	// No update within the threshold
+	now := start.Add(time.Minute * 2)
+	transitions, alerts = monitor.update(now, serverDetailsCollection{server})
+	require.Len(t, transitions, 1)
+	require.False(t, transitions[0].Up)
+	require.Equal(t, []healthAlertKind{healthAlertDown}, alertKinds(alerts))
+
+	// Still down, nothing new
+	transitions, alerts = monitor.update(now.Add(time.Minute), serverDetailsCollection{server})
+	require.Empty(t, transitions)
+	require.Empty(t, alerts)
+
+	now = now.Add(time.Minute * 2)
+	server.LastUpdate = now
+	transitions, alerts = monitor.update(now, serverDetailsCollection{server})
+	require.Len(t, transitions, 1)
+	require.True(t, transitions[0].Up)
+	require.Equal(t, []healthAlertKind{healthAlertUp}, alertKinds(alerts))
+
+	// Down again within the debounce period, the down & up alerts are suppressed but the crash loop is detected
+	now = now.Add(time.Minute * 2)
+	transitions, alerts = monitor.update(now, serverDetailsCollection{server})
+	require.Len(t, transitions, 1)
+	require.Equal(t, []healthAlertKind{healthAlertCrashLoop}, alertKinds(alerts))
+
+	now = now.Add(time.Minute)
+	server.LastUpdate = now
+	transitions, alerts = monitor.update(now, serverDetailsCollection{server})
+	require.Len(t, transitions, 1)
+	require.Empty(t, alerts)
+
+	status := monitor.status()
+	require.Len(t, status, 1)
+	require.True(t, status[0].Up)
+	require.Equal(t, now, status[0].Since)
+
+	// Removed servers are no longer tracked
+	_, _ = monitor.update(now, serverDetailsCollection{})
+	require.Empty(t, monitor.status())
+}
+
+func TestHealthMonitorRestore(t *testing.T) {
+	monitor := newHealthMonitor(healthConfig{
+		DownAfter:       "90s",
+		AlertDebounce:   "15m",
+		CrashLoopWindow: "30m",
+		StaleMap:        "0",
+	})
+
+	now := time.Date(2023, time.March, 15, 10, 0, 0, 0, time.UTC)
+
+	monitor.restore([]store.ServerDowntime{{ServerID: 1, StartedOn: now.Add(-time.Hour)}})
+
+	transitions, alerts := monitor.update(now, serverDetailsCollection{{ServerID: 1, NameShort: "us-1"}})
+	require.Empty(t, transitions)
+	require.Empty(t, alerts)
+
+	transitions, alerts = monitor.update(now, serverDetailsCollection{{ServerID: 1, NameShort: "us-1", LastUpdate: now}})
+	require.Len(t, transitions, 1)
+	require.Equal(t, []healthAlertKind{healthAlertUp}, alertKinds(alerts))
+}
+
+func TestHealthMonitorPlayersAndMap(t *testing.T) {
+	monitor := newHealthMonitor(healthConfig{
+		DownAfter:       "90s",
+		AlertDebounce:   "15m",
+		CrashLoopWindow: "30m",
+		BusyPlayers:     10,
+		StaleMap:        "2h",
+	})
+
+	var (
+		now    = time.Date(2023, time.March, 15, 10, 0, 0, 0, time.UTC)
+		server = serverDetails{ServerID: 1, NameShort: "us-1", Map: "pl_upward", MapChangedOn: now, PlayerCount: 24}
+	)
+
+	for i := 0; i < 200; i++ {
+		now = now.Add(healthCheckInterval)
+		server.LastUpdate = now
+		_, alerts := monitor.update(now, serverDetailsCollection{server})
+		require.Empty(t, alerts)
+	}
+
+	server.PlayerCount = 0
+	now = now.Add(healthCheckInterval)
+	server.LastUpdate = now
+	_, alerts := monitor.update(now, serverDetailsCollection{server})
+	require.Equal(t, []healthAlertKind{healthAlertEmpty}, alertKinds(alerts))
+
+	// Only alerted once while empty
+	now = now.Add(healthCheckInterval)
+	server.LastUpdate = now
+	_, alerts = monitor.update(now, serverDetailsCollection{server})
+	require.Empty(t, alerts)
+
+	now = now.Add(time.Hour * 2)
+	server.LastUpdate = now
+	_, alerts = monitor.update(now, serverDetailsCollection{server})
+	require.Equal(t, []healthAlertKind{healthAlertStaleMap}, alertKinds(alerts))
+
+	_, alerts = monitor.update(now.Add(time.Second), serverDetailsCollection{server})
+	require.Empty(t, alerts)
+}
+
+func TestHealthMonitorRCON(t *testing.T) {
+	monitor := newHealthMonitor(healthConfig{
+		DownAfter:       "90s",
+		AlertDebounce:   "15m",
+		CrashLoopWindow: "30m",
+		StaleMap:        "0",
+	})
+
+	var (
+		now    = time.Date(2023, time.March, 15, 10, 0, 0, 0, time.UTC)
+		server = serverDetails{ServerID: 1, NameShort: "us-1", RconPassword: "secret", LastUpdate: now, LastRCON: now}
+	)
+
+	_, alerts := monitor.update(now, serverDetailsCollection{server})
+	require.Empty(t, alerts)
+
+	// Still answering a2s queries while rcon has stopped responding
+	now = now.Add(time.Minute * 2)
+	server.LastUpdate = now
+	transitions, alerts := monitor.update(now, serverDetailsCollection{server})
+	require.Empty(t, transitions)
+	require.Equal(t, []healthAlertKind{healthAlertRCONDown}, alertKinds(alerts))
+
+	status := monitor.status()
+	require.True(t, status[0].Up)
+	require.False(t, status[0].RCONUp)
+
+	now = now.Add(time.Minute)
+	server.LastUpdate = now
+	_, alerts = monitor.update(now, serverDetailsCollection{server})
+	require.Empty(t, alerts)
+
+	now = now.Add(time.Minute)
+	server.LastUpdate = now
+	server.LastRCON = now
+	_, alerts = monitor.update(now, serverDetailsCollection{server})
+	require.Equal(t, []healthAlertKind{healthAlertRCONUp}, alertKinds(alerts))
+	require.True(t, monitor.status()[0].RCONUp)
+
+	// Servers without rcon configured are only checked over a2s
+	other := serverDetails{ServerID: 2, NameShort: "us-2", LastUpdate: now}
+	_, _ = monitor.update(now, serverDetailsCollection{other})
+	now = now.Add(time.Minute * 2)
+	other.LastUpdate = now
+	_, alerts = monitor.update(now, serverDetailsCollection{other})
+	require.Empty(t, alerts)
+}
+
+func TestUptime(t *testing.T) {
+	var (
+		from    = time.Date(2023, time.March, 1, 0, 0, 0, 0, time.UTC)
+		to      = from.AddDate(0, 0, 2)
+		ended   = from.Add(time.Hour * 36)
+		earlier = from.Add(-time.Hour)
+		endedAt = from.Add(time.Hour * 6)
+	)
+
+	downtimes := []store.ServerDowntime{
+		// 6 hours within the window
+		{StartedOn: earlier, EndedOn: &endedAt},
+		// 6 hours on the second day
+		{StartedOn: from.Add(time.Hour * 30), EndedOn: &ended},
+		// Ongoing, 6 hours
+		{StartedOn: to.Add(-time.Hour * 6)},
+	}
+
+	require.InDelta(t, 1-18.0/48.0, uptimeRatio(downtimes, from, to), 0.0001)
+	require.Equal(t, 1.0, uptimeRatio(nil, from, to))
+
+	days := dailyUptime(downtimes, from, to)
+	require.Len(t, days, 2)
+	require.InDelta(t, 1-6.0/24.0, days[0].Uptime, 0.0001)
+	require.InDelta(t, 1-12.0/24.0, days[1].Uptime, 0.0001)
+	require.Equal(t, int64(12*60*60), days[1].Downtime)
+}
@@ -24,6 +24,8 @@ import (
 	"github.com/leighmacdonald/gbans/internal/thirdparty"
 	"github.com/leighmacdonald/gbans/pkg/fp"
 	"github.com/leighmacdonald/gbans/pkg/ip2location"
+	"github.com/leighmacdonald/gbans/pkg/logparse"
+	"github.com/leighmacdonald/gbans/pkg/mm"
 	"github.com/leighmacdonald/gbans/pkg/util"
 	"github.com/leighmacdonald/gbans/pkg/wiki"
 	"github.com/leighmacdonald/srcdsup/srcdsup"
@@ -860,6 +862,35 @@ func onAPIPostServerCheck(app *App) gin.HandlerFunc {
 			log.Error("Failed to add conn history", zap.Error(errAddHist))
 		}
 
+		// The name filter is only checked once the player is known to not be banned so that banned
+		// players are always shown their ban instead of being asked to change their name.
+		checkName := func() bool {
+			matched, filter := app.findNameFilterMatch(app.conf.Filter.Name, request.Name)
+			if filter == nil {
+				return false
+			}
+
+			nameConf := app.conf.Filter.Name
+			if nameConf.Action != Kick {
+				if errApply := app.applyNameFilterAction(responseCtx, nameConf, steamID, "Name",
+					request.Name, matched, filter); errApply != nil {
+					log.Error("Failed to apply name filter action", zap.Error(errApply))
+				}
+			}
+
+			if app.conf.Filter.Dry || (nameConf.Action != Kick && nameConf.Action != Ban) {
+				return false
+			}
+
+			resp.BanType = store.Banned
+			resp.Msg = nameConf.Message
+			responseOK(ctx, http.StatusOK, resp)
+			log.Info("Player dropped", zap.String("drop_type", "name"),
+				zap.Int64("sid64", steamID.Int64()))
+
+			return true
+		}
+
 		// Check IP first
 		banNet, errGetBanNet := app.db.GetBanNetByAddress(responseCtx, request.IP)
 		if errGetBanNet != nil {
@@ -905,6 +936,10 @@ func onAPIPostServerCheck(app *App) gin.HandlerFunc {
 		bannedPerson := store.NewBannedPerson()
 		if errGetBan := app.db.GetBanBySteamID(responseCtx, steamID, &bannedPerson, false); errGetBan != nil {
 			if errors.Is(errGetBan, store.ErrNoResult) {
+				if checkName() {
+					return
+				}
+
 				// No ban, exit early
 				resp.BanType = store.OK
 				responseOK(ctx, http.StatusOK, resp)
@@ -919,6 +954,11 @@ func onAPIPostServerCheck(app *App) gin.HandlerFunc {
 			return
 		}
 
+		// Muted players can still join, so their name is checked the same as unbanned players
+		if bannedPerson.Ban.BanType != store.Banned && checkName() {
+			return
+		}
+
 		resp.BanType = bannedPerson.Ban.BanType
 
 		var reason string
@@ -1433,7 +1473,7 @@ func onAPIPostWordMatch(app *App) gin.HandlerFunc {
 		var matches []store.Filter
 
 		for _, filter := range words {
-			if filter.Match(req.Query) {
+			if _, matched := filter.Match(req.Query); matched {
 				matches = append(matches, filter)
 			}
 		}
@@ -1442,6 +1482,81 @@ func onAPIPostWordMatch(app *App) gin.HandlerFunc {
 	}
 }
 
+// onAPIPostFilterBacktest runs a candidate filter over recent chat history. Since this can take a while
+// on larger datasets, the response is streamed as server-sent events. Zero or more "progress" events
+// are sent, followed by a single "result" or "error" event.
+func onAPIPostFilterBacktest(app *App) gin.HandlerFunc {
+	type backtestOutcome struct {
+		result FilterBacktestResult
+		err    error
+	}
+
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		var req FilterBacktestOpts
+		if errBind := ctx.BindJSON(&req); errBind != nil {
+			responseErr(ctx, http.StatusBadRequest, nil)
+			log.Error("Failed to parse request", zap.Error(errBind))
+
+			return
+		}
+
+		if req.Filter.Pattern == "" {
+			responseErr(ctx, http.StatusBadRequest, nil)
+
+			return
+		}
+
+		if req.Filter.IsRegex {
+			if _, compErr := regexp.Compile(req.Filter.Pattern); compErr != nil {
+				responseErrUser(ctx, http.StatusBadRequest, nil, "Invalid regex: %v", compErr)
+
+				return
+			}
+		}
+
+		var (
+			reqCtx       = ctx.Request.Context()
+			progressChan = make(chan FilterBacktestProgress)
+			doneChan     = make(chan backtestOutcome, 1)
+		)
+
+		go func() {
+			result, errBacktest := BacktestFilter(reqCtx, app.db, req, func(progress FilterBacktestProgress) {
+				select {
+				case progressChan <- progress:
+				case <-reqCtx.Done():
+				}
+			})
+
+			doneChan <- backtestOutcome{result: result, err: errBacktest}
+		}()
+
+		ctx.Stream(func(_ io.Writer) bool {
+			select {
+			case progress := <-progressChan:
+				ctx.SSEvent("progress", progress)
+
+				return true
+			case outcome := <-doneChan:
+				if outcome.err != nil {
+					log.Error("Failed to backtest filter", zap.Error(outcome.err))
+					ctx.SSEvent("error", apiResponse{Status: false, Error: "Failed to run backtest"})
+
+					return false
+				}
+
+				ctx.SSEvent("result", outcome.result)
+
+				return false
+			case <-reqCtx.Done():
+				return false
+			}
+		})
+	}
+}
+
 func onAPIDeleteWordFilter(app *App) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		wordID, wordIDErr := getInt64Param(ctx, "word_id")
@@ -1521,6 +1636,7 @@ func onAPIPostWordFilter(app *App) gin.HandlerFunc {
 			existingFilter.Pattern = filter.Pattern
 			existingFilter.IsRegex = filter.IsRegex
 			existingFilter.IsEnabled = filter.IsEnabled
+			existingFilter.Normalize = filter.Normalize
 
 			if errSave := app.FilterAdd(ctx, &existingFilter); errSave != nil {
 				responseErr(ctx, http.StatusInternalServerError, nil)
@@ -1538,6 +1654,7 @@ func onAPIPostWordFilter(app *App) gin.HandlerFunc {
 				UpdatedOn: now,
 				IsRegex:   filter.IsRegex,
 				IsEnabled: filter.IsEnabled,
+				Normalize: filter.Normalize,
 			}
 
 			if errSave := app.FilterAdd(ctx, &newFilter); errSave != nil {
@@ -1909,19 +2026,76 @@ func onAPIGetMapUsage(app *App) gin.HandlerFunc {
 	}
 }
 
+func onAPIGetMapStats(app *App) gin.HandlerFunc {
+	const (
+		defaultLimit = 10
+		maxLimit     = 100
+	)
+
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		opts := store.MapStatsQuery{
+			MapName:     ctx.Param("map_name"),
+			Bucket:      store.MapTrendBucket(ctx.DefaultQuery("bucket", string(store.MapTrendWeek))),
+			PlayerLimit: defaultLimit,
+		}
+
+		if opts.MapName == "" {
+			responseErrUser(ctx, http.StatusBadRequest, nil, "Invalid map_name")
+
+			return
+		}
+
+		if !opts.Bucket.Valid() {
+			responseErrUser(ctx, http.StatusBadRequest, nil, "Invalid bucket")
+
+			return
+		}
+
+		if limitValue := ctx.Query("limit"); limitValue != "" {
+			parsedLimit, errLimit := strconv.ParseUint(limitValue, 10, 64)
+			if errLimit != nil || parsedLimit == 0 || parsedLimit > maxLimit {
+				responseErrUser(ctx, http.StatusBadRequest, nil, "Limit must be between 1 and %d", maxLimit)
+
+				return
+			}
+
+			opts.PlayerLimit = parsedLimit
+		}
+
+		stats, errStats := app.db.MapStats(ctx, opts)
+		if errStats != nil {
+			if errors.Is(errStats, store.ErrNoResult) {
+				responseErr(ctx, http.StatusNotFound, nil)
+
+				return
+			}
+
+			log.Error("Failed to query map stats", zap.Error(errStats))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		responseOK(ctx, http.StatusOK, stats)
+	}
+}
+
 type serverUpdateRequest struct {
-	ServerName      string  `json:"server_name"`
-	ServerNameShort string  `json:"server_name_short"`
-	Host            string  `json:"host"`
-	Port            int     `json:"port"`
-	ReservedSlots   int     `json:"reserved_slots"`
-	RCON            string  `json:"rcon"`
-	Lat             float64 `json:"lat"`
-	Lon             float64 `json:"lon"`
-	CC              string  `json:"cc"`
-	DefaultMap      string  `json:"default_map"`
-	Region          string  `json:"region"`
-	IsEnabled       bool    `json:"is_enabled"`
+	ServerName      string   `json:"server_name"`
+	ServerNameShort string   `json:"server_name_short"`
+	Host            string   `json:"host"`
+	Port            int      `json:"port"`
+	ReservedSlots   int      `json:"reserved_slots"`
+	RCON            string   `json:"rcon"`
+	Lat             float64  `json:"lat"`
+	Lon             float64  `json:"lon"`
+	CC              string   `json:"cc"`
+	DefaultMap      string   `json:"default_map"`
+	Region          string   `json:"region"`
+	IsEnabled       bool     `json:"is_enabled"`
+	Tags            []string `json:"tags"`
 }
 
 func onAPIPostServerUpdate(app *App) gin.HandlerFunc {
@@ -1962,6 +2136,15 @@ func onAPIPostServerUpdate(app *App) gin.HandlerFunc {
 		server.Region = serverReq.Region
 		server.IsEnabled = serverReq.IsEnabled
 
+		tags, errTags := normalizeServerTags(serverReq.Tags)
+		if errTags != nil {
+			responseErr(ctx, http.StatusBadRequest, nil)
+
+			return
+		}
+
+		server.Tags = tags
+
 		if errSave := app.db.SaveServer(ctx, &server); errSave != nil {
 			responseErr(ctx, http.StatusInternalServerError, nil)
 			log.Error("Failed to update server", zap.Error(errSave))
@@ -2033,6 +2216,15 @@ func onAPIPostServer(app *App) gin.HandlerFunc {
 		server.Region = serverReq.Region
 		server.IsEnabled = serverReq.IsEnabled
 
+		tags, errTags := normalizeServerTags(serverReq.Tags)
+		if errTags != nil {
+			responseErr(ctx, http.StatusBadRequest, nil)
+
+			return
+		}
+
+		server.Tags = tags
+
 		if errSave := app.db.SaveServer(ctx, &server); errSave != nil {
 			responseErr(ctx, http.StatusInternalServerError, nil)
 			log.Error("Failed to save new server", zap.Error(errSave))
@@ -3042,6 +3234,109 @@ func onAPIGetMatch(app *App) gin.HandlerFunc {
 	}
 }
 
+// loadMatchParam loads the match referenced by the match_id url parameter, writing the error response on failure.
+func loadMatchParam(ctx *gin.Context, app *App, log *zap.Logger, match *store.MatchResult) bool {
+	matchID, errID := getUUIDParam(ctx, "match_id")
+	if errID != nil {
+		log.Error("Invalid match_id value", zap.Error(errID))
+		responseErr(ctx, http.StatusBadRequest, nil)
+
+		return false
+	}
+
+	if errMatch := app.db.MatchGetByID(ctx, matchID, match); errMatch != nil {
+		if errors.Is(errMatch, store.ErrNoResult) {
+			responseErr(ctx, http.StatusNotFound, nil)
+
+			return false
+		}
+
+		log.Error("Failed to load match", zap.Error(errMatch))
+		responseErr(ctx, http.StatusInternalServerError, nil)
+
+		return false
+	}
+
+	return true
+}
+
+// onAPIGetMatchLogsTF returns the match using the logs.tf json schema. The response is not wrapped so that it
+// can be consumed directly by existing logs.tf tooling.
+func onAPIGetMatchLogsTF(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		var match store.MatchResult
+		if !loadMatchParam(ctx, app, log, &match) {
+			return
+		}
+
+		ctx.JSON(http.StatusOK, LogsTFExport(&match))
+	}
+}
+
+func onAPIGetMatchRawLog(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		var match store.MatchResult
+		if !loadMatchParam(ctx, app, log, &match) {
+			return
+		}
+
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.log"`, match.MatchID.String()))
+		ctx.Data(http.StatusOK, "text/plain", []byte(RawLogExport(&match)))
+	}
+}
+
+func onAPIGetMatchRounds(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		matchID, errID := getUUIDParam(ctx, "match_id")
+		if errID != nil {
+			log.Error("Invalid match_id value", zap.Error(errID))
+			responseErr(ctx, http.StatusBadRequest, nil)
+
+			return
+		}
+
+		rounds, errRounds := app.db.MatchGetRounds(ctx, matchID)
+		if errRounds != nil {
+			log.Error("Failed to load match rounds", zap.Error(errRounds))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		responseOK(ctx, http.StatusOK, rounds)
+	}
+}
+
+func onAPIGetMatchTimeline(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		matchID, errID := getUUIDParam(ctx, "match_id")
+		if errID != nil {
+			log.Error("Invalid match_id value", zap.Error(errID))
+			responseErr(ctx, http.StatusBadRequest, nil)
+
+			return
+		}
+
+		timeline, errTimeline := app.db.MatchGetTimeline(ctx, matchID)
+		if errTimeline != nil {
+			log.Error("Failed to load match timeline", zap.Error(errTimeline))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		responseOK(ctx, http.StatusOK, timeline)
+	}
+}
+
 type ResultsCount struct {
 	Count int64 `json:"count"`
 }
@@ -3273,114 +3568,279 @@ func onAPIGetPlayerStatsOverall(app *App) gin.HandlerFunc {
 	}
 }
 
-func onAPIGetsStatsWeapon(app *App) gin.HandlerFunc {
-	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+// ratingQueryParams parses the optional game_type & player_class query values used to select a rating, defaulting
+// to the rating across all formats & classes.
+func ratingQueryParams(ctx *gin.Context) (mm.GameType, logparse.PlayerClass, error) {
+	var (
+		gameType    = store.RatingAnyFormat
+		playerClass = store.RatingAnyClass
+	)
 
-	type resp struct {
-		LazyResult
-		Weapon store.Weapon `json:"weapon"`
-	}
+	if value := ctx.Query("game_type"); value != "" {
+		parsed, errParse := strconv.Atoi(value)
+		if errParse != nil || parsed < int(store.RatingAnyFormat) || parsed > int(mm.Ultiduo) {
+			return gameType, playerClass, errors.New("Invalid game_type")
+		}
 
-	return func(ctx *gin.Context) {
-		weaponID, errWeaponID := getIntParam(ctx, "weapon_id")
-		if errWeaponID != nil {
-			responseErr(ctx, http.StatusBadRequest, nil)
+		gameType = mm.GameType(parsed)
+	}
 
-			return
+	if value := ctx.Query("player_class"); value != "" {
+		parsed, errParse := strconv.Atoi(value)
+		if errParse != nil || parsed < int(store.RatingAnyClass) || parsed > int(logparse.Spy) {
+			return gameType, playerClass, errors.New("Invalid player_class")
 		}
 
-		var weapon store.Weapon
+		playerClass = logparse.PlayerClass(parsed)
+	}
 
-		errWeapon := app.db.GetWeaponByID(ctx, weaponID, &weapon)
+	return gameType, playerClass, nil
+}
 
-		if errWeapon != nil {
-			responseErr(ctx, http.StatusNotFound, nil)
+func onAPIGetPlayerRatings(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		steamID, errSteamID := getSID64Param(ctx, "steam_id")
+		if errSteamID != nil {
+			responseErr(ctx, http.StatusBadRequest, nil)
 
 			return
 		}
 
-		weaponStats, errChat := app.db.WeaponsOverallTopPlayers(ctx, weaponID)
-		if errChat != nil && !errors.Is(errChat, store.ErrNoResult) {
-			log.Error("Failed to get weapons overall top stats",
-				zap.Error(errChat))
+		ratings, errRatings := app.db.PlayerRatings(ctx, steamid.Collection{steamID})
+		if errRatings != nil {
+			log.Error("Failed to query player ratings", zap.Error(errRatings))
 			responseErr(ctx, http.StatusInternalServerError, nil)
 
 			return
 		}
 
-		if weaponStats == nil {
-			weaponStats = []store.PlayerWeaponResult{}
-		}
-
-		responseOK(ctx, http.StatusOK, resp{
-			LazyResult: LazyResult{
-				Count: len(weaponStats),
-				Data:  weaponStats,
-			}, Weapon: weapon,
-		})
+		responseOK(ctx, http.StatusOK, ratings)
 	}
 }
 
-func onAPIQueryMessages(app *App) gin.HandlerFunc {
+func onAPIGetPlayerRatingHistory(app *App) gin.HandlerFunc {
+	const (
+		defaultLimit = 100
+		maxLimit     = 1000
+	)
+
 	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
 
 	return func(ctx *gin.Context) {
-		var query store.ChatHistoryQueryFilter
-		if !bind(ctx, &query) {
+		steamID, errSteamID := getSID64Param(ctx, "steam_id")
+		if errSteamID != nil {
+			responseErr(ctx, http.StatusBadRequest, nil)
+
 			return
 		}
 
-		if query.Limit <= 0 || query.Limit > 1000 {
-			query.Limit = 1000
+		gameType, playerClass, errParams := ratingQueryParams(ctx)
+		if errParams != nil {
+			responseErrUser(ctx, http.StatusBadRequest, nil, errParams.Error())
+
+			return
 		}
 
-		user := currentUserProfile(ctx)
-		if user.PermissionLevel <= consts.PUser {
-			query.Unrestricted = false
-			beforeLimit := time.Now().Add(-time.Minute * 20)
+		limit := defaultLimit
 
-			if query.SentBefore != nil && query.SentBefore.After(beforeLimit) {
-				query.SentBefore = &beforeLimit
-			}
+		if limitValue := ctx.Query("limit"); limitValue != "" {
+			parsedLimit, errLimit := strconv.Atoi(limitValue)
+			if errLimit != nil || parsedLimit <= 0 || parsedLimit > maxLimit {
+				responseErrUser(ctx, http.StatusBadRequest, nil, "Limit must be between 1 and %d", maxLimit)
 
-			if query.SentBefore == nil {
-				query.SentBefore = &beforeLimit
+				return
 			}
+
+			limit = parsedLimit
 		}
 
-		messages, totalMessages, errChat := app.db.QueryChatHistory(ctx, query)
-		if errChat != nil && !errors.Is(errChat, store.ErrNoResult) {
-			log.Error("Failed to query messages history",
-				zap.Error(errChat), zap.String("sid", query.SteamID))
+		history, errHistory := app.db.PlayerRatingHistory(ctx, steamID, gameType, playerClass, uint64(limit))
+		if errHistory != nil {
+			log.Error("Failed to query player rating history", zap.Error(errHistory))
 			responseErr(ctx, http.StatusInternalServerError, nil)
 
 			return
 		}
 
-		if messages == nil {
-			messages = []store.QueryChatHistoryResult{}
-		}
-
-		responseOK(ctx, http.StatusOK, messageQueryResults{
-			ResultsCount: ResultsCount{Count: totalMessages},
-			Messages:     messages,
-		})
+		responseOK(ctx, http.StatusOK, history)
 	}
 }
 
-type messageQueryResults struct {
-	ResultsCount
-	Messages []store.QueryChatHistoryResult `json:"messages"`
-}
+func onAPIGetRatingLeaderboard(app *App) gin.HandlerFunc {
+	const (
+		defaultLimit = 100
+		maxLimit     = 500
+	)
 
-func onAPIGetPersonMessages(app *App) gin.HandlerFunc {
 	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
 
 	return func(ctx *gin.Context) {
-		steamID, errID := getSID64Param(ctx, "steam_id")
-		if errID != nil {
-			log.Error("Invalid steam_id value", zap.Error(errID))
-			responseErr(ctx, http.StatusBadRequest, nil)
+		gameType, playerClass, errParams := ratingQueryParams(ctx)
+		if errParams != nil {
+			responseErrUser(ctx, http.StatusBadRequest, nil, errParams.Error())
+
+			return
+		}
+
+		opts := store.RatingLeaderboardOpts{
+			GameType:    gameType,
+			PlayerClass: playerClass,
+			MinMatches:  app.conf.Rating.LeaderboardMinMatches,
+			Limit:       defaultLimit,
+		}
+
+		if limitValue := ctx.Query("limit"); limitValue != "" {
+			parsedLimit, errLimit := strconv.ParseUint(limitValue, 10, 64)
+			if errLimit != nil || parsedLimit == 0 || parsedLimit > maxLimit {
+				responseErrUser(ctx, http.StatusBadRequest, nil, "Limit must be between 1 and %d", maxLimit)
+
+				return
+			}
+
+			opts.Limit = parsedLimit
+		}
+
+		if offsetValue := ctx.Query("offset"); offsetValue != "" {
+			parsedOffset, errOffset := strconv.ParseUint(offsetValue, 10, 64)
+			if errOffset != nil {
+				responseErr(ctx, http.StatusBadRequest, nil)
+
+				return
+			}
+
+			opts.Offset = parsedOffset
+		}
+
+		entries, count, errLeaderboard := app.db.RatingLeaderboard(ctx, opts)
+		if errLeaderboard != nil {
+			log.Error("Failed to query rating leaderboard", zap.Error(errLeaderboard))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		responseOK(ctx, http.StatusOK, LazyResult{Count: int(count), Data: entries})
+	}
+}
+
+func onAPIGetsStatsWeapon(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	type resp struct {
+		LazyResult
+		Weapon store.Weapon `json:"weapon"`
+	}
+
+	return func(ctx *gin.Context) {
+		weaponID, errWeaponID := getIntParam(ctx, "weapon_id")
+		if errWeaponID != nil {
+			responseErr(ctx, http.StatusBadRequest, nil)
+
+			return
+		}
+
+		var weapon store.Weapon
+
+		errWeapon := app.db.GetWeaponByID(ctx, weaponID, &weapon)
+
+		if errWeapon != nil {
+			responseErr(ctx, http.StatusNotFound, nil)
+
+			return
+		}
+
+		weaponStats, errChat := app.db.WeaponsOverallTopPlayers(ctx, weaponID)
+		if errChat != nil && !errors.Is(errChat, store.ErrNoResult) {
+			log.Error("Failed to get weapons overall top stats",
+				zap.Error(errChat))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		if weaponStats == nil {
+			weaponStats = []store.PlayerWeaponResult{}
+		}
+
+		responseOK(ctx, http.StatusOK, resp{
+			LazyResult: LazyResult{
+				Count: len(weaponStats),
+				Data:  weaponStats,
+			}, Weapon: weapon,
+		})
+	}
+}
+
+func onAPIQueryMessages(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		var query store.ChatHistoryQueryFilter
+		if !bind(ctx, &query) {
+			return
+		}
+
+		if query.Limit <= 0 || query.Limit > 1000 {
+			query.Limit = 1000
+		}
+
+		user := currentUserProfile(ctx)
+		if user.PermissionLevel <= consts.PUser {
+			query.Unrestricted = false
+			beforeLimit := time.Now().Add(-time.Minute * 20)
+
+			if query.SentBefore != nil && query.SentBefore.After(beforeLimit) {
+				query.SentBefore = &beforeLimit
+			}
+
+			if query.SentBefore == nil {
+				query.SentBefore = &beforeLimit
+			}
+		}
+
+		messages, totalMessages, errChat := app.db.QueryChatHistory(ctx, query)
+		if errChat != nil && !errors.Is(errChat, store.ErrNoResult) {
+			log.Error("Failed to query messages history",
+				zap.Error(errChat), zap.String("sid", query.SteamID))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		if messages == nil {
+			messages = []store.QueryChatHistoryResult{}
+		}
+
+		results := messageQueryResults{
+			ResultsCount: ResultsCount{Count: totalMessages},
+			Messages:     messages,
+		}
+
+		// Only a full page can have more results after it
+		if len(messages) > 0 && uint64(len(messages)) == query.Limit {
+			results.NextCursor = store.NewChatHistoryCursor(messages[len(messages)-1]).Encode()
+		}
+
+		responseOK(ctx, http.StatusOK, results)
+	}
+}
+
+type messageQueryResults struct {
+	ResultsCount
+	Messages   []store.QueryChatHistoryResult `json:"messages"`
+	NextCursor string                         `json:"next_cursor,omitempty"`
+}
+
+func onAPIGetPersonMessages(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		steamID, errID := getSID64Param(ctx, "steam_id")
+		if errID != nil {
+			log.Error("Invalid steam_id value", zap.Error(errID))
+			responseErr(ctx, http.StatusBadRequest, nil)
 
 			return
 		}
@@ -4029,3 +4489,1099 @@ func onAPIPostServerState(app *App) gin.HandlerFunc {
 		responseOK(ctx, http.StatusNoContent, "")
 	}
 }
+
+func onAPIPostDataExport(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		user := currentUserProfile(ctx)
+
+		export, errExport := app.RequestDataExport(ctx, user.SteamID)
+		if errExport != nil {
+			if errors.Is(errExport, errDataExportCooldown) {
+				responseErrUser(ctx, http.StatusTooManyRequests, nil, "An export was already requested in the last 24 hours")
+
+				return
+			}
+
+			log.Error("Failed to request data export", zap.Error(errExport))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		responseOKUser(ctx, http.StatusCreated, export, "Export requested, you will be notified when it is ready")
+	}
+}
+
+func onAPIGetDataExports(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		user := currentUserProfile(ctx)
+
+		exports, errExports := app.db.GetDataExports(ctx, user.SteamID)
+		if errExports != nil && !errors.Is(errExports, store.ErrNoResult) {
+			log.Error("Failed to get data exports", zap.Error(errExports))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		if exports == nil {
+			exports = []store.DataExport{}
+		}
+
+		responseOK(ctx, http.StatusOK, exports)
+	}
+}
+
+func onAPIGetDataExportDownload(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		dataExportID, errID := getInt64Param(ctx, "data_export_id")
+		if errID != nil {
+			responseErr(ctx, http.StatusBadRequest, nil)
+
+			return
+		}
+
+		var export store.DataExport
+		if errExport := app.db.GetDataExport(ctx, dataExportID, &export); errExport != nil {
+			if errors.Is(errExport, store.ErrNoResult) {
+				responseErr(ctx, http.StatusNotFound, nil)
+
+				return
+			}
+
+			log.Error("Failed to get data export", zap.Error(errExport))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		// Exports are only ever available to the player they belong to
+		if export.SteamID != currentUserProfile(ctx).SteamID {
+			responseErr(ctx, http.StatusNotFound, nil)
+
+			return
+		}
+
+		if export.Status != store.DataExportComplete {
+			responseErr(ctx, http.StatusConflict, "Export is not ready")
+
+			return
+		}
+
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, dataExportFilename(export)))
+		ctx.Data(http.StatusOK, "application/zip", export.Contents)
+	}
+}
+
+func onAPIPostErasure(app *App) gin.HandlerFunc {
+	type req struct {
+		Reason string `json:"reason"`
+	}
+
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		var request req
+		if !bind(ctx, &request) {
+			return
+		}
+
+		erasure, errErasure := app.RequestErasure(ctx, currentUserProfile(ctx).SteamID, request.Reason)
+		if errErasure != nil {
+			switch {
+			case errors.Is(errErasure, errErasureExists):
+				responseErrUser(ctx, http.StatusConflict, erasure, "An erasure request is already in progress")
+			case errors.Is(errErasure, errAlreadyErased):
+				responseErrUser(ctx, http.StatusConflict, nil, "Your data has already been erased")
+			default:
+				log.Error("Failed to request erasure", zap.Error(errErasure))
+				responseErr(ctx, http.StatusInternalServerError, nil)
+			}
+
+			return
+		}
+
+		responseOKUser(ctx, http.StatusCreated, erasure, "Erasure requested, it will be reviewed by a moderator")
+	}
+}
+
+func onAPIGetErasures(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		erasures, errErasures := app.db.GetErasures(ctx, store.ErasureQueryFilter{SteamID: currentUserProfile(ctx).SteamID})
+		if errErasures != nil && !errors.Is(errErasures, store.ErrNoResult) {
+			log.Error("Failed to get erasures", zap.Error(errErasures))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		if erasures == nil {
+			erasures = []store.PersonErasure{}
+		}
+
+		responseOK(ctx, http.StatusOK, erasures)
+	}
+}
+
+func onAPIQueryErasures(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		var filter store.ErasureQueryFilter
+		if !bind(ctx, &filter) {
+			return
+		}
+
+		erasures, errErasures := app.db.GetErasures(ctx, filter)
+		if errErasures != nil && !errors.Is(errErasures, store.ErrNoResult) {
+			log.Error("Failed to query erasures", zap.Error(errErasures))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		if erasures == nil {
+			erasures = []store.PersonErasure{}
+		}
+
+		responseOK(ctx, http.StatusOK, erasures)
+	}
+}
+
+func onAPIPostErasureReview(app *App) gin.HandlerFunc {
+	type req struct {
+		Approve bool   `json:"approve"`
+		Note    string `json:"note"`
+	}
+
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		erasureID, errID := getInt64Param(ctx, "erasure_id")
+		if errID != nil {
+			responseErr(ctx, http.StatusBadRequest, nil)
+
+			return
+		}
+
+		var request req
+		if !bind(ctx, &request) {
+			return
+		}
+
+		erasure, errReview := app.ReviewErasure(ctx, erasureID, currentUserProfile(ctx).SteamID, request.Approve, request.Note)
+		if errReview != nil {
+			switch {
+			case errors.Is(errReview, store.ErrNoResult):
+				responseErr(ctx, http.StatusNotFound, nil)
+			case errors.Is(errReview, errErasureNotPending):
+				responseErrUser(ctx, http.StatusConflict, erasure, "Erasure has already been reviewed")
+			default:
+				log.Error("Failed to review erasure", zap.Error(errReview))
+				responseErr(ctx, http.StatusInternalServerError, nil)
+			}
+
+			return
+		}
+
+		responseOK(ctx, http.StatusOK, erasure)
+	}
+}
+
+func onAPIQueryAuditLog(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		var filter store.AuditQueryFilter
+		if !bind(ctx, &filter) {
+			return
+		}
+
+		entries, errEntries := app.db.GetAuditEntries(ctx, filter)
+		if errEntries != nil && !errors.Is(errEntries, store.ErrNoResult) {
+			log.Error("Failed to query audit log", zap.Error(errEntries))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		if entries == nil {
+			entries = []store.AuditEntry{}
+		}
+
+		responseOK(ctx, http.StatusOK, entries)
+	}
+}
+
+func onAPIGetPersonNames(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		steamID, errID := getSID64Param(ctx, "steam_id")
+		if errID != nil {
+			responseErr(ctx, http.StatusBadRequest, nil)
+
+			return
+		}
+
+		names, errNames := app.db.GetPersonNames(ctx, steamID)
+		if errNames != nil && !errors.Is(errNames, store.ErrNoResult) {
+			log.Error("Failed to get name history", zap.Error(errNames), zap.Int64("sid64", steamID.Int64()))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		if names == nil {
+			names = []store.PersonName{}
+		}
+
+		responseOK(ctx, http.StatusOK, names)
+	}
+}
+
+func onAPISearchPersonNames(app *App) gin.HandlerFunc {
+	type req struct {
+		Name  string `json:"name"`
+		Limit uint64 `json:"limit"`
+	}
+
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		var request req
+		if !bind(ctx, &request) {
+			return
+		}
+
+		request.Name = strings.TrimSpace(request.Name)
+		if len(request.Name) < 3 {
+			responseErrUser(ctx, http.StatusBadRequest, nil, "Name must be at least 3 characters")
+
+			return
+		}
+
+		if request.Limit == 0 || request.Limit > 100 {
+			request.Limit = 100
+		}
+
+		results, errSearch := app.db.SearchPersonNames(ctx, request.Name, request.Limit)
+		if errSearch != nil && !errors.Is(errSearch, store.ErrNoResult) {
+			log.Error("Failed to search names", zap.Error(errSearch))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		if results == nil {
+			results = []store.PersonNameSearchResult{}
+		}
+
+		responseOK(ctx, http.StatusOK, results)
+	}
+}
+
+type personNoteRequest struct {
+	BodyMD   string             `json:"body_md"`
+	Severity store.NoteSeverity `json:"severity"`
+}
+
+func (req personNoteRequest) validate() bool {
+	return strings.TrimSpace(req.BodyMD) != "" && req.Severity >= store.NoteInfo && req.Severity <= store.NoteCritical
+}
+
+func onAPIGetPersonNotes(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		steamID, errID := getSID64Param(ctx, "steam_id")
+		if errID != nil {
+			responseErr(ctx, http.StatusBadRequest, nil)
+
+			return
+		}
+
+		notes, errNotes := app.db.GetPersonNotes(ctx, steamID)
+		if errNotes != nil && !errors.Is(errNotes, store.ErrNoResult) {
+			log.Error("Failed to get person notes", zap.Error(errNotes), zap.Int64("sid64", steamID.Int64()))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		if notes == nil {
+			notes = []store.PersonNote{}
+		}
+
+		responseOK(ctx, http.StatusOK, notes)
+	}
+}
+
+func onAPIPostPersonNote(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		steamID, errID := getSID64Param(ctx, "steam_id")
+		if errID != nil {
+			responseErr(ctx, http.StatusBadRequest, nil)
+
+			return
+		}
+
+		var request personNoteRequest
+		if !bind(ctx, &request) {
+			return
+		}
+
+		if !request.validate() {
+			responseErr(ctx, http.StatusBadRequest, nil)
+
+			return
+		}
+
+		var person store.Person
+		if errPerson := app.PersonBySID(ctx, steamID, &person); errPerson != nil {
+			log.Error("Failed to load person", zap.Error(errPerson))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		author := currentUserProfile(ctx)
+		note := store.NewPersonNote(steamID, author.SteamID, request.BodyMD, request.Severity)
+
+		if errSave := app.db.SavePersonNote(ctx, &note, author.SteamID); errSave != nil {
+			log.Error("Failed to save person note", zap.Error(errSave))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		note.AuthorName = author.Name
+
+		responseOK(ctx, http.StatusCreated, note)
+	}
+}
+
+func onAPIEditPersonNote(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		personNoteID, errID := getInt64Param(ctx, "person_note_id")
+		if errID != nil {
+			responseErr(ctx, http.StatusBadRequest, nil)
+
+			return
+		}
+
+		var request personNoteRequest
+		if !bind(ctx, &request) {
+			return
+		}
+
+		if !request.validate() {
+			responseErr(ctx, http.StatusBadRequest, nil)
+
+			return
+		}
+
+		var note store.PersonNote
+		if errNote := app.db.GetPersonNote(ctx, personNoteID, &note); errNote != nil {
+			if errors.Is(errNote, store.ErrNoResult) {
+				responseErr(ctx, http.StatusNotFound, nil)
+
+				return
+			}
+
+			log.Error("Failed to load person note", zap.Error(errNote))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		if note.Deleted {
+			responseErr(ctx, http.StatusNotFound, nil)
+
+			return
+		}
+
+		note.BodyMD = request.BodyMD
+		note.Severity = request.Severity
+
+		if errSave := app.db.SavePersonNote(ctx, &note, currentUserProfile(ctx).SteamID); errSave != nil {
+			log.Error("Failed to save person note", zap.Error(errSave))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		responseOK(ctx, http.StatusOK, note)
+	}
+}
+
+func onAPIDeletePersonNote(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		personNoteID, errID := getInt64Param(ctx, "person_note_id")
+		if errID != nil {
+			responseErr(ctx, http.StatusBadRequest, nil)
+
+			return
+		}
+
+		if errDelete := app.db.DeletePersonNote(ctx, personNoteID); errDelete != nil {
+			log.Error("Failed to delete person note", zap.Error(errDelete))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		responseOK(ctx, http.StatusOK, nil)
+	}
+}
+
+func onAPIGetPersonNoteRevisions(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		personNoteID, errID := getInt64Param(ctx, "person_note_id")
+		if errID != nil {
+			responseErr(ctx, http.StatusBadRequest, nil)
+
+			return
+		}
+
+		revisions, errRevisions := app.db.GetPersonNoteRevisions(ctx, personNoteID)
+		if errRevisions != nil && !errors.Is(errRevisions, store.ErrNoResult) {
+			log.Error("Failed to get note revisions", zap.Error(errRevisions))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		if revisions == nil {
+			revisions = []store.PersonNoteRevision{}
+		}
+
+		responseOK(ctx, http.StatusOK, revisions)
+	}
+}
+
+type timelineResults struct {
+	Events     []store.TimelineEvent `json:"events"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+}
+
+func onAPIGetPersonTimeline(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		steamID, errID := getSID64Param(ctx, "steam_id")
+		if errID != nil {
+			responseErr(ctx, http.StatusBadRequest, nil)
+
+			return
+		}
+
+		var filter store.TimelineQueryFilter
+		if !bind(ctx, &filter) {
+			return
+		}
+
+		filter.SteamID = steamID
+
+		events, nextCursor, errTimeline := app.db.GetPersonTimeline(ctx, filter)
+		if errTimeline != nil {
+			if errors.Is(errTimeline, store.ErrInvalidTimelineType) {
+				responseErr(ctx, http.StatusBadRequest, nil)
+
+				return
+			}
+
+			log.Error("Failed to get person timeline", zap.Error(errTimeline), zap.Int64("sid64", steamID.Int64()))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		responseOK(ctx, http.StatusOK, timelineResults{Events: events, NextCursor: nextCursor})
+	}
+}
+
+type scheduledTaskRequest struct {
+	Name           string                  `json:"name"`
+	CronExpression string                  `json:"cron_expression"`
+	Timezone       string                  `json:"timezone"`
+	TaskType       store.ScheduledTaskType `json:"task_type"`
+	Command        string                  `json:"command"`
+	ServerSelector string                  `json:"server_selector"`
+	PlayersBelow   *int                    `json:"players_below"`
+	PlayersAtLeast *int                    `json:"players_at_least"`
+	AlertOnFailure bool                    `json:"alert_on_failure"`
+	IsEnabled      bool                    `json:"is_enabled"`
+}
+
+func (req scheduledTaskRequest) apply(task *store.ScheduledTask) {
+	task.Name = req.Name
+	task.CronExpression = req.CronExpression
+	task.Timezone = req.Timezone
+	task.TaskType = req.TaskType
+	task.Command = req.Command
+	task.ServerSelector = req.ServerSelector
+	task.PlayersBelow = req.PlayersBelow
+	task.PlayersAtLeast = req.PlayersAtLeast
+	task.AlertOnFailure = req.AlertOnFailure
+	task.IsEnabled = req.IsEnabled
+}
+
+func onAPIGetScheduledTasks(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		tasks, errTasks := app.db.GetScheduledTasks(ctx, false)
+		if errTasks != nil {
+			log.Error("Failed to get scheduled tasks", zap.Error(errTasks))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		responseOK(ctx, http.StatusOK, tasks)
+	}
+}
+
+func saveScheduledTask(ctx *gin.Context, app *App, log *zap.Logger, task *store.ScheduledTask) bool {
+	var req scheduledTaskRequest
+	if !bind(ctx, &req) {
+		return false
+	}
+
+	req.apply(task)
+
+	if errValidate := validateScheduledTask(task); errValidate != nil {
+		responseErrUser(ctx, http.StatusBadRequest, nil, "%v", errValidate)
+
+		return false
+	}
+
+	if errSave := app.db.SaveScheduledTask(ctx, task); errSave != nil {
+		log.Error("Failed to save scheduled task", zap.Error(errSave))
+		responseErr(ctx, http.StatusInternalServerError, nil)
+
+		return false
+	}
+
+	app.audit(ctx, currentUserProfile(ctx).SteamID, "", store.AuditTaskSaved,
+		fmt.Sprintf("task_id=%d name=%s", task.ScheduledTaskID, task.Name))
+
+	return true
+}
+
+func onAPIPostScheduledTask(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		var task store.ScheduledTask
+		if !saveScheduledTask(ctx, app, log, &task) {
+			return
+		}
+
+		responseOK(ctx, http.StatusCreated, task)
+	}
+}
+
+func onAPIEditScheduledTask(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		taskID, errID := getInt64Param(ctx, "scheduled_task_id")
+		if errID != nil {
+			responseErr(ctx, http.StatusBadRequest, nil)
+
+			return
+		}
+
+		var task store.ScheduledTask
+		if errTask := app.db.GetScheduledTask(ctx, taskID, &task); errTask != nil {
+			if errors.Is(errTask, store.ErrNoResult) {
+				responseErr(ctx, http.StatusNotFound, nil)
+
+				return
+			}
+
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		if !saveScheduledTask(ctx, app, log, &task) {
+			return
+		}
+
+		responseOK(ctx, http.StatusOK, task)
+	}
+}
+
+func onAPIDeleteScheduledTask(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		taskID, errID := getInt64Param(ctx, "scheduled_task_id")
+		if errID != nil {
+			responseErr(ctx, http.StatusBadRequest, nil)
+
+			return
+		}
+
+		if errDelete := app.db.DeleteScheduledTask(ctx, taskID); errDelete != nil {
+			log.Error("Failed to delete scheduled task", zap.Error(errDelete))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		app.audit(ctx, currentUserProfile(ctx).SteamID, "", store.AuditTaskDeleted, fmt.Sprintf("task_id=%d", taskID))
+
+		responseOK(ctx, http.StatusOK, nil)
+	}
+}
+
+// onAPIPostScheduledTaskRun executes the task immediately, regardless of its schedule or enabled state.
+func onAPIPostScheduledTaskRun(app *App) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		taskID, errID := getInt64Param(ctx, "scheduled_task_id")
+		if errID != nil {
+			responseErr(ctx, http.StatusBadRequest, nil)
+
+			return
+		}
+
+		var task store.ScheduledTask
+		if errTask := app.db.GetScheduledTask(ctx, taskID, &task); errTask != nil {
+			if errors.Is(errTask, store.ErrNoResult) {
+				responseErr(ctx, http.StatusNotFound, nil)
+
+				return
+			}
+
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		app.audit(ctx, currentUserProfile(ctx).SteamID, "", store.AuditTaskExecuted, fmt.Sprintf("task_id=%d", taskID))
+
+		responseOK(ctx, http.StatusOK, app.runScheduledTask(ctx, task))
+	}
+}
+
+func onAPIGetScheduledTaskRuns(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		taskID, errID := getInt64Param(ctx, "scheduled_task_id")
+		if errID != nil {
+			responseErr(ctx, http.StatusBadRequest, nil)
+
+			return
+		}
+
+		runs, errRuns := app.db.GetScheduledTaskRuns(ctx, taskID, 100)
+		if errRuns != nil {
+			log.Error("Failed to get scheduled task runs", zap.Error(errRuns))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		responseOK(ctx, http.StatusOK, runs)
+	}
+}
+
+func onAPIGetServersHealth(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		statuses, errStatuses := app.serverHealthStatuses(ctx)
+		if errStatuses != nil {
+			log.Error("Failed to get server health", zap.Error(errStatuses))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		responseOK(ctx, http.StatusOK, statuses)
+	}
+}
+
+type serverUptimeHistory struct {
+	ServerID int `json:"server_id"`
+	// SLA is the uptime ratio, 0-1, over the entire period
+	SLA       float64                `json:"sla"`
+	Days      []uptimeDay            `json:"days"`
+	Downtimes []store.ServerDowntime `json:"downtimes"`
+}
+
+func onAPIGetServerHealthHistory(app *App) gin.HandlerFunc {
+	const (
+		defaultDays = 30
+		maxDays     = 365
+	)
+
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		serverID, errServerID := getIntParam(ctx, "server_id")
+		if errServerID != nil {
+			responseErr(ctx, http.StatusBadRequest, nil)
+
+			return
+		}
+
+		days := defaultDays
+
+		if daysValue := ctx.Query("days"); daysValue != "" {
+			parsedDays, errDays := strconv.Atoi(daysValue)
+			if errDays != nil || parsedDays <= 0 || parsedDays > maxDays {
+				responseErrUser(ctx, http.StatusBadRequest, nil, "Days must be between 1 and %d", maxDays)
+
+				return
+			}
+
+			days = parsedDays
+		}
+
+		var server store.Server
+		if errServer := app.db.GetServer(ctx, serverID, &server); errServer != nil {
+			if errors.Is(errServer, store.ErrNoResult) {
+				responseErr(ctx, http.StatusNotFound, nil)
+
+				return
+			}
+
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		var (
+			now  = time.Now()
+			from = now.UTC().Truncate(time.Hour*24).AddDate(0, 0, -(days - 1))
+		)
+
+		// Time before the server was added does not count against its uptime.
+		if server.CreatedOn.After(from) {
+			from = server.CreatedOn
+		}
+
+		downtimes, errDowntimes := app.db.GetServerDowntimes(ctx, serverID, from)
+		if errDowntimes != nil {
+			log.Error("Failed to get server downtime", zap.Error(errDowntimes))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		responseOK(ctx, http.StatusOK, serverUptimeHistory{
+			ServerID:  serverID,
+			SLA:       uptimeRatio(downtimes, from, now),
+			Days:      dailyUptime(downtimes, from, now),
+			Downtimes: downtimes,
+		})
+	}
+}
+
+func onAPIGetAnomalies(app *App) gin.HandlerFunc {
+	const (
+		defaultLimit = 100
+		maxLimit     = 500
+	)
+
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		opts := store.AnomalyQueryFilter{
+			QueryFilter: store.QueryFilter{Limit: defaultLimit},
+			Status:      store.AnomalyOpen,
+		}
+
+		if statusValue := ctx.Query("status"); statusValue != "" {
+			status, errStatus := strconv.ParseInt(statusValue, 10, 32)
+			if errStatus != nil || status < int64(store.AnomalyStatusAny) || status > int64(store.AnomalyReported) {
+				responseErrUser(ctx, http.StatusBadRequest, nil, "Invalid status")
+
+				return
+			}
+
+			opts.Status = store.AnomalyStatus(status)
+		}
+
+		if steamIDValue := ctx.Query("steam_id"); steamIDValue != "" {
+			steamID := steamid.New(steamIDValue)
+			if !steamID.Valid() {
+				responseErrUser(ctx, http.StatusBadRequest, nil, "Invalid steam_id")
+
+				return
+			}
+
+			opts.SteamID = steamID
+		}
+
+		if limitValue := ctx.Query("limit"); limitValue != "" {
+			parsedLimit, errLimit := strconv.ParseUint(limitValue, 10, 64)
+			if errLimit != nil || parsedLimit == 0 || parsedLimit > maxLimit {
+				responseErrUser(ctx, http.StatusBadRequest, nil, "Limit must be between 1 and %d", maxLimit)
+
+				return
+			}
+
+			opts.Limit = parsedLimit
+		}
+
+		if offsetValue := ctx.Query("offset"); offsetValue != "" {
+			parsedOffset, errOffset := strconv.ParseUint(offsetValue, 10, 64)
+			if errOffset != nil {
+				responseErr(ctx, http.StatusBadRequest, nil)
+
+				return
+			}
+
+			opts.Offset = parsedOffset
+		}
+
+		anomalies, count, errAnomalies := app.db.Anomalies(ctx, opts)
+		if errAnomalies != nil {
+			log.Error("Failed to query anomalies", zap.Error(errAnomalies))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		responseOK(ctx, http.StatusOK, LazyResult{Count: int(count), Data: anomalies})
+	}
+}
+
+// loadAnomalyParam loads the anomaly referenced by the anomaly_id path parameter, responding with an error
+// when it could not be loaded.
+func loadAnomalyParam(ctx *gin.Context, app *App, log *zap.Logger, anomaly *store.Anomaly) bool {
+	anomalyID, errID := getInt64Param(ctx, "anomaly_id")
+	if errID != nil || anomalyID <= 0 {
+		responseErr(ctx, http.StatusBadRequest, "Invalid anomaly_id")
+
+		return false
+	}
+
+	if errAnomaly := app.db.GetAnomaly(ctx, anomalyID, anomaly); errAnomaly != nil {
+		if errors.Is(errAnomaly, store.ErrNoResult) {
+			responseErr(ctx, http.StatusNotFound, nil)
+
+			return false
+		}
+
+		log.Error("Failed to load anomaly", zap.Error(errAnomaly))
+		responseErr(ctx, http.StatusInternalServerError, nil)
+
+		return false
+	}
+
+	return true
+}
+
+func onAPIPostAnomalyStatus(app *App) gin.HandlerFunc {
+	type setStatusReq struct {
+		Status store.AnomalyStatus `json:"status"`
+	}
+
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		var req setStatusReq
+		if errBind := ctx.BindJSON(&req); errBind != nil {
+			responseErr(ctx, http.StatusBadRequest, "Invalid request")
+
+			return
+		}
+
+		// Reported is only set by filing a report
+		if req.Status != store.AnomalyOpen && req.Status != store.AnomalyDismissed {
+			responseErrUser(ctx, http.StatusBadRequest, nil, "Invalid status")
+
+			return
+		}
+
+		var anomaly store.Anomaly
+		if !loadAnomalyParam(ctx, app, log, &anomaly) {
+			return
+		}
+
+		if anomaly.Status == req.Status {
+			responseErr(ctx, http.StatusConflict, "State must be different than previous")
+
+			return
+		}
+
+		original := anomaly.Status
+		anomaly.Status = req.Status
+
+		if errSave := app.db.SaveAnomaly(ctx, &anomaly); errSave != nil {
+			log.Error("Failed to save anomaly status", zap.Error(errSave))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		responseOK(ctx, http.StatusAccepted, anomaly)
+		log.Info("Updated anomaly status",
+			zap.Int64("anomaly_id", anomaly.AnomalyID),
+			zap.String("from_state", original.String()),
+			zap.String("to_state", anomaly.Status.String()))
+	}
+}
+
+func onAPIPostAnomalyReport(app *App) gin.HandlerFunc {
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		var anomaly store.Anomaly
+		if !loadAnomalyParam(ctx, app, log, &anomaly) {
+			return
+		}
+
+		if anomaly.Status == store.AnomalyReported {
+			responseErrUser(ctx, http.StatusConflict, nil, "Anomaly already reported")
+
+			return
+		}
+
+		report, errReport := app.reportAnomalies(ctx, []store.Anomaly{anomaly})
+		if errReport != nil {
+			log.Error("Failed to report anomaly", zap.Error(errReport))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		responseOK(ctx, http.StatusCreated, report)
+	}
+}
+
+func onAPIGetLeaderboard(app *App) gin.HandlerFunc {
+	const (
+		defaultLimit = 100
+		maxLimit     = 500
+	)
+
+	type leaderboardResponse struct {
+		Board   store.LeaderboardBoard   `json:"board"`
+		Period  string                   `json:"period"`
+		Start   time.Time                `json:"start"`
+		End     time.Time                `json:"end"`
+		Scope   store.LeaderboardScope   `json:"scope"`
+		Entries []store.LeaderboardEntry `json:"entries"`
+	}
+
+	periods := map[string]store.LeaderboardPeriod{}
+	for _, period := range leaderboardPeriods {
+		periods[period.String()] = period
+	}
+
+	log := app.log.Named(runtime.FuncForPC(make([]uintptr, 10)[0]).Name())
+
+	return func(ctx *gin.Context) {
+		opts := store.LeaderboardQuery{
+			Board: store.LeaderboardBoard(ctx.DefaultQuery("board", string(store.LeaderboardKills))),
+			Limit: defaultLimit,
+		}
+
+		switch opts.Board {
+		case store.LeaderboardKills, store.LeaderboardDamage, store.LeaderboardHealing, store.LeaderboardWins:
+		case store.LeaderboardWeapon:
+			weaponID, errWeaponID := strconv.Atoi(ctx.Query("weapon_id"))
+			if errWeaponID != nil || weaponID <= 0 {
+				responseErrUser(ctx, http.StatusBadRequest, nil, "Invalid weapon_id")
+
+				return
+			}
+
+			opts.WeaponID = weaponID
+		default:
+			responseErrUser(ctx, http.StatusBadRequest, nil, "Invalid board")
+
+			return
+		}
+
+		period, found := periods[ctx.DefaultQuery("period", store.LeaderboardWeekly.String())]
+		if !found {
+			responseErrUser(ctx, http.StatusBadRequest, nil, "Invalid period")
+
+			return
+		}
+
+		var serverID int
+
+		if serverIDValue := ctx.Query("server_id"); serverIDValue != "" {
+			parsedServerID, errServerID := strconv.Atoi(serverIDValue)
+			if errServerID != nil || parsedServerID <= 0 {
+				responseErrUser(ctx, http.StatusBadRequest, nil, "Invalid server_id")
+
+				return
+			}
+
+			serverID = parsedServerID
+		}
+
+		scope, errScope := leaderboardScopeFor(serverID, ctx.Query("region"))
+		if errScope != nil {
+			responseErrUser(ctx, http.StatusBadRequest, nil, errScope.Error())
+
+			return
+		}
+
+		if limitValue := ctx.Query("limit"); limitValue != "" {
+			parsedLimit, errLimit := strconv.ParseUint(limitValue, 10, 64)
+			if errLimit != nil || parsedLimit == 0 || parsedLimit > maxLimit {
+				responseErrUser(ctx, http.StatusBadRequest, nil, "Limit must be between 1 and %d", maxLimit)
+
+				return
+			}
+
+			opts.Limit = parsedLimit
+		}
+
+		opts.Scope = scope
+		opts.Window = leaderboardWindow(app.conf.Leaderboard, period, time.Now())
+
+		// Previous windows are kept as snapshots of the final standings
+		if previous, _ := strconv.ParseBool(ctx.Query("previous")); previous {
+			opts.Window = previousLeaderboardWindow(app.conf.Leaderboard, opts.Window)
+		}
+
+		opts.PreviousStart = previousLeaderboardWindow(app.conf.Leaderboard, opts.Window).Start
+
+		entries, errEntries := app.db.Leaderboard(ctx, opts)
+		if errEntries != nil {
+			log.Error("Failed to query leaderboard", zap.Error(errEntries))
+			responseErr(ctx, http.StatusInternalServerError, nil)
+
+			return
+		}
+
+		responseOK(ctx, http.StatusOK, leaderboardResponse{
+			Board:   opts.Board,
+			Period:  period.String(),
+			Start:   opts.Window.Start,
+			End:     leaderboardWindowEnd(app.conf.Leaderboard, opts.Window),
+			Scope:   scope,
+			Entries: entries,
+		})
+	}
+}
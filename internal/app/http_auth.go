@@ -505,7 +505,7 @@ func authMiddleware(app *App, level consts.Privilege) gin.HandlerFunc {
 
 	return func(ctx *gin.Context) {
 		var token string
-		if ctx.FullPath() == "/ws" {
+		if strings.HasPrefix(ctx.FullPath(), "/ws") {
 			token = ctx.Query("token")
 		} else {
 			hdr := header{}
@@ -163,6 +163,7 @@ func createRouter(ctx context.Context, app *App) *gin.Engine {
 	engine.GET("/api/servers", onAPIGetServers(app))
 
 	engine.GET("/api/stats/map", onAPIGetMapUsage(app))
+	engine.GET("/api/stats/map/:map_name", onAPIGetMapStats(app))
 
 	// Service discovery endpoints
 	engine.GET("/api/sd/prometheus/hosts", onAPIGetPrometheusHosts(app))
@@ -221,16 +222,29 @@ func createRouter(ctx context.Context, app *App) *gin.Engine {
 		authed.GET("/api/sourcebans/:steam_id", onAPIGetSourceBans(app))
 		authed.GET("/api/auth/logout", onGetLogout(app))
 		authed.GET("/api/log/:match_id", onAPIGetMatch(app))
+		authed.GET("/api/log/:match_id/logstf", onAPIGetMatchLogsTF(app))
+		authed.GET("/api/log/:match_id/raw", onAPIGetMatchRawLog(app))
+		authed.GET("/api/log/:match_id/rounds", onAPIGetMatchRounds(app))
+		authed.GET("/api/log/:match_id/timeline", onAPIGetMatchTimeline(app))
 		authed.POST("/api/logs", onAPIGetMatches(app))
 		authed.POST("/api/messages", onAPIQueryMessages(app))
+		authed.GET("/api/export", onAPIGetDataExports(app))
+		authed.POST("/api/export", onAPIPostDataExport(app))
+		authed.GET("/api/export/:data_export_id/download", onAPIGetDataExportDownload(app))
+		authed.GET("/api/erasure", onAPIGetErasures(app))
+		authed.POST("/api/erasure", onAPIPostErasure(app))
 
 		authed.GET("/api/stats/weapons", onAPIGetStatsWeaponsOverall(ctx, app))
 		authed.GET("/api/stats/weapon/:weapon_id", onAPIGetsStatsWeapon(app))
 		authed.GET("/api/stats/players", onAPIGetStatsPlayersOverall(ctx, app))
 		authed.GET("/api/stats/healers", onAPIGetStatsHealersOverall(ctx, app))
+		authed.GET("/api/leaderboards", onAPIGetLeaderboard(app))
 		authed.GET("/api/stats/player/:steam_id/weapons", onAPIGetPlayerWeaponStatsOverall(app))
 		authed.GET("/api/stats/player/:steam_id/classes", onAPIGetPlayerClassStatsOverall(app))
 		authed.GET("/api/stats/player/:steam_id/overall", onAPIGetPlayerStatsOverall(app))
+		authed.GET("/api/ratings/leaderboard", onAPIGetRatingLeaderboard(app))
+		authed.GET("/api/ratings/player/:steam_id", onAPIGetPlayerRatings(app))
+		authed.GET("/api/ratings/player/:steam_id/history", onAPIGetPlayerRatingHistory(app))
 	}
 
 	editorGrp := engine.Group("/")
@@ -245,6 +259,7 @@ func createRouter(ctx context.Context, app *App) *gin.Engine {
 		editorRoute.POST("/api/filters", onAPIPostWordFilter(app))
 		editorRoute.DELETE("/api/filters/:word_id", onAPIDeleteWordFilter(app))
 		editorRoute.POST("/api/filter_match", onAPIPostWordMatch(app))
+		editorRoute.POST("/api/filter_backtest", onAPIPostFilterBacktest(app))
 		editorRoute.GET("/export/bans/valve/network", onAPIExportBansValveIP(app))
 		editorRoute.GET("/api/players", onAPIGetPlayers(app))
 	}
@@ -254,8 +269,19 @@ func createRouter(ctx context.Context, app *App) *gin.Engine {
 		// Moderator access
 		modRoute := modGrp.Use(authMiddleware(app, consts.PModerator))
 		modRoute.POST("/api/report/:report_id/state", onAPIPostBanState(app))
+		modRoute.GET("/api/anomalies", onAPIGetAnomalies(app))
+		modRoute.POST("/api/anomalies/:anomaly_id/status", onAPIPostAnomalyStatus(app))
+		modRoute.POST("/api/anomalies/:anomaly_id/report", onAPIPostAnomalyReport(app))
 		modRoute.POST("/api/connections", onAPIQueryPersonConnections(app))
 		modRoute.GET("/api/messages/:steam_id", onAPIGetPersonMessages(app))
+		modRoute.GET("/api/names/:steam_id", onAPIGetPersonNames(app))
+		modRoute.POST("/api/names/search", onAPISearchPersonNames(app))
+		modRoute.GET("/api/notes/:steam_id", onAPIGetPersonNotes(app))
+		modRoute.POST("/api/notes/:steam_id", onAPIPostPersonNote(app))
+		modRoute.POST("/api/note/:person_note_id", onAPIEditPersonNote(app))
+		modRoute.DELETE("/api/note/:person_note_id", onAPIDeletePersonNote(app))
+		modRoute.GET("/api/note/:person_note_id/revisions", onAPIGetPersonNoteRevisions(app))
+		modRoute.POST("/api/timeline/:steam_id", onAPIGetPersonTimeline(app))
 		modRoute.GET("/api/message/:person_message_id/context/:padding", onAPIQueryMessageContext(app))
 		modRoute.POST("/api/appeals", onAPIGetAppeals(app))
 		modRoute.POST("/api/bans/steam", onAPIGetBansSteam(app))
@@ -272,16 +298,28 @@ func createRouter(ctx context.Context, app *App) *gin.Engine {
 		modRoute.POST("/api/bans/group", onAPIGetBansGroup(app))
 		modRoute.DELETE("/api/bans/group/:ban_group_id", onAPIDeleteBansGroup(app))
 		modRoute.GET("/api/patreon/pledges", onAPIGetPatreonPledges(app))
+		modRoute.POST("/api/erasures", onAPIQueryErasures(app))
+		modRoute.POST("/api/erasures/:erasure_id/review", onAPIPostErasureReview(app))
+		modRoute.POST("/api/audit", onAPIQueryAuditLog(app))
 	}
 
 	adminGrp := engine.Group("/")
 	{
 		// Admin access
 		adminRoute := adminGrp.Use(authMiddleware(app, consts.PAdmin))
+		adminRoute.GET("/ws/console/:server_id", onWSConsole(app))
 		adminRoute.POST("/api/servers", onAPIPostServer(app))
 		adminRoute.POST("/api/servers/:server_id", onAPIPostServerUpdate(app))
 		adminRoute.DELETE("/api/servers/:server_id", onAPIPostServerDelete(app))
 		adminRoute.GET("/api/servers_admin", onAPIGetServersAdmin(app))
+		adminRoute.GET("/api/servers/health", onAPIGetServersHealth(app))
+		adminRoute.GET("/api/servers/:server_id/health", onAPIGetServerHealthHistory(app))
+		adminRoute.GET("/api/tasks", onAPIGetScheduledTasks(app))
+		adminRoute.POST("/api/tasks", onAPIPostScheduledTask(app))
+		adminRoute.POST("/api/tasks/:scheduled_task_id", onAPIEditScheduledTask(app))
+		adminRoute.DELETE("/api/tasks/:scheduled_task_id", onAPIDeleteScheduledTask(app))
+		adminRoute.POST("/api/tasks/:scheduled_task_id/run", onAPIPostScheduledTaskRun(app))
+		adminRoute.GET("/api/tasks/:scheduled_task_id/runs", onAPIGetScheduledTaskRuns(app))
 	}
 
 	return engine
@@ -0,0 +1,352 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/leighmacdonald/gbans/internal/discord"
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/leighmacdonald/gbans/pkg/logparse"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const seasonStartFormat = "2006-01-02"
+
+// leaderboardPeriods are the periods every match is added to.
+var leaderboardPeriods = []store.LeaderboardPeriod{ //nolint:gochecknoglobals
+	store.LeaderboardDaily, store.LeaderboardWeekly, store.LeaderboardMonthly, store.LeaderboardSeason,
+}
+
+// leaderboardWindow returns the window of the period containing the time. Windows start at midnight UTC, weeks
+// start on monday and seasons are consecutive blocks of conf.SeasonDays starting from conf.SeasonStart.
+func leaderboardWindow(conf leaderboardConfig, period store.LeaderboardPeriod, when time.Time) store.LeaderboardWindow {
+	var (
+		day   = when.UTC().Truncate(time.Hour * 24)
+		start time.Time
+	)
+
+	switch period {
+	case store.LeaderboardWeekly:
+		start = day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case store.LeaderboardMonthly:
+		start = time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case store.LeaderboardSeason:
+		seasonStart, errSeason := time.Parse(seasonStartFormat, conf.SeasonStart)
+		if errSeason != nil || conf.SeasonDays <= 0 {
+			// Validated when the config is loaded
+			seasonStart, conf.SeasonDays = time.Date(day.Year(), 1, 1, 0, 0, 0, 0, time.UTC), 365
+		}
+
+		seasons := int(day.Sub(seasonStart).Hours()/24) / conf.SeasonDays
+		if day.Before(seasonStart) {
+			seasons = -((int(seasonStart.Sub(day).Hours()/24) + conf.SeasonDays - 1) / conf.SeasonDays)
+		}
+
+		start = seasonStart.AddDate(0, 0, seasons*conf.SeasonDays)
+	default:
+		start = day
+	}
+
+	return store.LeaderboardWindow{Period: period, Start: start}
+}
+
+// previousLeaderboardWindow returns the window directly before the window.
+func previousLeaderboardWindow(conf leaderboardConfig, window store.LeaderboardWindow) store.LeaderboardWindow {
+	return leaderboardWindow(conf, window.Period, window.Start.Add(-time.Second))
+}
+
+// leaderboardWindowEnd returns the time the window ends, which is the start of the next window.
+func leaderboardWindowEnd(conf leaderboardConfig, window store.LeaderboardWindow) time.Time {
+	switch window.Period {
+	case store.LeaderboardWeekly:
+		return window.Start.AddDate(0, 0, 7)
+	case store.LeaderboardMonthly:
+		return window.Start.AddDate(0, 1, 0)
+	case store.LeaderboardSeason:
+		return window.Start.AddDate(0, 0, conf.SeasonDays)
+	default:
+		return window.Start.AddDate(0, 0, 1)
+	}
+}
+
+// leaderboardStats returns the stats the match adds to the leaderboards. Only players with a valid steam id are
+// included, bots are never saved with a match.
+func leaderboardStats(match *store.MatchResult) ([]store.LeaderboardPlayerStats, []store.LeaderboardWeaponStats) {
+	var (
+		players []store.LeaderboardPlayerStats
+		weapons []store.LeaderboardWeaponStats
+	)
+
+	for _, player := range match.Players {
+		if !player.SteamID.Valid() {
+			continue
+		}
+
+		stats := store.LeaderboardPlayerStats{
+			SteamID:     player.SteamID,
+			Matches:     1,
+			Kills:       player.Kills,
+			Assists:     player.Assists,
+			Deaths:      player.Deaths,
+			Damage:      int64(player.Damage),
+			DamageTaken: int64(player.DamageTaken),
+		}
+
+		if match.Winner != logparse.UNASSIGNED && player.Team == match.Winner {
+			stats.Wins = 1
+		}
+
+		if player.MedicStats != nil {
+			stats.Healing = int64(player.MedicStats.Healing)
+		}
+
+		for _, class := range player.Classes {
+			stats.Playtime += int64(class.Playtime)
+		}
+
+		players = append(players, stats)
+
+		for _, weapon := range player.Weapons {
+			weapons = append(weapons, store.LeaderboardWeaponStats{
+				SteamID:   player.SteamID,
+				WeaponID:  weapon.WeaponID,
+				Kills:     weapon.Kills,
+				Damage:    int64(weapon.Damage),
+				Shots:     weapon.Shots,
+				Hits:      weapon.Hits,
+				Headshots: weapon.Headshots,
+				Airshots:  weapon.Airshots,
+				Backstabs: weapon.Backstabs,
+			})
+		}
+	}
+
+	return players, weapons
+}
+
+// leaderboardScopes returns the scopes a match on the server is added to, the overall scope, the server itself
+// and the region of the server when it has one.
+func leaderboardScopes(server store.Server) []store.LeaderboardScope {
+	scopes := []store.LeaderboardScope{{}}
+
+	if server.ServerID > 0 {
+		scopes = append(scopes, store.LeaderboardScope{ServerID: server.ServerID})
+	}
+
+	if server.Region != "" {
+		scopes = append(scopes, store.LeaderboardScope{Region: server.Region})
+	}
+
+	return scopes
+}
+
+func saveMatchLeaderboards(ctx context.Context, database *store.Store, conf leaderboardConfig,
+	match *store.MatchResult, server store.Server,
+) error {
+	windows := make([]store.LeaderboardWindow, len(leaderboardPeriods))
+	for index, period := range leaderboardPeriods {
+		windows[index] = leaderboardWindow(conf, period, match.TimeEnd)
+	}
+
+	players, weapons := leaderboardStats(match)
+
+	return database.LeaderboardSaveMatch(ctx, match.MatchID, windows, leaderboardScopes(server), players, weapons)
+}
+
+// updateMatchLeaderboards adds a newly completed match to the leaderboards. Matches that were already added
+// are ignored.
+func (app *App) updateMatchLeaderboards(ctx context.Context, match *store.MatchResult) error {
+	var server store.Server
+	if errServer := app.db.GetServer(ctx, match.ServerID, &server); errServer != nil {
+		return errors.Wrap(errServer, "Failed to load match server")
+	}
+
+	if errSave := saveMatchLeaderboards(ctx, app.db, app.conf.Leaderboard, match, server); errSave != nil {
+		if errors.Is(errSave, store.ErrDuplicate) {
+			return nil
+		}
+
+		return errSave
+	}
+
+	return nil
+}
+
+// LeaderboardRebuildResult summarises a rebuild of all leaderboards.
+type LeaderboardRebuildResult struct {
+	Matches int
+}
+
+// RebuildLeaderboards deletes all leaderboard stats and adds every stored match again. The rebuild covers every
+// period, including daily periods older than the retention which will be pruned again by the running server.
+func RebuildLeaderboards(ctx context.Context, database *store.Store, conf *Config,
+	onMatch func(matchID uuid.UUID),
+) (LeaderboardRebuildResult, error) {
+	var result LeaderboardRebuildResult
+
+	matchIDs, errMatchIDs := database.LeaderboardMatchIDs(ctx)
+	if errMatchIDs != nil {
+		return result, errors.Wrap(errMatchIDs, "Failed to load matches")
+	}
+
+	if errReset := database.LeaderboardReset(ctx); errReset != nil {
+		return result, errors.Wrap(errReset, "Failed to reset leaderboards")
+	}
+
+	servers := map[int]store.Server{}
+
+	for _, matchID := range matchIDs {
+		if ctx.Err() != nil {
+			return result, errors.Wrap(ctx.Err(), "Rebuild cancelled")
+		}
+
+		var match store.MatchResult
+		if errMatch := database.MatchGetByID(ctx, matchID, &match); errMatch != nil {
+			return result, errors.Wrapf(errMatch, "Failed to load match %s", matchID)
+		}
+
+		server, found := servers[match.ServerID]
+		if !found {
+			if errServer := database.GetServer(ctx, match.ServerID, &server); errServer != nil {
+				return result, errors.Wrapf(errServer, "Failed to load server %d", match.ServerID)
+			}
+
+			servers[match.ServerID] = server
+		}
+
+		if errSave := saveMatchLeaderboards(ctx, database, conf.Leaderboard, &match, server); errSave != nil {
+			return result, errors.Wrapf(errSave, "Failed to save leaderboards for match %s", matchID)
+		}
+
+		result.Matches++
+		onMatch(matchID)
+	}
+
+	return result, nil
+}
+
+// announceLeaderboardWinners posts the top players of the previous week to discord, once per week.
+func (app *App) announceLeaderboardWinners(ctx context.Context) error {
+	var (
+		conf     = app.conf.Leaderboard
+		current  = leaderboardWindow(conf, store.LeaderboardWeekly, time.Now())
+		previous = previousLeaderboardWindow(conf, current)
+		boards   = []store.LeaderboardBoard{store.LeaderboardKills, store.LeaderboardDamage, store.LeaderboardHealing}
+		titles   = map[store.LeaderboardBoard]string{
+			store.LeaderboardKills:   "Kills",
+			store.LeaderboardDamage:  "Damage",
+			store.LeaderboardHealing: "Healing",
+		}
+	)
+
+	if errAnnounce := app.db.SaveLeaderboardAnnouncement(ctx, previous); errAnnounce != nil {
+		if errors.Is(errAnnounce, store.ErrDuplicate) {
+			return nil
+		}
+
+		return errors.Wrap(errAnnounce, "Failed to save leaderboard announcement")
+	}
+
+	msgEmbed := discord.
+		NewEmbed("Weekly Leaderboard Winners").
+		SetDescription(fmt.Sprintf("Top players for the week of %s", previous.Start.Format(seasonStartFormat))).
+		SetColor(app.bot.Colour.Success).
+		SetURL(app.ExtURLRaw("/stats"))
+
+	found := false
+
+	for _, board := range boards {
+		entries, errEntries := app.db.Leaderboard(ctx, store.LeaderboardQuery{
+			Board:         board,
+			Window:        previous,
+			PreviousStart: previousLeaderboardWindow(conf, previous).Start,
+			Limit:         uint64(conf.AnnounceCount),
+		})
+		if errEntries != nil {
+			return errors.Wrapf(errEntries, "Failed to load %s leaderboard", board)
+		}
+
+		if len(entries) == 0 {
+			continue
+		}
+
+		found = true
+
+		var lines []string
+
+		for _, entry := range entries {
+			name := entry.PersonaName
+			if name == "" {
+				name = entry.SteamID.String()
+			}
+
+			lines = append(lines, fmt.Sprintf("%d. %s (%d)", entry.Rank, name, entry.Value))
+		}
+
+		msgEmbed.AddField(titles[board], strings.Join(lines, "\n")).MakeFieldInline()
+	}
+
+	if !found {
+		return nil
+	}
+
+	channelID := conf.AnnounceChannelID
+	if channelID == "" {
+		channelID = app.conf.Discord.PublicLogChannelID
+	}
+
+	app.bot.SendPayload(discord.Payload{ChannelID: channelID, Embed: msgEmbed.Truncate().MessageEmbed})
+
+	return nil
+}
+
+// leaderboardWorker announces the weekly winners and prunes expired daily leaderboards.
+func (app *App) leaderboardWorker(ctx context.Context) {
+	if !app.conf.Leaderboard.Enabled {
+		return
+	}
+
+	var (
+		log    = app.log.Named("leaderboard")
+		ticker = time.NewTicker(time.Hour)
+	)
+
+	update := func() {
+		if app.conf.Leaderboard.AnnounceWeekly {
+			if errAnnounce := app.announceLeaderboardWinners(ctx); errAnnounce != nil {
+				log.Error("Failed to announce weekly winners", zap.Error(errAnnounce))
+			}
+		}
+
+		olderThan := time.Now().Add(-app.conf.Leaderboard.DailyRetention.Duration())
+		if errPrune := app.db.PruneLeaderboards(ctx, store.LeaderboardDaily, olderThan); errPrune != nil {
+			log.Error("Failed to prune daily leaderboards", zap.Error(errPrune))
+		}
+	}
+
+	update()
+
+	for {
+		select {
+		case <-ticker.C:
+			update()
+		case <-ctx.Done():
+			log.Debug("leaderboardWorker shutting down")
+
+			return
+		}
+	}
+}
+
+// leaderboardScopeFor returns the scope of a leaderboard query, only one of server or region can be set.
+func leaderboardScopeFor(serverID int, region string) (store.LeaderboardScope, error) {
+	if serverID > 0 && region != "" {
+		return store.LeaderboardScope{}, errors.New("Only one of server_id or region can be set")
+	}
+
+	return store.LeaderboardScope{ServerID: serverID, Region: region}, nil
+}
@@ -0,0 +1,96 @@
+package app // nolint:testpackage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/leighmacdonald/gbans/pkg/logparse"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaderboardWindow(t *testing.T) {
+	var (
+		conf = leaderboardConfig{SeasonStart: "2024-01-01", SeasonDays: 91}
+		// Thursday
+		when = time.Date(2024, 5, 16, 22, 30, 0, 0, time.UTC)
+		date = func(year int, month time.Month, day int) time.Time {
+			return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		}
+	)
+
+	for _, testCase := range []struct {
+		period   store.LeaderboardPeriod
+		when     time.Time
+		expected time.Time
+	}{
+		{store.LeaderboardDaily, when, date(2024, 5, 16)},
+		{store.LeaderboardWeekly, when, date(2024, 5, 13)},
+		{store.LeaderboardWeekly, date(2024, 5, 13), date(2024, 5, 13)},
+		{store.LeaderboardWeekly, date(2024, 5, 19), date(2024, 5, 13)},
+		{store.LeaderboardMonthly, when, date(2024, 5, 1)},
+		{store.LeaderboardSeason, when, date(2024, 4, 1)},
+		{store.LeaderboardSeason, date(2024, 3, 31), date(2024, 1, 1)},
+		{store.LeaderboardSeason, date(2023, 12, 31), date(2023, 10, 2)},
+	} {
+		window := leaderboardWindow(conf, testCase.period, testCase.when)
+		require.Equal(t, testCase.expected, window.Start, "%s %s", testCase.period, testCase.when)
+		require.Equal(t, testCase.period, window.Period)
+	}
+
+	// Local times are bucketed by their UTC time
+	local := time.Date(2024, 5, 13, 1, 0, 0, 0, time.FixedZone("test", 3*60*60))
+	require.Equal(t, date(2024, 5, 12), leaderboardWindow(conf, store.LeaderboardDaily, local).Start)
+}
+
+func TestPreviousLeaderboardWindow(t *testing.T) {
+	conf := leaderboardConfig{SeasonStart: "2024-01-01", SeasonDays: 91}
+
+	for _, period := range leaderboardPeriods {
+		window := leaderboardWindow(conf, period, time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+		previous := previousLeaderboardWindow(conf, window)
+
+		require.True(t, previous.Start.Before(window.Start), period.String())
+		require.Equal(t, window.Start, leaderboardWindowEnd(conf, previous), period.String())
+	}
+}
+
+func TestLeaderboardStats(t *testing.T) {
+	match := testRatingMatch(2, 2, logparse.BLU)
+	match.Players[0].Kills = 10
+	match.Players[0].Weapons = []store.MatchPlayerWeapon{
+		{Weapon: store.Weapon{WeaponID: 4}, Kills: 8, Shots: 50, Hits: 20},
+	}
+	match.Players[2].MedicStats = &store.MatchHealer{Healing: 5000}
+	match.Players[3].SteamID = steamid.New(logparse.BotSid)
+
+	players, weapons := leaderboardStats(match)
+	require.Len(t, players, 3)
+	require.Len(t, weapons, 1)
+
+	require.Equal(t, 10, players[0].Kills)
+	require.Equal(t, 0, players[0].Wins)
+	require.Equal(t, int64(1800), players[0].Playtime)
+	require.Equal(t, 1, players[2].Wins)
+	require.Equal(t, int64(5000), players[2].Healing)
+	require.Equal(t, 8, weapons[0].Kills)
+	require.Equal(t, players[0].SteamID, weapons[0].SteamID)
+
+	match.Winner = logparse.UNASSIGNED
+	players, _ = leaderboardStats(match)
+
+	for _, player := range players {
+		require.Equal(t, 0, player.Wins)
+		require.Equal(t, 1, player.Matches)
+	}
+}
+
+func TestLeaderboardScopes(t *testing.T) {
+	require.Equal(t, []store.LeaderboardScope{{}, {ServerID: 3}, {Region: "eu"}},
+		leaderboardScopes(store.Server{ServerID: 3, Region: "eu"}))
+	require.Equal(t, []store.LeaderboardScope{{}, {ServerID: 3}}, leaderboardScopes(store.Server{ServerID: 3}))
+
+	_, errScope := leaderboardScopeFor(3, "eu")
+	require.Error(t, errScope)
+}
@@ -0,0 +1,277 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/leighmacdonald/gbans/pkg/logparse"
+	"github.com/pkg/errors"
+)
+
+// Max length of a single log line, srcds truncates lines well before this.
+const replayMaxLineSize = 1024 * 64
+
+var gzipMagic = []byte{0x1f, 0x8b} //nolint:gochecknoglobals
+
+// LogReplayStatus describes what happened to a match found while replaying a log.
+type LogReplayStatus string
+
+const (
+	LogReplaySaved        LogReplayStatus = "saved"
+	LogReplayDryRun       LogReplayStatus = "dry_run"
+	LogReplayDuplicate    LogReplayStatus = "duplicate"
+	LogReplayIncomplete   LogReplayStatus = "incomplete"
+	LogReplayInsufficient LogReplayStatus = "insufficient_players"
+	LogReplayFailed       LogReplayStatus = "failed"
+)
+
+// LogReplayOpts defines which server the log files belong to and if the results should be persisted.
+type LogReplayOpts struct {
+	ServerID int
+	DryRun   bool
+}
+
+// LogReplayMatch is a single match rebuilt from a log file.
+type LogReplayMatch struct {
+	File   string
+	Match  logparse.Match
+	Status LogReplayStatus
+	Err    error
+}
+
+// LogReplayResult summarises the processing of one or more log files.
+type LogReplayResult struct {
+	Files   int
+	Lines   int64
+	Unknown int64
+	Failed  int64
+	Matches []LogReplayMatch
+}
+
+// Count returns the number of matches with the status.
+func (r LogReplayResult) Count(status LogReplayStatus) int {
+	count := 0
+
+	for _, match := range r.Matches {
+		if match.Status == status {
+			count++
+		}
+	}
+
+	return count
+}
+
+type logFileReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *logFileReader) Close() error {
+	var err error
+
+	for i := len(r.closers) - 1; i >= 0; i-- {
+		if errClose := r.closers[i].Close(); errClose != nil && err == nil {
+			err = errClose
+		}
+	}
+
+	return err
+}
+
+// openLogFile opens a plain or gzip compressed log file. Compression is detected from the file contents
+// rather than the extension.
+func openLogFile(path string) (io.ReadCloser, error) {
+	file, errOpen := os.Open(path)
+	if errOpen != nil {
+		return nil, errors.Wrap(errOpen, "Failed to open log file")
+	}
+
+	reader := bufio.NewReader(file)
+
+	magic, errPeek := reader.Peek(len(gzipMagic))
+	if errPeek != nil && !errors.Is(errPeek, io.EOF) {
+		_ = file.Close()
+
+		return nil, errors.Wrap(errPeek, "Failed to read log file")
+	}
+
+	if !bytes.Equal(magic, gzipMagic) {
+		return &logFileReader{Reader: reader, closers: []io.Closer{file}}, nil
+	}
+
+	gzipReader, errGzip := gzip.NewReader(reader)
+	if errGzip != nil {
+		_ = file.Close()
+
+		return nil, errors.Wrap(errGzip, "Failed to open gzip log file")
+	}
+
+	return &logFileReader{Reader: gzipReader, closers: []io.Closer{file, gzipReader}}, nil
+}
+
+// replayLog rebuilds the matches contained within the log. Matches are split the same way as
+// matchSummarizer does with live logs, ending on the second final score event or the log closing.
+// Any match still in progress at the end of the log is also returned.
+func replayLog(reader io.Reader, serverID int, serverName string, result *LogReplayResult) ([]logparse.Match, error) {
+	var (
+		parser      = logparse.NewLogParser()
+		scanner     = bufio.NewScanner(reader)
+		matches     []logparse.Match
+		current     *logparse.Match
+		finalScores int
+	)
+
+	// Trailing events after the final scores, such as players respawning, create matches that never
+	// had a round start. Stats are not collected outside of rounds so these are discarded.
+	flush := func() {
+		if current != nil && current.RoundCount() > 0 {
+			matches = append(matches, *current)
+		}
+
+		current = nil
+	}
+
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), replayMaxLineSize)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		result.Lines++
+
+		parseResult, errParse := parser.Parse(line)
+		if errParse != nil {
+			result.Failed++
+
+			continue
+		}
+
+		switch parseResult.EventType {
+		case logparse.IgnoredMsg:
+			continue
+		case logparse.UnknownMsg:
+			result.Unknown++
+
+			continue
+		}
+
+		if current == nil {
+			match := logparse.NewMatch(serverID, serverName)
+			current = &match
+			finalScores = 0
+		}
+
+		// Errors are expected for events outside a round, same as the live summarizer
+		_ = current.Apply(parseResult)
+
+		switch parseResult.EventType {
+		case logparse.WTeamFinalScore:
+			finalScores++
+			if finalScores < 2 {
+				continue
+			}
+
+			fallthrough
+		case logparse.LogStop:
+			flush()
+		}
+	}
+
+	if errScan := scanner.Err(); errScan != nil {
+		return matches, errors.Wrap(errScan, "Failed to read log")
+	}
+
+	flush()
+
+	return matches, nil
+}
+
+// replayMatch persists the match unless it's incomplete or was already saved by the live pipeline
+// or a previous replay. Dry runs never touch the database, so existing matches are not detected.
+func replayMatch(ctx context.Context, database *store.Store, opts LogReplayOpts, match *logparse.Match) (LogReplayStatus, error) {
+	if match.TimeStart == nil || match.MapName == "" {
+		return LogReplayIncomplete, nil
+	}
+
+	if opts.DryRun {
+		return LogReplayDryRun, nil
+	}
+
+	_, errExisting := database.MatchIDByStart(ctx, match.ServerID, *match.TimeStart)
+	if errExisting == nil {
+		return LogReplayDuplicate, nil
+	} else if !errors.Is(errExisting, store.ErrNoResult) {
+		return LogReplayFailed, errExisting
+	}
+
+	if errSave := database.MatchSave(ctx, match); errSave != nil {
+		switch {
+		case errors.Is(errSave, store.ErrIncompleteMatch):
+			return LogReplayIncomplete, nil
+		case errors.Is(errSave, store.ErrInsufficientPlayers):
+			return LogReplayInsufficient, nil
+		default:
+			return LogReplayFailed, errSave
+		}
+	}
+
+	return LogReplaySaved, nil
+}
+
+// ReplayLogs rebuilds matches from historical srcds log files and saves them, filling in gaps where
+// the live log listener was unavailable. The onMatch func, if supplied, is called for every match found.
+// The database is not used for dry runs and may be nil.
+func ReplayLogs(ctx context.Context, database *store.Store, opts LogReplayOpts, paths []string,
+	onMatch func(match LogReplayMatch),
+) (LogReplayResult, error) {
+	var (
+		result LogReplayResult
+		server = store.Server{ServerID: opts.ServerID}
+	)
+
+	if !opts.DryRun {
+		if errServer := database.GetServer(ctx, opts.ServerID, &server); errServer != nil {
+			return result, errors.Wrap(errServer, "Failed to load server")
+		}
+	}
+
+	for _, path := range paths {
+		reader, errOpen := openLogFile(path)
+		if errOpen != nil {
+			return result, errOpen
+		}
+
+		matches, errReplay := replayLog(reader, server.ServerID, server.ServerNameLong, &result)
+
+		_ = reader.Close()
+
+		if errReplay != nil {
+			return result, errors.Wrap(errReplay, path)
+		}
+
+		result.Files++
+
+		for idx := range matches {
+			status, errMatch := replayMatch(ctx, database, opts, &matches[idx])
+			replayed := LogReplayMatch{File: path, Match: matches[idx], Status: status, Err: errMatch}
+			result.Matches = append(result.Matches, replayed)
+
+			if onMatch != nil {
+				onMatch(replayed)
+			}
+
+			if ctx.Err() != nil {
+				return result, errors.Wrap(ctx.Err(), "Replay cancelled")
+			}
+		}
+	}
+
+	return result, nil
+}
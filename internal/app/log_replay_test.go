@@ -0,0 +1,112 @@
+package app // nolint:testpackage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/leighmacdonald/golib"
+	"github.com/stretchr/testify/require"
+)
+
+func replayTestFile(t *testing.T, name string) string {
+	t.Helper()
+
+	testFilePath := golib.FindFile(path.Join("testdata", name), "gbans")
+	if testFilePath == "" {
+		t.Skipf("Cant find test file: %s", name)
+	}
+
+	return testFilePath
+}
+
+func TestReplayLog(t *testing.T) {
+	testFilePath := replayTestFile(t, "log_3124689.log")
+
+	body, errRead := os.ReadFile(testFilePath)
+	require.NoError(t, errRead)
+
+	var buf bytes.Buffer
+
+	writer := gzip.NewWriter(&buf)
+	_, errWrite := writer.Write(body)
+	require.NoError(t, errWrite)
+	require.NoError(t, writer.Close())
+
+	gzipPath := filepath.Join(t.TempDir(), "log_3124689.log.gz")
+	require.NoError(t, os.WriteFile(gzipPath, buf.Bytes(), 0o600))
+
+	var results []LogReplayResult
+
+	for _, filePath := range []string{testFilePath, gzipPath} {
+		reader, errOpen := openLogFile(filePath)
+		require.NoError(t, errOpen)
+
+		var result LogReplayResult
+
+		matches, errReplay := replayLog(reader, 1, "test server", &result)
+		require.NoError(t, errReplay)
+		require.NoError(t, reader.Close())
+
+		require.Len(t, matches, 1)
+		require.Equal(t, 19, matches[0].PlayerCount())
+		require.Equal(t, 43, matches[0].ChatCount())
+		require.NotNil(t, matches[0].TimeStart)
+		require.NotNil(t, matches[0].TimeEnd)
+		// Original log timestamps are used rather than the current time
+		require.Equal(t, 2022, matches[0].TimeStart.Year())
+
+		results = append(results, result)
+	}
+
+	require.Equal(t, results[0], results[1])
+	require.Positive(t, results[0].Lines)
+}
+
+func TestReplayLogMultipleMatches(t *testing.T) {
+	var combined []byte
+
+	for _, name := range []string{"log_3124689.log", "log_3474527.log"} {
+		body, errRead := os.ReadFile(replayTestFile(t, name))
+		require.NoError(t, errRead)
+
+		combined = append(combined, body...)
+	}
+
+	var result LogReplayResult
+
+	matches, errReplay := replayLog(bytes.NewReader(combined), 1, "test server", &result)
+	require.NoError(t, errReplay)
+	require.Len(t, matches, 2)
+	require.NotEqual(t, matches[0].MatchID, matches[1].MatchID)
+	require.True(t, matches[0].TimeStart.Before(*matches[1].TimeStart))
+}
+
+func TestReplayLogsDryRun(t *testing.T) {
+	body, errRead := os.ReadFile(replayTestFile(t, "log_3124689.log"))
+	require.NoError(t, errRead)
+
+	// The log starts after the map has loaded, without the map name the match would be incomplete
+	mapLoad := []byte("L 02/05/2022 - 06:22:20: Loading map \"pl_upward\"\n")
+	testFilePath := filepath.Join(t.TempDir(), "log_3124689.log")
+	require.NoError(t, os.WriteFile(testFilePath, append(mapLoad, body...), 0o600))
+
+	var found []LogReplayMatch
+
+	// Dry runs must work without a database
+	result, errReplay := ReplayLogs(context.Background(), nil, LogReplayOpts{ServerID: 1, DryRun: true},
+		[]string{testFilePath}, func(match LogReplayMatch) {
+			found = append(found, match)
+		})
+	require.NoError(t, errReplay)
+	require.Equal(t, 1, result.Files)
+	require.Len(t, found, 1)
+	require.Equal(t, LogReplayDryRun, found[0].Status)
+	require.NoError(t, found[0].Err)
+	require.Equal(t, 1, found[0].Match.ServerID)
+	require.Equal(t, "pl_upward", found[0].Match.MapName)
+}
@@ -0,0 +1,514 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/leighmacdonald/gbans/pkg/logparse"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+)
+
+// logsTFVersion is the version of the logs.tf json schema that is produced.
+const logsTFVersion = 3
+
+type LogsTFTeam struct {
+	Score     int `json:"score"`
+	Kills     int `json:"kills"`
+	Deaths    int `json:"deaths"`
+	Dmg       int `json:"dmg"`
+	Charges   int `json:"charges"`
+	Drops     int `json:"drops"`
+	FirstCaps int `json:"firstcaps"`
+	Caps      int `json:"caps"`
+}
+
+type LogsTFWeapon struct {
+	Kills  int     `json:"kills"`
+	Dmg    int     `json:"dmg"`
+	AvgDmg float64 `json:"avg_dmg"`
+	Shots  int     `json:"shots"`
+	Hits   int     `json:"hits"`
+}
+
+type LogsTFClassStats struct {
+	Type      string                  `json:"type"`
+	Kills     int                     `json:"kills"`
+	Assists   int                     `json:"assists"`
+	Deaths    int                     `json:"deaths"`
+	Dmg       int                     `json:"dmg"`
+	Weapon    map[string]LogsTFWeapon `json:"weapon"`
+	TotalTime int                     `json:"total_time"`
+}
+
+type LogsTFMedicStats struct {
+	AdvantagesLost       int     `json:"advantages_lost"`
+	BiggestAdvantageLost int     `json:"biggest_advantage_lost"`
+	DeathsWith9599Uber   int     `json:"deaths_with_95_99_uber"`
+	AvgUberLength        float64 `json:"avg_uber_length"`
+}
+
+type LogsTFPlayer struct {
+	Team         string             `json:"team"`
+	ClassStats   []LogsTFClassStats `json:"class_stats"`
+	Kills        int                `json:"kills"`
+	Deaths       int                `json:"deaths"`
+	Assists      int                `json:"assists"`
+	Suicides     int                `json:"suicides"`
+	KAPD         string             `json:"kapd"`
+	KPD          string             `json:"kpd"`
+	Dmg          int                `json:"dmg"`
+	DT           int                `json:"dt"`
+	HR           int                `json:"hr"`
+	LKS          int                `json:"lks"`
+	AS           int                `json:"as"`
+	DAPD         int                `json:"dapd"`
+	DAPM         int                `json:"dapm"`
+	Ubers        int                `json:"ubers"`
+	UberTypes    map[string]int     `json:"ubertypes"`
+	Drops        int                `json:"drops"`
+	Medkits      int                `json:"medkits"`
+	Backstabs    int                `json:"backstabs"`
+	Headshots    int                `json:"headshots"`
+	HeadshotsHit int                `json:"headshots_hit"`
+	Heal         int                `json:"heal"`
+	CPC          int                `json:"cpc"`
+	IC           int                `json:"ic"`
+	MedicStats   *LogsTFMedicStats  `json:"medicstats,omitempty"`
+}
+
+type LogsTFRoundTeam struct {
+	Score int `json:"score"`
+	Kills int `json:"kills"`
+	Dmg   int `json:"dmg"`
+	Ubers int `json:"ubers"`
+}
+
+type LogsTFRound struct {
+	StartTime int64                      `json:"start_time"`
+	Winner    string                     `json:"winner"`
+	Team      map[string]LogsTFRoundTeam `json:"team"`
+	Length    int                        `json:"length"`
+}
+
+type LogsTFChat struct {
+	SteamID string `json:"steamid"`
+	Name    string `json:"name"`
+	Msg     string `json:"msg"`
+}
+
+type LogsTFKillstreak struct {
+	SteamID string `json:"steamid"`
+	Streak  int    `json:"streak"`
+}
+
+type LogsTFInfo struct {
+	Map             string   `json:"map"`
+	Supplemental    bool     `json:"supplemental"`
+	TotalLength     int      `json:"total_length"`
+	HasRealDamage   bool     `json:"hasRealDamage"`
+	HasWeaponDamage bool     `json:"hasWeaponDamage"`
+	HasAccuracy     bool     `json:"hasAccuracy"`
+	HasHP           bool     `json:"hasHP"`
+	HasHPReal       bool     `json:"hasHP_real"`
+	HasHS           bool     `json:"hasHS"`
+	HasHSHit        bool     `json:"hasHS_hit"`
+	HasBS           bool     `json:"hasBS"`
+	HasCP           bool     `json:"hasCP"`
+	HasSB           bool     `json:"hasSB"`
+	HasDT           bool     `json:"hasDT"`
+	HasAS           bool     `json:"hasAS"`
+	HasHR           bool     `json:"hasHR"`
+	HasIntel        bool     `json:"hasIntel"`
+	ADScoring       bool     `json:"AD_scoring"`
+	Notifications   []string `json:"notifications"`
+	Title           string   `json:"title"`
+	Date            int64    `json:"date"`
+}
+
+// LogsTFMatch mirrors the json schema used by logs.tf so that existing community tools can consume our matches.
+type LogsTFMatch struct {
+	Version          int                       `json:"version"`
+	Teams            map[string]LogsTFTeam     `json:"teams"`
+	Length           int                       `json:"length"`
+	Players          map[string]LogsTFPlayer   `json:"players"`
+	Names            map[string]string         `json:"names"`
+	Rounds           []LogsTFRound             `json:"rounds"`
+	HealSpread       map[string]map[string]int `json:"healspread"`
+	ClassKills       map[string]map[string]int `json:"classkills"`
+	ClassDeaths      map[string]map[string]int `json:"classdeaths"`
+	ClassKillAssists map[string]map[string]int `json:"classkillassists"`
+	Chat             []LogsTFChat              `json:"chat"`
+	Info             LogsTFInfo                `json:"info"`
+	Killstreaks      []LogsTFKillstreak        `json:"killstreaks"`
+	Success          bool                      `json:"success"`
+}
+
+// logsTFClassName returns the class names as they appear in the game logs, which logs.tf uses as keys.
+func logsTFClassName(class logparse.PlayerClass) string {
+	switch class {
+	case logparse.Demo:
+		return "demoman"
+	case logparse.Heavy:
+		return "heavyweapons"
+	default:
+		return class.String()
+	}
+}
+
+func logsTFTeamName(team logparse.Team) string {
+	switch team {
+	case logparse.RED:
+		return "Red"
+	case logparse.BLU:
+		return "Blue"
+	default:
+		return ""
+	}
+}
+
+func logsTFRatio(value int, deaths int) string {
+	if deaths <= 0 {
+		return fmt.Sprintf("%d.0", value)
+	}
+
+	return fmt.Sprintf("%.1f", float64(value)/float64(deaths))
+}
+
+func addLogsTFClassCount(counts map[string]map[string]int, sid string, className string, value int) {
+	if value <= 0 {
+		return
+	}
+
+	if _, found := counts[sid]; !found {
+		counts[sid] = map[string]int{}
+	}
+
+	counts[sid][className] = value
+}
+
+func logsTFPlayer(player *store.MatchPlayer) LogsTFPlayer {
+	out := LogsTFPlayer{
+		Team:         logsTFTeamName(player.Team),
+		ClassStats:   []LogsTFClassStats{},
+		Kills:        player.Kills,
+		Deaths:       player.Deaths,
+		Assists:      player.Assists,
+		Suicides:     player.Suicides,
+		KAPD:         logsTFRatio(player.Kills+player.Assists, player.Deaths),
+		KPD:          logsTFRatio(player.Kills, player.Deaths),
+		Dmg:          player.Damage,
+		DT:           player.DamageTaken,
+		HR:           player.HealingTaken,
+		AS:           player.Airshots,
+		DAPM:         player.DamagePerMin(),
+		UberTypes:    map[string]int{},
+		Medkits:      player.HealthPacks,
+		Backstabs:    player.Backstabs,
+		Headshots:    player.Headshots,
+		HeadshotsHit: player.Headshots,
+		CPC:          player.Captures,
+	}
+
+	if player.Deaths > 0 {
+		out.DAPD = player.Damage / player.Deaths
+	} else {
+		out.DAPD = player.Damage
+	}
+
+	if killstreak := player.BiggestKillstreak(); killstreak != nil {
+		out.LKS = killstreak.Killstreak
+	}
+
+	if player.MedicStats != nil {
+		stats := player.MedicStats
+		out.Heal = stats.Healing
+		out.Drops = stats.Drops
+		out.Ubers = stats.ChargesUber + stats.ChargesKritz + stats.ChargesVacc + stats.ChargesQuickfix
+
+		for name, charges := range map[string]int{
+			"medigun":    stats.ChargesUber,
+			"kritzkrieg": stats.ChargesKritz,
+			"vaccinator": stats.ChargesVacc,
+			"quickfix":   stats.ChargesQuickfix,
+		} {
+			if charges > 0 {
+				out.UberTypes[name] = charges
+			}
+		}
+
+		out.MedicStats = &LogsTFMedicStats{
+			AdvantagesLost:       stats.MajorAdvLost,
+			BiggestAdvantageLost: stats.BiggestAdvLost,
+			DeathsWith9599Uber:   stats.NearFullChargeDeath,
+			AvgUberLength:        math.Round(float64(stats.AvgUberLength)*100) / 100,
+		}
+	}
+
+	classes := make([]store.MatchPlayerClass, len(player.Classes))
+	copy(classes, player.Classes)
+
+	sort.SliceStable(classes, func(i, j int) bool {
+		return classes[i].Playtime > classes[j].Playtime
+	})
+
+	for idx, class := range classes {
+		classStats := LogsTFClassStats{
+			Type:      logsTFClassName(class.PlayerClass),
+			Kills:     class.Kills,
+			Assists:   class.Assists,
+			Deaths:    class.Deaths,
+			Dmg:       class.Damage,
+			Weapon:    map[string]LogsTFWeapon{},
+			TotalTime: class.Playtime,
+		}
+
+		// Weapon stats are not tracked per class, so they are attributed to the most played class
+		if idx == 0 {
+			for _, weapon := range player.Weapons {
+				var avgDmg float64
+				if weapon.Hits > 0 {
+					avgDmg = float64(weapon.Damage) / float64(weapon.Hits)
+				}
+
+				classStats.Weapon[string(weapon.Key)] = LogsTFWeapon{
+					Kills:  weapon.Kills,
+					Dmg:    weapon.Damage,
+					AvgDmg: avgDmg,
+					Shots:  weapon.Shots,
+					Hits:   weapon.Hits,
+				}
+			}
+		}
+
+		out.ClassStats = append(out.ClassStats, classStats)
+	}
+
+	return out
+}
+
+// LogsTFExport renders a saved match using the logs.tf json schema. Values that are not tracked, such as
+// real damage, are left out and flagged as missing in the info block the same way logs.tf does for
+// logs lacking supplemental data.
+func LogsTFExport(match *store.MatchResult) LogsTFMatch {
+	length := int(match.TimeEnd.Sub(match.TimeStart).Seconds())
+	out := LogsTFMatch{
+		Version: logsTFVersion,
+		Teams: map[string]LogsTFTeam{
+			"Red":  {Score: match.TeamScores.Red},
+			"Blue": {Score: match.TeamScores.Blu},
+		},
+		Length:           length,
+		Players:          map[string]LogsTFPlayer{},
+		Names:            map[string]string{},
+		Rounds:           []LogsTFRound{},
+		HealSpread:       map[string]map[string]int{},
+		ClassKills:       map[string]map[string]int{},
+		ClassDeaths:      map[string]map[string]int{},
+		ClassKillAssists: map[string]map[string]int{},
+		Chat:             []LogsTFChat{},
+		Killstreaks:      []LogsTFKillstreak{},
+		Info: LogsTFInfo{
+			Map:             match.MapName,
+			Supplemental:    true,
+			TotalLength:     length,
+			HasWeaponDamage: true,
+			HasAccuracy:     true,
+			HasHP:           true,
+			HasHS:           true,
+			HasHSHit:        true,
+			HasBS:           true,
+			HasCP:           true,
+			HasDT:           true,
+			HasAS:           true,
+			HasHR:           true,
+			Notifications:   []string{},
+			Title:           match.Title,
+			Date:            match.TimeEnd.Unix(),
+		},
+		Success: true,
+	}
+
+	for _, player := range match.Players {
+		sid := string(steamid.SID64ToSID3(player.SteamID))
+		out.Players[sid] = logsTFPlayer(player)
+		out.Names[sid] = player.Name
+
+		if team, found := out.Teams[logsTFTeamName(player.Team)]; found {
+			team.Kills += player.Kills
+			team.Deaths += player.Deaths
+			team.Dmg += player.Damage
+			team.Caps += player.Captures
+
+			if player.MedicStats != nil {
+				team.Charges += out.Players[sid].Ubers
+				team.Drops += player.MedicStats.Drops
+			}
+
+			out.Teams[logsTFTeamName(player.Team)] = team
+		}
+
+		if len(player.HealSpread) > 0 {
+			spread := map[string]int{}
+			for _, target := range player.HealSpread {
+				spread[string(steamid.SID64ToSID3(target.SteamID))] = target.Healing
+			}
+
+			out.HealSpread[sid] = spread
+		}
+
+		for _, classKill := range player.ClassKills {
+			className := logsTFClassName(classKill.PlayerClass)
+			addLogsTFClassCount(out.ClassKills, sid, className, classKill.Kills)
+			addLogsTFClassCount(out.ClassDeaths, sid, className, classKill.Deaths)
+			addLogsTFClassCount(out.ClassKillAssists, sid, className, classKill.Assists)
+		}
+
+		for _, killstreak := range player.Killstreaks {
+			out.Killstreaks = append(out.Killstreaks, LogsTFKillstreak{SteamID: sid, Streak: killstreak.Killstreak})
+		}
+	}
+
+	sort.SliceStable(out.Killstreaks, func(i, j int) bool {
+		if out.Killstreaks[i].Streak == out.Killstreaks[j].Streak {
+			return out.Killstreaks[i].SteamID < out.Killstreaks[j].SteamID
+		}
+
+		return out.Killstreaks[i].Streak > out.Killstreaks[j].Streak
+	})
+
+	for _, round := range match.Rounds {
+		out.Rounds = append(out.Rounds, LogsTFRound{
+			StartTime: round.TimeStart.Unix(),
+			Winner:    logsTFTeamName(round.Winner),
+			Team: map[string]LogsTFRoundTeam{
+				"Red": {
+					Score: round.ScoreRed,
+					Kills: round.KillsRed,
+					Dmg:   round.DamageRed,
+					Ubers: int(math.Round(round.UbersRed)),
+				},
+				"Blue": {
+					Score: round.ScoreBlu,
+					Kills: round.KillsBlu,
+					Dmg:   round.DamageBlu,
+					Ubers: int(math.Round(round.UbersBlu)),
+				},
+			},
+			Length: round.Length,
+		})
+	}
+
+	for _, msg := range match.Chat {
+		out.Chat = append(out.Chat, LogsTFChat{
+			SteamID: string(steamid.SID64ToSID3(msg.SteamID)),
+			Name:    msg.PersonaName,
+			Msg:     msg.Body,
+		})
+	}
+
+	return out
+}
+
+func rawLogTime(t time.Time) string {
+	return t.UTC().Format("01/02/2006 - 15:04:05")
+}
+
+type rawLogLine struct {
+	createdOn time.Time
+	line      string
+}
+
+// RawLogExport renders the match as srcds style log text. Only the events that are stored are included,
+// being the map, rounds, chat and final scores, which is enough for log parsers to rebuild the match outline.
+func RawLogExport(match *store.MatchResult) string {
+	var (
+		builder strings.Builder
+		userIDs = map[steamid.SID64]int{}
+		teams   = map[steamid.SID64]logparse.Team{}
+		counts  = map[logparse.Team]int{}
+		start   = rawLogTime(match.TimeStart)
+		end     = rawLogTime(match.TimeEnd)
+	)
+
+	players := make([]*store.MatchPlayer, len(match.Players))
+	copy(players, match.Players)
+
+	sort.SliceStable(players, func(i, j int) bool {
+		return players[i].SteamID.Int64() < players[j].SteamID.Int64()
+	})
+
+	for idx, player := range players {
+		userIDs[player.SteamID] = idx + 1
+		teams[player.SteamID] = player.Team
+		counts[player.Team]++
+	}
+
+	writeLine := func(timestamp string, format string, args ...any) {
+		builder.WriteString(fmt.Sprintf("L %s: ", timestamp))
+		builder.WriteString(fmt.Sprintf(format, args...))
+		builder.WriteString("\n")
+	}
+
+	// Round and chat events are collected first so that they can be written in the order they happened
+	var events []rawLogLine
+
+	addEvent := func(createdOn time.Time, format string, args ...any) {
+		events = append(events, rawLogLine{createdOn: createdOn, line: fmt.Sprintf(format, args...)})
+	}
+
+	writeLine(start, `Log file started (file "%s.log") (game "tf")`, match.MatchID.String())
+	writeLine(start, `Loading map "%s"`, match.MapName)
+	writeLine(start, `Started map "%s" (CRC "")`, match.MapName)
+
+	if len(match.Rounds) == 0 {
+		writeLine(start, `World triggered "Round_Start"`)
+	}
+
+	for _, round := range match.Rounds {
+		roundEnd := round.TimeStart.Add(time.Duration(round.Length) * time.Second)
+
+		addEvent(round.TimeStart, `World triggered "Round_Start"`)
+
+		if winner := logsTFTeamName(round.Winner); winner != "" {
+			addEvent(roundEnd, `World triggered "Round_Win" (winner "%s")`, winner)
+		}
+
+		addEvent(roundEnd, `World triggered "Round_Length" (seconds "%d.00")`, round.Length)
+		addEvent(roundEnd, `Team "Red" current score "%d" with "%d" players`, round.ScoreRed, counts[logparse.RED])
+		addEvent(roundEnd, `Team "Blue" current score "%d" with "%d" players`, round.ScoreBlu, counts[logparse.BLU])
+	}
+
+	for _, msg := range match.Chat {
+		team := logsTFTeamName(teams[msg.SteamID])
+		if team == "" {
+			team = "Unassigned"
+		}
+
+		command := "say"
+		if msg.Team {
+			command = "say_team"
+		}
+
+		addEvent(msg.CreatedOn, `"%s<%d><%s><%s>" %s "%s"`, msg.PersonaName, userIDs[msg.SteamID],
+			steamid.SID64ToSID3(msg.SteamID), team, command, msg.Body)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].createdOn.Before(events[j].createdOn)
+	})
+
+	for _, event := range events {
+		writeLine(rawLogTime(event.createdOn), "%s", event.line)
+	}
+
+	writeLine(end, `World triggered "Game_Over" reason "Match Complete"`)
+	writeLine(end, `Team "Red" final score "%d" with "%d" players`, match.TeamScores.Red, counts[logparse.RED])
+	writeLine(end, `Team "Blue" final score "%d" with "%d" players`, match.TeamScores.Blu, counts[logparse.BLU])
+	writeLine(end, `Log file closed.`)
+
+	return builder.String()
+}
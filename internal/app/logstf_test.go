@@ -0,0 +1,93 @@
+package app // nolint:testpackage
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/leighmacdonald/gbans/pkg/logparse"
+	"github.com/leighmacdonald/golib"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "Update golden test files") //nolint:gochecknoglobals
+
+func readMatchFixture(t *testing.T) (store.MatchResult, string) {
+	t.Helper()
+
+	fixturePath := golib.FindFile(path.Join("testdata", "logstf_match.json"), "gbans")
+	if fixturePath == "" {
+		t.Skipf("Cant find test file: logstf_match.json")
+	}
+
+	body, errRead := os.ReadFile(fixturePath)
+	require.NoError(t, errRead)
+
+	var match store.MatchResult
+	require.NoError(t, json.Unmarshal(body, &match))
+
+	return match, filepath.Dir(fixturePath)
+}
+
+func compareGolden(t *testing.T, goldenPath string, actual []byte) {
+	t.Helper()
+
+	if *updateGolden {
+		require.NoError(t, os.WriteFile(goldenPath, actual, 0o600))
+	}
+
+	expected, errRead := os.ReadFile(goldenPath)
+	require.NoError(t, errRead)
+	require.Equal(t, string(expected), string(actual))
+}
+
+func TestLogsTFExport(t *testing.T) {
+	match, testDataDir := readMatchFixture(t)
+
+	body, errMarshal := json.MarshalIndent(LogsTFExport(&match), "", "  ")
+	require.NoError(t, errMarshal)
+
+	compareGolden(t, filepath.Join(testDataDir, "logstf_match.golden.json"), append(body, '\n'))
+}
+
+func TestRawLogExport(t *testing.T) {
+	match, testDataDir := readMatchFixture(t)
+
+	rawLog := RawLogExport(&match)
+
+	compareGolden(t, filepath.Join(testDataDir, "logstf_match.golden.log"), []byte(rawLog))
+
+	// The output must be readable by our own parser
+	var (
+		parser = logparse.NewLogParser()
+		parsed = logparse.NewMatch(match.ServerID, match.Title)
+	)
+
+	for _, line := range strings.Split(strings.TrimSpace(rawLog), "\n") {
+		result, errParse := parser.Parse(line)
+		require.NoError(t, errParse)
+		require.NotEqual(t, logparse.UnknownMsg, result.EventType, line)
+
+		// Errors are expected for events that don't affect match state, same as the live summarizer
+		_ = parsed.Apply(result)
+	}
+
+	require.Equal(t, match.MapName, parsed.MapName)
+	require.Equal(t, len(match.Chat), parsed.ChatCount())
+	require.Equal(t, match.TeamScores.Red, parsed.TeamScores.Red)
+	require.Equal(t, match.TeamScores.Blu, parsed.TeamScores.Blu)
+	require.Equal(t, len(match.Rounds), parsed.RoundCount())
+
+	for index, round := range match.Rounds {
+		require.Equal(t, round.TimeStart, parsed.Rounds[index].TimeStart)
+		require.Equal(t, round.Winner, parsed.Rounds[index].RoundWinner)
+		require.Equal(t, round.Length, int(parsed.Rounds[index].Length.Seconds()))
+		require.Equal(t, round.ScoreRed, parsed.Rounds[index].Score.Red)
+		require.Equal(t, round.ScoreBlu, parsed.Rounds[index].Score.Blu)
+	}
+}
@@ -0,0 +1,45 @@
+package app // nolint:testpackage
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func serveMapStats(app *App, target string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.GET("/api/stats/map/:map_name", onAPIGetMapStats(app))
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+
+	return recorder
+}
+
+func TestMapStatsInvalidQuery(t *testing.T) {
+	// Invalid queries are rejected before the database is used
+	app := &App{log: zap.NewNop()}
+
+	for _, target := range []string{
+		"/api/stats/map/pl_upward?bucket=hour",
+		"/api/stats/map/pl_upward?bucket=",
+		"/api/stats/map/pl_upward?limit=0",
+		"/api/stats/map/pl_upward?limit=101",
+		"/api/stats/map/pl_upward?limit=-1",
+		"/api/stats/map/pl_upward?limit=ten",
+	} {
+		require.Equal(t, http.StatusBadRequest, serveMapStats(app, target).Code, target)
+	}
+}
+
+func testMapStatsNotFound(app *App) func(t *testing.T) {
+	return func(t *testing.T) {
+		require.Equal(t, http.StatusNotFound, serveMapStats(app, "/api/stats/map/pl_unknown_map?bucket=day&limit=5").Code)
+	}
+}
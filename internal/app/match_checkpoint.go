@@ -0,0 +1,264 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/leighmacdonald/gbans/pkg/logparse"
+	"go.uber.org/zap"
+)
+
+const (
+	reasonNoEvents        = "No events received within resume window"
+	reasonNotResumed      = "Server did not resume after restart"
+	reasonNewMap          = "New map loaded"
+	reasonMapChanged      = "Map changed"
+	checkpointSaveTimeout = time.Second * 10
+)
+
+// isNewGameEvent returns true for events that indicate the server has started a new map, so any match
+// interrupted by a restart cannot be continued.
+func isNewGameEvent(eventType logparse.EventType) bool {
+	switch eventType {
+	case logparse.LogStart, logparse.MapLoad, logparse.MapStarted:
+		return true
+	default:
+		return false
+	}
+}
+
+// checkpointExpiryReason checks if the checkpointed match can be resumed by the incoming event. An empty
+// reason is returned when the match can be resumed.
+func checkpointExpiryReason(checkpoint store.MatchCheckpoint, eventType logparse.EventType, currentMap string,
+	now time.Time, window time.Duration,
+) string {
+	if now.Sub(checkpoint.LastEventOn) > window {
+		return reasonNoEvents
+	}
+
+	if isNewGameEvent(eventType) {
+		return reasonNewMap
+	}
+
+	if currentMap != "" && checkpoint.MapName != "" && currentMap != checkpoint.MapName {
+		return reasonMapChanged
+	}
+
+	return ""
+}
+
+// restoreMatch rebuilds the match state by replaying the checkpointed log lines.
+func restoreMatch(checkpoint store.MatchCheckpoint) logparse.Match {
+	parser := logparse.NewLogParser()
+
+	match := logparse.NewMatch(checkpoint.ServerID, checkpoint.Title)
+	match.MatchID = checkpoint.MatchID
+
+	for _, line := range checkpoint.Log {
+		result, errParse := parser.Parse(line)
+		if errParse != nil {
+			continue
+		}
+
+		// Errors are expected for events that don't affect match state, same as the live summarizer
+		_ = match.Apply(result)
+	}
+
+	return match
+}
+
+// track records the raw event so that it can be checkpointed and updates the checkpoint metadata.
+func (am *activeMatchContext) track(evt logparse.ServerEvent) {
+	am.checkpoint.LastEventOn = time.Now()
+
+	if evt.Raw != "" {
+		am.pendingLines = append(am.pendingLines, evt.Raw)
+	}
+
+	switch evt.EventType {
+	case logparse.WRoundStart:
+		am.roundStarted = true
+	case logparse.MapStarted:
+		if mapEvt, ok := evt.Event.(logparse.MapStartedEvt); ok {
+			am.checkpoint.MapName = mapEvt.Map
+		}
+	}
+}
+
+func (app *App) currentMapName(serverID int) string {
+	state := app.state.current()
+
+	server, found := state.byServerID(serverID)
+	if !found {
+		return ""
+	}
+
+	return server.Map
+}
+
+// loadMatchCheckpoints fetches the matches that were in progress when gbans last stopped.
+func (app *App) loadMatchCheckpoints(ctx context.Context, log *zap.Logger) map[int]store.MatchCheckpoint {
+	resumable := map[int]store.MatchCheckpoint{}
+
+	checkpoints, errCheckpoints := app.db.GetActiveMatchCheckpoints(ctx)
+	if errCheckpoints != nil {
+		log.Error("Failed to load match checkpoints", zap.Error(errCheckpoints))
+
+		return resumable
+	}
+
+	for _, checkpoint := range checkpoints {
+		resumable[checkpoint.ServerID] = checkpoint
+	}
+
+	if len(resumable) > 0 {
+		log.Info("Loaded resumable matches", zap.Int("count", len(resumable)))
+	}
+
+	return resumable
+}
+
+// newMatchContext creates the match context for the first event received from a server, resuming the
+// checkpointed match for the server when possible.
+func (app *App) newMatchContext(ctx context.Context, log *zap.Logger, evt logparse.ServerEvent,
+	resumable map[int]store.MatchCheckpoint,
+) *activeMatchContext {
+	var (
+		now        = time.Now()
+		currentMap = app.currentMapName(evt.ServerID)
+	)
+
+	matchContext := &activeMatchContext{
+		log:            log.Named(evt.ServerName),
+		incomingEvents: make(chan logparse.ServerEvent),
+	}
+
+	if checkpoint, found := resumable[evt.ServerID]; found {
+		delete(resumable, evt.ServerID)
+
+		reason := checkpointExpiryReason(checkpoint, evt.EventType, currentMap, now,
+			app.conf.Match.ResumeWindow.Duration())
+		if reason == "" {
+			matchContext.match = restoreMatch(checkpoint)
+			matchContext.roundStarted = true
+			matchContext.persisted = true
+			checkpoint.Log = nil
+			matchContext.checkpoint = checkpoint
+
+			log.Info("Resumed match", zap.String("match_id", checkpoint.MatchID.String()),
+				zap.Int("server_id", checkpoint.ServerID))
+
+			return matchContext
+		}
+
+		app.expireMatchCheckpoint(ctx, log, checkpoint, reason)
+	}
+
+	matchContext.match = logparse.NewMatch(evt.ServerID, evt.ServerName)
+	matchContext.checkpoint = store.MatchCheckpoint{
+		MatchID:   matchContext.match.MatchID,
+		ServerID:  evt.ServerID,
+		Title:     evt.ServerName,
+		MapName:   currentMap,
+		Status:    store.MatchCheckpointActive,
+		CreatedOn: now,
+	}
+
+	return matchContext
+}
+
+// saveMatchCheckpoint writes any lines received since the last checkpoint. Nothing is written until the
+// first round has started, so the map change noise between matches is not persisted.
+func (app *App) saveMatchCheckpoint(ctx context.Context, log *zap.Logger, matchContext *activeMatchContext) {
+	if !matchContext.roundStarted || len(matchContext.pendingLines) == 0 {
+		return
+	}
+
+	saveCtx, cancel := context.WithTimeout(ctx, checkpointSaveTimeout)
+	defer cancel()
+
+	matchContext.checkpoint.UpdatedOn = time.Now()
+
+	if errSave := app.db.SaveMatchCheckpoint(saveCtx, &matchContext.checkpoint, matchContext.pendingLines); errSave != nil {
+		// Lines are kept and retried on the next interval
+		log.Error("Failed to save match checkpoint",
+			zap.String("match_id", matchContext.checkpoint.MatchID.String()), zap.Error(errSave))
+
+		return
+	}
+
+	matchContext.pendingLines = nil
+	matchContext.persisted = true
+}
+
+// closeMatchCheckpoint sets the final status of the checkpoint for the match, if one was ever written.
+func (app *App) closeMatchCheckpoint(ctx context.Context, log *zap.Logger, matchContext *activeMatchContext,
+	status store.MatchCheckpointStatus, reason string,
+) {
+	if !matchContext.persisted {
+		return
+	}
+
+	if errClose := app.db.CloseMatchCheckpoint(ctx, matchContext.checkpoint.MatchID, status, reason); errClose != nil {
+		log.Error("Failed to close match checkpoint",
+			zap.String("match_id", matchContext.checkpoint.MatchID.String()), zap.Error(errClose))
+
+		return
+	}
+
+	if status != store.MatchCheckpointCompleted {
+		log.Warn("Match closed without completing",
+			zap.String("match_id", matchContext.checkpoint.MatchID.String()),
+			zap.String("status", status.String()), zap.String("reason", reason))
+	}
+}
+
+func (app *App) expireMatchCheckpoint(ctx context.Context, log *zap.Logger, checkpoint store.MatchCheckpoint, reason string) {
+	if errClose := app.db.CloseMatchCheckpoint(ctx, checkpoint.MatchID, store.MatchCheckpointExpired, reason); errClose != nil {
+		log.Error("Failed to expire match checkpoint",
+			zap.String("match_id", checkpoint.MatchID.String()), zap.Error(errClose))
+
+		return
+	}
+
+	log.Warn("Expired interrupted match", zap.String("match_id", checkpoint.MatchID.String()),
+		zap.Int("server_id", checkpoint.ServerID), zap.String("reason", reason))
+}
+
+// checkpointMatches persists the state of all in progress matches and expires any that have stopped
+// receiving events.
+func (app *App) checkpointMatches(ctx context.Context, log *zap.Logger, matches map[int]*activeMatchContext,
+	resumable map[int]store.MatchCheckpoint,
+) {
+	var (
+		now    = time.Now()
+		window = app.conf.Match.ResumeWindow.Duration()
+	)
+
+	for serverID, matchContext := range matches {
+		if now.Sub(matchContext.checkpoint.LastEventOn) > window {
+			matchContext.cancel()
+			app.closeMatchCheckpoint(ctx, log, matchContext, store.MatchCheckpointExpired, reasonNoEvents)
+			delete(matches, serverID)
+
+			continue
+		}
+
+		app.saveMatchCheckpoint(ctx, log, matchContext)
+	}
+
+	for serverID, checkpoint := range resumable {
+		if now.Sub(checkpoint.LastEventOn) > window {
+			app.expireMatchCheckpoint(ctx, log, checkpoint, reasonNotResumed)
+			delete(resumable, serverID)
+		}
+	}
+}
+
+func (app *App) pruneMatchCheckpoints(ctx context.Context, log *zap.Logger) {
+	before := time.Now().Add(-app.conf.Match.CheckpointRetention.Duration())
+	if errPrune := app.db.PruneMatchCheckpoints(ctx, before); errPrune != nil {
+		log.Error("Failed to prune match checkpoints", zap.Error(errPrune))
+	}
+}
@@ -0,0 +1,63 @@
+package app // nolint:testpackage
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/leighmacdonald/gbans/pkg/logparse"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointExpiryReason(t *testing.T) {
+	var (
+		now        = time.Now()
+		window     = time.Minute * 10
+		checkpoint = store.MatchCheckpoint{MapName: "pl_upward", LastEventOn: now.Add(-time.Minute)}
+	)
+
+	require.Equal(t, "", checkpointExpiryReason(checkpoint, logparse.Killed, "pl_upward", now, window))
+	require.Equal(t, "", checkpointExpiryReason(checkpoint, logparse.Killed, "", now, window))
+	require.Equal(t, reasonNewMap, checkpointExpiryReason(checkpoint, logparse.MapLoad, "pl_upward", now, window))
+	require.Equal(t, reasonNewMap, checkpointExpiryReason(checkpoint, logparse.LogStart, "pl_upward", now, window))
+	require.Equal(t, reasonMapChanged, checkpointExpiryReason(checkpoint, logparse.Killed, "koth_product", now, window))
+	require.Equal(t, reasonNoEvents, checkpointExpiryReason(checkpoint, logparse.Killed, "pl_upward",
+		now.Add(window), window))
+}
+
+func TestRestoreMatch(t *testing.T) {
+	body, errRead := os.ReadFile(replayTestFile(t, "log_3124689.log"))
+	require.NoError(t, errRead)
+
+	var (
+		lines  = strings.Split(strings.TrimSpace(string(body)), "\n")
+		half   = len(lines) / 2
+		parser = logparse.NewLogParser()
+		live   = logparse.NewMatch(1, "test server")
+	)
+
+	for _, line := range lines[:half] {
+		result, errParse := parser.Parse(line)
+		require.NoError(t, errParse)
+
+		_ = live.Apply(result)
+	}
+
+	checkpoint := store.MatchCheckpoint{
+		MatchID:  uuid.Must(uuid.NewV4()),
+		ServerID: 1,
+		Title:    "test server",
+		Log:      lines[:half],
+	}
+
+	restored := restoreMatch(checkpoint)
+
+	require.Equal(t, checkpoint.MatchID, restored.MatchID)
+	require.Equal(t, live.PlayerCount(), restored.PlayerCount())
+	require.Equal(t, live.ChatCount(), restored.ChatCount())
+	require.Equal(t, live.RoundCount(), restored.RoundCount())
+	require.Equal(t, live.TimeStart, restored.TimeStart)
+}
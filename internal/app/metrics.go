@@ -24,6 +24,12 @@ type metricCollector struct {
 	disconnectedCounter *prometheus.CounterVec
 	classCounter        *prometheus.CounterVec
 	playerCounter       *prometheus.HistogramVec
+	spamCounter         *prometheus.CounterVec
+	spamGagCounter      *prometheus.CounterVec
+	serverUp            *prometheus.GaugeVec
+	serverUptime        *prometheus.GaugeVec
+	serverDownCounter   *prometheus.CounterVec
+	healthAlertCounter  *prometheus.CounterVec
 }
 
 func newMetricCollector() *metricCollector {
@@ -78,6 +84,30 @@ func newMetricCollector() *metricCollector {
 		classCounter: prometheus.NewCounterVec(
 			prometheus.CounterOpts{Name: "gbans_player_class_total", Help: "Player class"},
 			[]string{"class"}),
+
+		spamCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: "gbans_game_chat_spam_total", Help: "Total chat spam detections"},
+			[]string{"server_name", "kind"}),
+
+		spamGagCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: "gbans_game_chat_spam_gags_total", Help: "Total gags applied for chat spam"},
+			[]string{"server_name"}),
+
+		serverUp: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: "gbans_server_up", Help: "Server is responding to status updates"},
+			[]string{"server_name"}),
+
+		serverUptime: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Name: "gbans_server_uptime_ratio", Help: "Ratio of time the server was up within the window"},
+			[]string{"server_name", "window"}),
+
+		serverDownCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: "gbans_server_down_total", Help: "Total times a server went down"},
+			[]string{"server_name"}),
+
+		healthAlertCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Name: "gbans_server_health_alerts_total", Help: "Total server health alerts"},
+			[]string{"server_name", "kind"}),
 	}
 	for _, metric := range []prometheus.Collector{
 		collector.damageCounter,
@@ -93,6 +123,12 @@ func newMetricCollector() *metricCollector {
 		collector.connectedCounter,
 		collector.disconnectedCounter,
 		collector.classCounter,
+		collector.spamCounter,
+		collector.spamGagCounter,
+		collector.serverUp,
+		collector.serverUptime,
+		collector.serverDownCounter,
+		collector.healthAlertCounter,
 	} {
 		_ = prometheus.Register(metric)
 	}
@@ -0,0 +1,138 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/leighmacdonald/gbans/internal/consts"
+	"github.com/leighmacdonald/gbans/internal/discord"
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/leighmacdonald/gbans/pkg/logparse"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// findNameFilterMatch checks a name or profile value against the loaded word filters. Returns a nil filter
+// when the target is disabled or nothing matched.
+func (app *App) findNameFilterMatch(conf filterTargetConfig, value string) (string, *store.Filter) {
+	if !app.conf.Filter.Enabled || !conf.Enabled || value == "" {
+		return "", nil
+	}
+
+	return app.wordFilters.findFilteredWordMatch(value)
+}
+
+// applyNameFilterAction performs the configured action against a player whose name or profile matched
+// a filter. Players that are not currently connected are only affected by the gag & ban actions.
+func (app *App) applyNameFilterAction(ctx context.Context, conf filterTargetConfig, target steamid.SID64,
+	field string, value string, matched string, filter *store.Filter,
+) error {
+	if !app.conf.Filter.Dry {
+		var errAction error
+
+		switch conf.Action {
+		case Warn:
+			errAction = app.PSay(ctx, target, conf.Message)
+		case Kick:
+			errAction = app.OnFindExec(ctx, findOpts{SteamID: target}, func(info playerServerInfo) string {
+				return fmt.Sprintf(`sm_kick #%d "%s"`, info.Player.UserID, conf.Message)
+			})
+		case Gag, Ban:
+			banType := store.Banned
+			if conf.Action == Gag {
+				banType = store.NoComm
+			}
+
+			var banSteam store.BanSteam
+			if errNewBan := store.NewBanSteam(ctx, store.StringSID(app.conf.General.Owner.String()),
+				store.StringSID(target.String()),
+				store.Duration(conf.Duration),
+				store.Profile,
+				conf.Message,
+				fmt.Sprintf("Automatic %s filter match: %s", field, value),
+				store.System,
+				0,
+				banType,
+				&banSteam); errNewBan != nil {
+				return errors.Wrap(errNewBan, "Failed to create name filter ban")
+			}
+
+			errAction = app.BanSteam(ctx, &banSteam)
+			if errors.Is(errAction, store.ErrDuplicate) {
+				// Already handled, eg: during the connection check
+				return nil
+			}
+		default:
+			return errors.Errorf("Unknown filter action: %s", conf.Action)
+		}
+
+		if errAction != nil && !errors.Is(errAction, consts.ErrPlayerNotFound) {
+			return errors.Wrap(errAction, "Failed to apply name filter action")
+		}
+	}
+
+	if app.conf.Filter.PingDiscord {
+		msgEmbed := discord.
+			NewEmbed(fmt.Sprintf("%s Filter Matched", field)).
+			SetDescription(value).
+			SetColor(app.bot.Colour.Warn).
+			AddField("Filter ID", fmt.Sprintf("%d", filter.FilterID)).
+			AddField("Matched", matched).
+			AddField("Action", string(conf.Action)).InlineAllFields().
+			AddField("Pattern", filter.Pattern)
+
+		app.addTarget(ctx, msgEmbed, target)
+
+		app.bot.SendPayload(discord.Payload{
+			ChannelID: app.conf.Discord.LogChannelID,
+			Embed:     msgEmbed.Truncate().MessageEmbed,
+		})
+	}
+
+	return nil
+}
+
+// nameFilterWorker checks player names against the word filters as they connect or change their name
+// while in-game.
+func (app *App) nameFilterWorker(ctx context.Context) {
+	log := app.log.Named("nameFilter")
+
+	serverEventChan := make(chan logparse.ServerEvent)
+	if errRegister := app.eb.Consume(serverEventChan, logparse.Connected, logparse.ChangedName); errRegister != nil {
+		log.Warn("Tried to register duplicate reader channel", zap.Error(errRegister))
+
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case evt := <-serverEventChan:
+			var (
+				sid64 steamid.SID64
+				name  string
+			)
+
+			switch newServerEvent := evt.Event.(type) {
+			case logparse.ConnectedEvt:
+				sid64, name = newServerEvent.SID, newServerEvent.Name
+			case logparse.ChangedNameEvt:
+				sid64, name = newServerEvent.SID, newServerEvent.NewName
+			default:
+				continue
+			}
+
+			matched, filter := app.findNameFilterMatch(app.conf.Filter.Name, name)
+			if filter == nil {
+				continue
+			}
+
+			if errApply := app.applyNameFilterAction(ctx, app.conf.Filter.Name, sid64, "Name", name, matched, filter); errApply != nil {
+				log.Error("Failed to apply name filter action", zap.Error(errApply),
+					zap.Int64("sid64", sid64.Int64()))
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/leighmacdonald/gbans/pkg/logparse"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+	"go.uber.org/zap"
+)
+
+// nameHistoryUpdateInterval is the minimum time between writes of an already recorded name, so that every
+// chat message does not result in a write.
+const nameHistoryUpdateInterval = time.Minute * 10
+
+type nameHistoryKey struct {
+	steamID steamid.SID64
+	name    string
+}
+
+// nameHistoryCache tracks when each name was last written to the name history.
+type nameHistoryCache struct {
+	*sync.Mutex
+	lastWrite map[nameHistoryKey]time.Time
+}
+
+func newNameHistoryCache() *nameHistoryCache {
+	return &nameHistoryCache{
+		Mutex:     &sync.Mutex{},
+		lastWrite: map[nameHistoryKey]time.Time{},
+	}
+}
+
+// shouldWrite returns true and marks the name as written if it has not been written within the
+// update interval.
+func (c *nameHistoryCache) shouldWrite(steamID steamid.SID64, name string, seen time.Time) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	key := nameHistoryKey{steamID: steamID, name: name}
+
+	last, found := c.lastWrite[key]
+	if found && seen.Sub(last) < nameHistoryUpdateInterval {
+		return false
+	}
+
+	c.lastWrite[key] = seen
+
+	return true
+}
+
+func (c *nameHistoryCache) prune(olderThan time.Time) {
+	c.Lock()
+	defer c.Unlock()
+
+	for key, last := range c.lastWrite {
+		if last.Before(olderThan) {
+			delete(c.lastWrite, key)
+		}
+	}
+}
+
+// recordPersonaName adds the name to the players name history. Names used by erased players are skipped
+// by the store so that erasure is not undone the next time they join.
+func (app *App) recordPersonaName(ctx context.Context, steamID steamid.SID64, name string, seen time.Time) {
+	name = strings.ToValidUTF8(strings.TrimSpace(name), "_")
+	if name == "" || !steamID.Valid() {
+		return
+	}
+
+	if !app.nameHistory.shouldWrite(steamID, name, seen) {
+		return
+	}
+
+	if errAdd := app.db.AddPersonName(ctx, steamID, name, seen); errAdd != nil {
+		app.log.Error("Failed to add name history", zap.Error(errAdd), zap.Int64("sid64", steamID.Int64()))
+	}
+}
+
+// nameHistoryWorker records the names players use in game.
+func (app *App) nameHistoryWorker(ctx context.Context) {
+	var (
+		log         = app.log.Named("nameHistory")
+		pruneTicker = time.NewTicker(nameHistoryUpdateInterval)
+	)
+
+	serverEventChan := make(chan logparse.ServerEvent)
+	if errRegister := app.eb.Consume(serverEventChan, logparse.Connected, logparse.ChangedName,
+		logparse.Say, logparse.SayTeam); errRegister != nil {
+		log.Warn("Tried to register duplicate reader channel", zap.Error(errRegister))
+
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-pruneTicker.C:
+			app.nameHistory.prune(time.Now().Add(-nameHistoryUpdateInterval))
+		case evt := <-serverEventChan:
+			switch newServerEvent := evt.Event.(type) {
+			case logparse.ConnectedEvt:
+				app.recordPersonaName(ctx, newServerEvent.SID, newServerEvent.Name, newServerEvent.CreatedOn)
+			case logparse.ChangedNameEvt:
+				app.recordPersonaName(ctx, newServerEvent.SID, newServerEvent.NewName, newServerEvent.CreatedOn)
+			case logparse.SayEvt:
+				app.recordPersonaName(ctx, newServerEvent.SID, newServerEvent.Name, newServerEvent.CreatedOn)
+			}
+		}
+	}
+}
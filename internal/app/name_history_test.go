@@ -0,0 +1,25 @@
+package app // nolint:testpackage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/leighmacdonald/steamid/v3/steamid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNameHistoryCache(t *testing.T) {
+	var (
+		cache = newNameHistoryCache()
+		sid   = steamid.New(76561198084134025)
+		now   = time.Now()
+	)
+
+	require.True(t, cache.shouldWrite(sid, "name", now))
+	require.False(t, cache.shouldWrite(sid, "name", now.Add(time.Minute)))
+	require.True(t, cache.shouldWrite(sid, "other", now.Add(time.Minute)))
+	require.True(t, cache.shouldWrite(sid, "name", now.Add(nameHistoryUpdateInterval)))
+
+	cache.prune(now.Add(nameHistoryUpdateInterval * 2))
+	require.Empty(t, cache.lastWrite)
+}
@@ -0,0 +1,356 @@
+package app
+
+import (
+	"context"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/leighmacdonald/gbans/pkg/logparse"
+	"github.com/leighmacdonald/gbans/pkg/mm"
+	"github.com/leighmacdonald/gbans/pkg/rating"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+	"github.com/pkg/errors"
+)
+
+var (
+	errRatingDuration  = errors.New("Match too short to be rated")
+	errRatingPlayers   = errors.New("Not enough players to be rated")
+	errRatingImbalance = errors.New("Teams too uneven to be rated")
+)
+
+type ratingKey struct {
+	steamID     steamid.SID64
+	gameType    mm.GameType
+	playerClass logparse.PlayerClass
+}
+
+// ratingCache holds the current ratings of players, players missing from the cache have not been rated yet.
+type ratingCache map[ratingKey]store.PlayerRating
+
+func (c ratingCache) get(key ratingKey) store.PlayerRating {
+	if current, found := c[key]; found {
+		return current
+	}
+
+	return store.PlayerRating{
+		SteamID:     key.steamID,
+		GameType:    key.gameType,
+		PlayerClass: key.playerClass,
+		Rating:      rating.New(),
+	}
+}
+
+// ratedPlayer is a player eligible to have their rating updated by a match.
+type ratedPlayer struct {
+	steamID     steamid.SID64
+	team        logparse.Team
+	playerClass logparse.PlayerClass
+}
+
+// primaryClass returns the class the player spent the most time on.
+func primaryClass(player *store.MatchPlayer) logparse.PlayerClass {
+	var (
+		class    = store.RatingAnyClass
+		playtime int
+	)
+
+	for _, playerClass := range player.Classes {
+		if playerClass.PlayerClass == logparse.Spectator || playerClass.PlayerClass == logparse.Multi {
+			continue
+		}
+
+		if playerClass.Playtime > playtime {
+			class = playerClass.PlayerClass
+			playtime = playerClass.Playtime
+		}
+	}
+
+	return class
+}
+
+// ratedPlayers applies the anti farming rules to a match, returning the players which should be rated.
+//
+// Bots are never rated, they are not saved with the match so don't count towards the minimum players either.
+// Players that only took part in a small part of the match are left out so that joining a match just
+// before it ends doesn't count.
+func ratedPlayers(conf ratingConfig, match *store.MatchResult) ([]ratedPlayer, error) {
+	duration := match.TimeEnd.Sub(match.TimeStart)
+	if duration < conf.MinDuration.Duration() {
+		return nil, errRatingDuration
+	}
+
+	var (
+		players []ratedPlayer
+		red     int
+		blu     int
+	)
+
+	for _, player := range match.Players {
+		if !player.SteamID.Valid() || (player.Team != logparse.RED && player.Team != logparse.BLU) {
+			continue
+		}
+
+		if player.TimeEnd.Sub(player.TimeStart).Seconds() < duration.Seconds()*conf.MinParticipation {
+			continue
+		}
+
+		if player.Team == logparse.RED {
+			red++
+		} else {
+			blu++
+		}
+
+		players = append(players, ratedPlayer{
+			steamID:     player.SteamID,
+			team:        player.Team,
+			playerClass: primaryClass(player),
+		})
+	}
+
+	if len(players) < conf.MinPlayers || red == 0 || blu == 0 {
+		return nil, errRatingPlayers
+	}
+
+	if red-blu > conf.MaxTeamDifference || blu-red > conf.MaxTeamDifference {
+		return nil, errRatingImbalance
+	}
+
+	return players, nil
+}
+
+// matchGameType determines the competitive format from the team sizes. Matches with any other team sizes, such
+// as regular pub matches, only update the ratings for all formats.
+func matchGameType(players []ratedPlayer) (mm.GameType, bool) {
+	var red, blu int
+
+	for _, player := range players {
+		if player.team == logparse.RED {
+			red++
+		} else {
+			blu++
+		}
+	}
+
+	if red != blu {
+		return store.RatingAnyFormat, false
+	}
+
+	switch red {
+	case 9:
+		return mm.Highlander, true
+	case 6:
+		return mm.Sixes, true
+	case 2:
+		return mm.Ultiduo, true
+	default:
+		return store.RatingAnyFormat, false
+	}
+}
+
+// rateMatch calculates the updated ratings of the players in the match.
+//
+// Each team is treated as a single opponent with the average rating of its members, every player is then rated
+// as having won, lost or drawn against the opposing team. Ratings are kept across all formats & classes, for the
+// format of the match, and for the primary class played by each player. Class ratings are rated against the
+// opposing teams rating in the same format.
+func rateMatch(conf ratingConfig, match *store.MatchResult, cache ratingCache) ([]store.PlayerRating, []store.PlayerRatingHistory, error) {
+	players, errPlayers := ratedPlayers(conf, match)
+	if errPlayers != nil {
+		return nil, nil, errPlayers
+	}
+
+	gameTypes := []mm.GameType{store.RatingAnyFormat}
+	if gameType, found := matchGameType(players); found {
+		gameTypes = append(gameTypes, gameType)
+	}
+
+	// Opposing team ratings must be calculated before any of the players are updated
+	teams := map[mm.GameType]map[logparse.Team]rating.Rating{}
+
+	for _, gameType := range gameTypes {
+		members := map[logparse.Team][]rating.Rating{}
+
+		for _, player := range players {
+			current := cache.get(ratingKey{steamID: player.steamID, gameType: gameType, playerClass: store.RatingAnyClass})
+			members[player.team] = append(members[player.team], current.Rating)
+		}
+
+		teams[gameType] = map[logparse.Team]rating.Rating{
+			logparse.RED: rating.Team(members[logparse.RED]),
+			logparse.BLU: rating.Team(members[logparse.BLU]),
+		}
+	}
+
+	var (
+		ratings []store.PlayerRating
+		history []store.PlayerRatingHistory
+	)
+
+	for _, player := range players {
+		opponent := logparse.RED
+		if player.team == logparse.RED {
+			opponent = logparse.BLU
+		}
+
+		score := rating.Draw
+
+		switch match.Winner {
+		case player.team:
+			score = rating.Win
+		case opponent:
+			score = rating.Loss
+		}
+
+		for _, gameType := range gameTypes {
+			classes := []logparse.PlayerClass{store.RatingAnyClass}
+			if player.playerClass != store.RatingAnyClass {
+				classes = append(classes, player.playerClass)
+			}
+
+			for _, playerClass := range classes {
+				updated := cache.get(ratingKey{steamID: player.steamID, gameType: gameType, playerClass: playerClass})
+				previous := updated.Rating
+
+				updated.Rating = rating.Update(previous, []rating.Result{
+					{Opponent: teams[gameType][opponent], Score: score},
+				}, conf.Tau)
+				updated.Matches++
+				updated.UpdatedOn = match.TimeEnd
+
+				switch score {
+				case rating.Win:
+					updated.Wins++
+				case rating.Loss:
+					updated.Losses++
+				default:
+					updated.Draws++
+				}
+
+				ratings = append(ratings, updated)
+				history = append(history, store.PlayerRatingHistory{
+					MatchID:     match.MatchID,
+					SteamID:     player.steamID,
+					GameType:    gameType,
+					PlayerClass: playerClass,
+					Rating:      updated.Rating,
+					Change:      updated.Rating.Rating - previous.Rating,
+					CreatedOn:   match.TimeEnd,
+				})
+			}
+		}
+	}
+
+	return ratings, history, nil
+}
+
+// isRatingSkipped checks if the error is from a match not being eligible for rating.
+func isRatingSkipped(err error) bool {
+	return errors.Is(err, errRatingDuration) || errors.Is(err, errRatingPlayers) || errors.Is(err, errRatingImbalance)
+}
+
+func loadRatingCache(ctx context.Context, database *store.Store, match *store.MatchResult) (ratingCache, error) {
+	var steamIDs steamid.Collection
+	for _, player := range match.Players {
+		steamIDs = append(steamIDs, player.SteamID)
+	}
+
+	current, errRatings := database.PlayerRatings(ctx, steamIDs)
+	if errRatings != nil {
+		return nil, errors.Wrap(errRatings, "Failed to load player ratings")
+	}
+
+	cache := ratingCache{}
+	for _, playerRating := range current {
+		cache[ratingKey{steamID: playerRating.SteamID, gameType: playerRating.GameType, playerClass: playerRating.PlayerClass}] = playerRating
+	}
+
+	return cache, nil
+}
+
+// updateMatchRatings rates a newly completed match. Matches that were already rated are ignored.
+func (app *App) updateMatchRatings(ctx context.Context, match *store.MatchResult) error {
+	rated, errRated := app.db.MatchRated(ctx, match.MatchID)
+	if errRated != nil {
+		return errors.Wrap(errRated, "Failed to check match rating state")
+	}
+
+	if rated {
+		return nil
+	}
+
+	cache, errCache := loadRatingCache(ctx, app.db, match)
+	if errCache != nil {
+		return errCache
+	}
+
+	ratings, history, errRate := rateMatch(app.conf.Rating, match, cache)
+	if errRate != nil {
+		return errRate
+	}
+
+	return app.db.PlayerRatingsSave(ctx, ratings, history)
+}
+
+// RatingRecomputeResult summarises a recompute of all ratings.
+type RatingRecomputeResult struct {
+	Matches int
+	Rated   int
+	Skipped int
+}
+
+// RecomputeRatings replaces all existing ratings by rating every stored match again in the order they were
+// played. This should be used after changing the rating config. The ratings are replaced in a single transaction,
+// matches completed while it runs are rated against the ratings from before the recompute.
+func RecomputeRatings(ctx context.Context, database *store.Store, conf *Config,
+	onMatch func(matchID uuid.UUID, err error),
+) (RatingRecomputeResult, error) {
+	var result RatingRecomputeResult
+
+	matchIDs, errMatchIDs := database.RatedMatchIDs(ctx)
+	if errMatchIDs != nil {
+		return result, errors.Wrap(errMatchIDs, "Failed to load matches")
+	}
+
+	// Every rating is computed here, so they can be kept in memory instead of loaded for each match
+	cache := ratingCache{}
+
+	errRecompute := database.PlayerRatingsRecompute(ctx, matchIDs,
+		func(matchID uuid.UUID) ([]store.PlayerRating, []store.PlayerRatingHistory, error) {
+			if ctx.Err() != nil {
+				return nil, nil, errors.Wrap(ctx.Err(), "Recompute cancelled")
+			}
+
+			result.Matches++
+
+			var match store.MatchResult
+			if errMatch := database.MatchGetByID(ctx, matchID, &match); errMatch != nil {
+				return nil, nil, errors.Wrapf(errMatch, "Failed to load match %s", matchID)
+			}
+
+			ratings, history, errRate := rateMatch(conf.Rating, &match, cache)
+			if errRate != nil {
+				if !isRatingSkipped(errRate) {
+					return nil, nil, errRate
+				}
+
+				result.Skipped++
+				onMatch(matchID, errRate)
+
+				return nil, nil, nil
+			}
+
+			for _, playerRating := range ratings {
+				cache[ratingKey{steamID: playerRating.SteamID, gameType: playerRating.GameType, playerClass: playerRating.PlayerClass}] = playerRating
+			}
+
+			result.Rated++
+			onMatch(matchID, nil)
+
+			return ratings, history, nil
+		})
+	if errRecompute != nil {
+		return result, errRecompute
+	}
+
+	return result, nil
+}
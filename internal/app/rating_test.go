@@ -0,0 +1,165 @@
+package app // nolint:testpackage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/leighmacdonald/gbans/pkg/logparse"
+	"github.com/leighmacdonald/gbans/pkg/mm"
+	"github.com/leighmacdonald/gbans/pkg/rating"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+	"github.com/stretchr/testify/require"
+)
+
+func testRatingConfig() ratingConfig {
+	return ratingConfig{
+		Enabled:           true,
+		Tau:               rating.DefaultTau,
+		MinPlayers:        12,
+		MaxTeamDifference: 2,
+		MinDuration:       StringDuration("10m"),
+		MinParticipation:  0.5,
+	}
+}
+
+func testRatingMatch(redPlayers int, bluPlayers int, winner logparse.Team) *store.MatchResult {
+	var (
+		start = time.Date(2024, 3, 10, 18, 0, 0, 0, time.UTC)
+		end   = start.Add(time.Minute * 30)
+		match = &store.MatchResult{
+			MatchID:   uuid.Must(uuid.NewV4()),
+			TimeStart: start,
+			TimeEnd:   end,
+			Winner:    winner,
+		}
+	)
+
+	addPlayer := func(index int, team logparse.Team) {
+		player := &store.MatchPlayer{
+			Team:      team,
+			TimeStart: start,
+			TimeEnd:   end,
+			Classes: []store.MatchPlayerClass{
+				{PlayerClass: logparse.Scout, Playtime: 600},
+				{PlayerClass: logparse.Soldier, Playtime: 1200},
+			},
+		}
+		player.SteamID = steamid.New(76561198000000000 + int64(index))
+
+		match.Players = append(match.Players, player)
+	}
+
+	for index := 0; index < redPlayers; index++ {
+		addPlayer(index, logparse.RED)
+	}
+
+	for index := 0; index < bluPlayers; index++ {
+		addPlayer(100+index, logparse.BLU)
+	}
+
+	return match
+}
+
+func TestRateMatch(t *testing.T) {
+	match := testRatingMatch(6, 6, logparse.RED)
+
+	ratings, history, errRate := rateMatch(testRatingConfig(), match, ratingCache{})
+	require.NoError(t, errRate)
+	// All formats & classes, sixes, and soldier for each of those
+	require.Len(t, ratings, 12*4)
+	require.Len(t, history, len(ratings))
+
+	gameTypes := map[mm.GameType]int{}
+
+	for index, playerRating := range ratings {
+		gameTypes[playerRating.GameType]++
+
+		require.Contains(t, []logparse.PlayerClass{store.RatingAnyClass, logparse.Soldier}, playerRating.PlayerClass)
+		require.Equal(t, 1, playerRating.Matches)
+		require.Equal(t, match.MatchID, history[index].MatchID)
+		require.InDelta(t, playerRating.Rating.Rating-rating.DefaultRating, history[index].Change, 0.0001)
+
+		if playerRating.SteamID.Int64() < 76561198000000100 {
+			require.Greater(t, playerRating.Rating.Rating, rating.DefaultRating)
+			require.Equal(t, 1, playerRating.Wins)
+		} else {
+			require.Less(t, playerRating.Rating.Rating, rating.DefaultRating)
+			require.Equal(t, 1, playerRating.Losses)
+		}
+
+		require.Less(t, playerRating.Deviation, rating.DefaultDeviation)
+	}
+
+	require.Equal(t, map[mm.GameType]int{store.RatingAnyFormat: 24, mm.Sixes: 24}, gameTypes)
+}
+
+func TestRateMatchDraw(t *testing.T) {
+	ratings, _, errRate := rateMatch(testRatingConfig(), testRatingMatch(8, 7, logparse.UNASSIGNED), ratingCache{})
+	require.NoError(t, errRate)
+	// Uneven teams don't match a competitive format
+	require.Len(t, ratings, 15*2)
+
+	for _, playerRating := range ratings {
+		require.Equal(t, store.RatingAnyFormat, playerRating.GameType)
+		require.Equal(t, 1, playerRating.Draws)
+		require.InDelta(t, rating.DefaultRating, playerRating.Rating.Rating, 0.0001)
+	}
+}
+
+func TestRateMatchCache(t *testing.T) {
+	var (
+		conf  = testRatingConfig()
+		cache = ratingCache{}
+	)
+
+	for _, winner := range []logparse.Team{logparse.RED, logparse.RED} {
+		ratings, _, errRate := rateMatch(conf, testRatingMatch(6, 6, winner), cache)
+		require.NoError(t, errRate)
+
+		for _, playerRating := range ratings {
+			cache[ratingKey{steamID: playerRating.SteamID, gameType: playerRating.GameType, playerClass: playerRating.PlayerClass}] = playerRating
+		}
+	}
+
+	winner := cache.get(ratingKey{steamID: steamid.New(76561198000000000), gameType: store.RatingAnyFormat, playerClass: store.RatingAnyClass})
+	require.Equal(t, 2, winner.Matches)
+	require.Equal(t, 2, winner.Wins)
+
+	// An unrated player starts with the defaults
+	require.Equal(t, rating.New(), cache.get(ratingKey{steamID: steamid.New(76561198000000999)}).Rating)
+}
+
+func TestRatedPlayers(t *testing.T) {
+	conf := testRatingConfig()
+
+	short := testRatingMatch(6, 6, logparse.RED)
+	short.TimeEnd = short.TimeStart.Add(time.Minute * 5)
+
+	_, errShort := ratedPlayers(conf, short)
+	require.ErrorIs(t, errShort, errRatingDuration)
+
+	_, errPlayers := ratedPlayers(conf, testRatingMatch(4, 4, logparse.RED))
+	require.ErrorIs(t, errPlayers, errRatingPlayers)
+
+	_, errImbalance := ratedPlayers(conf, testRatingMatch(9, 5, logparse.RED))
+	require.ErrorIs(t, errImbalance, errRatingImbalance)
+
+	// Bots & players who only joined at the end are not rated or counted
+	match := testRatingMatch(6, 6, logparse.RED)
+	match.Players[0].SteamID = steamid.New(logparse.BotSid)
+	match.Players[1].TimeStart = match.TimeEnd.Add(-time.Minute)
+
+	_, errExcluded := ratedPlayers(conf, match)
+	require.ErrorIs(t, errExcluded, errRatingPlayers)
+
+	conf.MinPlayers = 10
+	players, errRated := ratedPlayers(conf, match)
+	require.NoError(t, errRated)
+	require.Len(t, players, 10)
+
+	gameType, found := matchGameType(players)
+	require.False(t, found)
+	require.Equal(t, store.RatingAnyFormat, gameType)
+}
@@ -0,0 +1,352 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/leighmacdonald/gbans/internal/discord"
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/leighmacdonald/gbans/pkg/cron"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	scheduledTaskCheckInterval = time.Second * 30
+	// scheduledTaskMaxDelay is how late a task can be before the run is skipped, eg: when gbans was
+	// not running at the scheduled time. Server restarts at 6am should not happen at noon.
+	scheduledTaskMaxDelay   = time.Minute * 5
+	scheduledTaskRetention  = time.Hour * 24 * 90
+	scheduledTaskMaxRespLen = 2000
+)
+
+var errInvalidTask = errors.New("Invalid task")
+
+// scheduledTaskTemplateData is the data available to Say and CSay message templates, eg: {{ .Players }}.
+type scheduledTaskTemplateData struct {
+	ServerName string
+	Hostname   string
+	Map        string
+	Players    int
+	MaxPlayers int
+	Time       time.Time
+}
+
+func taskLocation(task store.ScheduledTask) (*time.Location, error) {
+	loc, errLoc := time.LoadLocation(task.Timezone)
+	if errLoc != nil {
+		return nil, errors.Wrapf(errInvalidTask, "Unknown timezone: %s", task.Timezone)
+	}
+
+	return loc, nil
+}
+
+// nextTaskRun calculates the next time the task should run after the provided time.
+func nextTaskRun(task store.ScheduledTask, after time.Time) (*time.Time, error) {
+	schedule, errSchedule := cron.Parse(task.CronExpression)
+	if errSchedule != nil {
+		return nil, errors.Wrap(errInvalidTask, errSchedule.Error())
+	}
+
+	loc, errLoc := taskLocation(task)
+	if errLoc != nil {
+		return nil, errLoc
+	}
+
+	next := schedule.Next(after.In(loc))
+	if next.IsZero() {
+		return nil, errors.Wrap(errInvalidTask, "Schedule never runs")
+	}
+
+	return &next, nil
+}
+
+func parseTaskTemplate(task store.ScheduledTask) (*template.Template, error) {
+	tmpl, errTmpl := template.New("task").Option("missingkey=error").Parse(task.Command)
+	if errTmpl != nil {
+		return nil, errors.Wrap(errInvalidTask, errTmpl.Error())
+	}
+
+	return tmpl, nil
+}
+
+// validateScheduledTask checks the task can be executed and updates the next run time.
+func validateScheduledTask(task *store.ScheduledTask) error {
+	task.Name = strings.TrimSpace(task.Name)
+	task.Command = strings.TrimSpace(task.Command)
+	task.ServerSelector = strings.TrimSpace(task.ServerSelector)
+
+	if task.Timezone == "" {
+		task.Timezone = "UTC"
+	}
+
+	switch {
+	case task.Name == "":
+		return errors.Wrap(errInvalidTask, "Name cannot be empty")
+	case task.Command == "":
+		return errors.Wrap(errInvalidTask, "Command cannot be empty")
+	case task.ServerSelector == "":
+		return errors.Wrap(errInvalidTask, "Server selector cannot be empty")
+	case task.TaskType < store.TaskRCON || task.TaskType > store.TaskCSay:
+		return errors.Wrap(errInvalidTask, "Unknown task type")
+	case task.PlayersBelow != nil && *task.PlayersBelow < 1:
+		return errors.Wrap(errInvalidTask, "Players below must be positive")
+	case task.PlayersAtLeast != nil && *task.PlayersAtLeast < 0:
+		return errors.Wrap(errInvalidTask, "Players at least cannot be negative")
+	}
+
+	if task.TaskType != store.TaskRCON {
+		if _, errTmpl := parseTaskTemplate(*task); errTmpl != nil {
+			return errTmpl
+		}
+	}
+
+	nextRun, errNext := nextTaskRun(*task, time.Now())
+	if errNext != nil {
+		return errNext
+	}
+
+	task.NextRun = nextRun
+
+	return nil
+}
+
+// taskConditionsMet checks the optional player count conditions against the current server state.
+func taskConditionsMet(task store.ScheduledTask, server serverDetails) bool {
+	if task.PlayersBelow != nil && server.PlayerCount >= *task.PlayersBelow {
+		return false
+	}
+
+	if task.PlayersAtLeast != nil && server.PlayerCount < *task.PlayersAtLeast {
+		return false
+	}
+
+	return true
+}
+
+// taskCommand returns the rcon command to execute on the server.
+func taskCommand(task store.ScheduledTask, tmpl *template.Template, server serverDetails, now time.Time) (string, error) {
+	if task.TaskType == store.TaskRCON {
+		return task.Command, nil
+	}
+
+	var buf bytes.Buffer
+	if errExec := tmpl.Execute(&buf, scheduledTaskTemplateData{
+		ServerName: server.NameShort,
+		Hostname:   server.Name,
+		Map:        server.Map,
+		Players:    server.PlayerCount,
+		MaxPlayers: server.MaxPlayers,
+		Time:       now,
+	}); errExec != nil {
+		return "", errors.Wrap(errExec, "Failed to render message")
+	}
+
+	if task.TaskType == store.TaskCSay {
+		return "sm_csay " + buf.String(), nil
+	}
+
+	return "sm_say " + buf.String(), nil
+}
+
+// executeScheduledTask runs the task against all the matching servers, returning the aggregated results.
+func (app *App) executeScheduledTask(task store.ScheduledTask) (run store.ScheduledTaskRun) {
+	run = store.ScheduledTaskRun{
+		ScheduledTaskID: task.ScheduledTaskID,
+		StartedOn:       time.Now(),
+		Results:         []store.ScheduledTaskServerResult{},
+	}
+
+	defer func() {
+		run.FinishedOn = time.Now()
+	}()
+
+	var tmpl *template.Template
+
+	if task.TaskType != store.TaskRCON {
+		parsed, errTmpl := parseTaskTemplate(task)
+		if errTmpl != nil {
+			run.Error = errTmpl.Error()
+
+			return run
+		}
+
+		tmpl = parsed
+	}
+
+	now := run.StartedOn
+	if loc, errLoc := taskLocation(task); errLoc == nil {
+		now = now.In(loc)
+	}
+
+	state := app.state.current()
+	servers := state.bySelector(task.ServerSelector)
+
+	if len(servers) == 0 {
+		run.Error = errUnknownServer.Error()
+
+		return run
+	}
+
+	var (
+		results   = make([]store.ScheduledTaskServerResult, len(servers))
+		waitGroup sync.WaitGroup
+	)
+
+	for idx, server := range servers {
+		results[idx] = store.ScheduledTaskServerResult{ServerID: server.ServerID, ServerName: server.NameShort}
+
+		if !taskConditionsMet(task, server) {
+			results[idx].Skipped = true
+
+			continue
+		}
+
+		waitGroup.Add(1)
+
+		go func(result *store.ScheduledTaskServerResult, server serverDetails) {
+			defer waitGroup.Done()
+
+			command, errCommand := taskCommand(task, tmpl, server, now)
+			if errCommand != nil {
+				result.Error = errCommand.Error()
+
+				return
+			}
+
+			resp, errRcon := app.state.rcon(server.ServerID, command)
+			if errRcon != nil {
+				result.Error = errRcon.Error()
+
+				return
+			}
+
+			if len(resp) > scheduledTaskMaxRespLen {
+				resp = resp[:scheduledTaskMaxRespLen]
+			}
+
+			result.Response = resp
+		}(&results[idx], server)
+	}
+
+	waitGroup.Wait()
+
+	run.Results = results
+	run.Success = true
+
+	for _, result := range results {
+		if result.Error != "" {
+			run.Success = false
+		}
+	}
+
+	return run
+}
+
+// runScheduledTask executes the task, records the results and sends an alert if it failed.
+func (app *App) runScheduledTask(ctx context.Context, task store.ScheduledTask) store.ScheduledTaskRun {
+	run := app.executeScheduledTask(task)
+
+	if errSave := app.db.SaveScheduledTaskRun(ctx, &run); errSave != nil {
+		app.log.Error("Failed to save task run", zap.Int64("task_id", task.ScheduledTaskID), zap.Error(errSave))
+	}
+
+	if !run.Success && task.AlertOnFailure {
+		app.sendScheduledTaskAlert(task, run)
+	}
+
+	return run
+}
+
+func (app *App) sendScheduledTaskAlert(task store.ScheduledTask, run store.ScheduledTaskRun) {
+	msgEmbed := discord.
+		NewEmbed(fmt.Sprintf("Scheduled task failed: %s", task.Name)).
+		SetColor(app.bot.Colour.Error).
+		SetDescription(task.Command).
+		AddField("Type", task.TaskType.String()).
+		AddField("Servers", task.ServerSelector).
+		InlineAllFields()
+
+	if run.Error != "" {
+		msgEmbed.AddField("Error", run.Error)
+	}
+
+	for _, result := range run.Results {
+		if result.Error != "" {
+			msgEmbed.AddField(result.ServerName, result.Error)
+		}
+	}
+
+	app.bot.SendPayload(discord.Payload{ChannelID: app.conf.Discord.LogChannelID, Embed: msgEmbed.Truncate().MessageEmbed})
+}
+
+// scheduledTaskWorker runs the enabled tasks when they are due.
+func (app *App) scheduledTaskWorker(ctx context.Context) {
+	var (
+		log         = app.log.Named("scheduledTasks")
+		ticker      = time.NewTicker(scheduledTaskCheckInterval)
+		pruneTicker = time.NewTicker(time.Hour * 24)
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-pruneTicker.C:
+			if errPrune := app.db.PruneScheduledTaskRuns(ctx, time.Now().Add(-scheduledTaskRetention)); errPrune != nil {
+				log.Error("Failed to prune task history", zap.Error(errPrune))
+			}
+		case now := <-ticker.C:
+			tasks, errTasks := app.db.GetScheduledTasks(ctx, true)
+			if errTasks != nil {
+				log.Error("Failed to load scheduled tasks", zap.Error(errTasks))
+
+				continue
+			}
+
+			for _, task := range tasks {
+				if task.NextRun != nil && task.NextRun.After(now) {
+					continue
+				}
+
+				due := task.NextRun
+
+				nextRun, errNext := nextTaskRun(task, now)
+				if errNext != nil {
+					log.Error("Failed to schedule task, disabling", zap.Int64("task_id", task.ScheduledTaskID),
+						zap.Error(errNext))
+
+					task.IsEnabled = false
+				}
+
+				task.NextRun = nextRun
+
+				// Skip runs that were missed rather than running them late, or when a task was enabled
+				// without a next run time.
+				shouldRun := task.IsEnabled && due != nil && now.Sub(*due) <= scheduledTaskMaxDelay
+				if shouldRun {
+					task.LastRun = &now
+				}
+
+				// Update the schedule before executing so slow tasks are not started again on the next tick.
+				if errSave := app.db.SaveScheduledTask(ctx, &task); errSave != nil {
+					log.Error("Failed to update task schedule", zap.Int64("task_id", task.ScheduledTaskID),
+						zap.Error(errSave))
+
+					continue
+				}
+
+				if !shouldRun {
+					continue
+				}
+
+				go app.runScheduledTask(ctx, task)
+			}
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package app // nolint:testpackage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateScheduledTask(t *testing.T) {
+	below := 10
+	task := store.ScheduledTask{
+		Name:           " restart ",
+		CronExpression: "0 6 * * *",
+		TaskType:       store.TaskSay,
+		Command:        "Restarting {{ .ServerName }}",
+		ServerSelector: "tag:pub",
+		PlayersBelow:   &below,
+	}
+
+	require.NoError(t, validateScheduledTask(&task))
+	require.Equal(t, "restart", task.Name)
+	require.Equal(t, "UTC", task.Timezone)
+	require.NotNil(t, task.NextRun)
+	require.True(t, task.NextRun.After(time.Now()))
+
+	invalid := []func(task *store.ScheduledTask){
+		func(task *store.ScheduledTask) { task.Name = "" },
+		func(task *store.ScheduledTask) { task.CronExpression = "* * *" },
+		func(task *store.ScheduledTask) { task.CronExpression = "0 0 31 2 *" },
+		func(task *store.ScheduledTask) { task.Timezone = "Nowhere/Invalid" },
+		func(task *store.ScheduledTask) { task.TaskType = 10 },
+		func(task *store.ScheduledTask) { task.Command = "{{ .Players " },
+		func(task *store.ScheduledTask) { task.ServerSelector = " " },
+		func(task *store.ScheduledTask) { zero := 0; task.PlayersBelow = &zero },
+	}
+
+	for idx, mutate := range invalid {
+		invalidTask := task
+		mutate(&invalidTask)
+		require.ErrorIs(t, validateScheduledTask(&invalidTask), errInvalidTask, idx)
+	}
+}
+
+func TestTaskConditionsMet(t *testing.T) {
+	below := 5
+	atLeast := 2
+	task := store.ScheduledTask{PlayersBelow: &below, PlayersAtLeast: &atLeast}
+
+	require.True(t, taskConditionsMet(store.ScheduledTask{}, serverDetails{PlayerCount: 24}))
+	require.True(t, taskConditionsMet(task, serverDetails{PlayerCount: 2}))
+	require.True(t, taskConditionsMet(task, serverDetails{PlayerCount: 4}))
+	require.False(t, taskConditionsMet(task, serverDetails{PlayerCount: 5}))
+	require.False(t, taskConditionsMet(task, serverDetails{PlayerCount: 1}))
+}
+
+func TestTaskCommand(t *testing.T) {
+	server := serverDetails{NameShort: "us-1", Map: "pl_upward", PlayerCount: 12, MaxPlayers: 24}
+	now := time.Date(2023, time.March, 15, 10, 30, 0, 0, time.UTC)
+
+	rconTask := store.ScheduledTask{TaskType: store.TaskRCON, Command: "changelevel {{ .Map }}"}
+	command, errCommand := taskCommand(rconTask, nil, server, now)
+	require.NoError(t, errCommand)
+	require.Equal(t, "changelevel {{ .Map }}", command)
+
+	sayTask := store.ScheduledTask{
+		TaskType: store.TaskCSay,
+		Command:  "{{ .ServerName }} {{ .Map }} {{ .Players }}/{{ .MaxPlayers }} {{ .Time.Format \"15:04\" }}",
+	}
+	tmpl, errTmpl := parseTaskTemplate(sayTask)
+	require.NoError(t, errTmpl)
+
+	command, errCommand = taskCommand(sayTask, tmpl, server, now)
+	require.NoError(t, errCommand)
+	require.Equal(t, "sm_csay us-1 pl_upward 12/24 10:30", command)
+
+	badTask := store.ScheduledTask{TaskType: store.TaskSay, Command: "{{ .Unknown }}"}
+	tmpl, errTmpl = parseTaskTemplate(badTask)
+	require.NoError(t, errTmpl)
+
+	_, errCommand = taskCommand(badTask, tmpl, server, now)
+	require.Error(t, errCommand)
+}
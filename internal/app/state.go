@@ -13,6 +13,8 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/leighmacdonald/gbans/pkg/a2s"
+	"github.com/leighmacdonald/gbans/pkg/fp"
 	"github.com/leighmacdonald/gbans/pkg/ip2location"
 	"github.com/leighmacdonald/rcon/rcon"
 	"github.com/leighmacdonald/steamid/v3/extra"
@@ -44,7 +46,15 @@ type serverDetails struct {
 	Protocol      uint8     `json:"protocol"`
 	RconPassword  string    `json:"rcon_password"`
 
+	// LastRCON is when the server last answered a rcon status query.
+	LastRCON time.Time `json:"last_rcon"`
+	// LastPluginUpdate is when the game server plugin last pushed a state update. The plugin reports
+	// independently of rcon, so it does not indicate that the server is reachable.
+	LastPluginUpdate time.Time `json:"last_plugin_update"`
+
 	Map string `json:"map"`
+	// When the map was last changed, used to detect servers stuck on a map.
+	MapChangedOn time.Time `json:"map_changed_on"`
 	// Name of the folder containing the game files.
 	Folder string `json:"folder"`
 	// Full name of the game.
@@ -78,6 +88,24 @@ type serverDetails struct {
 
 	Tags    []string       `json:"tags"`
 	Players []extra.Player `json:"players"`
+	// PlayersStale is true when Players is the last list received over rcon. Player details are not
+	// available over a2s, so they are kept as is while the state is updated using a2s.
+	PlayersStale bool `json:"players_stale"`
+	// StateSource is how the state was last updated, either rcon or a2s when rcon is unavailable.
+	StateSource string `json:"state_source"`
+	// ServerTags are the admin defined tags used for targeting groups of servers. These are
+	// unrelated to the sv_tags reported by the game in Tags.
+	ServerTags []string `json:"server_tags"`
+}
+
+func (s serverDetails) hasServerTag(tag string) bool {
+	for _, serverTag := range s.ServerTags {
+		if strings.EqualFold(serverTag, tag) {
+			return true
+		}
+	}
+
+	return false
 }
 
 type baseServer struct {
@@ -98,7 +126,46 @@ type baseServer struct {
 	Distance   float64  `json:"distance"`
 }
 
-var errUnknownServer = errors.New("Unknown server")
+var (
+	errUnknownServer    = errors.New("Unknown server")
+	errInvalidServerTag = errors.New("Invalid server tag")
+	errRCONUnavailable  = errors.New("RCON unavailable")
+)
+
+const (
+	stateSourceRCON = "rcon"
+	stateSourceA2S  = "a2s"
+)
+
+// serverTagSelectorPrefix is used to select servers by tag instead of by name, eg: tag:eu.
+const serverTagSelectorPrefix = "tag:"
+
+var serverTagRx = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// normalizeServerTags lowercases, deduplicates and sorts the tags, returning an error if any of the
+// tags contain characters that would conflict with the selector syntax.
+func normalizeServerTags(tags []string) ([]string, error) {
+	normalized := []string{}
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+
+		if !serverTagRx.MatchString(tag) {
+			return nil, errors.Wrapf(errInvalidServerTag, "%s", tag)
+		}
+
+		if !fp.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+
+	sort.Strings(normalized)
+
+	return normalized, nil
+}
 
 type serverDetailsCollection []serverDetails
 
@@ -208,6 +275,57 @@ func (c *serverDetailsCollection) byName(name string, wildcardOk bool) serverDet
 	return servers
 }
 
+// bySelector returns the servers matching any of the comma separated selectors. A selector is either a
+// short server name as accepted by byName or a tag prefixed with serverTagSelectorPrefix, eg: "us-1,tag:eu".
+func (c *serverDetailsCollection) bySelector(selector string) serverDetailsCollection {
+	var (
+		servers serverDetailsCollection
+		seen    = map[int]bool{}
+	)
+
+	for _, term := range strings.Split(selector, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+
+		var matched serverDetailsCollection
+
+		if strings.HasPrefix(strings.ToLower(term), serverTagSelectorPrefix) {
+			tag := term[len(serverTagSelectorPrefix):]
+
+			for _, server := range *c {
+				if server.hasServerTag(tag) {
+					matched = append(matched, server)
+				}
+			}
+		} else {
+			matched = c.byName(term, true)
+		}
+
+		for _, server := range matched {
+			if seen[server.ServerID] {
+				continue
+			}
+
+			seen[server.ServerID] = true
+
+			servers = append(servers, server)
+		}
+	}
+
+	return servers
+}
+
+func (c *serverDetailsCollection) serverIDsBySelector(selector string) []int {
+	var servers []int //nolint:prealloc
+	for _, server := range c.bySelector(selector) {
+		servers = append(servers, server.ServerID)
+	}
+
+	return servers
+}
+
 func (c *serverDetailsCollection) serverIDsByName(name string, wildcardOk bool) []int {
 	var servers []int //nolint:prealloc
 	for _, server := range c.byName(name, wildcardOk) {
@@ -310,30 +428,58 @@ func (c *serverStateCollector) rcon(serverID int, cmd string) (string, error) {
 	return resp, nil
 }
 
-func (c *serverStateCollector) broadcast(serverIDs []int, cmd string) map[int]string {
-	results := map[int]string{}
-	waitGroup := sync.WaitGroup{}
+// rconResult is the outcome of a command sent to a single server.
+type rconResult struct {
+	ServerID  int
+	NameShort string
+	Response  string
+	Err       error
+}
+
+// broadcast sends the command to all the servers concurrently. The results are ordered by server id.
+func (c *serverStateCollector) broadcast(serverIDs []int, cmd string) []rconResult {
+	var (
+		results   = make([]rconResult, len(serverIDs))
+		waitGroup = sync.WaitGroup{}
+	)
 
-	for _, serverID := range serverIDs {
+	c.stateMu.RLock()
+	for idx, serverID := range serverIDs {
+		results[idx] = rconResult{ServerID: serverID, NameShort: c.serverState[serverID].NameShort}
+	}
+	c.stateMu.RUnlock()
+
+	for idx := range results {
 		waitGroup.Add(1)
 
-		go func(sid int) {
+		go func(result *rconResult) {
 			defer waitGroup.Done()
 
-			resp, errExec := c.rcon(sid, cmd)
-			if errExec != nil {
-				return
-			}
-
-			results[sid] = resp
-		}(serverID)
+			result.Response, result.Err = c.rcon(result.ServerID, cmd)
+		}(&results[idx])
 	}
 
 	waitGroup.Wait()
 
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ServerID < results[j].ServerID
+	})
+
 	return results
 }
 
+// broadcastSelector sends the command to all servers matching the selector, see serverDetailsCollection.bySelector.
+func (c *serverStateCollector) broadcastSelector(selector string, cmd string) ([]rconResult, error) {
+	state := c.current()
+
+	serverIDs := state.serverIDsBySelector(selector)
+	if len(serverIDs) == 0 {
+		return nil, errUnknownServer
+	}
+
+	return c.broadcast(serverIDs, cmd), nil
+}
+
 func (c *serverStateCollector) current() serverDetailsCollection {
 	c.stateMu.RLock()
 	defer c.stateMu.RUnlock()
@@ -398,9 +544,14 @@ func (c *serverStateCollector) onStatusUpdate(conf serverConfig, newState extra.
 
 	if newState.Map != "" && newState.Map != server.Map {
 		server.Map = newState.Map
+		server.MapChangedOn = time.Now()
 	}
 
 	server.Players = newState.Players
+	server.PlayersStale = false
+	server.StateSource = stateSourceRCON
+	server.LastUpdate = time.Now()
+	server.LastRCON = server.LastUpdate
 
 	c.serverState[conf.ServerID] = server
 }
@@ -432,7 +583,10 @@ func (c *serverStateCollector) setServerConfigs(configs []serverConfig) {
 	}
 
 	for _, config := range configs {
-		if _, found := c.serverState[config.ServerID]; !found {
+		if existing, found := c.serverState[config.ServerID]; found {
+			existing.ServerTags = config.ServerTags
+			c.serverState[config.ServerID] = existing
+		} else {
 			c.serverState[config.ServerID] = serverDetails{
 				ServerID:      config.ServerID,
 				Name:          config.DefaultHostname,
@@ -445,6 +599,7 @@ func (c *serverStateCollector) setServerConfigs(configs []serverConfig) {
 				Region:        config.Region,
 				Latitude:      config.Latitude,
 				Longitude:     config.Longitude,
+				ServerTags:    config.ServerTags,
 			}
 		}
 	}
@@ -474,10 +629,15 @@ func (c *serverStateCollector) updateState(serverID int, update partialStateUpda
 		curState.Name = update.Hostname
 	}
 
+	if update.CurrentMap != curState.Map {
+		curState.MapChangedOn = time.Now()
+	}
+
 	curState.Map = update.CurrentMap
 	curState.PlayerCount = update.PlayersReal
 	curState.MaxPlayers = update.PlayersVisible
 	curState.Bots = update.PlayersTotal - update.PlayersReal
+	curState.LastPluginUpdate = time.Now()
 	c.serverState[serverID] = curState
 
 	return nil
@@ -542,6 +702,134 @@ func (c *serverStateCollector) maxVisiblePlayers(controller *rconController) (in
 	return int(maxPlayers), nil
 }
 
+// statusRCON updates the server state using rcon. existing is true when an existing connection was used.
+func (c *serverStateCollector) statusRCON(ctx context.Context, conf serverConfig, log *zap.Logger) (bool, error) {
+	c.connectionsMu.Lock()
+
+	controller, found := c.connections[conf.ServerID]
+	if !found {
+		controller = &rconController{RWMutex: &sync.RWMutex{}, RemoteConsole: nil, attempts: 0, lastConnectAttempt: time.Now()}
+		c.connections[conf.ServerID] = controller
+	}
+
+	c.connectionsMu.Unlock()
+
+	connected := controller.connected()
+	existing := connected
+
+	if !connected && !controller.allowedToConnect() {
+		return false, errRCONUnavailable
+	}
+
+	if !connected {
+		dialCtx, cancel := context.WithTimeout(ctx, time.Second*5)
+		newConsole, errDial := rcon.Dial(dialCtx, conf.addr(), conf.RconPassword, c.updateTimeout)
+
+		if errDial != nil {
+			log.Debug("Failed to dial rcon", zap.String("err", errDial.Error()))
+		}
+
+		cancel()
+
+		controller.Lock()
+		controller.lastConnectAttempt = time.Now()
+
+		if newConsole != nil {
+			controller.lastConnectSuccess = controller.lastConnectAttempt
+			controller.attempts = 0
+			controller.RemoteConsole = newConsole
+			connected = true
+		} else {
+			controller.attempts++
+		}
+		controller.Unlock()
+	}
+
+	if !connected {
+		return false, errRCONUnavailable
+	}
+
+	status, errStatus := c.status(controller)
+	if errStatus != nil {
+		return existing, errStatus
+	}
+
+	maxVisible, errMaxVisible := c.maxVisiblePlayers(controller)
+	if errMaxVisible != nil {
+		log.Warn("Got invalid max players value", zap.Error(errMaxVisible))
+	}
+
+	c.onStatusUpdate(conf, status, maxVisible)
+
+	c.connectionsMu.Lock()
+	c.connections[conf.ServerID] = controller
+	c.connectionsMu.Unlock()
+
+	return existing, nil
+}
+
+// statusA2S updates the server state using an A2S_INFO query. This is used for servers without rcon configured, and
+// as a fallback when rcon is unavailable.
+func (c *serverStateCollector) statusA2S(ctx context.Context, conf serverConfig) error {
+	info, errInfo := a2s.NewClient(conf.addr(), c.updateTimeout).Info(ctx)
+	if errInfo != nil {
+		return errors.Wrap(errInfo, "Failed to query server info")
+	}
+
+	c.onA2SUpdate(conf, info)
+
+	return nil
+}
+
+func (c *serverStateCollector) onA2SUpdate(conf serverConfig, info a2s.Info) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+
+	server := c.serverState[conf.ServerID]
+
+	if info.Name != "" {
+		server.Name = info.Name
+	}
+
+	if info.Map != "" && info.Map != server.Map {
+		server.Map = info.Map
+		server.MapChangedOn = time.Now()
+	}
+
+	server.PlayerCount = int(info.Players) - int(info.Bots)
+	if server.PlayerCount < 0 {
+		server.PlayerCount = 0
+	}
+
+	server.MaxPlayers = int(info.MaxPlayers)
+	server.Bots = int(info.Bots)
+	server.Protocol = info.Protocol
+	server.Folder = info.Folder
+	server.Game = info.Game
+	server.AppID = info.AppID
+	server.GameID = info.GameID
+	server.Password = info.Password
+	server.VAC = info.VAC
+	server.Version = info.Version
+	server.STVPort = info.STVPort
+	server.STVName = info.STVName
+
+	if info.SteamID > 0 {
+		server.SteamID = steamid.New(int64(info.SteamID))
+	}
+
+	if info.Keywords != "" {
+		server.Tags = strings.Split(info.Keywords, ",")
+	}
+
+	// Player details such as steam ids are only available over rcon
+	server.PlayersStale = true
+	server.StateSource = stateSourceA2S
+	server.LastUpdate = time.Now()
+
+	c.serverState[conf.ServerID] = server
+}
+
 func (c *serverStateCollector) startStatus(ctx context.Context) {
 	var (
 		logger             = c.log.Named("statusUpdate")
@@ -554,6 +842,7 @@ func (c *serverStateCollector) startStatus(ctx context.Context) {
 			waitGroup := &sync.WaitGroup{}
 			successful := atomic.Int32{}
 			existing := atomic.Int32{}
+			fallback := atomic.Int32{}
 
 			c.stateMu.RLock()
 			configs := c.configs
@@ -569,69 +858,27 @@ func (c *serverStateCollector) startStatus(ctx context.Context) {
 
 					log := logger.Named(conf.Tag)
 
-					c.connectionsMu.Lock()
-
-					controller, found := c.connections[conf.ServerID]
-					if !found {
-						controller = &rconController{RWMutex: &sync.RWMutex{}, RemoteConsole: nil, attempts: 0, lastConnectAttempt: time.Now()}
-						c.connections[conf.ServerID] = controller
-					}
-
-					c.connectionsMu.Unlock()
-
-					connected := controller.connected()
-
-					if !connected && !controller.allowedToConnect() {
-						return
-					}
-
-					if !connected {
-						dialCtx, cancel := context.WithTimeout(ctx, time.Second*5)
-						newConsole, errDial := rcon.Dial(dialCtx, conf.addr(), conf.RconPassword, c.updateTimeout)
-
-						if errDial != nil {
-							log.Debug("Failed to dial rcon", zap.String("err", errDial.Error()))
+					if conf.RconPassword != "" {
+						existingConn, errRCON := c.statusRCON(ctx, conf, log)
+						if existingConn {
+							existing.Add(1)
 						}
 
-						cancel()
-
-						controller.Lock()
-						controller.lastConnectAttempt = time.Now()
+						if errRCON == nil {
+							successful.Add(1)
 
-						if newConsole != nil {
-							controller.lastConnectSuccess = controller.lastConnectAttempt
-							controller.attempts = 0
-							controller.RemoteConsole = newConsole
-							connected = true
-						} else {
-							controller.attempts++
+							return
 						}
-						controller.Unlock()
-					} else {
-						existing.Add(1)
 					}
 
-					if !connected {
-						return
-					}
+					if errA2S := c.statusA2S(ctx, conf); errA2S != nil {
+						log.Debug("Failed to query a2s", zap.Error(errA2S))
 
-					status, errStatus := c.status(controller)
-					if errStatus != nil {
 						return
 					}
 
-					maxVisible, errMaxVisible := c.maxVisiblePlayers(controller)
-					if errMaxVisible != nil {
-						log.Warn("Got invalid max players value", zap.Error(errMaxVisible))
-					}
-
-					c.onStatusUpdate(conf, status, maxVisible)
-
-					c.connectionsMu.Lock()
-					c.connections[conf.ServerID] = controller
-					c.connectionsMu.Unlock()
-
 					successful.Add(1)
+					fallback.Add(1)
 				}(serverConfigInstance)
 			}
 
@@ -640,6 +887,7 @@ func (c *serverStateCollector) startStatus(ctx context.Context) {
 			logger.Debug("RCON update cycle complete",
 				zap.Int32("success", successful.Load()),
 				zap.Int32("existing", existing.Load()),
+				zap.Int32("a2s", fallback.Load()),
 				zap.Int32("fail", int32(len(configs))-successful.Load()),
 				zap.Duration("duration", time.Since(startTIme)))
 		case <-ctx.Done():
@@ -724,6 +972,7 @@ func (c *serverStateCollector) start(ctx context.Context) {
 
 func newServerConfig(serverID int, name string, defaultHostname string, address string,
 	port int, rconPassword string, reserved int, countryCode string, region string, lat float64, long float64,
+	tags []string,
 ) serverConfig {
 	return serverConfig{
 		ServerID:        serverID,
@@ -737,6 +986,7 @@ func newServerConfig(serverID int, name string, defaultHostname string, address
 		Region:          region,
 		Latitude:        lat,
 		Longitude:       long,
+		ServerTags:      tags,
 	}
 }
 
@@ -752,6 +1002,7 @@ type serverConfig struct {
 	Region          string
 	Latitude        float64
 	Longitude       float64
+	ServerTags      []string
 }
 
 func (config *serverConfig) addr() string {
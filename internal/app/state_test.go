@@ -0,0 +1,97 @@
+package app // nolint:testpackage
+
+import (
+	"testing"
+
+	"github.com/leighmacdonald/gbans/pkg/a2s"
+	"github.com/leighmacdonald/steamid/v3/extra"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestServerSelector(t *testing.T) {
+	servers := serverDetailsCollection{
+		{ServerID: 1, NameShort: "us-1", ServerTags: []string{"pub", "us"}},
+		{ServerID: 2, NameShort: "us-2", ServerTags: []string{"comp", "us"}},
+		{ServerID: 3, NameShort: "eu-1", ServerTags: []string{"pub", "eu"}},
+	}
+
+	require.Equal(t, []int{1, 2, 3}, servers.serverIDsBySelector("*"))
+	require.Equal(t, []int{2}, servers.serverIDsBySelector("us-2"))
+	require.Equal(t, []int{1, 3}, servers.serverIDsBySelector("tag:pub"))
+	require.Equal(t, []int{1, 2}, servers.serverIDsBySelector("TAG:US"))
+	require.Equal(t, []int{3, 1, 2}, servers.serverIDsBySelector("eu-1, tag:us,us-1"))
+	require.Empty(t, servers.serverIDsBySelector("tag:event"))
+	require.Empty(t, servers.serverIDsBySelector(""))
+}
+
+func TestNormalizeServerTags(t *testing.T) {
+	tags, errTags := normalizeServerTags([]string{" EU", "pub", "eu", ""})
+	require.NoError(t, errTags)
+	require.Equal(t, []string{"eu", "pub"}, tags)
+
+	_, errInvalid := normalizeServerTags([]string{"tag:eu"})
+	require.ErrorIs(t, errInvalid, errInvalidServerTag)
+
+	_, errInvalid = normalizeServerTags([]string{"eu,us"})
+	require.ErrorIs(t, errInvalid, errInvalidServerTag)
+}
+
+func TestA2SUpdate(t *testing.T) {
+	collector := newServerStateCollector(zap.NewNop())
+	conf := serverConfig{ServerID: 1, Tag: "us-1"}
+	collector.setServerConfigs([]serverConfig{conf})
+	collector.onStatusUpdate(conf, extra.Status{
+		Map:     "pl_badwater",
+		Players: []extra.Player{{Name: "player", SID: steamid.New(76561198000000001)}},
+	}, 32)
+
+	collector.onA2SUpdate(conf, a2s.Info{
+		Name:       "Uncletopia | US-1",
+		Map:        "pl_upward",
+		Players:    24,
+		MaxPlayers: 32,
+		Bots:       2,
+		SteamID:    85568392920040000,
+		Keywords:   "alltalk,nocrits",
+	})
+
+	state := collector.current()
+	server, found := state.byServerID(1)
+	require.True(t, found)
+	require.Equal(t, "Uncletopia | US-1", server.Name)
+	require.Equal(t, "pl_upward", server.Map)
+	require.Equal(t, 22, server.PlayerCount)
+	require.Equal(t, 32, server.MaxPlayers)
+	require.Equal(t, 2, server.Bots)
+	require.Equal(t, []string{"alltalk", "nocrits"}, server.Tags)
+	require.Equal(t, stateSourceA2S, server.StateSource)
+	require.False(t, server.LastUpdate.IsZero())
+	require.False(t, server.MapChangedOn.IsZero())
+	// The last player list received over rcon is kept
+	require.Len(t, server.Players, 1)
+	require.True(t, server.PlayersStale)
+}
+
+func TestPluginUpdate(t *testing.T) {
+	collector := newServerStateCollector(zap.NewNop())
+	collector.setServerConfigs([]serverConfig{{ServerID: 1, Tag: "us-1"}})
+
+	require.NoError(t, collector.updateState(1, partialStateUpdate{
+		CurrentMap:     "pl_upward",
+		PlayersReal:    20,
+		PlayersTotal:   22,
+		PlayersVisible: 32,
+	}))
+	require.ErrorIs(t, collector.updateState(2, partialStateUpdate{}), errUnknownServer)
+
+	state := collector.current()
+	server, found := state.byServerID(1)
+	require.True(t, found)
+	require.Equal(t, 20, server.PlayerCount)
+	require.False(t, server.LastPluginUpdate.IsZero())
+	// The plugin reporting does not mean the server is answering queries
+	require.True(t, server.LastUpdate.IsZero())
+	require.True(t, server.LastRCON.IsZero())
+}
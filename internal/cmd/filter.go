@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/leighmacdonald/gbans/internal/app"
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func filterCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "filter",
+		Short: "Word filter management",
+		Long:  `Word filter management`,
+	}
+}
+
+func filterBacktestCmd() *cobra.Command {
+	var opts app.FilterBacktestOpts
+
+	command := &cobra.Command{
+		Use:   "backtest <pattern>",
+		Short: "Test a candidate filter against historical chat logs",
+		Long: `Test a candidate filter against historical chat logs. Reports the number of matches, affected players
+and the matches that are not already caught by the existing filters.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := context.Background()
+			rootCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			var conf app.Config
+			if errConfig := app.ReadConfig(&conf, false); errConfig != nil {
+				panic("Failed to read config")
+			}
+
+			rootLogger := app.MustCreateLogger(&conf)
+			defer func() {
+				_ = rootLogger.Sync()
+			}()
+
+			connCtx, cancelConn := context.WithTimeout(rootCtx, time.Second*5)
+			defer cancelConn()
+
+			database := store.New(rootLogger, conf.DB.DSN, false, conf.DB.LogQueries)
+			if errConnect := database.Connect(connCtx); errConnect != nil {
+				rootLogger.Fatal("Failed to connect to database", zap.Error(errConnect))
+			}
+
+			defer func() {
+				if errClose := database.Close(); errClose != nil {
+					rootLogger.Error("Failed to close database cleanly", zap.Error(errClose))
+				}
+			}()
+
+			opts.Filter.Pattern = args[0]
+
+			result, errBacktest := app.BacktestFilter(rootCtx, database, opts, func(progress app.FilterBacktestProgress) {
+				rootLogger.Info("Progress update",
+					zap.Int64("processed", progress.Processed),
+					zap.Int64("total", progress.Total),
+					zap.Int64("matches", progress.Matches))
+			})
+			if errBacktest != nil {
+				rootLogger.Fatal("Failed to backtest filter", zap.Error(errBacktest))
+			}
+
+			fmt.Printf("Messages: %d Matches: %d Players: %d New Matches: %d New Players: %d Duration: %s\n",
+				result.Processed, result.Matches, result.DistinctPlayers, result.NewMatches, result.NewPlayers,
+				result.Duration.Round(time.Millisecond))
+
+			writeSamples := func(title string, samples []app.FilterBacktestMatch) {
+				fmt.Printf("\n%s\n", title)
+
+				table := tablewriter.NewWriter(os.Stdout)
+				table.SetHeader([]string{"Created", "Steam ID", "Name", "Matched", "Existing", "Message"})
+
+				for _, sample := range samples {
+					existing := ""
+					if sample.ExistingFilterID > 0 {
+						existing = fmt.Sprintf("%d", sample.ExistingFilterID)
+					}
+
+					table.Append([]string{
+						sample.CreatedOn.Format(time.DateTime),
+						sample.SteamID.String(),
+						sample.PersonaName,
+						sample.Matched,
+						existing,
+						sample.Body,
+					})
+				}
+
+				table.Render()
+			}
+
+			writeSamples("Sample Matches", result.Samples)
+			writeSamples("Sample New Matches", result.NewSamples)
+		},
+	}
+
+	command.Flags().BoolVarP(&opts.Filter.IsRegex, "regex", "r", false, "Treat the pattern as a regular expression")
+	command.Flags().IntVarP(&opts.Days, "days", "d", 7, "Number of days of history to test against")
+	command.Flags().IntVarP(&opts.Samples, "samples", "s", 10, "Max number of sample matches to show")
+	command.Flags().BoolVar(&opts.Filter.Normalize.Confusables, "confusables", false, "Fold confusable characters")
+	command.Flags().BoolVar(&opts.Filter.Normalize.Invisible, "invisible", false, "Strip invisible characters")
+	command.Flags().BoolVar(&opts.Filter.Normalize.Leet, "leet", false, "Replace leetspeak characters")
+	command.Flags().BoolVar(&opts.Filter.Normalize.Repeats, "repeats", false, "Collapse repeated characters")
+	command.Flags().BoolVar(&opts.Filter.Normalize.Spaces, "spaces", false, "Remove spaces and separators")
+
+	return command
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/leighmacdonald/gbans/internal/app"
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func leaderboardsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "leaderboards",
+		Short: "Leaderboard management",
+		Long:  `Leaderboard management`,
+	}
+}
+
+func leaderboardsRebuildCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rebuild",
+		Short: "Rebuild all leaderboards from the stored matches",
+		Long: `Deletes the stats of every leaderboard period, then adds every stored match again. Matches completed
+while this is running will be lost from the leaderboards, so it's best run while gbans is stopped.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := context.Background()
+			rootCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			var conf app.Config
+			if errConfig := app.ReadConfig(&conf, false); errConfig != nil {
+				panic("Failed to read config")
+			}
+
+			rootLogger := app.MustCreateLogger(&conf)
+			defer func() {
+				_ = rootLogger.Sync()
+			}()
+
+			connCtx, cancelConn := context.WithTimeout(rootCtx, time.Second*5)
+			defer cancelConn()
+
+			database := store.New(rootLogger, conf.DB.DSN, false, conf.DB.LogQueries)
+			if errConnect := database.Connect(connCtx); errConnect != nil {
+				rootLogger.Fatal("Failed to connect to database", zap.Error(errConnect))
+			}
+
+			defer func() {
+				if errClose := database.Close(); errClose != nil {
+					rootLogger.Error("Failed to close database cleanly", zap.Error(errClose))
+				}
+			}()
+
+			result, errRebuild := app.RebuildLeaderboards(rootCtx, database, &conf, func(matchID uuid.UUID) {
+				rootLogger.Debug("Added match to leaderboards", zap.String("match_id", matchID.String()))
+			})
+			if errRebuild != nil {
+				rootLogger.Fatal("Failed to rebuild leaderboards", zap.Error(errRebuild))
+			}
+
+			fmt.Printf("Matches: %d\n", result.Matches)
+		},
+	}
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/leighmacdonald/gbans/internal/app"
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func logsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "logs",
+		Short: "Game log management",
+		Long:  `Game log management`,
+	}
+}
+
+func logsReplayCmd() *cobra.Command {
+	var opts app.LogReplayOpts
+
+	command := &cobra.Command{
+		Use:   "replay <log_file>...",
+		Short: "Rebuild matches from srcds log files",
+		Long: `Rebuild matches from srcds log files, plain or gzip compressed. Matches that already exist for the
+server with the same start time are skipped, so logs can safely be replayed more than once. Dry runs do not
+connect to the database, so they do not detect matches that were already saved.`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := context.Background()
+			rootCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			var conf app.Config
+			if errConfig := app.ReadConfig(&conf, false); errConfig != nil {
+				panic("Failed to read config")
+			}
+
+			rootLogger := app.MustCreateLogger(&conf)
+			defer func() {
+				_ = rootLogger.Sync()
+			}()
+
+			if opts.ServerID <= 0 {
+				rootLogger.Fatal("Server id is required")
+			}
+
+			// Dry runs only parse the logs, so they can be checked without access to the database
+			var database *store.Store
+
+			if !opts.DryRun {
+				connCtx, cancelConn := context.WithTimeout(rootCtx, time.Second*5)
+				defer cancelConn()
+
+				database = store.New(rootLogger, conf.DB.DSN, false, conf.DB.LogQueries)
+				if errConnect := database.Connect(connCtx); errConnect != nil {
+					rootLogger.Fatal("Failed to connect to database", zap.Error(errConnect))
+				}
+
+				defer func() {
+					if errClose := database.Close(); errClose != nil {
+						rootLogger.Error("Failed to close database cleanly", zap.Error(errClose))
+					}
+				}()
+			}
+
+			table := tablewriter.NewWriter(os.Stdout)
+			table.SetHeader([]string{"File", "Map", "Started", "Duration", "Players", "Red", "Blu", "Status"})
+
+			result, errReplay := app.ReplayLogs(rootCtx, database, opts, args, func(match app.LogReplayMatch) {
+				if match.Err != nil {
+					rootLogger.Error("Failed to save match", zap.String("file", match.File), zap.Error(match.Err))
+				}
+
+				var started, duration string
+				if match.Match.TimeStart != nil {
+					started = match.Match.TimeStart.Format(time.DateTime)
+
+					if match.Match.TimeEnd != nil {
+						duration = match.Match.TimeEnd.Sub(*match.Match.TimeStart).Round(time.Second).String()
+					}
+				}
+
+				table.Append([]string{
+					match.File,
+					match.Match.MapName,
+					started,
+					duration,
+					fmt.Sprintf("%d", match.Match.PlayerCount()),
+					fmt.Sprintf("%d", match.Match.TeamScores.Red),
+					fmt.Sprintf("%d", match.Match.TeamScores.Blu),
+					string(match.Status),
+				})
+			})
+
+			table.Render()
+
+			if errReplay != nil {
+				rootLogger.Fatal("Failed to replay logs", zap.Error(errReplay))
+			}
+
+			fmt.Printf("Files: %d Lines: %d Unknown: %d Failed: %d Matches: %d Saved: %d Duplicate: %d Skipped: %d\n",
+				result.Files, result.Lines, result.Unknown, result.Failed, len(result.Matches),
+				result.Count(app.LogReplaySaved)+result.Count(app.LogReplayDryRun), result.Count(app.LogReplayDuplicate),
+				result.Count(app.LogReplayIncomplete)+result.Count(app.LogReplayInsufficient))
+		},
+	}
+
+	command.Flags().IntVarP(&opts.ServerID, "server", "s", 0, "Server id the logs belong to")
+	command.Flags().BoolVarP(&opts.DryRun, "dry-run", "n", false, "Print the matches found without connecting to the database")
+
+	return command
+}
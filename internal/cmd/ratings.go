@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/leighmacdonald/gbans/internal/app"
+	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+func ratingsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "ratings",
+		Short: "Player rating management",
+		Long:  `Player rating management`,
+	}
+}
+
+func ratingsRecomputeCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "recompute",
+		Short: "Recompute all player ratings from scratch",
+		Long: `Replaces all player ratings and their history by rating every stored match again in the order they
+were played using the current rating config. The existing ratings are kept if this fails or is cancelled.
+Matches completed while this is running are rated against the previous ratings, so it's best run while
+gbans is stopped.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			ctx := context.Background()
+			rootCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			var conf app.Config
+			if errConfig := app.ReadConfig(&conf, false); errConfig != nil {
+				panic("Failed to read config")
+			}
+
+			rootLogger := app.MustCreateLogger(&conf)
+			defer func() {
+				_ = rootLogger.Sync()
+			}()
+
+			connCtx, cancelConn := context.WithTimeout(rootCtx, time.Second*5)
+			defer cancelConn()
+
+			database := store.New(rootLogger, conf.DB.DSN, false, conf.DB.LogQueries)
+			if errConnect := database.Connect(connCtx); errConnect != nil {
+				rootLogger.Fatal("Failed to connect to database", zap.Error(errConnect))
+			}
+
+			defer func() {
+				if errClose := database.Close(); errClose != nil {
+					rootLogger.Error("Failed to close database cleanly", zap.Error(errClose))
+				}
+			}()
+
+			result, errRecompute := app.RecomputeRatings(rootCtx, database, &conf, func(matchID uuid.UUID, err error) {
+				if err != nil {
+					rootLogger.Debug("Match not rated", zap.String("match_id", matchID.String()), zap.Error(err))
+				}
+			})
+			if errRecompute != nil {
+				rootLogger.Fatal("Failed to recompute ratings", zap.Error(errRecompute))
+			}
+
+			fmt.Printf("Matches: %d Rated: %d Skipped: %d\n", result.Matches, result.Rated, result.Skipped)
+		},
+	}
+}
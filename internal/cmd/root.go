@@ -3,9 +3,13 @@
 // ban asn - Ban based on ASN
 // ban cidr - Ban an IP or network with CIDR notation
 // ban steam - Ban a player via steamid or vanity name
+// filter backtest - Test a candidate word filter against historical chat logs
 // import - Imports bans from a folder in json format
+// leaderboards rebuild - Rebuild all leaderboards from the stored matches
+// logs replay - Rebuild matches from srcds log files
 // migrate - Initiate a database migration manually
 // net update - Download and import the latest ip2location databases
+// ratings recompute - Recompute all player ratings from scratch
 // seed - Pre seed the database with data, used for development mostly
 // serve - The main application service entry point
 // server create
@@ -58,6 +62,18 @@ func setupRootCmd() *cobra.Command {
 	importCommands.AddCommand(importConnectionsCmd())
 	importCommands.AddCommand(importMessagesCmd())
 
+	filterCommands := filterCmd()
+	filterCommands.AddCommand(filterBacktestCmd())
+
+	logsCommands := logsCmd()
+	logsCommands.AddCommand(logsReplayCmd())
+
+	ratingsCommands := ratingsCmd()
+	ratingsCommands.AddCommand(ratingsRecomputeCmd())
+
+	leaderboardsCommands := leaderboardsCmd()
+	leaderboardsCommands.AddCommand(leaderboardsRebuildCmd())
+
 	netCommands := netCmd()
 	netCommands.AddCommand(netUpdateCmd())
 
@@ -65,6 +81,10 @@ func setupRootCmd() *cobra.Command {
 	root.AddCommand(importCommands)
 	root.AddCommand(serveCmd())
 	root.AddCommand(refreshCommands)
+	root.AddCommand(filterCommands)
+	root.AddCommand(logsCommands)
+	root.AddCommand(ratingsCommands)
+	root.AddCommand(leaderboardsCommands)
 	// root.PersistentFlags().StringVar(&cfgFile, "config", "gbans.yml", "config file (default is $HOME/.gbans.yaml)").
 
 	return root
@@ -24,6 +24,7 @@ const (
 	CmdPSay        Cmd = "psay"
 	CmdCSay        Cmd = "csay"
 	CmdSay         Cmd = "say"
+	CmdRCON        Cmd = "rcon"
 	CmdServers     Cmd = "servers"
 	CmdSetSteam    Cmd = "set_steam"
 	CmdStats       Cmd = "stats"
@@ -33,6 +34,7 @@ const (
 	CmdHistory     Cmd = "history"
 	CmdHistoryIP   Cmd = "ip"
 	CmdHistoryChat Cmd = "chat"
+	CmdHistoryName Cmd = "names"
 	CmdFilter      Cmd = "filter"
 	CmdLog         Cmd = "log"
 	CmdLogs        Cmd = "logs"
@@ -64,12 +66,14 @@ const (
 	OptCIDR             = "cidr"
 	OptPattern          = "pattern"
 	OptIsRegex          = "is_regex"
+	OptCommand          = "command"
 )
 
 //nolint:funlen,maintidx
 func (bot *Bot) botRegisterSlashCommands(appID string) error {
 	dmPerms := false
 	modPerms := int64(discordgo.PermissionBanMembers)
+	adminPerms := int64(discordgo.PermissionAdministrator)
 	userPerms := int64(discordgo.PermissionViewChannel)
 	optUserID := &discordgo.ApplicationCommandOption{
 		Type:        discordgo.ApplicationCommandOptionString,
@@ -89,6 +93,12 @@ func (bot *Bot) botRegisterSlashCommands(appID string) error {
 		Description: "Short server name",
 		Required:    true,
 	}
+	optServerSelector := &discordgo.ApplicationCommandOption{
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        OptServerIdentifier,
+		Description: "Comma separated short server names or tags, eg: `us-1,tag:eu`, or `*` for all",
+		Required:    true,
+	}
 	// optReason := &discordgo.ApplicationCommandOption{
 	//	Type:        discordgo.ApplicationCommandOptionString,
 	//	Name:        "reason",
@@ -242,12 +252,7 @@ func (bot *Bot) botRegisterSlashCommands(appID string) error {
 			DMPermission:             &dmPerms,
 			DefaultMemberPermissions: &modPerms,
 			Options: []*discordgo.ApplicationCommandOption{
-				{
-					Type:        discordgo.ApplicationCommandOptionString,
-					Name:        OptServerIdentifier,
-					Description: "Short server name or `*` for all",
-					Required:    true,
-				},
+				optServerSelector,
 				optMessage,
 			},
 		},
@@ -257,10 +262,25 @@ func (bot *Bot) botRegisterSlashCommands(appID string) error {
 			DMPermission:             &dmPerms,
 			DefaultMemberPermissions: &modPerms,
 			Options: []*discordgo.ApplicationCommandOption{
-				optServerID,
+				optServerSelector,
 				optMessage,
 			},
 		},
+		{
+			Name:                     string(CmdRCON),
+			Description:              "Execute a RCON command on one or more servers",
+			DMPermission:             &dmPerms,
+			DefaultMemberPermissions: &adminPerms,
+			Options: []*discordgo.ApplicationCommandOption{
+				optServerSelector,
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        OptCommand,
+					Description: "Command to execute",
+					Required:    true,
+				},
+			},
+		},
 		{
 			Name:                     string(CmdServers),
 			Description:              "Show the high level status of all servers",
@@ -306,6 +326,14 @@ func (bot *Bot) botRegisterSlashCommands(appID string) error {
 						optUserID,
 					},
 				},
+				{
+					Name:        string(CmdHistoryName),
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Description: "Get the names previously used by the user",
+					Options: []*discordgo.ApplicationCommandOption{
+						optUserID,
+					},
+				},
 			},
 		},
 		{
@@ -0,0 +1,237 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/gofrs/uuid/v5"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+	"github.com/pkg/errors"
+)
+
+// AnomalyMetric is the weapon stat which was found to be statistically unlikely.
+type AnomalyMetric int
+
+const (
+	AnomalyAccuracy AnomalyMetric = iota + 1
+	AnomalyHeadshots
+	AnomalyKillDistance
+)
+
+func (metric AnomalyMetric) String() string {
+	switch metric {
+	case AnomalyAccuracy:
+		return "Accuracy"
+	case AnomalyHeadshots:
+		return "Headshot Ratio"
+	case AnomalyKillDistance:
+		return "Kill Distance"
+	default:
+		return "Unknown"
+	}
+}
+
+// AnomalyStatus is the moderation state of an anomaly.
+type AnomalyStatus int
+
+const (
+	// AnomalyStatusAny matches anomalies of every status when querying.
+	AnomalyStatusAny AnomalyStatus = iota - 1
+	AnomalyOpen
+	AnomalyDismissed
+	AnomalyReported
+)
+
+func (status AnomalyStatus) String() string {
+	switch status {
+	case AnomalyOpen:
+		return "Open"
+	case AnomalyDismissed:
+		return "Dismissed"
+	case AnomalyReported:
+		return "Reported"
+	default:
+		return "Unknown"
+	}
+}
+
+// Anomaly is a statistically extreme weapon stat of a player in a single match.
+type Anomaly struct {
+	AnomalyID int64         `json:"anomaly_id"`
+	MatchID   uuid.UUID     `json:"match_id"`
+	SteamID   steamid.SID64 `json:"steam_id"`
+	Weapon
+	Metric AnomalyMetric `json:"metric"`
+	// Value is the players value of the metric, Baseline is the population average.
+	Value    float64 `json:"value"`
+	Baseline float64 `json:"baseline"`
+	// Score is the number of standard deviations the value is above the baseline.
+	Score       float64       `json:"score"`
+	Samples     int           `json:"samples"`
+	Explanation string        `json:"explanation"`
+	Status      AnomalyStatus `json:"status"`
+	ReportID    int64         `json:"report_id"`
+	PersonaName string        `json:"persona_name"`
+	AvatarHash  string        `json:"avatar_hash"`
+	MapName     string        `json:"map_name"`
+	CreatedOn   time.Time     `json:"created_on"`
+	UpdatedOn   time.Time     `json:"updated_on"`
+}
+
+type AnomalyQueryFilter struct {
+	QueryFilter
+	Status  AnomalyStatus `json:"status"`
+	SteamID steamid.SID64 `json:"steam_id"`
+}
+
+// SaveAnomaly inserts a new anomaly or updates the status of an existing one. ErrDuplicate is returned
+// when the anomaly was already flagged for the match.
+func (db *Store) SaveAnomaly(ctx context.Context, anomaly *Anomaly) error {
+	anomaly.UpdatedOn = time.Now()
+
+	if anomaly.AnomalyID > 0 {
+		var reportID *int64
+		if anomaly.ReportID > 0 {
+			reportID = &anomaly.ReportID
+		}
+
+		return db.Exec(ctx, `UPDATE anomaly SET anomaly_status = $1, report_id = $2, updated_on = $3 WHERE anomaly_id = $4`,
+			anomaly.Status, reportID, anomaly.UpdatedOn, anomaly.AnomalyID)
+	}
+
+	const query = `
+		INSERT INTO anomaly (match_id, steam_id, weapon_id, metric, value, baseline, score, samples, explanation,
+		                     anomaly_status, created_on, updated_on)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING anomaly_id`
+
+	if errQuery := db.QueryRow(ctx, query, anomaly.MatchID, anomaly.SteamID.Int64(), anomaly.WeaponID,
+		anomaly.Metric, anomaly.Value, anomaly.Baseline, anomaly.Score, anomaly.Samples, anomaly.Explanation,
+		anomaly.Status, anomaly.CreatedOn, anomaly.UpdatedOn).Scan(&anomaly.AnomalyID); errQuery != nil {
+		return Err(errQuery)
+	}
+
+	return nil
+}
+
+func (db *Store) anomalyQuery() sq.SelectBuilder {
+	return db.sb.
+		Select("a.anomaly_id", "a.match_id", "a.steam_id", "a.weapon_id", "w.key", "w.name", "a.metric",
+			"a.value", "a.baseline", "a.score", "a.samples", "a.explanation", "a.anomaly_status", "a.report_id",
+			"p.personaname", "p.avatarhash", "m.map", "a.created_on", "a.updated_on").
+		From("anomaly a").
+		LeftJoin("weapon w ON w.weapon_id = a.weapon_id").
+		LeftJoin("person p ON p.steam_id = a.steam_id").
+		LeftJoin("match m ON m.match_id = a.match_id")
+}
+
+func scanAnomaly(row interface{ Scan(dest ...any) error }, anomaly *Anomaly) error {
+	var (
+		steamID    int64
+		reportID   *int64
+		name       *string
+		avatarHash *string
+		mapName    *string
+	)
+
+	if errScan := row.Scan(&anomaly.AnomalyID, &anomaly.MatchID, &steamID, &anomaly.WeaponID, &anomaly.Key,
+		&anomaly.Name, &anomaly.Metric, &anomaly.Value, &anomaly.Baseline, &anomaly.Score, &anomaly.Samples,
+		&anomaly.Explanation, &anomaly.Status, &reportID, &name, &avatarHash, &mapName,
+		&anomaly.CreatedOn, &anomaly.UpdatedOn); errScan != nil {
+		return Err(errScan)
+	}
+
+	anomaly.SteamID = steamid.New(steamID)
+
+	if reportID != nil {
+		anomaly.ReportID = *reportID
+	}
+
+	if name != nil {
+		anomaly.PersonaName = *name
+	}
+
+	if avatarHash != nil {
+		anomaly.AvatarHash = *avatarHash
+	}
+
+	if mapName != nil {
+		anomaly.MapName = *mapName
+	}
+
+	return nil
+}
+
+func (db *Store) GetAnomaly(ctx context.Context, anomalyID int64, anomaly *Anomaly) error {
+	query, args, errQuery := db.anomalyQuery().Where(sq.Eq{"a.anomaly_id": anomalyID}).ToSql()
+	if errQuery != nil {
+		return errors.Wrap(errQuery, "Failed to build query")
+	}
+
+	return scanAnomaly(db.QueryRow(ctx, query, args...), anomaly)
+}
+
+// Anomalies returns the anomalies matching the filter, newest first, along with the total count of matches.
+func (db *Store) Anomalies(ctx context.Context, opts AnomalyQueryFilter) ([]Anomaly, int64, error) {
+	filter := sq.And{}
+
+	if opts.Status != AnomalyStatusAny {
+		filter = append(filter, sq.Eq{"a.anomaly_status": opts.Status})
+	}
+
+	if opts.SteamID.Valid() {
+		filter = append(filter, sq.Eq{"a.steam_id": opts.SteamID.Int64()})
+	}
+
+	countQuery, countArgs, errCountQuery := db.sb.
+		Select("count(*)").
+		From("anomaly a").
+		Where(filter).
+		ToSql()
+	if errCountQuery != nil {
+		return nil, 0, errors.Wrap(errCountQuery, "Failed to build count query")
+	}
+
+	var count int64
+	if errCount := db.QueryRow(ctx, countQuery, countArgs...).Scan(&count); errCount != nil {
+		return nil, 0, Err(errCount)
+	}
+
+	builder := db.anomalyQuery().
+		Where(filter).
+		OrderBy("a.created_on DESC", "a.score DESC")
+
+	if opts.Limit > 0 {
+		builder = builder.Limit(opts.Limit)
+	}
+
+	if opts.Offset > 0 {
+		builder = builder.Offset(opts.Offset)
+	}
+
+	query, args, errQuery := builder.ToSql()
+	if errQuery != nil {
+		return nil, 0, errors.Wrap(errQuery, "Failed to build query")
+	}
+
+	rows, errRows := db.Query(ctx, query, args...)
+	if errRows != nil {
+		return nil, 0, Err(errRows)
+	}
+
+	defer rows.Close()
+
+	anomalies := []Anomaly{}
+
+	for rows.Next() {
+		var anomaly Anomaly
+		if errScan := scanAnomaly(rows, &anomaly); errScan != nil {
+			return nil, 0, errScan
+		}
+
+		anomalies = append(anomalies, anomaly)
+	}
+
+	return anomalies, count, nil
+}
@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+)
+
+type AuditAction string
+
+const (
+	AuditErasureRequested AuditAction = "erasure_requested"
+	AuditErasureApproved  AuditAction = "erasure_approved"
+	AuditErasureDenied    AuditAction = "erasure_denied"
+	AuditErasureCompleted AuditAction = "erasure_completed"
+	AuditTaskSaved        AuditAction = "task_saved"
+	AuditTaskDeleted      AuditAction = "task_deleted"
+	AuditTaskExecuted     AuditAction = "task_executed"
+	AuditConsoleCommand   AuditAction = "console_command"
+)
+
+// AuditEntry is a permanent record of a privileged action. Entries must not contain personal data
+// beyond the steam ids of those involved.
+type AuditEntry struct {
+	AuditID   int64         `json:"audit_id"`
+	SourceID  steamid.SID64 `json:"source_id"`
+	TargetID  steamid.SID64 `json:"target_id"`
+	Action    AuditAction   `json:"action"`
+	Details   string        `json:"details"`
+	CreatedOn time.Time     `json:"created_on"`
+}
+
+// NewAuditEntry creates a new entry. An empty source indicates the action was performed by the system.
+func NewAuditEntry(source steamid.SID64, target steamid.SID64, action AuditAction, details string) AuditEntry {
+	return AuditEntry{
+		SourceID:  source,
+		TargetID:  target,
+		Action:    action,
+		Details:   details,
+		CreatedOn: time.Now(),
+	}
+}
+
+func nullableSID(sid steamid.SID64) *int64 {
+	if !sid.Valid() {
+		return nil
+	}
+
+	value := sid.Int64()
+
+	return &value
+}
+
+func (db *Store) SaveAuditEntry(ctx context.Context, entry *AuditEntry) error {
+	query, args, errQueryArgs := db.sb.
+		Insert("audit_log").
+		Columns("source_id", "target_id", "action", "details", "created_on").
+		Values(nullableSID(entry.SourceID), nullableSID(entry.TargetID), entry.Action, entry.Details, entry.CreatedOn).
+		Suffix("RETURNING audit_id").
+		ToSql()
+	if errQueryArgs != nil {
+		return Err(errQueryArgs)
+	}
+
+	return Err(db.QueryRow(ctx, query, args...).Scan(&entry.AuditID))
+}
+
+type AuditQueryFilter struct {
+	QueryFilter
+	TargetID steamid.SID64 `json:"target_id,omitempty"`
+	Action   AuditAction   `json:"action,omitempty"`
+}
+
+func (db *Store) GetAuditEntries(ctx context.Context, opts AuditQueryFilter) ([]AuditEntry, error) {
+	builder := db.sb.
+		Select("audit_id", "source_id", "target_id", "action", "details", "created_on").
+		From("audit_log").
+		OrderBy("audit_id DESC")
+
+	if opts.TargetID.Valid() {
+		builder = builder.Where(sq.Eq{"target_id": opts.TargetID.Int64()})
+	}
+
+	if opts.Action != "" {
+		builder = builder.Where(sq.Eq{"action": opts.Action})
+	}
+
+	if opts.Offset > 0 {
+		builder = builder.Offset(opts.Offset)
+	}
+
+	if opts.Limit > 0 {
+		builder = builder.Limit(opts.Limit)
+	}
+
+	query, args, errQueryArgs := builder.ToSql()
+	if errQueryArgs != nil {
+		return nil, Err(errQueryArgs)
+	}
+
+	rows, errQuery := db.Query(ctx, query, args...)
+	if errQuery != nil {
+		return nil, Err(errQuery)
+	}
+
+	defer rows.Close()
+
+	var entries []AuditEntry
+
+	for rows.Next() {
+		var (
+			entry    AuditEntry
+			sourceID *int64
+			targetID *int64
+		)
+
+		if errScan := rows.Scan(&entry.AuditID, &sourceID, &targetID, &entry.Action, &entry.Details,
+			&entry.CreatedOn); errScan != nil {
+			return nil, Err(errScan)
+		}
+
+		if sourceID != nil {
+			entry.SourceID = steamid.New(*sourceID)
+		}
+
+		if targetID != nil {
+			entry.TargetID = steamid.New(*targetID)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
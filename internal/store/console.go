@@ -0,0 +1,92 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+)
+
+// ConsoleCommand is a command sent to a server through the web console.
+type ConsoleCommand struct {
+	ConsoleCommandID int64         `json:"console_command_id"`
+	ServerID         int           `json:"server_id"`
+	SteamID          steamid.SID64 `json:"steam_id"`
+	PersonaName      string        `json:"persona_name"`
+	Command          string        `json:"command"`
+	Response         string        `json:"response"`
+	// Allowed is false when the user did not have permission to execute the command.
+	Allowed   bool      `json:"allowed"`
+	Error     string    `json:"error"`
+	CreatedOn time.Time `json:"created_on"`
+}
+
+func (db *Store) SaveConsoleCommand(ctx context.Context, command *ConsoleCommand) error {
+	query, args, errQueryArgs := db.sb.
+		Insert("console_command").
+		Columns("server_id", "steam_id", "command", "response", "allowed", "error", "created_on").
+		Values(command.ServerID, command.SteamID.Int64(), command.Command, command.Response, command.Allowed,
+			command.Error, command.CreatedOn).
+		Suffix("RETURNING console_command_id").
+		ToSql()
+	if errQueryArgs != nil {
+		return Err(errQueryArgs)
+	}
+
+	return Err(db.QueryRow(ctx, query, args...).Scan(&command.ConsoleCommandID))
+}
+
+// GetConsoleCommands returns the most recent commands sent to the server, oldest first.
+func (db *Store) GetConsoleCommands(ctx context.Context, serverID int, limit uint64) ([]ConsoleCommand, error) {
+	query, args, errQueryArgs := db.sb.
+		Select("c.console_command_id", "c.server_id", "c.steam_id", "p.personaname", "c.command", "c.response",
+			"c.allowed", "c.error", "c.created_on").
+		From("console_command c").
+		LeftJoin("person p USING (steam_id)").
+		Where(sq.Eq{"c.server_id": serverID}).
+		OrderBy("c.console_command_id DESC").
+		Limit(limit).
+		ToSql()
+	if errQueryArgs != nil {
+		return nil, Err(errQueryArgs)
+	}
+
+	rows, errQuery := db.Query(ctx, query, args...)
+	if errQuery != nil {
+		return nil, Err(errQuery)
+	}
+
+	defer rows.Close()
+
+	commands := []ConsoleCommand{}
+
+	for rows.Next() {
+		var (
+			command     ConsoleCommand
+			steamID     int64
+			personaName *string
+		)
+
+		if errScan := rows.Scan(&command.ConsoleCommandID, &command.ServerID, &steamID, &personaName,
+			&command.Command, &command.Response, &command.Allowed, &command.Error,
+			&command.CreatedOn); errScan != nil {
+			return nil, Err(errScan)
+		}
+
+		command.SteamID = steamid.New(steamID)
+
+		if personaName != nil {
+			command.PersonaName = *personaName
+		}
+
+		commands = append(commands, command)
+	}
+
+	// Fetched newest first for the limit, returned in chronological order
+	for i, j := 0, len(commands)-1; i < j; i, j = i+1, j-1 {
+		commands[i], commands[j] = commands[j], commands[i]
+	}
+
+	return commands, nil
+}
@@ -0,0 +1,315 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+	"github.com/leighmacdonald/steamweb/v2"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// ErasedPersonName is the tombstone name shown in place of an erased players name.
+	ErasedPersonName = "Erased Player"
+	// ErasedContent replaces any user generated text belonging to an erased player.
+	ErasedContent = "[erased]"
+)
+
+type ErasureStatus int
+
+const (
+	ErasurePending ErasureStatus = iota
+	// ErasureApproved means the identity has been anonymized, but items we are required to hold onto
+	// are still retained until RetainUntil.
+	ErasureApproved
+	ErasureDenied
+	ErasureComplete
+)
+
+func (status ErasureStatus) String() string {
+	switch status {
+	case ErasurePending:
+		return "Pending"
+	case ErasureApproved:
+		return "Approved"
+	case ErasureDenied:
+		return "Denied"
+	case ErasureComplete:
+		return "Complete"
+	default:
+		return "Unknown"
+	}
+}
+
+// PersonErasure is a players request to have their data erased. Requests must be approved by a moderator
+// before anything is modified.
+type PersonErasure struct {
+	ErasureID   int64         `json:"erasure_id"`
+	SteamID     steamid.SID64 `json:"steam_id"`
+	Status      ErasureStatus `json:"status"`
+	Reason      string        `json:"reason"`
+	ReviewerID  steamid.SID64 `json:"reviewer_id"`
+	ReviewNote  string        `json:"review_note"`
+	RetainUntil *time.Time    `json:"retain_until"`
+	CompletedOn *time.Time    `json:"completed_on"`
+	CreatedOn   time.Time     `json:"created_on"`
+	UpdatedOn   time.Time     `json:"updated_on"`
+}
+
+func NewPersonErasure(steamID steamid.SID64, reason string) PersonErasure {
+	now := time.Now()
+
+	return PersonErasure{
+		SteamID:   steamID,
+		Status:    ErasurePending,
+		Reason:    reason,
+		CreatedOn: now,
+		UpdatedOn: now,
+	}
+}
+
+// anonymize replaces all identifying profile data with the tombstone identity.
+func (p *Person) anonymize() {
+	p.Erased = true
+	p.DiscordID = ""
+	p.IPAddr = nil
+	p.PlayerSummary = &steamweb.PlayerSummary{
+		SteamID:     p.SteamID,
+		PersonaName: ErasedPersonName,
+	}
+}
+
+func (db *Store) SaveErasure(ctx context.Context, erasure *PersonErasure) error {
+	erasure.UpdatedOn = time.Now()
+
+	reviewerID := nullableSID(erasure.ReviewerID)
+
+	if erasure.ErasureID > 0 {
+		query, args, errQueryArgs := db.sb.Update("person_erasure").
+			Set("status", erasure.Status).
+			Set("reviewer_id", reviewerID).
+			Set("review_note", erasure.ReviewNote).
+			Set("retain_until", erasure.RetainUntil).
+			Set("completed_on", erasure.CompletedOn).
+			Set("updated_on", erasure.UpdatedOn).
+			Where(sq.Eq{"erasure_id": erasure.ErasureID}).
+			ToSql()
+		if errQueryArgs != nil {
+			return Err(errQueryArgs)
+		}
+
+		return db.Exec(ctx, query, args...)
+	}
+
+	query, args, errQueryArgs := db.sb.Insert("person_erasure").
+		Columns("steam_id", "status", "reason", "reviewer_id", "review_note", "retain_until", "completed_on",
+			"created_on", "updated_on").
+		Values(erasure.SteamID.Int64(), erasure.Status, erasure.Reason, reviewerID, erasure.ReviewNote,
+			erasure.RetainUntil, erasure.CompletedOn, erasure.CreatedOn, erasure.UpdatedOn).
+		Suffix("RETURNING erasure_id").
+		ToSql()
+	if errQueryArgs != nil {
+		return Err(errQueryArgs)
+	}
+
+	return Err(db.QueryRow(ctx, query, args...).Scan(&erasure.ErasureID))
+}
+
+type ErasureQueryFilter struct {
+	SteamID steamid.SID64   `json:"steam_id,omitempty"`
+	Status  []ErasureStatus `json:"status,omitempty"`
+	// RetainedBefore only returns approved erasures with a retention period ending before this time.
+	RetainedBefore *time.Time `json:"-"`
+}
+
+func (db *Store) GetErasures(ctx context.Context, opts ErasureQueryFilter) ([]PersonErasure, error) {
+	builder := db.sb.
+		Select("erasure_id", "steam_id", "status", "reason", "reviewer_id", "review_note", "retain_until",
+			"completed_on", "created_on", "updated_on").
+		From("person_erasure").
+		OrderBy("erasure_id DESC")
+
+	if opts.SteamID.Valid() {
+		builder = builder.Where(sq.Eq{"steam_id": opts.SteamID.Int64()})
+	}
+
+	if len(opts.Status) > 0 {
+		builder = builder.Where(sq.Eq{"status": opts.Status})
+	}
+
+	if opts.RetainedBefore != nil {
+		builder = builder.Where(sq.And{
+			sq.Eq{"status": ErasureApproved},
+			sq.LtOrEq{"retain_until": *opts.RetainedBefore},
+		})
+	}
+
+	query, args, errQueryArgs := builder.ToSql()
+	if errQueryArgs != nil {
+		return nil, Err(errQueryArgs)
+	}
+
+	rows, errQuery := db.Query(ctx, query, args...)
+	if errQuery != nil {
+		return nil, Err(errQuery)
+	}
+
+	defer rows.Close()
+
+	var erasures []PersonErasure
+
+	for rows.Next() {
+		erasure, errScan := scanErasure(rows)
+		if errScan != nil {
+			return nil, errScan
+		}
+
+		erasures = append(erasures, erasure)
+	}
+
+	return erasures, nil
+}
+
+func (db *Store) GetErasure(ctx context.Context, erasureID int64, erasure *PersonErasure) error {
+	query, args, errQueryArgs := db.sb.
+		Select("erasure_id", "steam_id", "status", "reason", "reviewer_id", "review_note", "retain_until",
+			"completed_on", "created_on", "updated_on").
+		From("person_erasure").
+		Where(sq.Eq{"erasure_id": erasureID}).
+		ToSql()
+	if errQueryArgs != nil {
+		return Err(errQueryArgs)
+	}
+
+	result, errScan := scanErasure(db.QueryRow(ctx, query, args...))
+	if errScan != nil {
+		return errScan
+	}
+
+	*erasure = result
+
+	return nil
+}
+
+func scanErasure(row pgx.Row) (PersonErasure, error) {
+	var (
+		erasure    PersonErasure
+		steamID    int64
+		reviewerID *int64
+	)
+
+	if errScan := row.Scan(&erasure.ErasureID, &steamID, &erasure.Status, &erasure.Reason, &reviewerID,
+		&erasure.ReviewNote, &erasure.RetainUntil, &erasure.CompletedOn, &erasure.CreatedOn,
+		&erasure.UpdatedOn); errScan != nil {
+		return erasure, Err(errScan)
+	}
+
+	erasure.SteamID = steamid.New(steamID)
+
+	if reviewerID != nil {
+		erasure.ReviewerID = steamid.New(*reviewerID)
+	}
+
+	return erasure, nil
+}
+
+// execTx runs each query in a single transaction, rolling back if any fail.
+func (db *Store) execTx(ctx context.Context, queries []sq.Sqlizer) error {
+	transaction, errTx := db.conn.Begin(ctx)
+	if errTx != nil {
+		return errors.Wrap(errTx, "Failed to create tx")
+	}
+
+	for _, builder := range queries {
+		query, args, errQueryArgs := builder.ToSql()
+		if errQueryArgs == nil {
+			_, errQueryArgs = transaction.Exec(ctx, query, args...)
+		}
+
+		if errQueryArgs != nil {
+			if errRollback := transaction.Rollback(ctx); errRollback != nil {
+				db.log.Error("Failed to rollback tx", zap.Error(errRollback))
+			}
+
+			return Err(errQueryArgs)
+		}
+	}
+
+	return errors.Wrap(transaction.Commit(ctx), "Failed to commit tx")
+}
+
+// AnonymizePerson replaces the players identity with a tombstone and erases their chat, name and ip history.
+//
+// Ban records, reports and aggregate stats are kept, attached to the tombstone identity. Chat messages that
+// were flagged by a filter or attached to a report are considered evidence and are retained until
+// PurgeRetainedPersonData is called.
+func (db *Store) AnonymizePerson(ctx context.Context, steamID steamid.SID64) error {
+	sid := steamID.Int64()
+	notEvidence := sq.Expr(`NOT EXISTS (SELECT 1 FROM person_messages_filter f WHERE f.person_message_id = person_messages.person_message_id)
+		AND NOT EXISTS (SELECT 1 FROM report r WHERE r.person_message_id = person_messages.person_message_id)`)
+
+	return db.execTx(ctx, []sq.Sqlizer{
+		db.sb.Update("person").
+			Set("erased", true).
+			Set("personaname", ErasedPersonName).
+			Set("profileurl", "").
+			Set("avatar", "").
+			Set("avatarmedium", "").
+			Set("avatarfull", "").
+			Set("avatarhash", "").
+			Set("realname", "").
+			Set("loccountrycode", "").
+			Set("locstatecode", "").
+			Set("loccityid", 0).
+			Set("discord_id", "").
+			Set("updated_on", time.Now()).
+			Where(sq.Eq{"steam_id": sid}),
+		db.sb.Update("person_connections").
+			Set("ip_addr", "0.0.0.0").
+			Set("persona_name", ErasedPersonName).
+			Where(sq.Eq{"steam_id": sid}),
+		db.sb.Update("person_messages").
+			Set("persona_name", ErasedPersonName).
+			Where(sq.Eq{"steam_id": sid}),
+		db.sb.Update("person_messages").
+			Set("body", ErasedContent).
+			Where(sq.And{sq.Eq{"steam_id": sid}, notEvidence}),
+		db.sb.Delete("person_names").Where(sq.Eq{"steam_id": sid}),
+		db.sb.Delete("person_auth").Where(sq.Eq{"steam_id": sid}),
+		db.sb.Delete("person_notification").Where(sq.Eq{"steam_id": sid}),
+		db.sb.Delete("person_data_export").Where(sq.Eq{"steam_id": sid}),
+	})
+}
+
+// PurgeRetainedPersonData erases the remaining user generated content once the retention period for an
+// erased player has ended, including any moderator notes. The ban records themselves are kept so that they
+// are still enforced.
+func (db *Store) PurgeRetainedPersonData(ctx context.Context, steamID steamid.SID64) error {
+	sid := steamID.Int64()
+
+	return db.execTx(ctx, []sq.Sqlizer{
+		db.sb.Update("person_messages").
+			Set("body", ErasedContent).
+			Where(sq.Eq{"steam_id": sid}),
+		db.sb.Update("report").
+			Set("description", ErasedContent).
+			Where(sq.Eq{"author_id": sid}),
+		db.sb.Update("report_message").
+			Set("message_md", ErasedContent).
+			Where(sq.Eq{"author_id": sid}),
+		db.sb.Update("ban_appeal").
+			Set("message_md", ErasedContent).
+			Where(sq.Eq{"author_id": sid}),
+		db.sb.Update("ban").
+			Set("note", "").
+			Where(sq.Eq{"target_id": sid}),
+		db.sb.Update("person_warning").
+			Set("matched", ErasedContent).
+			Where(sq.Eq{"steam_id": sid}),
+		db.sb.Delete("person_note").Where(sq.Eq{"steam_id": sid}),
+	})
+}
@@ -0,0 +1,177 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+	"github.com/pkg/errors"
+)
+
+type DataExportStatus int
+
+const (
+	DataExportPending DataExportStatus = iota
+	DataExportRunning
+	DataExportComplete
+	DataExportFailed
+)
+
+// DataExport tracks a players request for an archive of all the data we hold on them.
+type DataExport struct {
+	DataExportID int64            `json:"data_export_id"`
+	SteamID      steamid.SID64    `json:"steam_id"`
+	Status       DataExportStatus `json:"status"`
+	Error        string           `json:"error"`
+	Size         int64            `json:"size"`
+	Contents     []byte           `json:"-"`
+	CreatedOn    time.Time        `json:"created_on"`
+	UpdatedOn    time.Time        `json:"updated_on"`
+}
+
+func NewDataExport(steamID steamid.SID64) DataExport {
+	now := time.Now()
+
+	return DataExport{
+		SteamID:   steamID,
+		Status:    DataExportPending,
+		CreatedOn: now,
+		UpdatedOn: now,
+	}
+}
+
+func (db *Store) SaveDataExport(ctx context.Context, export *DataExport) error {
+	export.UpdatedOn = time.Now()
+	export.Size = int64(len(export.Contents))
+
+	if export.DataExportID > 0 {
+		return db.updateDataExport(ctx, export)
+	}
+
+	return db.insertDataExport(ctx, export)
+}
+
+func (db *Store) insertDataExport(ctx context.Context, export *DataExport) error {
+	query, args, errQueryArgs := db.sb.Insert("person_data_export").
+		Columns("steam_id", "status", "error", "contents", "size", "created_on", "updated_on").
+		Values(export.SteamID.Int64(), export.Status, export.Error, export.Contents, export.Size,
+			export.CreatedOn, export.UpdatedOn).
+		Suffix("RETURNING data_export_id").
+		ToSql()
+	if errQueryArgs != nil {
+		return errors.Wrapf(errQueryArgs, "Failed to create query")
+	}
+
+	if errQueryRow := db.QueryRow(ctx, query, args...).Scan(&export.DataExportID); errQueryRow != nil {
+		return Err(errQueryRow)
+	}
+
+	return nil
+}
+
+func (db *Store) updateDataExport(ctx context.Context, export *DataExport) error {
+	query, args, errQueryArgs := db.sb.Update("person_data_export").
+		Set("status", export.Status).
+		Set("error", export.Error).
+		Set("contents", export.Contents).
+		Set("size", export.Size).
+		Set("updated_on", export.UpdatedOn).
+		Where(sq.Eq{"data_export_id": export.DataExportID}).
+		ToSql()
+	if errQueryArgs != nil {
+		return errors.Wrapf(errQueryArgs, "Failed to create query")
+	}
+
+	if errExec := db.Exec(ctx, query, args...); errExec != nil {
+		return errors.Wrapf(errExec, "Failed to update data export")
+	}
+
+	return nil
+}
+
+func (db *Store) getDataExports(ctx context.Context, conditions sq.Sqlizer) ([]DataExport, error) {
+	query, args, errQueryArgs := db.sb.
+		Select("data_export_id", "steam_id", "status", "error", "size", "created_on", "updated_on").
+		From("person_data_export").
+		Where(conditions).
+		OrderBy("data_export_id DESC").
+		ToSql()
+	if errQueryArgs != nil {
+		return nil, Err(errQueryArgs)
+	}
+
+	rows, errQuery := db.Query(ctx, query, args...)
+	if errQuery != nil {
+		return nil, Err(errQuery)
+	}
+
+	defer rows.Close()
+
+	var exports []DataExport
+
+	for rows.Next() {
+		var (
+			export  DataExport
+			steamID int64
+		)
+
+		if errScan := rows.Scan(&export.DataExportID, &steamID, &export.Status, &export.Error, &export.Size,
+			&export.CreatedOn, &export.UpdatedOn); errScan != nil {
+			return nil, Err(errScan)
+		}
+
+		export.SteamID = steamid.New(steamID)
+
+		exports = append(exports, export)
+	}
+
+	return exports, nil
+}
+
+// GetDataExports returns all exports for the player, newest first. Contents are not loaded.
+func (db *Store) GetDataExports(ctx context.Context, steamID steamid.SID64) ([]DataExport, error) {
+	return db.getDataExports(ctx, sq.Eq{"steam_id": steamID.Int64()})
+}
+
+// GetIncompleteDataExports returns exports that have not finished processing, including any that were
+// interrupted by a restart.
+func (db *Store) GetIncompleteDataExports(ctx context.Context) ([]DataExport, error) {
+	return db.getDataExports(ctx, sq.Eq{"status": []DataExportStatus{DataExportPending, DataExportRunning}})
+}
+
+// GetDataExport loads the export including the archive contents.
+func (db *Store) GetDataExport(ctx context.Context, dataExportID int64, export *DataExport) error {
+	query, args, errQueryArgs := db.sb.
+		Select("data_export_id", "steam_id", "status", "error", "contents", "size", "created_on", "updated_on").
+		From("person_data_export").
+		Where(sq.Eq{"data_export_id": dataExportID}).
+		ToSql()
+	if errQueryArgs != nil {
+		return Err(errQueryArgs)
+	}
+
+	var steamID int64
+
+	if errQuery := db.QueryRow(ctx, query, args...).Scan(&export.DataExportID, &steamID, &export.Status,
+		&export.Error, &export.Contents, &export.Size, &export.CreatedOn, &export.UpdatedOn); errQuery != nil {
+		return Err(errQuery)
+	}
+
+	export.SteamID = steamid.New(steamID)
+
+	return nil
+}
+
+// PruneDataExports deletes exports created before the provided time.
+func (db *Store) PruneDataExports(ctx context.Context, olderThan time.Time) error {
+	query, args, errQueryArgs := db.sb.
+		Delete("person_data_export").
+		Where(sq.Lt{"created_on": olderThan}).
+		ToSql()
+	if errQueryArgs != nil {
+		return Err(errQueryArgs)
+	}
+
+	return db.Exec(ctx, query, args...)
+}
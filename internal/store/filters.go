@@ -7,34 +7,109 @@ import (
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
+	"github.com/leighmacdonald/gbans/pkg/util"
 	"github.com/leighmacdonald/steamid/v3/steamid"
 	"go.uber.org/zap"
 )
 
 type Filter struct {
-	FilterID     int64          `json:"filter_id"`
-	AuthorID     steamid.SID64  `json:"author_id"`
-	Pattern      string         `json:"pattern"`
-	IsRegex      bool           `json:"is_regex"`
-	IsEnabled    bool           `json:"is_enabled"`
-	Regex        *regexp.Regexp `json:"-"`
-	TriggerCount int64          `json:"trigger_count"`
-	CreatedOn    time.Time      `json:"created_on"`
-	UpdatedOn    time.Time      `json:"updated_on"`
+	FilterID     int64              `json:"filter_id"`
+	AuthorID     steamid.SID64      `json:"author_id"`
+	Pattern      string             `json:"pattern"`
+	IsRegex      bool               `json:"is_regex"`
+	IsEnabled    bool               `json:"is_enabled"`
+	Regex        *regexp.Regexp     `json:"-"`
+	Normalize    util.NormalizeOpts `json:"normalize"`
+	TriggerCount int64              `json:"trigger_count"`
+	CreatedOn    time.Time          `json:"created_on"`
+	UpdatedOn    time.Time          `json:"updated_on"`
+	// normalizedPattern is the exact match pattern after having the same normalization
+	// steps as the input applied to it.
+	normalizedPattern string
 }
 
 func (f *Filter) Init() {
 	if f.IsRegex {
 		f.Regex = regexp.MustCompile(f.Pattern)
+	} else {
+		f.normalizedPattern = util.Normalize(f.Pattern, f.Normalize)
 	}
 }
 
-func (f *Filter) Match(value string) bool {
+// Match checks the body of a message against the filter, returning the matched text. The body is
+// normalized using the filters Normalize options, see MatchTokens.
+func (f *Filter) Match(body string) (string, bool) {
+	return f.MatchTokens(FilterTokens(body, f.Normalize))
+}
+
+// MatchTokens checks the words returned by FilterTokens against the filter, returning the matched text.
+// The words must have been normalized using the same options as the filter.
+//
+// Plain patterns only ever match whole words. When spaces are being removed, runs of adjacent words are
+// joined together and checked so that split up words are still caught, eg. "c h e a t e r", without
+// matching patterns which are only a part of a larger word.
+func (f *Filter) MatchTokens(words []string) (string, bool) {
 	if f.IsRegex {
-		return f.Regex.MatchString(strings.ToLower(value))
+		if f.Normalize.Spaces {
+			value := strings.Join(words, "")
+			if f.Normalize.Repeats {
+				value = util.CollapseRepeats(value)
+			}
+
+			matched := f.Regex.FindString(value)
+
+			return matched, matched != ""
+		}
+
+		for _, word := range words {
+			if matched := f.Regex.FindString(word); matched != "" {
+				return matched, true
+			}
+		}
+
+		return "", false
 	}
 
-	return f.Pattern == strings.ToLower(value)
+	if f.normalizedPattern == "" {
+		return "", false
+	}
+
+	if !f.Normalize.Spaces {
+		for _, word := range words {
+			if word == f.normalizedPattern {
+				return word, true
+			}
+		}
+
+		return "", false
+	}
+
+	for start := range words {
+		var joined string
+
+		for _, word := range words[start:] {
+			joined += word
+			if f.Normalize.Repeats {
+				joined = util.CollapseRepeats(joined)
+			}
+
+			if joined == f.normalizedPattern {
+				return joined, true
+			}
+
+			// Joining more words can only make the value longer
+			if len(joined) >= len(f.normalizedPattern) {
+				break
+			}
+		}
+	}
+
+	return "", false
+}
+
+// FilterTokens normalizes and tokenizes a message body using the provided options.
+func FilterTokens(body string, opts util.NormalizeOpts) []string {
+	return util.NormalizeWords(body, opts)
 }
 
 func (db *Store) SaveFilter(ctx context.Context, filter *Filter) error {
@@ -48,12 +123,15 @@ func (db *Store) SaveFilter(ctx context.Context, filter *Filter) error {
 // todo squirrel version, it expects sql.db though...
 func (db *Store) insertFilter(ctx context.Context, filter *Filter) error {
 	const query = `
-		INSERT INTO filtered_word (author_id, pattern, is_regex, is_enabled, trigger_count, created_on, updated_on) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7) 
+		INSERT INTO filtered_word (author_id, pattern, is_regex, is_enabled, trigger_count, created_on, updated_on,
+		                           norm_confusables, norm_invisible, norm_leet, norm_repeats, norm_spaces) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) 
 		RETURNING filter_id`
 
 	if errQuery := db.QueryRow(ctx, query, filter.AuthorID.Int64(), filter.Pattern,
-		filter.IsRegex, filter.IsEnabled, filter.TriggerCount, filter.CreatedOn, filter.UpdatedOn).
+		filter.IsRegex, filter.IsEnabled, filter.TriggerCount, filter.CreatedOn, filter.UpdatedOn,
+		filter.Normalize.Confusables, filter.Normalize.Invisible, filter.Normalize.Leet,
+		filter.Normalize.Repeats, filter.Normalize.Spaces).
 		Scan(&filter.FilterID); errQuery != nil {
 		return Err(errQuery)
 	}
@@ -72,6 +150,11 @@ func (db *Store) updateFilter(ctx context.Context, filter *Filter) error {
 		Set("trigger_count", filter.TriggerCount).
 		Set("created_on", filter.CreatedOn).
 		Set("updated_on", filter.UpdatedOn).
+		Set("norm_confusables", filter.Normalize.Confusables).
+		Set("norm_invisible", filter.Normalize.Invisible).
+		Set("norm_leet", filter.Normalize.Leet).
+		Set("norm_repeats", filter.Normalize.Repeats).
+		Set("norm_spaces", filter.Normalize.Spaces).
 		Where(sq.Eq{"filter_id": filter.FilterID}).ToSql()
 	if errQuery != nil {
 		return Err(errQuery)
@@ -99,13 +182,16 @@ func (db *Store) DropFilter(ctx context.Context, filter *Filter) error {
 
 func (db *Store) GetFilterByID(ctx context.Context, wordID int64, filter *Filter) error {
 	const query = `
-		SELECT filter_id, author_id, pattern, is_regex, is_enabled, trigger_count, created_on, updated_on 
+		SELECT filter_id, author_id, pattern, is_regex, is_enabled, trigger_count, created_on, updated_on,
+		       norm_confusables, norm_invisible, norm_leet, norm_repeats, norm_spaces
 		FROM filtered_word 
 		WHERE filter_id = $1`
 
 	var authorID int64
 	if errQuery := db.QueryRow(ctx, query, wordID).Scan(&filter.FilterID, &authorID, &filter.Pattern,
-		&filter.IsRegex, &filter.IsEnabled, &filter.TriggerCount, &filter.CreatedOn, &filter.UpdatedOn); errQuery != nil {
+		&filter.IsRegex, &filter.IsEnabled, &filter.TriggerCount, &filter.CreatedOn, &filter.UpdatedOn,
+		&filter.Normalize.Confusables, &filter.Normalize.Invisible, &filter.Normalize.Leet,
+		&filter.Normalize.Repeats, &filter.Normalize.Spaces); errQuery != nil {
 		return Err(errQuery)
 	}
 
@@ -118,7 +204,8 @@ func (db *Store) GetFilterByID(ctx context.Context, wordID int64, filter *Filter
 
 func (db *Store) GetFilters(ctx context.Context) ([]Filter, error) {
 	const query = `
-		SELECT filter_id, author_id, pattern, is_regex, is_enabled, trigger_count, created_on, updated_on
+		SELECT filter_id, author_id, pattern, is_regex, is_enabled, trigger_count, created_on, updated_on,
+		       norm_confusables, norm_invisible, norm_leet, norm_repeats, norm_spaces
 		FROM filtered_word`
 
 	rows, errQuery := db.Query(ctx, query)
@@ -137,7 +224,9 @@ func (db *Store) GetFilters(ctx context.Context) ([]Filter, error) {
 		)
 
 		if errQuery = rows.Scan(&filter.FilterID, &authorID, &filter.Pattern, &filter.IsRegex,
-			&filter.IsEnabled, &filter.TriggerCount, &filter.CreatedOn, &filter.UpdatedOn); errQuery != nil {
+			&filter.IsEnabled, &filter.TriggerCount, &filter.CreatedOn, &filter.UpdatedOn,
+			&filter.Normalize.Confusables, &filter.Normalize.Invisible, &filter.Normalize.Leet,
+			&filter.Normalize.Repeats, &filter.Normalize.Spaces); errQuery != nil {
 			return nil, Err(errQuery)
 		}
 
@@ -0,0 +1,336 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// LeaderboardPeriod is the length of time a leaderboard covers.
+type LeaderboardPeriod int
+
+const (
+	LeaderboardDaily LeaderboardPeriod = iota + 1
+	LeaderboardWeekly
+	LeaderboardMonthly
+	LeaderboardSeason
+)
+
+func (period LeaderboardPeriod) String() string {
+	switch period {
+	case LeaderboardDaily:
+		return "daily"
+	case LeaderboardWeekly:
+		return "weekly"
+	case LeaderboardMonthly:
+		return "monthly"
+	case LeaderboardSeason:
+		return "season"
+	default:
+		return "unknown"
+	}
+}
+
+// LeaderboardBoard is the stat players are ranked by.
+type LeaderboardBoard string
+
+const (
+	LeaderboardKills   LeaderboardBoard = "kills"
+	LeaderboardDamage  LeaderboardBoard = "damage"
+	LeaderboardHealing LeaderboardBoard = "healing"
+	LeaderboardWins    LeaderboardBoard = "wins"
+	// LeaderboardWeapon ranks players by their kills with a single weapon.
+	LeaderboardWeapon LeaderboardBoard = "weapon"
+)
+
+// leaderboardColumns maps the boards to their table & value column. Only these values are ever
+// interpolated into leaderboard queries.
+var leaderboardColumns = map[LeaderboardBoard]struct { //nolint:gochecknoglobals
+	table  string
+	column string
+}{
+	LeaderboardKills:   {table: "leaderboard_player", column: "kills"},
+	LeaderboardDamage:  {table: "leaderboard_player", column: "damage"},
+	LeaderboardHealing: {table: "leaderboard_player", column: "healing"},
+	LeaderboardWins:    {table: "leaderboard_player", column: "wins"},
+	LeaderboardWeapon:  {table: "leaderboard_weapon", column: "kills"},
+}
+
+// LeaderboardScope limits a leaderboard to a single server or region. The zero value covers all servers.
+type LeaderboardScope struct {
+	ServerID int    `json:"server_id"`
+	Region   string `json:"region"`
+}
+
+// LeaderboardWindow is a single instance of a period, eg. the week starting on a specific monday.
+type LeaderboardWindow struct {
+	Period LeaderboardPeriod `json:"period"`
+	Start  time.Time         `json:"start"`
+}
+
+// LeaderboardPlayerStats are the totals a match adds to a players leaderboard stats.
+type LeaderboardPlayerStats struct {
+	SteamID     steamid.SID64
+	Matches     int
+	Wins        int
+	Kills       int
+	Assists     int
+	Deaths      int
+	Damage      int64
+	DamageTaken int64
+	Healing     int64
+	Playtime    int64
+}
+
+// LeaderboardWeaponStats are the totals a match adds to a players leaderboard stats for a weapon.
+type LeaderboardWeaponStats struct {
+	SteamID   steamid.SID64
+	WeaponID  int
+	Kills     int
+	Damage    int64
+	Shots     int
+	Hits      int
+	Headshots int
+	Airshots  int
+	Backstabs int
+}
+
+// LeaderboardEntry is a ranked player on a leaderboard. PreviousRank is the players rank in the previous
+// period, or 0 when they were not ranked.
+type LeaderboardEntry struct {
+	Rank         int           `json:"rank"`
+	PreviousRank int           `json:"previous_rank"`
+	SteamID      steamid.SID64 `json:"steam_id"`
+	PersonaName  string        `json:"persona_name"`
+	AvatarHash   string        `json:"avatar_hash"`
+	Value        int64         `json:"value"`
+	Matches      int           `json:"matches"`
+}
+
+type LeaderboardQuery struct {
+	Board         LeaderboardBoard
+	Window        LeaderboardWindow
+	PreviousStart time.Time
+	Scope         LeaderboardScope
+	WeaponID      int
+	Limit         uint64
+}
+
+// LeaderboardSaveMatch adds the stats of a match to every window & scope combination. ErrDuplicate is returned
+// when the match has already been added.
+func (db *Store) LeaderboardSaveMatch(ctx context.Context, matchID uuid.UUID, windows []LeaderboardWindow,
+	scopes []LeaderboardScope, players []LeaderboardPlayerStats, weapons []LeaderboardWeaponStats,
+) error {
+	const (
+		playerQuery = `
+		INSERT INTO leaderboard_player (
+			period, period_start, server_id, region, steam_id, matches, wins, kills, assists, deaths, damage,
+			damage_taken, healing, playtime, updated_on)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		ON CONFLICT (period, period_start, server_id, region, steam_id) DO UPDATE SET
+			matches = leaderboard_player.matches + excluded.matches,
+			wins = leaderboard_player.wins + excluded.wins,
+			kills = leaderboard_player.kills + excluded.kills,
+			assists = leaderboard_player.assists + excluded.assists,
+			deaths = leaderboard_player.deaths + excluded.deaths,
+			damage = leaderboard_player.damage + excluded.damage,
+			damage_taken = leaderboard_player.damage_taken + excluded.damage_taken,
+			healing = leaderboard_player.healing + excluded.healing,
+			playtime = leaderboard_player.playtime + excluded.playtime,
+			updated_on = excluded.updated_on`
+		weaponQuery = `
+		INSERT INTO leaderboard_weapon (
+			period, period_start, server_id, region, steam_id, weapon_id, kills, damage, shots, hits, headshots,
+			airshots, backstabs, updated_on)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (period, period_start, server_id, region, weapon_id, steam_id) DO UPDATE SET
+			kills = leaderboard_weapon.kills + excluded.kills,
+			damage = leaderboard_weapon.damage + excluded.damage,
+			shots = leaderboard_weapon.shots + excluded.shots,
+			hits = leaderboard_weapon.hits + excluded.hits,
+			headshots = leaderboard_weapon.headshots + excluded.headshots,
+			airshots = leaderboard_weapon.airshots + excluded.airshots,
+			backstabs = leaderboard_weapon.backstabs + excluded.backstabs,
+			updated_on = excluded.updated_on`
+	)
+
+	now := time.Now()
+
+	transaction, errTx := db.conn.Begin(ctx)
+	if errTx != nil {
+		return errors.Wrap(errTx, "Failed to create leaderboard tx")
+	}
+
+	rollback := func() {
+		if errRollback := transaction.Rollback(ctx); errRollback != nil {
+			db.log.Error("Failed to rollback tx", zap.Error(errRollback))
+		}
+	}
+
+	if _, errExec := transaction.Exec(ctx, `INSERT INTO leaderboard_match (match_id, created_on) VALUES ($1, $2)`,
+		matchID, now); errExec != nil {
+		rollback()
+
+		return Err(errExec)
+	}
+
+	batch := pgx.Batch{}
+
+	for _, window := range windows {
+		for _, scope := range scopes {
+			for _, player := range players {
+				batch.Queue(playerQuery, window.Period, window.Start, scope.ServerID, scope.Region,
+					player.SteamID.Int64(), player.Matches, player.Wins, player.Kills, player.Assists, player.Deaths,
+					player.Damage, player.DamageTaken, player.Healing, player.Playtime, now)
+			}
+
+			for _, weapon := range weapons {
+				batch.Queue(weaponQuery, window.Period, window.Start, scope.ServerID, scope.Region,
+					weapon.SteamID.Int64(), weapon.WeaponID, weapon.Kills, weapon.Damage, weapon.Shots, weapon.Hits,
+					weapon.Headshots, weapon.Airshots, weapon.Backstabs, now)
+			}
+		}
+	}
+
+	if errBatch := transaction.SendBatch(ctx, &batch).Close(); errBatch != nil {
+		rollback()
+
+		return errors.Wrap(Err(errBatch), "Failed to save leaderboard stats")
+	}
+
+	if errCommit := transaction.Commit(ctx); errCommit != nil {
+		return errors.Wrap(errCommit, "Failed to commit leaderboard stats")
+	}
+
+	return nil
+}
+
+// LeaderboardReset deletes all leaderboard stats so they can be rebuilt from the stored matches.
+func (db *Store) LeaderboardReset(ctx context.Context) error {
+	return db.Exec(ctx, `TRUNCATE leaderboard_weapon, leaderboard_player, leaderboard_match`)
+}
+
+// LeaderboardMatchIDs returns the ids of every match in the order they were played, used when rebuilding the
+// leaderboards.
+func (db *Store) LeaderboardMatchIDs(ctx context.Context) ([]uuid.UUID, error) {
+	rows, errRows := db.Query(ctx, `SELECT match_id FROM match ORDER BY time_start, match_id`)
+	if errRows != nil {
+		return nil, Err(errRows)
+	}
+
+	defer rows.Close()
+
+	var matchIDs []uuid.UUID
+
+	for rows.Next() {
+		var matchID uuid.UUID
+		if errScan := rows.Scan(&matchID); errScan != nil {
+			return nil, Err(errScan)
+		}
+
+		matchIDs = append(matchIDs, matchID)
+	}
+
+	return matchIDs, nil
+}
+
+// Leaderboard returns the top ranked players of the window along with their rank in the previous window.
+func (db *Store) Leaderboard(ctx context.Context, opts LeaderboardQuery) ([]LeaderboardEntry, error) {
+	board, found := leaderboardColumns[opts.Board]
+	if !found {
+		return nil, errors.Errorf("Unknown leaderboard: %s", opts.Board)
+	}
+
+	var (
+		matches     = "matches"
+		weaponWhere string
+		args        = []any{
+			opts.Window.Period, opts.Window.Start, opts.PreviousStart, opts.Scope.ServerID, opts.Scope.Region,
+			opts.Limit,
+		}
+	)
+
+	if opts.Board == LeaderboardWeapon {
+		matches = "0"
+		weaponWhere = "AND weapon_id = $7"
+
+		args = append(args, opts.WeaponID)
+	}
+
+	query := fmt.Sprintf(`
+		WITH cur AS (
+			SELECT steam_id, %[1]s AS value, %[2]s AS matches, row_number() OVER (ORDER BY %[1]s DESC, steam_id) AS rank
+			FROM %[3]s
+			WHERE period = $1 AND period_start = $2 AND server_id = $4 AND region = $5 AND %[1]s > 0 %[4]s
+		), prev AS (
+			SELECT steam_id, row_number() OVER (ORDER BY %[1]s DESC, steam_id) AS rank
+			FROM %[3]s
+			WHERE period = $1 AND period_start = $3 AND server_id = $4 AND region = $5 AND %[1]s > 0 %[4]s
+		)
+		SELECT c.rank, coalesce(pr.rank, 0), c.steam_id, p.personaname, p.avatarhash, c.value, c.matches
+		FROM cur c
+		LEFT JOIN prev pr ON pr.steam_id = c.steam_id
+		LEFT JOIN person p ON p.steam_id = c.steam_id
+		ORDER BY c.rank
+		LIMIT $6`, board.column, matches, board.table, weaponWhere)
+
+	rows, errRows := db.Query(ctx, query, args...)
+	if errRows != nil {
+		return nil, Err(errRows)
+	}
+
+	defer rows.Close()
+
+	entries := []LeaderboardEntry{}
+
+	for rows.Next() {
+		var (
+			entry      LeaderboardEntry
+			steamID    int64
+			name       *string
+			avatarHash *string
+		)
+
+		if errScan := rows.Scan(&entry.Rank, &entry.PreviousRank, &steamID, &name, &avatarHash, &entry.Value,
+			&entry.Matches); errScan != nil {
+			return nil, Err(errScan)
+		}
+
+		entry.SteamID = steamid.New(steamID)
+
+		if name != nil {
+			entry.PersonaName = *name
+		}
+
+		if avatarHash != nil {
+			entry.AvatarHash = *avatarHash
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// SaveLeaderboardAnnouncement records that the winners of the window were announced. ErrDuplicate is returned
+// when they already have been.
+func (db *Store) SaveLeaderboardAnnouncement(ctx context.Context, window LeaderboardWindow) error {
+	return db.Exec(ctx, `INSERT INTO leaderboard_announcement (period, period_start, created_on) VALUES ($1, $2, $3)`,
+		window.Period, window.Start, time.Now())
+}
+
+// PruneLeaderboards deletes the stats of windows of the period which started before olderThan.
+func (db *Store) PruneLeaderboards(ctx context.Context, period LeaderboardPeriod, olderThan time.Time) error {
+	if errPlayers := db.Exec(ctx, `DELETE FROM leaderboard_player WHERE period = $1 AND period_start < $2`,
+		period, olderThan); errPlayers != nil {
+		return errPlayers
+	}
+
+	return db.Exec(ctx, `DELETE FROM leaderboard_weapon WHERE period = $1 AND period_start < $2`, period, olderThan)
+}
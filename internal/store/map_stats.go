@@ -0,0 +1,350 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/leighmacdonald/gbans/pkg/logparse"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+	"github.com/pkg/errors"
+)
+
+// MapTrendBucket is the length of time each point of a map trend covers.
+type MapTrendBucket string
+
+const (
+	MapTrendDay   MapTrendBucket = "day"
+	MapTrendWeek  MapTrendBucket = "week"
+	MapTrendMonth MapTrendBucket = "month"
+)
+
+// Valid returns true for the buckets supported by date_trunc that are exposed to the api.
+func (bucket MapTrendBucket) Valid() bool {
+	return bucket == MapTrendDay || bucket == MapTrendWeek || bucket == MapTrendMonth
+}
+
+// MapClassStats is the usage & performance of a single class across every match played on a map.
+type MapClassStats struct {
+	PlayerClass logparse.PlayerClass `json:"player_class"`
+	Playtime    int64                `json:"playtime"`
+	Percent     float64              `json:"percent"`
+	Kills       int64                `json:"kills"`
+	Assists     int64                `json:"assists"`
+	Deaths      int64                `json:"deaths"`
+	Damage      int64                `json:"damage"`
+}
+
+// MapPlayerStats is the total performance of a player across every match they played on a map.
+type MapPlayerStats struct {
+	Rank        int           `json:"rank"`
+	SteamID     steamid.SID64 `json:"steam_id"`
+	PersonaName string        `json:"persona_name"`
+	AvatarHash  string        `json:"avatar_hash"`
+	Matches     int64         `json:"matches"`
+	Wins        int64         `json:"wins"`
+	Kills       int64         `json:"kills"`
+	Assists     int64         `json:"assists"`
+	Deaths      int64         `json:"deaths"`
+	Damage      int64         `json:"damage"`
+	Healing     int64         `json:"healing"`
+	Playtime    int64         `json:"playtime"`
+}
+
+// MapTrendPoint holds the outcomes & round metrics of the matches which started within a single UTC bucket.
+type MapTrendPoint struct {
+	Bucket          time.Time `json:"bucket"`
+	Matches         int64     `json:"matches"`
+	RedWins         int64     `json:"red_wins"`
+	BluWins         int64     `json:"blu_wins"`
+	RedWinRate      float64   `json:"red_win_rate"`
+	BluWinRate      float64   `json:"blu_win_rate"`
+	AvgMatchLength  float64   `json:"avg_match_length"`
+	Rounds          int64     `json:"rounds"`
+	AvgRoundLength  float64   `json:"avg_round_length"`
+	AvgCapsPerRound float64   `json:"avg_caps_per_round"`
+}
+
+// MapStats are the team outcome, class & player statistics of a map, computed from all of its saved matches.
+// Lengths are in seconds and win rates are percentages of all matches, draws included.
+type MapStats struct {
+	MapName         string           `json:"map_name"`
+	Matches         int64            `json:"matches"`
+	Playtime        int64            `json:"playtime"`
+	RedWins         int64            `json:"red_wins"`
+	BluWins         int64            `json:"blu_wins"`
+	Draws           int64            `json:"draws"`
+	RedWinRate      float64          `json:"red_win_rate"`
+	BluWinRate      float64          `json:"blu_win_rate"`
+	AvgMatchLength  float64          `json:"avg_match_length"`
+	Rounds          int64            `json:"rounds"`
+	AvgRoundLength  float64          `json:"avg_round_length"`
+	AvgCapsPerRound float64          `json:"avg_caps_per_round"`
+	Classes         []MapClassStats  `json:"classes"`
+	TopPlayers      []MapPlayerStats `json:"top_players"`
+	Trend           []MapTrendPoint  `json:"trend"`
+}
+
+// MapStatsQuery selects the map, the trend bucket size and the amount of top players to return.
+type MapStatsQuery struct {
+	MapName     string
+	Bucket      MapTrendBucket
+	PlayerLimit uint64
+}
+
+func percentOf(value int64, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+
+	return float64(value) / float64(total) * 100
+}
+
+// MapStats computes the statistics of a map. ErrNoResult is returned when no matches have been played on it.
+func (db *Store) MapStats(ctx context.Context, opts MapStatsQuery) (MapStats, error) {
+	const overallQuery = `
+		SELECT count(*),
+			coalesce(sum(extract('epoch' from time_end - time_start)), 0)::bigint,
+			count(*) FILTER (WHERE winner = $2),
+			count(*) FILTER (WHERE winner = $3),
+			coalesce(avg(extract('epoch' from time_end - time_start)), 0)::float
+		FROM match
+		WHERE map = $1`
+
+	const roundQuery = `
+		SELECT count(*), coalesce(avg(r.length), 0)::float, coalesce(avg(r.caps_red + r.caps_blu), 0)::float
+		FROM match_round r
+		LEFT JOIN match m ON m.match_id = r.match_id
+		WHERE m.map = $1`
+
+	if !opts.Bucket.Valid() {
+		return MapStats{}, errors.Errorf("Invalid trend bucket: %s", opts.Bucket)
+	}
+
+	stats := MapStats{
+		MapName:    opts.MapName,
+		Classes:    []MapClassStats{},
+		TopPlayers: []MapPlayerStats{},
+		Trend:      []MapTrendPoint{},
+	}
+
+	if errRow := db.QueryRow(ctx, overallQuery, opts.MapName, logparse.RED, logparse.BLU).
+		Scan(&stats.Matches, &stats.Playtime, &stats.RedWins, &stats.BluWins, &stats.AvgMatchLength); errRow != nil {
+		return stats, Err(errRow)
+	}
+
+	if stats.Matches == 0 {
+		return stats, ErrNoResult
+	}
+
+	stats.Draws = stats.Matches - stats.RedWins - stats.BluWins
+	stats.RedWinRate = percentOf(stats.RedWins, stats.Matches)
+	stats.BluWinRate = percentOf(stats.BluWins, stats.Matches)
+
+	if errRow := db.QueryRow(ctx, roundQuery, opts.MapName).
+		Scan(&stats.Rounds, &stats.AvgRoundLength, &stats.AvgCapsPerRound); errRow != nil {
+		return stats, Err(errRow)
+	}
+
+	classes, errClasses := db.mapClassStats(ctx, opts.MapName)
+	if errClasses != nil {
+		return stats, errClasses
+	}
+
+	stats.Classes = classes
+
+	players, errPlayers := db.mapTopPlayers(ctx, opts.MapName, opts.PlayerLimit)
+	if errPlayers != nil {
+		return stats, errPlayers
+	}
+
+	stats.TopPlayers = players
+
+	trend, errTrend := db.mapTrend(ctx, opts.MapName, opts.Bucket)
+	if errTrend != nil {
+		return stats, errTrend
+	}
+
+	stats.Trend = trend
+
+	return stats, nil
+}
+
+// mapClassStats returns the class totals of the map ordered by playtime, most played first.
+func (db *Store) mapClassStats(ctx context.Context, mapName string) ([]MapClassStats, error) {
+	const query = `
+		SELECT c.player_class_id, sum(c.playtime), sum(c.kills), sum(c.assists), sum(c.deaths), sum(c.damage)
+		FROM match_player_class c
+		LEFT JOIN match_player mp ON mp.match_player_id = c.match_player_id
+		LEFT JOIN match m ON m.match_id = mp.match_id
+		WHERE m.map = $1 AND c.player_class_id BETWEEN $2 AND $3
+		GROUP BY c.player_class_id
+		ORDER BY sum(c.playtime) DESC`
+
+	rows, errRows := db.Query(ctx, query, mapName, logparse.Scout, logparse.Spy)
+	if errRows != nil {
+		return nil, Err(errRows)
+	}
+
+	defer rows.Close()
+
+	var (
+		classes = []MapClassStats{}
+		total   int64
+	)
+
+	for rows.Next() {
+		var class MapClassStats
+		if errScan := rows.Scan(&class.PlayerClass, &class.Playtime, &class.Kills, &class.Assists, &class.Deaths,
+			&class.Damage); errScan != nil {
+			return nil, Err(errScan)
+		}
+
+		total += class.Playtime
+
+		classes = append(classes, class)
+	}
+
+	if rows.Err() != nil {
+		return nil, errors.Wrap(rows.Err(), "rows returned error")
+	}
+
+	for index := range classes {
+		classes[index].Percent = percentOf(classes[index].Playtime, total)
+	}
+
+	return classes, nil
+}
+
+// mapTopPlayers returns the players with the most kills on the map.
+func (db *Store) mapTopPlayers(ctx context.Context, mapName string, limit uint64) ([]MapPlayerStats, error) {
+	const query = `
+		WITH players AS (
+			SELECT mp.steam_id,
+				count(DISTINCT mp.match_id) AS matches,
+				count(DISTINCT mp.match_id) FILTER (WHERE m.winner = mp.team) AS wins,
+				coalesce(sum(c.kills), 0) AS kills,
+				coalesce(sum(c.assists), 0) AS assists,
+				coalesce(sum(c.deaths), 0) AS deaths,
+				coalesce(sum(c.damage), 0) AS damage,
+				coalesce(sum(c.playtime), 0) AS playtime
+			FROM match_player mp
+			LEFT JOIN match m ON m.match_id = mp.match_id
+			LEFT JOIN match_player_class c ON c.match_player_id = mp.match_player_id
+			WHERE m.map = $1
+			GROUP BY mp.steam_id
+		), healing AS (
+			SELECT mp.steam_id, sum(mm.healing) AS healing
+			FROM match_medic mm
+			LEFT JOIN match_player mp ON mp.match_player_id = mm.match_player_id
+			LEFT JOIN match m ON m.match_id = mp.match_id
+			WHERE m.map = $1
+			GROUP BY mp.steam_id
+		)
+		SELECT row_number() OVER (ORDER BY pl.kills DESC, pl.steam_id), pl.steam_id, p.personaname, p.avatarhash,
+			pl.matches, pl.wins, pl.kills, pl.assists, pl.deaths, pl.damage, coalesce(h.healing, 0), pl.playtime
+		FROM players pl
+		LEFT JOIN healing h ON h.steam_id = pl.steam_id
+		LEFT JOIN person p ON p.steam_id = pl.steam_id
+		ORDER BY pl.kills DESC, pl.steam_id
+		LIMIT $2`
+
+	rows, errRows := db.Query(ctx, query, mapName, limit)
+	if errRows != nil {
+		return nil, Err(errRows)
+	}
+
+	defer rows.Close()
+
+	players := []MapPlayerStats{}
+
+	for rows.Next() {
+		var (
+			player     MapPlayerStats
+			steamID    int64
+			name       *string
+			avatarHash *string
+		)
+
+		if errScan := rows.Scan(&player.Rank, &steamID, &name, &avatarHash, &player.Matches, &player.Wins,
+			&player.Kills, &player.Assists, &player.Deaths, &player.Damage, &player.Healing,
+			&player.Playtime); errScan != nil {
+			return nil, Err(errScan)
+		}
+
+		player.SteamID = steamid.New(steamID)
+
+		if name != nil {
+			player.PersonaName = *name
+		}
+
+		if avatarHash != nil {
+			player.AvatarHash = *avatarHash
+		}
+
+		players = append(players, player)
+	}
+
+	if rows.Err() != nil {
+		return nil, errors.Wrap(rows.Err(), "rows returned error")
+	}
+
+	return players, nil
+}
+
+// mapTrend returns the match outcomes of the map grouped by the time the matches started, oldest first.
+// Buckets are truncated in UTC so that they do not depend on the session time zone.
+func (db *Store) mapTrend(ctx context.Context, mapName string, bucket MapTrendBucket) ([]MapTrendPoint, error) {
+	// bucket is validated by the caller and is the only interpolated value. Rounds of the map's
+	// matches are aggregated per match first so that joining them does not inflate the match counts.
+	query := fmt.Sprintf(`
+		WITH rounds AS (
+			SELECT mr.match_id, count(*) AS rounds, sum(mr.length) AS length,
+			       sum(mr.caps_red + mr.caps_blu) AS caps
+			FROM match_round mr
+			JOIN match rm ON rm.match_id = mr.match_id
+			WHERE rm.map = $1
+			GROUP BY mr.match_id
+		)
+		SELECT date_trunc('%s', m.time_start AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' AS bucket, count(*),
+			count(*) FILTER (WHERE m.winner = $2),
+			count(*) FILTER (WHERE m.winner = $3),
+			avg(extract('epoch' from m.time_end - m.time_start))::float,
+			coalesce(sum(r.rounds), 0)::bigint,
+			coalesce(sum(r.length)::float / nullif(sum(r.rounds), 0), 0)::float,
+			coalesce(sum(r.caps)::float / nullif(sum(r.rounds), 0), 0)::float
+		FROM match m
+		LEFT JOIN rounds r ON r.match_id = m.match_id
+		WHERE m.map = $1
+		GROUP BY bucket
+		ORDER BY bucket`, bucket)
+
+	rows, errRows := db.Query(ctx, query, mapName, logparse.RED, logparse.BLU)
+	if errRows != nil {
+		return nil, Err(errRows)
+	}
+
+	defer rows.Close()
+
+	trend := []MapTrendPoint{}
+
+	for rows.Next() {
+		var point MapTrendPoint
+		if errScan := rows.Scan(&point.Bucket, &point.Matches, &point.RedWins, &point.BluWins,
+			&point.AvgMatchLength, &point.Rounds, &point.AvgRoundLength, &point.AvgCapsPerRound); errScan != nil {
+			return nil, Err(errScan)
+		}
+
+		point.Bucket = point.Bucket.UTC()
+		point.RedWinRate = percentOf(point.RedWins, point.Matches)
+		point.BluWinRate = percentOf(point.BluWins, point.Matches)
+
+		trend = append(trend, point)
+	}
+
+	if rows.Err() != nil {
+		return nil, errors.Wrap(rows.Err(), "rows returned error")
+	}
+
+	return trend, nil
+}
@@ -54,6 +54,53 @@ type MatchPlayerClass struct {
 	BuildingDestroyed  int                  `json:"building_destroyed"`
 }
 
+// MatchPlayerClassKill holds the kills, deaths & assists of a player against a single opposing class.
+type MatchPlayerClassKill struct {
+	PlayerClass logparse.PlayerClass `json:"player_class"`
+	Kills       int                  `json:"kills"`
+	Deaths      int                  `json:"deaths"`
+	Assists     int                  `json:"assists"`
+}
+
+// MatchHealTarget is the total healing a player gave to a single target.
+type MatchHealTarget struct {
+	SteamID steamid.SID64 `json:"steam_id"`
+	Healing int           `json:"healing"`
+}
+
+// MatchRound holds the team totals for a single round. Length is in seconds.
+type MatchRound struct {
+	Round     int           `json:"round"`
+	TimeStart time.Time     `json:"time_start"`
+	Length    int           `json:"length"`
+	Winner    logparse.Team `json:"winner"`
+	ScoreRed  int           `json:"score_red"`
+	ScoreBlu  int           `json:"score_blu"`
+	KillsRed  int           `json:"kills_red"`
+	KillsBlu  int           `json:"kills_blu"`
+	DamageRed int           `json:"damage_red"`
+	DamageBlu int           `json:"damage_blu"`
+	UbersRed  float64       `json:"ubers_red"`
+	UbersBlu  float64       `json:"ubers_blu"`
+	CapsRed   int           `json:"caps_red"`
+	CapsBlu   int           `json:"caps_blu"`
+	// CartProgressRed and CartProgressBlu are the furthest each team pushed their payload cart, from 0 to 1
+	CartProgressRed float64 `json:"cart_progress_red"`
+	CartProgressBlu float64 `json:"cart_progress_blu"`
+}
+
+// MatchTimeline holds the team totals for a slice of the match starting at Offset seconds from the
+// start of the match.
+type MatchTimeline struct {
+	Offset    int     `json:"offset"`
+	KillsRed  int     `json:"kills_red"`
+	KillsBlu  int     `json:"kills_blu"`
+	DamageRed int     `json:"damage_red"`
+	DamageBlu int     `json:"damage_blu"`
+	UbersRed  float64 `json:"ubers_red"`
+	UbersBlu  float64 `json:"ubers_blu"`
+}
+
 type MatchPlayer struct {
 	MatchPlayerID int64 `json:"match_player_id"`
 	CommonPlayerStats
@@ -65,6 +112,9 @@ type MatchPlayer struct {
 	Classes     []MatchPlayerClass      `json:"classes"`
 	Killstreaks []MatchPlayerKillstreak `json:"killstreaks"`
 	Weapons     []MatchPlayerWeapon     `json:"weapons"`
+	ClassKills  []MatchPlayerClassKill  `json:"class_kills"`
+	HealSpread  []MatchHealTarget       `json:"heal_spread"`
+	Objectives  logparse.ObjectiveStats `json:"objectives"`
 }
 
 func (player MatchPlayer) BiggestKillstreak() *MatchPlayerKillstreak {
@@ -133,11 +183,13 @@ type MatchResult struct {
 	ServerID   int                 `json:"server_id"`
 	Title      string              `json:"title"`
 	MapName    string              `json:"map_name"`
+	GameMode   logparse.GameMode   `json:"game_mode"`
 	TeamScores logparse.TeamScores `json:"team_scores"`
 	TimeStart  time.Time           `json:"time_start"`
 	TimeEnd    time.Time           `json:"time_end"`
 	Winner     logparse.Team       `json:"winner"`
 	Players    []*MatchPlayer      `json:"players"`
+	Rounds     []MatchRound        `json:"rounds"`
 	Chat       PersonMessages      `json:"chat"`
 }
 
@@ -246,11 +298,25 @@ type MatchPlayerWeapon struct {
 	Backstabs int     `json:"backstabs"`
 	Headshots int     `json:"headshots"`
 	Airshots  int     `json:"airshots"`
+	// Number of kills with known positions and the sums of their distances
+	DistanceKills     int     `json:"distance_kills"`
+	DistanceSum       float64 `json:"-"`
+	DistanceSquareSum float64 `json:"-"`
+}
+
+// KillDistance returns the mean distance of the kills with known positions.
+func (w MatchPlayerWeapon) KillDistance() float64 {
+	if w.DistanceKills == 0 {
+		return 0
+	}
+
+	return w.DistanceSum / float64(w.DistanceKills)
 }
 
 func (db *Store) matchGetPlayerWeapons(ctx context.Context, matchID uuid.UUID) (map[steamid.SID64][]MatchPlayerWeapon, error) {
 	const query = `
-		SELECT mp.steam_id, mw.weapon_id, w.name, w.key,  mw.kills, mw.damage, mw.shots, mw.hits, mw.backstabs, mw.headshots, mw.airshots
+		SELECT mp.steam_id, mw.weapon_id, w.name, w.key,  mw.kills, mw.damage, mw.shots, mw.hits, mw.backstabs, mw.headshots, mw.airshots,
+		       mw.distance_kills, mw.distance_sum, mw.distance_square_sum
 		FROM match m
 		LEFT JOIN match_player mp on m.match_id = mp.match_id
 		LEFT JOIN match_weapon mw on mp.match_player_id = mw.match_player_id
@@ -275,7 +341,8 @@ func (db *Store) matchGetPlayerWeapons(ctx context.Context, matchID uuid.UUID) (
 
 		if errScan := rows.
 			Scan(&steamID, &mpw.WeaponID, &mpw.Weapon.Name, &mpw.Weapon.Key, &mpw.Kills, &mpw.Damage, &mpw.Shots,
-				&mpw.Hits, &mpw.Backstabs, &mpw.Headshots, &mpw.Airshots); errScan != nil {
+				&mpw.Hits, &mpw.Backstabs, &mpw.Headshots, &mpw.Airshots,
+				&mpw.DistanceKills, &mpw.DistanceSum, &mpw.DistanceSquareSum); errScan != nil {
 			return nil, Err(errScan)
 		}
 
@@ -420,6 +487,125 @@ func (db *Store) matchGetPlayers(ctx context.Context, matchID uuid.UUID) ([]*Mat
 	return players, nil
 }
 
+func (db *Store) matchGetPlayerClassKills(ctx context.Context, matchID uuid.UUID) (map[steamid.SID64][]MatchPlayerClassKill, error) {
+	const query = `
+		SELECT mp.steam_id, k.player_class_id, k.kills, k.deaths, k.assists
+		FROM match_player_class_kill k
+		LEFT JOIN match_player mp on mp.match_player_id = k.match_player_id
+		WHERE mp.match_id = $1
+		ORDER BY k.player_class_id`
+
+	rows, errRows := db.Query(ctx, query, matchID)
+	if errRows != nil {
+		return nil, Err(errRows)
+	}
+
+	defer rows.Close()
+
+	results := map[steamid.SID64][]MatchPlayerClassKill{}
+
+	for rows.Next() {
+		var (
+			steamID int64
+			stats   MatchPlayerClassKill
+		)
+
+		if errScan := rows.Scan(&steamID, &stats.PlayerClass, &stats.Kills, &stats.Deaths, &stats.Assists); errScan != nil {
+			return nil, Err(errScan)
+		}
+
+		sid := steamid.New(steamID)
+		results[sid] = append(results[sid], stats)
+	}
+
+	if errRows := rows.Err(); errRows != nil {
+		return nil, Err(errRows)
+	}
+
+	return results, nil
+}
+
+func (db *Store) matchGetPlayerHealSpread(ctx context.Context, matchID uuid.UUID) (map[steamid.SID64][]MatchHealTarget, error) {
+	const query = `
+		SELECT mp.steam_id, h.target_steam_id, h.healing
+		FROM match_player_healspread h
+		LEFT JOIN match_player mp on mp.match_player_id = h.match_player_id
+		WHERE mp.match_id = $1
+		ORDER BY h.healing DESC`
+
+	rows, errRows := db.Query(ctx, query, matchID)
+	if errRows != nil {
+		return nil, Err(errRows)
+	}
+
+	defer rows.Close()
+
+	results := map[steamid.SID64][]MatchHealTarget{}
+
+	for rows.Next() {
+		var (
+			steamID  int64
+			targetID int64
+			target   MatchHealTarget
+		)
+
+		if errScan := rows.Scan(&steamID, &targetID, &target.Healing); errScan != nil {
+			return nil, Err(errScan)
+		}
+
+		target.SteamID = steamid.New(targetID)
+		sid := steamid.New(steamID)
+		results[sid] = append(results[sid], target)
+	}
+
+	if errRows := rows.Err(); errRows != nil {
+		return nil, Err(errRows)
+	}
+
+	return results, nil
+}
+
+func (db *Store) matchGetPlayerObjectives(ctx context.Context, matchID uuid.UUID) (map[steamid.SID64]logparse.ObjectiveStats, error) {
+	const query = `
+		SELECT mp.steam_id, o.ball_grabs, o.ball_passes, o.ball_catches, o.ball_interceptions, o.ball_steals, 
+		       o.ball_blocks, o.ball_scores, o.ball_score_assists, o.robot_kills, o.cart_points, o.koth_cap_time, 
+		       o.waves_completed, o.waves_failed
+		FROM match_player_objective o
+		LEFT JOIN match_player mp on mp.match_player_id = o.match_player_id
+		WHERE mp.match_id = $1`
+
+	rows, errRows := db.Query(ctx, query, matchID)
+	if errRows != nil {
+		return nil, Err(errRows)
+	}
+
+	defer rows.Close()
+
+	results := map[steamid.SID64]logparse.ObjectiveStats{}
+
+	for rows.Next() {
+		var (
+			steamID int64
+			stats   logparse.ObjectiveStats
+		)
+
+		if errScan := rows.Scan(&steamID, &stats.BallGrabs, &stats.BallPasses, &stats.BallCatches,
+			&stats.BallInterceptions, &stats.BallSteals, &stats.BallBlocks, &stats.BallScores,
+			&stats.BallScoreAssists, &stats.RobotKills, &stats.CartPoints, &stats.KothCapTime, &stats.WavesCompleted,
+			&stats.WavesFailed); errScan != nil {
+			return nil, Err(errScan)
+		}
+
+		results[steamid.New(steamID)] = stats
+	}
+
+	if errRows := rows.Err(); errRows != nil {
+		return nil, Err(errRows)
+	}
+
+	return results, nil
+}
+
 func (db *Store) matchGetMedics(ctx context.Context, matchID uuid.UUID) (map[steamid.SID64]MatchHealer, error) {
 	const query = `
 		SELECT m.match_medic_id,
@@ -526,14 +712,15 @@ func (db *Store) matchGetChat(ctx context.Context, matchID uuid.UUID) (PersonMes
 
 func (db *Store) MatchGetByID(ctx context.Context, matchID uuid.UUID, match *MatchResult) error {
 	const query = `
-		SELECT match_id, server_id, map, title, score_red, score_blu, time_red, time_blu, time_start, time_end, winner
+		SELECT match_id, server_id, map, title, score_red, score_blu, time_red, time_blu, time_start, time_end, 
+		       winner, game_mode
 		FROM match WHERE match_id = $1`
 
 	if errMatch := db.
 		QueryRow(ctx, query, matchID).
 		Scan(&match.MatchID, &match.ServerID, &match.MapName, &match.Title,
-			&match.TeamScores.Red, &match.TeamScores.Blu, &match.TeamScores.BluTime, &match.TeamScores.BluTime,
-			&match.TimeStart, &match.TimeEnd, &match.Winner); errMatch != nil {
+			&match.TeamScores.Red, &match.TeamScores.Blu, &match.TeamScores.RedTime, &match.TeamScores.BluTime,
+			&match.TimeStart, &match.TimeEnd, &match.Winner, &match.GameMode); errMatch != nil {
 		return errors.Wrapf(errMatch, "Failed to load root match")
 	}
 
@@ -600,6 +787,34 @@ func (db *Store) MatchGetByID(ctx context.Context, matchID uuid.UUID, match *Mat
 		}
 	}
 
+	classKills, errClassKills := db.matchGetPlayerClassKills(ctx, matchID)
+	if errClassKills != nil {
+		return errors.Wrap(errClassKills, "Failed to fetch match class kills")
+	}
+
+	healSpread, errHealSpread := db.matchGetPlayerHealSpread(ctx, matchID)
+	if errHealSpread != nil {
+		return errors.Wrap(errHealSpread, "Failed to fetch match heal spread")
+	}
+
+	objectives, errObjectives := db.matchGetPlayerObjectives(ctx, matchID)
+	if errObjectives != nil {
+		return errors.Wrap(errObjectives, "Failed to fetch match objective stats")
+	}
+
+	for _, player := range match.Players {
+		player.ClassKills = classKills[player.SteamID]
+		player.HealSpread = healSpread[player.SteamID]
+		player.Objectives = objectives[player.SteamID]
+	}
+
+	rounds, errRounds := db.MatchGetRounds(ctx, matchID)
+	if errRounds != nil {
+		return errors.Wrap(errRounds, "Failed to fetch match rounds")
+	}
+
+	match.Rounds = rounds
+
 	chat, errChat := db.matchGetChat(ctx, matchID)
 
 	if errChat != nil && !errors.Is(errChat, ErrNoResult) {
@@ -624,11 +839,103 @@ func (db *Store) MatchGetByID(ctx context.Context, matchID uuid.UUID, match *Mat
 		if player.Killstreaks == nil {
 			player.Killstreaks = []MatchPlayerKillstreak{}
 		}
+
+		if player.ClassKills == nil {
+			player.ClassKills = []MatchPlayerClassKill{}
+		}
+
+		if player.HealSpread == nil {
+			player.HealSpread = []MatchHealTarget{}
+		}
 	}
 
 	return nil
 }
 
+// MatchGetRounds returns the per round team totals of a match in the order they were played.
+func (db *Store) MatchGetRounds(ctx context.Context, matchID uuid.UUID) ([]MatchRound, error) {
+	const query = `
+		SELECT round, time_start, length, winner, score_red, score_blu, kills_red, kills_blu, damage_red, 
+		       damage_blu, ubers_red, ubers_blu, caps_red, caps_blu, cart_progress_red, cart_progress_blu
+		FROM match_round
+		WHERE match_id = $1
+		ORDER BY round`
+
+	rows, errRows := db.Query(ctx, query, matchID)
+	if errRows != nil {
+		return nil, Err(errRows)
+	}
+
+	defer rows.Close()
+
+	rounds := []MatchRound{}
+
+	for rows.Next() {
+		var round MatchRound
+		if errScan := rows.Scan(&round.Round, &round.TimeStart, &round.Length, &round.Winner,
+			&round.ScoreRed, &round.ScoreBlu, &round.KillsRed, &round.KillsBlu, &round.DamageRed, &round.DamageBlu,
+			&round.UbersRed, &round.UbersBlu, &round.CapsRed, &round.CapsBlu, &round.CartProgressRed,
+			&round.CartProgressBlu); errScan != nil {
+			return nil, Err(errScan)
+		}
+
+		rounds = append(rounds, round)
+	}
+
+	if errRows := rows.Err(); errRows != nil {
+		return nil, Err(errRows)
+	}
+
+	return rounds, nil
+}
+
+// MatchGetTimeline returns the time bucketed team totals of a match.
+func (db *Store) MatchGetTimeline(ctx context.Context, matchID uuid.UUID) ([]MatchTimeline, error) {
+	const query = `
+		SELECT time_offset, kills_red, kills_blu, damage_red, damage_blu, ubers_red, ubers_blu
+		FROM match_timeline
+		WHERE match_id = $1
+		ORDER BY time_offset`
+
+	rows, errRows := db.Query(ctx, query, matchID)
+	if errRows != nil {
+		return nil, Err(errRows)
+	}
+
+	defer rows.Close()
+
+	timeline := []MatchTimeline{}
+
+	for rows.Next() {
+		var bucket MatchTimeline
+		if errScan := rows.Scan(&bucket.Offset, &bucket.KillsRed, &bucket.KillsBlu, &bucket.DamageRed,
+			&bucket.DamageBlu, &bucket.UbersRed, &bucket.UbersBlu); errScan != nil {
+			return nil, Err(errScan)
+		}
+
+		timeline = append(timeline, bucket)
+	}
+
+	if errRows := rows.Err(); errRows != nil {
+		return nil, Err(errRows)
+	}
+
+	return timeline, nil
+}
+
+// MatchIDByStart finds an existing match on the server which started at the same time. This is used to
+// avoid creating duplicate matches when importing historical logs.
+func (db *Store) MatchIDByStart(ctx context.Context, serverID int, timeStart time.Time) (uuid.UUID, error) {
+	const query = `SELECT match_id FROM match WHERE server_id = $1 AND time_start = $2 LIMIT 1`
+
+	var matchID uuid.UUID
+	if errQuery := db.QueryRow(ctx, query, serverID, timeStart).Scan(&matchID); errQuery != nil {
+		return matchID, Err(errQuery)
+	}
+
+	return matchID, nil
+}
+
 var (
 	ErrIncompleteMatch     = errors.New("Insufficient match data")
 	ErrInsufficientPlayers = errors.New("Insufficient match players")
@@ -640,8 +947,9 @@ func (db *Store) MatchSave(ctx context.Context, match *logparse.Match) error {
 	const (
 		minPlayers = 6
 		query      = `
-		INSERT INTO match (match_id, server_id, map, title, score_red, score_blu, time_red, time_blu, time_start, time_end, winner) 
-		VALUES ($1, $2, $3, $4, $5, $6,$7, $8, $9, $10, $11) 
+		INSERT INTO match (
+			match_id, server_id, map, title, score_red, score_blu, time_red, time_blu, time_start, time_end, winner, game_mode) 
+		VALUES ($1, $2, $3, $4, $5, $6,$7, $8, $9, $10, $11, $12) 
 		RETURNING match_id`
 	)
 
@@ -661,7 +969,7 @@ func (db *Store) MatchSave(ctx context.Context, match *logparse.Match) error {
 	if errQuery := transaction.
 		QueryRow(ctx, query, match.MatchID, match.ServerID, match.MapName, match.Title,
 			match.TeamScores.Red, match.TeamScores.Blu, match.TeamScores.RedTime, match.TeamScores.BluTime,
-			match.TimeStart, match.TimeEnd, match.Winner()).
+			match.TimeStart, match.TimeEnd, match.Winner(), match.GameMode).
 		Scan(&match.MatchID); errQuery != nil {
 		if errRollback := transaction.Rollback(ctx); errRollback != nil {
 			db.log.Error("Failed to rollback tx", zap.Error(errRollback))
@@ -717,6 +1025,30 @@ func (db *Store) MatchSave(ctx context.Context, match *logparse.Match) error {
 			return errSave
 		}
 
+		if errSave := db.saveMatchClassKillStats(ctx, transaction, player); errSave != nil {
+			if errRollback := transaction.Rollback(ctx); errRollback != nil {
+				db.log.Error("Failed to rollback tx", zap.Error(errRollback))
+			}
+
+			return errSave
+		}
+
+		if errSave := db.saveMatchHealSpread(ctx, transaction, player); errSave != nil {
+			if errRollback := transaction.Rollback(ctx); errRollback != nil {
+				db.log.Error("Failed to rollback tx", zap.Error(errRollback))
+			}
+
+			return errSave
+		}
+
+		if errSave := db.saveMatchObjectiveStats(ctx, transaction, player); errSave != nil {
+			if errRollback := transaction.Rollback(ctx); errRollback != nil {
+				db.log.Error("Failed to rollback tx", zap.Error(errRollback))
+			}
+
+			return errSave
+		}
+
 		if player.HealingStats != nil && player.HealingStats.Healing >= MinMedicHealing {
 			if errSave := db.saveMatchMedicStats(ctx, transaction, player.MatchPlayerID, player.HealingStats); errSave != nil {
 				if errRollback := transaction.Rollback(ctx); errRollback != nil {
@@ -728,6 +1060,22 @@ func (db *Store) MatchSave(ctx context.Context, match *logparse.Match) error {
 		}
 	}
 
+	if errSave := db.saveMatchRounds(ctx, transaction, match); errSave != nil {
+		if errRollback := transaction.Rollback(ctx); errRollback != nil {
+			db.log.Error("Failed to rollback tx", zap.Error(errRollback))
+		}
+
+		return errSave
+	}
+
+	if errSave := db.saveMatchTimeline(ctx, transaction, match); errSave != nil {
+		if errRollback := transaction.Rollback(ctx); errRollback != nil {
+			db.log.Error("Failed to rollback tx", zap.Error(errRollback))
+		}
+
+		return errSave
+	}
+
 	if errCommit := transaction.Commit(ctx); errCommit != nil {
 		return errors.Wrapf(errCommit, "Failed to commit match")
 	}
@@ -735,6 +1083,43 @@ func (db *Store) MatchSave(ctx context.Context, match *logparse.Match) error {
 	return nil
 }
 
+func (db *Store) saveMatchRounds(ctx context.Context, transaction pgx.Tx, match *logparse.Match) error {
+	const query = `
+		INSERT INTO match_round (
+			match_id, round, time_start, length, winner, score_red, score_blu, kills_red, kills_blu, 
+			damage_red, damage_blu, ubers_red, ubers_blu, caps_red, caps_blu, cart_progress_red, cart_progress_blu) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)`
+
+	for index, round := range match.Rounds {
+		if _, errExec := transaction.
+			Exec(ctx, query, match.MatchID, index+1, round.TimeStart, int(round.Length.Seconds()), round.RoundWinner,
+				round.Score.Red, round.Score.Blu, round.KillsRed, round.KillsBlu, round.DamageRed, round.DamageBlu,
+				round.UbersRed, round.UbersBlu, round.CapsRed, round.CapsBlu, round.CartProgressRed,
+				round.CartProgressBlu); errExec != nil {
+			return errors.Wrapf(errExec, "Failed to write match round")
+		}
+	}
+
+	return nil
+}
+
+func (db *Store) saveMatchTimeline(ctx context.Context, transaction pgx.Tx, match *logparse.Match) error {
+	const query = `
+		INSERT INTO match_timeline (
+			match_id, time_offset, kills_red, kills_blu, damage_red, damage_blu, ubers_red, ubers_blu) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+
+	for _, bucket := range match.Timeline {
+		if _, errExec := transaction.
+			Exec(ctx, query, match.MatchID, int(bucket.Offset.Seconds()), bucket.KillsRed, bucket.KillsBlu,
+				bucket.DamageRed, bucket.DamageBlu, bucket.UbersRed, bucket.UbersBlu); errExec != nil {
+			return errors.Wrapf(errExec, "Failed to write match timeline")
+		}
+	}
+
+	return nil
+}
+
 func (db *Store) saveMatchPlayerStats(ctx context.Context, transaction pgx.Tx, match *logparse.Match, stats *logparse.PlayerStats) error {
 	const playerQuery = `
 		INSERT INTO match_player (
@@ -782,8 +1167,9 @@ func (db *Store) saveMatchMedicStats(ctx context.Context, transaction pgx.Tx, ma
 
 func (db *Store) saveMatchWeaponStats(ctx context.Context, transaction pgx.Tx, player *logparse.PlayerStats) error {
 	const query = `
-		INSERT INTO match_weapon (match_player_id, weapon_id, kills, damage, shots, hits, backstabs, headshots, airshots) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) 
+		INSERT INTO match_weapon (match_player_id, weapon_id, kills, damage, shots, hits, backstabs, headshots, airshots,
+		                          distance_kills, distance_sum, distance_square_sum) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) 
 		RETURNING player_weapon_id`
 
 	for weapon, info := range player.WeaponInfo {
@@ -796,7 +1182,8 @@ func (db *Store) saveMatchWeaponStats(ctx context.Context, transaction pgx.Tx, p
 
 		if _, errWeapon := transaction.
 			Exec(ctx, query, player.MatchPlayerID, weaponID, info.Kills, info.Damage, info.Shots, info.Hits,
-				info.BackStabs, info.Headshots, info.Airshots); errWeapon != nil {
+				info.BackStabs, info.Headshots, info.Airshots,
+				info.DistanceKills, info.DistanceSum, info.DistanceSquareSum); errWeapon != nil {
 			return errors.Wrapf(errWeapon, "Failed to write weapon stats")
 		}
 	}
@@ -838,6 +1225,71 @@ func (db *Store) saveMatchKillstreakStats(ctx context.Context, transaction pgx.T
 	return nil
 }
 
+func (db *Store) saveMatchClassKillStats(ctx context.Context, transaction pgx.Tx, player *logparse.PlayerStats) error {
+	const query = `
+		INSERT INTO match_player_class_kill (match_player_id, player_class_id, kills, deaths, assists) 
+		VALUES ($1, $2, $3, $4, $5)`
+
+	classes := map[logparse.PlayerClass]bool{}
+	for _, counts := range []map[logparse.PlayerClass]int{player.ClassKills, player.ClassDeaths, player.ClassKillAssists} {
+		for class := range counts {
+			classes[class] = true
+		}
+	}
+
+	for class := range classes {
+		if _, errExec := transaction.
+			Exec(ctx, query, player.MatchPlayerID, class, player.ClassKills[class], player.ClassDeaths[class],
+				player.ClassKillAssists[class]); errExec != nil {
+			return errors.Wrapf(errExec, "Failed to write player class kill stats")
+		}
+	}
+
+	return nil
+}
+
+func (db *Store) saveMatchHealSpread(ctx context.Context, transaction pgx.Tx, player *logparse.PlayerStats) error {
+	const query = `
+		INSERT INTO match_player_healspread (match_player_id, target_steam_id, healing) 
+		VALUES ($1, $2, $3)`
+
+	for targetID, target := range player.TargetInfo {
+		if target.HealingTaken <= 0 || !targetID.Valid() {
+			continue
+		}
+
+		if _, errExec := transaction.
+			Exec(ctx, query, player.MatchPlayerID, targetID.Int64(), target.HealingTaken); errExec != nil {
+			return errors.Wrapf(errExec, "Failed to write player heal spread")
+		}
+	}
+
+	return nil
+}
+
+func (db *Store) saveMatchObjectiveStats(ctx context.Context, transaction pgx.Tx, player *logparse.PlayerStats) error {
+	const query = `
+		INSERT INTO match_player_objective (
+			match_player_id, ball_grabs, ball_passes, ball_catches, ball_interceptions, ball_steals, ball_blocks, 
+			ball_scores, ball_score_assists, robot_kills, cart_points, koth_cap_time, waves_completed, waves_failed) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`
+
+	if player.Objectives.Total() == 0 {
+		return nil
+	}
+
+	stats := player.Objectives
+
+	if _, errExec := transaction.
+		Exec(ctx, query, player.MatchPlayerID, stats.BallGrabs, stats.BallPasses, stats.BallCatches,
+			stats.BallInterceptions, stats.BallSteals, stats.BallBlocks, stats.BallScores, stats.BallScoreAssists,
+			stats.RobotKills, stats.CartPoints, stats.KothCapTime, stats.WavesCompleted, stats.WavesFailed); errExec != nil {
+		return errors.Wrapf(errExec, "Failed to write player objective stats")
+	}
+
+	return nil
+}
+
 type PlayerClassStats struct {
 	Class              logparse.PlayerClass
 	ClassName          string
@@ -0,0 +1,145 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/gofrs/uuid/v5"
+)
+
+type MatchCheckpointStatus int
+
+const (
+	// MatchCheckpointActive matches are still being played.
+	MatchCheckpointActive MatchCheckpointStatus = iota
+	// MatchCheckpointCompleted matches were saved successfully.
+	MatchCheckpointCompleted
+	// MatchCheckpointExpired matches could not be resumed, or stopped receiving events before completing.
+	MatchCheckpointExpired
+	// MatchCheckpointFailed matches completed but could not be saved.
+	MatchCheckpointFailed
+)
+
+func (s MatchCheckpointStatus) String() string {
+	switch s {
+	case MatchCheckpointActive:
+		return "active"
+	case MatchCheckpointCompleted:
+		return "completed"
+	case MatchCheckpointExpired:
+		return "expired"
+	case MatchCheckpointFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+// MatchCheckpoint holds the raw log lines of an in progress match so that it can be rebuilt if gbans
+// restarts before the match completes.
+type MatchCheckpoint struct {
+	MatchID     uuid.UUID             `json:"match_id"`
+	ServerID    int                   `json:"server_id"`
+	Title       string                `json:"title"`
+	MapName     string                `json:"map_name"`
+	Status      MatchCheckpointStatus `json:"status"`
+	Reason      string                `json:"reason"`
+	Log         []string              `json:"-"`
+	LastEventOn time.Time             `json:"last_event_on"`
+	CreatedOn   time.Time             `json:"created_on"`
+	UpdatedOn   time.Time             `json:"updated_on"`
+}
+
+// SaveMatchCheckpoint creates or updates the checkpoint, appending the new lines to the existing log.
+func (db *Store) SaveMatchCheckpoint(ctx context.Context, checkpoint *MatchCheckpoint, lines []string) error {
+	if lines == nil {
+		lines = []string{}
+	}
+
+	query, args, errQueryArgs := db.sb.
+		Insert("match_checkpoint").
+		Columns("match_id", "server_id", "title", "map_name", "status", "reason", "log", "last_event_on",
+			"created_on", "updated_on").
+		Values(checkpoint.MatchID, checkpoint.ServerID, checkpoint.Title, checkpoint.MapName, checkpoint.Status,
+			checkpoint.Reason, lines, checkpoint.LastEventOn, checkpoint.CreatedOn, checkpoint.UpdatedOn).
+		Suffix(`ON CONFLICT (match_id) DO UPDATE SET title = EXCLUDED.title, map_name = EXCLUDED.map_name,
+			log = match_checkpoint.log || EXCLUDED.log, last_event_on = EXCLUDED.last_event_on,
+			updated_on = EXCLUDED.updated_on`).
+		ToSql()
+	if errQueryArgs != nil {
+		return Err(errQueryArgs)
+	}
+
+	return db.Exec(ctx, query, args...)
+}
+
+// CloseMatchCheckpoint sets the final status of the checkpoint. The log is discarded for completed matches
+// as the match itself has been saved, otherwise it's kept so the match can be inspected or replayed.
+func (db *Store) CloseMatchCheckpoint(ctx context.Context, matchID uuid.UUID, status MatchCheckpointStatus, reason string) error {
+	builder := db.sb.
+		Update("match_checkpoint").
+		Set("status", status).
+		Set("reason", reason).
+		Set("updated_on", time.Now()).
+		Where(sq.Eq{"match_id": matchID})
+
+	if status == MatchCheckpointCompleted {
+		builder = builder.Set("log", []string{})
+	}
+
+	query, args, errQueryArgs := builder.ToSql()
+	if errQueryArgs != nil {
+		return Err(errQueryArgs)
+	}
+
+	return db.Exec(ctx, query, args...)
+}
+
+// GetActiveMatchCheckpoints returns all in progress matches, including their logs.
+func (db *Store) GetActiveMatchCheckpoints(ctx context.Context) ([]MatchCheckpoint, error) {
+	query, args, errQueryArgs := db.sb.
+		Select("match_id", "server_id", "title", "map_name", "status", "reason", "log", "last_event_on",
+			"created_on", "updated_on").
+		From("match_checkpoint").
+		Where(sq.Eq{"status": MatchCheckpointActive}).
+		ToSql()
+	if errQueryArgs != nil {
+		return nil, Err(errQueryArgs)
+	}
+
+	rows, errQuery := db.Query(ctx, query, args...)
+	if errQuery != nil {
+		return nil, Err(errQuery)
+	}
+
+	defer rows.Close()
+
+	checkpoints := []MatchCheckpoint{}
+
+	for rows.Next() {
+		var checkpoint MatchCheckpoint
+		if errScan := rows.Scan(&checkpoint.MatchID, &checkpoint.ServerID, &checkpoint.Title, &checkpoint.MapName,
+			&checkpoint.Status, &checkpoint.Reason, &checkpoint.Log, &checkpoint.LastEventOn,
+			&checkpoint.CreatedOn, &checkpoint.UpdatedOn); errScan != nil {
+			return nil, Err(errScan)
+		}
+
+		checkpoints = append(checkpoints, checkpoint)
+	}
+
+	return checkpoints, nil
+}
+
+// PruneMatchCheckpoints deletes closed checkpoints last updated before the given time.
+func (db *Store) PruneMatchCheckpoints(ctx context.Context, before time.Time) error {
+	query, args, errQueryArgs := db.sb.
+		Delete("match_checkpoint").
+		Where(sq.And{sq.NotEq{"status": MatchCheckpointActive}, sq.Lt{"updated_on": before}}).
+		ToSql()
+	if errQueryArgs != nil {
+		return Err(errQueryArgs)
+	}
+
+	return db.Exec(ctx, query, args...)
+}
@@ -0,0 +1,14 @@
+BEGIN;
+
+ALTER TABLE IF EXISTS filtered_word
+    DROP COLUMN IF EXISTS norm_confusables;
+ALTER TABLE IF EXISTS filtered_word
+    DROP COLUMN IF EXISTS norm_invisible;
+ALTER TABLE IF EXISTS filtered_word
+    DROP COLUMN IF EXISTS norm_leet;
+ALTER TABLE IF EXISTS filtered_word
+    DROP COLUMN IF EXISTS norm_repeats;
+ALTER TABLE IF EXISTS filtered_word
+    DROP COLUMN IF EXISTS norm_spaces;
+
+COMMIT;
@@ -0,0 +1,14 @@
+BEGIN;
+
+ALTER TABLE IF EXISTS filtered_word
+    ADD COLUMN IF NOT EXISTS norm_confusables boolean not null default false;
+ALTER TABLE IF EXISTS filtered_word
+    ADD COLUMN IF NOT EXISTS norm_invisible boolean not null default false;
+ALTER TABLE IF EXISTS filtered_word
+    ADD COLUMN IF NOT EXISTS norm_leet boolean not null default false;
+ALTER TABLE IF EXISTS filtered_word
+    ADD COLUMN IF NOT EXISTS norm_repeats boolean not null default false;
+ALTER TABLE IF EXISTS filtered_word
+    ADD COLUMN IF NOT EXISTS norm_spaces boolean not null default false;
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS person_messages_body_search ON person_messages;
+
+DROP FUNCTION IF EXISTS person_messages_body_search_update();
+
+ALTER TABLE IF EXISTS person_messages
+    DROP COLUMN IF EXISTS body_search;
+
+ALTER TABLE IF EXISTS person_messages
+    ADD COLUMN IF NOT EXISTS message_search tsvector GENERATED ALWAYS AS (to_tsvector('simple', body)) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_message_search ON person_messages USING GIN (message_search);
+
+COMMIT;
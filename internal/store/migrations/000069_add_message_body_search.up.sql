@@ -0,0 +1,31 @@
+BEGIN;
+
+-- Replaced by body_search, which uses the english configuration and is maintained by a trigger. Dropping
+-- a generated column only updates the catalog so the table is not rewritten.
+DROP INDEX IF EXISTS idx_message_search;
+
+ALTER TABLE IF EXISTS person_messages
+    DROP COLUMN IF EXISTS message_search;
+
+-- Nullable with no default so that adding the column does not rewrite the table. Existing rows are
+-- populated by the following backfill migration.
+ALTER TABLE IF EXISTS person_messages
+    ADD COLUMN IF NOT EXISTS body_search tsvector;
+
+CREATE OR REPLACE FUNCTION person_messages_body_search_update() RETURNS trigger AS
+$$
+BEGIN
+    NEW.body_search := to_tsvector('english', coalesce(NEW.body, ''));
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS person_messages_body_search ON person_messages;
+
+CREATE TRIGGER person_messages_body_search
+    BEFORE INSERT OR UPDATE OF body
+    ON person_messages
+    FOR EACH ROW
+EXECUTE FUNCTION person_messages_body_search_update();
+
+COMMIT;
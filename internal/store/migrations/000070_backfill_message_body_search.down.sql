@@ -0,0 +1,2 @@
+-- Nothing to do, the column is removed by the previous migration.
+SELECT 1;
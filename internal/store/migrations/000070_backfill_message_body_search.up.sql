@@ -0,0 +1,26 @@
+-- Backfill the search vectors in small batches, committing after each one so that only the rows
+-- in the current batch are locked at any time. This must remain the only statement in the file
+-- as transaction control is not allowed within a multi statement query.
+DO
+$$
+    DECLARE
+        batch_size CONSTANT bigint := 10000;
+        last_id             bigint := 0;
+        max_id              bigint;
+    BEGIN
+        SELECT coalesce(max(person_message_id), 0) INTO max_id FROM person_messages;
+
+        WHILE last_id < max_id
+            LOOP
+                UPDATE person_messages
+                SET body_search = to_tsvector('english', coalesce(body, ''))
+                WHERE person_message_id > last_id
+                  AND person_message_id <= last_id + batch_size
+                  AND body_search IS NULL;
+
+                last_id := last_id + batch_size;
+
+                COMMIT;
+            END LOOP;
+    END
+$$;
@@ -0,0 +1 @@
+DROP INDEX CONCURRENTLY IF EXISTS idx_person_messages_body_search;
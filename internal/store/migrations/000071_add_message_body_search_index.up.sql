@@ -0,0 +1,2 @@
+-- Must remain the only statement in the file as CONCURRENTLY cannot be used within a transaction.
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_person_messages_body_search ON person_messages USING GIN (body_search);
@@ -0,0 +1,18 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS person_data_export
+(
+    data_export_id bigserial primary key,
+    steam_id       bigint    not null
+        constraint fk_steam_id references person (steam_id) on delete cascade,
+    status         int       not null default 0,
+    error          text      not null default '',
+    contents       bytea,
+    size           bigint    not null default 0,
+    created_on     timestamp not null,
+    updated_on     timestamp not null
+);
+
+CREATE INDEX IF NOT EXISTS idx_person_data_export_steam_id ON person_data_export (steam_id);
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP TABLE IF EXISTS audit_log;
+DROP TABLE IF EXISTS person_erasure;
+
+ALTER TABLE person
+    DROP COLUMN IF EXISTS erased;
+
+COMMIT;
@@ -0,0 +1,43 @@
+BEGIN;
+
+ALTER TABLE person
+    ADD COLUMN IF NOT EXISTS erased boolean default false not null;
+
+CREATE TABLE IF NOT EXISTS person_erasure
+(
+    erasure_id   bigserial primary key,
+    steam_id     bigint                   not null
+        constraint person_erasure_steam_id_fk
+            references person
+            on update cascade on delete restrict,
+    status       int         default 0    not null,
+    reason       text        default ''   not null,
+    reviewer_id  bigint
+        constraint person_erasure_reviewer_id_fk
+            references person
+            on update cascade on delete restrict,
+    review_note  text        default ''   not null,
+    retain_until timestamptz,
+    completed_on timestamptz,
+    created_on   timestamptz              not null,
+    updated_on   timestamptz              not null
+);
+
+CREATE INDEX IF NOT EXISTS person_erasure_steam_id_idx ON person_erasure (steam_id);
+
+CREATE TABLE IF NOT EXISTS audit_log
+(
+    audit_id   bigserial primary key,
+    source_id  bigint
+        constraint audit_log_source_id_fk
+            references person
+            on update cascade on delete restrict,
+    target_id  bigint,
+    action     text                     not null,
+    details    text        default ''   not null,
+    created_on timestamptz              not null
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_target_id_idx ON audit_log (target_id);
+
+COMMIT;
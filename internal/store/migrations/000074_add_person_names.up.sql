@@ -0,0 +1,34 @@
+BEGIN;
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE TABLE IF NOT EXISTS person_names
+(
+    person_name_id bigserial primary key,
+    steam_id       bigint      not null
+        constraint person_names_steam_id_fk
+            references person
+            on update cascade on delete cascade,
+    persona_name   text        not null,
+    first_seen     timestamptz not null,
+    last_seen      timestamptz not null
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS person_names_uindex ON person_names (steam_id, persona_name);
+CREATE INDEX IF NOT EXISTS person_names_trgm_idx ON person_names USING gin (persona_name gin_trgm_ops);
+
+INSERT INTO person_names (steam_id, persona_name, first_seen, last_seen)
+SELECT steam_id, persona_name, min(created_on), max(created_on)
+FROM person_connections
+WHERE persona_name != '' AND steam_id IS NOT NULL
+  AND steam_id NOT IN (SELECT steam_id FROM person WHERE erased = true)
+GROUP BY steam_id, persona_name
+ON CONFLICT DO NOTHING;
+
+INSERT INTO person_names (steam_id, persona_name, first_seen, last_seen)
+SELECT steam_id, personaname, updated_on_steam, updated_on_steam
+FROM person
+WHERE personaname != '' AND erased = false
+ON CONFLICT DO NOTHING;
+
+COMMIT;
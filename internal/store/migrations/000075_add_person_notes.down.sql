@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS person_note_revision;
+DROP TABLE IF EXISTS person_note;
+
+COMMIT;
@@ -0,0 +1,42 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS person_note
+(
+    person_note_id bigserial primary key,
+    steam_id       bigint                 not null
+        constraint person_note_steam_id_fk
+            references person
+            on update cascade on delete cascade,
+    author_id      bigint                 not null
+        constraint person_note_author_id_fk
+            references person
+            on update cascade on delete restrict,
+    body_md        text                   not null,
+    severity       int     default 0      not null,
+    deleted        boolean default false  not null,
+    created_on     timestamptz            not null,
+    updated_on     timestamptz            not null
+);
+
+CREATE INDEX IF NOT EXISTS person_note_steam_id_idx ON person_note (steam_id);
+
+-- Every version of a note, including the initial one, is kept as a revision.
+CREATE TABLE IF NOT EXISTS person_note_revision
+(
+    person_note_revision_id bigserial primary key,
+    person_note_id          bigint      not null
+        constraint person_note_revision_note_id_fk
+            references person_note
+            on update cascade on delete cascade,
+    editor_id               bigint      not null
+        constraint person_note_revision_editor_id_fk
+            references person
+            on update cascade on delete restrict,
+    body_md                 text        not null,
+    severity                int         not null,
+    created_on              timestamptz not null
+);
+
+CREATE INDEX IF NOT EXISTS person_note_revision_note_id_idx ON person_note_revision (person_note_id);
+
+COMMIT;
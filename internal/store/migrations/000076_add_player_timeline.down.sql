@@ -0,0 +1,12 @@
+BEGIN;
+
+DROP TABLE IF EXISTS person_warning;
+DROP INDEX IF EXISTS person_connections_steam_id_idx;
+
+ALTER TABLE person_connections
+    DROP COLUMN IF EXISTS disconnected_on;
+
+ALTER TABLE person_connections
+    DROP COLUMN IF EXISTS server_id;
+
+COMMIT;
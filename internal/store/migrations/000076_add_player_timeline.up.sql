@@ -0,0 +1,36 @@
+BEGIN;
+
+ALTER TABLE person_connections
+    ADD COLUMN IF NOT EXISTS server_id bigint
+        constraint person_connections_server_id_fk
+            references server
+            on update cascade on delete set null;
+
+ALTER TABLE person_connections
+    ADD COLUMN IF NOT EXISTS disconnected_on timestamptz;
+
+CREATE INDEX IF NOT EXISTS person_connections_steam_id_idx ON person_connections (steam_id, created_on);
+
+CREATE TABLE IF NOT EXISTS person_warning
+(
+    person_warning_id bigserial primary key,
+    steam_id          bigint      not null
+        constraint person_warning_steam_id_fk
+            references person
+            on update cascade on delete cascade,
+    person_message_id bigint
+        constraint person_warning_person_message_id_fk
+            references person_messages
+            on update cascade on delete set null,
+    server_id         bigint
+        constraint person_warning_server_id_fk
+            references server
+            on update cascade on delete set null,
+    reason            int         not null,
+    matched           text        not null default '',
+    created_on        timestamptz not null
+);
+
+CREATE INDEX IF NOT EXISTS person_warning_steam_id_idx ON person_warning (steam_id, created_on);
+
+COMMIT;
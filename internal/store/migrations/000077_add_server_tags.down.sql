@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE server
+    DROP COLUMN IF EXISTS tags;
+
+COMMIT;
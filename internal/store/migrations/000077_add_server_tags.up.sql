@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE server
+    ADD COLUMN IF NOT EXISTS tags text[] not null default '{}';
+
+COMMIT;
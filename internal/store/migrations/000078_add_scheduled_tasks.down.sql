@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS scheduled_task_run;
+DROP TABLE IF EXISTS scheduled_task;
+
+COMMIT;
@@ -0,0 +1,38 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS scheduled_task
+(
+    scheduled_task_id bigserial primary key,
+    name              text        not null,
+    cron_expression   text        not null,
+    timezone          text        not null default 'UTC',
+    task_type         int         not null,
+    command           text        not null,
+    server_selector   text        not null,
+    players_below     int,
+    players_at_least  int,
+    alert_on_failure  bool        not null default true,
+    is_enabled        bool        not null default true,
+    last_run          timestamptz,
+    next_run          timestamptz,
+    created_on        timestamptz not null,
+    updated_on        timestamptz not null
+);
+
+CREATE TABLE IF NOT EXISTS scheduled_task_run
+(
+    scheduled_task_run_id bigserial primary key,
+    scheduled_task_id     bigint      not null
+        constraint scheduled_task_run_scheduled_task_id_fk
+            references scheduled_task
+            on update cascade on delete cascade,
+    success               bool        not null,
+    error                 text        not null default '',
+    results               jsonb       not null default '[]',
+    started_on            timestamptz not null,
+    finished_on           timestamptz not null
+);
+
+CREATE INDEX IF NOT EXISTS scheduled_task_run_task_idx ON scheduled_task_run (scheduled_task_id, started_on);
+
+COMMIT;
@@ -0,0 +1,20 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS server_downtime
+(
+    server_downtime_id bigserial primary key,
+    server_id          bigint      not null
+        constraint server_downtime_server_id_fk
+            references server
+            on update cascade on delete cascade,
+    reason             text        not null default '',
+    started_on         timestamptz not null,
+    ended_on           timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS server_downtime_server_id_idx ON server_downtime (server_id, started_on);
+
+-- Only a single open downtime period can exist for a server at a time
+CREATE UNIQUE INDEX IF NOT EXISTS server_downtime_open_uidx ON server_downtime (server_id) WHERE ended_on IS NULL;
+
+COMMIT;
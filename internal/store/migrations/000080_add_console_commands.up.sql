@@ -0,0 +1,23 @@
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS console_command
+(
+    console_command_id bigserial primary key,
+    server_id          bigint      not null
+        constraint console_command_server_id_fk
+            references server
+            on update cascade on delete cascade,
+    steam_id           bigint      not null
+        constraint console_command_steam_id_fk
+            references person
+            on update cascade on delete cascade,
+    command            text        not null,
+    response           text        not null default '',
+    allowed            bool        not null,
+    error              text        not null default '',
+    created_on         timestamptz not null
+);
+
+CREATE INDEX IF NOT EXISTS console_command_server_id_idx ON console_command (server_id, created_on);
+
+COMMIT;
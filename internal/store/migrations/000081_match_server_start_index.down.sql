@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP INDEX IF EXISTS match_server_id_time_start_idx;
+
+COMMIT;
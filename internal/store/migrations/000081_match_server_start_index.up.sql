@@ -0,0 +1,5 @@
+BEGIN;
+
+CREATE INDEX IF NOT EXISTS match_server_id_time_start_idx ON match (server_id, time_start);
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS match_player_healspread;
+DROP TABLE IF EXISTS match_player_class_kill;
+
+COMMIT;
@@ -0,0 +1,23 @@
+BEGIN;
+
+-- Kills, deaths & assists against each opposing class
+CREATE TABLE IF NOT EXISTS match_player_class_kill
+(
+    match_player_id BIGINT  NOT NULL REFERENCES match_player (match_player_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    player_class_id INTEGER NOT NULL REFERENCES player_class (player_class_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    kills           INTEGER NOT NULL DEFAULT 0,
+    deaths          INTEGER NOT NULL DEFAULT 0,
+    assists         INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (match_player_id, player_class_id)
+);
+
+-- Healing done to each target
+CREATE TABLE IF NOT EXISTS match_player_healspread
+(
+    match_player_id BIGINT  NOT NULL REFERENCES match_player (match_player_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    target_steam_id BIGINT  NOT NULL,
+    healing         INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (match_player_id, target_steam_id)
+);
+
+COMMIT;
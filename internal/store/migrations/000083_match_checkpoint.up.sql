@@ -0,0 +1,21 @@
+BEGIN;
+
+-- Raw log lines of in progress matches, used to resume matches after a restart
+CREATE TABLE IF NOT EXISTS match_checkpoint
+(
+    match_id      uuid PRIMARY KEY,
+    server_id     INTEGER                  NOT NULL REFERENCES server (server_id) ON DELETE CASCADE,
+    title         TEXT                     NOT NULL DEFAULT '',
+    map_name      TEXT                     NOT NULL DEFAULT '',
+    status        INTEGER                  NOT NULL DEFAULT 0,
+    reason        TEXT                     NOT NULL DEFAULT '',
+    log           TEXT[]                   NOT NULL DEFAULT '{}',
+    last_event_on TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_on    TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_on    TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Only a single match can be in progress per server
+CREATE UNIQUE INDEX IF NOT EXISTS match_checkpoint_active_uindex ON match_checkpoint (server_id) WHERE status = 0;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS match_timeline;
+DROP TABLE IF EXISTS match_round;
+
+COMMIT;
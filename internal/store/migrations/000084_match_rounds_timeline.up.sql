@@ -0,0 +1,38 @@
+BEGIN;
+
+-- Team totals for each round of a match
+CREATE TABLE IF NOT EXISTS match_round
+(
+    match_id   uuid                     NOT NULL REFERENCES match (match_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    round      INTEGER                  NOT NULL,
+    time_start timestamp with time zone NOT NULL,
+    length     INTEGER                  NOT NULL DEFAULT 0,
+    winner     INTEGER                  NOT NULL DEFAULT 0,
+    score_red  INTEGER                  NOT NULL DEFAULT 0,
+    score_blu  INTEGER                  NOT NULL DEFAULT 0,
+    kills_red  INTEGER                  NOT NULL DEFAULT 0,
+    kills_blu  INTEGER                  NOT NULL DEFAULT 0,
+    damage_red INTEGER                  NOT NULL DEFAULT 0,
+    damage_blu INTEGER                  NOT NULL DEFAULT 0,
+    ubers_red  REAL                     NOT NULL DEFAULT 0,
+    ubers_blu  REAL                     NOT NULL DEFAULT 0,
+    caps_red   INTEGER                  NOT NULL DEFAULT 0,
+    caps_blu   INTEGER                  NOT NULL DEFAULT 0,
+    PRIMARY KEY (match_id, round)
+);
+
+-- Team totals bucketed by time since the start of the match, time_offset is in seconds
+CREATE TABLE IF NOT EXISTS match_timeline
+(
+    match_id    uuid    NOT NULL REFERENCES match (match_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    time_offset INTEGER NOT NULL,
+    kills_red   INTEGER NOT NULL DEFAULT 0,
+    kills_blu   INTEGER NOT NULL DEFAULT 0,
+    damage_red  INTEGER NOT NULL DEFAULT 0,
+    damage_blu  INTEGER NOT NULL DEFAULT 0,
+    ubers_red   REAL    NOT NULL DEFAULT 0,
+    ubers_blu   REAL    NOT NULL DEFAULT 0,
+    PRIMARY KEY (match_id, time_offset)
+);
+
+COMMIT;
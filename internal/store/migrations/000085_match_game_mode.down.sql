@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TABLE IF EXISTS match_player_objective;
+
+ALTER TABLE match
+    DROP COLUMN IF EXISTS game_mode;
+
+COMMIT;
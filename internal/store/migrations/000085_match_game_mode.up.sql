@@ -0,0 +1,22 @@
+BEGIN;
+
+ALTER TABLE match
+    ADD COLUMN IF NOT EXISTS game_mode INTEGER NOT NULL DEFAULT 0;
+
+-- Game mode specific objective totals, only written for players with at least one non-zero value
+CREATE TABLE IF NOT EXISTS match_player_objective
+(
+    match_player_id    BIGINT  NOT NULL PRIMARY KEY
+        REFERENCES match_player (match_player_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    ball_grabs         INTEGER NOT NULL DEFAULT 0,
+    ball_passes        INTEGER NOT NULL DEFAULT 0,
+    ball_catches       INTEGER NOT NULL DEFAULT 0,
+    ball_interceptions INTEGER NOT NULL DEFAULT 0,
+    ball_steals        INTEGER NOT NULL DEFAULT 0,
+    ball_blocks        INTEGER NOT NULL DEFAULT 0,
+    ball_scores        INTEGER NOT NULL DEFAULT 0,
+    ball_score_assists INTEGER NOT NULL DEFAULT 0,
+    robot_kills        INTEGER NOT NULL DEFAULT 0
+);
+
+COMMIT;
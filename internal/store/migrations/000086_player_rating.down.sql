@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS player_rating_history;
+DROP TABLE IF EXISTS player_rating;
+
+COMMIT;
@@ -0,0 +1,41 @@
+BEGIN;
+
+-- Current glicko-2 rating of each player. game_type is -1 for ratings across all formats and player_class
+-- is 0 for ratings across all classes.
+CREATE TABLE IF NOT EXISTS player_rating
+(
+    steam_id     BIGINT                   NOT NULL REFERENCES person (steam_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    game_type    INTEGER                  NOT NULL,
+    player_class INTEGER                  NOT NULL,
+    rating       DOUBLE PRECISION         NOT NULL,
+    deviation    DOUBLE PRECISION         NOT NULL,
+    volatility   DOUBLE PRECISION         NOT NULL,
+    matches      INTEGER                  NOT NULL DEFAULT 0,
+    wins         INTEGER                  NOT NULL DEFAULT 0,
+    losses       INTEGER                  NOT NULL DEFAULT 0,
+    draws        INTEGER                  NOT NULL DEFAULT 0,
+    updated_on   timestamp with time zone NOT NULL,
+    PRIMARY KEY (steam_id, game_type, player_class)
+);
+
+CREATE INDEX IF NOT EXISTS player_rating_leaderboard_idx ON player_rating (game_type, player_class, (rating - 2 * deviation) DESC);
+
+-- Rating of the player after each rated match
+CREATE TABLE IF NOT EXISTS player_rating_history
+(
+    player_rating_history_id BIGSERIAL PRIMARY KEY,
+    match_id                 uuid                     NOT NULL REFERENCES match (match_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    steam_id                 BIGINT                   NOT NULL REFERENCES person (steam_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    game_type                INTEGER                  NOT NULL,
+    player_class             INTEGER                  NOT NULL,
+    rating                   DOUBLE PRECISION         NOT NULL,
+    deviation                DOUBLE PRECISION         NOT NULL,
+    volatility               DOUBLE PRECISION         NOT NULL,
+    rating_change            DOUBLE PRECISION         NOT NULL,
+    created_on               timestamp with time zone NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS player_rating_history_match_uidx ON player_rating_history (match_id, steam_id, game_type, player_class);
+CREATE INDEX IF NOT EXISTS player_rating_history_player_idx ON player_rating_history (steam_id, game_type, player_class, created_on);
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP TABLE IF EXISTS anomaly;
+
+ALTER TABLE match_weapon
+    DROP COLUMN IF EXISTS distance_kills,
+    DROP COLUMN IF EXISTS distance_sum,
+    DROP COLUMN IF EXISTS distance_square_sum;
+
+COMMIT;
@@ -0,0 +1,32 @@
+BEGIN;
+
+-- Kill distance sums used to build the population kill distance baselines of each weapon
+ALTER TABLE match_weapon
+    ADD COLUMN IF NOT EXISTS distance_kills      INTEGER          NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS distance_sum        DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS distance_square_sum DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+-- Statistically unlikely weapon stats of a player in a match awaiting moderator review
+CREATE TABLE IF NOT EXISTS anomaly
+(
+    anomaly_id     BIGSERIAL PRIMARY KEY,
+    match_id       uuid                     NOT NULL REFERENCES match (match_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    steam_id       BIGINT                   NOT NULL REFERENCES person (steam_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    weapon_id      INTEGER                  NOT NULL REFERENCES weapon (weapon_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    metric         INTEGER                  NOT NULL,
+    value          DOUBLE PRECISION         NOT NULL,
+    baseline       DOUBLE PRECISION         NOT NULL,
+    score          DOUBLE PRECISION         NOT NULL,
+    samples        INTEGER                  NOT NULL,
+    explanation    TEXT                     NOT NULL,
+    anomaly_status INTEGER                  NOT NULL DEFAULT 0,
+    report_id      INTEGER REFERENCES report (report_id) ON DELETE SET NULL,
+    created_on     timestamp with time zone NOT NULL,
+    updated_on     timestamp with time zone NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS anomaly_match_uidx ON anomaly (match_id, steam_id, weapon_id, metric);
+CREATE INDEX IF NOT EXISTS anomaly_status_idx ON anomaly (anomaly_status, created_on DESC);
+CREATE INDEX IF NOT EXISTS anomaly_player_idx ON anomaly (steam_id);
+
+COMMIT;
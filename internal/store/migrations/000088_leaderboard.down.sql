@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TABLE IF EXISTS leaderboard_announcement;
+DROP TABLE IF EXISTS leaderboard_weapon;
+DROP TABLE IF EXISTS leaderboard_player;
+DROP TABLE IF EXISTS leaderboard_match;
+
+COMMIT;
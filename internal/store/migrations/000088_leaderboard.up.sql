@@ -0,0 +1,61 @@
+BEGIN;
+
+-- Matches which have been added to the leaderboards, so a match is never counted twice
+CREATE TABLE IF NOT EXISTS leaderboard_match
+(
+    match_id   uuid PRIMARY KEY REFERENCES match (match_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    created_on timestamp with time zone NOT NULL
+);
+
+-- Player totals for each leaderboard period. server_id is 0 and region is empty for the totals across all
+-- servers, rows for previous periods are kept to track movement between periods.
+CREATE TABLE IF NOT EXISTS leaderboard_player
+(
+    period       INTEGER                  NOT NULL,
+    period_start timestamp with time zone NOT NULL,
+    server_id    INTEGER                  NOT NULL DEFAULT 0,
+    region       TEXT                     NOT NULL DEFAULT '',
+    steam_id     BIGINT                   NOT NULL REFERENCES person (steam_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    matches      INTEGER                  NOT NULL DEFAULT 0,
+    wins         INTEGER                  NOT NULL DEFAULT 0,
+    kills        INTEGER                  NOT NULL DEFAULT 0,
+    assists      INTEGER                  NOT NULL DEFAULT 0,
+    deaths       INTEGER                  NOT NULL DEFAULT 0,
+    damage       BIGINT                   NOT NULL DEFAULT 0,
+    damage_taken BIGINT                   NOT NULL DEFAULT 0,
+    healing      BIGINT                   NOT NULL DEFAULT 0,
+    playtime     BIGINT                   NOT NULL DEFAULT 0,
+    updated_on   timestamp with time zone NOT NULL,
+    PRIMARY KEY (period, period_start, server_id, region, steam_id)
+);
+
+-- Per weapon player totals for each leaderboard period
+CREATE TABLE IF NOT EXISTS leaderboard_weapon
+(
+    period       INTEGER                  NOT NULL,
+    period_start timestamp with time zone NOT NULL,
+    server_id    INTEGER                  NOT NULL DEFAULT 0,
+    region       TEXT                     NOT NULL DEFAULT '',
+    steam_id     BIGINT                   NOT NULL REFERENCES person (steam_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    weapon_id    INTEGER                  NOT NULL REFERENCES weapon (weapon_id) ON DELETE CASCADE ON UPDATE CASCADE,
+    kills        INTEGER                  NOT NULL DEFAULT 0,
+    damage       BIGINT                   NOT NULL DEFAULT 0,
+    shots        INTEGER                  NOT NULL DEFAULT 0,
+    hits         INTEGER                  NOT NULL DEFAULT 0,
+    headshots    INTEGER                  NOT NULL DEFAULT 0,
+    airshots     INTEGER                  NOT NULL DEFAULT 0,
+    backstabs    INTEGER                  NOT NULL DEFAULT 0,
+    updated_on   timestamp with time zone NOT NULL,
+    PRIMARY KEY (period, period_start, server_id, region, weapon_id, steam_id)
+);
+
+-- Periods which have had their winners announced
+CREATE TABLE IF NOT EXISTS leaderboard_announcement
+(
+    period       INTEGER                  NOT NULL,
+    period_start timestamp with time zone NOT NULL,
+    created_on   timestamp with time zone NOT NULL,
+    PRIMARY KEY (period, period_start)
+);
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP INDEX IF EXISTS match_map_idx;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+-- Map statistics filter every match table by the map
+CREATE INDEX IF NOT EXISTS match_map_idx ON match (map);
+
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+
+ALTER TABLE match_player_objective
+    DROP COLUMN IF EXISTS cart_points,
+    DROP COLUMN IF EXISTS koth_cap_time,
+    DROP COLUMN IF EXISTS waves_completed,
+    DROP COLUMN IF EXISTS waves_failed;
+
+ALTER TABLE match_round
+    DROP COLUMN IF EXISTS cart_progress_red,
+    DROP COLUMN IF EXISTS cart_progress_blu;
+
+COMMIT;
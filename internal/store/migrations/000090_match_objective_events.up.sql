@@ -0,0 +1,14 @@
+BEGIN;
+
+-- Objective events logged by the gbans plugin
+ALTER TABLE match_player_objective
+    ADD COLUMN IF NOT EXISTS cart_points     INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS koth_cap_time   INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS waves_completed INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS waves_failed    INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE match_round
+    ADD COLUMN IF NOT EXISTS cart_progress_red REAL    NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS cart_progress_blu REAL    NOT NULL DEFAULT 0;
+
+COMMIT;
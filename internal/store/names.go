@@ -0,0 +1,123 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+)
+
+// PersonName is a unique name a player has been seen using.
+type PersonName struct {
+	PersonNameID int64         `json:"person_name_id"`
+	SteamID      steamid.SID64 `json:"steam_id"`
+	PersonaName  string        `json:"persona_name"`
+	FirstSeen    time.Time     `json:"first_seen"`
+	LastSeen     time.Time     `json:"last_seen"`
+}
+
+// AddPersonName records the name as being seen at the provided time, extending the first and last seen
+// range of an existing entry. Names are never recorded for erased players.
+func (db *Store) AddPersonName(ctx context.Context, steamID steamid.SID64, name string, seen time.Time) error {
+	const query = `
+		INSERT INTO person_names (steam_id, persona_name, first_seen, last_seen)
+		SELECT $1, $2, $3, $3
+		WHERE NOT EXISTS (SELECT 1 FROM person WHERE steam_id = $1 AND erased = true)
+		ON CONFLICT (steam_id, persona_name) DO UPDATE
+		SET first_seen = least(person_names.first_seen, excluded.first_seen),
+		    last_seen = greatest(person_names.last_seen, excluded.last_seen)`
+
+	return db.Exec(ctx, query, steamID.Int64(), name, seen)
+}
+
+// GetPersonNames returns the players name history, most recently used first.
+func (db *Store) GetPersonNames(ctx context.Context, steamID steamid.SID64) ([]PersonName, error) {
+	query, args, errQueryArgs := db.sb.
+		Select("person_name_id", "steam_id", "persona_name", "first_seen", "last_seen").
+		From("person_names").
+		Where(sq.Eq{"steam_id": steamID.Int64()}).
+		OrderBy("last_seen DESC").
+		ToSql()
+	if errQueryArgs != nil {
+		return nil, Err(errQueryArgs)
+	}
+
+	rows, errQuery := db.Query(ctx, query, args...)
+	if errQuery != nil {
+		return nil, Err(errQuery)
+	}
+
+	defer rows.Close()
+
+	var names []PersonName
+
+	for rows.Next() {
+		var (
+			name    PersonName
+			steamID int64
+		)
+
+		if errScan := rows.Scan(&name.PersonNameID, &steamID, &name.PersonaName, &name.FirstSeen,
+			&name.LastSeen); errScan != nil {
+			return nil, Err(errScan)
+		}
+
+		name.SteamID = steamid.New(steamID)
+
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+type PersonNameSearchResult struct {
+	PersonName
+	Similarity float32 `json:"similarity"`
+}
+
+// SearchPersonNames finds players who have used a name similar to, or containing, the query. Results are
+// ordered by trigram similarity.
+func (db *Store) SearchPersonNames(ctx context.Context, name string, limit uint64) ([]PersonNameSearchResult, error) {
+	query, args, errQueryArgs := db.sb.
+		Select("person_name_id", "steam_id", "persona_name", "first_seen", "last_seen").
+		Column(sq.Alias(sq.Expr("similarity(persona_name, ?)", name), "score")).
+		From("person_names").
+		Where(sq.Or{
+			sq.Expr("persona_name % ?", name),
+			sq.ILike{"persona_name": "%" + name + "%"},
+		}).
+		OrderBy("score DESC", "last_seen DESC").
+		Limit(limit).
+		ToSql()
+	if errQueryArgs != nil {
+		return nil, Err(errQueryArgs)
+	}
+
+	rows, errQuery := db.Query(ctx, query, args...)
+	if errQuery != nil {
+		return nil, Err(errQuery)
+	}
+
+	defer rows.Close()
+
+	var results []PersonNameSearchResult
+
+	for rows.Next() {
+		var (
+			result  PersonNameSearchResult
+			steamID int64
+		)
+
+		if errScan := rows.Scan(&result.PersonNameID, &steamID, &result.PersonaName, &result.FirstSeen,
+			&result.LastSeen, &result.Similarity); errScan != nil {
+			return nil, Err(errScan)
+		}
+
+		result.SteamID = steamid.New(steamID)
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
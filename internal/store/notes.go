@@ -0,0 +1,247 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+type NoteSeverity int
+
+const (
+	NoteInfo NoteSeverity = iota
+	NoteWarning
+	NoteCritical
+)
+
+func (severity NoteSeverity) String() string {
+	switch severity {
+	case NoteWarning:
+		return "Warning"
+	case NoteCritical:
+		return "Critical"
+	default:
+		return "Info"
+	}
+}
+
+// PersonNote is a private moderator note attached to a player. These must never be shown to non-moderators.
+type PersonNote struct {
+	PersonNoteID int64         `json:"person_note_id"`
+	SteamID      steamid.SID64 `json:"steam_id"`
+	AuthorID     steamid.SID64 `json:"author_id"`
+	AuthorName   string        `json:"author_name"`
+	BodyMD       string        `json:"body_md"`
+	Severity     NoteSeverity  `json:"severity"`
+	Deleted      bool          `json:"deleted"`
+	// Revisions is the total number of versions of the note, including the initial version.
+	Revisions int       `json:"revisions"`
+	CreatedOn time.Time `json:"created_on"`
+	UpdatedOn time.Time `json:"updated_on"`
+}
+
+// PersonNoteRevision is a single version of a note.
+type PersonNoteRevision struct {
+	PersonNoteRevisionID int64         `json:"person_note_revision_id"`
+	PersonNoteID         int64         `json:"person_note_id"`
+	EditorID             steamid.SID64 `json:"editor_id"`
+	EditorName           string        `json:"editor_name"`
+	BodyMD               string        `json:"body_md"`
+	Severity             NoteSeverity  `json:"severity"`
+	CreatedOn            time.Time     `json:"created_on"`
+}
+
+func NewPersonNote(steamID steamid.SID64, authorID steamid.SID64, body string, severity NoteSeverity) PersonNote {
+	now := time.Now()
+
+	return PersonNote{
+		SteamID:   steamID,
+		AuthorID:  authorID,
+		BodyMD:    body,
+		Severity:  severity,
+		CreatedOn: now,
+		UpdatedOn: now,
+	}
+}
+
+// SavePersonNote creates or updates the note. Each save also records a new revision attributed to the editor.
+func (db *Store) SavePersonNote(ctx context.Context, note *PersonNote, editorID steamid.SID64) error {
+	note.UpdatedOn = time.Now()
+
+	transaction, errTx := db.conn.Begin(ctx)
+	if errTx != nil {
+		return errors.Wrap(errTx, "Failed to create tx")
+	}
+
+	rollback := func(err error) error {
+		if errRollback := transaction.Rollback(ctx); errRollback != nil {
+			db.log.Error("Failed to rollback tx", zap.Error(errRollback))
+		}
+
+		return Err(err)
+	}
+
+	if note.PersonNoteID > 0 {
+		if _, errExec := transaction.Exec(ctx,
+			`UPDATE person_note SET body_md = $2, severity = $3, deleted = $4, updated_on = $5 WHERE person_note_id = $1`,
+			note.PersonNoteID, note.BodyMD, note.Severity, note.Deleted, note.UpdatedOn); errExec != nil {
+			return rollback(errExec)
+		}
+	} else {
+		if errQuery := transaction.QueryRow(ctx,
+			`INSERT INTO person_note (steam_id, author_id, body_md, severity, deleted, created_on, updated_on)
+			VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING person_note_id`,
+			note.SteamID.Int64(), note.AuthorID.Int64(), note.BodyMD, note.Severity, note.Deleted, note.CreatedOn,
+			note.UpdatedOn).Scan(&note.PersonNoteID); errQuery != nil {
+			return rollback(errQuery)
+		}
+	}
+
+	if _, errExec := transaction.Exec(ctx,
+		`INSERT INTO person_note_revision (person_note_id, editor_id, body_md, severity, created_on)
+		VALUES ($1, $2, $3, $4, $5)`,
+		note.PersonNoteID, editorID.Int64(), note.BodyMD, note.Severity, note.UpdatedOn); errExec != nil {
+		return rollback(errExec)
+	}
+
+	note.Revisions++
+
+	return errors.Wrap(transaction.Commit(ctx), "Failed to commit tx")
+}
+
+func (db *Store) DeletePersonNote(ctx context.Context, personNoteID int64) error {
+	query, args, errQueryArgs := db.sb.
+		Update("person_note").
+		Set("deleted", true).
+		Set("updated_on", time.Now()).
+		Where(sq.Eq{"person_note_id": personNoteID}).
+		ToSql()
+	if errQueryArgs != nil {
+		return Err(errQueryArgs)
+	}
+
+	return db.Exec(ctx, query, args...)
+}
+
+func (db *Store) personNoteQuery() sq.SelectBuilder {
+	return db.sb.
+		Select("n.person_note_id", "n.steam_id", "n.author_id", "a.personaname", "n.body_md", "n.severity",
+			"n.deleted", "n.created_on", "n.updated_on",
+			"(SELECT count(*) FROM person_note_revision r WHERE r.person_note_id = n.person_note_id)").
+		From("person_note n").
+		Join("person a ON a.steam_id = n.author_id")
+}
+
+func scanPersonNote(row pgx.Row) (PersonNote, error) {
+	var (
+		note     PersonNote
+		steamID  int64
+		authorID int64
+	)
+
+	if errScan := row.Scan(&note.PersonNoteID, &steamID, &authorID, &note.AuthorName, &note.BodyMD, &note.Severity,
+		&note.Deleted, &note.CreatedOn, &note.UpdatedOn, &note.Revisions); errScan != nil {
+		return note, Err(errScan)
+	}
+
+	note.SteamID = steamid.New(steamID)
+	note.AuthorID = steamid.New(authorID)
+
+	return note, nil
+}
+
+func (db *Store) GetPersonNote(ctx context.Context, personNoteID int64, note *PersonNote) error {
+	query, args, errQueryArgs := db.personNoteQuery().
+		Where(sq.Eq{"n.person_note_id": personNoteID}).
+		ToSql()
+	if errQueryArgs != nil {
+		return Err(errQueryArgs)
+	}
+
+	result, errScan := scanPersonNote(db.QueryRow(ctx, query, args...))
+	if errScan != nil {
+		return errScan
+	}
+
+	*note = result
+
+	return nil
+}
+
+// GetPersonNotes returns all non-deleted notes for the player, newest first.
+func (db *Store) GetPersonNotes(ctx context.Context, steamID steamid.SID64) ([]PersonNote, error) {
+	query, args, errQueryArgs := db.personNoteQuery().
+		Where(sq.Eq{"n.steam_id": steamID.Int64(), "n.deleted": false}).
+		OrderBy("n.created_on DESC").
+		ToSql()
+	if errQueryArgs != nil {
+		return nil, Err(errQueryArgs)
+	}
+
+	rows, errQuery := db.Query(ctx, query, args...)
+	if errQuery != nil {
+		return nil, Err(errQuery)
+	}
+
+	defer rows.Close()
+
+	var notes []PersonNote
+
+	for rows.Next() {
+		note, errScan := scanPersonNote(rows)
+		if errScan != nil {
+			return nil, errScan
+		}
+
+		notes = append(notes, note)
+	}
+
+	return notes, nil
+}
+
+// GetPersonNoteRevisions returns the edit history of a note, oldest first.
+func (db *Store) GetPersonNoteRevisions(ctx context.Context, personNoteID int64) ([]PersonNoteRevision, error) {
+	query, args, errQueryArgs := db.sb.
+		Select("r.person_note_revision_id", "r.person_note_id", "r.editor_id", "e.personaname", "r.body_md",
+			"r.severity", "r.created_on").
+		From("person_note_revision r").
+		Join("person e ON e.steam_id = r.editor_id").
+		Where(sq.Eq{"r.person_note_id": personNoteID}).
+		OrderBy("r.person_note_revision_id").
+		ToSql()
+	if errQueryArgs != nil {
+		return nil, Err(errQueryArgs)
+	}
+
+	rows, errQuery := db.Query(ctx, query, args...)
+	if errQuery != nil {
+		return nil, Err(errQuery)
+	}
+
+	defer rows.Close()
+
+	var revisions []PersonNoteRevision
+
+	for rows.Next() {
+		var (
+			revision PersonNoteRevision
+			editorID int64
+		)
+
+		if errScan := rows.Scan(&revision.PersonNoteRevisionID, &revision.PersonNoteID, &editorID,
+			&revision.EditorName, &revision.BodyMD, &revision.Severity, &revision.CreatedOn); errScan != nil {
+			return nil, Err(errScan)
+		}
+
+		revision.EditorID = steamid.New(editorID)
+
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, nil
+}
@@ -3,6 +3,8 @@ package store
 import (
 	"context"
 	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"net"
@@ -37,6 +39,7 @@ type Person struct {
 	UpdatedOn        time.Time             `json:"updated_on"`
 	PermissionLevel  consts.Privilege      `json:"permission_level"`
 	Muted            bool                  `json:"muted"`
+	Erased           bool                  `json:"erased"`
 	IsNew            bool                  `json:"-"`
 	DiscordID        string                `json:"discord_id"`
 	IPAddr           net.IP                `json:"-"` // TODO Allow json for admins endpoints
@@ -208,6 +211,7 @@ type PersonConnection struct {
 	IPAddr             net.IP        `json:"ip_addr"`
 	SteamID            steamid.SID64 `json:"steam_id"`
 	PersonaName        string        `json:"persona_name"`
+	ServerID           int           `json:"server_id"`
 	CreatedOn          time.Time     `json:"created_on"`
 }
 
@@ -229,22 +233,14 @@ type PersonMessage struct {
 
 type PersonMessages []PersonMessage
 
-func (db *Store) DropPerson(ctx context.Context, steamID steamid.SID64) error {
-	query, args, errQueryArgs := db.sb.Delete("person").Where(sq.Eq{"steam_id": steamID.Int64()}).ToSql()
-	if errQueryArgs != nil {
-		return errors.Wrapf(errQueryArgs, "Failed to create query")
-	}
-
-	if errExec := db.Exec(ctx, query, args...); errExec != nil {
-		return Err(errExec)
-	}
-
-	return nil
-}
-
 // SavePerson will insert or update the person record.
 func (db *Store) SavePerson(ctx context.Context, person *Person) error {
 	person.UpdatedOn = time.Now()
+
+	if person.Erased {
+		// Never allow updated steam profile data to be written back over an erased identity
+		person.anonymize()
+	}
 	// FIXME
 	if person.PermissionLevel == 0 {
 		person.PermissionLevel = 10
@@ -325,7 +321,7 @@ var profileColumns = []string{ //nolint:gochecknoglobals
 	"avatarmedium", "avatarfull", "avatarhash", "personastate", "realname", "timecreated",
 	"loccountrycode", "locstatecode", "loccityid", "permission_level", "discord_id",
 	"community_banned", "vac_bans", "game_bans", "economy_ban", "days_since_last_ban", "updated_on_steam",
-	"muted",
+	"muted", "erased",
 }
 
 // GetPersonBySteamID returns a person by their steam_id. ErrNoResult is returned if the steam_id
@@ -364,7 +360,8 @@ func (db *Store) GetPersonBySteamID(ctx context.Context, sid64 steamid.SID64, pe
 			p.economy_ban,
 			p.days_since_last_ban,
 			p.updated_on_steam,
-			p.muted
+			p.muted,
+			p.erased
 	FROM person p
 	WHERE p.steam_id = $1;`
 
@@ -384,7 +381,7 @@ func (db *Store) GetPersonBySteamID(ctx context.Context, sid64 steamid.SID64, pe
 			&person.PersonaState, &person.RealName, &person.TimeCreated, &person.LocCountryCode, &person.LocStateCode,
 			&person.LocCityID, &person.PermissionLevel, &person.DiscordID /*&person.IPAddr,*/, &person.CommunityBanned,
 			&person.VACBans, &person.GameBans, &person.EconomyBan, &person.DaysSinceLastBan, &person.UpdatedOnSteam,
-			&person.Muted)
+			&person.Muted, &person.Erased)
 	if errQuery != nil {
 		return Err(errQuery)
 	}
@@ -428,7 +425,7 @@ func (db *Store) GetPeopleBySteamID(ctx context.Context, steamIds steamid.Collec
 			&person.AvatarFull, &person.AvatarHash, &person.PersonaState, &person.RealName, &person.TimeCreated,
 			&person.LocCountryCode, &person.LocStateCode, &person.LocCityID, &person.PermissionLevel, &person.DiscordID,
 			&person.CommunityBanned, &person.VACBans, &person.GameBans, &person.EconomyBan, &person.DaysSinceLastBan,
-			&person.UpdatedOnSteam, &person.Muted); errScan != nil {
+			&person.UpdatedOnSteam, &person.Muted, &person.Erased); errScan != nil {
 			return nil, errors.Wrapf(errScan, "Failedto scan person")
 		}
 
@@ -488,7 +485,7 @@ func (db *Store) GetPeople(ctx context.Context, queryFilter QueryFilter) (People
 				&person.RealName, &person.TimeCreated, &person.LocCountryCode, &person.LocStateCode,
 				&person.LocCityID, &person.PermissionLevel, &person.DiscordID, &person.CommunityBanned,
 				&person.VACBans, &person.GameBans, &person.EconomyBan, &person.DaysSinceLastBan,
-				&person.UpdatedOnSteam, &person.Muted); errScan != nil {
+				&person.UpdatedOnSteam, &person.Muted, &person.Erased); errScan != nil {
 			return nil, errors.Wrapf(errScan, "Failed to scan person")
 		}
 
@@ -535,7 +532,7 @@ func (db *Store) GetPersonByDiscordID(ctx context.Context, discordID string, per
 		&person.ProfileURL, &person.Avatar, &person.AvatarMedium, &person.AvatarFull, &person.AvatarHash,
 		&person.PersonaState, &person.RealName, &person.TimeCreated, &person.LocCountryCode, &person.LocStateCode,
 		&person.LocCityID, &person.PermissionLevel, &person.DiscordID, &person.CommunityBanned, &person.VACBans,
-		&person.GameBans, &person.EconomyBan, &person.DaysSinceLastBan, &person.UpdatedOnSteam, &person.Muted)
+		&person.GameBans, &person.EconomyBan, &person.DaysSinceLastBan, &person.UpdatedOnSteam, &person.Muted, &person.Erased)
 	if errQuery != nil {
 		return Err(errQuery)
 	}
@@ -549,6 +546,7 @@ func (db *Store) GetExpiredProfiles(ctx context.Context, limit uint64) ([]Person
 	query, args, errArgs := db.sb.
 		Select(profileColumns...).
 		From("person").
+		Where(sq.Eq{"erased": false}).
 		OrderBy("updated_on_steam").
 		Limit(limit).
 		ToSql()
@@ -576,7 +574,7 @@ func (db *Store) GetExpiredProfiles(ctx context.Context, limit uint64) ([]Person
 			&person.AvatarFull, &person.AvatarHash, &person.PersonaState, &person.RealName, &person.TimeCreated,
 			&person.LocCountryCode, &person.LocStateCode, &person.LocCityID, &person.PermissionLevel,
 			&person.DiscordID, &person.CommunityBanned, &person.VACBans, &person.GameBans,
-			&person.EconomyBan, &person.DaysSinceLastBan, &person.UpdatedOnSteam, &person.Muted); errScan != nil {
+			&person.EconomyBan, &person.DaysSinceLastBan, &person.UpdatedOnSteam, &person.Muted, &person.Erased); errScan != nil {
 			return nil, Err(errScan)
 		}
 
@@ -715,7 +713,7 @@ func (db *Store) QueryConnectionHistory(ctx context.Context, query ConnectionHis
 	}
 	//
 	// if query.Query != "" {
-	//	ands = append(ands, sq.Expr(`message_search @@ websearch_to_tsquery('simple', ?)`, query.Query))
+	//	ands = append(ands, sq.Expr(`body_search @@ websearch_to_tsquery('english', ?)`, query.Query))
 	// }
 
 	count = count.Where(ands)
@@ -787,14 +785,67 @@ type ChatHistoryQueryFilter struct {
 	Unrestricted  bool       `json:"-"`
 	DontCalcTotal bool       `json:"-"`
 	FlaggedOnly   bool       `json:"flagged_only"`
+	// Cursor continues from the last result of a previous page. When set, Offset is ignored.
+	Cursor string `json:"cursor,omitempty"`
 }
 
 type QueryChatHistoryResult struct {
 	PersonMessage
 	AutoFilterFlagged bool `json:"auto_filter_flagged"`
+	// Rank is the full-text search relevance. Only set when searching.
+	Rank float32 `json:"rank,omitempty"`
+	// Headline is the message body with the matched terms wrapped in <mark></mark> tags. Only set when searching.
+	Headline string `json:"headline,omitempty"`
 }
 
-const minQueryLen = 2
+// ChatHistoryCursor holds the position of the last returned result for keyset pagination of chat history.
+type ChatHistoryCursor struct {
+	PersonMessageID int64     `json:"id"`
+	CreatedOn       time.Time `json:"created_on"`
+	Rank            float32   `json:"rank"`
+}
+
+// NewChatHistoryCursor creates a cursor pointing at the message.
+func NewChatHistoryCursor(message QueryChatHistoryResult) ChatHistoryCursor {
+	return ChatHistoryCursor{
+		PersonMessageID: message.PersonMessageID,
+		CreatedOn:       message.CreatedOn,
+		Rank:            message.Rank,
+	}
+}
+
+// Encode returns the opaque string form of the cursor used by clients.
+func (c ChatHistoryCursor) Encode() string {
+	body, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(body)
+}
+
+func decodeChatHistoryCursor(value string) (ChatHistoryCursor, error) {
+	var cursor ChatHistoryCursor
+
+	body, errDecode := base64.RawURLEncoding.DecodeString(value)
+	if errDecode != nil {
+		return cursor, errors.Wrap(errDecode, "Invalid cursor")
+	}
+
+	if errUnmarshal := json.Unmarshal(body, &cursor); errUnmarshal != nil {
+		return cursor, errors.Wrap(errUnmarshal, "Invalid cursor")
+	}
+
+	return cursor, nil
+}
+
+const (
+	minQueryLen = 2
+	// chatSearchConfig is the text search configuration used for the body_search column. Queries
+	// must use the same configuration for stemming to match.
+	chatSearchConfig = "english"
+	// chatSearchRank is the select alias for the search relevance.
+	chatSearchRank = "search_rank"
+	// chatHeadlineOpts controls the generated snippets for search results.
+	chatHeadlineOpts = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5"
+)
 
 func (db *Store) QueryChatHistory(ctx context.Context, query ChatHistoryQueryFilter) ([]QueryChatHistoryResult, int64, error) { //nolint:maintidx
 	if query.Limit > 1000 && !query.Unrestricted {
@@ -841,7 +892,31 @@ func (db *Store) QueryChatHistory(ctx context.Context, query ChatHistoryQueryFil
 		LeftJoin("person_messages_filter f on m.person_message_id = f.person_message_id").
 		LeftJoin("person p on p.steam_id = m.steam_id")
 
-	if query.Offset > 0 {
+	// websearch_to_tsquery handles "quoted phrases", OR and -excluded terms
+	tsQuery := fmt.Sprintf("websearch_to_tsquery('%s', ?)", chatSearchConfig)
+	rankExpr := fmt.Sprintf("ts_rank_cd(m.body_search, %s)", tsQuery)
+
+	if query.Query != "" {
+		builder = builder.
+			Column(sq.Expr(rankExpr+" as "+chatSearchRank, query.Query)).
+			Column(sq.Expr(fmt.Sprintf("ts_headline('%s', m.body, %s, '%s') as headline",
+				chatSearchConfig, tsQuery, chatHeadlineOpts), query.Query))
+	} else {
+		builder = builder.
+			Column("0::real as " + chatSearchRank).
+			Column("'' as headline")
+	}
+
+	var cursor *ChatHistoryCursor
+
+	if query.Cursor != "" {
+		decoded, errCursor := decodeChatHistoryCursor(query.Cursor)
+		if errCursor != nil {
+			return nil, 0, errCursor
+		}
+
+		cursor = &decoded
+	} else if query.Offset > 0 {
 		builder = builder.Offset(query.Offset)
 	}
 
@@ -851,27 +926,50 @@ func (db *Store) QueryChatHistory(ctx context.Context, query ChatHistoryQueryFil
 		builder = builder.Limit(50)
 	}
 
-	if query.OrderBy != "created_on" && query.OrderBy != "person_message_id" {
+	orderByRank := query.OrderBy == "rank"
+
+	if orderByRank {
+		if query.Query == "" {
+			return nil, 0, errors.New("Sort by rank requires a query")
+		}
+
+		// Most relevant first
+		query.Desc = true
+	} else if query.OrderBy != "created_on" && query.OrderBy != "person_message_id" {
 		return nil, 0, errors.New("Sort only allowed on created_on")
 	}
 
+	dir := "ASC"
+	if query.Desc {
+		dir = "DESC"
+	}
+
 	prefix := "m."
 
-	query.OrderBy = prefix + query.OrderBy
+	if orderByRank {
+		query.OrderBy = chatSearchRank
+	} else {
+		query.OrderBy = prefix + query.OrderBy
+	}
 
 	if query.OrderBy != "" {
-		orderBy := []string{query.OrderBy}
+		orderBy := []string{query.OrderBy + " " + dir}
 
-		if query.Desc {
-			builder = builder.OrderBy(strings.Join(orderBy, ",") + " DESC")
-		} else {
-			builder = builder.OrderBy(strings.Join(orderBy, ",") + " ASC")
+		if query.OrderBy != "m.person_message_id" {
+			// Tie-breaker so that cursor pagination is stable
+			orderBy = append(orderBy, "m.person_message_id "+dir)
 		}
 
-		groupBy := []string{query.OrderBy, "m.created_on", "m.person_message_id", "s.short_name", "f.person_message_id", "p.avatarhash"}
+		builder = builder.OrderBy(orderBy...)
+
+		groupBy := []string{"m.created_on", "m.person_message_id", "s.short_name", "f.person_message_id", "p.avatarhash"}
+
+		if !orderByRank {
+			groupBy = append([]string{query.OrderBy}, groupBy...)
+		}
 
 		if query.Query != "" {
-			groupBy = append(groupBy, "m.message_search")
+			groupBy = append(groupBy, "m.body_search")
 		}
 
 		if query.PersonaName != "" {
@@ -919,7 +1017,7 @@ func (db *Store) QueryChatHistory(ctx context.Context, query ChatHistoryQueryFil
 	}
 
 	if query.Query != "" {
-		ands = append(ands, sq.Expr(`message_search @@ websearch_to_tsquery('simple', ?)`, query.Query))
+		ands = append(ands, sq.Expr("m.body_search @@ "+tsQuery, query.Query))
 	}
 
 	if query.FlaggedOnly {
@@ -929,6 +1027,24 @@ func (db *Store) QueryChatHistory(ctx context.Context, query ChatHistoryQueryFil
 	count = count.Where(ands)
 	builder = builder.Where(ands)
 
+	if cursor != nil {
+		cmp := ">"
+		if query.Desc {
+			cmp = "<"
+		}
+
+		switch query.OrderBy {
+		case chatSearchRank:
+			builder = builder.Where(sq.Expr(fmt.Sprintf("(%s, m.person_message_id) %s (?::real, ?)", rankExpr, cmp),
+				query.Query, cursor.Rank, cursor.PersonMessageID))
+		case "m.created_on":
+			builder = builder.Where(sq.Expr(fmt.Sprintf("(m.created_on, m.person_message_id) %s (?, ?)", cmp),
+				cursor.CreatedOn, cursor.PersonMessageID))
+		default:
+			builder = builder.Where(sq.Expr(fmt.Sprintf("m.person_message_id %s ?", cmp), cursor.PersonMessageID))
+		}
+	}
+
 	var totalRows int64
 
 	if !query.DontCalcTotal {
@@ -977,6 +1093,8 @@ func (db *Store) QueryChatHistory(ctx context.Context, query ChatHistoryQueryFil
 				&message.ServerName,
 				&message.AutoFilterFlagged,
 				&message.AvatarHash,
+				&message.Rank,
+				&message.Headline,
 			}
 		)
 
@@ -1075,6 +1193,68 @@ func (db *Store) GetPersonMessageContext(ctx context.Context, serverID int, mess
 	return messages, nil
 }
 
+// CountChatHistorySince returns the total number of chat messages sent since the time provided.
+func (db *Store) CountChatHistorySince(ctx context.Context, since time.Time) (int64, error) {
+	const query = `SELECT count(person_message_id) FROM person_messages WHERE created_on >= $1`
+
+	var count int64
+	if errScan := db.QueryRow(ctx, query, since).Scan(&count); errScan != nil {
+		return 0, Err(errScan)
+	}
+
+	return count, nil
+}
+
+// ChatHistoryBatch returns up to limit messages sent since the time provided which have a id greater than
+// afterID. Results are ordered by person_message_id, so the last id of one batch can be used as the afterID
+// of the next batch to walk over large ranges of history without the cost of large offsets.
+func (db *Store) ChatHistoryBatch(ctx context.Context, since time.Time, afterID int64, limit uint64) ([]PersonMessage, error) {
+	query, args, errQuery := db.sb.
+		Select("m.person_message_id", "m.steam_id", "m.server_id", "m.body", "m.team", "m.created_on",
+			"m.persona_name", "m.match_id", "coalesce(s.short_name, '')").
+		From("person_messages m").
+		LeftJoin("server s on m.server_id = s.server_id").
+		Where(sq.And{sq.GtOrEq{"m.created_on": since}, sq.Gt{"m.person_message_id": afterID}}).
+		OrderBy("m.person_message_id ASC").
+		Limit(limit).
+		ToSql()
+	if errQuery != nil {
+		return nil, errors.Wrap(errQuery, "Failed to create query")
+	}
+
+	rows, errRows := db.Query(ctx, query, args...)
+	if errRows != nil {
+		return nil, Err(errRows)
+	}
+
+	defer rows.Close()
+
+	var messages []PersonMessage
+
+	for rows.Next() {
+		var (
+			msg     PersonMessage
+			steamID int64
+			matchID []byte
+		)
+
+		if errScan := rows.Scan(&msg.PersonMessageID, &steamID, &msg.ServerID, &msg.Body, &msg.Team,
+			&msg.CreatedOn, &msg.PersonaName, &matchID, &msg.ServerName); errScan != nil {
+			return nil, Err(errScan)
+		}
+
+		if matchID != nil {
+			msg.MatchID = uuid.FromBytesOrNil(matchID)
+		}
+
+		msg.SteamID = steamid.New(steamID)
+
+		messages = append(messages, msg)
+	}
+
+	return messages, nil
+}
+
 func (db *Store) GetPersonIPHistory(ctx context.Context, sid64 steamid.SID64, limit uint64) (PersonConnections, error) {
 	builder := db.sb.
 		Select(
@@ -1124,11 +1304,11 @@ func (db *Store) GetPersonIPHistory(ctx context.Context, sid64 steamid.SID64, li
 
 func (db *Store) AddConnectionHistory(ctx context.Context, conn *PersonConnection) error {
 	const query = `
-		INSERT INTO person_connections (steam_id, ip_addr, persona_name, created_on) 
-		VALUES ($1, $2, $3, $4) RETURNING person_connection_id`
+		INSERT INTO person_connections (steam_id, ip_addr, persona_name, server_id, created_on) 
+		VALUES ($1, $2, $3, case WHEN $4 = 0 THEN null ELSE $4 END, $5) RETURNING person_connection_id`
 
 	if errQuery := db.
-		QueryRow(ctx, query, conn.SteamID.Int64(), conn.IPAddr, conn.PersonaName, conn.CreatedOn).
+		QueryRow(ctx, query, conn.SteamID.Int64(), conn.IPAddr, conn.PersonaName, conn.ServerID, conn.CreatedOn).
 		Scan(&conn.PersonConnectionID); errQuery != nil {
 		return Err(errQuery)
 	}
@@ -1136,6 +1316,22 @@ func (db *Store) AddConnectionHistory(ctx context.Context, conn *PersonConnectio
 	return nil
 }
 
+// SetConnectionDisconnected marks the players most recent open connection to the server as disconnected.
+func (db *Store) SetConnectionDisconnected(ctx context.Context, steamID steamid.SID64, serverID int,
+	disconnectedOn time.Time,
+) error {
+	const query = `
+		UPDATE person_connections SET disconnected_on = $3
+		WHERE person_connection_id = (
+			SELECT person_connection_id FROM person_connections
+			WHERE steam_id = $1 AND server_id = $2 AND disconnected_on IS NULL AND created_on <= $3
+			ORDER BY created_on DESC
+			LIMIT 1
+		)`
+
+	return db.Exec(ctx, query, steamID.Int64(), serverID, disconnectedOn)
+}
+
 var personAuthColumns = []string{"person_auth_id", "steam_id", "ip_addr", "refresh_token", "created_on"} //nolint:gochecknoglobals
 
 func (db *Store) GetPersonAuth(ctx context.Context, sid64 steamid.SID64, ipAddr net.IP, auth *PersonAuth) error {
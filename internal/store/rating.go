@@ -0,0 +1,390 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/gofrs/uuid/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/leighmacdonald/gbans/pkg/logparse"
+	"github.com/leighmacdonald/gbans/pkg/mm"
+	"github.com/leighmacdonald/gbans/pkg/rating"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// RatingAnyFormat is the game type of ratings which include matches of every format.
+	RatingAnyFormat mm.GameType = -1
+	// RatingAnyClass is the player class of ratings which include every class played.
+	RatingAnyClass = logparse.Spectator
+)
+
+// PlayerRating is the current rating of a player for a single format & class combination.
+type PlayerRating struct {
+	SteamID     steamid.SID64        `json:"steam_id"`
+	GameType    mm.GameType          `json:"game_type"`
+	PlayerClass logparse.PlayerClass `json:"player_class"`
+	rating.Rating
+	Matches   int       `json:"matches"`
+	Wins      int       `json:"wins"`
+	Losses    int       `json:"losses"`
+	Draws     int       `json:"draws"`
+	UpdatedOn time.Time `json:"updated_on"`
+}
+
+// PlayerRatingHistory is the rating of a player after a rated match.
+type PlayerRatingHistory struct {
+	PlayerRatingHistoryID int64                `json:"player_rating_history_id"`
+	MatchID               uuid.UUID            `json:"match_id"`
+	SteamID               steamid.SID64        `json:"steam_id"`
+	GameType              mm.GameType          `json:"game_type"`
+	PlayerClass           logparse.PlayerClass `json:"player_class"`
+	rating.Rating
+	Change    float64   `json:"change"`
+	CreatedOn time.Time `json:"created_on"`
+}
+
+// RatingLeaderboardEntry is a ranked player rating. Players are ranked by their conservative rating.
+type RatingLeaderboardEntry struct {
+	PlayerRating
+	Rank        int     `json:"rank"`
+	PersonaName string  `json:"persona_name"`
+	AvatarHash  string  `json:"avatar_hash"`
+	Score       float64 `json:"score"`
+}
+
+type RatingLeaderboardOpts struct {
+	GameType    mm.GameType          `json:"game_type"`
+	PlayerClass logparse.PlayerClass `json:"player_class"`
+	// Players need at least this many rated matches to be listed.
+	MinMatches int    `json:"min_matches"`
+	Limit      uint64 `json:"limit"`
+	Offset     uint64 `json:"offset"`
+}
+
+// PlayerRatings returns the current ratings of the players.
+func (db *Store) PlayerRatings(ctx context.Context, steamIDs steamid.Collection) ([]PlayerRating, error) {
+	ids := make([]int64, len(steamIDs))
+	for index, steamID := range steamIDs {
+		ids[index] = steamID.Int64()
+	}
+
+	query, args, errQuery := db.sb.
+		Select("steam_id", "game_type", "player_class", "rating", "deviation", "volatility", "matches", "wins",
+			"losses", "draws", "updated_on").
+		From("player_rating").
+		Where(sq.Eq{"steam_id": ids}).
+		OrderBy("game_type", "player_class").
+		ToSql()
+	if errQuery != nil {
+		return nil, errors.Wrap(errQuery, "Failed to build query")
+	}
+
+	rows, errRows := db.Query(ctx, query, args...)
+	if errRows != nil {
+		return nil, Err(errRows)
+	}
+
+	defer rows.Close()
+
+	ratings := []PlayerRating{}
+
+	for rows.Next() {
+		var (
+			playerRating PlayerRating
+			steamID      int64
+		)
+
+		if errScan := rows.Scan(&steamID, &playerRating.GameType, &playerRating.PlayerClass, &playerRating.Rating.Rating,
+			&playerRating.Deviation, &playerRating.Volatility, &playerRating.Matches, &playerRating.Wins,
+			&playerRating.Losses, &playerRating.Draws, &playerRating.UpdatedOn); errScan != nil {
+			return nil, Err(errScan)
+		}
+
+		playerRating.SteamID = steamid.New(steamID)
+		ratings = append(ratings, playerRating)
+	}
+
+	return ratings, nil
+}
+
+// MatchRated checks if ratings have already been calculated for the match.
+func (db *Store) MatchRated(ctx context.Context, matchID uuid.UUID) (bool, error) {
+	const query = `SELECT EXISTS(SELECT 1 FROM player_rating_history WHERE match_id = $1)`
+
+	var rated bool
+	if errQuery := db.QueryRow(ctx, query, matchID).Scan(&rated); errQuery != nil {
+		return false, Err(errQuery)
+	}
+
+	return rated, nil
+}
+
+// RatedMatchIDs returns the ids of every match in the order they were played, used when recomputing ratings.
+func (db *Store) RatedMatchIDs(ctx context.Context) ([]uuid.UUID, error) {
+	rows, errRows := db.Query(ctx, `SELECT match_id FROM match ORDER BY time_start, match_id`)
+	if errRows != nil {
+		return nil, Err(errRows)
+	}
+
+	defer rows.Close()
+
+	var matchIDs []uuid.UUID
+
+	for rows.Next() {
+		var matchID uuid.UUID
+		if errScan := rows.Scan(&matchID); errScan != nil {
+			return nil, Err(errScan)
+		}
+
+		matchIDs = append(matchIDs, matchID)
+	}
+
+	return matchIDs, nil
+}
+
+const (
+	playerRatingQuery = `
+		INSERT INTO player_rating (
+			steam_id, game_type, player_class, rating, deviation, volatility, matches, wins, losses, draws, updated_on)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (steam_id, game_type, player_class) DO UPDATE SET
+			rating = $4, deviation = $5, volatility = $6, matches = $7, wins = $8, losses = $9, draws = $10,
+			updated_on = $11`
+	playerRatingHistoryQuery = `
+		INSERT INTO player_rating_history (
+			match_id, steam_id, game_type, player_class, rating, deviation, volatility, rating_change, created_on)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING player_rating_history_id`
+)
+
+func (db *Store) savePlayerRatings(ctx context.Context, transaction pgx.Tx, ratings []PlayerRating,
+	history []PlayerRatingHistory,
+) error {
+	for _, playerRating := range ratings {
+		if _, errExec := transaction.Exec(ctx, playerRatingQuery, playerRating.SteamID.Int64(), playerRating.GameType,
+			playerRating.PlayerClass, playerRating.Rating.Rating, playerRating.Deviation, playerRating.Volatility,
+			playerRating.Matches, playerRating.Wins, playerRating.Losses, playerRating.Draws,
+			playerRating.UpdatedOn); errExec != nil {
+			return errors.Wrap(Err(errExec), "Failed to save player rating")
+		}
+	}
+
+	for index := range history {
+		entry := &history[index]
+		if errQuery := transaction.QueryRow(ctx, playerRatingHistoryQuery, entry.MatchID, entry.SteamID.Int64(),
+			entry.GameType, entry.PlayerClass, entry.Rating.Rating, entry.Deviation, entry.Volatility, entry.Change,
+			entry.CreatedOn).Scan(&entry.PlayerRatingHistoryID); errQuery != nil {
+			return errors.Wrap(Err(errQuery), "Failed to save player rating history")
+		}
+	}
+
+	return nil
+}
+
+// PlayerRatingsSave stores the updated ratings of a match along with their history entries.
+func (db *Store) PlayerRatingsSave(ctx context.Context, ratings []PlayerRating, history []PlayerRatingHistory) error {
+	transaction, errTx := db.conn.Begin(ctx)
+	if errTx != nil {
+		return errors.Wrap(errTx, "Failed to create rating tx")
+	}
+
+	if errSave := db.savePlayerRatings(ctx, transaction, ratings, history); errSave != nil {
+		if errRollback := transaction.Rollback(ctx); errRollback != nil {
+			db.log.Error("Failed to rollback tx", zap.Error(errRollback))
+		}
+
+		return errSave
+	}
+
+	if errCommit := transaction.Commit(ctx); errCommit != nil {
+		return errors.Wrap(errCommit, "Failed to commit ratings")
+	}
+
+	return nil
+}
+
+// RateMatchFunc returns the ratings to save for a match. Matches which are not rated return no ratings.
+type RateMatchFunc func(matchID uuid.UUID) ([]PlayerRating, []PlayerRatingHistory, error)
+
+// PlayerRatingsRecompute replaces all ratings and their history with the ratings returned by rate for each of
+// the matches, in order, within a single transaction. The previous ratings remain visible until the recompute is
+// committed and are kept when it fails.
+func (db *Store) PlayerRatingsRecompute(ctx context.Context, matchIDs []uuid.UUID, rate RateMatchFunc) error {
+	transaction, errTx := db.conn.Begin(ctx)
+	if errTx != nil {
+		return errors.Wrap(errTx, "Failed to create rating tx")
+	}
+
+	rollback := func() {
+		if errRollback := transaction.Rollback(ctx); errRollback != nil {
+			db.log.Error("Failed to rollback tx", zap.Error(errRollback))
+		}
+	}
+
+	// DELETE instead of TRUNCATE so readers are not locked out for the duration of the recompute
+	for _, table := range []string{"player_rating_history", "player_rating"} {
+		if _, errExec := transaction.Exec(ctx, fmt.Sprintf("DELETE FROM %s", table)); errExec != nil {
+			rollback()
+
+			return errors.Wrap(Err(errExec), "Failed to reset ratings")
+		}
+	}
+
+	for _, matchID := range matchIDs {
+		ratings, history, errRate := rate(matchID)
+		if errRate != nil {
+			rollback()
+
+			return errRate
+		}
+
+		if errSave := db.savePlayerRatings(ctx, transaction, ratings, history); errSave != nil {
+			rollback()
+
+			return errors.Wrapf(errSave, "Failed to save ratings for match %s", matchID)
+		}
+	}
+
+	if errCommit := transaction.Commit(ctx); errCommit != nil {
+		return errors.Wrap(errCommit, "Failed to commit ratings")
+	}
+
+	return nil
+}
+
+// PlayerRatingHistory returns the rating history of a player for a format & class, oldest first.
+func (db *Store) PlayerRatingHistory(ctx context.Context, steamID steamid.SID64, gameType mm.GameType,
+	playerClass logparse.PlayerClass, limit uint64,
+) ([]PlayerRatingHistory, error) {
+	builder := db.sb.
+		Select("player_rating_history_id", "match_id", "game_type", "player_class", "rating", "deviation",
+			"volatility", "rating_change", "created_on").
+		From("player_rating_history").
+		Where(sq.Eq{"steam_id": steamID.Int64(), "game_type": gameType, "player_class": playerClass}).
+		OrderBy("created_on DESC", "player_rating_history_id DESC")
+
+	if limit > 0 {
+		builder = builder.Limit(limit)
+	}
+
+	query, args, errQuery := builder.ToSql()
+	if errQuery != nil {
+		return nil, errors.Wrap(errQuery, "Failed to build query")
+	}
+
+	rows, errRows := db.Query(ctx, query, args...)
+	if errRows != nil {
+		return nil, Err(errRows)
+	}
+
+	defer rows.Close()
+
+	history := []PlayerRatingHistory{}
+
+	for rows.Next() {
+		entry := PlayerRatingHistory{SteamID: steamID}
+
+		if errScan := rows.Scan(&entry.PlayerRatingHistoryID, &entry.MatchID, &entry.GameType, &entry.PlayerClass,
+			&entry.Rating.Rating, &entry.Deviation, &entry.Volatility, &entry.Change, &entry.CreatedOn); errScan != nil {
+			return nil, Err(errScan)
+		}
+
+		history = append(history, entry)
+	}
+
+	// Query newest first so the limit returns the most recent entries
+	for left, right := 0, len(history)-1; left < right; left, right = left+1, right-1 {
+		history[left], history[right] = history[right], history[left]
+	}
+
+	return history, nil
+}
+
+// RatingLeaderboard returns the ranked ratings for a format & class along with the total count of ranked
+// players.
+func (db *Store) RatingLeaderboard(ctx context.Context, opts RatingLeaderboardOpts) ([]RatingLeaderboardEntry, int64, error) {
+	filter := sq.And{
+		sq.Eq{"r.game_type": opts.GameType, "r.player_class": opts.PlayerClass},
+		sq.GtOrEq{"r.matches": opts.MinMatches},
+	}
+
+	countQuery, countArgs, errCountQuery := db.sb.
+		Select("count(*)").
+		From("player_rating r").
+		Where(filter).
+		ToSql()
+	if errCountQuery != nil {
+		return nil, 0, errors.Wrap(errCountQuery, "Failed to build count query")
+	}
+
+	var count int64
+	if errCount := db.QueryRow(ctx, countQuery, countArgs...).Scan(&count); errCount != nil {
+		return nil, 0, Err(errCount)
+	}
+
+	builder := db.sb.
+		Select("r.steam_id", "r.game_type", "r.player_class", "r.rating", "r.deviation", "r.volatility",
+			"r.matches", "r.wins", "r.losses", "r.draws", "r.updated_on", "p.personaname", "p.avatarhash").
+		From("player_rating r").
+		LeftJoin("person p ON p.steam_id = r.steam_id").
+		Where(filter).
+		OrderBy("(r.rating - 2 * r.deviation) DESC", "r.steam_id")
+
+	if opts.Limit > 0 {
+		builder = builder.Limit(opts.Limit)
+	}
+
+	if opts.Offset > 0 {
+		builder = builder.Offset(opts.Offset)
+	}
+
+	query, args, errQuery := builder.ToSql()
+	if errQuery != nil {
+		return nil, 0, errors.Wrap(errQuery, "Failed to build query")
+	}
+
+	rows, errRows := db.Query(ctx, query, args...)
+	if errRows != nil {
+		return nil, 0, Err(errRows)
+	}
+
+	defer rows.Close()
+
+	entries := []RatingLeaderboardEntry{}
+
+	for rows.Next() {
+		var (
+			entry      RatingLeaderboardEntry
+			steamID    int64
+			name       *string
+			avatarHash *string
+		)
+
+		if errScan := rows.Scan(&steamID, &entry.GameType, &entry.PlayerClass, &entry.Rating.Rating,
+			&entry.Deviation, &entry.Volatility, &entry.Matches, &entry.Wins, &entry.Losses, &entry.Draws,
+			&entry.UpdatedOn, &name, &avatarHash); errScan != nil {
+			return nil, 0, Err(errScan)
+		}
+
+		entry.SteamID = steamid.New(steamID)
+		entry.Rank = int(opts.Offset) + len(entries) + 1
+		entry.Score = entry.Conservative()
+
+		if name != nil {
+			entry.PersonaName = *name
+		}
+
+		if avatarHash != nil {
+			entry.AvatarHash = *avatarHash
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, count, nil
+}
@@ -223,6 +223,7 @@ func (db *Store) DropReportMessage(ctx context.Context, message *UserMessage) er
 type AuthorQueryFilter struct {
 	QueryFilter
 	AuthorID steamid.SID64 `json:"author_id"`
+	TargetID steamid.SID64 `json:"target_id,omitempty"`
 }
 
 type ReportQueryFilter struct {
@@ -237,6 +238,10 @@ func (db *Store) GetReports(ctx context.Context, opts AuthorQueryFilter) ([]Repo
 		conditions = append(conditions, sq.Eq{"author_id": opts.AuthorID})
 	}
 
+	if opts.TargetID.Valid() {
+		conditions = append(conditions, sq.Eq{"reported_id": opts.TargetID.Int64()})
+	}
+
 	builder := db.sb.
 		Select("r.report_id", "r.author_id", "r.reported_id", "r.report_status",
 			"r.description", "r.deleted", "r.created_on", "r.updated_on", "r.reason", "r.reason_text",
@@ -0,0 +1,283 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+type ScheduledTaskType int
+
+const (
+	// TaskRCON executes the command as a raw rcon command.
+	TaskRCON ScheduledTaskType = iota
+	// TaskSay sends the command as a message using sm_say.
+	TaskSay
+	// TaskCSay sends the command as a centered message using sm_csay.
+	TaskCSay
+)
+
+func (taskType ScheduledTaskType) String() string {
+	switch taskType {
+	case TaskSay:
+		return "Say"
+	case TaskCSay:
+		return "CSay"
+	default:
+		return "RCON"
+	}
+}
+
+// ScheduledTask is a command that is run against a set of servers according to a cron expression.
+type ScheduledTask struct {
+	ScheduledTaskID int64  `json:"scheduled_task_id"`
+	Name            string `json:"name"`
+	CronExpression  string `json:"cron_expression"`
+	// Timezone is the IANA name of the timezone the cron expression is evaluated in.
+	Timezone string            `json:"timezone"`
+	TaskType ScheduledTaskType `json:"task_type"`
+	// Command is the rcon command, or a text/template of the message for Say and CSay tasks.
+	Command string `json:"command"`
+	// ServerSelector selects the servers by name or tag, eg: "us-1,tag:eu"
+	ServerSelector string `json:"server_selector"`
+	// PlayersBelow, when set, only runs the task on servers with fewer players than the value.
+	PlayersBelow *int `json:"players_below"`
+	// PlayersAtLeast, when set, only runs the task on servers with at least this many players.
+	PlayersAtLeast *int       `json:"players_at_least"`
+	AlertOnFailure bool       `json:"alert_on_failure"`
+	IsEnabled      bool       `json:"is_enabled"`
+	LastRun        *time.Time `json:"last_run"`
+	NextRun        *time.Time `json:"next_run"`
+	CreatedOn      time.Time  `json:"created_on"`
+	UpdatedOn      time.Time  `json:"updated_on"`
+}
+
+// ScheduledTaskServerResult is the outcome of a task on a single server.
+type ScheduledTaskServerResult struct {
+	ServerID   int    `json:"server_id"`
+	ServerName string `json:"server_name"`
+	// Skipped is set when the server did not meet the task conditions.
+	Skipped  bool   `json:"skipped"`
+	Response string `json:"response"`
+	Error    string `json:"error"`
+}
+
+// ScheduledTaskRun is a single execution of a task.
+type ScheduledTaskRun struct {
+	ScheduledTaskRunID int64                       `json:"scheduled_task_run_id"`
+	ScheduledTaskID    int64                       `json:"scheduled_task_id"`
+	Success            bool                        `json:"success"`
+	Error              string                      `json:"error"`
+	Results            []ScheduledTaskServerResult `json:"results"`
+	StartedOn          time.Time                   `json:"started_on"`
+	FinishedOn         time.Time                   `json:"finished_on"`
+}
+
+func (db *Store) SaveScheduledTask(ctx context.Context, task *ScheduledTask) error {
+	task.UpdatedOn = time.Now()
+
+	if task.ScheduledTaskID > 0 {
+		query, args, errQueryArgs := db.sb.
+			Update("scheduled_task").
+			SetMap(map[string]interface{}{
+				"name":             task.Name,
+				"cron_expression":  task.CronExpression,
+				"timezone":         task.Timezone,
+				"task_type":        task.TaskType,
+				"command":          task.Command,
+				"server_selector":  task.ServerSelector,
+				"players_below":    task.PlayersBelow,
+				"players_at_least": task.PlayersAtLeast,
+				"alert_on_failure": task.AlertOnFailure,
+				"is_enabled":       task.IsEnabled,
+				"last_run":         task.LastRun,
+				"next_run":         task.NextRun,
+				"updated_on":       task.UpdatedOn,
+			}).
+			Where(sq.Eq{"scheduled_task_id": task.ScheduledTaskID}).
+			ToSql()
+		if errQueryArgs != nil {
+			return Err(errQueryArgs)
+		}
+
+		return db.Exec(ctx, query, args...)
+	}
+
+	task.CreatedOn = task.UpdatedOn
+
+	query, args, errQueryArgs := db.sb.
+		Insert("scheduled_task").
+		Columns("name", "cron_expression", "timezone", "task_type", "command", "server_selector", "players_below",
+			"players_at_least", "alert_on_failure", "is_enabled", "last_run", "next_run", "created_on", "updated_on").
+		Values(task.Name, task.CronExpression, task.Timezone, task.TaskType, task.Command, task.ServerSelector,
+			task.PlayersBelow, task.PlayersAtLeast, task.AlertOnFailure, task.IsEnabled, task.LastRun, task.NextRun,
+			task.CreatedOn, task.UpdatedOn).
+		Suffix("RETURNING scheduled_task_id").
+		ToSql()
+	if errQueryArgs != nil {
+		return Err(errQueryArgs)
+	}
+
+	if errQuery := db.QueryRow(ctx, query, args...).Scan(&task.ScheduledTaskID); errQuery != nil {
+		return Err(errQuery)
+	}
+
+	return nil
+}
+
+func (db *Store) DeleteScheduledTask(ctx context.Context, scheduledTaskID int64) error {
+	query, args, errQueryArgs := db.sb.
+		Delete("scheduled_task").
+		Where(sq.Eq{"scheduled_task_id": scheduledTaskID}).
+		ToSql()
+	if errQueryArgs != nil {
+		return Err(errQueryArgs)
+	}
+
+	return db.Exec(ctx, query, args...)
+}
+
+func (db *Store) scheduledTaskQuery() sq.SelectBuilder {
+	return db.sb.
+		Select("scheduled_task_id", "name", "cron_expression", "timezone", "task_type", "command", "server_selector",
+			"players_below", "players_at_least", "alert_on_failure", "is_enabled", "last_run", "next_run", "created_on",
+			"updated_on").
+		From("scheduled_task")
+}
+
+func scanScheduledTask(row pgx.Row) (ScheduledTask, error) {
+	var task ScheduledTask
+
+	if errScan := row.Scan(&task.ScheduledTaskID, &task.Name, &task.CronExpression, &task.Timezone, &task.TaskType,
+		&task.Command, &task.ServerSelector, &task.PlayersBelow, &task.PlayersAtLeast, &task.AlertOnFailure,
+		&task.IsEnabled, &task.LastRun, &task.NextRun, &task.CreatedOn, &task.UpdatedOn); errScan != nil {
+		return task, Err(errScan)
+	}
+
+	return task, nil
+}
+
+func (db *Store) GetScheduledTask(ctx context.Context, scheduledTaskID int64, task *ScheduledTask) error {
+	query, args, errQueryArgs := db.scheduledTaskQuery().
+		Where(sq.Eq{"scheduled_task_id": scheduledTaskID}).
+		ToSql()
+	if errQueryArgs != nil {
+		return Err(errQueryArgs)
+	}
+
+	result, errScan := scanScheduledTask(db.QueryRow(ctx, query, args...))
+	if errScan != nil {
+		return errScan
+	}
+
+	*task = result
+
+	return nil
+}
+
+// GetScheduledTasks returns all tasks, or only the enabled tasks if enabledOnly is set.
+func (db *Store) GetScheduledTasks(ctx context.Context, enabledOnly bool) ([]ScheduledTask, error) {
+	builder := db.scheduledTaskQuery().OrderBy("scheduled_task_id")
+	if enabledOnly {
+		builder = builder.Where(sq.Eq{"is_enabled": true})
+	}
+
+	query, args, errQueryArgs := builder.ToSql()
+	if errQueryArgs != nil {
+		return nil, Err(errQueryArgs)
+	}
+
+	rows, errQuery := db.Query(ctx, query, args...)
+	if errQuery != nil {
+		return nil, Err(errQuery)
+	}
+
+	defer rows.Close()
+
+	tasks := []ScheduledTask{}
+
+	for rows.Next() {
+		task, errScan := scanScheduledTask(rows)
+		if errScan != nil {
+			return nil, errScan
+		}
+
+		tasks = append(tasks, task)
+	}
+
+	return tasks, nil
+}
+
+func (db *Store) SaveScheduledTaskRun(ctx context.Context, run *ScheduledTaskRun) error {
+	if run.Results == nil {
+		run.Results = []ScheduledTaskServerResult{}
+	}
+
+	query, args, errQueryArgs := db.sb.
+		Insert("scheduled_task_run").
+		Columns("scheduled_task_id", "success", "error", "results", "started_on", "finished_on").
+		Values(run.ScheduledTaskID, run.Success, run.Error, run.Results, run.StartedOn, run.FinishedOn).
+		Suffix("RETURNING scheduled_task_run_id").
+		ToSql()
+	if errQueryArgs != nil {
+		return Err(errQueryArgs)
+	}
+
+	if errQuery := db.QueryRow(ctx, query, args...).Scan(&run.ScheduledTaskRunID); errQuery != nil {
+		return Err(errQuery)
+	}
+
+	return nil
+}
+
+// GetScheduledTaskRuns returns the execution history of a task, newest first.
+func (db *Store) GetScheduledTaskRuns(ctx context.Context, scheduledTaskID int64, limit uint64) ([]ScheduledTaskRun, error) {
+	query, args, errQueryArgs := db.sb.
+		Select("scheduled_task_run_id", "scheduled_task_id", "success", "error", "results", "started_on",
+			"finished_on").
+		From("scheduled_task_run").
+		Where(sq.Eq{"scheduled_task_id": scheduledTaskID}).
+		OrderBy("started_on DESC").
+		Limit(limit).
+		ToSql()
+	if errQueryArgs != nil {
+		return nil, Err(errQueryArgs)
+	}
+
+	rows, errQuery := db.Query(ctx, query, args...)
+	if errQuery != nil {
+		return nil, Err(errQuery)
+	}
+
+	defer rows.Close()
+
+	runs := []ScheduledTaskRun{}
+
+	for rows.Next() {
+		var run ScheduledTaskRun
+
+		if errScan := rows.Scan(&run.ScheduledTaskRunID, &run.ScheduledTaskID, &run.Success, &run.Error, &run.Results,
+			&run.StartedOn, &run.FinishedOn); errScan != nil {
+			return nil, Err(errScan)
+		}
+
+		runs = append(runs, run)
+	}
+
+	return runs, nil
+}
+
+// PruneScheduledTaskRuns deletes execution history older than the provided time.
+func (db *Store) PruneScheduledTaskRuns(ctx context.Context, olderThan time.Time) error {
+	query, args, errQueryArgs := db.sb.
+		Delete("scheduled_task_run").
+		Where(sq.Lt{"started_on": olderThan}).
+		ToSql()
+	if errQueryArgs != nil {
+		return Err(errQueryArgs)
+	}
+
+	return db.Exec(ctx, query, args...)
+}
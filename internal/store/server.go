@@ -30,6 +30,7 @@ func NewServer(name string, address string, port int) Server {
 		TokenCreatedOn: time.Unix(0, 0),
 		CreatedOn:      time.Now(),
 		UpdatedOn:      time.Now(),
+		Tags:           []string{},
 	}
 }
 
@@ -55,6 +56,8 @@ type Server struct {
 	Latitude  float64 `json:"latitude"`
 	Longitude float64 `json:"longitude"`
 	LogSecret int     `json:"log_secret"`
+	// Tags are used to group servers so that they can be targeted together, eg: pub, eu, event
+	Tags []string `json:"tags"`
 	// TokenCreatedOn is set when changing the token
 	TokenCreatedOn time.Time `db:"token_created_on" json:"token_created_on"`
 	CreatedOn      time.Time `db:"created_on" json:"created_on"`
@@ -87,7 +90,7 @@ func (s Server) Slots(statusSlots int) int {
 var columnsServer = []string{ //nolint:gochecknoglobals
 	"server_id", "short_name", "name", "address", "port", "rcon", "password",
 	"token_created_on", "created_on", "updated_on", "reserved_slots", "is_enabled", "region", "cc",
-	"latitude", "longitude", "deleted", "log_secret",
+	"latitude", "longitude", "deleted", "log_secret", "tags",
 }
 
 func (db *Store) GetServer(ctx context.Context, serverID int, server *Server) error {
@@ -105,7 +108,7 @@ func (db *Store) GetServer(ctx context.Context, serverID int, server *Server) er
 			&server.Password, &server.TokenCreatedOn, &server.CreatedOn, &server.UpdatedOn,
 			&server.ReservedSlots, &server.IsEnabled, &server.Region, &server.CC,
 			&server.Latitude, &server.Longitude,
-			&server.Deleted, &server.LogSecret); errRow != nil {
+			&server.Deleted, &server.LogSecret, &server.Tags); errRow != nil {
 		return Err(errRow)
 	}
 
@@ -195,7 +198,7 @@ func (db *Store) GetServers(ctx context.Context, includeDisabled bool) ([]Server
 			Scan(&server.ServerID, &server.ServerName, &server.ServerNameLong, &server.Address, &server.Port, &server.RCON,
 				&server.Password, &server.TokenCreatedOn, &server.CreatedOn, &server.UpdatedOn, &server.ReservedSlots,
 				&server.IsEnabled, &server.Region, &server.CC, &server.Latitude, &server.Longitude,
-				&server.Deleted, &server.LogSecret); errScan != nil {
+				&server.Deleted, &server.LogSecret, &server.Tags); errScan != nil {
 			return nil, errors.Wrap(errScan, "Failed to scan server")
 		}
 
@@ -239,12 +242,16 @@ func (db *Store) GetServerByName(ctx context.Context, serverName string, server
 			&server.RCON,
 			&server.Password, &server.TokenCreatedOn, &server.CreatedOn, &server.UpdatedOn, &server.ReservedSlots,
 			&server.IsEnabled, &server.Region, &server.CC, &server.Latitude, &server.Longitude,
-			&server.Deleted, &server.LogSecret))
+			&server.Deleted, &server.LogSecret, &server.Tags))
 }
 
 // SaveServer updates or creates the server data in the database.
 func (db *Store) SaveServer(ctx context.Context, server *Server) error {
 	server.UpdatedOn = time.Now()
+	if server.Tags == nil {
+		server.Tags = []string{}
+	}
+
 	if server.ServerID > 0 {
 		return db.updateServer(ctx, server)
 	}
@@ -259,14 +266,14 @@ func (db *Store) insertServer(ctx context.Context, server *Server) error {
 		INSERT INTO server (
 		    short_name, name, address, port, rcon, token_created_on, 
 		    reserved_slots, created_on, updated_on, password, is_enabled, region, cc, latitude, longitude, 
-			deleted, log_secret) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+			deleted, log_secret, tags) 
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 		RETURNING server_id;`
 
 	err := db.QueryRow(ctx, query, server.ServerName, server.ServerNameLong, server.Address, server.Port,
 		server.RCON, server.TokenCreatedOn, server.ReservedSlots, server.CreatedOn, server.UpdatedOn,
 		server.Password, server.IsEnabled, server.Region, server.CC,
-		server.Latitude, server.Longitude, server.Deleted, &server.LogSecret).Scan(&server.ServerID)
+		server.Latitude, server.Longitude, server.Deleted, &server.LogSecret, server.Tags).Scan(&server.ServerID)
 	if err != nil {
 		return Err(err)
 	}
@@ -294,6 +301,7 @@ func (db *Store) updateServer(ctx context.Context, server *Server) error {
 		Set("latitude", server.Latitude).
 		Set("longitude", server.Longitude).
 		Set("log_secret", server.LogSecret).
+		Set("tags", server.Tags).
 		Where(sq.Eq{"server_id": server.ServerID}).
 		ToSql()
 	if errQueryArgs != nil {
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+)
+
+// ServerDowntime is a period of time a server was not responding. EndedOn is nil while the server is
+// still down.
+type ServerDowntime struct {
+	ServerDowntimeID int64      `json:"server_downtime_id"`
+	ServerID         int        `json:"server_id"`
+	Reason           string     `json:"reason"`
+	StartedOn        time.Time  `json:"started_on"`
+	EndedOn          *time.Time `json:"ended_on"`
+}
+
+// OpenServerDowntime records the start of a downtime period. Any existing open period for the server
+// is left untouched.
+func (db *Store) OpenServerDowntime(ctx context.Context, downtime *ServerDowntime) error {
+	query, args, errQueryArgs := db.sb.
+		Insert("server_downtime").
+		Columns("server_id", "reason", "started_on").
+		Values(downtime.ServerID, downtime.Reason, downtime.StartedOn).
+		Suffix("ON CONFLICT (server_id) WHERE ended_on IS NULL DO NOTHING RETURNING server_downtime_id").
+		ToSql()
+	if errQueryArgs != nil {
+		return Err(errQueryArgs)
+	}
+
+	errQuery := Err(db.QueryRow(ctx, query, args...).Scan(&downtime.ServerDowntimeID))
+	if errors.Is(errQuery, ErrNoResult) {
+		return nil
+	}
+
+	return errQuery
+}
+
+// CloseServerDowntime ends the open downtime period for the server, if any.
+func (db *Store) CloseServerDowntime(ctx context.Context, serverID int, endedOn time.Time) error {
+	query, args, errQueryArgs := db.sb.
+		Update("server_downtime").
+		Set("ended_on", endedOn).
+		Where(sq.And{sq.Eq{"server_id": serverID}, sq.Eq{"ended_on": nil}}).
+		ToSql()
+	if errQueryArgs != nil {
+		return Err(errQueryArgs)
+	}
+
+	return db.Exec(ctx, query, args...)
+}
+
+// GetServerDowntimes returns the downtime periods that were ongoing at, or started after, the since time
+// ordered by start time. A serverID of 0 returns periods for all servers.
+func (db *Store) GetServerDowntimes(ctx context.Context, serverID int, since time.Time) ([]ServerDowntime, error) {
+	builder := db.sb.
+		Select("server_downtime_id", "server_id", "reason", "started_on", "ended_on").
+		From("server_downtime").
+		Where(sq.Or{sq.Eq{"ended_on": nil}, sq.Gt{"ended_on": since}}).
+		OrderBy("started_on")
+
+	if serverID > 0 {
+		builder = builder.Where(sq.Eq{"server_id": serverID})
+	}
+
+	query, args, errQueryArgs := builder.ToSql()
+	if errQueryArgs != nil {
+		return nil, Err(errQueryArgs)
+	}
+
+	rows, errQuery := db.Query(ctx, query, args...)
+	if errQuery != nil {
+		return nil, Err(errQuery)
+	}
+
+	defer rows.Close()
+
+	downtimes := []ServerDowntime{}
+
+	for rows.Next() {
+		var downtime ServerDowntime
+		if errScan := rows.Scan(&downtime.ServerDowntimeID, &downtime.ServerID, &downtime.Reason,
+			&downtime.StartedOn, &downtime.EndedOn); errScan != nil {
+			return nil, Err(errScan)
+		}
+
+		downtimes = append(downtimes, downtime)
+	}
+
+	return downtimes, nil
+}
@@ -729,6 +729,10 @@ type WeaponsOverallResult struct {
 	ShotsPct     float64 `json:"shots_pct"`
 	Hits         int64   `json:"hits"`
 	HitsPct      float64 `json:"hits_pct"`
+	// Kill distance distribution of kills with known positions. Only calculated for the overall stats.
+	DistanceKills  int64   `json:"distance_kills"`
+	DistanceMean   float64 `json:"distance_mean"`
+	DistanceStdDev float64 `json:"distance_std_dev"`
 }
 
 func (db *Store) WeaponsOverall(ctx context.Context) ([]WeaponsOverallResult, error) {
@@ -741,7 +745,9 @@ func (db *Store) WeaponsOverall(ctx context.Context) ([]WeaponsOverallResult, er
 		    s.bs, case t.backstabs_total WHEN 0 THEN 0 ELSE (s.bs::float / t.backstabs_total::float) * 100 END  bs_pct,
 			s.shots,  case t.shots_total WHEN 0 THEN 0 ELSE (s.shots::float / t.shots_total::float) * 100 END shots_pct,
 			s.hits, case t.hits_total WHEN 0 THEN 0 ELSE (s.hits::float / t.hits_total::float) * 100 END hits_pct,
-			s.damage, case t.damage_total WHEN 0 THEN 0 ELSE (s.damage::float / t.damage_total::float) * 100 END damage_pct
+			s.damage, case t.damage_total WHEN 0 THEN 0 ELSE (s.damage::float / t.damage_total::float) * 100 END damage_pct,
+			s.dk, case s.dk WHEN 0 THEN 0 ELSE s.ds / s.dk::float END distance_mean,
+			case s.dk WHEN 0 THEN 0 ELSE sqrt(greatest(s.dss / s.dk::float - (s.ds / s.dk::float) ^ 2, 0)) END distance_std_dev
 		FROM (
     		SELECT
     		    w.weapon_id, w.key, w.name,
@@ -751,7 +757,10 @@ func (db *Store) WeaponsOverall(ctx context.Context) ([]WeaponsOverallResult, er
              	SUM(mw.hits) as hits,
              	SUM(headshots) as hs,
              	SUM(airshots)  as airshots,
-             	SUM(backstabs) as bs
+             	SUM(backstabs) as bs,
+             	SUM(mw.distance_kills) as dk,
+             	SUM(mw.distance_sum) as ds,
+             	SUM(mw.distance_square_sum) as dss
       		FROM match_weapon mw
     		LEFT JOIN public.weapon w on w.weapon_id = mw.weapon_id
       		GROUP BY w.weapon_id
@@ -785,7 +794,8 @@ func (db *Store) WeaponsOverall(ctx context.Context) ([]WeaponsOverallResult, er
 				&wor.Backstabs, &wor.BackstabsPct,
 				&wor.Shots, &wor.ShotsPct,
 				&wor.Hits, &wor.HitsPct,
-				&wor.Damage, &wor.DamagePct); errScan != nil {
+				&wor.Damage, &wor.DamagePct,
+				&wor.DistanceKills, &wor.DistanceMean, &wor.DistanceStdDev); errScan != nil {
 			return nil, Err(errScan)
 		}
 
@@ -3,12 +3,21 @@ package store_test
 import (
 	"context"
 	"fmt"
+	"math"
 	"math/rand"
 	"net"
+	"os"
+	"path"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/gofrs/uuid/v5"
 	"github.com/leighmacdonald/gbans/internal/store"
+	"github.com/leighmacdonald/gbans/pkg/logparse"
+	"github.com/leighmacdonald/gbans/pkg/mm"
+	"github.com/leighmacdonald/gbans/pkg/rating"
+	"github.com/leighmacdonald/gbans/pkg/util"
 	"github.com/leighmacdonald/golib"
 	"github.com/leighmacdonald/steamid/v3/steamid"
 	"github.com/pkg/errors"
@@ -71,7 +80,23 @@ func TestStore(t *testing.T) {
 	t.Run("ban_group", testBanGroup(database))
 	t.Run("person", testPerson(database))
 	t.Run("chat_hist", testChatHistory(database))
+	t.Run("person_erasure", testPersonErasure(database))
+	t.Run("person_names", testPersonNames(database))
+	t.Run("person_notes", testPersonNotes(database))
+	t.Run("person_timeline", testPersonTimeline(database))
+	t.Run("scheduled_tasks", testScheduledTasks(database))
+	t.Run("server_downtime", testServerDowntime(database))
+	t.Run("console_commands", testConsoleCommands(database))
+	t.Run("match_checkpoints", testMatchCheckpoints(database))
 	t.Run("filters", testFilters(database))
+	t.Run("leaderboards", testLeaderboards(database))
+	t.Run("player_ratings", testPlayerRatings(database))
+	t.Run("anomalies", testAnomalies(database))
+	t.Run("map_stats", testMapStats(database))
+	t.Run("data_exports", testDataExports(database))
+	t.Run("match_class_kills_heal_spread", testMatchClassKillsHealSpread(database))
+	t.Run("match_rounds_timeline", testMatchRoundsTimeline(database))
+	t.Run("match_objectives", testMatchObjectives(database))
 }
 
 func TestParseDuration(t *testing.T) {
@@ -115,6 +140,88 @@ func TestParseDuration(t *testing.T) {
 	}
 }
 
+func TestFilterNormalizedMatch(t *testing.T) {
+	evasions := []string{
+		"cheater",
+		"CHEATER",
+		"ch3at3r",
+		"ch34t3r",
+		"c h e a t e r",
+		"c.h.e.a.t.e.r",
+		"chea​ter",
+		"ch‍e‍ater",
+		"сhеаtеr",
+		"ｃｈｅａｔｅｒ",
+		"chéàtér",
+		"cheeeeaaaaterrrr",
+		"you are a ch eat er lol",
+	}
+
+	exact := store.Filter{
+		Pattern:   "cheater",
+		IsEnabled: true,
+		Normalize: util.NormalizeOpts{Confusables: true, Invisible: true, Leet: true, Repeats: true, Spaces: true},
+	}
+	exact.Init()
+
+	regex := store.Filter{
+		Pattern:   "ch[e]+ater",
+		IsRegex:   true,
+		IsEnabled: true,
+		Normalize: exact.Normalize,
+	}
+	regex.Init()
+
+	matches := func(filter store.Filter, body string) bool {
+		_, matched := filter.Match(body)
+
+		return matched
+	}
+
+	for _, evasion := range evasions {
+		require.True(t, matches(exact, evasion), "Failed to match exact: %s", evasion)
+		require.True(t, matches(regex, evasion), "Failed to match regex: %s", evasion)
+	}
+
+	plain := store.Filter{Pattern: "cheater", IsEnabled: true}
+	plain.Init()
+
+	require.True(t, matches(plain, "you CHEATER"))
+	require.False(t, matches(plain, "ch3at3r"))
+	require.False(t, matches(plain, "c h e a t e r"))
+	require.False(t, matches(exact, "cheat at poker"))
+	require.False(t, matches(exact, "cheaters"))
+
+	// Plain patterns keep word boundaries, even when spaces are removed
+	word := store.Filter{
+		Pattern:   "ass",
+		IsEnabled: true,
+		Normalize: util.NormalizeOpts{Leet: true, Spaces: true},
+	}
+	word.Init()
+
+	require.True(t, matches(word, "you @ss"))
+	require.True(t, matches(word, "you a s s"))
+	require.False(t, matches(word, "pick a class"))
+	require.False(t, matches(word, "assist me"))
+
+	// Regex filters match against the normalized input
+	leet := store.Filter{
+		Pattern:   "^no+b$",
+		IsRegex:   true,
+		IsEnabled: true,
+		Normalize: util.NormalizeOpts{Leet: true, Repeats: true},
+	}
+	leet.Init()
+
+	require.True(t, matches(leet, "ur a n000000b"))
+	require.False(t, matches(leet, "noobs"))
+
+	matched, found := exact.Match("you are a ch eat er lol")
+	require.True(t, found)
+	require.Equal(t, "cheater", matched)
+}
+
 func testServerTest(database *store.Store) func(t *testing.T) {
 	return func(t *testing.T) {
 		serverA := store.Server{
@@ -127,6 +234,7 @@ func testServerTest(database *store.Store) func(t *testing.T) {
 			TokenCreatedOn: time.Now(),
 			CreatedOn:      time.Now(),
 			UpdatedOn:      time.Now(),
+			Tags:           []string{"eu", "pub"},
 		}
 
 		ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
@@ -146,6 +254,7 @@ func testServerTest(database *store.Store) func(t *testing.T) {
 		require.Equal(t, serverA.Port, s1Get.Port)
 		require.Equal(t, serverA.RCON, s1Get.RCON)
 		require.Equal(t, serverA.Password, s1Get.Password)
+		require.Equal(t, serverA.Tags, s1Get.Tags)
 		require.Equal(t, serverA.TokenCreatedOn.Second(), s1Get.TokenCreatedOn.Second())
 		require.Equal(t, serverA.CreatedOn.Second(), s1Get.CreatedOn.Second())
 		require.Equal(t, serverA.UpdatedOn.Second(), s1Get.UpdatedOn.Second())
@@ -317,7 +426,228 @@ func testPerson(database *store.Store) func(t *testing.T) {
 
 		_, errHistory := database.GetPersonIPHistory(ctx, person1.SteamID, 1000)
 		require.NoError(t, errHistory)
-		require.NoError(t, database.DropPerson(ctx, person1.SteamID))
+		require.NoError(t, database.AnonymizePerson(ctx, person1.SteamID))
+
+		erased := store.NewPerson(person1.SteamID)
+		require.NoError(t, database.GetPersonBySteamID(ctx, person1.SteamID, &erased))
+		require.True(t, erased.Erased)
+		require.Equal(t, store.ErasedPersonName, erased.PersonaName)
+
+		// Updated steam data must not restore the erased identity
+		erased.PersonaName = "restored"
+		erased.RealName = "restored"
+		require.NoError(t, database.SavePerson(ctx, &erased))
+		require.NoError(t, database.GetPersonBySteamID(ctx, person1.SteamID, &erased))
+		require.Equal(t, store.ErasedPersonName, erased.PersonaName)
+		require.Empty(t, erased.RealName)
+		require.NoError(t, database.PurgeRetainedPersonData(ctx, person1.SteamID))
+	}
+}
+
+func testPersonErasure(database *store.Store) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := context.Background()
+
+		server := store.NewServer(golib.RandomString(10), "localhost", rand.Intn(65535)) //nolint:gosec
+		require.NoError(t, database.SaveServer(ctx, &server))
+
+		person := store.NewPerson(randSID())
+		require.NoError(t, database.SavePerson(ctx, &person))
+
+		conn := store.PersonConnection{
+			IPAddr:      net.ParseIP(randIP()),
+			SteamID:     person.SteamID,
+			PersonaName: "erase-me",
+			ServerID:    server.ServerID,
+			CreatedOn:   time.Now(),
+		}
+		require.NoError(t, database.AddConnectionHistory(ctx, &conn))
+		require.NoError(t, database.AddPersonName(ctx, person.SteamID, "erase-me", time.Now()))
+
+		msg := store.PersonMessage{
+			SteamID:     person.SteamID,
+			PersonaName: "erase-me",
+			ServerName:  server.ServerName,
+			ServerID:    server.ServerID,
+			Body:        "my real name is bob",
+			CreatedOn:   time.Now(),
+		}
+		require.NoError(t, database.AddChatHistory(ctx, &msg))
+
+		require.NoError(t, database.AnonymizePerson(ctx, person.SteamID))
+
+		erased := store.NewPerson(person.SteamID)
+		require.NoError(t, database.GetPersonBySteamID(ctx, person.SteamID, &erased))
+		require.True(t, erased.Erased)
+		require.Equal(t, store.ErasedPersonName, erased.PersonaName)
+
+		connections, _, errConns := database.QueryConnectionHistory(ctx, store.ConnectionHistoryQueryFilter{
+			QueryFilter: store.QueryFilter{Limit: 10},
+			SteamID:     person.SteamID,
+		})
+		require.NoError(t, errConns)
+		require.Len(t, connections, 1)
+		require.Equal(t, "0.0.0.0", connections[0].IPAddr.String())
+		require.Equal(t, store.ErasedPersonName, connections[0].PersonaName)
+
+		messages, _, errMessages := database.QueryChatHistory(ctx, store.ChatHistoryQueryFilter{
+			QueryFilter:  store.QueryFilter{Limit: 10},
+			SteamID:      person.SteamID.String(),
+			Unrestricted: true,
+		})
+		require.NoError(t, errMessages)
+		require.Len(t, messages, 1)
+		require.Equal(t, store.ErasedContent, messages[0].Body)
+		require.Equal(t, store.ErasedPersonName, messages[0].PersonaName)
+
+		names, errNames := database.GetPersonNames(ctx, person.SteamID)
+		if errNames != nil {
+			require.ErrorIs(t, errNames, store.ErrNoResult)
+		}
+
+		require.Empty(t, names)
+
+		// New names seen after erasure must not be recorded
+		require.NoError(t, database.AddPersonName(ctx, person.SteamID, "new-name", time.Now()))
+
+		names, errNames = database.GetPersonNames(ctx, person.SteamID)
+		if errNames != nil {
+			require.ErrorIs(t, errNames, store.ErrNoResult)
+		}
+
+		require.Empty(t, names)
+
+		require.NoError(t, database.PurgeRetainedPersonData(ctx, person.SteamID))
+	}
+}
+
+func testPersonNames(database *store.Store) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := context.Background()
+		person := store.NewPerson(randSID())
+		require.NoError(t, database.SavePerson(ctx, &person))
+
+		var (
+			alias = "alias-" + golib.RandomString(10)
+			now   = time.Now().Truncate(time.Second)
+		)
+
+		require.NoError(t, database.AddPersonName(ctx, person.SteamID, alias, now))
+		require.NoError(t, database.AddPersonName(ctx, person.SteamID, alias, now.Add(-time.Hour)))
+		require.NoError(t, database.AddPersonName(ctx, person.SteamID, alias, now.Add(time.Hour)))
+		require.NoError(t, database.AddPersonName(ctx, person.SteamID, "other", now))
+
+		names, errNames := database.GetPersonNames(ctx, person.SteamID)
+		require.NoError(t, errNames)
+		require.Len(t, names, 2)
+		require.Equal(t, alias, names[0].PersonaName)
+		require.True(t, now.Add(-time.Hour).Equal(names[0].FirstSeen))
+		require.True(t, now.Add(time.Hour).Equal(names[0].LastSeen))
+
+		results, errSearch := database.SearchPersonNames(ctx, alias[:12], 10)
+		require.NoError(t, errSearch)
+		require.NotEmpty(t, results)
+		require.Equal(t, person.SteamID, results[0].SteamID)
+	}
+}
+
+func testPersonNotes(database *store.Store) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := context.Background()
+		target := store.NewPerson(randSID())
+		author := store.NewPerson(randSID())
+		editor := store.NewPerson(randSID())
+
+		for _, person := range []*store.Person{&target, &author, &editor} {
+			require.NoError(t, database.SavePerson(ctx, person))
+		}
+
+		note := store.NewPersonNote(target.SteamID, author.SteamID, "first", store.NoteInfo)
+		require.NoError(t, database.SavePersonNote(ctx, &note, author.SteamID))
+
+		note.BodyMD = "second"
+		note.Severity = store.NoteCritical
+		require.NoError(t, database.SavePersonNote(ctx, &note, editor.SteamID))
+
+		notes, errNotes := database.GetPersonNotes(ctx, target.SteamID)
+		require.NoError(t, errNotes)
+		require.Len(t, notes, 1)
+		require.Equal(t, "second", notes[0].BodyMD)
+		require.Equal(t, store.NoteCritical, notes[0].Severity)
+		require.Equal(t, author.SteamID, notes[0].AuthorID)
+		require.Equal(t, 2, notes[0].Revisions)
+
+		revisions, errRevisions := database.GetPersonNoteRevisions(ctx, note.PersonNoteID)
+		require.NoError(t, errRevisions)
+		require.Len(t, revisions, 2)
+		require.Equal(t, "first", revisions[0].BodyMD)
+		require.Equal(t, editor.SteamID, revisions[1].EditorID)
+
+		require.NoError(t, database.DeletePersonNote(ctx, note.PersonNoteID))
+
+		notes, errNotes = database.GetPersonNotes(ctx, target.SteamID)
+		require.NoError(t, errNotes)
+		require.Empty(t, notes)
+	}
+}
+
+func testPersonTimeline(database *store.Store) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := context.Background()
+		person := store.NewPerson(randSID())
+		require.NoError(t, database.SavePerson(ctx, &person))
+
+		now := time.Now().Truncate(time.Second)
+
+		conn := store.PersonConnection{
+			IPAddr:      net.ParseIP(randIP()),
+			SteamID:     person.SteamID,
+			PersonaName: "timeline",
+			CreatedOn:   now.Add(-time.Hour * 2),
+		}
+		require.NoError(t, database.AddConnectionHistory(ctx, &conn))
+		require.NoError(t, database.AddPersonName(ctx, person.SteamID, "timeline", now.Add(-time.Hour)))
+
+		warning := store.PersonWarning{
+			SteamID:   person.SteamID,
+			Reason:    store.Language,
+			Matched:   "badword",
+			CreatedOn: now,
+		}
+		require.NoError(t, database.AddPersonWarning(ctx, &warning))
+
+		filter := store.TimelineQueryFilter{SteamID: person.SteamID, Limit: 2}
+
+		events, cursor, errTimeline := database.GetPersonTimeline(ctx, filter)
+		require.NoError(t, errTimeline)
+		require.Len(t, events, 2)
+		require.NotEmpty(t, cursor)
+		require.Equal(t, store.TimelineWarning, events[0].EventType)
+		require.Equal(t, "badword", events[0].Body)
+		require.Equal(t, store.TimelineName, events[1].EventType)
+
+		filter.Cursor = cursor
+
+		events, cursor, errTimeline = database.GetPersonTimeline(ctx, filter)
+		require.NoError(t, errTimeline)
+		require.Len(t, events, 1)
+		require.Empty(t, cursor)
+		require.Equal(t, store.TimelineConnect, events[0].EventType)
+		require.Equal(t, conn.PersonConnectionID, events[0].ID)
+
+		events, _, errTimeline = database.GetPersonTimeline(ctx, store.TimelineQueryFilter{
+			SteamID: person.SteamID,
+			Types:   []store.TimelineEventType{store.TimelineConnect},
+			Asc:     true,
+		})
+		require.NoError(t, errTimeline)
+		require.Len(t, events, 1)
+
+		_, _, errTimeline = database.GetPersonTimeline(ctx, store.TimelineQueryFilter{
+			SteamID: person.SteamID,
+			Types:   []store.TimelineEventType{"invalid"},
+		})
+		require.ErrorIs(t, errTimeline, store.ErrInvalidTimelineType)
 	}
 }
 
@@ -354,6 +684,58 @@ func testChatHistory(database *store.Store) func(t *testing.T) {
 		// require.True(t, len(hist) >= 2, "History size too small: %d", len(hist))
 		// require.Equal(t, "test-2", hist[0].Msg)
 		require.NoError(t, database.SaveServer(ctx, &newServer))
+
+		player := store.NewPerson(randSID())
+		require.NoError(t, database.SavePerson(ctx, &player))
+
+		for index, body := range []string{
+			"the medic was healing everyone",
+			"medics heal the team",
+			"healing the sniper is pointless",
+			"nice shot sniper",
+			"the medic is healing the heavy",
+		} {
+			msg := store.PersonMessage{
+				SteamID:     player.SteamID,
+				PersonaName: "test-name",
+				ServerName:  newServer.ServerName,
+				ServerID:    newServer.ServerID,
+				Body:        body,
+				CreatedOn:   time.Now().Add(-time.Minute * time.Duration(index)),
+			}
+			require.NoError(t, database.AddChatHistory(ctx, &msg))
+		}
+
+		search := func(query string, cursor string) ([]store.QueryChatHistoryResult, int64) {
+			results, count, errQuery := database.QueryChatHistory(ctx, store.ChatHistoryQueryFilter{
+				QueryFilter: store.QueryFilter{Query: query, OrderBy: "rank", Limit: 2},
+				SteamID:     player.SteamID.String(),
+				Cursor:      cursor,
+			})
+			require.NoError(t, errQuery)
+
+			return results, count
+		}
+
+		// Stemmed match, medics -> medic
+		stemmed, stemmedCount := search("medic", "")
+		require.EqualValues(t, 3, stemmedCount)
+		require.Len(t, stemmed, 2)
+		require.Contains(t, stemmed[0].Headline, "<mark>")
+		require.True(t, stemmed[0].Rank >= stemmed[1].Rank)
+
+		nextPage, _ := search("medic", store.NewChatHistoryCursor(stemmed[1]).Encode())
+		require.Len(t, nextPage, 1)
+		require.NotEqual(t, stemmed[0].PersonMessageID, nextPage[0].PersonMessageID)
+		require.NotEqual(t, stemmed[1].PersonMessageID, nextPage[0].PersonMessageID)
+
+		phrase, phraseCount := search(`"nice shot"`, "")
+		require.EqualValues(t, 1, phraseCount)
+		require.Equal(t, "nice shot sniper", phrase[0].Body)
+
+		// heal & healing share a stem
+		_, excludedCount := search("healing -sniper", "")
+		require.EqualValues(t, 3, excludedCount)
 	}
 }
 
@@ -468,3 +850,1047 @@ func testBanGroup(database *store.Store) func(t *testing.T) {
 		require.EqualError(t, store.ErrNoResult, database.GetBanGroup(context.TODO(), banGroup.GroupID, &bgDeleted).Error())
 	}
 }
+
+func testScheduledTasks(database *store.Store) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := context.Background()
+		below := 8
+		nextRun := time.Now().Add(time.Hour).Truncate(time.Second)
+		task := store.ScheduledTask{
+			Name:           golib.RandomString(10),
+			CronExpression: "0 6 * * *",
+			Timezone:       "UTC",
+			TaskType:       store.TaskCSay,
+			Command:        "Server restart in 5 minutes",
+			ServerSelector: "tag:pub",
+			PlayersBelow:   &below,
+			AlertOnFailure: true,
+			IsEnabled:      true,
+			NextRun:        &nextRun,
+		}
+		require.NoError(t, database.SaveScheduledTask(ctx, &task))
+		require.True(t, task.ScheduledTaskID > 0)
+
+		task.IsEnabled = false
+		require.NoError(t, database.SaveScheduledTask(ctx, &task))
+
+		var fetched store.ScheduledTask
+		require.NoError(t, database.GetScheduledTask(ctx, task.ScheduledTaskID, &fetched))
+		require.Equal(t, task.Name, fetched.Name)
+		require.Equal(t, store.TaskCSay, fetched.TaskType)
+		require.Equal(t, below, *fetched.PlayersBelow)
+		require.Nil(t, fetched.PlayersAtLeast)
+		require.Nil(t, fetched.LastRun)
+		require.False(t, fetched.IsEnabled)
+
+		enabled, errEnabled := database.GetScheduledTasks(ctx, true)
+		require.NoError(t, errEnabled)
+
+		for _, enabledTask := range enabled {
+			require.NotEqual(t, task.ScheduledTaskID, enabledTask.ScheduledTaskID)
+		}
+
+		run := store.ScheduledTaskRun{
+			ScheduledTaskID: task.ScheduledTaskID,
+			Results: []store.ScheduledTaskServerResult{
+				{ServerID: 1, ServerName: "test-1", Response: "ok"},
+				{ServerID: 2, ServerName: "test-2", Error: "timeout"},
+			},
+			StartedOn:  time.Now(),
+			FinishedOn: time.Now(),
+		}
+		require.NoError(t, database.SaveScheduledTaskRun(ctx, &run))
+		require.True(t, run.ScheduledTaskRunID > 0)
+
+		runs, errRuns := database.GetScheduledTaskRuns(ctx, task.ScheduledTaskID, 10)
+		require.NoError(t, errRuns)
+		require.Len(t, runs, 1)
+		require.Equal(t, run.Results, runs[0].Results)
+
+		require.NoError(t, database.DeleteScheduledTask(ctx, task.ScheduledTaskID))
+		require.ErrorIs(t, database.GetScheduledTask(ctx, task.ScheduledTaskID, &fetched), store.ErrNoResult)
+
+		runs, errRuns = database.GetScheduledTaskRuns(ctx, task.ScheduledTaskID, 10)
+		require.NoError(t, errRuns)
+		require.Empty(t, runs)
+	}
+}
+
+func testServerDowntime(database *store.Store) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := context.Background()
+		server := store.NewServer(golib.RandomString(10), "localhost", rand.Intn(65535)) //nolint:gosec
+		require.NoError(t, database.SaveServer(ctx, &server))
+
+		start := time.Now().Add(-time.Hour).Truncate(time.Second)
+		downtime := store.ServerDowntime{ServerID: server.ServerID, Reason: "No response", StartedOn: start}
+		require.NoError(t, database.OpenServerDowntime(ctx, &downtime))
+		require.True(t, downtime.ServerDowntimeID > 0)
+
+		// Only a single open period can exist
+		duplicate := store.ServerDowntime{ServerID: server.ServerID, StartedOn: start.Add(time.Minute)}
+		require.NoError(t, database.OpenServerDowntime(ctx, &duplicate))
+		require.Zero(t, duplicate.ServerDowntimeID)
+
+		downtimes, errDowntimes := database.GetServerDowntimes(ctx, server.ServerID, time.Now())
+		require.NoError(t, errDowntimes)
+		require.Len(t, downtimes, 1)
+		require.Nil(t, downtimes[0].EndedOn)
+
+		end := time.Now().Truncate(time.Second)
+		require.NoError(t, database.CloseServerDowntime(ctx, server.ServerID, end))
+
+		downtimes, errDowntimes = database.GetServerDowntimes(ctx, server.ServerID, start)
+		require.NoError(t, errDowntimes)
+		require.Len(t, downtimes, 1)
+		require.NotNil(t, downtimes[0].EndedOn)
+		require.Equal(t, "No response", downtimes[0].Reason)
+
+		downtimes, errDowntimes = database.GetServerDowntimes(ctx, server.ServerID, end.Add(time.Minute))
+		require.NoError(t, errDowntimes)
+		require.Empty(t, downtimes)
+	}
+}
+
+func testConsoleCommands(database *store.Store) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := context.Background()
+		server := store.NewServer(golib.RandomString(10), "localhost", rand.Intn(65535)) //nolint:gosec
+		require.NoError(t, database.SaveServer(ctx, &server))
+
+		person := store.NewPerson(randSID())
+		require.NoError(t, database.SavePerson(ctx, &person))
+
+		for _, command := range []store.ConsoleCommand{
+			{Command: "status", Response: "hostname: test", Allowed: true},
+			{Command: "rcon_password x", Error: "Command is blocked"},
+			{Command: "users", Response: "0 users", Allowed: true},
+		} {
+			command.ServerID = server.ServerID
+			command.SteamID = person.SteamID
+			command.CreatedOn = time.Now()
+			require.NoError(t, database.SaveConsoleCommand(ctx, &command))
+			require.True(t, command.ConsoleCommandID > 0)
+		}
+
+		commands, errCommands := database.GetConsoleCommands(ctx, server.ServerID, 2)
+		require.NoError(t, errCommands)
+		require.Len(t, commands, 2)
+		require.Equal(t, "rcon_password x", commands[0].Command)
+		require.False(t, commands[0].Allowed)
+		require.Equal(t, "users", commands[1].Command)
+		require.Equal(t, person.SteamID, commands[1].SteamID)
+	}
+}
+
+func testMatchCheckpoints(database *store.Store) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := context.Background()
+		server := store.NewServer(golib.RandomString(10), "localhost", rand.Intn(65535)) //nolint:gosec
+		require.NoError(t, database.SaveServer(ctx, &server))
+
+		now := time.Now().Truncate(time.Second)
+		checkpoint := store.MatchCheckpoint{
+			MatchID:     uuid.Must(uuid.NewV4()),
+			ServerID:    server.ServerID,
+			MapName:     "pl_upward",
+			LastEventOn: now,
+			CreatedOn:   now,
+			UpdatedOn:   now,
+		}
+
+		require.NoError(t, database.SaveMatchCheckpoint(ctx, &checkpoint, []string{"line 1", "line 2"}))
+		require.NoError(t, database.SaveMatchCheckpoint(ctx, &checkpoint, []string{"line 3"}))
+
+		findActive := func() *store.MatchCheckpoint {
+			active, errActive := database.GetActiveMatchCheckpoints(ctx)
+			require.NoError(t, errActive)
+
+			for _, cp := range active {
+				if cp.MatchID == checkpoint.MatchID {
+					return &cp
+				}
+			}
+
+			return nil
+		}
+
+		found := findActive()
+		require.NotNil(t, found)
+		require.Equal(t, []string{"line 1", "line 2", "line 3"}, found.Log)
+
+		// Only a single active match per server
+		other := checkpoint
+		other.MatchID = uuid.Must(uuid.NewV4())
+		require.ErrorIs(t, database.SaveMatchCheckpoint(ctx, &other, nil), store.ErrDuplicate)
+
+		require.NoError(t, database.CloseMatchCheckpoint(ctx, checkpoint.MatchID, store.MatchCheckpointExpired, "timed out"))
+		require.Nil(t, findActive())
+		require.NoError(t, database.PruneMatchCheckpoints(ctx, time.Now().Add(time.Minute)))
+	}
+}
+
+// insertTestMatch inserts a match without any player stats, tests add the stats they need themselves.
+func insertTestMatch(t *testing.T, database *store.Store, serverID int, mapName string, winner logparse.Team,
+	start time.Time, length time.Duration,
+) uuid.UUID {
+	t.Helper()
+
+	matchID := uuid.Must(uuid.NewV4())
+	require.NoError(t, database.Exec(context.Background(), `
+		INSERT INTO match (match_id, server_id, map, title, winner, time_start, time_end)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		matchID, serverID, mapName, "test match", winner, start, start.Add(length)))
+
+	return matchID
+}
+
+func testLeaderboards(database *store.Store) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := context.Background()
+		server := store.NewServer(golib.RandomString(10), "localhost", rand.Intn(65535)) //nolint:gosec
+		require.NoError(t, database.SaveServer(ctx, &server))
+
+		var players []steamid.SID64
+
+		for i := 0; i < 3; i++ {
+			person := store.NewPerson(randSID())
+			require.NoError(t, database.SavePerson(ctx, &person))
+
+			players = append(players, person.SteamID)
+		}
+
+		weapon := store.Weapon{Key: logparse.Weapon(golib.RandomString(10)), Name: golib.RandomString(10)}
+		require.NoError(t, database.SaveWeapon(ctx, &weapon))
+
+		var (
+			// Random week so that other tests and previous runs do not affect the ranks
+			weeks    = rand.Intn(2000) //nolint:gosec
+			previous = store.LeaderboardWindow{
+				Period: store.LeaderboardWeekly,
+				Start:  time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, weeks*7),
+			}
+			current = store.LeaderboardWindow{Period: store.LeaderboardWeekly, Start: previous.Start.AddDate(0, 0, 7)}
+			all     = store.LeaderboardScope{}
+			byID    = store.LeaderboardScope{ServerID: server.ServerID}
+			region  = store.LeaderboardScope{Region: golib.RandomString(6)}
+		)
+
+		kills := func(values ...int) []store.LeaderboardPlayerStats {
+			var stats []store.LeaderboardPlayerStats
+
+			for index, value := range values {
+				if value > 0 {
+					stats = append(stats, store.LeaderboardPlayerStats{SteamID: players[index], Matches: 1, Kills: value})
+				}
+			}
+
+			return stats
+		}
+
+		saveMatch := func(window store.LeaderboardWindow, scopes []store.LeaderboardScope,
+			stats []store.LeaderboardPlayerStats, weapons []store.LeaderboardWeaponStats,
+		) uuid.UUID {
+			matchID := insertTestMatch(t, database, server.ServerID, "pl_upward", logparse.RED, window.Start, time.Minute*30)
+			require.NoError(t, database.LeaderboardSaveMatch(ctx, matchID, []store.LeaderboardWindow{window}, scopes,
+				stats, weapons))
+
+			return matchID
+		}
+
+		first := saveMatch(previous, []store.LeaderboardScope{all, byID, region}, kills(10, 5, 1), nil)
+
+		// Matches are only ever counted once
+		require.ErrorIs(t, database.LeaderboardSaveMatch(ctx, first, []store.LeaderboardWindow{previous},
+			[]store.LeaderboardScope{all}, kills(10, 5, 1), nil), store.ErrDuplicate)
+
+		saveMatch(current, []store.LeaderboardScope{all, byID}, kills(2, 8, 0), []store.LeaderboardWeaponStats{
+			{SteamID: players[0], WeaponID: weapon.WeaponID, Kills: 2},
+			{SteamID: players[1], WeaponID: weapon.WeaponID, Kills: 3},
+		})
+		saveMatch(current, []store.LeaderboardScope{all, region}, kills(0, 0, 20), nil)
+
+		query := func(scope store.LeaderboardScope, window store.LeaderboardWindow, board store.LeaderboardBoard,
+			limit uint64,
+		) []store.LeaderboardEntry {
+			entries, errEntries := database.Leaderboard(ctx, store.LeaderboardQuery{
+				Board:         board,
+				Window:        window,
+				PreviousStart: window.Start.AddDate(0, 0, -7),
+				Scope:         scope,
+				WeaponID:      weapon.WeaponID,
+				Limit:         limit,
+			})
+			require.NoError(t, errEntries)
+
+			return entries
+		}
+
+		type rank struct {
+			steamID  steamid.SID64
+			value    int64
+			rank     int
+			previous int
+		}
+
+		requireRanks := func(expected []rank, entries []store.LeaderboardEntry) {
+			require.Len(t, entries, len(expected))
+
+			for index, entry := range entries {
+				require.Equal(t, expected[index], rank{
+					steamID: entry.SteamID, value: entry.Value, rank: entry.Rank, previous: entry.PreviousRank,
+				})
+			}
+		}
+
+		// The duplicate save did not add the stats again
+		requireRanks([]rank{
+			{players[0], 10, 1, 0}, {players[1], 5, 2, 0}, {players[2], 1, 3, 0},
+		}, query(all, previous, store.LeaderboardKills, 10))
+
+		// Movement is relative to the ranks of the previous week
+		requireRanks([]rank{
+			{players[2], 20, 1, 3}, {players[1], 8, 2, 2}, {players[0], 2, 3, 1},
+		}, query(all, current, store.LeaderboardKills, 10))
+		requireRanks([]rank{{players[2], 20, 1, 3}}, query(all, current, store.LeaderboardKills, 1))
+
+		// Each scope only contains the matches saved for it
+		requireRanks([]rank{
+			{players[1], 8, 1, 2}, {players[0], 2, 2, 1},
+		}, query(byID, current, store.LeaderboardKills, 10))
+		requireRanks([]rank{{players[2], 20, 1, 3}}, query(region, current, store.LeaderboardKills, 10))
+
+		requireRanks([]rank{
+			{players[1], 3, 1, 0}, {players[0], 2, 2, 0},
+		}, query(all, current, store.LeaderboardWeapon, 10))
+
+		// Winners are announced once per week
+		require.NoError(t, database.SaveLeaderboardAnnouncement(ctx, current))
+		require.ErrorIs(t, database.SaveLeaderboardAnnouncement(ctx, current), store.ErrDuplicate)
+		require.NoError(t, database.SaveLeaderboardAnnouncement(ctx, previous))
+	}
+}
+
+func testPlayerRatings(database *store.Store) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := context.Background()
+		server := store.NewServer(golib.RandomString(10), "localhost", rand.Intn(65535)) //nolint:gosec
+		require.NoError(t, database.SaveServer(ctx, &server))
+
+		var players steamid.Collection
+
+		for i := 0; i < 4; i++ {
+			person := store.NewPerson(randSID())
+			require.NoError(t, database.SavePerson(ctx, &person))
+
+			players = append(players, person.SteamID)
+		}
+
+		var (
+			start  = time.Now().Add(-time.Hour * 2).Truncate(time.Second)
+			first  = insertTestMatch(t, database, server.ServerID, "pl_upward", logparse.RED, start, time.Minute*30)
+			second = insertTestMatch(t, database, server.ServerID, "pl_upward", logparse.BLU, start.Add(time.Hour),
+				time.Minute*30)
+			class = logparse.Medic
+		)
+
+		playerRating := func(steamID steamid.SID64, value float64, deviation float64, matches int) store.PlayerRating {
+			return store.PlayerRating{
+				SteamID:     steamID,
+				GameType:    mm.Ultiduo,
+				PlayerClass: class,
+				Rating:      rating.Rating{Rating: value, Deviation: deviation, Volatility: 0.06},
+				Matches:     matches,
+				Wins:        matches,
+				UpdatedOn:   start,
+			}
+		}
+
+		history := func(matchID uuid.UUID, current store.PlayerRating, change float64) store.PlayerRatingHistory {
+			return store.PlayerRatingHistory{
+				MatchID:     matchID,
+				SteamID:     current.SteamID,
+				GameType:    current.GameType,
+				PlayerClass: current.PlayerClass,
+				Rating:      current.Rating,
+				Change:      change,
+				CreatedOn:   current.UpdatedOn,
+			}
+		}
+
+		// Saving the same format & class again updates the existing rating
+		initial := playerRating(players[0], 1500, 350, 1)
+		require.NoError(t, database.PlayerRatingsSave(ctx, []store.PlayerRating{initial},
+			[]store.PlayerRatingHistory{history(first, initial, 0)}))
+
+		updated := playerRating(players[0], 1600, 200, 2)
+		updated.UpdatedOn = start.Add(time.Hour)
+		require.NoError(t, database.PlayerRatingsSave(ctx, []store.PlayerRating{updated},
+			[]store.PlayerRatingHistory{history(second, updated, 100)}))
+
+		ratings, errRatings := database.PlayerRatings(ctx, steamid.Collection{players[0]})
+		require.NoError(t, errRatings)
+		require.Len(t, ratings, 1)
+		require.InDelta(t, 1600, ratings[0].Rating.Rating, 0.001)
+		require.InDelta(t, 200, ratings[0].Deviation, 0.001)
+		require.Equal(t, 2, ratings[0].Matches)
+
+		playerHistory, errHistory := database.PlayerRatingHistory(ctx, players[0], mm.Ultiduo, class, 0)
+		require.NoError(t, errHistory)
+		require.Len(t, playerHistory, 2)
+		require.Equal(t, first, playerHistory[0].MatchID)
+		require.Equal(t, second, playerHistory[1].MatchID)
+		require.InDelta(t, 100, playerHistory[1].Change, 0.001)
+
+		// A match is only rated once per player, the rating update is rolled back with it
+		duplicate := playerRating(players[0], 1700, 150, 3)
+		require.True(t, errors.Is(database.PlayerRatingsSave(ctx, []store.PlayerRating{duplicate},
+			[]store.PlayerRatingHistory{history(first, duplicate, 100)}), store.ErrDuplicate))
+
+		ratings, errRatings = database.PlayerRatings(ctx, steamid.Collection{players[0]})
+		require.NoError(t, errRatings)
+		require.Len(t, ratings, 1)
+		require.InDelta(t, 1600, ratings[0].Rating.Rating, 0.001)
+
+		// Ranked by rating - 2 * deviation, not by the rating alone
+		require.NoError(t, database.PlayerRatingsSave(ctx, []store.PlayerRating{
+			playerRating(players[1], 2000, 350, 3), // 1300
+			playerRating(players[2], 1600, 50, 5),  // 1500
+			playerRating(players[3], 1800, 100, 1), // 1600
+		}, nil))
+
+		entries, count, errLeaderboard := database.RatingLeaderboard(ctx, store.RatingLeaderboardOpts{
+			GameType:    mm.Ultiduo,
+			PlayerClass: class,
+		})
+		require.NoError(t, errLeaderboard)
+		require.Equal(t, int64(4), count)
+		require.Len(t, entries, 4)
+
+		for index, steamID := range []steamid.SID64{players[3], players[2], players[1], players[0]} {
+			require.Equal(t, steamID, entries[index].SteamID)
+			require.Equal(t, index+1, entries[index].Rank)
+		}
+
+		require.InDelta(t, 1600, entries[0].Score, 0.001)
+		require.InDelta(t, 1200, entries[3].Score, 0.001)
+
+		entries, count, errLeaderboard = database.RatingLeaderboard(ctx, store.RatingLeaderboardOpts{
+			GameType:    mm.Ultiduo,
+			PlayerClass: class,
+			MinMatches:  2,
+			Limit:       1,
+			Offset:      1,
+		})
+		require.NoError(t, errLeaderboard)
+		require.Equal(t, int64(3), count)
+		require.Len(t, entries, 1)
+		require.Equal(t, players[1], entries[0].SteamID)
+		require.Equal(t, 2, entries[0].Rank)
+
+		// Recomputing replaces every existing rating & history entry with the replayed matches
+		replayed := map[uuid.UUID]store.PlayerRating{
+			first:  playerRating(players[1], 1550, 300, 1),
+			second: playerRating(players[1], 1650, 250, 2),
+		}
+
+		var order []uuid.UUID
+
+		require.NoError(t, database.PlayerRatingsRecompute(ctx, []uuid.UUID{first, second},
+			func(matchID uuid.UUID) ([]store.PlayerRating, []store.PlayerRatingHistory, error) {
+				order = append(order, matchID)
+				current := replayed[matchID]
+
+				return []store.PlayerRating{current}, []store.PlayerRatingHistory{history(matchID, current, 50)}, nil
+			}))
+		require.Equal(t, []uuid.UUID{first, second}, order)
+
+		ratings, errRatings = database.PlayerRatings(ctx, players)
+		require.NoError(t, errRatings)
+		require.Len(t, ratings, 1)
+		require.Equal(t, players[1], ratings[0].SteamID)
+		require.InDelta(t, 1650, ratings[0].Rating.Rating, 0.001)
+
+		playerHistory, errHistory = database.PlayerRatingHistory(ctx, players[0], mm.Ultiduo, class, 0)
+		require.NoError(t, errHistory)
+		require.Empty(t, playerHistory)
+
+		playerHistory, errHistory = database.PlayerRatingHistory(ctx, players[1], mm.Ultiduo, class, 0)
+		require.NoError(t, errHistory)
+		require.Len(t, playerHistory, 2)
+		require.Equal(t, first, playerHistory[0].MatchID)
+		require.Equal(t, second, playerHistory[1].MatchID)
+
+		// A failed recompute keeps the previous ratings
+		errRate := errors.New("rate failed")
+		require.ErrorIs(t, database.PlayerRatingsRecompute(ctx, []uuid.UUID{first},
+			func(_ uuid.UUID) ([]store.PlayerRating, []store.PlayerRatingHistory, error) {
+				return nil, nil, errRate
+			}), errRate)
+
+		ratings, errRatings = database.PlayerRatings(ctx, players)
+		require.NoError(t, errRatings)
+		require.Len(t, ratings, 1)
+		require.InDelta(t, 1650, ratings[0].Rating.Rating, 0.001)
+	}
+}
+
+func testAnomalies(database *store.Store) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := context.Background()
+		server := store.NewServer(golib.RandomString(10), "localhost", rand.Intn(65535)) //nolint:gosec
+		require.NoError(t, database.SaveServer(ctx, &server))
+
+		var players []steamid.SID64
+
+		for i := 0; i < 2; i++ {
+			person := store.NewPerson(randSID())
+			require.NoError(t, database.SavePerson(ctx, &person))
+
+			players = append(players, person.SteamID)
+		}
+
+		weapon := store.Weapon{Key: logparse.Weapon(golib.RandomString(10)), Name: golib.RandomString(10)}
+		require.NoError(t, database.SaveWeapon(ctx, &weapon))
+
+		unused := store.Weapon{Key: logparse.Weapon(golib.RandomString(10)), Name: golib.RandomString(10)}
+		require.NoError(t, database.SaveWeapon(ctx, &unused))
+
+		start := time.Now().Add(-time.Hour).Truncate(time.Second)
+		matchID := insertTestMatch(t, database, server.ServerID, "cp_process_final", logparse.RED, start, time.Minute*30)
+
+		// Kill distances of 100 & 200 for the first player and 300 for the second
+		for index, distances := range [][]float64{{100, 200}, {300}} {
+			var sum, squareSum float64
+
+			for _, distance := range distances {
+				sum += distance
+				squareSum += distance * distance
+			}
+
+			matchPlayerID := insertTestMatchPlayer(t, database, matchID, players[index], logparse.RED, start)
+
+			const query = `
+				INSERT INTO match_weapon (match_player_id, weapon_id, kills, distance_kills, distance_sum,
+				                          distance_square_sum)
+				VALUES ($1, $2, $3, $4, $5, $6)`
+
+			require.NoError(t, database.Exec(ctx, query, matchPlayerID, weapon.WeaponID, len(distances),
+				len(distances), sum, squareSum))
+			require.NoError(t, database.Exec(ctx, query, matchPlayerID, unused.WeaponID, len(distances), 0, 0, 0))
+		}
+
+		overall, errOverall := database.WeaponsOverall(ctx)
+		require.NoError(t, errOverall)
+
+		results := map[int]store.WeaponsOverallResult{}
+		for _, result := range overall {
+			results[result.WeaponID] = result
+		}
+
+		require.Equal(t, int64(3), results[weapon.WeaponID].DistanceKills)
+		require.InDelta(t, 200, results[weapon.WeaponID].DistanceMean, 0.001)
+		require.InDelta(t, math.Sqrt(20000.0/3), results[weapon.WeaponID].DistanceStdDev, 0.001)
+
+		// Weapons without any kill positions have no distribution rather than a division by zero
+		require.Equal(t, int64(0), results[unused.WeaponID].DistanceKills)
+		require.Zero(t, results[unused.WeaponID].DistanceMean)
+		require.Zero(t, results[unused.WeaponID].DistanceStdDev)
+
+		newAnomaly := func(steamID steamid.SID64, metric store.AnomalyMetric) store.Anomaly {
+			return store.Anomaly{
+				MatchID:     matchID,
+				SteamID:     steamID,
+				Weapon:      weapon,
+				Metric:      metric,
+				Value:       0.9,
+				Baseline:    0.3,
+				Score:       4.5,
+				Samples:     50,
+				Explanation: golib.RandomString(20),
+				Status:      store.AnomalyOpen,
+				CreatedOn:   start,
+			}
+		}
+
+		accuracy := newAnomaly(players[0], store.AnomalyAccuracy)
+		require.NoError(t, database.SaveAnomaly(ctx, &accuracy))
+		require.Positive(t, accuracy.AnomalyID)
+
+		headshots := newAnomaly(players[0], store.AnomalyHeadshots)
+		require.NoError(t, database.SaveAnomaly(ctx, &headshots))
+
+		// The same stat of a player is only flagged once per match
+		duplicate := newAnomaly(players[0], store.AnomalyAccuracy)
+		require.ErrorIs(t, database.SaveAnomaly(ctx, &duplicate), store.ErrDuplicate)
+
+		// Other players and metrics of the same match are not duplicates
+		other := newAnomaly(players[1], store.AnomalyAccuracy)
+		require.NoError(t, database.SaveAnomaly(ctx, &other))
+
+		var fetched store.Anomaly
+
+		require.NoError(t, database.GetAnomaly(ctx, accuracy.AnomalyID, &fetched))
+		require.Equal(t, players[0], fetched.SteamID)
+		require.Equal(t, weapon.Key, fetched.Key)
+		require.Equal(t, "cp_process_final", fetched.MapName)
+		require.Equal(t, store.AnomalyOpen, fetched.Status)
+		require.Zero(t, fetched.ReportID)
+
+		// Reporting an anomaly links it to the report
+		report := store.NewReport()
+		report.SourceID = players[1]
+		report.TargetID = players[0]
+		report.Description = golib.RandomString(120)
+		require.NoError(t, database.SaveReport(ctx, &report))
+
+		fetched.Status = store.AnomalyReported
+		fetched.ReportID = report.ReportID
+		require.NoError(t, database.SaveAnomaly(ctx, &fetched))
+
+		var reported store.Anomaly
+
+		require.NoError(t, database.GetAnomaly(ctx, accuracy.AnomalyID, &reported))
+		require.Equal(t, store.AnomalyReported, reported.Status)
+		require.Equal(t, report.ReportID, reported.ReportID)
+		require.Equal(t, accuracy.Explanation, reported.Explanation)
+
+		headshots.Status = store.AnomalyDismissed
+		require.NoError(t, database.SaveAnomaly(ctx, &headshots))
+
+		query := func(status store.AnomalyStatus) []store.Anomaly {
+			anomalies, count, errAnomalies := database.Anomalies(ctx, store.AnomalyQueryFilter{
+				Status:  status,
+				SteamID: players[0],
+			})
+			require.NoError(t, errAnomalies)
+			require.Equal(t, int64(len(anomalies)), count)
+
+			return anomalies
+		}
+
+		require.Len(t, query(store.AnomalyStatusAny), 2)
+		require.Empty(t, query(store.AnomalyOpen))
+
+		dismissed := query(store.AnomalyDismissed)
+		require.Len(t, dismissed, 1)
+		require.Equal(t, headshots.AnomalyID, dismissed[0].AnomalyID)
+
+		reportedAnomalies := query(store.AnomalyReported)
+		require.Len(t, reportedAnomalies, 1)
+		require.Equal(t, accuracy.AnomalyID, reportedAnomalies[0].AnomalyID)
+		require.Equal(t, report.ReportID, reportedAnomalies[0].ReportID)
+	}
+}
+
+func insertTestMatchPlayer(t *testing.T, database *store.Store, matchID uuid.UUID, steamID steamid.SID64,
+	team logparse.Team, start time.Time,
+) int64 {
+	t.Helper()
+
+	var matchPlayerID int64
+
+	require.NoError(t, database.QueryRow(context.Background(), `
+		INSERT INTO match_player (match_id, steam_id, team, time_start, time_end)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING match_player_id`,
+		matchID, steamID.Int64(), team, start, start.Add(time.Minute*30)).Scan(&matchPlayerID))
+
+	return matchPlayerID
+}
+
+func testMapStats(database *store.Store) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := context.Background()
+		server := store.NewServer(golib.RandomString(10), "localhost", rand.Intn(65535)) //nolint:gosec
+		require.NoError(t, database.SaveServer(ctx, &server))
+
+		var players []steamid.SID64
+
+		for i := 0; i < 3; i++ {
+			person := store.NewPerson(randSID())
+			require.NoError(t, database.SavePerson(ctx, &person))
+
+			players = append(players, person.SteamID)
+		}
+
+		var (
+			mapName = "pl_" + golib.RandomString(10)
+			// The second match starts late in the day and the third just after midnight so that
+			// the week buckets only line up when truncated in UTC.
+			startA = time.Date(2024, time.January, 1, 10, 0, 0, 0, time.UTC)
+			startB = time.Date(2024, time.January, 7, 23, 30, 0, 0, time.UTC)
+			startC = time.Date(2024, time.January, 8, 0, 30, 0, 0, time.UTC)
+			matchA = insertTestMatch(t, database, server.ServerID, mapName, logparse.RED, startA, time.Minute*30)
+			matchB = insertTestMatch(t, database, server.ServerID, mapName, logparse.BLU, startB, time.Minute*20)
+		)
+
+		// A draw with no players or rounds
+		insertTestMatch(t, database, server.ServerID, mapName, logparse.UNASSIGNED, startC, time.Minute*10)
+
+		// Matches on other maps are never included
+		insertTestMatch(t, database, server.ServerID, "pl_"+golib.RandomString(10), logparse.RED, startA, time.Hour)
+
+		addClass := func(matchPlayerID int64, class logparse.PlayerClass, playtime int, kills int) {
+			require.NoError(t, database.Exec(ctx, `
+				INSERT INTO match_player_class (match_player_id, player_class_id, playtime, kills, assists, deaths, damage)
+				VALUES ($1, $2, $3, $4, 1, 1, $5)`, matchPlayerID, class, playtime, kills, kills*100))
+		}
+
+		addHealing := func(matchPlayerID int64, healing int) {
+			require.NoError(t, database.Exec(ctx, `INSERT INTO match_medic (match_player_id, healing) VALUES ($1, $2)`,
+				matchPlayerID, healing))
+		}
+
+		addRound := func(matchID uuid.UUID, round int, start time.Time, length int, capsRed int, capsBlu int) {
+			require.NoError(t, database.Exec(ctx, `
+				INSERT INTO match_round (match_id, round, time_start, length, caps_red, caps_blu)
+				VALUES ($1, $2, $3, $4, $5, $6)`, matchID, round, start, length, capsRed, capsBlu))
+		}
+
+		playerA := insertTestMatchPlayer(t, database, matchA, players[0], logparse.RED, startA)
+		addClass(playerA, logparse.Soldier, 1200, 10)
+		addClass(playerA, logparse.Medic, 600, 1)
+		addHealing(playerA, 1000)
+
+		playerB := insertTestMatchPlayer(t, database, matchA, players[1], logparse.BLU, startA)
+		addClass(playerB, logparse.Scout, 1800, 5)
+
+		playerC := insertTestMatchPlayer(t, database, matchB, players[1], logparse.BLU, startB)
+		addClass(playerC, logparse.Scout, 1200, 7)
+
+		playerD := insertTestMatchPlayer(t, database, matchB, players[2], logparse.RED, startB)
+		addClass(playerD, logparse.Medic, 1200, 0)
+		addHealing(playerD, 5000)
+
+		addRound(matchA, 1, startA, 600, 2, 1)
+		addRound(matchA, 2, startA.Add(time.Minute*10), 900, 0, 1)
+		addRound(matchB, 1, startB, 1200, 1, 0)
+
+		stats, errStats := database.MapStats(ctx, store.MapStatsQuery{
+			MapName: mapName, Bucket: store.MapTrendWeek, PlayerLimit: 2,
+		})
+		require.NoError(t, errStats)
+
+		// Draws count towards the total so the win rates do not sum to 100
+		require.Equal(t, int64(3), stats.Matches)
+		require.Equal(t, int64(3600), stats.Playtime)
+		require.Equal(t, int64(1), stats.RedWins)
+		require.Equal(t, int64(1), stats.BluWins)
+		require.Equal(t, int64(1), stats.Draws)
+		require.InDelta(t, 100.0/3, stats.RedWinRate, 0.001)
+		require.InDelta(t, 100.0/3, stats.BluWinRate, 0.001)
+		require.InDelta(t, 1200, stats.AvgMatchLength, 0.001)
+
+		require.Equal(t, int64(3), stats.Rounds)
+		require.InDelta(t, 900, stats.AvgRoundLength, 0.001)
+		require.InDelta(t, 5.0/3, stats.AvgCapsPerRound, 0.001)
+
+		require.Len(t, stats.Classes, 3)
+		require.Equal(t, logparse.Scout, stats.Classes[0].PlayerClass)
+		require.Equal(t, int64(3000), stats.Classes[0].Playtime)
+		require.InDelta(t, 50, stats.Classes[0].Percent, 0.001)
+		require.Equal(t, int64(12), stats.Classes[0].Kills)
+		require.Equal(t, logparse.Medic, stats.Classes[1].PlayerClass)
+		require.InDelta(t, 30, stats.Classes[1].Percent, 0.001)
+		require.Equal(t, logparse.Soldier, stats.Classes[2].PlayerClass)
+		require.InDelta(t, 20, stats.Classes[2].Percent, 0.001)
+
+		// Ranked by kills and cut to the limit
+		require.Len(t, stats.TopPlayers, 2)
+		require.Equal(t, 1, stats.TopPlayers[0].Rank)
+		require.Equal(t, players[1], stats.TopPlayers[0].SteamID)
+		require.Equal(t, int64(12), stats.TopPlayers[0].Kills)
+		require.Equal(t, int64(2), stats.TopPlayers[0].Matches)
+		require.Equal(t, int64(1), stats.TopPlayers[0].Wins)
+		require.Equal(t, 2, stats.TopPlayers[1].Rank)
+		require.Equal(t, players[0], stats.TopPlayers[1].SteamID)
+		require.Equal(t, int64(11), stats.TopPlayers[1].Kills)
+		require.Equal(t, int64(1800), stats.TopPlayers[1].Playtime)
+		require.Equal(t, int64(1000), stats.TopPlayers[1].Healing)
+
+		require.Len(t, stats.Trend, 2)
+		require.True(t, time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC).Equal(stats.Trend[0].Bucket))
+		require.Equal(t, int64(2), stats.Trend[0].Matches)
+		require.InDelta(t, 50, stats.Trend[0].RedWinRate, 0.001)
+		require.InDelta(t, 50, stats.Trend[0].BluWinRate, 0.001)
+		require.InDelta(t, 1500, stats.Trend[0].AvgMatchLength, 0.001)
+		require.Equal(t, int64(3), stats.Trend[0].Rounds)
+		require.InDelta(t, 900, stats.Trend[0].AvgRoundLength, 0.001)
+		require.InDelta(t, 5.0/3, stats.Trend[0].AvgCapsPerRound, 0.001)
+		require.True(t, time.Date(2024, time.January, 8, 0, 0, 0, 0, time.UTC).Equal(stats.Trend[1].Bucket))
+		require.Equal(t, int64(1), stats.Trend[1].Matches)
+		require.Zero(t, stats.Trend[1].RedWinRate)
+		require.Zero(t, stats.Trend[1].Rounds)
+		require.Zero(t, stats.Trend[1].AvgCapsPerRound)
+
+		daily, errDaily := database.MapStats(ctx, store.MapStatsQuery{
+			MapName: mapName, Bucket: store.MapTrendDay, PlayerLimit: 10,
+		})
+		require.NoError(t, errDaily)
+		require.Len(t, daily.Trend, 3)
+		require.Len(t, daily.TopPlayers, 3)
+		require.Equal(t, int64(5000), daily.TopPlayers[2].Healing)
+
+		_, errUnknown := database.MapStats(ctx, store.MapStatsQuery{
+			MapName: "pl_" + golib.RandomString(10), Bucket: store.MapTrendWeek, PlayerLimit: 10,
+		})
+		require.ErrorIs(t, errUnknown, store.ErrNoResult)
+
+		_, errBucket := database.MapStats(ctx, store.MapStatsQuery{
+			MapName: mapName, Bucket: "hour", PlayerLimit: 10,
+		})
+		require.Error(t, errBucket)
+	}
+}
+
+func testDataExports(database *store.Store) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := context.Background()
+		person := store.NewPerson(randSID())
+		require.NoError(t, database.SavePerson(ctx, &person))
+
+		export := store.NewDataExport(person.SteamID)
+		require.NoError(t, database.SaveDataExport(ctx, &export))
+		require.Positive(t, export.DataExportID)
+
+		incomplete, errIncomplete := database.GetIncompleteDataExports(ctx)
+		require.NoError(t, errIncomplete)
+
+		var incompleteIDs []int64
+		for _, incompleteExport := range incomplete {
+			incompleteIDs = append(incompleteIDs, incompleteExport.DataExportID)
+		}
+
+		require.Contains(t, incompleteIDs, export.DataExportID)
+
+		// Archives are arbitrary binary data
+		archive := []byte{0x50, 0x4b, 0x03, 0x04, 0x00, 0xff, 0x00, 0x7f}
+		export.Status = store.DataExportComplete
+		export.Contents = archive
+		require.NoError(t, database.SaveDataExport(ctx, &export))
+
+		exports, errExports := database.GetDataExports(ctx, person.SteamID)
+		require.NoError(t, errExports)
+		require.Len(t, exports, 1)
+		require.Equal(t, store.DataExportComplete, exports[0].Status)
+		require.Equal(t, int64(len(archive)), exports[0].Size)
+		// Listing never loads the archive
+		require.Empty(t, exports[0].Contents)
+
+		var loaded store.DataExport
+		require.NoError(t, database.GetDataExport(ctx, export.DataExportID, &loaded))
+		require.Equal(t, archive, loaded.Contents)
+		require.Equal(t, person.SteamID, loaded.SteamID)
+
+		old := store.NewDataExport(person.SteamID)
+		old.CreatedOn = time.Now().AddDate(0, 0, -30)
+		require.NoError(t, database.SaveDataExport(ctx, &old))
+
+		require.NoError(t, database.PruneDataExports(ctx, time.Now().AddDate(0, 0, -7)))
+
+		remaining, errRemaining := database.GetDataExports(ctx, person.SteamID)
+		require.NoError(t, errRemaining)
+		require.Len(t, remaining, 1)
+		require.Equal(t, export.DataExportID, remaining[0].DataExportID)
+		require.ErrorIs(t, database.GetDataExport(ctx, old.DataExportID, &loaded), store.ErrNoResult)
+	}
+}
+
+// saveTestMatch parses the fixture log into a new match on the server and saves it. The map load line is
+// added since it's missing from logs downloaded from logs.tf.
+func saveTestMatch(t *testing.T, database *store.Store, serverID int, name string) logparse.Match {
+	t.Helper()
+
+	testFilePath := golib.FindFile(path.Join("testdata", name), "gbans")
+	if testFilePath == "" {
+		t.Skipf("Cant find test file: %s", name)
+	}
+
+	body, errRead := os.ReadFile(testFilePath)
+	require.NoError(t, errRead)
+
+	var (
+		parser = logparse.NewLogParser()
+		match  = logparse.NewMatch(serverID, "test server")
+		lines  = append([]string{`L 02/05/2022 - 06:22:20: Loading map "pl_upward"`}, strings.Split(string(body), "\n")...)
+	)
+
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+
+		result, errResult := parser.Parse(line)
+		require.NoError(t, errResult)
+
+		// Errors are expected for events that don't affect match state, same as the live summarizer
+		_ = match.Apply(result)
+	}
+
+	require.NoError(t, database.MatchSave(context.Background(), &match))
+
+	return match
+}
+
+func testMatchClassKillsHealSpread(database *store.Store) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := context.Background()
+		server := store.NewServer(golib.RandomString(10), "localhost", rand.Intn(65535)) //nolint:gosec
+		require.NoError(t, database.SaveServer(ctx, &server))
+
+		match := saveTestMatch(t, database, server.ServerID, "log_3124689.log")
+
+		var result store.MatchResult
+		require.NoError(t, database.MatchGetByID(ctx, match.MatchID, &result))
+		require.NotEmpty(t, result.Players)
+
+		var healers int
+
+		for _, player := range result.Players {
+			stats := match.PlayerBySteamID(player.SteamID)
+			require.NotNil(t, stats)
+
+			classKills := map[logparse.PlayerClass]store.MatchPlayerClassKill{}
+			for _, classKill := range player.ClassKills {
+				classKills[classKill.PlayerClass] = classKill
+			}
+
+			for class, kills := range stats.ClassKills {
+				require.Equal(t, kills, classKills[class].Kills)
+			}
+
+			for class, deaths := range stats.ClassDeaths {
+				require.Equal(t, deaths, classKills[class].Deaths)
+			}
+
+			for class, assists := range stats.ClassKillAssists {
+				require.Equal(t, assists, classKills[class].Assists)
+			}
+
+			var targets int
+
+			for targetID, target := range stats.TargetInfo {
+				if target.HealingTaken > 0 && targetID.Valid() {
+					targets++
+				}
+			}
+
+			require.Len(t, player.HealSpread, targets)
+
+			for index, target := range player.HealSpread {
+				require.Equal(t, stats.TargetInfo[target.SteamID].HealingTaken, target.Healing)
+
+				// Largest heal target first
+				if index > 0 {
+					require.LessOrEqual(t, target.Healing, player.HealSpread[index-1].Healing)
+				}
+			}
+
+			if targets > 0 {
+				healers++
+			}
+
+			if player.SteamID == steamid.New(76561198164892406) {
+				require.Equal(t, 4, classKills[logparse.Heavy].Kills)
+			}
+		}
+
+		require.Positive(t, healers)
+	}
+}
+
+func testMatchRoundsTimeline(database *store.Store) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := context.Background()
+		server := store.NewServer(golib.RandomString(10), "localhost", rand.Intn(65535)) //nolint:gosec
+		require.NoError(t, database.SaveServer(ctx, &server))
+
+		match := saveTestMatch(t, database, server.ServerID, "log_3124689.log")
+		require.NotEmpty(t, match.Rounds)
+		require.NotEmpty(t, match.Timeline)
+
+		rounds, errRounds := database.MatchGetRounds(ctx, match.MatchID)
+		require.NoError(t, errRounds)
+		require.Len(t, rounds, len(match.Rounds))
+
+		for index, expected := range match.Rounds {
+			round := rounds[index]
+
+			require.Equal(t, index+1, round.Round)
+			require.True(t, expected.TimeStart.Equal(round.TimeStart))
+			require.Equal(t, int(expected.Length.Seconds()), round.Length)
+			require.Equal(t, expected.RoundWinner, round.Winner)
+			require.Equal(t, expected.Score.Red, round.ScoreRed)
+			require.Equal(t, expected.Score.Blu, round.ScoreBlu)
+			require.Equal(t, expected.KillsRed, round.KillsRed)
+			require.Equal(t, expected.KillsBlu, round.KillsBlu)
+			require.Equal(t, expected.DamageRed, round.DamageRed)
+			require.Equal(t, expected.DamageBlu, round.DamageBlu)
+			require.InDelta(t, expected.UbersRed, round.UbersRed, 0.001)
+			require.InDelta(t, expected.UbersBlu, round.UbersBlu, 0.001)
+			require.Equal(t, expected.CapsRed, round.CapsRed)
+			require.Equal(t, expected.CapsBlu, round.CapsBlu)
+			require.InDelta(t, expected.CartProgressRed, round.CartProgressRed, 0.001)
+			require.InDelta(t, expected.CartProgressBlu, round.CartProgressBlu, 0.001)
+		}
+
+		timeline, errTimeline := database.MatchGetTimeline(ctx, match.MatchID)
+		require.NoError(t, errTimeline)
+		require.Len(t, timeline, len(match.Timeline))
+
+		for index, expected := range match.Timeline {
+			bucket := timeline[index]
+
+			require.Equal(t, int(expected.Offset.Seconds()), bucket.Offset)
+			require.Equal(t, expected.KillsRed, bucket.KillsRed)
+			require.Equal(t, expected.KillsBlu, bucket.KillsBlu)
+			require.Equal(t, expected.DamageRed, bucket.DamageRed)
+			require.Equal(t, expected.DamageBlu, bucket.DamageBlu)
+			require.InDelta(t, expected.UbersRed, bucket.UbersRed, 0.001)
+			require.InDelta(t, expected.UbersBlu, bucket.UbersBlu, 0.001)
+		}
+
+		// The rounds are also loaded with the match
+		var result store.MatchResult
+		require.NoError(t, database.MatchGetByID(ctx, match.MatchID, &result))
+		require.Equal(t, rounds, result.Rounds)
+	}
+}
+
+func testMatchObjectives(database *store.Store) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := context.Background()
+		server := store.NewServer(golib.RandomString(10), "localhost", rand.Intn(65535)) //nolint:gosec
+		require.NoError(t, database.SaveServer(ctx, &server))
+
+		match := saveTestMatch(t, database, server.ServerID, "log_payload.log")
+
+		var result store.MatchResult
+		require.NoError(t, database.MatchGetByID(ctx, match.MatchID, &result))
+		require.NotEmpty(t, result.Players)
+
+		var pushers int
+
+		for _, player := range result.Players {
+			expected := match.PlayerBySteamID(player.SteamID)
+			require.NotNil(t, expected)
+			require.Equal(t, expected.Objectives, player.Objectives, player.SteamID.String())
+
+			if player.Objectives.CartPoints > 0 {
+				pushers++
+			}
+		}
+
+		require.Equal(t, 8, pushers)
+
+		rounds, errRounds := database.MatchGetRounds(ctx, match.MatchID)
+		require.NoError(t, errRounds)
+		require.Len(t, rounds, 2)
+
+		for _, round := range rounds {
+			require.InDelta(t, 1.0, round.CartProgressBlu, 0.001)
+			require.Zero(t, round.CartProgressRed)
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package store
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidTimelineType = errors.New("Invalid timeline event type")
+
+type TimelineEventType string
+
+const (
+	TimelineConnect        TimelineEventType = "connect"
+	TimelineDisconnect     TimelineEventType = "disconnect"
+	TimelineChat           TimelineEventType = "chat"
+	TimelineMatch          TimelineEventType = "match"
+	TimelineReportFiled    TimelineEventType = "report_filed"
+	TimelineReportReceived TimelineEventType = "report_received"
+	TimelineBan            TimelineEventType = "ban"
+	TimelineMute           TimelineEventType = "mute"
+	TimelineUnban          TimelineEventType = "unban"
+	TimelineWarning        TimelineEventType = "warning"
+	TimelineName           TimelineEventType = "name"
+)
+
+// PersonWarning is a warning issued to a player by the chat filters or spam detection.
+type PersonWarning struct {
+	PersonWarningID int64         `json:"person_warning_id"`
+	SteamID         steamid.SID64 `json:"steam_id"`
+	PersonMessageID int64         `json:"person_message_id"`
+	ServerID        int           `json:"server_id"`
+	Reason          Reason        `json:"reason"`
+	Matched         string        `json:"matched"`
+	CreatedOn       time.Time     `json:"created_on"`
+}
+
+func (db *Store) AddPersonWarning(ctx context.Context, warning *PersonWarning) error {
+	const query = `
+		INSERT INTO person_warning (steam_id, person_message_id, server_id, reason, matched, created_on)
+		VALUES ($1, case WHEN $2 = 0 THEN null ELSE $2 END, case WHEN $3 = 0 THEN null ELSE $3 END, $4, $5, $6)
+		RETURNING person_warning_id`
+
+	if errQuery := db.
+		QueryRow(ctx, query, warning.SteamID.Int64(), warning.PersonMessageID, warning.ServerID, warning.Reason,
+			warning.Matched, warning.CreatedOn).
+		Scan(&warning.PersonWarningID); errQuery != nil {
+		return Err(errQuery)
+	}
+
+	return nil
+}
+
+// TimelineEvent is a single entry in a players activity timeline. The meaning of Body and Ref depend on the
+// event type, eg. the chat message and match id for chat events, or the ban reason and ban author for bans.
+type TimelineEvent struct {
+	EventType TimelineEventType `json:"event_type"`
+	// ID is the primary key of the source record. It is only unique in combination with the event type.
+	ID        int64     `json:"id"`
+	ServerID  int       `json:"server_id"`
+	Body      string    `json:"body"`
+	Reason    Reason    `json:"reason"`
+	Ref       string    `json:"ref"`
+	CreatedOn time.Time `json:"created_on"`
+}
+
+// TimelineCursor holds the position of the last returned event for keyset pagination of the timeline.
+type TimelineCursor struct {
+	CreatedOn time.Time         `json:"created_on"`
+	EventType TimelineEventType `json:"event_type"`
+	ID        int64             `json:"id"`
+}
+
+// Encode returns the opaque string form of the cursor used by clients.
+func (c TimelineCursor) Encode() string {
+	body, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(body)
+}
+
+func decodeTimelineCursor(value string) (TimelineCursor, error) {
+	var cursor TimelineCursor
+
+	body, errDecode := base64.RawURLEncoding.DecodeString(value)
+	if errDecode != nil {
+		return cursor, errors.Wrap(errDecode, "Invalid cursor")
+	}
+
+	if errUnmarshal := json.Unmarshal(body, &cursor); errUnmarshal != nil {
+		return cursor, errors.Wrap(errUnmarshal, "Invalid cursor")
+	}
+
+	return cursor, nil
+}
+
+type TimelineQueryFilter struct {
+	SteamID steamid.SID64 `json:"-"`
+	// Types limits the results to the provided event types. All types are returned when empty.
+	Types    []TimelineEventType `json:"types"`
+	ServerID int                 `json:"server_id"`
+	After    *time.Time          `json:"after"`
+	Before   *time.Time          `json:"before"`
+	// Asc returns the oldest events first, the default is newest first.
+	Asc    bool   `json:"asc"`
+	Limit  uint64 `json:"limit"`
+	Cursor string `json:"cursor"`
+}
+
+// timelineSource returns the query for a single type of event. All sources must return the same columns.
+func timelineSource(eventType TimelineEventType, steamID int64) (sq.SelectBuilder, error) {
+	columns := func(id string, serverID string, body string, reason string, ref string, createdOn string) []string {
+		return []string{
+			fmt.Sprintf("'%s'::text AS event_type", eventType),
+			id + "::bigint AS id",
+			fmt.Sprintf("coalesce(%s, 0)::int AS server_id", serverID),
+			fmt.Sprintf("coalesce(%s, '')::text AS body", body),
+			reason + "::int AS reason",
+			fmt.Sprintf("coalesce(%s, '')::text AS ref", ref),
+			createdOn + "::timestamptz AS created_on",
+		}
+	}
+
+	switch eventType {
+	case TimelineConnect:
+		return sq.Select(columns("c.person_connection_id", "c.server_id", "c.persona_name", "0",
+			"host(c.ip_addr)", "c.created_on")...).
+			From("person_connections c").
+			Where(sq.Eq{"c.steam_id": steamID}), nil
+	case TimelineDisconnect:
+		return sq.Select(columns("c.person_connection_id", "c.server_id", "c.persona_name", "0",
+			"host(c.ip_addr)", "c.disconnected_on")...).
+			From("person_connections c").
+			Where(sq.And{sq.Eq{"c.steam_id": steamID}, sq.NotEq{"c.disconnected_on": nil}}), nil
+	case TimelineChat:
+		return sq.Select(columns("m.person_message_id", "m.server_id", "m.body", "0",
+			"m.match_id::text", "m.created_on")...).
+			From("person_messages m").
+			Where(sq.Eq{"m.steam_id": steamID}), nil
+	case TimelineMatch:
+		return sq.Select(columns("mp.match_player_id", "m.server_id", "m.map", "0",
+			"m.match_id::text", "mp.time_start")...).
+			From("match_player mp").
+			Join("match m ON m.match_id = mp.match_id").
+			Where(sq.Eq{"mp.steam_id": steamID}), nil
+	case TimelineReportFiled:
+		return sq.Select(columns("r.report_id", "0", "r.description", "r.reason",
+			"r.reported_id::text", "r.created_on")...).
+			From("report r").
+			Where(sq.Eq{"r.author_id": steamID, "r.deleted": false}), nil
+	case TimelineReportReceived:
+		return sq.Select(columns("r.report_id", "0", "r.description", "r.reason",
+			"r.author_id::text", "r.created_on")...).
+			From("report r").
+			Where(sq.Eq{"r.reported_id": steamID, "r.deleted": false}), nil
+	case TimelineBan:
+		return sq.Select(columns("b.ban_id", "0", "b.reason_text", "b.reason",
+			"b.source_id::text", "b.created_on")...).
+			From("ban b").
+			Where(sq.Eq{"b.target_id": steamID, "b.ban_type": Banned}), nil
+	case TimelineMute:
+		return sq.Select(columns("b.ban_id", "0", "b.reason_text", "b.reason",
+			"b.source_id::text", "b.created_on")...).
+			From("ban b").
+			Where(sq.Eq{"b.target_id": steamID, "b.ban_type": NoComm}), nil
+	case TimelineUnban:
+		return sq.Select(columns("b.ban_id", "0", "b.unban_reason_text", "b.reason",
+			"b.source_id::text", "b.updated_on")...).
+			From("ban b").
+			Where(sq.Eq{"b.target_id": steamID, "b.deleted": true}), nil
+	case TimelineWarning:
+		return sq.Select(columns("w.person_warning_id", "w.server_id", "w.matched", "w.reason",
+			"w.person_message_id::text", "w.created_on")...).
+			From("person_warning w").
+			Where(sq.Eq{"w.steam_id": steamID}), nil
+	case TimelineName:
+		return sq.Select(columns("n.person_name_id", "0", "n.persona_name", "0",
+			"null", "n.first_seen")...).
+			From("person_names n").
+			Where(sq.Eq{"n.steam_id": steamID}), nil
+	}
+
+	return sq.SelectBuilder{}, ErrInvalidTimelineType
+}
+
+var timelineEventTypes = []TimelineEventType{
+	TimelineConnect, TimelineDisconnect, TimelineChat, TimelineMatch, TimelineReportFiled,
+	TimelineReportReceived, TimelineBan, TimelineMute, TimelineUnban, TimelineWarning, TimelineName,
+}
+
+func (db *Store) timelineQuery(filter TimelineQueryFilter, limit uint64) (string, []any, error) {
+	eventTypes := filter.Types
+	if len(eventTypes) == 0 {
+		eventTypes = timelineEventTypes
+	}
+
+	var union sq.SelectBuilder
+
+	for idx, eventType := range eventTypes {
+		source, errSource := timelineSource(eventType, filter.SteamID.Int64())
+		if errSource != nil {
+			return "", nil, errSource
+		}
+
+		if idx == 0 {
+			union = source
+
+			continue
+		}
+
+		sourceQuery, sourceArgs, errSourceQuery := source.ToSql()
+		if errSourceQuery != nil {
+			return "", nil, Err(errSourceQuery)
+		}
+
+		union = union.Suffix("UNION ALL "+sourceQuery, sourceArgs...)
+	}
+
+	builder := db.sb.
+		Select("t.event_type", "t.id", "t.server_id", "t.body", "t.reason", "t.ref", "t.created_on").
+		FromSelect(union, "t").
+		Limit(limit)
+
+	if filter.ServerID > 0 {
+		builder = builder.Where(sq.Eq{"t.server_id": filter.ServerID})
+	}
+
+	if filter.After != nil {
+		builder = builder.Where(sq.GtOrEq{"t.created_on": *filter.After})
+	}
+
+	if filter.Before != nil {
+		builder = builder.Where(sq.Lt{"t.created_on": *filter.Before})
+	}
+
+	if filter.Cursor != "" {
+		cursor, errCursor := decodeTimelineCursor(filter.Cursor)
+		if errCursor != nil {
+			return "", nil, errCursor
+		}
+
+		operator := "<"
+		if filter.Asc {
+			operator = ">"
+		}
+
+		builder = builder.Where(fmt.Sprintf("(t.created_on, t.event_type, t.id) %s (?, ?, ?)", operator),
+			cursor.CreatedOn, string(cursor.EventType), cursor.ID)
+	}
+
+	if filter.Asc {
+		builder = builder.OrderBy("t.created_on", "t.event_type", "t.id")
+	} else {
+		builder = builder.OrderBy("t.created_on DESC", "t.event_type DESC", "t.id DESC")
+	}
+
+	query, args, errQuery := builder.ToSql()
+	if errQuery != nil {
+		return "", nil, Err(errQuery)
+	}
+
+	return query, args, nil
+}
+
+// GetPersonTimeline returns the players activity from all sources merged in chronological order. If there
+// are more results available, the encoded cursor pointing to the next page is also returned.
+func (db *Store) GetPersonTimeline(ctx context.Context, filter TimelineQueryFilter) ([]TimelineEvent, string, error) {
+	limit := filter.Limit
+	if limit == 0 || limit > 500 {
+		limit = 100
+	}
+
+	// Fetch an extra row to determine if there is another page.
+	query, args, errQuery := db.timelineQuery(filter, limit+1)
+	if errQuery != nil {
+		return nil, "", errQuery
+	}
+
+	rows, errRows := db.Query(ctx, query, args...)
+	if errRows != nil {
+		return nil, "", Err(errRows)
+	}
+
+	defer rows.Close()
+
+	events := []TimelineEvent{}
+
+	for rows.Next() {
+		var event TimelineEvent
+
+		if errScan := rows.Scan(&event.EventType, &event.ID, &event.ServerID, &event.Body, &event.Reason,
+			&event.Ref, &event.CreatedOn); errScan != nil {
+			return nil, "", Err(errScan)
+		}
+
+		events = append(events, event)
+	}
+
+	if rows.Err() != nil {
+		return nil, "", Err(rows.Err())
+	}
+
+	var nextCursor string
+
+	if uint64(len(events)) > limit {
+		events = events[:limit]
+		last := events[len(events)-1]
+		nextCursor = TimelineCursor{CreatedOn: last.CreatedOn, EventType: last.EventType, ID: last.ID}.Encode()
+	}
+
+	return events, nextCursor, nil
+}
@@ -0,0 +1,535 @@
+// Package a2s implements a client for the source engine server query protocol. It supports the A2S_INFO,
+// A2S_PLAYER and A2S_RULES queries, including challenge handling and split (optionally compressed) responses.
+// https://developer.valvesoftware.com/wiki/Server_queries
+package a2s
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"math"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	headerSimple int32 = -1
+	headerSplit  int32 = -2
+
+	requestInfo    byte = 'T'
+	requestPlayers byte = 'U'
+	requestRules   byte = 'V'
+
+	responseChallenge byte = 'A'
+	responseInfo      byte = 'I'
+	responsePlayers   byte = 'D'
+	responseRules     byte = 'E'
+
+	infoPayload = "Source Engine Query\x00"
+
+	// maxPacketSize is the largest packet the server will send.
+	maxPacketSize = 1400
+	// maxChallenges limits how many times the server can respond with a new challenge before giving up.
+	maxChallenges = 3
+	// maxSplitPackets is the largest number of packets a split response can contain.
+	maxSplitPackets = 32
+	compressedFlag  = 0x80000000
+
+	// edf flags for the optional fields of the info response
+	edfGameID   = 0x01
+	edfSteamID  = 0x10
+	edfKeywords = 0x20
+	edfSTV      = 0x40
+	edfPort     = 0x80
+
+	defaultTimeout = time.Second * 3
+)
+
+var (
+	ErrInvalidResponse = errors.New("Invalid response")
+	ErrTooManyRequests = errors.New("Too many challenge responses")
+)
+
+// ServerType is the type of the server.
+type ServerType byte
+
+const (
+	ServerTypeDedicated ServerType = 'd'
+	ServerTypeListen    ServerType = 'l'
+	ServerTypeProxy     ServerType = 'p'
+)
+
+// Environment is the operating system of the server.
+type Environment byte
+
+const (
+	EnvironmentLinux   Environment = 'l'
+	EnvironmentWindows Environment = 'w'
+	EnvironmentMac     Environment = 'm'
+)
+
+// Info is the response to an A2S_INFO query.
+type Info struct {
+	Protocol    uint8       `json:"protocol"`
+	Name        string      `json:"name"`
+	Map         string      `json:"map"`
+	Folder      string      `json:"folder"`
+	Game        string      `json:"game"`
+	AppID       uint16      `json:"app_id"`
+	Players     uint8       `json:"players"`
+	MaxPlayers  uint8       `json:"max_players"`
+	Bots        uint8       `json:"bots"`
+	ServerType  ServerType  `json:"server_type"`
+	Environment Environment `json:"environment"`
+	// Password is set when the server requires a password to join.
+	Password bool   `json:"password"`
+	VAC      bool   `json:"vac"`
+	Version  string `json:"version"`
+	// The following fields are optional and only set when reported by the server.
+	Port     uint16 `json:"port"`
+	SteamID  uint64 `json:"steam_id"`
+	STVPort  uint16 `json:"stv_port"`
+	STVName  string `json:"stv_name"`
+	Keywords string `json:"keywords"`
+	GameID   uint64 `json:"game_id"`
+}
+
+// Player is a single player entry of an A2S_PLAYER response.
+type Player struct {
+	Index    uint8         `json:"index"`
+	Name     string        `json:"name"`
+	Score    int32         `json:"score"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Client queries a single server.
+type Client struct {
+	addr    string
+	timeout time.Duration
+}
+
+// NewClient creates a new client for the server at the host:port address. A timeout of 0 uses the default
+// of 3 seconds.
+func NewClient(addr string, timeout time.Duration) *Client {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	return &Client{addr: addr, timeout: timeout}
+}
+
+// Info performs an A2S_INFO query.
+func (c *Client) Info(ctx context.Context) (Info, error) {
+	resp, errQuery := c.query(ctx, requestInfo, []byte(infoPayload), nil, responseInfo)
+	if errQuery != nil {
+		return Info{}, errQuery
+	}
+
+	return parseInfo(resp)
+}
+
+// Players performs an A2S_PLAYER query.
+func (c *Client) Players(ctx context.Context) ([]Player, error) {
+	resp, errQuery := c.query(ctx, requestPlayers, nil, []byte{0xFF, 0xFF, 0xFF, 0xFF}, responsePlayers)
+	if errQuery != nil {
+		return nil, errQuery
+	}
+
+	return parsePlayers(resp)
+}
+
+// Rules performs an A2S_RULES query, returning the server cvars.
+func (c *Client) Rules(ctx context.Context) (map[string]string, error) {
+	resp, errQuery := c.query(ctx, requestRules, nil, []byte{0xFF, 0xFF, 0xFF, 0xFF}, responseRules)
+	if errQuery != nil {
+		return nil, errQuery
+	}
+
+	return parseRules(resp)
+}
+
+// query sends the request, resending it with the challenge number when the server responds with a challenge.
+// The returned payload has the packet header and response type removed.
+func (c *Client) query(ctx context.Context, request byte, payload []byte, challenge []byte, expected byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+
+	conn, errDial := dialer.DialContext(ctx, "udp", c.addr)
+	if errDial != nil {
+		return nil, errors.Wrap(errDial, "Failed to dial server")
+	}
+
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if errDeadline := conn.SetDeadline(deadline); errDeadline != nil {
+			return nil, errors.Wrap(errDeadline, "Failed to set deadline")
+		}
+	}
+
+	for attempt := 0; attempt < maxChallenges; attempt++ {
+		packet := bytes.NewBuffer([]byte{0xFF, 0xFF, 0xFF, 0xFF, request})
+		packet.Write(payload)
+		packet.Write(challenge)
+
+		if _, errWrite := conn.Write(packet.Bytes()); errWrite != nil {
+			return nil, errors.Wrap(errWrite, "Failed to send request")
+		}
+
+		resp, errRead := readResponse(conn)
+		if errRead != nil {
+			return nil, errRead
+		}
+
+		if len(resp) < 1 {
+			return nil, ErrInvalidResponse
+		}
+
+		switch resp[0] {
+		case expected:
+			return resp[1:], nil
+		case responseChallenge:
+			if len(resp) < 5 { //nolint:gomnd
+				return nil, ErrInvalidResponse
+			}
+
+			challenge = resp[1:5]
+		default:
+			return nil, errors.Wrapf(ErrInvalidResponse, "unexpected response type %x", resp[0])
+		}
+	}
+
+	return nil, ErrTooManyRequests
+}
+
+// readResponse reads a complete response, reassembling split packets.
+func readResponse(conn net.Conn) ([]byte, error) {
+	var (
+		packets  [][]byte
+		total    = -1
+		received = 0
+		id       uint32
+	)
+
+	for total < 0 || received < total {
+		buf := make([]byte, maxPacketSize)
+
+		size, errRead := conn.Read(buf)
+		if errRead != nil {
+			return nil, errors.Wrap(errRead, "Failed to read response")
+		}
+
+		if size < 4 { //nolint:gomnd
+			return nil, ErrInvalidResponse
+		}
+
+		reader := newPacketReader(buf[:size])
+
+		switch reader.int32() {
+		case headerSimple:
+			return reader.rest(), nil
+		case headerSplit:
+			packetID := reader.uint32()
+			packetTotal := int(reader.uint8())
+			number := int(reader.uint8())
+
+			if total < 0 {
+				if packetTotal == 0 || packetTotal > maxSplitPackets {
+					return nil, errors.Wrapf(ErrInvalidResponse, "invalid split packet count %d", packetTotal)
+				}
+
+				id = packetID
+				total = packetTotal
+				packets = make([][]byte, total)
+			}
+
+			if packetID != id || packetTotal != total || number >= total {
+				return nil, errors.Wrap(ErrInvalidResponse, "mismatched split packet")
+			}
+
+			// Max packet size, unused.
+			reader.uint16()
+
+			if reader.err != nil {
+				return nil, reader.err
+			}
+
+			if packets[number] == nil {
+				received++
+			}
+
+			packets[number] = reader.rest()
+		default:
+			return nil, errors.Wrap(ErrInvalidResponse, "unknown packet header")
+		}
+	}
+
+	data := bytes.Join(packets, nil)
+
+	if id&compressedFlag != 0 {
+		return decompress(data)
+	}
+
+	// The reassembled payload includes the simple header.
+	reader := newPacketReader(data)
+	if reader.int32() != headerSimple || reader.err != nil {
+		return nil, errors.Wrap(ErrInvalidResponse, "invalid split payload header")
+	}
+
+	return reader.rest(), nil
+}
+
+func decompress(data []byte) ([]byte, error) {
+	reader := newPacketReader(data)
+	size := reader.uint32()
+	checksum := reader.uint32()
+
+	if reader.err != nil {
+		return nil, reader.err
+	}
+
+	if size > math.MaxUint16*maxSplitPackets {
+		return nil, errors.Wrap(ErrInvalidResponse, "compressed payload too large")
+	}
+
+	decompressed, errRead := io.ReadAll(io.LimitReader(bzip2.NewReader(bytes.NewReader(reader.rest())), int64(size)))
+	if errRead != nil {
+		return nil, errors.Wrap(errRead, "Failed to decompress response")
+	}
+
+	if uint32(len(decompressed)) != size || crc32.ChecksumIEEE(decompressed) != checksum {
+		return nil, errors.Wrap(ErrInvalidResponse, "compressed payload checksum mismatch")
+	}
+
+	decompressedReader := newPacketReader(decompressed)
+	if decompressedReader.int32() != headerSimple || decompressedReader.err != nil {
+		return nil, errors.Wrap(ErrInvalidResponse, "invalid compressed payload header")
+	}
+
+	return decompressedReader.rest(), nil
+}
+
+func parseInfo(data []byte) (Info, error) {
+	reader := newPacketReader(data)
+	info := Info{
+		Protocol:    reader.uint8(),
+		Name:        reader.string(),
+		Map:         reader.string(),
+		Folder:      reader.string(),
+		Game:        reader.string(),
+		AppID:       reader.uint16(),
+		Players:     reader.uint8(),
+		MaxPlayers:  reader.uint8(),
+		Bots:        reader.uint8(),
+		ServerType:  ServerType(reader.uint8()),
+		Environment: Environment(reader.uint8()),
+		Password:    reader.uint8() == 1,
+		VAC:         reader.uint8() == 1,
+		Version:     reader.string(),
+	}
+
+	if reader.err != nil {
+		return Info{}, reader.err
+	}
+
+	// The extra data flag is optional
+	if reader.remaining() == 0 {
+		return info, nil
+	}
+
+	edf := reader.uint8()
+
+	if edf&edfPort != 0 {
+		info.Port = reader.uint16()
+	}
+
+	if edf&edfSteamID != 0 {
+		info.SteamID = reader.uint64()
+	}
+
+	if edf&edfSTV != 0 {
+		info.STVPort = reader.uint16()
+		info.STVName = reader.string()
+	}
+
+	if edf&edfKeywords != 0 {
+		info.Keywords = reader.string()
+	}
+
+	if edf&edfGameID != 0 {
+		info.GameID = reader.uint64()
+	}
+
+	if reader.err != nil {
+		return Info{}, reader.err
+	}
+
+	return info, nil
+}
+
+func parsePlayers(data []byte) ([]Player, error) {
+	reader := newPacketReader(data)
+	count := int(reader.uint8())
+	players := make([]Player, 0, count)
+
+	// The count is a single byte and will overflow on servers with more than 255 players, so the
+	// entries are read until the end of the payload instead.
+	for reader.err == nil && reader.remaining() > 0 {
+		player := Player{
+			Index:    reader.uint8(),
+			Name:     reader.string(),
+			Score:    reader.int32(),
+			Duration: time.Duration(float64(reader.float32()) * float64(time.Second)),
+		}
+
+		if reader.err != nil {
+			return nil, reader.err
+		}
+
+		players = append(players, player)
+	}
+
+	if reader.err != nil {
+		return nil, reader.err
+	}
+
+	return players, nil
+}
+
+func parseRules(data []byte) (map[string]string, error) {
+	reader := newPacketReader(data)
+	count := int(reader.uint16())
+	rules := make(map[string]string, count)
+
+	for i := 0; i < count && reader.remaining() > 0; i++ {
+		name := reader.string()
+		value := reader.string()
+
+		if reader.err != nil {
+			return nil, reader.err
+		}
+
+		rules[name] = value
+	}
+
+	if reader.err != nil {
+		return nil, reader.err
+	}
+
+	return rules, nil
+}
+
+// packetReader reads little endian values from a packet. Once a read fails, all subsequent reads
+// return zero values and err is set.
+type packetReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func newPacketReader(data []byte) *packetReader {
+	return &packetReader{data: data}
+}
+
+func (r *packetReader) remaining() int {
+	return len(r.data) - r.pos
+}
+
+func (r *packetReader) next(size int) []byte {
+	if r.err != nil {
+		return nil
+	}
+
+	if r.remaining() < size {
+		r.err = errors.Wrap(ErrInvalidResponse, "unexpected end of packet")
+
+		return nil
+	}
+
+	value := r.data[r.pos : r.pos+size]
+	r.pos += size
+
+	return value
+}
+
+func (r *packetReader) uint8() uint8 {
+	value := r.next(1)
+	if value == nil {
+		return 0
+	}
+
+	return value[0]
+}
+
+func (r *packetReader) uint16() uint16 {
+	value := r.next(2) //nolint:gomnd
+	if value == nil {
+		return 0
+	}
+
+	return binary.LittleEndian.Uint16(value)
+}
+
+func (r *packetReader) uint32() uint32 {
+	value := r.next(4) //nolint:gomnd
+	if value == nil {
+		return 0
+	}
+
+	return binary.LittleEndian.Uint32(value)
+}
+
+func (r *packetReader) int32() int32 {
+	return int32(r.uint32())
+}
+
+func (r *packetReader) uint64() uint64 {
+	value := r.next(8) //nolint:gomnd
+	if value == nil {
+		return 0
+	}
+
+	return binary.LittleEndian.Uint64(value)
+}
+
+func (r *packetReader) float32() float32 {
+	return math.Float32frombits(r.uint32())
+}
+
+func (r *packetReader) string() string {
+	if r.err != nil {
+		return ""
+	}
+
+	end := bytes.IndexByte(r.data[r.pos:], 0)
+	if end < 0 {
+		r.err = errors.Wrap(ErrInvalidResponse, "unterminated string")
+
+		return ""
+	}
+
+	value := string(r.data[r.pos : r.pos+end])
+	r.pos += end + 1
+
+	return value
+}
+
+func (r *packetReader) rest() []byte {
+	if r.err != nil {
+		return nil
+	}
+
+	value := r.data[r.pos:]
+	r.pos = len(r.data)
+
+	return value
+}
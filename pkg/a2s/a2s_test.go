@@ -0,0 +1,260 @@
+package a2s_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/leighmacdonald/gbans/pkg/a2s"
+	"github.com/stretchr/testify/require"
+)
+
+var challenge = []byte{0x01, 0x02, 0x03, 0x04}
+
+type packetBuilder struct {
+	bytes.Buffer
+}
+
+func newPacket(header int32) *packetBuilder {
+	var builder packetBuilder
+	builder.int32(header)
+
+	return &builder
+}
+
+func (b *packetBuilder) int32(value int32) *packetBuilder {
+	_ = binary.Write(&b.Buffer, binary.LittleEndian, value)
+
+	return b
+}
+
+func (b *packetBuilder) uint16(value uint16) *packetBuilder {
+	_ = binary.Write(&b.Buffer, binary.LittleEndian, value)
+
+	return b
+}
+
+func (b *packetBuilder) uint64(value uint64) *packetBuilder {
+	_ = binary.Write(&b.Buffer, binary.LittleEndian, value)
+
+	return b
+}
+
+func (b *packetBuilder) float32(value float32) *packetBuilder {
+	return b.int32(int32(math.Float32bits(value)))
+}
+
+func (b *packetBuilder) str(value string) *packetBuilder {
+	b.WriteString(value)
+	b.WriteByte(0)
+
+	return b
+}
+
+func (b *packetBuilder) raw(values ...byte) *packetBuilder {
+	b.Write(values)
+
+	return b
+}
+
+// split breaks the payload into source engine split packets.
+func split(payload []byte, packetID int32, size int) [][]byte {
+	var chunks [][]byte
+	for len(payload) > size {
+		chunks = append(chunks, payload[:size])
+		payload = payload[size:]
+	}
+
+	chunks = append(chunks, payload)
+
+	packets := make([][]byte, len(chunks))
+	for idx, chunk := range chunks {
+		packets[idx] = newPacket(-2).int32(packetID).raw(byte(len(chunks)), byte(idx)).uint16(1248).raw(chunk...).Bytes()
+	}
+
+	return packets
+}
+
+// standIn is a local udp server that requires a challenge for every query.
+type standIn struct {
+	conn    *net.UDPConn
+	rules   [][]byte
+	rulesMu sync.Mutex
+}
+
+func newStandIn(t *testing.T) *standIn {
+	t.Helper()
+
+	conn, errListen := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, errListen)
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	server := &standIn{conn: conn}
+
+	go server.serve()
+
+	return server
+}
+
+// setRules sets the packets sent in response to rules queries.
+func (s *standIn) setRules(packets [][]byte) {
+	s.rulesMu.Lock()
+	defer s.rulesMu.Unlock()
+
+	s.rules = packets
+}
+
+func (s *standIn) addr() string {
+	return s.conn.LocalAddr().String()
+}
+
+func (s *standIn) respond(addr *net.UDPAddr, packets ...[]byte) {
+	// Sent in reverse to ensure out of order packets are handled
+	for i := len(packets) - 1; i >= 0; i-- {
+		_, _ = s.conn.WriteToUDP(packets[i], addr)
+	}
+}
+
+func (s *standIn) serve() {
+	buf := make([]byte, 1400)
+
+	for {
+		size, addr, errRead := s.conn.ReadFromUDP(buf)
+		if errRead != nil {
+			return
+		}
+
+		request := buf[:size]
+		if size < 5 || !bytes.HasPrefix(request, []byte{0xFF, 0xFF, 0xFF, 0xFF}) {
+			continue
+		}
+
+		if !bytes.HasSuffix(request, challenge) {
+			s.respond(addr, newPacket(-1).raw('A').raw(challenge...).Bytes())
+
+			continue
+		}
+
+		switch request[4] {
+		case 'T':
+			s.respond(addr, newPacket(-1).raw('I', 17).
+				str("Uncletopia | US-1").str("pl_upward").str("tf").str("Team Fortress").
+				uint16(440).raw(24, 32, 2, 'd', 'l', 0, 1).str("8622567").
+				raw(0x80|0x10|0x40|0x20|0x01).
+				uint16(27015).uint64(85568392920040000).uint16(27020).str("SourceTV").
+				str("alltalk,nocrits").uint64(440).Bytes())
+		case 'U':
+			s.respond(addr, newPacket(-1).raw('D', 2).
+				raw(0).str("player one").int32(10).float32(120.5).
+				raw(0).str("player two").int32(-1).float32(30).Bytes())
+		case 'V':
+			s.rulesMu.Lock()
+			s.respond(addr, s.rules...)
+			s.rulesMu.Unlock()
+		}
+	}
+}
+
+func TestInfo(t *testing.T) {
+	server := newStandIn(t)
+	client := a2s.NewClient(server.addr(), time.Second)
+
+	info, errInfo := client.Info(context.Background())
+	require.NoError(t, errInfo)
+	require.Equal(t, "Uncletopia | US-1", info.Name)
+	require.Equal(t, "pl_upward", info.Map)
+	require.Equal(t, uint16(440), info.AppID)
+	require.Equal(t, uint8(24), info.Players)
+	require.Equal(t, uint8(32), info.MaxPlayers)
+	require.Equal(t, uint8(2), info.Bots)
+	require.Equal(t, a2s.ServerTypeDedicated, info.ServerType)
+	require.Equal(t, a2s.EnvironmentLinux, info.Environment)
+	require.False(t, info.Password)
+	require.True(t, info.VAC)
+	require.Equal(t, "8622567", info.Version)
+	require.Equal(t, uint16(27015), info.Port)
+	require.Equal(t, uint64(85568392920040000), info.SteamID)
+	require.Equal(t, uint16(27020), info.STVPort)
+	require.Equal(t, "SourceTV", info.STVName)
+	require.Equal(t, "alltalk,nocrits", info.Keywords)
+	require.Equal(t, uint64(440), info.GameID)
+}
+
+func TestPlayers(t *testing.T) {
+	server := newStandIn(t)
+	client := a2s.NewClient(server.addr(), time.Second)
+
+	players, errPlayers := client.Players(context.Background())
+	require.NoError(t, errPlayers)
+	require.Len(t, players, 2)
+	require.Equal(t, "player one", players[0].Name)
+	require.Equal(t, int32(10), players[0].Score)
+	require.Equal(t, time.Duration(120.5*float64(time.Second)), players[0].Duration)
+	require.Equal(t, int32(-1), players[1].Score)
+}
+
+func TestRulesSplit(t *testing.T) {
+	server := newStandIn(t)
+	payload := newPacket(-1).raw('E').uint16(3).
+		str("sv_gravity").str("800").
+		str("mp_timelimit").str("30").
+		str("sv_tags").str("alltalk,nocrits,payload").Bytes()
+	server.setRules(split(payload, 1234, 16))
+	client := a2s.NewClient(server.addr(), time.Second)
+
+	rules, errRules := client.Rules(context.Background())
+	require.NoError(t, errRules)
+	require.Equal(t, map[string]string{
+		"sv_gravity":   "800",
+		"mp_timelimit": "30",
+		"sv_tags":      "alltalk,nocrits,payload",
+	}, rules)
+}
+
+func TestRulesCompressed(t *testing.T) {
+	// bzip2 compressed rules response containing sv_gravity=800 & mp_timelimit=30
+	compressed, errDecode := hex.DecodeString("425a6839314159265359e41e1e010000134f80d000484002000000a2a65d2000" +
+		"00a000229a68d3d400f485309a680d313a5e944095022fe275a2add30b4cc9423bdcc5be2ee48a70a121c83c3c02")
+	require.NoError(t, errDecode)
+
+	var packetID uint32 = 0x80000001
+
+	// Compressed payloads are prefixed with the decompressed size & crc32 checksum
+	payload := new(packetBuilder).int32(38).int32(0x35c1df0f).raw(compressed...).Bytes()
+	server := newStandIn(t)
+	server.setRules(split(payload, int32(packetID), 32))
+	client := a2s.NewClient(server.addr(), time.Second)
+
+	rules, errRules := client.Rules(context.Background())
+	require.NoError(t, errRules)
+	require.Equal(t, map[string]string{"sv_gravity": "800", "mp_timelimit": "30"}, rules)
+
+	// Corrupted checksum
+	server.setRules(split(new(packetBuilder).int32(38).int32(0).raw(compressed...).Bytes(), int32(packetID), 32))
+
+	_, errRules = client.Rules(context.Background())
+	require.ErrorIs(t, errRules, a2s.ErrInvalidResponse)
+}
+
+func TestTimeout(t *testing.T) {
+	conn, errListen := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, errListen)
+
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	client := a2s.NewClient(conn.LocalAddr().String(), time.Millisecond*100)
+
+	_, errInfo := client.Info(context.Background())
+	require.Error(t, errInfo)
+}
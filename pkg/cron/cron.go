@@ -0,0 +1,264 @@
+// Package cron implements parsing of standard 5 field cron expressions and calculating their
+// next activation time.
+package cron
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidExpression = errors.New("Invalid cron expression")
+
+// maxSearchYears limits how far into the future Next will search for a matching time. Expressions
+// such as 0 0 31 2 * can never match.
+const maxSearchYears = 5
+
+type field struct {
+	min   int
+	max   int
+	names map[string]int
+}
+
+//nolint:gochecknoglobals
+var (
+	fieldMinute = field{min: 0, max: 59}
+	fieldHour   = field{min: 0, max: 23}
+	fieldDom    = field{min: 1, max: 31}
+	fieldMonth  = field{min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// Both 0 and 7 are accepted as sunday.
+	fieldDow = field{min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+	descriptors = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// bits is a set of the allowed values for a field.
+type bits uint64
+
+func (b bits) has(value int) bool {
+	return b&(1<<uint(value)) != 0
+}
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	minute bits
+	hour   bits
+	dom    bits
+	month  bits
+	dow    bits
+	// When both the day of month and day of week are restricted, a day matches if either matches.
+	domStar bool
+	dowStar bool
+}
+
+// Parse parses a standard 5 field cron expression: minute, hour, day of month, month and day of week.
+// Fields support lists (1,2), ranges (1-5), steps (*/15, 1-30/5) and 3 letter month and day names. The
+// descriptors @yearly, @annually, @monthly, @weekly, @daily, @midnight and @hourly are also supported.
+func Parse(expression string) (Schedule, error) {
+	expression = strings.TrimSpace(strings.ToLower(expression))
+
+	if strings.HasPrefix(expression, "@") {
+		expanded, found := descriptors[expression]
+		if !found {
+			return Schedule{}, errors.Wrapf(ErrInvalidExpression, "unknown descriptor %s", expression)
+		}
+
+		expression = expanded
+	}
+
+	fields := strings.Fields(expression)
+	if len(fields) != 5 { //nolint:gomnd
+		return Schedule{}, errors.Wrapf(ErrInvalidExpression, "expected 5 fields, got %d", len(fields))
+	}
+
+	var (
+		schedule Schedule
+		errParse error
+	)
+
+	if schedule.minute, errParse = parseField(fields[0], fieldMinute); errParse != nil {
+		return Schedule{}, errParse
+	}
+
+	if schedule.hour, errParse = parseField(fields[1], fieldHour); errParse != nil {
+		return Schedule{}, errParse
+	}
+
+	if schedule.dom, errParse = parseField(fields[2], fieldDom); errParse != nil {
+		return Schedule{}, errParse
+	}
+
+	if schedule.month, errParse = parseField(fields[3], fieldMonth); errParse != nil {
+		return Schedule{}, errParse
+	}
+
+	if schedule.dow, errParse = parseField(fields[4], fieldDow); errParse != nil {
+		return Schedule{}, errParse
+	}
+
+	if schedule.dow.has(7) { //nolint:gomnd
+		schedule.dow |= 1
+	}
+
+	schedule.domStar = strings.HasPrefix(fields[2], "*")
+	schedule.dowStar = strings.HasPrefix(fields[4], "*")
+
+	return schedule, nil
+}
+
+func parseValue(value string, spec field) (int, error) {
+	if named, found := spec.names[value]; found {
+		return named, nil
+	}
+
+	parsed, errParse := strconv.Atoi(value)
+	if errParse != nil {
+		return 0, errors.Wrapf(ErrInvalidExpression, "invalid value %s", value)
+	}
+
+	if parsed < spec.min || parsed > spec.max {
+		return 0, errors.Wrapf(ErrInvalidExpression, "value %d out of range %d-%d", parsed, spec.min, spec.max)
+	}
+
+	return parsed, nil
+}
+
+func parseField(value string, spec field) (bits, error) {
+	var set bits
+
+	for _, part := range strings.Split(value, ",") {
+		var (
+			rangeExpr = part
+			step      = 1
+		)
+
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			parsedStep, errStep := strconv.Atoi(part[idx+1:])
+			if errStep != nil || parsedStep <= 0 {
+				return 0, errors.Wrapf(ErrInvalidExpression, "invalid step %s", part)
+			}
+
+			rangeExpr = part[:idx]
+			step = parsedStep
+		}
+
+		var low, high int
+
+		switch {
+		case rangeExpr == "*":
+			low, high = spec.min, spec.max
+		case strings.Contains(rangeExpr, "-"):
+			bounds := strings.SplitN(rangeExpr, "-", 2) //nolint:gomnd
+
+			var errBound error
+
+			if low, errBound = parseValue(bounds[0], spec); errBound != nil {
+				return 0, errBound
+			}
+
+			if high, errBound = parseValue(bounds[1], spec); errBound != nil {
+				return 0, errBound
+			}
+
+			if low > high {
+				return 0, errors.Wrapf(ErrInvalidExpression, "invalid range %s", rangeExpr)
+			}
+		default:
+			single, errValue := parseValue(rangeExpr, spec)
+			if errValue != nil {
+				return 0, errValue
+			}
+
+			low = single
+			high = single
+
+			// a/n is shorthand for a-max/n
+			if step > 1 {
+				high = spec.max
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+
+	return set, nil
+}
+
+func (s Schedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom.has(t.Day())
+	dowMatch := s.dow.has(int(t.Weekday()))
+
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+
+	return domMatch || dowMatch
+}
+
+// Next returns the first activation time strictly after the provided time, in the same location. A zero
+// time is returned if the schedule can never be satisfied.
+func (s Schedule) Next(after time.Time) time.Time {
+	var (
+		loc   = after.Location()
+		next  = after.Truncate(time.Minute).Add(time.Minute)
+		limit = next.AddDate(maxSearchYears, 0, 0)
+	)
+
+	for next.Before(limit) {
+		if !s.month.has(int(next.Month())) {
+			next = advance(next, time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, loc))
+
+			continue
+		}
+
+		if !s.dayMatches(next) {
+			next = advance(next, time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, loc))
+
+			continue
+		}
+
+		if !s.hour.has(next.Hour()) {
+			// Added as a duration instead of using time.Date which can go backwards when the
+			// next hour falls within a DST gap.
+			next = next.Add(time.Duration(60-next.Minute()) * time.Minute)
+
+			continue
+		}
+
+		if !s.minute.has(next.Minute()) {
+			next = next.Add(time.Minute)
+
+			continue
+		}
+
+		return next
+	}
+
+	return time.Time{}
+}
+
+// advance returns the candidate time, unless it does not move forward in time which can happen when the
+// candidate falls within a DST gap, in which case the time is moved forward to the next hour.
+func advance(current time.Time, candidate time.Time) time.Time {
+	if candidate.After(current) {
+		return candidate
+	}
+
+	return current.Add(time.Duration(60-current.Minute()) * time.Minute)
+}
@@ -0,0 +1,84 @@
+package cron_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/leighmacdonald/gbans/pkg/cron"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"@never",
+	} {
+		_, errParse := cron.Parse(expr)
+		require.ErrorIs(t, errParse, cron.ErrInvalidExpression, expr)
+	}
+}
+
+func TestNext(t *testing.T) {
+	base := time.Date(2023, time.March, 15, 10, 30, 0, 0, time.UTC) // Wednesday
+
+	testCases := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2023, time.March, 15, 10, 31, 0, 0, time.UTC)},
+		{"0 6 * * *", time.Date(2023, time.March, 16, 6, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2023, time.March, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 */6 * * *", time.Date(2023, time.March, 15, 12, 0, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2023, time.March, 16, 10, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2023, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * mon-fri", time.Date(2023, time.March, 15, 12, 0, 0, 0, time.UTC)},
+		{"0 12 * * sat,sun", time.Date(2023, time.March, 18, 12, 0, 0, 0, time.UTC)},
+		{"0 12 * * 7", time.Date(2023, time.March, 19, 12, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		// Day of month OR day of week when both are restricted
+		{"0 0 20 * mon", time.Date(2023, time.March, 20, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * fri", time.Date(2023, time.March, 17, 0, 0, 0, 0, time.UTC)},
+		{"5/20 * * * *", time.Date(2023, time.March, 15, 10, 45, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2023, time.March, 15, 11, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2023, time.March, 19, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 2 *", time.Time{}},
+	}
+
+	for _, testCase := range testCases {
+		schedule, errParse := cron.Parse(testCase.expr)
+		require.NoError(t, errParse, testCase.expr)
+		require.Equal(t, testCase.expected, schedule.Next(base), testCase.expr)
+	}
+}
+
+func TestNextLocation(t *testing.T) {
+	loc, errLoc := time.LoadLocation("America/New_York")
+	if errLoc != nil {
+		t.Skip("Timezone data unavailable")
+	}
+
+	schedule, errParse := cron.Parse("0 6 * * *")
+	require.NoError(t, errParse)
+
+	// Crosses the DST change on 2023-03-12
+	next := schedule.Next(time.Date(2023, time.March, 11, 7, 0, 0, 0, loc))
+	require.Equal(t, time.Date(2023, time.March, 12, 6, 0, 0, 0, loc), next)
+	require.Equal(t, 10, next.UTC().Hour())
+
+	// Hours skipped by DST never match
+	schedule, errParse = cron.Parse("30 2 12 3 *")
+	require.NoError(t, errParse)
+	require.Equal(t, time.Date(2024, time.March, 12, 2, 30, 0, 0, loc),
+		schedule.Next(time.Date(2023, time.March, 11, 0, 0, 0, 0, loc)))
+}
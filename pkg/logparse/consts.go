@@ -1,5 +1,7 @@
 package logparse
 
+import "strings"
+
 // EventType defines a known, parsable message type.
 type EventType int
 
@@ -46,6 +48,14 @@ const (
 	MilkAttack          EventType = 53
 	GasAttack           EventType = 54
 	KilledCustom                  = 55
+	PassGet             EventType = 56 // Passtime ball picked up
+	PassFree            EventType = 57 // Passtime ball thrown or dropped
+	PassCaught          EventType = 58 // Passtime pass completed or intercepted
+	PassBallStolen      EventType = 59
+	PassScore           EventType = 60
+	PassScoreAssist     EventType = 61
+	PassBallBlocked     EventType = 62
+	EscortScore         EventType = 63 // Payload cart push, logged by the gbans plugin
 
 	// World events not attached to specific players.
 
@@ -59,7 +69,7 @@ const (
 	WPaused         EventType = 107
 	WResumed        EventType = 108
 
-	// WRoundSetupEnd        EventType = 109.
+	WRoundSetupEnd EventType = 109 // World triggered "Round_Setup_End"
 
 	WMiniRoundWin         EventType = 110 // World triggered "Mini_Round_Win" (winner "Blue") (round "round_a")
 	WMiniRoundLen         EventType = 111 // World triggered "Mini_Round_Length" (seconds "820.00")
@@ -68,6 +78,14 @@ const (
 	WRoundSetupBegin      EventType = 114 // World triggered "Round_Setup_Begin"
 	WIntermissionWinLimit EventType = 115 // Team "RED" triggered "Intermission_Win_Limit"
 
+	// Objective events logged by the gbans plugin, the game does not log these itself.
+
+	WEscortProgress  EventType = 116 // Team "Blue" triggered "escort_progress" (progress "0.52")
+	WKothTimer       EventType = 117 // Team "Red" triggered "koth_timer" (held "138")
+	WMvMWaveStart    EventType = 118 // World triggered "MvM_Wave_Start" (wave "1") (max_waves "7")
+	WMvMWaveComplete EventType = 119 // World triggered "MvM_Wave_Complete" (wave "1")
+	WMvMWaveFailed   EventType = 120 // World triggered "MvM_Wave_Failed" (wave "1")
+
 	// Metadata.
 
 	LogStart         EventType = 1000
@@ -82,6 +100,7 @@ const (
 	ServerConfigExec EventType = 1009
 	SteamAuth        EventType = 1010
 	MapStarted       EventType = 1011
+	ChangedName      EventType = 1012
 )
 
 type CritType int
@@ -117,6 +136,76 @@ func (t Team) String() string {
 	}
 }
 
+// GameMode is the objective type of the map being played.
+type GameMode int
+
+const (
+	GameModeUnknown GameMode = iota
+	GameModeControlPoint
+	GameModePayload
+	GameModePayloadRace
+	GameModeKOTH
+	GameModeCTF
+	GameModeMvM
+	GameModePasstime
+	GameModeArena
+)
+
+func (m GameMode) String() string {
+	switch m {
+	case GameModeControlPoint:
+		return "cp"
+	case GameModePayload:
+		return "pl"
+	case GameModePayloadRace:
+		return "plr"
+	case GameModeKOTH:
+		return "koth"
+	case GameModeCTF:
+		return "ctf"
+	case GameModeMvM:
+		return "mvm"
+	case GameModePasstime:
+		return "pass"
+	case GameModeArena:
+		return "arena"
+	default:
+		return "unknown"
+	}
+}
+
+// GameModeFromMap determines the game mode using the standard map name prefix. Workshop maps are
+// handled, eg: workshop/koth_product_final.ugc2933433143.
+func GameModeFromMap(mapName string) GameMode {
+	name := strings.ToLower(strings.TrimPrefix(mapName, "workshop/"))
+
+	prefix, _, found := strings.Cut(name, "_")
+	if !found {
+		return GameModeUnknown
+	}
+
+	switch prefix {
+	case "cp", "tc":
+		return GameModeControlPoint
+	case "pl":
+		return GameModePayload
+	case "plr":
+		return GameModePayloadRace
+	case "koth":
+		return GameModeKOTH
+	case "ctf":
+		return GameModeCTF
+	case "mvm":
+		return GameModeMvM
+	case "pass":
+		return GameModePasstime
+	case "arena":
+		return GameModeArena
+	default:
+		return GameModeUnknown
+	}
+}
+
 func (t Team) Opponent() Team {
 	switch t { //nolint:exhaustive
 	case RED:
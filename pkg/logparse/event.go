@@ -28,6 +28,8 @@ type WResumedEvt TimeStamp
 
 type WRoundSetupBeginEvt TimeStamp
 
+type WRoundSetupEndEvt TimeStamp
+
 type WMiniRoundSelectedEvt TimeStamp
 
 type WMiniRoundStartEvt TimeStamp
@@ -248,6 +250,14 @@ type ConnectedEvt struct {
 	Port    int    `json:"port" mapstructure:"port"`
 }
 
+// ChangedNameEvt is emitted when a player changes their name while connected. The SourcePlayer
+// name is the previous name.
+type ChangedNameEvt struct {
+	TimeStamp
+	SourcePlayer
+	NewName string `json:"new_name" mapstructure:"new_name"`
+}
+
 type DisconnectedEvt struct {
 	TimeStamp
 	SourcePlayer
@@ -281,6 +291,112 @@ type WIntermissionWinLimitEvt struct {
 	Team Team `json:"team" mapstructure:"team"`
 }
 
+type MapLoadEvt struct {
+	TimeStamp
+	Map string `json:"map" mapstructure:"map"`
+}
+
+// PassGetEvt is triggered when a player picks up the passtime ball.
+type PassGetEvt struct {
+	TimeStamp
+	SourcePlayer
+	FirstContact bool `json:"firstcontact" mapstructure:"firstcontact"`
+	Position     Pos  `json:"position" mapstructure:"position"`
+}
+
+// PassFreeEvt is triggered when the ball carrier throws or drops the ball.
+type PassFreeEvt struct {
+	TimeStamp
+	SourcePlayer
+	Position Pos `json:"position" mapstructure:"position"`
+}
+
+// PassCaughtEvt is triggered when the ball thrown by the source player is caught by the target player.
+type PassCaughtEvt struct {
+	TimeStamp
+	SourcePlayer
+	TargetPlayer
+	Interception    bool    `json:"interception" mapstructure:"interception"`
+	Save            bool    `json:"save" mapstructure:"save"`
+	Handoff         bool    `json:"handoff" mapstructure:"handoff"`
+	Dist            float64 `json:"dist" mapstructure:"dist"`
+	Duration        float64 `json:"duration" mapstructure:"duration"`
+	ThrowerPosition Pos     `json:"thrower_position" mapstructure:"thrower_position"`
+	CatcherPosition Pos     `json:"catcher_position" mapstructure:"catcher_position"`
+}
+
+// PassBallStolenEvt is triggered when the source player steals the ball from the target player.
+type PassBallStolenEvt struct {
+	TimeStamp
+	SourcePlayer
+	TargetPlayer
+	StealDefense   bool `json:"steal_defense" mapstructure:"steal_defense"`
+	ThiefPosition  Pos  `json:"thief_position" mapstructure:"thief_position"`
+	VictimPosition Pos  `json:"victim_position" mapstructure:"victim_position"`
+}
+
+// PassScoreEvt is triggered when the source player scores a goal.
+type PassScoreEvt struct {
+	TimeStamp
+	SourcePlayer
+	Points    int     `json:"points" mapstructure:"points"`
+	Panacea   bool    `json:"panacea" mapstructure:"panacea"`
+	WinStrat  bool    `json:"win_strat" mapstructure:"win strat"`
+	Deathbomb bool    `json:"deathbomb" mapstructure:"deathbomb"`
+	Dist      float64 `json:"dist" mapstructure:"dist"`
+	Position  Pos     `json:"position" mapstructure:"position"`
+}
+
+type PassScoreAssistEvt struct {
+	TimeStamp
+	SourcePlayer
+	Position Pos `json:"position" mapstructure:"position"`
+}
+
+// PassBallBlockedEvt is triggered when the ball thrown by the source player is blocked by the target player.
+type PassBallBlockedEvt struct {
+	TimeStamp
+	SourcePlayer
+	TargetPlayer
+	ThrowerPosition Pos `json:"thrower_position" mapstructure:"thrower_position"`
+	BlockerPosition Pos `json:"blocker_position" mapstructure:"blocker_position"`
+}
+
+// EscortScoreEvt is triggered when pushing the payload cart earns the source player points.
+type EscortScoreEvt struct {
+	TimeStamp
+	SourcePlayer
+	Points int `json:"points" mapstructure:"points"`
+}
+
+// WEscortProgressEvt is the distance the team has pushed their cart, from 0 at the start to 1 at the final point.
+type WEscortProgressEvt struct {
+	TimeStamp
+	Team     Team    `json:"team" mapstructure:"team"`
+	Progress float64 `json:"progress" mapstructure:"progress"`
+}
+
+// WKothTimerEvt is the number of seconds the team held the koth point in the round, taken from the round
+// timer when the round ends.
+type WKothTimerEvt struct {
+	TimeStamp
+	Team Team `json:"team" mapstructure:"team"`
+	Held int  `json:"held" mapstructure:"held"`
+}
+
+type WMvMWaveStartEvt struct {
+	TimeStamp
+	Wave     int `json:"wave" mapstructure:"wave"`
+	MaxWaves int `json:"max_waves" mapstructure:"max_waves"`
+}
+
+type WMvMWaveCompleteEvt struct {
+	TimeStamp
+	Wave int `json:"wave" mapstructure:"wave"`
+}
+
+type WMvMWaveFailedEvt WMvMWaveCompleteEvt
+
 type WRoundWinEvt struct {
 	TimeStamp
 	Winner Team `json:"winner" mapstructure:"winner"`
@@ -0,0 +1,6 @@
+package logparse
+
+// ParseRegex exposes the regex parser so the results of both parsing paths can be compared.
+func (p *LogParser) ParseRegex(logLine string) (*Results, error) {
+	return p.parseRegex(logLine)
+}
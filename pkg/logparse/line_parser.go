@@ -0,0 +1,1465 @@
+package logparse
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/leighmacdonald/steamid/v3/steamid"
+)
+
+// maxKeyValues matches the limit used by ParseKVs so that both parsing paths decode the same values.
+const maxKeyValues = 10
+
+type keyValue struct {
+	key   string
+	value string
+}
+
+// valueDecoder converts the raw string values of a line into their typed values. Values that cannot
+// be decoded are recorded so the line can be handed to the regex parser, which reports the error.
+type valueDecoder struct {
+	invalid bool
+}
+
+func (d *valueDecoder) int(value string) int {
+	if value == "" {
+		return 0
+	}
+
+	parsed, errParse := strconv.ParseInt(value, 0, 0)
+	if errParse != nil {
+		d.invalid = true
+	}
+
+	return int(parsed)
+}
+
+func (d *valueDecoder) float(value string, bitSize int) float64 {
+	if value == "" {
+		return 0
+	}
+
+	parsed, errParse := strconv.ParseFloat(value, bitSize)
+	if errParse != nil {
+		d.invalid = true
+	}
+
+	return parsed
+}
+
+func (d *valueDecoder) bool(value string) bool {
+	if value == "" {
+		return false
+	}
+
+	parsed, errParse := strconv.ParseBool(value)
+	if errParse != nil {
+		d.invalid = true
+	}
+
+	return parsed
+}
+
+func (d *valueDecoder) pos(value string) Pos {
+	var pos Pos
+	if !ParsePos(value, &pos) {
+		d.invalid = true
+	}
+
+	return pos
+}
+
+func (d *valueDecoder) team(value string) Team {
+	var team Team
+	if !parseTeam(value, &team) && value != "" {
+		d.invalid = true
+	}
+
+	return team
+}
+
+func (d *valueDecoder) class(value string) PlayerClass {
+	var class PlayerClass
+	if !parsePlayerClass(value, &class) {
+		d.invalid = true
+	}
+
+	return class
+}
+
+func (d *valueDecoder) item(value string) PickupItem {
+	var item PickupItem
+	if !parsePickupItem(value, &item) {
+		d.invalid = true
+	}
+
+	return item
+}
+
+func (d *valueDecoder) result(eventType EventType, event any) (*Results, bool) {
+	if d.invalid {
+		return nil, false
+	}
+
+	return &Results{eventType, event}, true
+}
+
+func parseCrit(value string) CritType {
+	switch value {
+	case "crit":
+		return Crit
+	case "mini":
+		return Mini
+	default:
+		return NonCrit
+	}
+}
+
+func (p *LogParser) parseWeapon(value string) Weapon {
+	if weapon := p.weapons.Parse(value); weapon != UnknownWeapon {
+		return weapon
+	}
+
+	return Weapon(value)
+}
+
+// isSpace matches the characters of the regexp \s class.
+func isSpace(char byte) bool {
+	return char == ' ' || char == '\t' || char == '\n' || char == '\f' || char == '\r'
+}
+
+// skipSpace removes leading whitespace, returning false if there was none.
+func skipSpace(input string) (string, bool) {
+	index := 0
+	for index < len(input) && isSpace(input[index]) {
+		index++
+	}
+
+	return input[index:], index > 0
+}
+
+// separatorIndex returns the index of the first colon followed by whitespace, which ends the timestamp.
+func separatorIndex(input string) int {
+	for index := 1; index < len(input)-1; index++ {
+		if input[index] == ':' && isSpace(input[index+1]) {
+			return index
+		}
+	}
+
+	return -1
+}
+
+func parseDigits(value string) (int, bool) {
+	result := 0
+
+	for index := 0; index < len(value); index++ {
+		if value[index] < '0' || value[index] > '9' {
+			return 0, false
+		}
+
+		result = result*10 + int(value[index]-'0')
+	}
+
+	return result, value != ""
+}
+
+// parseLogTime parses the "01/02/2006 - 15:04:05" timestamp of a line without the overhead of time.Parse.
+func parseLogTime(value string, outTime *time.Time) bool {
+	if len(value) != 21 || value[2] != '/' || value[5] != '/' || value[10:13] != " - " ||
+		value[15] != ':' || value[18] != ':' {
+		return ParseDateTime(value, outTime)
+	}
+
+	month, monthOk := parseDigits(value[0:2])
+	day, dayOk := parseDigits(value[3:5])
+	year, yearOk := parseDigits(value[6:10])
+	hour, hourOk := parseDigits(value[13:15])
+	minute, minuteOk := parseDigits(value[16:18])
+	second, secondOk := parseDigits(value[19:21])
+
+	if !monthOk || !dayOk || !yearOk || !hourOk || !minuteOk || !secondOk ||
+		month < 1 || month > 12 || day < 1 || hour > 23 || minute > 59 || second > 59 {
+		return ParseDateTime(value, outTime)
+	}
+
+	parsed := time.Date(year, time.Month(month), day, hour, minute, second, 0, time.UTC)
+	if parsed.Day() != day {
+		// Day overflowed into the next month
+		return false
+	}
+
+	*outTime = parsed
+
+	return true
+}
+
+// parseSID converts the steam id of a player, bots are assigned BotSid. Unknown formats are kept as is.
+func parseSID(value string) steamid.SID64 {
+	if value == "BOT" {
+		return steamid.New(BotSid)
+	}
+
+	if strings.HasPrefix(value, "[U") {
+		if sid64 := steamid.SID3ToSID64(steamid.SID3(value)); sid64.Valid() {
+			return sid64
+		}
+	}
+
+	return steamid.SID64(value)
+}
+
+// parsePlayerTeam parses the team of a player identifier, an empty team is unassigned.
+func parsePlayerTeam(input string) (Team, int, bool) {
+	for _, name := range []string{"Unassigned", "Red", "Blue", "Spectator", "unknown", ""} {
+		if strings.HasPrefix(input, name) && strings.HasPrefix(input[len(name):], `>"`) {
+			var team Team
+
+			parseTeam(name, &team)
+
+			return team, len(name) + 2, true
+		}
+	}
+
+	return UNASSIGNED, 0, false
+}
+
+// parsePlayerIDs parses the <pid><sid><team>" suffix of a player identifier.
+func parsePlayerIDs(input string, player *SourcePlayer) (string, bool) {
+	end := 1
+	for end < len(input) && input[end] >= '0' && input[end] <= '9' {
+		end++
+	}
+
+	if end == 1 || !strings.HasPrefix(input[end:], "><") {
+		return "", false
+	}
+
+	pid, errPid := strconv.Atoi(input[1:end])
+	if errPid != nil {
+		return "", false
+	}
+
+	sidStart := end + 2
+
+	for offset := sidStart + 1; offset < len(input); {
+		sidLen := strings.Index(input[offset:], "><")
+		if sidLen < 0 {
+			return "", false
+		}
+
+		sidEnd := offset + sidLen
+
+		if team, length, found := parsePlayerTeam(input[sidEnd+2:]); found {
+			player.PID = pid
+			player.SID = parseSID(input[sidStart:sidEnd])
+			player.Team = team
+
+			return input[sidEnd+2+length:], true
+		}
+
+		offset = sidEnd + 1
+	}
+
+	return "", false
+}
+
+// parsePlayer reads a quoted player identifier such as "Name<382><[U:1:22649331]><Red>" from the start
+// of the input, returning the input remaining after it. The name is the shortest one followed by a valid
+// identifier, the same as rxPlayer.
+func parsePlayer(input string, player *SourcePlayer) (string, bool) {
+	if len(input) < 2 || input[0] != '"' {
+		return "", false
+	}
+
+	for offset := 2; offset < len(input); {
+		index := strings.IndexByte(input[offset:], '<')
+		if index < 0 {
+			return "", false
+		}
+
+		start := offset + index
+
+		if rest, found := parsePlayerIDs(input[start:], player); found {
+			player.Name = input[1:start]
+
+			return rest, true
+		}
+
+		offset = start + 1
+	}
+
+	return "", false
+}
+
+func parseTarget(input string, target *TargetPlayer) (string, bool) {
+	var player SourcePlayer
+
+	rest, found := parsePlayer(input, &player)
+	if !found {
+		return "", false
+	}
+
+	*target = TargetPlayer{Name2: player.Name, PID2: player.PID, SID2: player.SID, Team2: player.Team}
+
+	return rest, true
+}
+
+// parseAgainst parses the target of a triggered event, eg: against "Name<382><[U:1:22649331]><Red>".
+func parseAgainst(input string, target *TargetPlayer) (string, bool) {
+	rest, found := strings.CutPrefix(input, " against ")
+	if !found {
+		return "", false
+	}
+
+	return parseTarget(rest, target)
+}
+
+// parseWith parses the weapon of an event, eg: with "scattergun".
+func parseWith(input string) (string, string, bool) {
+	rest, found := strings.CutPrefix(input, ` with "`)
+	if !found {
+		return "", "", false
+	}
+
+	weapon, rest, found := strings.Cut(rest, `"`)
+	if !found || weapon == "" {
+		return "", "", false
+	}
+
+	return weapon, rest, true
+}
+
+// cutQuotedLine returns the value of a line ending in a quoted value, eg: say "value".
+func cutQuotedLine(input string) (string, bool) {
+	if len(input) < 3 || input[0] != '"' || input[len(input)-1] != '"' {
+		return "", false
+	}
+
+	return input[1 : len(input)-1], true
+}
+
+// cutKV reads a single key value pair following an opening parenthesis. The key ends at the first quote
+// preceded by whitespace and the value at the first quote followed by a closing parenthesis.
+func cutKV(input string) (keyValue, string, bool) {
+	quote := -1
+
+	for index := 1; index < len(input); index++ {
+		if input[index] == '"' && isSpace(input[index-1]) {
+			quote = index
+
+			break
+		}
+	}
+
+	if quote < 0 {
+		return keyValue{}, "", false
+	}
+
+	keyEnd := quote - 1
+	for keyEnd > 0 && isSpace(input[keyEnd-1]) {
+		keyEnd--
+	}
+
+	if keyEnd == 0 || quote+2 > len(input) {
+		return keyValue{}, "", false
+	}
+
+	valueLen := strings.Index(input[quote+2:], `")`)
+	if valueLen < 0 {
+		return keyValue{}, "", false
+	}
+
+	valueEnd := quote + 2 + valueLen
+
+	return keyValue{key: input[:keyEnd], value: input[quote+1 : valueEnd]}, input[valueEnd+2:], true
+}
+
+// parseKVs reads the (key "value") pairs of a line into the kvs slice.
+func parseKVs(input string, kvs []keyValue) []keyValue {
+	for len(kvs) < maxKeyValues {
+		start := strings.IndexByte(input, '(')
+		if start < 0 {
+			break
+		}
+
+		pair, rest, found := cutKV(input[start+1:])
+		if !found {
+			break
+		}
+
+		kvs = append(kvs, pair)
+		input = rest
+	}
+
+	return kvs
+}
+
+// parseTrailingKVs parses the key value pairs which follow an event after at least one whitespace character.
+func parseTrailingKVs(input string, kvs []keyValue) ([]keyValue, bool) {
+	rest, found := skipSpace(input)
+	if !found || rest == "" {
+		return nil, false
+	}
+
+	return parseKVs(rest, kvs), true
+}
+
+// parseLine parses the log line by dispatching on the fixed prefixes of the log format, decoding the values
+// directly into the event structs. False is returned for lines which are not recognized, or contain values
+// that could not be decoded, so they can be handled by the regex parser.
+func (p *LogParser) parseLine(logLine string) (*Results, bool) {
+	line := strings.TrimSuffix(strings.TrimSuffix(logLine, "\n"), "\r")
+
+	rest, found := strings.CutPrefix(line, "L ")
+	if !found {
+		return nil, false
+	}
+
+	separator := separatorIndex(rest)
+	if separator < 0 {
+		return nil, false
+	}
+
+	var stamp TimeStamp
+	if !parseLogTime(rest[:separator], &stamp.CreatedOn) {
+		return nil, false
+	}
+
+	body, _ := skipSpace(rest[separator+1:])
+
+	switch {
+	case strings.HasPrefix(body, `"`):
+		return p.parsePlayerLine(stamp, body)
+	case strings.HasPrefix(body, `World triggered "`):
+		return parseWorldLine(stamp, body[len(`World triggered "`):])
+	case strings.HasPrefix(body, `Team "`):
+		return parseTeamLine(stamp, body[len(`Team "`):])
+	case strings.HasPrefix(body, "Log file started"), strings.HasPrefix(body, "log file started"):
+		return parseLogStart(stamp, body[len("Log file started"):])
+	case len(body) == len("Log file closed.") &&
+		(strings.HasPrefix(body, "Log file closed") || strings.HasPrefix(body, "log file closed")):
+		return &Results{LogStop, LogStopEvt(stamp)}, true
+	case strings.HasPrefix(body, "server_cvar:"):
+		return parseCVAR(stamp, body[len("server_cvar:"):])
+	case len(body) > 4 && strings.EqualFold(body[:4], "rcon"):
+		return parseRCON(stamp, body[4:])
+	case strings.HasPrefix(body, `Loading map "`):
+		if mapName, ok := cutQuotedLine(body[len(`Loading map `):]); ok {
+			return &Results{MapLoad, MapLoadEvt{TimeStamp: stamp, Map: mapName}}, true
+		}
+	case strings.HasPrefix(body, `Started map "`):
+		return parseMapStarted(stamp, body[len(`Started map "`):])
+	case len(body) > len("Executing dedicated server config file ") &&
+		strings.HasPrefix(body, "Executing dedicated server config file "):
+		return &Results{ServerConfigExec, nil}, true
+	case len(body) > len("STEAMAUTH: ") && strings.HasPrefix(body, "STEAMAUTH: "):
+		return &Results{SteamAuth, nil}, true
+	case strings.HasPrefix(body, "server cvars start"), strings.HasPrefix(body, "[META]"):
+		return &Results{IgnoredMsg, nil}, true
+	case separatorIndex(body) < 0:
+		// Without another separator the timestamp can't be any longer, so no other event can match.
+		return &Results{IgnoredMsg, IgnoredMsgEvt{TimeStamp: stamp, Message: logLine}}, true
+	}
+
+	return nil, false
+}
+
+func parseLogStart(stamp TimeStamp, input string) (*Results, bool) {
+	var buf [maxKeyValues]keyValue
+
+	kvs, found := parseTrailingKVs(input, buf[:0])
+	if !found {
+		return nil, false
+	}
+
+	evt := LogStartEvt{TimeStamp: stamp}
+
+	for _, pair := range kvs {
+		switch pair.key {
+		case "file":
+			evt.File = pair.value
+		case "game":
+			evt.Game = pair.value
+		case "version":
+			evt.Version = pair.value
+		}
+	}
+
+	return &Results{LogStart, evt}, true
+}
+
+// parseCVAR parses a cvar change, eg: server_cvar: "sm_nextmap" "pl_frontier_final".
+func parseCVAR(stamp TimeStamp, input string) (*Results, bool) {
+	rest, found := skipSpace(input)
+	if !found {
+		return nil, false
+	}
+
+	quoted, found := cutQuotedLine(rest)
+	if !found {
+		return nil, false
+	}
+
+	name, value, found := strings.Cut(quoted, `" "`)
+	if !found || name == "" || value == "" {
+		return nil, false
+	}
+
+	return &Results{CVAR, CVAREvt{TimeStamp: stamp, CVAR: name, Value: value}}, true
+}
+
+// parseRCON parses a rcon command, eg: rcon from "23.239.22.163:42004": command "status".
+func parseRCON(stamp TimeStamp, input string) (*Results, bool) {
+	rest, found := strings.CutPrefix(input, ` from "`)
+	if !found {
+		return nil, false
+	}
+
+	address, command, found := strings.Cut(rest, `": command "`)
+	if !found || address == "" || len(command) < 2 || command[len(command)-1] != '"' {
+		return nil, false
+	}
+
+	return &Results{RCON, RCONEvt{TimeStamp: stamp, Cmd: command[:len(command)-1]}}, true
+}
+
+// parseMapStarted parses the map start, eg: Started map "pl_upward" (CRC "...").
+func parseMapStarted(stamp TimeStamp, input string) (*Results, bool) {
+	for offset := 1; offset < len(input); {
+		index := strings.IndexByte(input[offset:], '"')
+		if index < 0 {
+			break
+		}
+
+		end := offset + index
+
+		if rest, found := skipSpace(input[end+1:]); found && rest != "" {
+			return &Results{MapStarted, MapStartedEvt{TimeStamp: stamp, Map: input[:end]}}, true
+		}
+
+		offset = end + 1
+	}
+
+	return nil, false
+}
+
+//nolint:cyclop
+func parseWorldLine(stamp TimeStamp, input string) (*Results, bool) {
+	name, rest, found := strings.Cut(input, `"`)
+	if !found {
+		return nil, false
+	}
+
+	var (
+		decoder valueDecoder
+		buf     [maxKeyValues]keyValue
+	)
+
+	switch name {
+	case "Round_Overtime":
+		return &Results{WRoundOvertime, WRoundOvertimeEvt(stamp)}, true
+	case "Round_Start":
+		return &Results{WRoundStart, WRoundStartEvt(stamp)}, true
+	case "Round_Setup_End":
+		return &Results{WRoundSetupEnd, WRoundSetupEndEvt(stamp)}, true
+	case "Round_Setup_Begin":
+		return &Results{WRoundSetupBegin, WRoundSetupBeginEvt(stamp)}, true
+	case "Game_Paused":
+		return &Results{WPaused, WPausedEvt(stamp)}, true
+	case "Game_Unpaused":
+		return &Results{WResumed, WResumedEvt(stamp)}, true
+	case "Mini_Round_Start":
+		return &Results{WMiniRoundStart, WMiniRoundStartEvt(stamp)}, true
+	case "Game_Over":
+		reason, found := strings.CutPrefix(rest, ` reason "`)
+		if !found || len(reason) < 2 {
+			return nil, false
+		}
+
+		end := strings.IndexByte(reason[1:], '"')
+		if end < 0 {
+			return nil, false
+		}
+
+		return &Results{WGameOver, WGameOverEvt{TimeStamp: stamp, Reason: reason[:end+1]}}, true
+	}
+
+	kvs, found := parseTrailingKVs(rest, buf[:0])
+	if !found {
+		return nil, false
+	}
+
+	switch name {
+	case "Round_Win":
+		evt := WRoundWinEvt{TimeStamp: stamp}
+
+		for _, pair := range kvs {
+			if pair.key == "winner" {
+				evt.Winner = decoder.team(pair.value)
+			}
+		}
+
+		return decoder.result(WRoundWin, evt)
+	case "Round_Length":
+		evt := WRoundLenEvt{TimeStamp: stamp}
+
+		for _, pair := range kvs {
+			if pair.key == "seconds" {
+				evt.Seconds = decoder.float(pair.value, 64)
+			}
+		}
+
+		return decoder.result(WRoundLen, evt)
+	case "Mini_Round_Win":
+		evt := WMiniRoundWinEvt{TimeStamp: stamp}
+
+		for _, pair := range kvs {
+			if pair.key == "team" {
+				evt.Team = decoder.team(pair.value)
+			}
+		}
+
+		return decoder.result(WMiniRoundWin, evt)
+	case "Mini_Round_Length":
+		evt := WMiniRoundLenEvt{TimeStamp: stamp}
+
+		for _, pair := range kvs {
+			if pair.key == "seconds" {
+				evt.Seconds = decoder.float(pair.value, 64)
+			}
+		}
+
+		return decoder.result(WMiniRoundLen, evt)
+	case "Mini_Round_Selected":
+		return &Results{WMiniRoundSelected, WMiniRoundSelectedEvt(stamp)}, true
+	case "MvM_Wave_Start":
+		evt := WMvMWaveStartEvt{TimeStamp: stamp}
+
+		for _, pair := range kvs {
+			switch pair.key {
+			case "wave":
+				evt.Wave = decoder.int(pair.value)
+			case "max_waves":
+				evt.MaxWaves = decoder.int(pair.value)
+			}
+		}
+
+		return decoder.result(WMvMWaveStart, evt)
+	case "MvM_Wave_Complete", "MvM_Wave_Failed":
+		evt := WMvMWaveCompleteEvt{TimeStamp: stamp}
+
+		for _, pair := range kvs {
+			if pair.key == "wave" {
+				evt.Wave = decoder.int(pair.value)
+			}
+		}
+
+		if name == "MvM_Wave_Failed" {
+			return decoder.result(WMvMWaveFailed, WMvMWaveFailedEvt(evt))
+		}
+
+		return decoder.result(WMvMWaveComplete, evt)
+	}
+
+	return nil, false
+}
+
+// parseTeamScore parses the score values of a team, eg: current score "1" with "2" players.
+func parseTeamScore(input string) (int, int, bool) {
+	score, rest, found := strings.Cut(input, `" with "`)
+	if !found {
+		return 0, 0, false
+	}
+
+	players, _, found := strings.Cut(rest, `" players`)
+	if !found {
+		return 0, 0, false
+	}
+
+	scoreValue, scoreOk := parseDigits(score)
+	playersValue, playersOk := parseDigits(players)
+
+	return scoreValue, playersValue, scoreOk && playersOk
+}
+
+func parseTeamLine(stamp TimeStamp, input string) (*Results, bool) {
+	teamName, rest, found := strings.Cut(input, `"`)
+	if !found || teamName == "" {
+		return nil, false
+	}
+
+	var decoder valueDecoder
+
+	if trailing, isCapture := strings.CutPrefix(rest, ` triggered "pointcaptured"`); isCapture {
+		var buf [maxKeyValues]keyValue
+
+		kvs, found := parseTrailingKVs(trailing, buf[:0])
+		if !found {
+			return nil, false
+		}
+
+		evt := PointCapturedEvt{TimeStamp: stamp, Team: decoder.team(teamName)}
+
+		for _, pair := range kvs {
+			switch pair.key {
+			case "cp":
+				evt.CP = decoder.int(pair.value)
+			case "cpname":
+				evt.Cpname = pair.value
+			case "numcappers":
+				evt.Numcappers = decoder.int(pair.value)
+			case "player1":
+				evt.Player1 = pair.value
+			case "position1":
+				evt.Position1 = decoder.pos(pair.value)
+			case "player2":
+				evt.Player2 = pair.value
+			case "position2":
+				evt.Position2 = decoder.pos(pair.value)
+			case "player3":
+				evt.Player3 = pair.value
+			case "position3":
+				evt.Position3 = decoder.pos(pair.value)
+			case "player4":
+				evt.Player4 = pair.value
+			case "position4":
+				evt.Position4 = decoder.pos(pair.value)
+			case "player5":
+				evt.Player5 = pair.value
+			case "position5":
+				evt.Position5 = decoder.pos(pair.value)
+			}
+		}
+
+		return decoder.result(PointCaptured, evt)
+	}
+
+	if rest == ` triggered "Intermission_Win_Limit"` && (teamName == "RED" || teamName == "BLUE") {
+		return &Results{WIntermissionWinLimit, WIntermissionWinLimitEvt{
+			TimeStamp: stamp,
+			Team:      decoder.team(teamName),
+		}}, true
+	}
+
+	if teamName != "Red" && teamName != "Blue" {
+		return nil, false
+	}
+
+	if trailing, isProgress := strings.CutPrefix(rest, ` triggered "escort_progress"`); isProgress {
+		var buf [maxKeyValues]keyValue
+
+		kvs, found := parseTrailingKVs(trailing, buf[:0])
+		if !found {
+			return nil, false
+		}
+
+		evt := WEscortProgressEvt{TimeStamp: stamp, Team: decoder.team(teamName)}
+
+		for _, pair := range kvs {
+			if pair.key == "progress" {
+				evt.Progress = decoder.float(pair.value, 64)
+			}
+		}
+
+		return decoder.result(WEscortProgress, evt)
+	}
+
+	if trailing, isTimer := strings.CutPrefix(rest, ` triggered "koth_timer"`); isTimer {
+		var buf [maxKeyValues]keyValue
+
+		kvs, found := parseTrailingKVs(trailing, buf[:0])
+		if !found {
+			return nil, false
+		}
+
+		evt := WKothTimerEvt{TimeStamp: stamp, Team: decoder.team(teamName)}
+
+		for _, pair := range kvs {
+			if pair.key == "held" {
+				evt.Held = decoder.int(pair.value)
+			}
+		}
+
+		return decoder.result(WKothTimer, evt)
+	}
+
+	if scores, isCurrent := strings.CutPrefix(rest, ` current score "`); isCurrent {
+		if score, players, ok := parseTeamScore(scores); ok {
+			return &Results{WTeamScore, WTeamScoreEvt{
+				TimeStamp: stamp, Team: decoder.team(teamName), Score: score, Players: players,
+			}}, true
+		}
+	}
+
+	if scores, isFinal := strings.CutPrefix(rest, ` final score "`); isFinal {
+		if score, players, ok := parseTeamScore(scores); ok {
+			return &Results{WTeamFinalScore, WTeamFinalScoreEvt{
+				TimeStamp: stamp, Team: decoder.team(teamName), Score: score, Players: players,
+			}}, true
+		}
+	}
+
+	return nil, false
+}
+
+// parsePlayerLine parses the events which start with the player performing them.
+//
+//nolint:cyclop
+func (p *LogParser) parsePlayerLine(stamp TimeStamp, body string) (*Results, bool) {
+	var source SourcePlayer
+
+	rest, found := parsePlayer(body, &source)
+	if !found {
+		return nil, false
+	}
+
+	rest, found = skipSpace(rest)
+	if !found {
+		return nil, false
+	}
+
+	var decoder valueDecoder
+
+	switch {
+	case strings.HasPrefix(rest, `triggered "`):
+		return p.parseTriggered(stamp, source, rest[len(`triggered "`):])
+	case strings.HasPrefix(rest, `killed "`):
+		return p.parseKilled(stamp, source, rest[len(`killed `):])
+	case strings.HasPrefix(rest, `picked up item "`):
+		return parsePickup(stamp, source, rest[len(`picked up item "`):])
+	case strings.HasPrefix(rest, `say_team`):
+		return parseSay(stamp, source, rest[len(`say_team`):], SayTeam)
+	case strings.HasPrefix(rest, `say`):
+		return parseSay(stamp, source, rest[len(`say`):], Say)
+	case strings.HasPrefix(rest, `spawned as `):
+		class, found := cutQuotedLine(rest[len(`spawned as `):])
+		if !found || strings.ContainsAny(class, " \t\n\f\r") {
+			return nil, false
+		}
+
+		return decoder.result(SpawnedAs, SpawnedAsEvt{TimeStamp: stamp, SourcePlayer: source, Class: decoder.class(class)})
+	case strings.HasPrefix(rest, `changed role to "`):
+		class, _, found := strings.Cut(rest[len(`changed role to "`):], `"`)
+		if !found || class == "" {
+			return nil, false
+		}
+
+		return decoder.result(ChangeClass, ChangeClassEvt{TimeStamp: stamp, SourcePlayer: source, Class: decoder.class(class)})
+	case strings.HasPrefix(rest, `changed name to `):
+		name, found := cutQuotedLine(rest[len(`changed name to `):])
+		if !found {
+			return nil, false
+		}
+
+		return &Results{ChangedName, ChangedNameEvt{TimeStamp: stamp, SourcePlayer: source, NewName: name}}, true
+	case strings.HasPrefix(rest, `joined team `):
+		teamName, found := cutQuotedLine(rest[len(`joined team `):])
+		if !found || (teamName != "Red" && teamName != "Blue" && teamName != "Spectator" && teamName != "Unassigned") {
+			return nil, false
+		}
+
+		return &Results{JoinedTeam, JoinedTeamEvt{TimeStamp: stamp, SourcePlayer: source, NewTeam: decoder.team(teamName)}}, true
+	case strings.HasPrefix(rest, `committed suicide`):
+		weapon, trailing, found := parseWith(rest[len(`committed suicide`):])
+		if !found {
+			return nil, false
+		}
+
+		var buf [maxKeyValues]keyValue
+
+		kvs, found := parseTrailingKVs(trailing, buf[:0])
+		if !found {
+			return nil, false
+		}
+
+		evt := SuicideEvt{TimeStamp: stamp, SourcePlayer: source, Weapon: p.parseWeapon(weapon)}
+
+		for _, pair := range kvs {
+			if pair.key == "attacker_position" {
+				evt.AttackerPosition = decoder.pos(pair.value)
+			}
+		}
+
+		return decoder.result(Suicide, evt)
+	case rest == "STEAM USERID validated" || rest == "STEAM USERID Validated":
+		return &Results{Validated, nil}, true
+	case rest == "Entered the game" || rest == "entered the game":
+		return &Results{Entered, EnteredEvt{TimeStamp: stamp, SourcePlayer: source}}, true
+	case strings.HasPrefix(rest, "Connected, address"), strings.HasPrefix(rest, "connected, address"):
+		return parseConnected(stamp, source, rest[len("Connected, address"):])
+	case strings.HasPrefix(rest, `Disconnected (reason "`), strings.HasPrefix(rest, `disconnected (reason "`):
+		reason := rest[len(`Disconnected (reason "`):]
+
+		end := strings.LastIndexByte(reason, '"')
+		if end < 0 {
+			return nil, false
+		}
+
+		return &Results{Disconnected, DisconnectedEvt{
+			TimeStamp: stamp, SourcePlayer: source, Reason: strings.TrimSuffix(reason[:end], `")`),
+		}}, true
+	}
+
+	return nil, false
+}
+
+func parseSay(stamp TimeStamp, source SourcePlayer, input string, eventType EventType) (*Results, bool) {
+	rest, found := skipSpace(input)
+	if !found {
+		return nil, false
+	}
+
+	msg, found := cutQuotedLine(rest)
+	if !found {
+		return nil, false
+	}
+
+	return &Results{eventType, SayEvt{TimeStamp: stamp, SourcePlayer: source, Msg: msg, Team: eventType == SayTeam}}, true
+}
+
+func parseConnected(stamp TimeStamp, source SourcePlayer, input string) (*Results, bool) {
+	evt := ConnectedEvt{TimeStamp: stamp, SourcePlayer: source}
+
+	if input == "" {
+		return &Results{Connected, evt}, true
+	}
+
+	if !isSpace(input[0]) {
+		return nil, false
+	}
+
+	address, found := cutQuotedLine(input[1:])
+	if !found {
+		return nil, false
+	}
+
+	var decoder valueDecoder
+
+	// Split the client port for easier queries
+	host, port, hasPort := strings.Cut(address, ":")
+	if !hasPort || strings.Contains(port, ":") {
+		evt.Address = address
+	} else {
+		evt.Address = host
+		evt.Port = decoder.int(port)
+	}
+
+	return decoder.result(Connected, evt)
+}
+
+func parsePickup(stamp TimeStamp, source SourcePlayer, input string) (*Results, bool) {
+	item, rest, found := strings.Cut(input, `"`)
+	if !found || item == "" || strings.ContainsAny(item, " \t\n\f\r") {
+		return nil, false
+	}
+
+	var (
+		decoder valueDecoder
+		buf     [maxKeyValues]keyValue
+		evt     = PickupEvt{TimeStamp: stamp, SourcePlayer: source, Item: decoder.item(item)}
+	)
+
+	if kvs, hasKVs := parseTrailingKVs(rest, buf[:0]); hasKVs {
+		for _, pair := range kvs {
+			if pair.key == "healing" {
+				evt.Healing = decoder.int(pair.value)
+			}
+		}
+	}
+
+	return decoder.result(Pickup, evt)
+}
+
+// parseKilled parses player kills, eg: killed "Name<8><[U:1:1080653073]><Blue>" with "scattergun".
+func (p *LogParser) parseKilled(stamp TimeStamp, source SourcePlayer, input string) (*Results, bool) {
+	var target TargetPlayer
+
+	rest, found := parseTarget(input, &target)
+	if !found {
+		return nil, false
+	}
+
+	weapon, rest, found := parseWith(rest)
+	if !found {
+		return nil, false
+	}
+
+	trailing, found := skipSpace(rest)
+	if !found || trailing == "" {
+		return nil, false
+	}
+
+	var (
+		decoder    valueDecoder
+		buf        [maxKeyValues]keyValue
+		customKill string
+	)
+
+	if custom, isCustom := strings.CutPrefix(trailing, `(customkill "`); isCustom && len(custom) > 1 {
+		if end := strings.Index(custom[1:], `")`); end >= 0 {
+			if after, hasKVs := skipSpace(custom[end+3:]); hasKVs && after != "" {
+				customKill = custom[:end+1]
+				trailing = after
+			}
+		}
+	}
+
+	var attackerPosition, victimPosition Pos
+
+	for _, pair := range parseKVs(trailing, buf[:0]) {
+		switch pair.key {
+		case "attacker_position":
+			attackerPosition = decoder.pos(pair.value)
+		case "victim_position":
+			victimPosition = decoder.pos(pair.value)
+		}
+	}
+
+	if customKill != "" {
+		return decoder.result(KilledCustom, CustomKilledEvt{
+			TimeStamp: stamp, SourcePlayer: source, TargetPlayer: target, Weapon: p.parseWeapon(weapon),
+			Customkill: customKill, AttackerPosition: attackerPosition, VictimPosition: victimPosition,
+		})
+	}
+
+	return decoder.result(Killed, KilledEvt{
+		TimeStamp: stamp, SourcePlayer: source, TargetPlayer: target, Weapon: p.parseWeapon(weapon),
+		AttackerPosition: attackerPosition, VictimPosition: victimPosition,
+	})
+}
+
+// parseAttack parses the events which apply a weapon effect to another player, eg:
+// triggered "jarate_attack" against "Name<8><[U:1:1080653073]><Blue>" with "tf_weapon_jar".
+func (p *LogParser) parseAttack(stamp TimeStamp, source SourcePlayer, input string) (JarateAttackEvt, bool) {
+	evt := JarateAttackEvt{TimeStamp: stamp, SourcePlayer: source}
+
+	rest, found := parseAgainst(input, &evt.TargetPlayer)
+	if !found {
+		return evt, false
+	}
+
+	weapon, rest, found := parseWith(rest)
+	if !found {
+		return evt, false
+	}
+
+	var (
+		decoder valueDecoder
+		buf     [maxKeyValues]keyValue
+	)
+
+	kvs, found := parseTrailingKVs(rest, buf[:0])
+	if !found {
+		return evt, false
+	}
+
+	evt.Weapon = p.parseWeapon(weapon)
+
+	for _, pair := range kvs {
+		switch pair.key {
+		case "attacker_position":
+			evt.AttackerPosition = decoder.pos(pair.value)
+		case "victim_position":
+			evt.VictimPosition = decoder.pos(pair.value)
+		}
+	}
+
+	return evt, !decoder.invalid
+}
+
+// parseTriggered parses the events of the form: triggered "event_name" ...
+//
+//nolint:gocognit,gocyclo,cyclop,funlen,maintidx
+func (p *LogParser) parseTriggered(stamp TimeStamp, source SourcePlayer, input string) (*Results, bool) {
+	name, rest, found := strings.Cut(input, `"`)
+	if !found {
+		return nil, false
+	}
+
+	var (
+		decoder valueDecoder
+		target  TargetPlayer
+		buf     [maxKeyValues]keyValue
+	)
+
+	// Events which have no, or fixed, trailing values
+	switch name {
+	case "chargeready":
+		return &Results{ChargeReady, ChargeReadyEvt{TimeStamp: stamp, SourcePlayer: source}}, true
+	case "empty_uber":
+		return &Results{EmptyUber, EmptyUberEvt{TimeStamp: stamp, SourcePlayer: source}}, true
+	case "chargedeployed":
+		evt := ChargeDeployedEvt{TimeStamp: stamp, SourcePlayer: source}
+
+		if medigun, hasMedigun := strings.CutPrefix(rest, ` (medigun "`); hasMedigun && len(medigun) > 1 {
+			if end := strings.Index(medigun[1:], `")`); end >= 0 {
+				parseMedigun(medigun[:end+1], &evt.Medigun)
+			}
+		}
+
+		return &Results{ChargeDeployed, evt}, true
+	case "chargeended":
+		duration, found := strings.CutPrefix(rest, ` (duration "`)
+		if !found || len(duration) < 2 {
+			return nil, false
+		}
+
+		end := strings.Index(duration[1:], `")`)
+		if end < 0 {
+			return nil, false
+		}
+
+		return decoder.result(ChargeEnded, ChargeEndedEvt{
+			TimeStamp: stamp, SourcePlayer: source, Duration: float32(decoder.float(duration[:end+1], 32)),
+		})
+	case "Domination", "domination":
+		if _, found := parseAgainst(rest, &target); !found {
+			return nil, false
+		}
+
+		return &Results{Domination, DominationEvt{TimeStamp: stamp, SourcePlayer: source, TargetPlayer: target}}, true
+	case "Revenge", "revenge":
+		if _, found := parseAgainst(rest, &target); !found {
+			return nil, false
+		}
+
+		return &Results{Revenge, RevengeEvt{TimeStamp: stamp, SourcePlayer: source, TargetPlayer: target}}, true
+	case "jarate_attack":
+		evt, found := p.parseAttack(stamp, source, rest)
+		if !found {
+			return nil, false
+		}
+
+		return &Results{JarateAttack, evt}, true
+	case "milk_attack":
+		evt, found := p.parseAttack(stamp, source, rest)
+		if !found {
+			return nil, false
+		}
+
+		return &Results{MilkAttack, MilkAttackEvt(evt)}, true
+	case "gas_attack":
+		evt, found := p.parseAttack(stamp, source, rest)
+		if !found {
+			return nil, false
+		}
+
+		return &Results{GasAttack, GasAttackEvt(evt)}, true
+	case "player_extinguished":
+		evt, found := p.parseAttack(stamp, source, rest)
+		if !found {
+			return nil, false
+		}
+
+		return &Results{Extinguished, ExtinguishedEvt(evt)}, true
+	case "damage", "Damage":
+		if damage, isSelf := strings.CutPrefix(rest, ` (damage "`); isSelf {
+			value, _, found := strings.Cut(damage, `")`)
+			if !found {
+				return nil, false
+			}
+
+			amount, isDigits := parseDigits(value)
+			if !isDigits {
+				return nil, false
+			}
+
+			return &Results{Damage, DamageEvt{TimeStamp: stamp, SourcePlayer: source, Damage: amount}}, true
+		}
+	}
+
+	// Events targeting another player
+	switch name {
+	case "damage", "Damage", "healed", "Healed", "kill assist", "medic_death", "pass_pass_caught",
+		"pass_ball_stolen", "pass_ball_blocked":
+		rest, found = parseAgainst(rest, &target)
+		if !found {
+			return nil, false
+		}
+	}
+
+	kvs, found := parseTrailingKVs(rest, buf[:0])
+	if !found {
+		return nil, false
+	}
+
+	switch name {
+	case "shot_fired", "shot_hit":
+		var weapon Weapon
+
+		for _, pair := range kvs {
+			if pair.key == "weapon" {
+				weapon = p.parseWeapon(pair.value)
+			}
+		}
+
+		if name == "shot_hit" {
+			return &Results{ShotHit, ShotHitEvt{TimeStamp: stamp, SourcePlayer: source, Weapon: weapon}}, true
+		}
+
+		return &Results{ShotFired, ShotFiredEvt{TimeStamp: stamp, SourcePlayer: source, Weapon: weapon}}, true
+	case "damage", "Damage":
+		evt := DamageEvt{TimeStamp: stamp, SourcePlayer: source, TargetPlayer: target}
+
+		for _, pair := range kvs {
+			switch pair.key {
+			case "damage":
+				evt.Damage = decoder.int(pair.value)
+			case "realdamage":
+				evt.Realdamage = decoder.int(pair.value)
+			case "weapon":
+				evt.Weapon = p.parseWeapon(pair.value)
+			case "healing":
+				evt.Healing = decoder.int(pair.value)
+			case "crit":
+				evt.Crit = parseCrit(pair.value)
+			case "airshot":
+				evt.Airshot = decoder.bool(pair.value)
+			case "headshot":
+				evt.Headshot = decoder.bool(pair.value)
+			}
+		}
+
+		return decoder.result(Damage, evt)
+	case "healed", "Healed":
+		evt := HealedEvt{TimeStamp: stamp, SourcePlayer: source, TargetPlayer: target}
+
+		for _, pair := range kvs {
+			if pair.key == "healing" {
+				evt.Healing = decoder.int(pair.value)
+			}
+		}
+
+		return decoder.result(Healed, evt)
+	case "kill assist":
+		evt := KillAssistEvt{TimeStamp: stamp, SourcePlayer: source, TargetPlayer: target}
+
+		for _, pair := range kvs {
+			switch pair.key {
+			case "assister_position":
+				evt.AssisterPosition = decoder.pos(pair.value)
+			case "attacker_position":
+				evt.AttackerPosition = decoder.pos(pair.value)
+			case "victim_position":
+				evt.VictimPosition = decoder.pos(pair.value)
+			}
+		}
+
+		return decoder.result(KillAssist, evt)
+	case "medic_death":
+		evt := MedicDeathEvt{TimeStamp: stamp, SourcePlayer: source, TargetPlayer: target}
+
+		for _, pair := range kvs {
+			switch pair.key {
+			case "healing":
+				evt.Healing = decoder.int(pair.value)
+			case "ubercharge":
+				evt.Ubercharge = decoder.bool(pair.value)
+			}
+		}
+
+		return decoder.result(MedicDeath, evt)
+	case "medic_death_ex":
+		evt := MedicDeathExEvt{TimeStamp: stamp, SourcePlayer: source}
+
+		for _, pair := range kvs {
+			if pair.key == "uberpct" {
+				evt.Uberpct = decoder.int(pair.value)
+			}
+		}
+
+		return decoder.result(MedicDeathEx, evt)
+	case "lost_uber_advantage":
+		evt := LostUberAdvantageEvt{TimeStamp: stamp, SourcePlayer: source}
+
+		for _, pair := range kvs {
+			if pair.key == "time" {
+				evt.Time = decoder.int(pair.value)
+			}
+		}
+
+		return decoder.result(LostUberAdv, evt)
+	case "first_heal_after_spawn":
+		evt := FirstHealAfterSpawnEvt{TimeStamp: stamp, SourcePlayer: source}
+
+		for _, pair := range kvs {
+			if pair.key == "time" {
+				evt.Time = decoder.float(pair.value, 64)
+			}
+		}
+
+		return decoder.result(FirstHealAfterSpawn, evt)
+	case "player_builtobject", "player_carryobject", "player_dropobject", "object_detonated":
+		evt := CarryObjectEvt{TimeStamp: stamp, SourcePlayer: source}
+
+		for _, pair := range kvs {
+			switch pair.key {
+			case "object":
+				evt.Object = pair.value
+			case "position":
+				evt.Position = decoder.pos(pair.value)
+			}
+		}
+
+		switch name {
+		case "player_builtobject":
+			return decoder.result(BuiltObject, BuiltObjectEvt(evt))
+		case "player_dropobject":
+			return decoder.result(DropObject, DropObjectEvt(evt))
+		case "object_detonated":
+			return decoder.result(DetonatedObject, DetonatedObjectEvt(evt))
+		default:
+			return decoder.result(CarryObject, evt)
+		}
+	case "killedobject":
+		evt := KilledObjectEvt{TimeStamp: stamp, SourcePlayer: source}
+
+		for _, pair := range kvs {
+			switch pair.key {
+			case "object":
+				evt.Object = pair.value
+			case "weapon":
+				evt.Weapon = p.parseWeapon(pair.value)
+			case "objectowner":
+				parseTarget(`"`+pair.value+`"`, &evt.TargetPlayer)
+			case "attacker_position":
+				evt.AttackerPosition = decoder.pos(pair.value)
+			}
+		}
+
+		return decoder.result(KilledObject, evt)
+	case "captureblocked":
+		evt := CaptureBlockedEvt{TimeStamp: stamp, SourcePlayer: source}
+
+		for _, pair := range kvs {
+			switch pair.key {
+			case "cp":
+				evt.CP = decoder.int(pair.value)
+			case "cpname":
+				evt.Cpname = pair.value
+			case "position":
+				evt.Position = decoder.pos(pair.value)
+			}
+		}
+
+		return decoder.result(CaptureBlocked, evt)
+	case "pass_get":
+		evt := PassGetEvt{TimeStamp: stamp, SourcePlayer: source}
+
+		for _, pair := range kvs {
+			switch pair.key {
+			case "firstcontact":
+				evt.FirstContact = decoder.bool(pair.value)
+			case "position":
+				evt.Position = decoder.pos(pair.value)
+			}
+		}
+
+		return decoder.result(PassGet, evt)
+	case "pass_free":
+		evt := PassFreeEvt{TimeStamp: stamp, SourcePlayer: source}
+
+		for _, pair := range kvs {
+			if pair.key == "position" {
+				evt.Position = decoder.pos(pair.value)
+			}
+		}
+
+		return decoder.result(PassFree, evt)
+	case "pass_pass_caught":
+		evt := PassCaughtEvt{TimeStamp: stamp, SourcePlayer: source, TargetPlayer: target}
+
+		for _, pair := range kvs {
+			switch pair.key {
+			case "interception":
+				evt.Interception = decoder.bool(pair.value)
+			case "save":
+				evt.Save = decoder.bool(pair.value)
+			case "handoff":
+				evt.Handoff = decoder.bool(pair.value)
+			case "dist":
+				evt.Dist = decoder.float(pair.value, 64)
+			case "duration":
+				evt.Duration = decoder.float(pair.value, 64)
+			case "thrower_position":
+				evt.ThrowerPosition = decoder.pos(pair.value)
+			case "catcher_position":
+				evt.CatcherPosition = decoder.pos(pair.value)
+			}
+		}
+
+		return decoder.result(PassCaught, evt)
+	case "pass_ball_stolen":
+		evt := PassBallStolenEvt{TimeStamp: stamp, SourcePlayer: source, TargetPlayer: target}
+
+		for _, pair := range kvs {
+			switch pair.key {
+			case "steal_defense":
+				evt.StealDefense = decoder.bool(pair.value)
+			case "thief_position":
+				evt.ThiefPosition = decoder.pos(pair.value)
+			case "victim_position":
+				evt.VictimPosition = decoder.pos(pair.value)
+			}
+		}
+
+		return decoder.result(PassBallStolen, evt)
+	case "pass_score":
+		evt := PassScoreEvt{TimeStamp: stamp, SourcePlayer: source}
+
+		for _, pair := range kvs {
+			switch pair.key {
+			case "points":
+				evt.Points = decoder.int(pair.value)
+			case "panacea":
+				evt.Panacea = decoder.bool(pair.value)
+			case "win strat":
+				evt.WinStrat = decoder.bool(pair.value)
+			case "deathbomb":
+				evt.Deathbomb = decoder.bool(pair.value)
+			case "dist":
+				evt.Dist = decoder.float(pair.value, 64)
+			case "position":
+				evt.Position = decoder.pos(pair.value)
+			}
+		}
+
+		return decoder.result(PassScore, evt)
+	case "pass_score_assist":
+		evt := PassScoreAssistEvt{TimeStamp: stamp, SourcePlayer: source}
+
+		for _, pair := range kvs {
+			if pair.key == "position" {
+				evt.Position = decoder.pos(pair.value)
+			}
+		}
+
+		return decoder.result(PassScoreAssist, evt)
+	case "pass_ball_blocked":
+		evt := PassBallBlockedEvt{TimeStamp: stamp, SourcePlayer: source, TargetPlayer: target}
+
+		for _, pair := range kvs {
+			switch pair.key {
+			case "thrower_position":
+				evt.ThrowerPosition = decoder.pos(pair.value)
+			case "blocker_position":
+				evt.BlockerPosition = decoder.pos(pair.value)
+			}
+		}
+
+		return decoder.result(PassBallBlocked, evt)
+	case "escort_score":
+		evt := EscortScoreEvt{TimeStamp: stamp, SourcePlayer: source}
+
+		for _, pair := range kvs {
+			if pair.key == "points" {
+				evt.Points = decoder.int(pair.value)
+			}
+		}
+
+		return decoder.result(EscortScore, evt)
+	}
+
+	return nil, false
+}
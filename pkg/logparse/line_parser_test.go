@@ -0,0 +1,100 @@
+package logparse_test
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/leighmacdonald/gbans/pkg/logparse"
+	"github.com/leighmacdonald/golib"
+	"github.com/leighmacdonald/steamid/v3/steamid"
+	"github.com/stretchr/testify/require"
+)
+
+var testLogFiles = []string{ //nolint:gochecknoglobals
+	"log_1.log", "log_3124689.log", "log_3474527.log", "log_sup_med_1.log", "log_koth.log", "log_payload.log",
+	"log_mvm.log", "log_passtime.log",
+}
+
+func readTestLog(tb testing.TB, name string) []string {
+	tb.Helper()
+
+	testFilePath := golib.FindFile(path.Join("testdata", name), "gbans")
+	if testFilePath == "" {
+		tb.Skipf("Cant find test file: %s", name)
+	}
+
+	body, errRead := os.ReadFile(testFilePath)
+	require.NoError(tb, errRead)
+
+	return strings.Split(string(body), "\n")
+}
+
+func TestParseLineEquivalence(t *testing.T) {
+	t.Parallel()
+
+	parser := logparse.NewLogParser()
+
+	for _, name := range testLogFiles {
+		for _, line := range readTestLog(t, name) {
+			expected, errExpected := parser.ParseRegex(line)
+			if errExpected != nil {
+				continue
+			}
+
+			result, errResult := parser.Parse(line)
+			require.NoError(t, errResult, line)
+			require.Equal(t, expected.EventType, result.EventType, line)
+			require.Equal(t, expected.Event, result.Event, line)
+		}
+	}
+}
+
+func TestParseLineBotNames(t *testing.T) {
+	t.Parallel()
+
+	testLogLine(t, `L 03/10/2024 - 18:03:41: "Soldier<21><BOT><Blue>" spawned as "Soldier"`, logparse.SpawnedAsEvt{
+		TimeStamp:    logparse.TimeStamp{CreatedOn: time.Date(2024, time.March, 10, 18, 3, 41, 0, time.UTC)},
+		SourcePlayer: logparse.SourcePlayer{Name: "Soldier", PID: 21, SID: steamid.New(logparse.BotSid), Team: logparse.BLU},
+		Class:        logparse.Soldier,
+	})
+	testLogLine(t, `L 02/05/2022 - 06:25:15: "maz<29><[U:1:90251326]><Red>" say "BOT"`, logparse.SayEvt{
+		TimeStamp:    logparse.TimeStamp{CreatedOn: time.Date(2022, time.February, 5, 6, 25, 15, 0, time.UTC)},
+		SourcePlayer: logparse.SourcePlayer{Name: "maz", PID: 29, SID: steamid.SID3ToSID64("[U:1:90251326]"), Team: logparse.RED},
+		Msg:          "BOT",
+	})
+}
+
+func BenchmarkParse(b *testing.B) {
+	var (
+		parser = logparse.NewLogParser()
+		lines  = readTestLog(b, "log_3124689.log")
+	)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, line := range lines {
+			_, _ = parser.Parse(line)
+		}
+	}
+}
+
+func BenchmarkParseRegex(b *testing.B) {
+	var (
+		parser = logparse.NewLogParser()
+		lines  = readTestLog(b, "log_3124689.log")
+	)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, line := range lines {
+			_, _ = parser.ParseRegex(line)
+		}
+	}
+}
@@ -6,6 +6,7 @@ package logparse
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 	"regexp"
 	"strconv"
@@ -33,6 +34,7 @@ type LogParser struct {
 
 type WeaponParser struct {
 	weaponNames map[Weapon]string
+	weaponKeys  map[string]Weapon
 }
 
 func (w *WeaponParser) Name(weaponKey Weapon) string {
@@ -45,13 +47,12 @@ func (w *WeaponParser) Name(weaponKey Weapon) string {
 }
 
 func (w *WeaponParser) Parse(s string) Weapon {
-	for weaponName, v := range w.weaponNames {
-		if v == s {
-			return weaponName
-		}
+	weapon, found := w.weaponKeys[s]
+	if !found {
+		return UnknownWeapon
 	}
 
-	return UnknownWeapon
+	return weapon
 }
 
 func (w *WeaponParser) NameMap() map[Weapon]string {
@@ -59,7 +60,7 @@ func (w *WeaponParser) NameMap() map[Weapon]string {
 }
 
 func NewWeaponParser() *WeaponParser { //nolint:maintidx
-	return &WeaponParser{
+	parser := &WeaponParser{
 		weaponNames: map[Weapon]string{
 			AiFlamethrower:           "Nostromo Napalmer",
 			Airstrike:                "Air Strike",
@@ -319,6 +320,13 @@ func NewWeaponParser() *WeaponParser { //nolint:maintidx
 			Wrench:                   "Wrench",
 		},
 	}
+
+	parser.weaponKeys = make(map[string]Weapon, len(parser.weaponNames))
+	for weapon, name := range parser.weaponNames {
+		parser.weaponKeys[name] = weapon
+	}
+
+	return parser
 }
 
 func NewLogParser() *LogParser {
@@ -335,7 +343,6 @@ func NewLogParser() *LogParser {
 			// L 08/12/2023 - 08:47:06: WARNING: ClientActive, but we don't know his SteamID?
 			// L 08/12/2023 - 08:47:05: VSCRIPT: Started VScript virtual machine using script language 'Squirrel'
 			// L 08/12/2023 - 08:47:05: Script not found (scripts/vscripts/mapspawn.nut)
-			// L 08/12/2023 - 08:47:05: "OMEGATRONIC<893><[U:1:918446193]><Red>" changed name to "butt cummer"
 			// L 08/12/2023 - 08:48:07: "sig_etc_ratelimit_exclude_commands" = ""
 			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+[Ll]og file started\s+(?P<keypairs>.+?)$`), LogStart},
 			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+[Ll]og file closed.$`), LogStop},
@@ -355,6 +362,7 @@ func NewLogParser() *LogParser {
 			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+"(?P<name>.+?)<(?P<pid>\d+)><(?P<sid>.+?)><(?P<team>(Unassigned|Red|Blue|Spectator|unknown))?>"\s+STEAM USERID [vV]alidated$`), Validated},
 			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+"(?P<name>.+?)<(?P<pid>\d+)><(?P<sid>.+?)><(?P<team>(Unassigned|Red|Blue|Spectator|unknown))?>"\s+[Cc]onnected, address(\s"(?P<address>.+?)")?$`), Connected},
 			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+"(?P<name>.+?)<(?P<pid>\d+)><(?P<sid>.+?)><(?P<team>(Unassigned|Red|Blue|Spectator|unknown))?>"\s+[Ee]ntered the game$`), Entered},
+			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+"(?P<name>.+?)<(?P<pid>\d+)><(?P<sid>.+?)><(?P<team>(Unassigned|Red|Blue|Spectator|unknown))?>"\s+changed name to "(?P<new_name>.+?)"$`), ChangedName},
 			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+"(?P<name>.+?)<(?P<pid>\d+)><(?P<sid>.+?)><(?P<team>(Unassigned|Red|Blue|Spectator|unknown))?>"\s+joined team "(?P<new_team>(Red|Blue|Spectator|Unassigned))"$`), JoinedTeam},
 			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+"(?P<name>.+?)<(?P<pid>\d+)><(?P<sid>.+?)><(?P<team>(Unassigned|Red|Blue|Spectator|unknown))?>"\s+changed role to "(?P<class>.+?)"`), ChangeClass},
 			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+"(?P<name>.+?)<(?P<pid>\d+)><(?P<sid>.+?)><(?P<team>(Unassigned|Red|Blue|Spectator|unknown))?>"\s+committed suicide with "(?P<weapon>.+?)"\s+(?P<keypairs>.+?)$`), Suicide},
@@ -377,12 +385,22 @@ func NewLogParser() *LogParser {
 			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+"(?P<name>.+?)<(?P<pid>\d+)><(?P<sid>.+?)><(?P<team>(Unassigned|Red|Blue|Spectator|unknown))?>"\s+triggered "killedobject"\s+(?P<keypairs>.+?)$`), KilledObject},
 			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+"(?P<name>.+?)<(?P<pid>\d+)><(?P<sid>.+?)><(?P<team>(Unassigned|Red|Blue|Spectator|unknown))?>"\s+triggered "object_detonated"\s+(?P<keypairs>.+?)$`), DetonatedObject},
 			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+"(?P<name>.+?)<(?P<pid>\d+)><(?P<sid>.+?)><(?P<team>(Unassigned|Red|Blue|Spectator|unknown))?>"\s+triggered "first_heal_after_spawn"\s+(?P<keypairs>.+?)$`), FirstHealAfterSpawn},
+			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+"(?P<name>.+?)<(?P<pid>\d+)><(?P<sid>.+?)><(?P<team>(Unassigned|Red|Blue|Spectator|unknown))?>"\s+triggered "pass_get"\s+(?P<keypairs>.+?)$`), PassGet},
+			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+"(?P<name>.+?)<(?P<pid>\d+)><(?P<sid>.+?)><(?P<team>(Unassigned|Red|Blue|Spectator|unknown))?>"\s+triggered "pass_free"\s+(?P<keypairs>.+?)$`), PassFree},
+			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+"(?P<name>.+?)<(?P<pid>\d+)><(?P<sid>.+?)><(?P<team>(Unassigned|Red|Blue|Spectator|unknown))?>"\s+triggered "pass_pass_caught" against "(?P<name2>.+?)<(?P<pid2>\d+)><(?P<sid2>.+?)><(?P<team2>(Unassigned|Red|Blue|Spectator)?)>"\s+(?P<keypairs>.+?)$`), PassCaught},
+			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+"(?P<name>.+?)<(?P<pid>\d+)><(?P<sid>.+?)><(?P<team>(Unassigned|Red|Blue|Spectator|unknown))?>"\s+triggered "pass_ball_stolen" against "(?P<name2>.+?)<(?P<pid2>\d+)><(?P<sid2>.+?)><(?P<team2>(Unassigned|Red|Blue|Spectator)?)>"\s+(?P<keypairs>.+?)$`), PassBallStolen},
+			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+"(?P<name>.+?)<(?P<pid>\d+)><(?P<sid>.+?)><(?P<team>(Unassigned|Red|Blue|Spectator|unknown))?>"\s+triggered "pass_score"\s+(?P<keypairs>.+?)$`), PassScore},
+			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+"(?P<name>.+?)<(?P<pid>\d+)><(?P<sid>.+?)><(?P<team>(Unassigned|Red|Blue|Spectator|unknown))?>"\s+triggered "pass_score_assist"\s+(?P<keypairs>.+?)$`), PassScoreAssist},
+			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+"(?P<name>.+?)<(?P<pid>\d+)><(?P<sid>.+?)><(?P<team>(Unassigned|Red|Blue|Spectator|unknown))?>"\s+triggered "pass_ball_blocked" against "(?P<name2>.+?)<(?P<pid2>\d+)><(?P<sid2>.+?)><(?P<team2>(Unassigned|Red|Blue|Spectator)?)>"\s+(?P<keypairs>.+?)$`), PassBallBlocked},
+			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+"(?P<name>.+?)<(?P<pid>\d+)><(?P<sid>.+?)><(?P<team>(Unassigned|Red|Blue|Spectator|unknown))?>"\s+triggered "escort_score"\s+(?P<keypairs>.+?)$`), EscortScore},
 			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+Team "(?P<team>.+?)" triggered "pointcaptured"\s+(?P<keypairs>.+?)$`), PointCaptured},
+			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+Team "(?P<team>Red|Blue)" triggered "escort_progress"\s+(?P<keypairs>.+?)$`), WEscortProgress},
+			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+Team "(?P<team>Red|Blue)" triggered "koth_timer"\s+(?P<keypairs>.+?)$`), WKothTimer},
 			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+"(?P<name>.+?)<(?P<pid>\d+)><(?P<sid>.+?)><(?P<team>(Unassigned|Red|Blue|Spectator|unknown))?>"\s+triggered "captureblocked"\s+(?P<keypairs>.+?)$`), CaptureBlocked},
 			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+"(?P<name>.+?)<(?P<pid>\d+)><(?P<sid>.+?)><(?P<team>(Unassigned|Red|Blue|Spectator|unknown))?>"\s+[Dd]isconnected \(reason "(?P<reason>(.|\n)*)"`), Disconnected},
 			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+World triggered "Round_Overtime"`), WRoundOvertime},
 			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+World triggered "Round_Start"`), WRoundStart},
-			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+World triggered "Round_Setup_End"`), WRoundStart},
+			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+World triggered "Round_Setup_End"`), WRoundSetupEnd},
 			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+World triggered "Round_Win"\s+(?P<keypairs>.+?)$`), WRoundWin},
 			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+World triggered "Round_Length"\s+(?P<keypairs>.+?)$`), WRoundLen},
 			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+World triggered "Game_Over" reason "(?P<reason>.+?)"`), WGameOver},
@@ -402,6 +420,9 @@ func NewLogParser() *LogParser {
 			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+World triggered "Round_Setup_Begin"`), WRoundSetupBegin},
 			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+World triggered "Mini_Round_Selected"\s+(?P<keypairs>.+?)$`), WMiniRoundSelected},
 			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+World triggered "Mini_Round_Start"`), WMiniRoundStart},
+			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+World triggered "MvM_Wave_Start"\s+(?P<keypairs>.+?)$`), WMvMWaveStart},
+			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+World triggered "MvM_Wave_Complete"\s+(?P<keypairs>.+?)$`), WMvMWaveComplete},
+			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+World triggered "MvM_Wave_Failed"\s+(?P<keypairs>.+?)$`), WMvMWaveFailed},
 			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+"(.+?)"\s=\s"(.+?)"$`), IgnoredMsg},
 			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+server cvars start`), IgnoredMsg},
 			{regexp.MustCompile(`^L\s(?P<created_on>.+?):\s+\[META]`), IgnoredMsg},
@@ -550,22 +571,24 @@ func reSubMatchMap(regex *regexp.Regexp, str string) (map[string]any, bool) {
 }
 
 func ParsePos(posStr string, pos *Pos) bool {
-	pieces := strings.SplitN(posStr, " ", 3)
-	if len(pieces) != 3 {
+	xStr, rest, foundX := strings.Cut(posStr, " ")
+	yStr, zStr, foundY := strings.Cut(rest, " ")
+
+	if !foundX || !foundY {
 		return false
 	}
 
-	posX, errParseX := strconv.ParseFloat(pieces[0], 64)
+	posX, errParseX := strconv.ParseFloat(xStr, 64)
 	if errParseX != nil {
 		return false
 	}
 
-	posY, errParseY := strconv.ParseFloat(pieces[1], 64)
+	posY, errParseY := strconv.ParseFloat(yStr, 64)
 	if errParseY != nil {
 		return false
 	}
 
-	posZ, errParseZ := strconv.ParseFloat(pieces[2], 64)
+	posZ, errParseZ := strconv.ParseFloat(zStr, 64)
 	if errParseZ != nil {
 		return false
 	}
@@ -719,8 +742,22 @@ type Results struct {
 
 // Parse will parse the log line into a known type and values.
 //
-//nolint:gocognit,funlen,maintidx
+// Lines are first handled by parseLine, which dispatches on the fixed prefixes of the log format and decodes
+// the values directly into the event structs. Any line it does not recognize is handled by the slower regex
+// parser.
 func (p *LogParser) Parse(logLine string) (*Results, error) {
+	if result, ok := p.parseLine(logLine); ok {
+		return result, nil
+	}
+
+	return p.parseRegex(logLine)
+}
+
+// parseRegex tries each of the known event regexes in order, decoding the matched values into the event
+// struct using mapstructure.
+//
+//nolint:gocognit,funlen,maintidx
+func (p *LogParser) parseRegex(logLine string) (*Results, error) {
 	for _, parser := range p.rxParsers {
 		matchMap, found := reSubMatchMap(parser.Rx, strings.TrimSuffix(strings.TrimSuffix(logLine, "\n"), "\r"))
 		if found {
@@ -865,6 +902,13 @@ func (p *LogParser) Parse(logLine string) (*Results, error) {
 					return nil, errUnmarshal
 				}
 
+				event = parsedEvent
+			case ChangedName:
+				var parsedEvent ChangedNameEvt
+				if errUnmarshal = p.unmarshal(values, &parsedEvent); errUnmarshal != nil {
+					return nil, errUnmarshal
+				}
+
 				event = parsedEvent
 			case KilledObject:
 				var parsedEvent KilledObjectEvt
@@ -1112,6 +1156,13 @@ func (p *LogParser) Parse(logLine string) (*Results, error) {
 					return nil, errUnmarshal
 				}
 
+				event = parsedEvent
+			case WRoundSetupEnd:
+				var parsedEvent WRoundSetupEndEvt
+				if errUnmarshal = p.unmarshal(values, &parsedEvent); errUnmarshal != nil {
+					return nil, errUnmarshal
+				}
+
 				event = parsedEvent
 			case WMiniRoundSelected:
 				var parsedEvent WMiniRoundSelectedEvt
@@ -1143,6 +1194,104 @@ func (p *LogParser) Parse(logLine string) (*Results, error) {
 				event = parsedEvent
 			case SteamAuth:
 				break
+			case PassGet:
+				var parsedEvent PassGetEvt
+				if errUnmarshal = p.unmarshal(values, &parsedEvent); errUnmarshal != nil {
+					return nil, errUnmarshal
+				}
+
+				event = parsedEvent
+			case PassFree:
+				var parsedEvent PassFreeEvt
+				if errUnmarshal = p.unmarshal(values, &parsedEvent); errUnmarshal != nil {
+					return nil, errUnmarshal
+				}
+
+				event = parsedEvent
+			case PassCaught:
+				var parsedEvent PassCaughtEvt
+				if errUnmarshal = p.unmarshal(values, &parsedEvent); errUnmarshal != nil {
+					return nil, errUnmarshal
+				}
+
+				event = parsedEvent
+			case PassBallStolen:
+				var parsedEvent PassBallStolenEvt
+				if errUnmarshal = p.unmarshal(values, &parsedEvent); errUnmarshal != nil {
+					return nil, errUnmarshal
+				}
+
+				event = parsedEvent
+			case PassScore:
+				var parsedEvent PassScoreEvt
+				if errUnmarshal = p.unmarshal(values, &parsedEvent); errUnmarshal != nil {
+					return nil, errUnmarshal
+				}
+
+				event = parsedEvent
+			case PassScoreAssist:
+				var parsedEvent PassScoreAssistEvt
+				if errUnmarshal = p.unmarshal(values, &parsedEvent); errUnmarshal != nil {
+					return nil, errUnmarshal
+				}
+
+				event = parsedEvent
+			case PassBallBlocked:
+				var parsedEvent PassBallBlockedEvt
+				if errUnmarshal = p.unmarshal(values, &parsedEvent); errUnmarshal != nil {
+					return nil, errUnmarshal
+				}
+
+				event = parsedEvent
+			case EscortScore:
+				var parsedEvent EscortScoreEvt
+				if errUnmarshal = p.unmarshal(values, &parsedEvent); errUnmarshal != nil {
+					return nil, errUnmarshal
+				}
+
+				event = parsedEvent
+			case WEscortProgress:
+				var parsedEvent WEscortProgressEvt
+				if errUnmarshal = p.unmarshal(values, &parsedEvent); errUnmarshal != nil {
+					return nil, errUnmarshal
+				}
+
+				event = parsedEvent
+			case WKothTimer:
+				var parsedEvent WKothTimerEvt
+				if errUnmarshal = p.unmarshal(values, &parsedEvent); errUnmarshal != nil {
+					return nil, errUnmarshal
+				}
+
+				event = parsedEvent
+			case WMvMWaveStart:
+				var parsedEvent WMvMWaveStartEvt
+				if errUnmarshal = p.unmarshal(values, &parsedEvent); errUnmarshal != nil {
+					return nil, errUnmarshal
+				}
+
+				event = parsedEvent
+			case WMvMWaveComplete:
+				var parsedEvent WMvMWaveCompleteEvt
+				if errUnmarshal = p.unmarshal(values, &parsedEvent); errUnmarshal != nil {
+					return nil, errUnmarshal
+				}
+
+				event = parsedEvent
+			case WMvMWaveFailed:
+				var parsedEvent WMvMWaveFailedEvt
+				if errUnmarshal = p.unmarshal(values, &parsedEvent); errUnmarshal != nil {
+					return nil, errUnmarshal
+				}
+
+				event = parsedEvent
+			case MapLoad:
+				var parsedEvent MapLoadEvt
+				if errUnmarshal = p.unmarshal(values, &parsedEvent); errUnmarshal != nil {
+					return nil, errUnmarshal
+				}
+
+				event = parsedEvent
 			case MapStarted:
 				var parsedEvent MapStartedEvt
 				if errUnmarshal = p.unmarshal(values, &parsedEvent); errUnmarshal != nil {
@@ -1179,8 +1328,10 @@ func (p *LogParser) Parse(logLine string) (*Results, error) {
 }
 
 func (p *LogParser) decodeTeam() func(reflect.Type, reflect.Type, any) (any, error) {
+	targetType := reflect.TypeOf(UNASSIGNED)
+
 	return func(fromType reflect.Type, toType reflect.Type, value any) (any, error) {
-		if fromType.Kind() != reflect.String {
+		if fromType.Kind() != reflect.String || toType != targetType {
 			return value, nil
 		}
 
@@ -1200,8 +1351,10 @@ func (p *LogParser) decodeTeam() func(reflect.Type, reflect.Type, any) (any, err
 }
 
 func (p *LogParser) decodePlayerClass() func(reflect.Type, reflect.Type, any) (any, error) {
+	targetType := reflect.TypeOf(Spectator)
+
 	return func(fromType reflect.Type, toType reflect.Type, value any) (any, error) {
-		if fromType.Kind() != reflect.String {
+		if fromType.Kind() != reflect.String || toType != targetType {
 			return value, nil
 		}
 
@@ -1221,8 +1374,10 @@ func (p *LogParser) decodePlayerClass() func(reflect.Type, reflect.Type, any) (a
 }
 
 func (p *LogParser) decodePos() func(reflect.Type, reflect.Type, any) (any, error) {
+	targetType := reflect.TypeOf(Pos{})
+
 	return func(fromType reflect.Type, toType reflect.Type, value any) (any, error) {
-		if fromType.Kind() != reflect.String {
+		if fromType.Kind() != reflect.String || toType != targetType {
 			return value, nil
 		}
 
@@ -1245,8 +1400,10 @@ func (p *LogParser) decodePos() func(reflect.Type, reflect.Type, any) (any, erro
 const BotSid = 807
 
 func (p *LogParser) decodeSID3() func(reflect.Type, reflect.Type, any) (any, error) {
+	targetType := reflect.TypeOf(steamid.SID64(""))
+
 	return func(fromType reflect.Type, toType reflect.Type, value any) (any, error) {
-		if fromType.Kind() != reflect.String {
+		if fromType.Kind() != reflect.String || toType != targetType {
 			return value, nil
 		}
 
@@ -1286,8 +1443,10 @@ func (p *LogParser) decodeSID3() func(reflect.Type, reflect.Type, any) (any, err
 //}
 
 func (p *LogParser) decodePickupItem() func(reflect.Type, reflect.Type, any) (any, error) {
+	targetType := reflect.TypeOf(PickupItem(0))
+
 	return func(fromType reflect.Type, toType reflect.Type, value any) (any, error) {
-		if fromType.Kind() != reflect.String {
+		if fromType.Kind() != reflect.String || toType != targetType {
 			return value, nil
 		}
 
@@ -1307,8 +1466,10 @@ func (p *LogParser) decodePickupItem() func(reflect.Type, reflect.Type, any) (an
 }
 
 func (p *LogParser) decodeWeapon() func(reflect.Type, reflect.Type, any) (any, error) {
+	targetType := reflect.TypeOf(Weapon(""))
+
 	return func(fromType reflect.Type, toType reflect.Type, value any) (any, error) {
-		if fromType.Kind() != reflect.String {
+		if fromType.Kind() != reflect.String || toType != targetType {
 			return value, nil
 		}
 
@@ -1327,8 +1488,10 @@ func (p *LogParser) decodeWeapon() func(reflect.Type, reflect.Type, any) (any, e
 }
 
 func (p *LogParser) decodeTime() func(reflect.Type, reflect.Type, any) (any, error) {
+	targetType := reflect.TypeOf(time.Time{})
+
 	return func(fromType reflect.Type, toType reflect.Type, value any) (any, error) {
-		if fromType.Kind() != reflect.String {
+		if fromType.Kind() != reflect.String || toType != targetType {
 			return value, nil
 		}
 
@@ -1351,6 +1514,8 @@ func (p *LogParser) decodeTime() func(reflect.Type, reflect.Type, any) (any, err
 // eg: {"sm_nextmap": "pl_frontier_final"} -> CVAREvt
 func (p *LogParser) unmarshal(input any, output any) error {
 	decoder, errNewDecoder := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		// Each hook only decodes into fields of its own type, so free text such as player names and chat
+		// matching a class, team or "BOT" is left as is.
 		DecodeHook: mapstructure.ComposeDecodeHookFunc(
 			p.decodeTime(),
 			p.decodeTeam(),
@@ -1388,3 +1553,13 @@ type Pos struct {
 func (p *Pos) Encode() string {
 	return fmt.Sprintf(`ST_SetSRID(ST_MakePoint(%f, %f, %f), 4326)`, p.Y, p.X, p.Z)
 }
+
+// Empty checks if the position is unset. Older servers and some events don't log positions.
+func (p Pos) Empty() bool {
+	return p.X == 0 && p.Y == 0 && p.Z == 0
+}
+
+// Distance returns the distance between the positions in hammer units.
+func (p Pos) Distance(other Pos) float64 {
+	return math.Sqrt((p.X-other.X)*(p.X-other.X) + (p.Y-other.Y)*(p.Y-other.Y) + (p.Z-other.Z)*(p.Z-other.Z))
+}
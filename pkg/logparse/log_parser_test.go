@@ -186,6 +186,94 @@ func TestParseWRoundStartEvt(t *testing.T) {
 		})
 }
 
+func TestParseWRoundSetupEndEvt(t *testing.T) {
+	t.Parallel()
+
+	testLogLine(t, `L 02/21/2021 - 06:22:23: World triggered "Round_Setup_End"`,
+		logparse.WRoundSetupEndEvt{
+			CreatedOn: time.Date(2021, time.February, 21, 6, 22, 23, 0, time.UTC),
+		})
+}
+
+func TestParseMapLoadEvt(t *testing.T) {
+	t.Parallel()
+
+	testLogLine(t, `L 02/21/2021 - 06:22:23: Loading map "pass_brickyard"`,
+		logparse.MapLoadEvt{
+			TimeStamp: logparse.TimeStamp{CreatedOn: time.Date(2021, time.February, 21, 6, 22, 23, 0, time.UTC)},
+			Map:       "pass_brickyard",
+		})
+}
+
+func TestParsePassEvts(t *testing.T) {
+	t.Parallel()
+
+	var (
+		created = logparse.TimeStamp{CreatedOn: time.Date(2024, time.March, 9, 20, 14, 31, 0, time.UTC)}
+		moogle  = logparse.SourcePlayer{Name: "Moogle", PID: 4, SID: steamid.New("[U:1:91618645]"), Team: logparse.BLU}
+		ashtray = logparse.TargetPlayer{Name2: "ashtray", PID2: 7, SID2: steamid.New("[U:1:204626678]"), Team2: logparse.BLU}
+		kernel  = logparse.SourcePlayer{Name: "kernel", PID: 11, SID: steamid.New("[U:1:423376881]"), Team: logparse.RED}
+	)
+
+	testLogLine(t, `L 03/09/2024 - 20:14:31: "Moogle<4><[U:1:91618645]><Blue>" triggered "pass_get" (firstcontact "1") (position "-12 1430 -223")`,
+		logparse.PassGetEvt{
+			TimeStamp: created, SourcePlayer: moogle, FirstContact: true, Position: logparse.Pos{X: -12, Y: 1430, Z: -223},
+		})
+
+	testLogLine(t, `L 03/09/2024 - 20:14:31: "Moogle<4><[U:1:91618645]><Blue>" triggered "pass_free" (position "-12 1430 -223")`,
+		logparse.PassFreeEvt{TimeStamp: created, SourcePlayer: moogle, Position: logparse.Pos{X: -12, Y: 1430, Z: -223}})
+
+	testLogLine(t, `L 03/09/2024 - 20:14:31: "Moogle<4><[U:1:91618645]><Blue>" triggered "pass_pass_caught" against "ashtray<7><[U:1:204626678]><Blue>" (interception "0") (save "1") (handoff "0") (dist "512.250") (duration "1.134") (thrower_position "-12 1430 -223") (catcher_position "140 1900 -223")`,
+		logparse.PassCaughtEvt{
+			TimeStamp: created, SourcePlayer: moogle, TargetPlayer: ashtray, Save: true, Dist: 512.25, Duration: 1.134,
+			ThrowerPosition: logparse.Pos{X: -12, Y: 1430, Z: -223}, CatcherPosition: logparse.Pos{X: 140, Y: 1900, Z: -223},
+		})
+
+	testLogLine(t, `L 03/09/2024 - 20:14:31: "kernel<11><[U:1:423376881]><Red>" triggered "pass_ball_stolen" against "ashtray<7><[U:1:204626678]><Blue>" (steal_defense "1") (thief_position "40 -900 -223") (victim_position "42 -910 -223")`,
+		logparse.PassBallStolenEvt{
+			TimeStamp: created, SourcePlayer: kernel, TargetPlayer: ashtray, StealDefense: true,
+			ThiefPosition: logparse.Pos{X: 40, Y: -900, Z: -223}, VictimPosition: logparse.Pos{X: 42, Y: -910, Z: -223},
+		})
+
+	testLogLine(t, `L 03/09/2024 - 20:14:31: "Moogle<4><[U:1:91618645]><Blue>" triggered "pass_score" (points "3") (panacea "0") (win strat "1") (deathbomb "0") (dist "50.000") (position "82 3650 -95")`,
+		logparse.PassScoreEvt{
+			TimeStamp: created, SourcePlayer: moogle, Points: 3, WinStrat: true, Dist: 50,
+			Position: logparse.Pos{X: 82, Y: 3650, Z: -95},
+		})
+
+	testLogLine(t, `L 03/09/2024 - 20:14:31: "Moogle<4><[U:1:91618645]><Blue>" triggered "pass_score_assist" (position "82 3200 -95")`,
+		logparse.PassScoreAssistEvt{TimeStamp: created, SourcePlayer: moogle, Position: logparse.Pos{X: 82, Y: 3200, Z: -95}})
+
+	testLogLine(t, `L 03/09/2024 - 20:14:31: "kernel<11><[U:1:423376881]><Red>" triggered "pass_ball_blocked" against "ashtray<7><[U:1:204626678]><Blue>" (thrower_position "40 -1300 -223") (blocker_position "40 -1500 -223")`,
+		logparse.PassBallBlockedEvt{
+			TimeStamp: created, SourcePlayer: kernel, TargetPlayer: ashtray,
+			ThrowerPosition: logparse.Pos{X: 40, Y: -1300, Z: -223}, BlockerPosition: logparse.Pos{X: 40, Y: -1500, Z: -223},
+		})
+}
+
+func TestParseObjectiveEvts(t *testing.T) {
+	t.Parallel()
+
+	created := logparse.TimeStamp{CreatedOn: time.Date(2023, time.August, 13, 9, 11, 31, 0, time.UTC)}
+
+	testLogLine(t, `L 08/13/2023 - 09:11:31: "e-waste<20><[U:1:375639964]><Blue>" triggered "escort_score" (points "2")`,
+		logparse.EscortScoreEvt{
+			TimeStamp:    created,
+			SourcePlayer: logparse.SourcePlayer{Name: "e-waste", PID: 20, SID: steamid.New("[U:1:375639964]"), Team: logparse.BLU},
+			Points:       2,
+		})
+	testLogLine(t, `L 08/13/2023 - 09:11:31: Team "Blue" triggered "escort_progress" (progress "0.52")`,
+		logparse.WEscortProgressEvt{TimeStamp: created, Team: logparse.BLU, Progress: 0.52})
+	testLogLine(t, `L 08/13/2023 - 09:11:31: Team "Red" triggered "koth_timer" (held "138")`,
+		logparse.WKothTimerEvt{TimeStamp: created, Team: logparse.RED, Held: 138})
+	testLogLine(t, `L 08/13/2023 - 09:11:31: World triggered "MvM_Wave_Start" (wave "2") (max_waves "7")`,
+		logparse.WMvMWaveStartEvt{TimeStamp: created, Wave: 2, MaxWaves: 7})
+	testLogLine(t, `L 08/13/2023 - 09:11:31: World triggered "MvM_Wave_Complete" (wave "2")`,
+		logparse.WMvMWaveCompleteEvt{TimeStamp: created, Wave: 2})
+	testLogLine(t, `L 08/13/2023 - 09:11:31: World triggered "MvM_Wave_Failed" (wave "3")`,
+		logparse.WMvMWaveFailedEvt{TimeStamp: created, Wave: 3})
+}
+
 func TestParseMedicDeathEvt(t *testing.T) {
 	t.Parallel()
 
@@ -294,6 +382,17 @@ func TestParseConnectedEvt(t *testing.T) {
 		})
 }
 
+func TestParseChangedNameEvt(t *testing.T) {
+	t.Parallel()
+
+	testLogLine(t, `L 08/12/2023 - 08:47:05: "OMEGATRONIC<893><[U:1:918446193]><Red>" changed name to "new name"`,
+		logparse.ChangedNameEvt{
+			TimeStamp:    logparse.TimeStamp{CreatedOn: time.Date(2023, time.August, 12, 8, 47, 5, 0, time.UTC)},
+			SourcePlayer: logparse.SourcePlayer{Name: "OMEGATRONIC", PID: 893, SID: steamid.New("[U:1:918446193]"), Team: logparse.RED},
+			NewName:      "new name",
+		})
+}
+
 // func TestParseEmptyEvt(t *testing.T) {
 //	testLogLine(t, `L 02/21/2021 - 06:22:23: "amogus gaming<13><[U:1:1089803558]><>" STEAM USERID Validated`,
 //		TimeStamp{
@@ -794,3 +893,14 @@ func TestParseKVs(t *testing.T) {
 		"position2":  "57 78 1602",
 	}, keyMapB)
 }
+
+func TestPosDistance(t *testing.T) {
+	t.Parallel()
+
+	source := logparse.Pos{X: 100, Y: 200, Z: -50}
+
+	require.InDelta(t, 0, source.Distance(source), 0.0001)
+	require.InDelta(t, 5, source.Distance(logparse.Pos{X: 103, Y: 204, Z: -50}), 0.0001)
+	require.True(t, logparse.Pos{}.Empty())
+	require.False(t, source.Empty())
+}
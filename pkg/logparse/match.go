@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"math"
 	"sort"
-	"strings"
 	"time"
 
 	"github.com/gofrs/uuid/v5"
@@ -48,6 +47,23 @@ type WeaponStats struct {
 	Airshots  int
 	Headshots int
 	BackStabs int
+	// Sums of the kill distances for kills where both positions were logged. The squared sum
+	// allows the variance to be derived from the aggregated values.
+	DistanceKills     int
+	DistanceSum       float64
+	DistanceSquareSum float64
+}
+
+func (ws *WeaponStats) addKillDistance(sourcePos Pos, targetPos Pos) {
+	if sourcePos.Empty() || targetPos.Empty() {
+		return
+	}
+
+	distance := sourcePos.Distance(targetPos)
+
+	ws.DistanceKills++
+	ws.DistanceSum += distance
+	ws.DistanceSquareSum += distance * distance
 }
 
 func NewWeaponStats() *WeaponStats {
@@ -87,9 +103,11 @@ type Match struct {
 	ServerID   int              `json:"server_id"`
 	Title      string           `json:"title"`
 	MapName    string           `json:"map_name"`
+	GameMode   GameMode         `json:"game_mode"`
 	TeamScores TeamScores       `json:"team_scores"`
 	PlayerSums MatchPlayerSums  `json:"player_sums"`
 	Rounds     []*MatchRoundSum `json:"rounds"`
+	Timeline   []*TimelineSum   `json:"timeline"`
 	Chat       []MatchChat      `json:"chat"`
 	TimeStart  *time.Time       `json:"time_start"`
 	TimeEnd    *time.Time       `json:"time_end"`
@@ -97,6 +115,16 @@ type Match struct {
 	inMatch  bool // We ignore most events until Round_Start event
 	inRound  bool
 	curRound int
+	// pointOwner is the team currently holding the koth control point
+	pointOwner      Team
+	pointOwnerSince time.Time
+	timeRed         time.Duration
+	timeBlu         time.Duration
+	// pointCappers are the players who captured the koth point for the current owner
+	pointCappers []steamid.SID64
+	// roundTimeRed and roundTimeBlu are the team point times at the start of the current round
+	roundTimeRed time.Duration
+	roundTimeBlu time.Duration
 }
 
 func NewMatch(serverID int, serverName string) Match {
@@ -133,11 +161,12 @@ func (match *Match) Winner() Team {
 		return BLU
 	}
 
-	if strings.HasPrefix(match.MapName, "pl_") {
-		if match.TeamScores.RedTime > match.TeamScores.BluTime {
-			return BLU
-		} else {
-			return RED
+	switch match.GameMode { //nolint:exhaustive
+	case GameModeMvM, GameModePayload:
+		// MvM has no team scores and stopwatch payload ties on score, in both cases the last round decides
+		// the match. Either the defenders completing the final wave or the team attacking second beating the time.
+		if len(match.Rounds) > 0 {
+			return match.Rounds[len(match.Rounds)-1].RoundWinner
 		}
 	}
 
@@ -189,6 +218,13 @@ func (match *Match) Apply(result *Results) error { //nolint:maintidx
 	// having effects on things like player stats.
 	switch result.EventType {
 	case MapLoad:
+		evt, ok := result.Event.(MapLoadEvt)
+		if !ok {
+			return ErrInvalidType
+		}
+
+		match.setMap(evt.Map)
+
 		return nil
 	case SayTeam:
 		fallthrough
@@ -220,12 +256,30 @@ func (match *Match) Apply(result *Results) error { //nolint:maintidx
 			return ErrInvalidType
 		}
 
-		match.MapName = evt.Map
+		match.setMap(evt.Map)
 
 		return nil
 
 	case WRoundStart:
-		match.roundStart()
+		evt, ok := result.Event.(WRoundStartEvt)
+		if !ok {
+			return ErrInvalidType
+		}
+
+		match.roundStart(evt)
+
+		return nil
+	case WRoundSetupEnd:
+		evt, ok := result.Event.(WRoundSetupEndEvt)
+		if !ok {
+			return ErrInvalidType
+		}
+
+		// Maps with a setup phase have already started the round, this only matters for logs which
+		// begin part way through setup.
+		if !match.inRound {
+			match.roundStart(WRoundStartEvt(evt))
+		}
 
 		return nil
 	case WGameOver:
@@ -336,6 +390,85 @@ func (match *Match) Apply(result *Results) error { //nolint:maintidx
 
 		match.pointCapture(evt)
 
+	case PassGet:
+		evt, ok := result.Event.(PassGetEvt)
+		if !ok {
+			return ErrInvalidType
+		}
+
+		match.passGet(evt)
+	case PassFree:
+		match.setGameModeFromEvent(GameModePasstime)
+	case PassCaught:
+		evt, ok := result.Event.(PassCaughtEvt)
+		if !ok {
+			return ErrInvalidType
+		}
+
+		match.passCaught(evt)
+	case PassBallStolen:
+		evt, ok := result.Event.(PassBallStolenEvt)
+		if !ok {
+			return ErrInvalidType
+		}
+
+		match.passBallStolen(evt)
+	case PassScore:
+		evt, ok := result.Event.(PassScoreEvt)
+		if !ok {
+			return ErrInvalidType
+		}
+
+		match.passScore(evt)
+	case PassScoreAssist:
+		evt, ok := result.Event.(PassScoreAssistEvt)
+		if !ok {
+			return ErrInvalidType
+		}
+
+		match.passScoreAssist(evt)
+	case PassBallBlocked:
+		evt, ok := result.Event.(PassBallBlockedEvt)
+		if !ok {
+			return ErrInvalidType
+		}
+
+		match.passBallBlocked(evt)
+	case EscortScore:
+		evt, ok := result.Event.(EscortScoreEvt)
+		if !ok {
+			return ErrInvalidType
+		}
+
+		match.escortScore(evt)
+	case WEscortProgress:
+		evt, ok := result.Event.(WEscortProgressEvt)
+		if !ok {
+			return ErrInvalidType
+		}
+
+		match.escortProgress(evt)
+	case WKothTimer:
+		evt, ok := result.Event.(WKothTimerEvt)
+		if !ok {
+			return ErrInvalidType
+		}
+
+		match.kothTimer(evt)
+	case WMvMWaveStart:
+		match.setGameModeFromEvent(GameModeMvM)
+	case WMvMWaveComplete:
+		if _, ok := result.Event.(WMvMWaveCompleteEvt); !ok {
+			return ErrInvalidType
+		}
+
+		match.waveEnd(true)
+	case WMvMWaveFailed:
+		if _, ok := result.Event.(WMvMWaveFailedEvt); !ok {
+			return ErrInvalidType
+		}
+
+		match.waveEnd(false)
 	case CaptureBlocked:
 		evt, ok := result.Event.(CaptureBlockedEvt)
 		if !ok {
@@ -595,16 +728,96 @@ func (match *Match) getRound() *MatchRoundSum {
 	return match.Rounds[match.curRound]
 }
 
-func (match *Match) roundStart() {
+// setMap updates the current map and the game mode it implies.
+func (match *Match) setMap(mapName string) {
+	match.MapName = mapName
+
+	if mode := GameModeFromMap(mapName); mode != GameModeUnknown {
+		match.GameMode = mode
+	}
+}
+
+// setGameModeFromEvent sets the game mode from a mode specific event when the map name is not known,
+// which is the case for logs that start after the map has loaded.
+func (match *Match) setGameModeFromEvent(mode GameMode) {
+	if match.GameMode == GameModeUnknown {
+		match.GameMode = mode
+	}
+}
+
+// updatePointTime credits the koth point owner with the time held since the last update.
+func (match *Match) updatePointTime(created time.Time) {
+	if match.GameMode != GameModeKOTH {
+		return
+	}
+
+	held := created.Sub(match.pointOwnerSince)
+
+	switch match.pointOwner { //nolint:exhaustive
+	case RED:
+		match.timeRed += held
+		match.TeamScores.RedTime = int(match.timeRed.Seconds())
+	case BLU:
+		match.timeBlu += held
+		match.TeamScores.BluTime = int(match.timeBlu.Seconds())
+	}
+
+	for _, sid := range match.pointCappers {
+		player := match.getPlayer(created, sid)
+		player.pointHeld += held
+		player.Objectives.KothCapTime = int(player.pointHeld.Seconds())
+	}
+
+	match.pointOwnerSince = created
+}
+
+func (match *Match) roundStart(evt WRoundStartEvt) {
+	// The koth point always starts out neutral
+	match.pointOwner = UNASSIGNED
+	match.pointOwnerSince = evt.CreatedOn
+	match.pointCappers = nil
+	match.roundTimeRed = match.timeRed
+	match.roundTimeBlu = match.timeBlu
+	round := &MatchRoundSum{
+		TimeStart: evt.CreatedOn,
+		Score:     TeamScores{},
+	}
+
+	// A round that never finished, eg: from the server restarting the round, is replaced
+	if match.inRound && match.curRound >= 0 {
+		match.Rounds[match.curRound] = round
+
+		return
+	}
+
 	match.inMatch = true
 	match.inRound = true
 	match.curRound++
-	match.Rounds = append(match.Rounds, &MatchRoundSum{
-		Score: TeamScores{},
-	})
+	match.Rounds = append(match.Rounds, round)
+}
+
+// getTimeline returns the timeline bucket the event time falls into, creating any missing buckets
+// up to that point. Events before the match start are not tracked.
+func (match *Match) getTimeline(created time.Time) *TimelineSum {
+	if match.TimeStart == nil || created.Before(*match.TimeStart) {
+		return nil
+	}
+
+	index := int(created.Sub(*match.TimeStart) / TimelineInterval)
+	for len(match.Timeline) <= index {
+		match.Timeline = append(match.Timeline, &TimelineSum{
+			Offset: time.Duration(len(match.Timeline)) * TimelineInterval,
+		})
+	}
+
+	return match.Timeline[index]
 }
 
 func (match *Match) roundWin(evt WRoundWinEvt) {
+	match.updatePointTime(evt.CreatedOn)
+	match.pointOwner = UNASSIGNED
+	match.pointCappers = nil
+
 	round := match.getRound()
 	if round != nil {
 		round.RoundWinner = evt.Winner
@@ -659,6 +872,11 @@ func (match *Match) spawnedAs(evt SpawnedAsEvt) {
 		playerSum.Name = evt.Name
 	}
 
+	// Logs which start part way through the match are missing the join team events
+	if evt.Team == RED || evt.Team == BLU {
+		playerSum.Team = evt.Team
+	}
+
 	playerSum.setPlayerClass(evt.CreatedOn, evt.Class)
 
 	playerSum.currentLifeStart = evt.CreatedOn
@@ -685,6 +903,10 @@ func (match *Match) assist(evt KillAssistEvt) {
 	if classStats := player.getClassStats(); classStats != nil {
 		classStats.Assists++
 	}
+
+	if victim, found := match.PlayerSums[evt.SID2]; found && victim.currentClass != Spectator {
+		player.ClassKillAssists[victim.currentClass]++
+	}
 }
 
 func (match *Match) joinTeam(evt JoinedTeamEvt) {
@@ -786,6 +1008,14 @@ func (match *Match) damage(evt DamageEvt) {
 		} else if evt.Team == BLU {
 			round.DamageBlu += dmg
 		}
+
+		if timeline := match.getTimeline(evt.CreatedOn); timeline != nil {
+			if evt.Team == RED {
+				timeline.DamageRed += dmg
+			} else if evt.Team == BLU {
+				timeline.DamageBlu += dmg
+			}
+		}
 	}
 }
 
@@ -829,6 +1059,125 @@ func (match *Match) pointCapture(evt PointCapturedEvt) {
 			cs.Captures++
 		}
 	}
+
+	if round := match.getRound(); round != nil {
+		if evt.Team == RED {
+			round.CapsRed++
+		} else if evt.Team == BLU {
+			round.CapsBlu++
+		}
+	}
+
+	if match.inRound {
+		match.updatePointTime(evt.CreatedOn)
+		match.pointOwner = evt.Team
+		match.pointCappers = match.pointCappers[:0]
+
+		for _, evtPlayer := range evt.Players() {
+			match.pointCappers = append(match.pointCappers, evtPlayer.SID)
+		}
+	}
+}
+
+// kothTimer replaces the point time of the team for the current round with the value from the round timer,
+// which unlike the capture times also accounts for the time the point was contested.
+func (match *Match) kothTimer(evt WKothTimerEvt) {
+	match.setGameModeFromEvent(GameModeKOTH)
+	match.updatePointTime(evt.CreatedOn)
+
+	held := time.Duration(evt.Held) * time.Second
+
+	switch evt.Team { //nolint:exhaustive
+	case RED:
+		match.timeRed = match.roundTimeRed + held
+		match.TeamScores.RedTime = int(match.timeRed.Seconds())
+	case BLU:
+		match.timeBlu = match.roundTimeBlu + held
+		match.TeamScores.BluTime = int(match.timeBlu.Seconds())
+	}
+}
+
+func (match *Match) escortScore(evt EscortScoreEvt) {
+	match.setGameModeFromEvent(GameModePayload)
+	match.getPlayer(evt.CreatedOn, evt.SID).Objectives.CartPoints += evt.Points
+}
+
+// escortProgress records the furthest the team pushed their cart in the current round, the cart rolling
+// back does not reduce it.
+func (match *Match) escortProgress(evt WEscortProgressEvt) {
+	match.setGameModeFromEvent(GameModePayload)
+
+	round := match.getRound()
+	if round == nil {
+		return
+	}
+
+	switch evt.Team { //nolint:exhaustive
+	case RED:
+		if evt.Progress > round.CartProgressRed {
+			round.CartProgressRed = evt.Progress
+		}
+	case BLU:
+		if evt.Progress > round.CartProgressBlu {
+			round.CartProgressBlu = evt.Progress
+		}
+	}
+}
+
+// waveEnd credits the human defenders still connected at the end of a MvM wave with the result.
+func (match *Match) waveEnd(completed bool) {
+	match.setGameModeFromEvent(GameModeMvM)
+
+	for _, player := range match.PlayerSums {
+		if player.Team != RED || player.TimeEnd != nil || player.SteamID == steamid.New(BotSid) {
+			continue
+		}
+
+		if completed {
+			player.Objectives.WavesCompleted++
+		} else {
+			player.Objectives.WavesFailed++
+		}
+	}
+}
+
+func (match *Match) passGet(evt PassGetEvt) {
+	match.setGameModeFromEvent(GameModePasstime)
+	match.getPlayer(evt.CreatedOn, evt.SID).Objectives.BallGrabs++
+}
+
+func (match *Match) passCaught(evt PassCaughtEvt) {
+	match.setGameModeFromEvent(GameModePasstime)
+
+	catcher := match.getPlayer(evt.CreatedOn, evt.SID2)
+	if evt.Interception {
+		catcher.Objectives.BallInterceptions++
+
+		return
+	}
+
+	match.getPlayer(evt.CreatedOn, evt.SID).Objectives.BallPasses++
+	catcher.Objectives.BallCatches++
+}
+
+func (match *Match) passBallStolen(evt PassBallStolenEvt) {
+	match.setGameModeFromEvent(GameModePasstime)
+	match.getPlayer(evt.CreatedOn, evt.SID).Objectives.BallSteals++
+}
+
+func (match *Match) passScore(evt PassScoreEvt) {
+	match.setGameModeFromEvent(GameModePasstime)
+	match.getPlayer(evt.CreatedOn, evt.SID).Objectives.BallScores++
+}
+
+func (match *Match) passScoreAssist(evt PassScoreAssistEvt) {
+	match.setGameModeFromEvent(GameModePasstime)
+	match.getPlayer(evt.CreatedOn, evt.SID).Objectives.BallScoreAssists++
+}
+
+func (match *Match) passBallBlocked(evt PassBallBlockedEvt) {
+	match.setGameModeFromEvent(GameModePasstime)
+	match.getPlayer(evt.CreatedOn, evt.SID2).Objectives.BallBlocks++
 }
 
 // func (match *Match) midFight(team logparse.Team) {
@@ -845,6 +1194,14 @@ func (match *Match) killed(evt KilledEvt) {
 		} else if evt.Team == RED {
 			match.getRound().KillsRed++
 		}
+
+		if timeline := match.getTimeline(evt.CreatedOn); timeline != nil {
+			if evt.Team == BLU {
+				timeline.KillsBlu++
+			} else if evt.Team == RED {
+				timeline.KillsRed++
+			}
+		}
 	}
 }
 
@@ -929,6 +1286,14 @@ func (match *Match) medicCharge(evt ChargeDeployedEvt) {
 		} else if evt.Team == BLU {
 			round.UbersBlu += amount
 		}
+
+		if timeline := match.getTimeline(evt.CreatedOn); timeline != nil {
+			if evt.Team == RED {
+				timeline.UbersRed += amount
+			} else if evt.Team == BLU {
+				timeline.UbersBlu += amount
+			}
+		}
 	}
 }
 
@@ -1067,9 +1432,15 @@ type PlayerStats struct {
 	BuildingCarried   int                               `json:"building_carried"`   // Building pickup count
 	Classes           map[PlayerClass]*PlayerClassStats `json:"classes"`
 	KillStreaks       []PlayerKillstreak                `json:"kill_streaks"`
+	ClassKills        map[PlayerClass]int               `json:"class_kills"`        // Kills by victim class
+	ClassDeaths       map[PlayerClass]int               `json:"class_deaths"`       // Deaths by attacker class
+	ClassKillAssists  map[PlayerClass]int               `json:"class_kill_assists"` // Assists by victim class
+	Objectives        ObjectiveStats                    `json:"objectives"`
 	currentKillStreak int
 	currentLifeStart  time.Time
 	currentClass      PlayerClass
+	// pointHeld is the time the koth point was held after the player captured it
+	pointHeld time.Duration
 }
 
 func newMatchPlayerStats(match *Match, sid steamid.SID64) *PlayerStats {
@@ -1081,6 +1452,10 @@ func newMatchPlayerStats(match *Match, sid steamid.SID64) *PlayerStats {
 		WeaponInfo: map[Weapon]*WeaponStats{},
 		Pickups:    map[PickupItem]int{},
 		Classes:    map[PlayerClass]*PlayerClassStats{},
+
+		ClassKills:       map[PlayerClass]int{},
+		ClassDeaths:      map[PlayerClass]int{},
+		ClassKillAssists: map[PlayerClass]int{},
 	}
 }
 
@@ -1310,6 +1685,11 @@ func (player *PlayerStats) addKill(evtTime time.Time, target steamid.SID64, weap
 	ws := player.getWeaponSum(weapon)
 	if ws != nil {
 		ws.Kills++
+		ws.addKillDistance(sourcePos, targetPos)
+	}
+
+	if player.match.GameMode == GameModeMvM && target == steamid.New(BotSid) {
+		player.Objectives.RobotKills++
 	}
 
 	if targetPlayer, ok := player.match.PlayerSums[target]; ok {
@@ -1318,6 +1698,14 @@ func (player *PlayerStats) addKill(evtTime time.Time, target steamid.SID64, weap
 		if classStats := targetPlayer.getClassStats(); classStats != nil {
 			classStats.Deaths++
 		}
+
+		if targetPlayer.currentClass != Spectator {
+			player.ClassKills[targetPlayer.currentClass]++
+		}
+
+		if player.currentClass != Spectator {
+			targetPlayer.ClassDeaths[player.currentClass]++
+		}
 	}
 
 	if classStats := player.getClassStats(); classStats != nil {
@@ -1398,18 +1786,64 @@ type TeamScores struct {
 }
 
 type MatchRoundSum struct {
-	Length      time.Duration `json:"length"`
-	Score       TeamScores    `json:"score"`
-	KillsBlu    int           `json:"kills_blu"`
-	KillsRed    int           `json:"kills_red"`
-	UbersBlu    float64       `json:"ubers_blu"`
-	UbersRed    float64       `json:"ubers_red"`
-	DamageBlu   int           `json:"damage_blu"`
-	DamageRed   int           `json:"damage_red"`
-	RoundWinner Team          `json:"round_winner,"`
+	TimeStart time.Time     `json:"time_start"`
+	Length    time.Duration `json:"length"`
+	Score     TeamScores    `json:"score"`
+	KillsBlu  int           `json:"kills_blu"`
+	KillsRed  int           `json:"kills_red"`
+	UbersBlu  float64       `json:"ubers_blu"`
+	UbersRed  float64       `json:"ubers_red"`
+	DamageBlu int           `json:"damage_blu"`
+	DamageRed int           `json:"damage_red"`
+	CapsBlu   int           `json:"caps_blu"`
+	CapsRed   int           `json:"caps_red"`
+	// CartProgressBlu and CartProgressRed are the furthest each team pushed their payload cart, from 0 to 1
+	CartProgressBlu float64 `json:"cart_progress_blu"`
+	CartProgressRed float64 `json:"cart_progress_red"`
+	RoundWinner     Team    `json:"round_winner,"`
 	// MidFight    Team          `json:"mid_fight"`
 }
 
+// TimelineInterval is the length of each bucket in the match timeline.
+const TimelineInterval = time.Second * 30
+
+// ObjectiveStats tracks the game mode specific objectives of a player which are not covered by
+// the point captures and blocks. The cart, koth timer and wave events are logged by the gbans plugin.
+type ObjectiveStats struct {
+	BallGrabs         int `json:"ball_grabs"`
+	BallPasses        int `json:"ball_passes"`
+	BallCatches       int `json:"ball_catches"`
+	BallInterceptions int `json:"ball_interceptions"`
+	BallSteals        int `json:"ball_steals"`
+	BallBlocks        int `json:"ball_blocks"`
+	BallScores        int `json:"ball_scores"`
+	BallScoreAssists  int `json:"ball_score_assists"`
+	RobotKills        int `json:"robot_kills"`
+	CartPoints        int `json:"cart_points"`     // Points earned pushing the payload cart
+	KothCapTime       int `json:"koth_cap_time"`   // Seconds the koth point was held after the player captured it
+	WavesCompleted    int `json:"waves_completed"` // MvM waves completed while defending
+	WavesFailed       int `json:"waves_failed"`    // MvM waves failed while defending
+}
+
+// Total returns the sum of all objective stats, used to skip players without any.
+func (o ObjectiveStats) Total() int {
+	return o.BallGrabs + o.BallPasses + o.BallCatches + o.BallInterceptions + o.BallSteals + o.BallBlocks +
+		o.BallScores + o.BallScoreAssists + o.RobotKills + o.CartPoints + o.KothCapTime + o.WavesCompleted +
+		o.WavesFailed
+}
+
+// TimelineSum holds the team totals for a single TimelineInterval of the match, starting at Offset from
+// the start of the match.
+type TimelineSum struct {
+	Offset    time.Duration `json:"offset"`
+	KillsBlu  int           `json:"kills_blu"`
+	KillsRed  int           `json:"kills_red"`
+	UbersBlu  float64       `json:"ubers_blu"`
+	UbersRed  float64       `json:"ubers_red"`
+	DamageBlu int           `json:"damage_blu"`
+	DamageRed int           `json:"damage_red"`
+}
+
 type HealingStats struct {
 	MatchMedicID        int64 `json:"match_medic_id"`
 	player              *PlayerStats
@@ -5,6 +5,7 @@ import (
 	"path"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/leighmacdonald/gbans/pkg/logparse"
 	"github.com/leighmacdonald/golib"
@@ -56,6 +57,10 @@ func TestMatch(t *testing.T) {
 	require.Equal(t, 4796, playerVar.Damage())
 	require.Equal(t, 277, playerVar.DamagePerMin())
 	require.Equal(t, 260, playerVar.DamageTakenPerMin())
+	require.Equal(t, 4, playerVar.ClassKills[logparse.Heavy])
+	require.Equal(t, 3, playerVar.ClassKills[logparse.Spy])
+	require.Equal(t, 4, playerVar.ClassDeaths[logparse.Soldier])
+	require.Equal(t, 3, playerVar.ClassKillAssists[logparse.Engineer])
 
 	playerTuna := newMatch.PlayerBySteamID(steamid.New(76561198809011070))
 	require.Equal(t, 2, playerTuna.CaptureCount())
@@ -95,4 +100,188 @@ func TestMatch(t *testing.T) {
 	require.Equal(t, float64(2), round.UbersBlu)
 	require.Equal(t, 14605, round.DamageRed)
 	require.Equal(t, 13801, round.DamageBlu)
+	require.Equal(t, 2, round.CapsRed)
+	require.Equal(t, 2, round.CapsBlu)
+	require.Equal(t, time.Date(2022, 2, 5, 6, 27, 44, 0, time.UTC), round.TimeStart)
+
+	// Timeline buckets cover the same events as the rounds
+	var damageRed, damageBlu, killsRed, killsBlu int
+	for _, timeline := range newMatch.Timeline {
+		damageRed += timeline.DamageRed
+		damageBlu += timeline.DamageBlu
+		killsRed += timeline.KillsRed
+		killsBlu += timeline.KillsBlu
+	}
+
+	require.Len(t, newMatch.Timeline, 35)
+	require.Equal(t, []int{38977, 35956}, []int{damageRed, damageBlu})
+	require.Equal(t, []int{110, 80}, []int{killsRed, killsBlu})
+	require.Equal(t, logparse.TimelineInterval*34, newMatch.Timeline[34].Offset)
+}
+
+// applyTestLog applies all the lines of the test log to a new match, the same as TestMatch. Extra lines are
+// applied first, which is used to add the map load line that is missing from logs downloaded from logs.tf.
+func applyTestLog(t *testing.T, name string, extraLines ...string) logparse.Match {
+	t.Helper()
+
+	testFilePath := golib.FindFile(path.Join("testdata", name), "gbans")
+	if testFilePath == "" {
+		t.Skipf("Cant find test file: %s", name)
+	}
+
+	body, errRead := os.ReadFile(testFilePath)
+	require.NoError(t, errRead)
+
+	var (
+		parser = logparse.NewLogParser()
+		match  = logparse.NewMatch(1, "test server")
+	)
+
+	for _, line := range append(extraLines, strings.Split(string(body), "\n")...) {
+		if line == "" {
+			continue
+		}
+
+		result, errResult := parser.Parse(line)
+		require.NoError(t, errResult)
+
+		// Errors are expected for events that don't affect match state, same as the live summarizer
+		_ = match.Apply(result)
+	}
+
+	return match
+}
+
+func TestGameModeFromMap(t *testing.T) {
+	t.Parallel()
+
+	for mapName, expected := range map[string]logparse.GameMode{
+		"koth_product_final":                   logparse.GameModeKOTH,
+		"workshop/koth_cascade_rc2.ugc1234567": logparse.GameModeKOTH,
+		"pl_upward":                            logparse.GameModePayload,
+		"plr_hightower":                        logparse.GameModePayloadRace,
+		"cp_process_f12":                       logparse.GameModeControlPoint,
+		"mvm_coaltown":                         logparse.GameModeMvM,
+		"pass_brickyard":                       logparse.GameModePasstime,
+		"ctf_2fort":                            logparse.GameModeCTF,
+		"tr_walkway":                           logparse.GameModeUnknown,
+		"itemtest":                             logparse.GameModeUnknown,
+	} {
+		require.Equal(t, expected, logparse.GameModeFromMap(mapName), mapName)
+	}
+}
+
+func TestMatchKOTH(t *testing.T) {
+	match := applyTestLog(t, "log_sup_med_1.log", `L 05/21/2021 - 20:35:17: Loading map "koth_viaduct"`)
+
+	require.Equal(t, logparse.GameModeKOTH, match.GameMode)
+	require.Equal(t, "koth_viaduct", match.MapName)
+	require.Equal(t, logparse.RED, match.Winner())
+	// Time each team held the point, the point is locked for the first 30 seconds of each round
+	require.Equal(t, 375, match.TeamScores.RedTime)
+	require.Equal(t, 222, match.TeamScores.BluTime)
+	require.Less(t, match.TeamScores.RedTime+match.TeamScores.BluTime,
+		int((match.Rounds[0].Length + match.Rounds[1].Length).Seconds()))
+}
+
+func TestMatchPayload(t *testing.T) {
+	match := applyTestLog(t, "log_3474527.log", `L 08/13/2023 - 09:09:00: Loading map "pl_badwater"`)
+
+	require.Equal(t, logparse.GameModePayload, match.GameMode)
+	// Duplicate Round_Start and Round_Setup_End events must not create extra rounds
+	require.Equal(t, 2, match.RoundCount())
+
+	for _, round := range match.Rounds {
+		require.Equal(t, 4, round.CapsBlu)
+		require.Equal(t, 0, round.CapsRed)
+		require.Equal(t, logparse.BLU, round.RoundWinner)
+	}
+
+	// Stopwatch final scores are tied, the second attacking team was faster
+	require.Equal(t, 0, match.TeamScores.Red)
+	require.Equal(t, 0, match.TeamScores.Blu)
+	require.Equal(t, logparse.BLU, match.Winner())
+	require.Zero(t, match.TeamScores.RedTime+match.TeamScores.BluTime)
+}
+
+func TestMatchKOTHTimer(t *testing.T) {
+	match := applyTestLog(t, "log_koth.log", `L 05/21/2021 - 20:35:17: Loading map "koth_viaduct"`)
+
+	require.Equal(t, logparse.GameModeKOTH, match.GameMode)
+	require.Equal(t, logparse.RED, match.Winner())
+	// The round timers replace the times calculated from the captures
+	require.Equal(t, 180+176, match.TeamScores.RedTime)
+	require.Equal(t, 32+180, match.TeamScores.BluTime)
+
+	// Cappers are credited with the time their team held the point until it was lost or the round ended
+	require.Equal(t, 101+80, match.PlayerBySteamID(steamid.New("[U:1:307374149]")).Objectives.KothCapTime)
+	require.Equal(t, 101+57+97, match.PlayerBySteamID(steamid.New("[U:1:108085947]")).Objectives.KothCapTime)
+	require.Equal(t, 32, match.PlayerBySteamID(steamid.New("[U:1:86340473]")).Objectives.KothCapTime)
+	require.Equal(t, 78, match.PlayerBySteamID(steamid.New("[U:1:97609911]")).Objectives.KothCapTime)
+}
+
+func TestMatchPayloadCart(t *testing.T) {
+	match := applyTestLog(t, "log_payload.log", `L 08/13/2023 - 09:09:00: Loading map "pl_badwater"`)
+
+	require.Equal(t, logparse.GameModePayload, match.GameMode)
+	require.Equal(t, 2, match.RoundCount())
+	require.Equal(t, logparse.BLU, match.Winner())
+
+	for _, round := range match.Rounds {
+		require.Equal(t, 1.0, round.CartProgressBlu)
+		require.Zero(t, round.CartProgressRed)
+		require.Equal(t, 4, round.CapsBlu)
+	}
+
+	for steamID, points := range map[string]int{
+		"[U:1:1125524840]": 3, "[U:1:375639964]": 2, "[U:1:839530472]": 2, "[U:1:384567481]": 1,
+		"[U:1:892671957]": 1, "[U:1:105005873]": 2, "[U:1:161993312]": 1, "[U:1:194967879]": 2,
+	} {
+		require.Equal(t, points, match.PlayerBySteamID(steamid.New(steamID)).Objectives.CartPoints, steamID)
+	}
+
+	// Defenders never push the cart
+	require.Zero(t, match.PlayerBySteamID(steamid.New("[U:1:150197102]")).Objectives.CartPoints)
+}
+
+func TestMatchPasstime(t *testing.T) {
+	// No map line, the mode is detected from the ball events instead
+	match := applyTestLog(t, "log_passtime.log")
+
+	require.Equal(t, logparse.GameModePasstime, match.GameMode)
+	require.Equal(t, logparse.BLU, match.Winner())
+
+	moogle := match.PlayerBySteamID(steamid.New("[U:1:91618645]"))
+	require.Equal(t, logparse.ObjectiveStats{
+		BallGrabs: 1, BallPasses: 1, BallInterceptions: 1, BallScoreAssists: 1,
+	}, moogle.Objectives)
+
+	ashtray := match.PlayerBySteamID(steamid.New("[U:1:204626678]"))
+	require.Equal(t, logparse.ObjectiveStats{BallGrabs: 1, BallCatches: 1, BallBlocks: 1, BallScores: 1},
+		ashtray.Objectives)
+
+	kernel := match.PlayerBySteamID(steamid.New("[U:1:423376881]"))
+	require.Equal(t, logparse.ObjectiveStats{BallSteals: 1}, kernel.Objectives)
+
+	pulp := match.PlayerBySteamID(steamid.New("[U:1:152978378]"))
+	require.Equal(t, logparse.ObjectiveStats{BallGrabs: 1}, pulp.Objectives)
+}
+
+func TestMatchMvM(t *testing.T) {
+	match := applyTestLog(t, "log_mvm.log")
+
+	require.Equal(t, logparse.GameModeMvM, match.GameMode)
+	require.Equal(t, 2, match.RoundCount())
+	require.Equal(t, logparse.BLU, match.Rounds[0].RoundWinner)
+	// No final scores are sent, the defenders completing the last wave wins
+	require.Equal(t, logparse.RED, match.Winner())
+
+	require.Equal(t, logparse.ObjectiveStats{RobotKills: 2, WavesCompleted: 1, WavesFailed: 1},
+		match.PlayerBySteamID(steamid.New("[U:1:91618645]")).Objectives)
+	// Joined after the first wave failed
+	require.Equal(t, logparse.ObjectiveStats{RobotKills: 1, WavesCompleted: 1},
+		match.PlayerBySteamID(steamid.New("[U:1:204626678]")).Objectives)
+	// Left before the wave was completed
+	require.Equal(t, logparse.ObjectiveStats{WavesFailed: 1},
+		match.PlayerBySteamID(steamid.New("[U:1:152978378]")).Objectives)
 }
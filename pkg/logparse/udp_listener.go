@@ -197,6 +197,8 @@ func (remoteSrc *UDPLogListener) Start(ctx context.Context) {
 type ServerEvent struct {
 	ServerID   int
 	ServerName string
+	// Raw is the original log line the event was parsed from
+	Raw string
 	*Results
 }
 
@@ -204,6 +206,7 @@ func logToServerEvent(parser *LogParser, serverID int, serverName string, msg st
 	event := ServerEvent{
 		ServerID:   serverID,
 		ServerName: serverName,
+		Raw:        msg,
 	}
 	parseResult, errParse := parser.Parse(msg)
 
@@ -0,0 +1,171 @@
+// Package rating implements the Glicko-2 rating system as described in
+// http://www.glicko.net/glicko/glicko2.pdf, along with helpers for rating team games.
+package rating
+
+import (
+	"math"
+)
+
+const (
+	DefaultRating     = 1500.0
+	DefaultDeviation  = 350.0
+	DefaultVolatility = 0.06
+	// DefaultTau constrains the change in volatility over time, reasonable values are between 0.3 and 1.2.
+	DefaultTau = 0.5
+
+	// Conversion factor between the glicko and glicko-2 scales.
+	scale       = 173.7178
+	convergence = 0.000001
+)
+
+// Score values of a single game.
+const (
+	Loss = 0.0
+	Draw = 0.5
+	Win  = 1.0
+)
+
+// Rating is the skill estimate of a player. Rating is the estimated skill, Deviation how uncertain the
+// estimate is and Volatility how consistent the players performance is.
+type Rating struct {
+	Rating     float64 `json:"rating"`
+	Deviation  float64 `json:"deviation"`
+	Volatility float64 `json:"volatility"`
+}
+
+// New returns the rating of a player with no games played.
+func New() Rating {
+	return Rating{Rating: DefaultRating, Deviation: DefaultDeviation, Volatility: DefaultVolatility}
+}
+
+// Conservative returns the lower bound of the 95% confidence interval of the rating. Ranking on this
+// keeps players with only a few lucky games from topping leaderboards.
+func (r Rating) Conservative() float64 {
+	return r.Rating - 2*r.Deviation
+}
+
+func (r Rating) mu() float64 {
+	return (r.Rating - DefaultRating) / scale
+}
+
+func (r Rating) phi() float64 {
+	return r.Deviation / scale
+}
+
+// Result is the outcome of a single game against an opponent.
+type Result struct {
+	Opponent Rating
+	Score    float64
+}
+
+// Team returns a composite rating representing the team as a single opponent. The rating is the mean of the
+// members and the deviation the root mean square of their deviations.
+func Team(members []Rating) Rating {
+	if len(members) == 0 {
+		return New()
+	}
+
+	var rating, deviation, volatility float64
+
+	for _, member := range members {
+		rating += member.Rating
+		deviation += member.Deviation * member.Deviation
+		volatility += member.Volatility
+	}
+
+	count := float64(len(members))
+
+	return Rating{
+		Rating:     rating / count,
+		Deviation:  math.Sqrt(deviation / count),
+		Volatility: volatility / count,
+	}
+}
+
+func g(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+func expected(mu float64, opponentMu float64, opponentPhi float64) float64 {
+	return 1 / (1 + math.Exp(-g(opponentPhi)*(mu-opponentMu)))
+}
+
+// volatility computes the new volatility using the Illinois algorithm (step 5).
+func volatility(phi float64, sigma float64, variance float64, delta float64, tau float64) float64 {
+	var (
+		alpha = math.Log(sigma * sigma)
+		f     = func(x float64) float64 {
+			expX := math.Exp(x)
+			denominator := phi*phi + variance + expX
+
+			return expX*(delta*delta-phi*phi-variance-expX)/(2*denominator*denominator) - (x-alpha)/(tau*tau)
+		}
+		lower = alpha
+		upper float64
+	)
+
+	if delta*delta > phi*phi+variance {
+		upper = math.Log(delta*delta - phi*phi - variance)
+	} else {
+		k := 1.0
+		for f(alpha-k*tau) < 0 {
+			k++
+		}
+
+		upper = alpha - k*tau
+	}
+
+	fLower, fUpper := f(lower), f(upper)
+
+	for math.Abs(upper-lower) > convergence {
+		next := lower + (lower-upper)*fLower/(fUpper-fLower)
+		fNext := f(next)
+
+		if fNext*fUpper <= 0 {
+			lower, fLower = upper, fUpper
+		} else {
+			fLower /= 2
+		}
+
+		upper, fUpper = next, fNext
+	}
+
+	return math.Exp(lower / 2)
+}
+
+// Update returns the new rating of the player after a rating period containing the results. A player
+// without any results only has their deviation increased.
+func Update(player Rating, results []Result, tau float64) Rating {
+	mu, phi := player.mu(), player.phi()
+
+	if len(results) == 0 {
+		return Rating{
+			Rating:     player.Rating,
+			Deviation:  math.Min(math.Sqrt(phi*phi+player.Volatility*player.Volatility)*scale, DefaultDeviation),
+			Volatility: player.Volatility,
+		}
+	}
+
+	var varianceInv, improvement float64
+
+	for _, result := range results {
+		opponentMu, opponentPhi := result.Opponent.mu(), result.Opponent.phi()
+		gPhi := g(opponentPhi)
+		expectedScore := expected(mu, opponentMu, opponentPhi)
+
+		varianceInv += gPhi * gPhi * expectedScore * (1 - expectedScore)
+		improvement += gPhi * (result.Score - expectedScore)
+	}
+
+	variance := 1 / varianceInv
+	sigma := volatility(phi, player.Volatility, variance, variance*improvement, tau)
+	phiStar := math.Sqrt(phi*phi + sigma*sigma)
+	newPhi := 1 / math.Sqrt(1/(phiStar*phiStar)+1/variance)
+	newMu := mu + newPhi*newPhi*improvement
+
+	return Rating{
+		Rating:     newMu*scale + DefaultRating,
+		Deviation:  math.Min(newPhi*scale, DefaultDeviation),
+		Volatility: sigma,
+	}
+}
@@ -0,0 +1,54 @@
+package rating_test
+
+import (
+	"testing"
+
+	"github.com/leighmacdonald/gbans/pkg/rating"
+	"github.com/stretchr/testify/require"
+)
+
+// Example calculation from the glicko-2 paper.
+func TestUpdate(t *testing.T) {
+	player := rating.Rating{Rating: 1500, Deviation: 200, Volatility: 0.06}
+
+	updated := rating.Update(player, []rating.Result{
+		{Opponent: rating.Rating{Rating: 1400, Deviation: 30, Volatility: 0.06}, Score: rating.Win},
+		{Opponent: rating.Rating{Rating: 1550, Deviation: 100, Volatility: 0.06}, Score: rating.Loss},
+		{Opponent: rating.Rating{Rating: 1700, Deviation: 300, Volatility: 0.06}, Score: rating.Loss},
+	}, 0.5)
+
+	require.InDelta(t, 1464.06, updated.Rating, 0.01)
+	require.InDelta(t, 151.52, updated.Deviation, 0.01)
+	require.InDelta(t, 0.05999, updated.Volatility, 0.00001)
+}
+
+func TestUpdateInactive(t *testing.T) {
+	player := rating.Rating{Rating: 1500, Deviation: 200, Volatility: 0.06}
+
+	updated := rating.Update(player, nil, rating.DefaultTau)
+	require.Equal(t, player.Rating, updated.Rating)
+	require.Greater(t, updated.Deviation, player.Deviation)
+
+	require.Equal(t, rating.DefaultDeviation, rating.Update(rating.New(), nil, rating.DefaultTau).Deviation)
+}
+
+func TestTeam(t *testing.T) {
+	team := rating.Team([]rating.Rating{
+		{Rating: 1400, Deviation: 30, Volatility: 0.06},
+		{Rating: 1600, Deviation: 40, Volatility: 0.06},
+	})
+
+	require.InDelta(t, 1500, team.Rating, 0.001)
+	require.InDelta(t, 35.355, team.Deviation, 0.001)
+	require.Equal(t, rating.New(), rating.Team(nil))
+}
+
+func TestUpdateDraw(t *testing.T) {
+	stronger := rating.Rating{Rating: 1700, Deviation: 80, Volatility: 0.06}
+	weaker := rating.Rating{Rating: 1400, Deviation: 80, Volatility: 0.06}
+
+	require.Less(t, rating.Update(stronger, []rating.Result{{Opponent: weaker, Score: rating.Draw}}, rating.DefaultTau).Rating,
+		stronger.Rating)
+	require.Greater(t, rating.Update(weaker, []rating.Result{{Opponent: stronger, Score: rating.Draw}}, rating.DefaultTau).Rating,
+		weaker.Rating)
+}
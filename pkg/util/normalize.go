@@ -0,0 +1,178 @@
+package util
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeOpts controls which steps of the Normalize pipeline are applied. Each step targets a
+// common technique used to sneak text past word filters.
+type NormalizeOpts struct {
+	// Confusables folds compatibility forms (fullwidth, ligatures, styled math letters), strips
+	// diacritics and maps common Cyrillic/Greek homoglyphs onto their latin lookalikes.
+	Confusables bool `json:"confusables"`
+	// Invisible removes zero-width, format, control and blank filler characters.
+	Invisible bool `json:"invisible"`
+	// Leet replaces common leetspeak digits and symbols with the letters they stand in for.
+	Leet bool `json:"leet"`
+	// Repeats collapses runs of the same character down to a single character.
+	Repeats bool `json:"repeats"`
+	// Spaces removes all whitespace and separator punctuation, joining the text into a single token.
+	Spaces bool `json:"spaces"`
+}
+
+// Enabled returns true if any normalization step is enabled.
+func (opts NormalizeOpts) Enabled() bool {
+	return opts.Confusables || opts.Invisible || opts.Leet || opts.Repeats || opts.Spaces
+}
+
+// homoglyphs maps visually confusable characters onto their latin equivalent. This is not the full
+// unicode confusables table, just the characters that are commonly used for filter evasion and which
+// do not already fold to ascii under NFKD.
+var homoglyphs = map[rune]rune{ //nolint:gochecknoglobals
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p', 'с': 'c',
+	'т': 't', 'у': 'y', 'х': 'x', 'ѕ': 's', 'і': 'i', 'ї': 'i', 'ј': 'j', 'һ': 'h', 'ԁ': 'd',
+	'ԛ': 'q', 'ԝ': 'w', 'ү': 'y', 'ӏ': 'l', 'г': 'r', 'п': 'n', 'и': 'u', 'ь': 'b', 'ы': 'b', 'д': 'd',
+	'А': 'a', 'В': 'b', 'Е': 'e', 'Ё': 'e', 'К': 'k', 'М': 'm', 'Н': 'h', 'О': 'o', 'Р': 'p', 'С': 'c',
+	'Т': 't', 'У': 'y', 'Х': 'x', 'Ѕ': 's', 'І': 'i', 'Ї': 'i', 'Ј': 'j', 'Һ': 'h', 'Ԁ': 'd', 'Ԛ': 'q',
+	'Ԝ': 'w', 'Ү': 'y', 'Ӏ': 'l', 'Г': 'r', 'П': 'n', 'И': 'u', 'Ь': 'b',
+	// Greek
+	'α': 'a', 'β': 'b', 'γ': 'y', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p',
+	'τ': 't', 'υ': 'u', 'χ': 'x', 'ω': 'w', 'ς': 'c', 'σ': 'o',
+	'Α': 'a', 'Β': 'b', 'Ε': 'e', 'Ζ': 'z', 'Η': 'h', 'Ι': 'i', 'Κ': 'k', 'Μ': 'm', 'Ν': 'n', 'Ο': 'o',
+	'Ρ': 'p', 'Τ': 't', 'Υ': 'y', 'Χ': 'x',
+	// Latin extensions & IPA
+	'ı': 'i', 'ȷ': 'j', 'ł': 'l', 'ø': 'o', 'đ': 'd', 'ħ': 'h', 'ŧ': 't', 'ƅ': 'b', 'ɑ': 'a', 'ɡ': 'g', 'ɩ': 'i',
+	'ɪ': 'i', 'ʀ': 'r', 'ɴ': 'n', 'ʏ': 'y', 'ᴀ': 'a', 'ʙ': 'b', 'ᴄ': 'c', 'ᴅ': 'd', 'ᴇ': 'e', 'ɢ': 'g',
+	'ʜ': 'h', 'ᴊ': 'j', 'ᴋ': 'k', 'ʟ': 'l', 'ᴍ': 'm', 'ᴏ': 'o', 'ᴘ': 'p', 'ꜱ': 's', 'ᴛ': 't', 'ᴜ': 'u',
+	'ᴠ': 'v', 'ᴡ': 'w', 'ᴢ': 'z', 'ß': 'b',
+}
+
+// leetMap maps leetspeak substitutions back to the letter they usually represent.
+var leetMap = map[rune]rune{ //nolint:gochecknoglobals
+	'0': 'o', '1': 'i', '2': 'z', '3': 'e', '4': 'a', '5': 's', '6': 'g', '7': 't', '8': 'b', '9': 'g',
+	'@': 'a', '$': 's', '!': 'i', '|': 'l', '+': 't', '€': 'e', '£': 'l', '¡': 'i',
+}
+
+// blankRunes are characters that render as empty space but are not classified as such by unicode.
+var blankRunes = map[rune]bool{ //nolint:gochecknoglobals
+	'ᅟ': true, // Hangul choseong filler
+	'ᅠ': true, // Hangul jungseong filler
+	'⠀': true, // Braille pattern blank
+	'ㅤ': true, // Hangul filler
+	'ﾠ': true, // Halfwidth hangul filler
+}
+
+func isInvisible(r rune) bool {
+	return unicode.In(r, unicode.Cf, unicode.Cc, unicode.Variation_Selector) || blankRunes[r]
+}
+
+func isSeparator(r rune) bool {
+	if unicode.IsSpace(r) {
+		return true
+	}
+
+	switch r {
+	case '.', ',', '-', '_', '*', '~', '\'', '"', '`', '^', '/', '\\', ':', ';':
+		return true
+	}
+
+	return false
+}
+
+// Normalize lowercases the input and applies the enabled normalization steps in a fixed order:
+// invisible character removal, confusable folding, leet replacement, space removal and finally
+// repeat collapsing.
+func Normalize(input string, opts NormalizeOpts) string {
+	value := input
+
+	if opts.Invisible {
+		value = strings.Map(func(r rune) rune {
+			if isInvisible(r) && !unicode.IsSpace(r) {
+				return -1
+			}
+
+			return r
+		}, value)
+	}
+
+	if opts.Confusables {
+		value = strings.Map(func(r rune) rune {
+			if unicode.Is(unicode.Mn, r) {
+				return -1
+			}
+
+			if mapped, found := homoglyphs[r]; found {
+				return mapped
+			}
+
+			return r
+		}, norm.NFKD.String(value))
+	}
+
+	value = strings.ToLower(value)
+
+	if opts.Leet {
+		value = strings.Map(func(r rune) rune {
+			if mapped, found := leetMap[r]; found {
+				return mapped
+			}
+
+			return r
+		}, value)
+	}
+
+	if opts.Spaces {
+		value = strings.Map(func(r rune) rune {
+			if isSeparator(r) {
+				return -1
+			}
+
+			return r
+		}, value)
+	}
+
+	if opts.Repeats {
+		value = CollapseRepeats(value)
+	}
+
+	return value
+}
+
+// CollapseRepeats collapses runs of the same character down to a single character.
+func CollapseRepeats(value string) string {
+	var (
+		builder strings.Builder
+		prev    rune = -1
+	)
+
+	builder.Grow(len(value))
+
+	for _, r := range value {
+		if r == prev {
+			continue
+		}
+
+		builder.WriteRune(r)
+
+		prev = r
+	}
+
+	return builder.String()
+}
+
+// NormalizeWords normalizes the input the same as Normalize and splits it into words. When opts.Spaces is
+// enabled the separators are used as word boundaries instead of being removed, so that callers can still
+// reassemble words which have been split apart while respecting word boundaries.
+func NormalizeWords(input string, opts NormalizeOpts) []string {
+	if !opts.Spaces {
+		return strings.Fields(Normalize(input, opts))
+	}
+
+	opts.Spaces = false
+
+	return strings.FieldsFunc(Normalize(input, opts), isSeparator)
+}
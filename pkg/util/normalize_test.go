@@ -0,0 +1,45 @@
+package util_test
+
+import (
+	"testing"
+
+	"github.com/leighmacdonald/gbans/pkg/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	all := util.NormalizeOpts{Confusables: true, Invisible: true, Leet: true, Repeats: true, Spaces: true}
+
+	tests := []struct {
+		name     string
+		input    string
+		opts     util.NormalizeOpts
+		expected string
+	}{
+		{"none", "Noob", util.NormalizeOpts{}, "noob"},
+		{"leet", "n00b", util.NormalizeOpts{Leet: true}, "noob"},
+		{"leet_symbols", "$c@mm3r", util.NormalizeOpts{Leet: true}, "scammer"},
+		{"zero_width_space", "no​ob", util.NormalizeOpts{Invisible: true}, "noob"},
+		{"zero_width_joiner", "n‍o‍o‍b", util.NormalizeOpts{Invisible: true}, "noob"},
+		{"soft_hyphen", "no­ob", util.NormalizeOpts{Invisible: true}, "noob"},
+		{"hangul_filler", "noㅤob", util.NormalizeOpts{Invisible: true}, "noob"},
+		{"invisible_keeps_spaces", "a​ b", util.NormalizeOpts{Invisible: true}, "a b"},
+		{"cyrillic", "nооb", util.NormalizeOpts{Confusables: true}, "noob"},
+		{"cyrillic_upper", "СНЕАТЕR", util.NormalizeOpts{Confusables: true}, "cheater"},
+		{"greek", "nοοb", util.NormalizeOpts{Confusables: true}, "noob"},
+		{"fullwidth", "ｎｏｏｂ", util.NormalizeOpts{Confusables: true}, "noob"},
+		{"math_bold", "𝐧𝐨𝐨𝐛", util.NormalizeOpts{Confusables: true}, "noob"},
+		{"diacritics", "ñöób", util.NormalizeOpts{Confusables: true}, "noob"},
+		{"small_caps", "ɴᴏᴏʙ", util.NormalizeOpts{Confusables: true}, "noob"},
+		{"repeats", "nooooooobbbb", util.NormalizeOpts{Repeats: true}, "nob"},
+		{"spaces", "n o o b", util.NormalizeOpts{Spaces: true}, "noob"},
+		{"separators", "n.o-o_b", util.NormalizeOpts{Spaces: true}, "noob"},
+		{"combined", "Ｎ 0​0 ß", all, "nob"},
+		{"combined_spaced_repeats", "n  n  0 0 0 b b", all, "nob"},
+		{"combined_cyrillic_leet", "с​h3 а7 3 r", all, "cheater"},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.expected, util.Normalize(test.input, test.opts), test.name)
+	}
+}